@@ -2,11 +2,12 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var addLong = `add dependencies and new components to the current module or workspace`
@@ -40,8 +41,7 @@ var AddCmd = &cobra.Command{
 
 		err = AddRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
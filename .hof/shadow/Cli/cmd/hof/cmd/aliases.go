@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/config"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var (
+	// the commands of the aliases, so they can be added again
+	aliasCmds []*cobra.Command
+
+	// how many aliases are expanding, to stop aliases which expand to themselves
+	aliasDepth int
+)
+
+const maxAliasDepth = 8
+
+// AddAliasCommands adds a command for each alias in hof's config, see config.Alias,
+// replacing those added before. Aliases which would hide a hof command are skipped.
+func AddAliasCommands() {
+	for _, C := range aliasCmds {
+		RootCmd.RemoveCommand(C)
+	}
+	aliasCmds = nil
+
+	aliases, err := config.LoadAliases()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	for _, A := range aliases {
+		if C, _, err := RootCmd.Find([]string{A.Name}); err == nil && C != RootCmd {
+			fmt.Fprintf(os.Stderr, "the alias %s is a hof command, rename it to use it\n", A.Name)
+			continue
+		}
+		C := aliasCommand(A)
+		RootCmd.AddCommand(C)
+		aliasCmds = append(aliasCmds, C)
+	}
+}
+
+func aliasCommand(A config.Alias) *cobra.Command {
+	short := A.Help
+	if short == "" {
+		short = "hof " + A.Cmd
+	}
+
+	return &cobra.Command{
+
+		Use: A.Name + " [args...]",
+
+		Short: short,
+
+		Long: fmt.Sprintf("%s\n\nAn alias from hof's config for\n\n  hof %s", short, A.Cmd),
+
+		// flags are for the command the alias runs
+		DisableFlagParsing: true,
+
+		// the command the alias runs has them
+		PersistentPreRun:  func(cmd *cobra.Command, args []string) {},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runAlias(A, args)
+			if err != nil {
+				errs.Exit(cmd.CommandPath(), err)
+			}
+		},
+	}
+}
+
+func runAlias(A config.Alias, args []string) error {
+	if aliasDepth >= maxAliasDepth {
+		return fmt.Errorf("the alias %s expands too deeply, does it run itself?", A.Name)
+	}
+	aliasDepth++
+	defer func() { aliasDepth-- }()
+
+	expanded, err := A.Expand(args)
+	if err != nil {
+		return err
+	}
+	RootCmd.SetArgs(expanded)
+	return RootCmd.Execute()
+}
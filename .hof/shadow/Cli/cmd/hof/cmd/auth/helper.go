@@ -0,0 +1,60 @@
+package cmdauth
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/auth/helper"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+)
+
+var helperLong = `speak the git credential helper protocol
+
+The credential helper set in the hof auth config is asked for tokens
+hof has no other way to find, and hof never saves what it returns.
+
+  helper: "osxkeychain"                          // runs git-credential-osxkeychain
+  hosts: "git.corp.example.com": helper: "!corp-sso token"
+
+These commands let other tools use hof, and its helper, the same way,
+reading key=value lines from stdin, as in
+
+  printf 'protocol=https\nhost=github.com\n\n' | hof auth helper get
+  git config --global credential.helper '!hof auth helper'`
+
+var HelperCmd = &cobra.Command{
+
+	Use: "helper",
+
+	Short: "speak the git credential helper protocol",
+
+	Long: helperLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+}
+
+func init() {
+
+	help := HelperCmd.HelpFunc()
+	usage := HelperCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	HelperCmd.SetHelpFunc(thelp)
+	HelperCmd.SetUsageFunc(tusage)
+
+	HelperCmd.AddCommand(cmdhelper.GetCmd)
+	HelperCmd.AddCommand(cmdhelper.StoreCmd)
+	HelperCmd.AddCommand(cmdhelper.EraseCmd)
+
+}
@@ -0,0 +1,66 @@
+package cmdhelper
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var eraseLong = `Read a credential from stdin and pass it to the credential helper
+configured for its host, to erase it, as after it was rejected.`
+
+func EraseRun(args []string) (err error) {
+
+	// you can safely comment this print out
+	fmt.Println("not implemented")
+
+	return err
+}
+
+var EraseCmd = &cobra.Command{
+
+	Use: "erase",
+
+	Short: "erase a credential with the configured helper",
+
+	Long: eraseLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = EraseRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := EraseCmd.HelpFunc()
+	usage := EraseCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	EraseCmd.SetHelpFunc(thelp)
+	EraseCmd.SetUsageFunc(tusage)
+
+}
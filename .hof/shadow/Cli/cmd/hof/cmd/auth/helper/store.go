@@ -0,0 +1,66 @@
+package cmdhelper
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var storeLong = `Read a credential from stdin and pass it to the credential helper
+configured for its host. hof does not keep it.`
+
+func StoreRun(args []string) (err error) {
+
+	// you can safely comment this print out
+	fmt.Println("not implemented")
+
+	return err
+}
+
+var StoreCmd = &cobra.Command{
+
+	Use: "store",
+
+	Short: "store a credential with the configured helper",
+
+	Long: storeLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = StoreRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := StoreCmd.HelpFunc()
+	usage := StoreCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	StoreCmd.SetHelpFunc(thelp)
+	StoreCmd.SetUsageFunc(tusage)
+
+}
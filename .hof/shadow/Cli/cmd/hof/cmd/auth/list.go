@@ -2,11 +2,12 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var listLong = `list known auth configurations and sessions`
@@ -40,8 +41,7 @@ var ListCmd = &cobra.Command{
 
 		err = ListRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,14 +2,19 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var loginLong = `login to an account, provider, system, or url`
+var loginLong = `login to an account, provider, system, or url
+
+For git hosts, where is [username@]host and the token is read from stdin,
+so it can be piped in on CI machines. Tokens are saved in the hof auth config,
+and used after $GITHUB_TOKEN, $GITLAB_TOKEN, $GITEA_TOKEN, $BITBUCKET_APP_PASSWORD, and ~/.netrc`
 
 func LoginRun(where string) (err error) {
 
@@ -48,8 +53,7 @@ var LoginCmd = &cobra.Command{
 
 		err = LoginRun(where)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,11 +2,12 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var logoutLong = `logout of an authenticated session`
@@ -40,8 +41,7 @@ var LogoutCmd = &cobra.Command{
 
 		err = LogoutRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
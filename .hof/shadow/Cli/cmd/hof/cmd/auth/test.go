@@ -2,11 +2,12 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var testLong = `test your auth configuration, defaults to current context`
@@ -40,8 +41,7 @@ var TestCmd = &cobra.Command{
 
 		err = TestRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,11 +2,12 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var bisectLong = `use binary search to find the commit that introduced a bug`
@@ -40,8 +41,7 @@ var BisectCmd = &cobra.Command{
 
 		err = BisectRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/hofstadter-io/hof/cmd/hof/verinfo"
+)
+
+// CompleteTreeCmd prints the command tree as JSON, for tools building on hof,
+// such as editor extensions and docs generators, which should not parse help text.
+// Its name starts like 'hof __complete', so the update notice is not printed either.
+var CompleteTreeCmd = &cobra.Command{
+	Use:    "__complete-tree",
+	Short:  "print the command tree, flags, and args as JSON",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		T := CommandTree{
+			Version: verinfo.Version,
+			Command: commandTree(RootCmd),
+		}
+		// usage lines have <args>, which should not be escaped
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(T)
+	},
+}
+
+// CommandTree is what 'hof __complete-tree' prints
+type CommandTree struct {
+	Version string `json:"version"`
+	Command
+}
+
+// Command is a command, its flags and args, and its subcommands
+type Command struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Usage   string   `json:"usage"`
+	Aliases []string `json:"aliases,omitempty"`
+	Short   string   `json:"short,omitempty"`
+	Long    string   `json:"long,omitempty"`
+	Hidden  bool     `json:"hidden,omitempty"`
+
+	// Runnable is false for commands which only group others
+	Runnable bool `json:"runnable"`
+
+	Args     []Arg     `json:"args,omitempty"`
+	Flags    []Flag    `json:"flags,omitempty"`
+	Commands []Command `json:"commands,omitempty"`
+}
+
+// Arg is a positional arg, read from the usage line, where
+// <name> is required, [name] is optional, and ... repeats
+type Arg struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Variadic bool   `json:"variadic,omitempty"`
+
+	// Values are the fixed values, and Completes is set when hof completes it from local state
+	Values    []string `json:"values,omitempty"`
+	Completes bool     `json:"completes,omitempty"`
+}
+
+// Flag is a flag of a command, Persistent ones are inherited by its subcommands
+type Flag struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Default    string `json:"default,omitempty"`
+	Usage      string `json:"usage"`
+	Persistent bool   `json:"persistent,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty"`
+}
+
+func commandTree(C *cobra.Command) Command {
+	T := Command{
+		Name:     C.Name(),
+		Path:     C.CommandPath(),
+		Usage:    C.Use,
+		Aliases:  C.Aliases,
+		Short:    C.Short,
+		Long:     C.Long,
+		Hidden:   C.Hidden,
+		Runnable: C.Runnable(),
+		Args:     commandArgs(C),
+	}
+
+	// the flags defined on this command, inherited ones are listed where they are defined
+	C.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" {
+			return
+		}
+		T.Flags = append(T.Flags, Flag{
+			Name:       f.Name,
+			Shorthand:  f.Shorthand,
+			Type:       f.Value.Type(),
+			Default:    f.DefValue,
+			Usage:      f.Usage,
+			Persistent: C.PersistentFlags().Lookup(f.Name) != nil,
+			Hidden:     f.Hidden,
+		})
+	})
+
+	subs := C.Commands()
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Name() < subs[j].Name() })
+	for _, S := range subs {
+		if S.Name() == "help" {
+			continue
+		}
+		T.Commands = append(T.Commands, commandTree(S))
+	}
+
+	return T
+}
+
+// commandArgs reads the args from the usage line, as in
+//
+//   login <where>
+//   jump [name] [args...]
+//   diff <orig> <next> [...entrypoints]
+func commandArgs(C *cobra.Command) []Arg {
+	var args []Arg
+	for _, field := range strings.Fields(C.Use)[1:] {
+		A := Arg{}
+		switch field[0] {
+		case '.':
+			A.Name, A.Variadic = "args", true
+		case '<', '[':
+			A.Required = field[0] == '<'
+			A.Variadic = strings.HasSuffix(field, "...")
+			field = strings.TrimSuffix(field, "...")
+
+			closing := ">"
+			if field[0] == '[' {
+				closing = "]"
+			}
+			name := strings.TrimSuffix(field[1:], closing)
+			A.Variadic = A.Variadic || strings.HasPrefix(name, "...") || strings.HasSuffix(name, "...")
+			A.Name = strings.Trim(name, ".")
+		default:
+			continue
+		}
+		if A.Name == "flags" {
+			continue
+		}
+		args = append(args, A)
+	}
+
+	completes := C.ValidArgsFunction != nil
+	if len(args) == 0 && (len(C.ValidArgs) > 0 || completes) {
+		args = append(args, Arg{Name: "args", Variadic: true})
+	}
+	if len(args) > 0 {
+		args[0].Values = C.ValidArgs
+		args[0].Completes = completes
+	}
+	return args
+}
+
+func init() {
+	RootCmd.AddCommand(CompleteTreeCmd)
+}
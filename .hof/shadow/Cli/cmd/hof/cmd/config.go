@@ -6,9 +6,39 @@ import (
 	"github.com/hofstadter-io/hof/cmd/hof/cmd/config"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var configLong = `manage local configurations`
+var configLong = `manage hof's config, layered from
+
+  global     ~/.hof/config.cue
+  workspace  .hofcfg.cue in the workspace root, the nearest directory up with a cue.mods file
+  local      .hofcfg.cue in the current directory
+  custom     the file given with --config
+
+where later layers override the values of earlier ones.
+Structs are merged field by field, while lists and other values are replaced.
+Use --layer, or --global and --local, to read or write a single layer.
+
+The aliases section adds commands which run hof command lines, so teams can share shortcuts, as in
+
+  aliases: {
+    "gen-api": "gen ./design/api.cue -t api -o $1"
+    "mod-up": {
+      cmd:  "mod outdated --update $@"
+      help: "update the direct requires"
+    }
+  }
+
+where $1, $2, and so on are the args of the alias, and $@ all of them.
+The args are appended when the command line has no placeholders.
+Aliases come from the global, workspace, and local layers, not --config.`
+
+func init() {
+
+	ConfigCmd.PersistentFlags().StringVarP(&(flags.ConfigLayerPflag), "layer", "", "", "config layer to read or write, one of global, workspace, local, or custom")
+}
 
 var ConfigCmd = &cobra.Command{
 
@@ -43,6 +73,7 @@ func init() {
 
 	ConfigCmd.AddCommand(cmdconfig.GetCmd)
 	ConfigCmd.AddCommand(cmdconfig.SetCmd)
+	ConfigCmd.AddCommand(cmdconfig.ViewCmd)
 	ConfigCmd.AddCommand(cmdconfig.UseCmd)
 
 }
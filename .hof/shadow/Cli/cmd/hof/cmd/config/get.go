@@ -2,14 +2,18 @@ package cmdconfig
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var getLong = `print a config or value(s) at path(s)`
+var getLong = `print a config or value(s) at path(s), as in a.b.c
+
+Values come from all of the config layers merged, or a single layer
+chosen with --layer, --global, --local, or --config, see 'hof config --help'.`
 
 func GetRun(args []string) (err error) {
 
@@ -40,8 +44,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -7,9 +7,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var setLong = `set config values with an expr`
+var setLong = `set config values with a Cue expr, merged into a config layer
+
+The layer is the .hofcfg.cue in the current directory, unless chosen
+with --layer, --global, --local, or --config, see 'hof config --help'.
+
+  hof config set 'mod: proxy: "https://proxy.example.com"'
+  hof config set --layer workspace 'gen: outdir: "out"'`
 
 func SetRun(expr string) (err error) {
 
@@ -54,8 +62,7 @@ var SetCmd = &cobra.Command{
 
 		err = SetRun(expr)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,77 @@
+package cmdconfig
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/config"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var viewLong = `print the config layers, or the resolved config and where its values come from
+
+Each layer is printed with its file, see 'hof config --help'.
+With --resolved, every value of the merged config is printed
+along with the layer which set it, as in
+
+  gen.outdir: "out"  // workspace /path/to/workspace/.hofcfg.cue`
+
+func init() {
+
+	ViewCmd.Flags().BoolVarP(&(flags.ViewFlags.Resolved), "resolved", "", false, "print the merged config and where each value comes from")
+}
+
+func ViewRun() (err error) {
+
+	err = config.GetRuntime().ConfigView(flags.ViewFlags.Resolved)
+
+	return err
+}
+
+var ViewCmd = &cobra.Command{
+
+	Use: "view",
+
+	Short: "print the config layers, or the resolved config and where its values come from",
+
+	Long: viewLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = ViewRun()
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ViewCmd.HelpFunc()
+	usage := ViewCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ViewCmd.SetHelpFunc(thelp)
+	ViewCmd.SetUsageFunc(tusage)
+
+}
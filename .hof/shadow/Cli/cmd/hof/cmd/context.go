@@ -8,7 +8,12 @@ import (
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 )
 
-var contextLong = `get, set, and use contexts`
+var contextLong = `get, set, and use contexts
+
+A context holds references to the account, project, and credentials hof works with,
+so you can switch between setups, such as work and personal, with 'hof context use'.
+Contexts are kept in ~/.hof/contexts.cue, or the file given with --context-file,
+and --context picks one for a single command.`
 
 var ContextCmd = &cobra.Command{
 
@@ -44,6 +49,9 @@ func init() {
 	ContextCmd.AddCommand(cmdcontext.GetCmd)
 	ContextCmd.AddCommand(cmdcontext.SetCmd)
 	ContextCmd.AddCommand(cmdcontext.UseCmd)
+	ContextCmd.AddCommand(cmdcontext.ListCmd)
+	ContextCmd.AddCommand(cmdcontext.CreateCmd)
+	ContextCmd.AddCommand(cmdcontext.DeleteCmd)
 	ContextCmd.AddCommand(cmdcontext.SourceCmd)
 	ContextCmd.AddCommand(cmdcontext.ClearCmd)
 
@@ -2,14 +2,15 @@ package cmdcontext
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var getLong = `print a context or value(s) at path(s)`
+var getLong = `print the context in use or value(s) at path(s) in it, as in Account or Project`
 
 func GetRun(args []string) (err error) {
 
@@ -40,8 +41,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
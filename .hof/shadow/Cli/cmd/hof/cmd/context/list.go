@@ -0,0 +1,68 @@
+package cmdcontext
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var listLong = `list the contexts, marking the one in use with a *
+
+Contexts are kept in ~/.hof/contexts.cue, or the file given with --context-file.
+Use --output-format (-O) to choose table (default), json, or yaml.`
+
+func ListRun(args []string) (err error) {
+
+	// you can safely comment this print out
+	fmt.Println("not implemented")
+
+	return err
+}
+
+var ListCmd = &cobra.Command{
+
+	Use: "list",
+
+	Short: "list the contexts",
+
+	Long: listLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = ListRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ListCmd.HelpFunc()
+	usage := ListCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ListCmd.SetHelpFunc(thelp)
+	ListCmd.SetUsageFunc(tusage)
+
+}
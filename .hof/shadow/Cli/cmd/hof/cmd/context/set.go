@@ -7,9 +7,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var setLong = `set context values with an expr`
+var setLong = `set values of the context in use with an expr, as in 'Project: "api"'`
 
 func SetRun(expr string) (err error) {
 
@@ -54,8 +56,7 @@ var SetCmd = &cobra.Command{
 
 		err = SetRun(expr)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
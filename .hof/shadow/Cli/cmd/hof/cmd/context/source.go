@@ -2,11 +2,12 @@ package cmdcontext
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var sourceLong = `source a context into your environment`
@@ -40,8 +41,7 @@ var SourceCmd = &cobra.Command{
 
 		err = SourceRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
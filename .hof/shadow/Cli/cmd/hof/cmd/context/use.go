@@ -7,11 +7,15 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var useLong = `set a context as the current default`
+var useLong = `set a context as the current default
+
+The context in use can be changed for a single command with --context.`
 
-func UseRun(args []string) (err error) {
+func UseRun(name string) (err error) {
 
 	// you can safely comment this print out
 	fmt.Println("not implemented")
@@ -21,7 +25,7 @@ func UseRun(args []string) (err error) {
 
 var UseCmd = &cobra.Command{
 
-	Use: "use [name]",
+	Use: "use <name>",
 
 	Short: "set a context as the current default",
 
@@ -38,11 +42,24 @@ var UseCmd = &cobra.Command{
 
 		// Argument Parsing
 
-		err = UseRun(args)
-		if err != nil {
-			fmt.Println(err)
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
 			os.Exit(1)
 		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = UseRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
 	},
 }
 
@@ -2,14 +2,22 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var createLong = `create resources`
+var createLong = `create resources
+
+Resources may be given as documents, in cue, json, or yaml, with - for stdin,
+@file, or @dir for the files of a directory, or with --input, as in
+
+  hof create @app.cue
+  hof create -i k8s/
+  cue export ./k8s | hof create -`
 
 func CreateRun(args []string) (err error) {
 
@@ -44,8 +52,7 @@ var CreateCmd = &cobra.Command{
 
 		err = CreateRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,11 +2,12 @@ package cmddatamodel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var applyLong = `apply a migraion sequence against a data store`
@@ -44,8 +45,7 @@ var ApplyCmd = &cobra.Command{
 
 		err = ApplyRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,91 @@
+package cmddatamodel
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/datamodel/checkpoint"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var checkpointLong = `Save data models, all of them or those named, as a new checkpoint in their history.
+Checkpoints are kept in .hof/datamodels/<name>, named by the sha256 of their Cue,
+and nothing is saved when a data model is unchanged since its last checkpoint.
+
+Checkpoints are referred to by their id, or enough of one to be unique,
+or as ~N, N checkpoints before the last. Diff and migrate work between any two.
+
+  hof datamodel checkpoint -m "add users"
+  hof datamodel checkpoint history
+  hof datamodel diff --from ~1 --to ~0`
+
+func init() {
+
+	CheckpointCmd.Flags().StringVarP(&(flags.CheckpointFlags.Message), "message", "m", "", "a message describing the checkpoint")
+}
+
+func CheckpointRun(args []string) (err error) {
+
+	// you can safely comment this print out
+	fmt.Println("not implemented")
+
+	return err
+}
+
+var CheckpointCmd = &cobra.Command{
+
+	Use: "checkpoint [names...]",
+
+	Aliases: []string{
+		"cp",
+	},
+
+	Short: "save data models as a checkpoint in their history",
+
+	Long: checkpointLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = CheckpointRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := CheckpointCmd.HelpFunc()
+	usage := CheckpointCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	CheckpointCmd.SetHelpFunc(thelp)
+	CheckpointCmd.SetUsageFunc(tusage)
+
+	CheckpointCmd.AddCommand(cmdcheckpoint.HistoryCmd)
+	CheckpointCmd.AddCommand(cmdcheckpoint.ShowCmd)
+	CheckpointCmd.AddCommand(cmdcheckpoint.RollbackCmd)
+
+}
@@ -2,14 +2,16 @@ package cmddatamodel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var historyLong = `show the history for a data model`
+var historyLong = `Show the checkpoints of data models, all of them or those named, newest first.
+The same as 'hof datamodel checkpoint history'.`
 
 func HistoryRun(args []string) (err error) {
 
@@ -21,7 +23,7 @@ func HistoryRun(args []string) (err error) {
 
 var HistoryCmd = &cobra.Command{
 
-	Use: "history",
+	Use: "history [names...]",
 
 	Aliases: []string{
 		"hist",
@@ -47,8 +49,7 @@ var HistoryCmd = &cobra.Command{
 
 		err = HistoryRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
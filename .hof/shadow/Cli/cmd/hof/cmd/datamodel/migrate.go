@@ -2,14 +2,52 @@ package cmddatamodel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var migrateLong = `calculate a changeset for a data model`
+var migrateLong = `Calculate the steps migrating data models, all of them or those named,
+from their last checkpoint, or --from, to the workspace, or --to.
+Either may be a checkpoint, see 'hof datamodel checkpoint history', or a Cue file of the data model.
+Data models without checkpoints are migrated from nothing.
+
+Steps are ordered as
+  rename   a field is renamed, so later steps use the new names
+  add      a field is new
+  alter    the type or constraints of a field changed
+  drop     a field is gone
+
+With --sql postgres or mysql, up and down SQL migrations are written instead, as
+
+  <dir>/<datamodel>/<version>_<name>.up.sql
+  <dir>/<datamodel>/<version>_<name>.down.sql
+
+which runners such as golang-migrate read. Each of Models is a table, and each of its
+fields, not starting with an upper case letter, a column. Optional fields are nullable,
+and Cue defaults are column defaults. Columns are set further with @sql(), as in
+
+  id:     int    @sql(primary)
+  email:  string @sql(unique)
+  name:   string @sql(index,type="VARCHAR(64)")
+  userId: int    @sql(references=User.id)
+
+Required columns added without a default are filled with the zero value of their kind.`
+
+func init() {
+
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.Format), "format", "", "text", "output format, one of text or json")
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.From), "from", "", "", "checkpoint or Cue file to migrate from, defaults to the last checkpoint")
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.To), "to", "", "", "checkpoint or Cue file to migrate to, defaults to the workspace")
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.Sql), "sql", "", "", "write SQL migrations for a database, one of postgres or mysql")
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.Dir), "dir", "", "migrations", "directory SQL migrations are written to")
+	MigrateCmd.Flags().StringVarP(&(flags.MigrateFlags.Name), "name", "", "", "name of the SQL migrations, defaults to where they go")
+}
 
 func MigrateRun(args []string) (err error) {
 
@@ -21,7 +59,7 @@ func MigrateRun(args []string) (err error) {
 
 var MigrateCmd = &cobra.Command{
 
-	Use: "migrate",
+	Use: "migrate [names...]",
 
 	Aliases: []string{
 		"mig",
@@ -46,8 +84,7 @@ var MigrateCmd = &cobra.Command{
 
 		err = MigrateRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,14 +2,22 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var deleteLong = `delete resources`
+var deleteLong = `delete resources
+
+Resources may be given as documents, in cue, json, or yaml, with - for stdin,
+@file, or @dir for the files of a directory, or with --input, as in
+
+  hof delete @app.cue
+  hof delete -i k8s/
+  cue export ./k8s | hof delete -`
 
 func DeleteRun(args []string) (err error) {
 
@@ -44,8 +52,7 @@ var DeleteCmd = &cobra.Command{
 
 		err = DeleteRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
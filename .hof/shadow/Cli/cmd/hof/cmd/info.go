@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
@@ -53,8 +54,7 @@ var InfoCmd = &cobra.Command{
 
 		err = InfoRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
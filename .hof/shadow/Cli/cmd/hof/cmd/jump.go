@@ -2,14 +2,24 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/jump"
+
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
-var jumpLong = `Jumps help you do things with fewer keystrokes.`
+var jumpLong = `Jumps help you do things with fewer keystrokes.
+
+A jump is a named directory shortcut, a sequence of commands, or commands run in a directory,
+which may use the fields of the context in use, as in {{ .Project }}.
+Jumps are kept in .hof/jumps.cue, in the workspace root and in your home directory,
+where workspace jumps hide global ones of the same name.
+
+'hof jump <name> [args...]' is short for 'hof jump run', and 'hof jump' lists the jumps.`
 
 func JumpRun(args []string) (err error) {
 
@@ -21,7 +31,7 @@ func JumpRun(args []string) (err error) {
 
 var JumpCmd = &cobra.Command{
 
-	Use: "jump",
+	Use: "jump [name] [args...]",
 
 	Aliases: []string{
 		"j",
@@ -45,8 +55,7 @@ var JumpCmd = &cobra.Command{
 
 		err = JumpRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -67,4 +76,9 @@ func init() {
 	JumpCmd.SetHelpFunc(thelp)
 	JumpCmd.SetUsageFunc(tusage)
 
+	JumpCmd.AddCommand(cmdjump.AddCmd)
+	JumpCmd.AddCommand(cmdjump.ListCmd)
+	JumpCmd.AddCommand(cmdjump.RmCmd)
+	JumpCmd.AddCommand(cmdjump.RunCmd)
+
 }
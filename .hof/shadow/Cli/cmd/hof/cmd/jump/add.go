@@ -0,0 +1,96 @@
+package cmdjump
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var addLong = `add or replace a jump, a directory shortcut, commands, or commands run in a directory
+
+Commands run with bash, in order, stopping at the first which fails.
+Both may use the fields of the context in use, as in
+
+  hof jump add api --dir ~/src/api
+  hof jump add deploy --dir ~/src/api --cmd "make image" --cmd "kubectl --context {{ .Environment }} apply -f k8s/"
+
+Jumps are added to the workspace, or the global jumps with --global or outside of a workspace.`
+
+func init() {
+
+	AddCmd.Flags().StringVarP(&(flags.AddFlags.Dir), "dir", "", "", "the directory of the jump, relative to the workspace root or home directory")
+	AddCmd.Flags().StringArrayVarP(&(flags.AddFlags.Cmd), "cmd", "", nil, "a command of the jump, may be repeated")
+}
+
+func AddRun(name string) (err error) {
+
+	// you can safely comment this print out
+	fmt.Println("not implemented")
+
+	return err
+}
+
+var AddCmd = &cobra.Command{
+
+	Use: "add <name>",
+
+	Short: "add or replace a jump",
+
+	Long: addLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = AddRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := AddCmd.HelpFunc()
+	usage := AddCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	AddCmd.SetHelpFunc(thelp)
+	AddCmd.SetUsageFunc(tusage)
+
+}
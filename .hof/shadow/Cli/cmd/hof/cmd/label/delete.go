@@ -2,11 +2,12 @@ package cmdlabel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var deleteLong = `delete labels from your workspace or system`
@@ -45,8 +46,7 @@ var DeleteCmd = &cobra.Command{
 
 		err = DeleteRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
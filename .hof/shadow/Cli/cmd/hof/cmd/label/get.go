@@ -2,11 +2,12 @@ package cmdlabel
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var getLong = `find and display labels from your workspace`
@@ -44,8 +45,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,11 +2,12 @@ package cmdlabelset
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var infoLong = `print info about labelsets in your workspace or system`
@@ -44,8 +45,7 @@ var InfoCmd = &cobra.Command{
 
 		err = InfoRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
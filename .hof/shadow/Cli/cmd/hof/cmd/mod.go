@@ -1,16 +1,19 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/lib/logs"
 	"github.com/hofstadter-io/hof/lib/mod"
+	"github.com/hofstadter-io/hof/lib/mod/cache"
 
 	"github.com/hofstadter-io/hof/cmd/hof/cmd/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
 var modLong = `The mod subcmd is a polyglot dependency management tool based on go mods.
@@ -26,11 +29,35 @@ mod file format:
   )
 
   replace <module path> => <local path>
-  ...`
+  ...
+
+Modules are fetched through the proxies set by $HTTPS_PROXY, $HTTP_PROXY, and $NO_PROXY.
+Set $HOF_CA_FILE to PEM files of certificate authorities to trust besides the system ones,
+as for proxies which intercept TLS.`
+
+func init() {
+
+	ModCmd.PersistentFlags().BoolVarP(&(flags.ModOfflinePflag), "offline", "", false, "only use the module cache and vendor dir, also set with HOF_MOD_OFFLINE=1")
+}
 
 func ModPersistentPreRun(args []string) (err error) {
 
+	// cobra only runs the nearest PersistentPreRun, so not the root one
+	err = logs.Configure(flags.RootVerbosePflag, flags.RootLogFormatPflag, flags.RootQuietPflag)
+	if err != nil {
+		return errs.WithCode(errs.CodeBadFlag, "", err)
+	}
+
 	mod.InitLangs()
+	if flags.ModOfflinePflag {
+		cache.SetOffline(true)
+	}
+
+	if flags.RootQuietPflag {
+		cache.SetProgress(nil)
+	} else {
+		cache.SetProgress(cache.StderrProgress(logs.Scope("mod").Enabled(logs.InfoLevel)))
+	}
 
 	return err
 }
@@ -54,8 +81,7 @@ var ModCmd = &cobra.Command{
 
 		err = ModPersistentPreRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 
@@ -90,5 +116,10 @@ func init() {
 	ModCmd.AddCommand(cmdmod.TidyCmd)
 	ModCmd.AddCommand(cmdmod.VendorCmd)
 	ModCmd.AddCommand(cmdmod.VerifyCmd)
+	ModCmd.AddCommand(cmdmod.WhyCmd)
+	ModCmd.AddCommand(cmdmod.OutdatedCmd)
+	ModCmd.AddCommand(cmdmod.CleanCmd)
+	ModCmd.AddCommand(cmdmod.CacheCmd)
+	ModCmd.AddCommand(cmdmod.PublishCmd)
 
 }
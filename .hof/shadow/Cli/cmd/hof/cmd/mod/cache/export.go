@@ -0,0 +1,94 @@
+package cmdcache
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var exportLong = `Push the modules in the cache to an OCI registry as a single artifact,
+with one layer per module version. Import it with 'hof mod cache import'
+to pre-seed the cache in CI or air-gapped environments.
+Credentials come from the hof auth config, ~/.netrc, or docker login.
+
+  hof mod cache export registry.example.com/team/hof-mods:v1
+  hof mod cache export --module github.com/org/repo registry.example.com/team/repo-mods`
+
+func init() {
+
+	ExportCmd.Flags().StringVarP(&(flags.ExportFlags.Module), "module", "", "", "only export a module path, or a single path@version")
+}
+
+func ExportRun(ref string) (err error) {
+
+	err = mod.CacheExport(ref, flags.ExportFlags.Module)
+
+	return err
+}
+
+var ExportCmd = &cobra.Command{
+
+	Use: "export <host/repo[:tag]>",
+
+	Short: "push the module cache to an OCI registry",
+
+	Long: exportLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'ref'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var ref string
+
+		if 0 < len(args) {
+
+			ref = args[0]
+
+		}
+
+		err = ExportRun(ref)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ExportCmd.HelpFunc()
+	usage := ExportCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ExportCmd.SetHelpFunc(thelp)
+	ExportCmd.SetUsageFunc(tusage)
+
+}
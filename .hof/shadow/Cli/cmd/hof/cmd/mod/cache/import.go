@@ -0,0 +1,85 @@
+package cmdcache
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var importLong = `Fetch the modules in an artifact made by 'hof mod cache export' into the cache.
+Modules already in the cache are left alone, and each one is checked
+against its digest before it is installed.
+
+  hof mod cache import registry.example.com/team/hof-mods:v1`
+
+func ImportRun(ref string) (err error) {
+
+	err = mod.CacheImport(ref)
+
+	return err
+}
+
+var ImportCmd = &cobra.Command{
+
+	Use: "import <host/repo[:tag]>",
+
+	Short: "fill the module cache from an OCI registry",
+
+	Long: importLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'ref'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var ref string
+
+		if 0 < len(args) {
+
+			ref = args[0]
+
+		}
+
+		err = ImportRun(ref)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ImportCmd.HelpFunc()
+	usage := ImportCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ImportCmd.SetHelpFunc(thelp)
+	ImportCmd.SetUsageFunc(tusage)
+
+}
@@ -0,0 +1,80 @@
+package cmdmod
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var cleanLong = `Report the size of the module cache and remove entries from it.
+Leftovers from interrupted fetches are always removed.
+
+  --cache               remove the whole module cache
+  --older-than 30d      remove modules not used within the age (s, m, h, d, w)
+  --module path[@ver]   remove a module, or one version of it
+  --unused              remove modules the module in the current directory does not depend on`
+
+func init() {
+
+	CleanCmd.Flags().BoolVarP(&(flags.CleanFlags.Cache), "cache", "", false, "remove the whole module cache")
+	CleanCmd.Flags().StringVarP(&(flags.CleanFlags.OlderThan), "older-than", "", "", "remove modules not used within the age, as in 30d")
+	CleanCmd.Flags().StringVarP(&(flags.CleanFlags.Module), "module", "", "", "remove a module path, or a single path@version")
+	CleanCmd.Flags().BoolVarP(&(flags.CleanFlags.Unused), "unused", "", false, "remove modules the module in the current directory does not depend on")
+}
+
+func CleanRun(args []string) (err error) {
+
+	err = mod.Clean(flags.CleanFlags.Cache, flags.CleanFlags.OlderThan, flags.CleanFlags.Module, flags.CleanFlags.Unused)
+
+	return err
+}
+
+var CleanCmd = &cobra.Command{
+
+	Use: "clean",
+
+	Short: "report the module cache size and remove stale entries",
+
+	Long: cleanLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = CleanRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := CleanCmd.HelpFunc()
+	usage := CleanCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	CleanCmd.SetHelpFunc(thelp)
+	CleanCmd.SetUsageFunc(tusage)
+
+}
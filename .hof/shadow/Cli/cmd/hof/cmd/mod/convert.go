@@ -9,6 +9,8 @@ import (
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var convertLong = `convert another package system to MVS.`
@@ -17,8 +19,7 @@ func ConvertRun(lang string, filename string) (err error) {
 
 	err = mod.Convert(lang, filename)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -73,8 +74,7 @@ var ConvertCmd = &cobra.Command{
 
 		err = ConvertRun(lang, filename)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
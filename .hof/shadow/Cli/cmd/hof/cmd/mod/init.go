@@ -9,6 +9,8 @@ import (
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var initLong = `initialize a new module in the current directory`
@@ -17,8 +19,7 @@ func InitRun(lang string, module string) (err error) {
 
 	err = mod.Init(lang, module)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -73,8 +74,7 @@ var InitCmd = &cobra.Command{
 
 		err = InitRun(lang, module)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -1,14 +1,13 @@
 package cmdmod
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var verifyLong = `verify dependencies have expected content`
@@ -17,8 +16,7 @@ func VerifyRun(args []string) (err error) {
 
 	err = mod.ProcessLangs("verify", args)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -45,8 +43,7 @@ var VerifyCmd = &cobra.Command{
 
 		err = VerifyRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,11 +2,12 @@ package cmdruntimes
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var deleteLong = `delete a runtime configuration`
@@ -44,8 +45,7 @@ var DeleteCmd = &cobra.Command{
 
 		err = DeleteRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,14 +2,24 @@ package cmdsecret
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var getLong = `print a secret or value(s) at path(s)`
+var getLong = `print a secret or value(s) at path(s)
+
+Values are masked unless --reveal is given, which asks to confirm first.`
+
+func init() {
+
+	GetCmd.Flags().BoolVarP(&(flags.GetFlags.Reveal), "reveal", "", false, "print secret values in the clear, after confirming")
+}
 
 func GetRun(args []string) (err error) {
 
@@ -40,8 +50,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,104 @@
+package cmdst
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/structural"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var convLong = `Convert <in> to another encoding, one of cue, json, yaml, toml, or xml, with --to.
+<in> is read by its extension, see 'hof st --help', and Cue keeps its comments.
+
+With --schema, <in> is unified with the schema first, filling in defaults
+and checking it, so that it must be concrete unless converting to Cue.
+The schema is an <op> too, as in @schema.cue: #Config
+
+  hof st conv @config.yaml --to toml --schema @schema.cue:#Config`
+
+func init() {
+
+	ConvCmd.Flags().StringVarP(&(flags.ConvFlags.To), "to", "", "cue", "encoding to convert to, one of cue, json, yaml, toml, or xml")
+	ConvCmd.Flags().StringVarP(&(flags.ConvFlags.Schema), "schema", "", "", "schema to unify with and check against before converting, see 'hof st --help' for format")
+}
+
+func ConvRun(in string, entrypoints []string) (err error) {
+
+	err = structural.RunConvFromArgs(in, entrypoints, flags.ConvFlags.To, flags.ConvFlags.Schema)
+
+	return err
+}
+
+var ConvCmd = &cobra.Command{
+
+	Use: "conv <in> [...entrypoints]",
+
+	Short: "convert between cue, json, yaml, toml, and xml, optionally with a schema",
+
+	Long: convLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'in'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var in string
+
+		if 0 < len(args) {
+
+			in = args[0]
+
+		}
+
+		var entrypoints []string
+
+		if 1 < len(args) {
+
+			entrypoints = args[1:]
+
+		}
+
+		err = ConvRun(in, entrypoints)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ConvCmd.HelpFunc()
+	usage := ConvCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ConvCmd.SetHelpFunc(thelp)
+	ConvCmd.SetUsageFunc(tusage)
+
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var maskLong = `mask <what> Cue value(s) from <orig>, thereby 'filtering' the original`
@@ -76,8 +78,7 @@ var MaskCmd = &cobra.Command{
 
 		err = MaskRun(orig, what, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -6,15 +6,34 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/lib/structural"
+
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var mergeLong = `merge <new> onto <orig>, replacing values and adding new ones`
+var mergeLong = `Merge <update> onto <orig>, replacing values and adding new ones.
+Structs are merged recursively, while lists and other values are replaced.
+
+The result is printed in the format of <orig>, or --output-format (-O),
+or with --inplace, written back to <orig>, which must then be a whole @file.
+
+When <orig> is -, <update> is merged onto each document of newline delimited JSON
+or multi-document Yaml read from stdin, see --input-format (-I), one at a time.
+
+  tail -f events.json | hof st merge - '{env: "prod"}'`
+
+func init() {
+
+	MergeCmd.Flags().BoolVarP(&(flags.MergeFlags.Inplace), "inplace", "", false, "write the result back to the orig file")
+}
 
 func MergeRun(orig string, update string, entrypoints []string) (err error) {
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	err = structural.RunMergeFromArgs(orig, update, entrypoints, flags.MergeFlags.Inplace, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
 
 	return err
 }
@@ -76,8 +95,7 @@ var MergeCmd = &cobra.Command{
 
 		err = MergeRun(orig, update, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
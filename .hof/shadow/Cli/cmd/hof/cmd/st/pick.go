@@ -6,15 +6,31 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/lib/structural"
+
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var pickLong = `pick <what> Cue value(s) from <orig>`
+var pickLong = `Pick the values of <orig> which unify with <what>, keeping the structure around them.
+<what> is a Cue value shape, as in {a: _, b: c: int}. Lists in <orig> keep the elements
+which unify with <what>, or element-wise when <what> is a list too.
+
+Use --output-format (-O) to print cue, json, yaml, or text,
+defaulting to the format of <orig>.
+
+When <orig> is -, <what> is picked from each document of newline delimited JSON
+or multi-document Yaml read from stdin, see --input-format (-I), one at a time.
+
+  hof st pick @deploy.yaml '{metadata: name: string}' -O json
+  cat manifests.yaml | hof st pick - '{kind: _, metadata: name: _}' -O json`
 
 func PickRun(orig string, pick string, entrypoints []string) (err error) {
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	err = structural.RunPickFromArgs(orig, pick, entrypoints, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
 
 	return err
 }
@@ -76,8 +92,7 @@ var PickCmd = &cobra.Command{
 
 		err = PickRun(orig, pick, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
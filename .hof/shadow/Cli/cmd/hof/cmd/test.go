@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/test"
+
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
@@ -80,6 +83,7 @@ Testers:
 func init() {
 
 	TestCmd.Flags().BoolVarP(&(flags.TestFlags.List), "list", "", false, "list matching tests that would run")
+	TestCmd.Flags().BoolVarP(&(flags.TestFlags.Lint), "lint", "", false, "check hls scripts for problems without running them")
 	TestCmd.Flags().BoolVarP(&(flags.TestFlags.Keep), "keep", "", false, "keep any generated test files")
 	TestCmd.Flags().StringSliceVarP(&(flags.TestFlags.Suite), "suite", "s", nil, "<name>: _ @test(suite)'s to run")
 	TestCmd.Flags().StringSliceVarP(&(flags.TestFlags.Tester), "tester", "t", nil, "<name>: _ @test(<tester>)'s to run")
@@ -119,8 +123,7 @@ var TestCmd = &cobra.Command{
 
 		err = TestRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -141,4 +144,6 @@ func init() {
 	TestCmd.SetHelpFunc(thelp)
 	TestCmd.SetUsageFunc(tusage)
 
+	TestCmd.AddCommand(cmdtest.RecordCmd)
+
 }
@@ -0,0 +1,99 @@
+package cmdtxtar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var packLong = `build a txtar archive from a directory tree, printing it when no archive file is given`
+
+func PackRun(dir string, archive string) (err error) {
+
+	a, err := txtar.Pack(dir, txtar.PackOptions{})
+	if err != nil {
+		return err
+	}
+	data := txtar.Format(a)
+	if archive == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	err = ioutil.WriteFile(archive, data, 0666)
+
+	return err
+}
+
+var PackCmd = &cobra.Command{
+
+	Use: "pack <dir> [archive]",
+
+	Short: "build a txtar archive from a directory tree",
+
+	Long: packLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'dir'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var dir string
+
+		if 0 < len(args) {
+
+			dir = args[0]
+
+		}
+
+		var archive string
+
+		if 1 < len(args) {
+
+			archive = args[1]
+
+		}
+
+		err = PackRun(dir, archive)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := PackCmd.HelpFunc()
+	usage := PackCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	PackCmd.SetHelpFunc(thelp)
+	PackCmd.SetUsageFunc(tusage)
+
+}
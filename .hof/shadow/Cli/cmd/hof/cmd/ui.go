@@ -2,14 +2,37 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var uiLong = `Run hof's local web ui`
+var uiLong = `Run hof's local web ui
+
+A browser ui for the current directory, to look through
+the datamodels, the dependency graph of each module,
+and the latest runs and outputs of hof gen.
+
+The ui is built on a JSON API, which other tools can use too
+
+  GET /api/datamodels                 the names of the datamodels
+  GET /api/modules                    the module of each language
+  GET /api/modules/<lang>/graph       the resolved dependency graph of a module
+  GET /api/runs                       the latest runs of hof gen
+  GET /api/outputs                    the files last generated, by generator
+  GET /api/outputs/<gen>/<file>       a file as last generated
+
+The --ui flag serves the ui once any command is done.`
+
+func init() {
+
+	UiCmd.Flags().StringVarP(&(flags.UiFlags.Addr), "addr", "", "localhost:2323", "the address to serve the ui on")
+}
 
 func UiRun(args []string) (err error) {
 
@@ -40,8 +63,7 @@ var UiCmd = &cobra.Command{
 
 		err = UiRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
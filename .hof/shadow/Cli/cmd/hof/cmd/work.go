@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/work"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+)
+
+var workLong = `A hof.work file lists the directories of local modules, like go.work.
+Within it, or any directory below, 'hof mod tidy', 'vendor', and 'graph'
+run for every module of the workspace. Requirements between the modules
+are replaced by their directories, and all of the modules use the same
+version of each of their other dependencies, the greatest any of them selects.
+
+workspace file format:
+
+  use (
+    ./apis
+    ./web
+  )
+
+Set HOF_WORK=off to work with a single module, or to the path of a workspace file.`
+
+var WorkCmd = &cobra.Command{
+
+	Use: "work",
+
+	Short: "manage workspaces of local modules which are resolved together",
+
+	Long: workLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+}
+
+func init() {
+
+	help := WorkCmd.HelpFunc()
+	usage := WorkCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	WorkCmd.SetHelpFunc(thelp)
+	WorkCmd.SetUsageFunc(tusage)
+
+	WorkCmd.AddCommand(cmdwork.InitCmd)
+	WorkCmd.AddCommand(cmdwork.UseCmd)
+
+}
@@ -0,0 +1,8 @@
+package flags
+
+type AddFlagpole struct {
+	Dir string
+	Cmd []string
+}
+
+var AddFlags AddFlagpole
@@ -0,0 +1,7 @@
+package flags
+
+type CheckpointFlagpole struct {
+	Message string
+}
+
+var CheckpointFlags CheckpointFlagpole
@@ -0,0 +1,8 @@
+package flags
+
+type ConvFlagpole struct {
+	To     string
+	Schema string
+}
+
+var ConvFlags ConvFlagpole
@@ -0,0 +1,11 @@
+package flags
+
+type DiffFlagpole struct {
+	Format     string
+	From       string
+	To         string
+	ExitCode   bool
+	Checkpoint bool
+}
+
+var DiffFlags DiffFlagpole
@@ -0,0 +1,7 @@
+package flags
+
+type GetFlagpole struct {
+	Reveal bool
+}
+
+var GetFlags GetFlagpole
@@ -0,0 +1,7 @@
+package flags
+
+type OutdatedFlagpole struct {
+	Update bool
+}
+
+var OutdatedFlags OutdatedFlagpole
@@ -0,0 +1,12 @@
+package flags
+
+type PublishFlagpole struct {
+	Version string
+	Remote  string
+	Release bool
+	Tests   string
+	NoTests bool
+	DryRun  bool
+}
+
+var PublishFlags PublishFlagpole
@@ -29,6 +29,7 @@ var (
 	RootStrictPflag             bool
 	RootVerbosePflag            string
 	RootQuietPflag              bool
+	RootLogFormatPflag          string
 	RootImpersonateAccountPflag string
 	RootTraceTokenPflag         string
 	RootLogHTTPPflag            string
@@ -2,6 +2,7 @@ package flags
 
 type TestFlagpole struct {
 	List        bool
+	Lint        bool
 	Keep        bool
 	Suite       []string
 	Tester      []string
@@ -0,0 +1,7 @@
+package flags
+
+type VerifyFlagpole struct {
+	Repair bool
+}
+
+var VerifyFlags VerifyFlagpole
@@ -45,5 +45,6 @@ func init() {
 	AuthCmd.AddCommand(cmdauth.LogoutCmd)
 	AuthCmd.AddCommand(cmdauth.ListCmd)
 	AuthCmd.AddCommand(cmdauth.TestCmd)
+	AuthCmd.AddCommand(cmdauth.HelperCmd)
 
 }
@@ -0,0 +1,69 @@
+package cmdhelper
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+var eraseLong = `Read a credential from stdin and pass it to the credential helper
+configured for its host, to erase it, as after it was rejected.`
+
+func EraseRun(args []string) (err error) {
+
+	attrs, err := auth.ReadAttrs(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	return auth.HelperPassRun(auth.HelperErase, attrs)
+}
+
+var EraseCmd = &cobra.Command{
+
+	Use: "erase",
+
+	Short: "erase a credential with the configured helper",
+
+	Long: eraseLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = EraseRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := EraseCmd.HelpFunc()
+	usage := EraseCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	EraseCmd.SetHelpFunc(thelp)
+	EraseCmd.SetUsageFunc(tusage)
+
+}
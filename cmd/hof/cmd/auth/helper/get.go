@@ -0,0 +1,76 @@
+package cmdhelper
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+var getLong = `Read protocol and host from stdin and print the username and password
+found for the host, as hof finds them to fetch modules, from the environment,
+~/.netrc, tokens saved by 'hof auth login', or the configured helper.
+No username or password is printed when there is none.`
+
+func GetRun(args []string) (err error) {
+
+	attrs, err := auth.ReadAttrs(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	out, err := auth.HelperGetRun(attrs)
+	if err != nil {
+		return err
+	}
+
+	return auth.WriteAttrs(os.Stdout, out)
+}
+
+var GetCmd = &cobra.Command{
+
+	Use: "get",
+
+	Short: "print the credential for a host",
+
+	Long: getLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = GetRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := GetCmd.HelpFunc()
+	usage := GetCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	GetCmd.SetHelpFunc(thelp)
+	GetCmd.SetUsageFunc(tusage)
+
+}
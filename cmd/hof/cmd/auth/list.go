@@ -2,21 +2,55 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
 )
 
 var listLong = `list known auth configurations and sessions`
 
 func ListRun(args []string) (err error) {
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	fn, err := auth.ConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := auth.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("config:", fn)
+	if cfg.Helper != "" {
+		fmt.Println("helper:", cfg.Helper)
+	}
+	hosts := make([]string, 0, len(cfg.Hosts))
+	for h := range cfg.Hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	for _, h := range hosts {
+		ha := cfg.Hosts[h]
+		switch {
+		case ha.Token != "":
+			fmt.Printf("  %s: token\n", h)
+		case ha.Helper != "":
+			fmt.Printf("  %s: helper %s\n", h, ha.Helper)
+		case ha.SSH && ha.KeyFile != "":
+			fmt.Printf("  %s: ssh key %s\n", h, ha.KeyFile)
+		case ha.SSH:
+			fmt.Printf("  %s: ssh-agent\n", h)
+		default:
+			fmt.Printf("  %s: https\n", h)
+		}
+	}
 
-	return err
+	return nil
 }
 
 var ListCmd = &cobra.Command{
@@ -40,8 +74,7 @@ var ListCmd = &cobra.Command{
 
 		err = ListRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
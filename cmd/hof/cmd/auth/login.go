@@ -1,22 +1,67 @@
 package cmdauth
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
 )
 
-var loginLong = `login to an account, provider, system, or url`
+var loginLong = `login to an account, provider, system, or url
+
+For git hosts, where is [username@]host and the token is read from stdin,
+so it can be piped in on CI machines. Tokens are saved in the hof auth config,
+and used after $GITHUB_TOKEN, $GITLAB_TOKEN, $GITEA_TOKEN, $BITBUCKET_APP_PASSWORD, and ~/.netrc`
 
 func LoginRun(where string) (err error) {
+	if where == "" {
+		return fmt.Errorf("missing host, usage: hof auth login [username@]host")
+	}
+
+	// [username@]host
+	username, host := "", where
+	if i := strings.LastIndex(where, "@"); i >= 0 {
+		username, host = where[:i], where[i+1:]
+	}
+
+	// the token is read from stdin, so it can be piped in on CI machines
+	fmt.Fprintf(os.Stderr, "token for %s: ", host)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return fmt.Errorf("no token given for %s", host)
+	}
+
+	cfg, err := auth.LoadConfig()
+	if err != nil {
+		return err
+	}
+	ha := cfg.Hosts[host]
+	if ha == nil {
+		ha = &auth.HostAuth{}
+		cfg.Hosts[host] = ha
+	}
+	ha.Username, ha.Token = username, token
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	err = auth.SaveConfig(cfg)
+	if err != nil {
+		return err
+	}
 
-	return err
+	fn, _ := auth.ConfigPath()
+	fmt.Println("saved token for", host, "to", fn)
+	return nil
 }
 
 var LoginCmd = &cobra.Command{
@@ -48,8 +93,7 @@ var LoginCmd = &cobra.Command{
 
 		err = LoginRun(where)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
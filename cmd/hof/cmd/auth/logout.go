@@ -2,21 +2,43 @@ package cmdauth
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
 )
 
 var logoutLong = `logout of an authenticated session`
 
 func LogoutRun(args []string) (err error) {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: hof auth logout <host>")
+	}
+	host := args[0]
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	cfg, err := auth.LoadConfig()
+	if err != nil {
+		return err
+	}
+	ha := cfg.Hosts[host]
+	if ha == nil || ha.Token == "" {
+		return fmt.Errorf("not logged in to %s", host)
+	}
+	ha.Username, ha.Token = "", ""
+	if *ha == (auth.HostAuth{}) {
+		delete(cfg.Hosts, host)
+	}
+
+	err = auth.SaveConfig(cfg)
+	if err != nil {
+		return err
+	}
 
-	return err
+	fmt.Println("removed token for", host)
+	return nil
 }
 
 var LogoutCmd = &cobra.Command{
@@ -40,8 +62,7 @@ var LogoutCmd = &cobra.Command{
 
 		err = LogoutRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
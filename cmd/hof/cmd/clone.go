@@ -8,6 +8,8 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/lib/workspace"
 )
 
@@ -66,8 +68,7 @@ var CloneCmd = &cobra.Command{
 
 		err = CloneRun(module, name)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
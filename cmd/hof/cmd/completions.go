@@ -3,10 +3,26 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/context"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/datamodel"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/datamodel/checkpoint"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/jump"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/labelset"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/mod"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/mod/cache"
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/runtimes"
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/config"
+	"github.com/hofstadter-io/hof/lib/datamodel"
+	"github.com/hofstadter-io/hof/lib/labels"
+	"github.com/hofstadter-io/hof/lib/mod"
+	"github.com/hofstadter-io/hof/lib/ops"
+	"github.com/hofstadter-io/hof/lib/runtimes"
 )
 
 var (
@@ -21,9 +37,22 @@ var CompletionCmd = &cobra.Command{
 	Use:     "completion",
 	Aliases: []string{"completions"},
 	Short:   "Generate completion helpers for popular terminals",
-	Long:    "Generate completion helpers for popular terminals",
+	Long:    CompletionLong,
 }
 
+var CompletionLong = `Generate completion helpers for popular terminals
+
+Besides commands and flags, arguments are completed from local state:
+
+  datamodel names     fields with @datamodel() in --datamodel-dir or the current directory
+  runtime names       the builtin runtimes and fields with @runtime() in --runtimes-dir
+  labelset names      fields with @labelset() in the current directory
+  modules             module paths, and path@version, in the module cache
+  context names       the contexts in the context file
+  jump names          the workspace and global jumps
+
+Completions are computed by 'hof __complete', which the scripts call as you type.`
+
 var BashCompletionLong = `Generate Bash completions
 
 To load completion run
@@ -69,22 +98,85 @@ var BashCompletionCmd = &cobra.Command{
 	},
 }
 
+var ZshCompletionLong = `Generate Zsh completions
+
+To load completion run
+
+source <(hof completion zsh)
+
+To configure your zsh shell to load completions for each session add to your zshrc,
+after compinit
+
+# ~/.zshrc
+source <(hof completion zsh)
+`
+
+// ZshCompletion asks hof for the completions, so that arguments complete from local state,
+// which the zsh script of our cobra version does not do.
+var ZshCompletion = `#compdef hof
+
+_hof() {
+  local -a lines completions opts
+  local out directive line name desc
+
+  # the words after hof, up to the one being completed, which may be empty
+  out=$(hof __complete "${(@)words[2,CURRENT]}" 2>/dev/null) || return 1
+  lines=("${(@f)out}")
+  directive=${lines[-1]#:}
+  lines=("${(@)lines[1,-2]}")
+
+  # error
+  (( directive & 1 )) && return 1
+
+  for line in "${lines[@]}"; do
+    [[ -z $line ]] && continue
+    name=${line%%$'\t'*}
+    desc=""
+    [[ $line == *$'\t'* ]] && desc=${line#*$'\t'}
+    completions+=("${name//:/\\:}${desc:+:$desc}")
+  done
+
+  # no space
+  (( directive & 2 )) && opts=(-S '')
+
+  if (( ${#completions} )); then
+    _describe -t hof 'hof' completions "${opts[@]}" && return 0
+  fi
+
+  # no file completion
+  (( directive & 4 )) || _files
+}
+
+compdef _hof hof
+`
+
 var ZshCompletionCmd = &cobra.Command{
 	Use:   "zsh",
 	Short: "Generate Zsh completions",
-	Long:  "Generate Zsh completions",
+	Long:  ZshCompletionLong,
 	Run: func(cmd *cobra.Command, args []string) {
 		// alias hof to _
 		fmt.Println(ZshHack)
 
-		RootCmd.GenZshCompletion(os.Stdout)
+		fmt.Print(ZshCompletion)
 	},
 }
 
+var FishCompletionLong = `Generate Fish completions
+
+To load completion run
+
+hof completion fish | source
+
+To configure your fish shell to load completions for each session run
+
+hof completion fish > ~/.config/fish/completions/hof.fish
+`
+
 var FishCompletionCmd = &cobra.Command{
 	Use:   "fish",
 	Short: "Generate Fish completions",
-	Long:  "Generate Fish completions",
+	Long:  FishCompletionLong,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		// alias hof to _
@@ -94,18 +186,143 @@ var FishCompletionCmd = &cobra.Command{
 	},
 }
 
+var PowerShellCompletionLong = `Generate PowerShell completions
+
+To load completion run
+
+hof completion powershell | Out-String | Invoke-Expression
+
+To configure PowerShell to load completions for each session add the same line to your $PROFILE
+`
+
+// PowerShellCompletion asks hof for the completions, as ZshCompletion does.
+var PowerShellCompletion = `Register-ArgumentCompleter -Native -CommandName hof -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    # the words after hof, up to the one being completed
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.ToString() })
+    # native commands drop empty arguments
+    if ($wordToComplete -eq "") { $words += '""' }
+
+    $out = @(& hof __complete @words 2>$null)
+    if ($out.Count -eq 0) { return }
+    $directive = [int]($out[-1].TrimStart(':'))
+
+    # error
+    if ($directive -band 1) { return }
+
+    $out | Select-Object -SkipLast 1 | Where-Object { $_ -ne "" } | ForEach-Object {
+        $name, $desc = $_ -split "` + "`" + `t", 2
+        if (-not $desc) { $desc = $name }
+        [System.Management.Automation.CompletionResult]::new($name, $name, 'ParameterValue', $desc)
+    }
+}
+`
+
 var PowerShellCompletionCmd = &cobra.Command{
-	Use:     "power-shell",
-	Aliases: []string{"windows", "win", "power", "ps"},
+	Use:     "powershell",
+	Aliases: []string{"power-shell", "windows", "win", "power", "ps"},
 	Short:   "Generate PowerShell completions",
-	Long:    "Generate PowerShell completions",
+	Long:    PowerShellCompletionLong,
 
 	Run: func(cmd *cobra.Command, args []string) {
 
-		RootCmd.GenPowerShellCompletion(os.Stdout)
+		fmt.Print(PowerShellCompletion)
 	},
 }
 
+// completeNames returns a ValidArgsFunction which suggests the names names returns,
+// looked up when the shell asks rather than when the script is generated.
+func completeNames(names func() []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var comps []string
+		for _, name := range names() {
+			if strings.HasPrefix(name, toComplete) {
+				comps = append(comps, name)
+			}
+		}
+		return comps, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func contextNames() []string {
+	F, err := config.LoadContextFile()
+	if err != nil {
+		return nil
+	}
+	return F.Names()
+}
+
+func moduleNames() []string {
+	return mod.CachedModules(false)
+}
+
+func moduleVersions() []string {
+	return mod.CachedModules(true)
+}
+
+func init() {
+	// the shell reads what 'hof __complete' prints, so the update notice must not be among it
+	if len(os.Args) > 1 && strings.HasPrefix(os.Args[1], cobra.ShellCompRequestCmd) {
+		os.Setenv("HOF_UPDATES_DISABLED", "1")
+	}
+
+	for _, C := range []*cobra.Command{
+		cmddatamodel.ApplyCmd,
+		cmddatamodel.CheckpointCmd,
+		cmddatamodel.DeleteCmd,
+		cmddatamodel.DiffCmd,
+		cmddatamodel.EditCmd,
+		cmddatamodel.GetCmd,
+		cmddatamodel.HistoryCmd,
+		cmddatamodel.MigrateCmd,
+		cmddatamodel.SetCmd,
+		cmddatamodel.StatusCmd,
+		cmddatamodel.VisualizeCmd,
+		cmdcheckpoint.HistoryCmd,
+		cmdcheckpoint.RollbackCmd,
+		cmdcheckpoint.ShowCmd,
+	} {
+		C.ValidArgsFunction = completeNames(datamodel.Names)
+	}
+
+	for _, C := range []*cobra.Command{
+		cmdruntimes.DeleteCmd,
+		cmdruntimes.EditCmd,
+		cmdruntimes.GetCmd,
+		cmdruntimes.InfoCmd,
+		cmdruntimes.InstallCmd,
+		cmdruntimes.SetCmd,
+		cmdruntimes.UninstallCmd,
+	} {
+		C.ValidArgsFunction = completeNames(runtimes.Names)
+	}
+
+	for _, C := range []*cobra.Command{
+		cmdlabelset.DeleteCmd,
+		cmdlabelset.EditCmd,
+		cmdlabelset.GetCmd,
+		cmdlabelset.InfoCmd,
+		cmdlabelset.SetCmd,
+	} {
+		C.ValidArgsFunction = completeNames(labels.LabelsetNames)
+	}
+
+	cmdcontext.UseCmd.ValidArgsFunction = completeNames(contextNames)
+	cmdcontext.DeleteCmd.ValidArgsFunction = completeNames(contextNames)
+
+	JumpCmd.ValidArgsFunction = completeNames(ops.JumpNames)
+	cmdjump.RunCmd.ValidArgsFunction = completeNames(ops.JumpNames)
+	cmdjump.RmCmd.ValidArgsFunction = completeNames(ops.JumpNames)
+
+	cmdmod.WhyCmd.ValidArgsFunction = completeNames(moduleNames)
+	cmdmod.CleanCmd.RegisterFlagCompletionFunc("module", completeNames(moduleVersions))
+	cmdcache.ExportCmd.RegisterFlagCompletionFunc("module", completeNames(moduleVersions))
+}
+
 func init() {
 	CompletionCmd.AddCommand(BashCompletionCmd)
 	CompletionCmd.AddCommand(ZshCompletionCmd)
@@ -2,24 +2,25 @@ package cmdconfig
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/format"
 
 	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var getLong = `print a config or value(s) at path(s)`
+var getLong = `print a config or value(s) at path(s), as in a.b.c
 
-func GetRun(args []string) (err error) {
+Values come from all of the config layers merged, or a single layer
+chosen with --layer, --global, --local, or --config, see 'hof config --help'.`
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+func GetRun(args []string) (err error) {
 
 	if len(args) == 0 {
 		val, err := config.GetRuntime().ConfigGet("")
@@ -77,8 +78,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
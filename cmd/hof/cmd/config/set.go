@@ -8,15 +8,20 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var setLong = `set config values with an expr`
+var setLong = `set config values with a Cue expr, merged into a config layer
 
-func SetRun(expr string) (err error) {
+The layer is the .hofcfg.cue in the current directory, unless chosen
+with --layer, --global, --local, or --config, see 'hof config --help'.
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+  hof config set 'mod: proxy: "https://proxy.example.com"'
+  hof config set --layer workspace 'gen: outdir: "out"'`
+
+func SetRun(expr string) (err error) {
 
 	err = config.GetRuntime().ConfigSet(expr)
 
@@ -58,8 +63,7 @@ var SetCmd = &cobra.Command{
 
 		err = SetRun(expr)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
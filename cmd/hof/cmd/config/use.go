@@ -2,11 +2,12 @@ package cmdconfig
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var useLong = `bring a config into the current`
@@ -40,8 +41,7 @@ var UseCmd = &cobra.Command{
 
 		err = UseRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -1,20 +1,20 @@
 package cmdcontext
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var clearLong = `clear your context and environment`
+var clearLong = `clear your context and environment, leaving no context as the current default`
 
 func ClearRun(args []string) (err error) {
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+	err = config.GetRuntime().ContextClear()
 
 	return err
 }
@@ -40,8 +40,7 @@ var ClearCmd = &cobra.Command{
 
 		err = ClearRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,110 @@
+package cmdcontext
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
+	"github.com/hofstadter-io/hof/lib/config"
+)
+
+var createLong = `add a context with account, project, and credential references
+
+Credentials are a reference, such as the name of a secret, rather than the secret itself.
+The first context created becomes the current default, as in
+
+  hof context create work --account acme --project api --credentials acme-studios
+  hof context create personal --account me --credentials my-studios
+  hof context use personal`
+
+func init() {
+
+	CreateCmd.Flags().StringVarP(&(flags.CreateFlags.Account), "account", "", "", "the account of the context")
+	CreateCmd.Flags().StringVarP(&(flags.CreateFlags.Project), "project", "", "", "the project of the context")
+	CreateCmd.Flags().StringVarP(&(flags.CreateFlags.Credentials), "credentials", "", "", "a reference to the credentials of the context, such as a secret name")
+	CreateCmd.Flags().StringVarP(&(flags.CreateFlags.Environment), "environment", "", "", "the environment of the context")
+}
+
+func CreateRun(name string) (err error) {
+
+	ctx := config.Context{}
+	for field, val := range map[string]string{
+		"Account":     flags.CreateFlags.Account,
+		"Project":     flags.CreateFlags.Project,
+		"Credentials": flags.CreateFlags.Credentials,
+		"Environment": flags.CreateFlags.Environment,
+	} {
+		if val != "" {
+			ctx[field] = val
+		}
+	}
+
+	err = config.GetRuntime().ContextCreate(name, ctx)
+
+	return err
+}
+
+var CreateCmd = &cobra.Command{
+
+	Use: "create <name>",
+
+	Short: "add a context with account, project, and credential references",
+
+	Long: createLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = CreateRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := CreateCmd.HelpFunc()
+	usage := CreateCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	CreateCmd.SetHelpFunc(thelp)
+	CreateCmd.SetUsageFunc(tusage)
+
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package cmdcontext
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/config"
+)
+
+var deleteLong = `remove a context, clearing the current default when it was that one`
+
+func DeleteRun(name string) (err error) {
+
+	err = config.GetRuntime().ContextDelete(name)
+
+	return err
+}
+
+var DeleteCmd = &cobra.Command{
+
+	Use: "delete <name>",
+
+	Short: "remove a context",
+
+	Long: deleteLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = DeleteRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := DeleteCmd.HelpFunc()
+	usage := DeleteCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	DeleteCmd.SetHelpFunc(thelp)
+	DeleteCmd.SetUsageFunc(tusage)
+
+}
\ No newline at end of file
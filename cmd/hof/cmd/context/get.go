@@ -2,19 +2,20 @@ package cmdcontext
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/format"
 
 	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var getLong = `print a context or value(s) at path(s)`
+var getLong = `print the context in use or value(s) at path(s) in it, as in Account or Project`
 
 func GetRun(args []string) (err error) {
 
@@ -77,8 +78,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
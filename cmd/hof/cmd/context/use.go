@@ -7,21 +7,26 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var useLong = `set a context as the current default`
+var useLong = `set a context as the current default
 
-func UseRun(args []string) (err error) {
+The context in use can be changed for a single command with --context.`
 
-	// you can safely comment this print out
-	fmt.Println("not implemented")
+func UseRun(name string) (err error) {
+
+	err = config.GetRuntime().ContextUse(name)
 
 	return err
 }
 
 var UseCmd = &cobra.Command{
 
-	Use: "use [name]",
+	Use: "use <name>",
 
 	Short: "set a context as the current default",
 
@@ -38,11 +43,24 @@ var UseCmd = &cobra.Command{
 
 		// Argument Parsing
 
-		err = UseRun(args)
-		if err != nil {
-			fmt.Println(err)
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
 			os.Exit(1)
 		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = UseRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
 	},
 }
 
@@ -62,4 +80,4 @@ func init() {
 	UseCmd.SetHelpFunc(thelp)
 	UseCmd.SetUsageFunc(tusage)
 
-}
+}
\ No newline at end of file
@@ -53,6 +53,7 @@ func init() {
 	DatamodelCmd.AddCommand(cmddatamodel.StatusCmd)
 	DatamodelCmd.AddCommand(cmddatamodel.VisualizeCmd)
 	DatamodelCmd.AddCommand(cmddatamodel.DiffCmd)
+	DatamodelCmd.AddCommand(cmddatamodel.CheckpointCmd)
 	DatamodelCmd.AddCommand(cmddatamodel.HistoryCmd)
 	DatamodelCmd.AddCommand(cmddatamodel.MigrateCmd)
 	DatamodelCmd.AddCommand(cmddatamodel.ApplyCmd)
@@ -0,0 +1,69 @@
+package cmdcheckpoint
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/datamodel"
+)
+
+var historyLong = `Show the checkpoints of data models, all of them or those named, newest first,
+and whether the workspace has changed since the last.`
+
+func HistoryRun(args []string) (err error) {
+
+	err = datamodel.RunHistoryFromArgs(args)
+
+	return err
+}
+
+var HistoryCmd = &cobra.Command{
+
+	Use: "history [names...]",
+
+	Aliases: []string{
+		"log",
+	},
+
+	Short: "show the checkpoints of data models",
+
+	Long: historyLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = HistoryRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := HistoryCmd.HelpFunc()
+	usage := HistoryCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	HistoryCmd.SetHelpFunc(thelp)
+	HistoryCmd.SetUsageFunc(tusage)
+
+}
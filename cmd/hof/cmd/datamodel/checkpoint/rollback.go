@@ -0,0 +1,94 @@
+package cmdcheckpoint
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/datamodel"
+)
+
+var rollbackLong = `Rewrite a data model in the workspace as it was at a checkpoint, the last one by default,
+discarding the changes since. The data model is checkpointed first, so a rollback can be undone.
+The checkpoint is an id, or enough of one to be unique, or ~N, N checkpoints before the last.
+
+The data model must be declared in a single field at the top of a file, which is rewritten
+with the Cue of the checkpoint, as evaluated, so references within it are resolved.`
+
+func RollbackRun(name string, checkpoint string) (err error) {
+
+	err = datamodel.RunRollbackFromArgs(name, checkpoint)
+
+	return err
+}
+
+var RollbackCmd = &cobra.Command{
+
+	Use: "rollback <name> [checkpoint]",
+
+	Short: "restore a data model to a checkpoint",
+
+	Long: rollbackLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		var checkpoint string
+
+		if 1 < len(args) {
+
+			checkpoint = args[1]
+
+		}
+
+		err = RollbackRun(name, checkpoint)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := RollbackCmd.HelpFunc()
+	usage := RollbackCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	RollbackCmd.SetHelpFunc(thelp)
+	RollbackCmd.SetUsageFunc(tusage)
+
+}
@@ -0,0 +1,90 @@
+package cmdcheckpoint
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/datamodel"
+)
+
+var showLong = `Print the Cue of a data model at a checkpoint, the last one by default.
+The checkpoint is an id, or enough of one to be unique, or ~N, N checkpoints before the last.`
+
+func ShowRun(name string, checkpoint string) (err error) {
+
+	err = datamodel.RunShowFromArgs(name, checkpoint)
+
+	return err
+}
+
+var ShowCmd = &cobra.Command{
+
+	Use: "show <name> [checkpoint]",
+
+	Short: "print a data model at a checkpoint",
+
+	Long: showLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		var checkpoint string
+
+		if 1 < len(args) {
+
+			checkpoint = args[1]
+
+		}
+
+		err = ShowRun(name, checkpoint)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ShowCmd.HelpFunc()
+	usage := ShowCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ShowCmd.SetHelpFunc(thelp)
+	ShowCmd.SetUsageFunc(tusage)
+
+}
@@ -1,31 +1,49 @@
 package cmddatamodel
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"github.com/hofstadter-io/hof/lib/datamodel"
 )
 
-var diffLong = `show the current diff for a data model`
+var diffLong = `Show the changes to the fields of data models, all of them or those named,
+from their last checkpoint, or --from, to the workspace, or --to.
+Either may be a checkpoint, see 'hof datamodel checkpoint history', or a Cue file of the data model.
+With --checkpoint, changed data models are saved as a new checkpoint.
 
-func DiffRun(args []string) (err error) {
+Changes are one of
+  added, removed   a field is new or gone
+  renamed          a field was removed and one with the same Cue added
+  type             the kind of a field changed, as in string to int
+  constraint       the Cue of a field changed otherwise, as in bounds, regexps, or defaults
+
+With --exit-code, hof exits with 1 when there are changes, for CI.`
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+func init() {
+
+	DiffCmd.Flags().StringVarP(&(flags.DiffFlags.Format), "format", "", "text", "output format, one of text or json")
+	DiffCmd.Flags().StringVarP(&(flags.DiffFlags.From), "from", "", "", "checkpoint or Cue file to diff from, defaults to the last checkpoint")
+	DiffCmd.Flags().StringVarP(&(flags.DiffFlags.To), "to", "", "", "checkpoint or Cue file to diff to, defaults to the workspace")
+	DiffCmd.Flags().BoolVarP(&(flags.DiffFlags.ExitCode), "exit-code", "", false, "exit with 1 when there are changes")
+	DiffCmd.Flags().BoolVarP(&(flags.DiffFlags.Checkpoint), "checkpoint", "", false, "save changed data models as a new checkpoint")
+}
+
+func DiffRun(args []string) (err error) {
 
-	err = datamodel.RunDiffFromArgs(args)
+	err = datamodel.RunDiffFromArgs(args, flags.DiffFlags)
 
 	return err
 }
 
 var DiffCmd = &cobra.Command{
 
-	Use: "diff",
+	Use: "diff [names...]",
 
 	Aliases: []string{
 		"d",
@@ -48,8 +66,7 @@ var DiffCmd = &cobra.Command{
 
 		err = DiffRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
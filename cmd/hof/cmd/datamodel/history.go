@@ -1,23 +1,20 @@
 package cmddatamodel
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/lib/datamodel"
 )
 
-var historyLong = `show the history for a data model`
+var historyLong = `Show the checkpoints of data models, all of them or those named, newest first.
+The same as 'hof datamodel checkpoint history'.`
 
 func HistoryRun(args []string) (err error) {
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
-
 	err = datamodel.RunHistoryFromArgs(args)
 
 	return err
@@ -25,7 +22,7 @@ func HistoryRun(args []string) (err error) {
 
 var HistoryCmd = &cobra.Command{
 
-	Use: "history",
+	Use: "history [names...]",
 
 	Aliases: []string{
 		"hist",
@@ -51,8 +48,7 @@ var HistoryCmd = &cobra.Command{
 
 		err = HistoryRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -1,31 +1,42 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/jump"
+
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"github.com/hofstadter-io/hof/lib/ops"
 )
 
-var jumpLong = `Jumps help you do things with fewer keystrokes.`
+var jumpLong = `Jumps help you do things with fewer keystrokes.
+
+A jump is a named directory shortcut, a sequence of commands, or commands run in a directory,
+which may use the fields of the context in use, as in {{ .Project }}.
+Jumps are kept in .hof/jumps.cue, in the workspace root and in your home directory,
+where workspace jumps hide global ones of the same name.
+
+'hof jump <name> [args...]' is short for 'hof jump run', and 'hof jump' lists the jumps.`
 
 func JumpRun(args []string) (err error) {
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+	if len(args) == 0 {
+		return ops.JumpList(flags.RootOutputFormatPflag)
+	}
 
-	err = ops.RunJumpFromArgs(args)
+	err = ops.JumpRun(args[0], args[1:])
 
 	return err
 }
 
 var JumpCmd = &cobra.Command{
 
-	Use: "jump",
+	Use: "jump [name] [args...]",
 
 	Aliases: []string{
 		"j",
@@ -49,8 +60,7 @@ var JumpCmd = &cobra.Command{
 
 		err = JumpRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -71,4 +81,9 @@ func init() {
 	JumpCmd.SetHelpFunc(thelp)
 	JumpCmd.SetUsageFunc(tusage)
 
+	JumpCmd.AddCommand(cmdjump.AddCmd)
+	JumpCmd.AddCommand(cmdjump.ListCmd)
+	JumpCmd.AddCommand(cmdjump.RmCmd)
+	JumpCmd.AddCommand(cmdjump.RunCmd)
+
 }
\ No newline at end of file
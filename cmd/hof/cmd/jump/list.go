@@ -0,0 +1,73 @@
+package cmdjump
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
+	"github.com/hofstadter-io/hof/lib/ops"
+)
+
+var listLong = `list the workspace and global jumps
+
+Workspace jumps hide global ones of the same name.
+Use --output-format (-O) to choose table (default), json, or yaml.`
+
+func ListRun(args []string) (err error) {
+
+	err = ops.JumpList(flags.RootOutputFormatPflag)
+
+	return err
+}
+
+var ListCmd = &cobra.Command{
+
+	Use: "list",
+
+	Aliases: []string{
+		"ls",
+	},
+
+	Short: "list the jumps",
+
+	Long: listLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = ListRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ListCmd.HelpFunc()
+	usage := ListCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ListCmd.SetHelpFunc(thelp)
+	ListCmd.SetUsageFunc(tusage)
+
+}
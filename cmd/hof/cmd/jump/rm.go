@@ -0,0 +1,86 @@
+package cmdjump
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/ops"
+)
+
+var rmLong = `remove a jump from the workspace, or the global jumps with --global`
+
+func RmRun(name string) (err error) {
+
+	err = ops.JumpRemove(name)
+
+	return err
+}
+
+var RmCmd = &cobra.Command{
+
+	Use: "rm <name>",
+
+	Aliases: []string{
+		"remove",
+		"delete",
+	},
+
+	Short: "remove a jump",
+
+	Long: rmLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		err = RmRun(name)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := RmCmd.HelpFunc()
+	usage := RmCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	RmCmd.SetHelpFunc(thelp)
+	RmCmd.SetUsageFunc(tusage)
+
+}
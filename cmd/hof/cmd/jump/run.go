@@ -0,0 +1,90 @@
+package cmdjump
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/ops"
+)
+
+var runLong = `run the commands of a jump, in its directory when it has one
+
+Args are passed to each command, as $1, $2, and so on.
+A jump with only a directory prints it, so a shell function can cd there, as in
+
+  j() { cd "$(hof jump run "$1")"; }
+
+Fields of the context in use fill in the jump, as in {{ .Project }}.`
+
+func RunRun(name string, args []string) (err error) {
+
+	err = ops.JumpRun(name, args)
+
+	return err
+}
+
+var RunCmd = &cobra.Command{
+
+	Use: "run <name> [args...]",
+
+	Short: "run a jump",
+
+	Long: runLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+		var rest []string
+
+		if 0 < len(args) {
+
+			name = args[0]
+			rest = args[1:]
+
+		}
+
+		err = RunRun(name, rest)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := RunCmd.HelpFunc()
+	usage := RunCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	RunCmd.SetHelpFunc(thelp)
+	RunCmd.SetUsageFunc(tusage)
+
+}
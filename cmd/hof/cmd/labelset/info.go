@@ -1,13 +1,14 @@
 package cmdlabelset
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"github.com/hofstadter-io/hof/lib/labels"
 )
 
@@ -18,7 +19,7 @@ func InfoRun(args []string) (err error) {
 	// you can safely comment this print out
 	// fmt.Println("not implemented")
 
-	err = labels.RunInfoLabelsetFromArgs(args)
+	err = labels.RunInfoLabelsetFromArgs(args, flags.RootOutputFormatPflag)
 
 	return err
 }
@@ -48,8 +49,7 @@ var InfoCmd = &cobra.Command{
 
 		err = InfoRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
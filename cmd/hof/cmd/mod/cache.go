@@ -0,0 +1,48 @@
+package cmdmod
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/mod/cache"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+)
+
+var cacheLong = `work with the module cache`
+
+var CacheCmd = &cobra.Command{
+
+	Use: "cache",
+
+	Short: "work with the module cache",
+
+	Long: cacheLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+}
+
+func init() {
+
+	help := CacheCmd.HelpFunc()
+	usage := CacheCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	CacheCmd.SetHelpFunc(thelp)
+	CacheCmd.SetUsageFunc(tusage)
+
+	CacheCmd.AddCommand(cmdcache.VerifyCmd)
+	CacheCmd.AddCommand(cmdcache.ExportCmd)
+	CacheCmd.AddCommand(cmdcache.ImportCmd)
+
+}
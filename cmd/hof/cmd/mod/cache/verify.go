@@ -0,0 +1,73 @@
+package cmdcache
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var verifyLong = `Check every module in the cache against the hash recorded when it was fetched.
+Modules from interrupted fetches have no hash and are reported as well.
+With --repair, damaged modules are removed and fetched again.`
+
+func init() {
+
+	VerifyCmd.Flags().BoolVarP(&(flags.VerifyFlags.Repair), "repair", "", false, "remove and refetch damaged modules")
+}
+
+func VerifyRun(args []string) (err error) {
+
+	err = mod.CacheVerify(flags.VerifyFlags.Repair)
+
+	return err
+}
+
+var VerifyCmd = &cobra.Command{
+
+	Use: "verify",
+
+	Short: "check cached modules have not been damaged",
+
+	Long: verifyLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = VerifyRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := VerifyCmd.HelpFunc()
+	usage := VerifyCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	VerifyCmd.SetHelpFunc(thelp)
+	VerifyCmd.SetUsageFunc(tusage)
+
+}
@@ -1,24 +1,30 @@
 package cmdmod
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var graphLong = `print module requirement graph`
+var graphLong = `print the resolved module requirement graph
+
+Each requirement is listed along with the version MVS selected
+for every module and the requirements which selected it.
+Use --output-format (-O) to choose text (default), dot, json, yaml, or table.
+
+  hof mod graph -O dot | dot -Tsvg > graph.svg`
 
 func GraphRun(args []string) (err error) {
 
-	err = mod.ProcessLangs("graph", args)
+	err = mod.GraphLangs(flags.RootOutputFormatPflag, args)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -26,7 +32,7 @@ func GraphRun(args []string) (err error) {
 
 var GraphCmd = &cobra.Command{
 
-	Use: "graph",
+	Use: "graph [langs...]",
 
 	Short: "print module requirement graph",
 
@@ -45,8 +51,7 @@ var GraphCmd = &cobra.Command{
 
 		err = GraphRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -2,13 +2,14 @@ package cmdmod
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var infoLong = `  print info about languages and modders known to mvs
@@ -19,8 +20,7 @@ func InfoRun(lang string) (err error) {
 
 	msg, err := mod.LangInfo(lang)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 	fmt.Println(msg)
 
@@ -56,8 +56,7 @@ var InfoCmd = &cobra.Command{
 
 		err = InfoRun(lang)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
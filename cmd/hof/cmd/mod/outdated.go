@@ -0,0 +1,74 @@
+package cmdmod
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var outdatedLong = `List the selected dependencies which have newer tags at their remotes.
+With --update, the modules required directly by version
+are bumped to their latest tag in the mod file.
+Use --output-format (-O) to choose table (default), json, or yaml.`
+
+func init() {
+
+	OutdatedCmd.Flags().BoolVarP(&(flags.OutdatedFlags.Update), "update", "u", false, "update direct requires to the latest tags")
+}
+
+func OutdatedRun(args []string) (err error) {
+
+	err = mod.Outdated(flags.OutdatedFlags.Update, flags.RootOutputFormatPflag, args)
+
+	return err
+}
+
+var OutdatedCmd = &cobra.Command{
+
+	Use: "outdated [langs...]",
+
+	Short: "list dependencies with newer versions upstream",
+
+	Long: outdatedLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = OutdatedRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := OutdatedCmd.HelpFunc()
+	usage := OutdatedCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	OutdatedCmd.SetHelpFunc(thelp)
+	OutdatedCmd.SetUsageFunc(tusage)
+
+}
@@ -0,0 +1,94 @@
+package cmdmod
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+var publishLong = `Validate the module and release the next version of it.
+The git worktree must be clean, the module tidy and verified,
+and the test scripts passing. The version is tagged and pushed,
+with the subdirectory as a tag prefix for nested modules.
+
+Without --version, the conventional commits to the module since
+the last release choose the part to bump: breaking changes are major,
+except before v1 where they are minor, feat is minor, and others patch.
+
+  --version v1.2.3|major|minor|patch   the version, or the part to bump
+  --release                            create a GitHub release with the module zip
+  --dry-run                            validate and print the version only`
+
+func init() {
+
+	PublishCmd.Flags().StringVarP(&(flags.PublishFlags.Version), "version", "", "", "version to release, or major, minor, or patch to bump")
+	PublishCmd.Flags().StringVarP(&(flags.PublishFlags.Remote), "remote", "", "origin", "git remote to push the tag to")
+	PublishCmd.Flags().BoolVarP(&(flags.PublishFlags.Release), "release", "", false, "create a GitHub release with the module zip")
+	PublishCmd.Flags().StringVarP(&(flags.PublishFlags.Tests), "tests", "", "testdata/*.txt", "glob of test scripts to run")
+	PublishCmd.Flags().BoolVarP(&(flags.PublishFlags.NoTests), "no-tests", "", false, "skip running the test scripts")
+	PublishCmd.Flags().BoolVarP(&(flags.PublishFlags.DryRun), "dry-run", "", false, "validate and print the next version without tagging")
+}
+
+func PublishRun(args []string) (err error) {
+
+	err = mod.Publish(args, mod.PublishOptions{
+		Version: flags.PublishFlags.Version,
+		Remote:  flags.PublishFlags.Remote,
+		Release: flags.PublishFlags.Release,
+		Tests:   flags.PublishFlags.Tests,
+		NoTests: flags.PublishFlags.NoTests,
+		DryRun:  flags.PublishFlags.DryRun,
+	})
+
+	return err
+}
+
+var PublishCmd = &cobra.Command{
+
+	Use: "publish [langs...]",
+
+	Short: "validate, tag, and push a module release",
+
+	Long: publishLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = PublishRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := PublishCmd.HelpFunc()
+	usage := PublishCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	PublishCmd.SetHelpFunc(thelp)
+	PublishCmd.SetUsageFunc(tusage)
+
+}
@@ -1,14 +1,13 @@
 package cmdmod
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
 )
 
 var tidyLong = `add missinad and remove unused modules`
@@ -17,8 +16,7 @@ func TidyRun(args []string) (err error) {
 
 	err = mod.ProcessLangs("tidy", args)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -45,8 +43,7 @@ var TidyCmd = &cobra.Command{
 
 		err = TidyRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
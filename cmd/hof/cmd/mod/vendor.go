@@ -1,24 +1,37 @@
 package cmdmod
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/lib/mod"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
-var vendorLong = `make a vendored copy of dependencies`
+var vendorLong = `Make a vendored copy of the selected dependencies. Modules which are
+no longer required are removed from the vendor dir and the sumfile.
+
+With --check, the vendor dir is compared to the sumfile without
+fetching or writing anything, failing when they differ, for CI.`
+
+func init() {
+
+	VendorCmd.Flags().BoolVarP(&(flags.VendorFlags.Check), "check", "", false, "verify the vendor dir matches the sumfile without rewriting it")
+}
 
 func VendorRun(args []string) (err error) {
 
-	err = mod.ProcessLangs("vendor", args)
+	method := "vendor"
+	if flags.VendorFlags.Check {
+		method = "vendor-check"
+	}
+	err = mod.ProcessLangs(method, args)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	return err
@@ -45,8 +58,7 @@ var VendorCmd = &cobra.Command{
 
 		err = VendorRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
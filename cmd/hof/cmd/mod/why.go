@@ -0,0 +1,82 @@
+package cmdmod
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var whyLong = `Print the shortest chain of requirements from the root module
+to each module, through the versions MVS selected.`
+
+func WhyRun(modules []string) (err error) {
+
+	err = mod.Why(modules)
+
+	return err
+}
+
+var WhyCmd = &cobra.Command{
+
+	Use: "why <modules...>",
+
+	Short: "explain why modules are in the dependency graph",
+
+	Long: whyLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'modules'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var modules []string
+
+		if 0 < len(args) {
+
+			modules = args[0:]
+
+		}
+
+		err = WhyRun(modules)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := WhyCmd.HelpFunc()
+	usage := WhyCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	WhyCmd.SetHelpFunc(thelp)
+	WhyCmd.SetUsageFunc(tusage)
+
+}
@@ -11,9 +11,14 @@ import (
 
 	"github.com/hofstadter-io/hof/script"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 
 	"github.com/hofstadter-io/hof/lib/config"
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/logs"
+	"github.com/hofstadter-io/hof/lib/tui"
+	"github.com/hofstadter-io/hof/lib/ui"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
@@ -26,17 +31,17 @@ func init() {
 
 	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootLabelsPflag, "label", "l", nil, "Labels for use across all commands")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootConfigPflag, "config", "", "", "Path to a hof configuration file")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootSecretPflag, "secret", "", "", "The path to a hof secret file")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootSecretPflag, "secret", "", "", "The path to an age encrypted hof secret file, or a secret provider reference")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootContextFilePflag, "context-file", "", "", "The path to a hof context file")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootContextPflag, "context", "", "", "The of an entry in the context file")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootContextPflag, "context", "", "", "The name of an entry in the context file")
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootGlobalPflag, "global", "", false, "Operate using only the global config/secret context")
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootLocalPflag, "local", "", false, "Operate using only the local config/secret context")
-	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootInputPflag, "input", "i", nil, "input streams, depending on the command context")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootInputFormatPflag, "input-format", "I", "", "input format, defaults to infered")
+	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootInputPflag, "input", "i", nil, "inputs for resource commands, - for stdin, files, or directories")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootInputFormatPflag, "input-format", "I", "", "input format, cue, json, or yaml, defaults to infered")
 	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootOutputPflag, "output", "o", nil, "output streams, depending on the command context")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootOutputFormatPflag, "output-format", "O", "", "output format, defaults to cue")
-	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootErrorPflag, "error", "", nil, "error streams, depending on the command context")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootErrorFormatPflag, "error-format", "", "", "error format, defaults to cue")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootOutputFormatPflag, "output-format", "O", "", "output format, json, yaml, table, or text, defaults depend on the command")
+	RootCmd.PersistentFlags().StringSliceVarP(&flags.RootErrorPflag, "error", "", nil, "error destinations, stdout, stderr, or files, defaults to stdout")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootErrorFormatPflag, "error-format", "", "", "error format, text or json, defaults to text")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootAccountPflag, "account", "", "", "the account context to use during this hof execution")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootBillingPflag, "billing", "", "", "the billing context to use during this hof execution")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootProjectPflag, "project", "", "", "the project context to use during this hof execution")
@@ -50,17 +55,23 @@ func init() {
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootSimplifyPflag, "simplify", "S", false, "simplify output")
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootTracePflag, "trace", "", false, "trace cue computation")
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootStrictPflag, "strict", "", false, "report errors for lossy mappings")
-	RootCmd.PersistentFlags().StringVarP(&flags.RootVerbosePflag, "verbose", "v", "", "set the verbosity of output")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootVerbosePflag, "verbose", "v", "", "set the log level, as in debug or info,mod=debug")
 	RootCmd.PersistentFlags().BoolVarP(&flags.RootQuietPflag, "quiet", "q", false, "turn off output and assume defaults at prompts")
+	RootCmd.PersistentFlags().StringVarP(&flags.RootLogFormatPflag, "log-format", "", "", "log format, console or json, defaults to console")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootImpersonateAccountPflag, "impersonate-account", "", "", "account to impersonate for this hof execution")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootTraceTokenPflag, "trace-token", "T", "", "used to help debug issues")
 	RootCmd.PersistentFlags().StringVarP(&flags.RootLogHTTPPflag, "log-http", "", "", "used to help debug issues")
-	RootCmd.PersistentFlags().BoolVarP(&flags.RootRunUIPflag, "ui", "", false, "run the command from the web ui")
-	RootCmd.PersistentFlags().BoolVarP(&flags.RootRunTUIPflag, "tui", "", false, "run the command from the terminal ui")
+	RootCmd.PersistentFlags().BoolVarP(&flags.RootRunUIPflag, "ui", "", false, "serve the web ui once the command is done")
+	RootCmd.PersistentFlags().BoolVarP(&flags.RootRunTUIPflag, "tui", "", false, "show the terminal ui dashboard once the command is done")
 }
 
 func RootPersistentPreRun(args []string) (err error) {
 
+	err = logs.Configure(flags.RootVerbosePflag, flags.RootLogFormatPflag, flags.RootQuietPflag)
+	if err != nil {
+		return errs.WithCode(errs.CodeBadFlag, "", err)
+	}
+
 	config.Init()
 
 	return err
@@ -70,6 +81,19 @@ func RootPersistentPostRun(args []string) (err error) {
 
 	WaitPrintUpdateAvailable()
 
+	// the web ui is served once the command is done
+	if flags.RootRunUIPflag {
+		err = ui.Serve(flags.UiFlags.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the dashboard is shown once the command is done
+	if flags.RootRunTUIPflag {
+		err = tui.Run()
+	}
+
 	return err
 }
 
@@ -88,8 +112,7 @@ var RootCmd = &cobra.Command{
 
 		err = RootPersistentPreRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 
@@ -106,8 +129,7 @@ var RootCmd = &cobra.Command{
 
 		err = RootPersistentPostRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -161,6 +183,7 @@ func RootInit() {
 	RootCmd.AddCommand(TourCmd)
 	RootCmd.AddCommand(TutorialCmd)
 	RootCmd.AddCommand(ModCmd)
+	RootCmd.AddCommand(WorkCmd)
 	RootCmd.AddCommand(AddCmd)
 	RootCmd.AddCommand(CmdCmd)
 	RootCmd.AddCommand(InfoCmd)
@@ -177,6 +200,7 @@ func RootInit() {
 	RootCmd.AddCommand(TrimCmd)
 	RootCmd.AddCommand(VetCmd)
 	RootCmd.AddCommand(StCmd)
+	RootCmd.AddCommand(TxtarCmd)
 	RootCmd.AddCommand(AuthCmd)
 	RootCmd.AddCommand(ConfigCmd)
 	RootCmd.AddCommand(SecretCmd)
@@ -241,6 +265,7 @@ Learn more about hof and the _ you can do:
 
 Download modules, add instances or content, and manage runtimes:
   mod             β     mod subcmd is a polyglot dependency management tool based on go mods
+  work            α     manage workspaces of local modules which are resolved together
   add             α     add dependencies and new components to the current module or workspace
   runtimes        α     work with runtimes (go, js, py, bash, docker, cloud-vms, k8s, custom)
 
@@ -261,7 +286,8 @@ Configure, Unify, Execute (see also https://cuelang.org):
   import          α     convert other formats and systems to hofland
   trim            α     cleanup code, configuration, and more
   vet             α     validate data
-  st              α     recursive diff, merge, mask, pick, and query helpers for Cue
+  st              α     recursive diff, merge, mask, pick, query, and conv helpers for Cue
+  txtar           α     pack and unpack txtar archives, the format of hof test scripts
 
 Manage logins, config, secrets, and context:
   auth            Ø     authentication subcommands
@@ -296,8 +322,8 @@ Collaborate (see also 'hof topic collaborate'):
 Local development commands:
   reproduce       Ø     Record, share, and replay reproducible environments and processes
   jump            α     Jumps help you do things with fewer keystrokes.
-  ui              Ø     Run hof's local web ui
-  tui             Ø     Run hof's terminal ui
+  ui                    Run hof's local web ui
+  tui                   Run hof's terminal ui
   repl            Ø     Run hof's local REPL
   pprof                 go pprof by setting HOF_CPU_PROFILE="hof-cpu.prof" hof <cmd>
 
@@ -331,14 +357,13 @@ Use "hof topic [subject]"  for more information about a subject.
 
 func RunExit() {
 	if err := RunErr(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		errs.Exit("hof", err)
 	}
 }
 
 func RunInt() int {
 	if err := RunErr(); err != nil {
-		fmt.Println(err)
+		errs.Write(flags.RootErrorPflag, flags.RootErrorFormatPflag, "hof", err)
 		return 1
 	}
 	return 0
@@ -362,14 +387,38 @@ func RunErr() error {
 	}
 
 	RootInit()
+	AddAliasCommands()
 	return RootCmd.Execute()
 }
 
 func CallTS(ts *script.Script, args []string) error {
+	resetFlags(RootCmd)
+	AddAliasCommands()
 	RootCmd.SetArgs(args)
 
 	err := RootCmd.Execute()
 	ts.Check(err)
 
 	return err
-}
\ No newline at end of file
+}
+
+// resetFlags sets the flags of cmd and its subcommands back to their defaults,
+// as they keep the values of earlier calls in the same process.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
@@ -8,7 +8,23 @@ import (
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 )
 
-var secretLong = `manage local secrets`
+var secretLong = `manage local secrets, encrypted at rest
+
+Secrets are kept in the OS keyring, the macOS Keychain, Windows Credential Manager,
+or the Secret Service on Linux, when there is one, and in ~/.hof/secrets.age otherwise.
+The age file is encrypted with a passphrase, from $HOF_SECRET_PASSPHRASE or the terminal.
+Use --secret for another age file, or $HOF_SECRET_STORE=keyring|age to pick the store.
+
+Plaintext .hofshh.cue files from earlier versions are moved in on the next 'hof secret set'.
+
+Secrets can also come from a provider, by reference, as <scheme>://<location>[#<key.path>]
+
+  vault://secret/data/app#token    HashiCorp Vault, at $VAULT_ADDR with $VAULT_TOKEN
+  awssm://prod/app#password        AWS Secrets Manager, through the aws cli
+  sops://secrets.enc.yaml#db.pass  a SOPS file, through the sops cli
+
+Give one to --secret to read the secrets from there, or reference one in a generator,
+as in 'token: string @secret("vault://secret/data/app#token")', to fetch it when generating.`
 
 var SecretCmd = &cobra.Command{
 
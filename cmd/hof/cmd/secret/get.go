@@ -2,42 +2,40 @@ package cmdsecret
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
-	"cuelang.org/go/cue"
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"cuelang.org/go/cue/format"
 
 	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var getLong = `print a secret or value(s) at path(s)`
+var getLong = `print a secret or value(s) at path(s)
 
-func GetRun(args []string) (err error) {
+Values are masked unless --reveal is given, which asks to confirm first.`
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+func init() {
 
-	if len(args) == 0 {
-		val, err := config.GetRuntime().SecretGet("")
-		if err != nil {
-			return err
-		}
+	GetCmd.Flags().BoolVarP(&(flags.GetFlags.Reveal), "reveal", "", false, "print secret values in the clear, after confirming")
+}
 
-		z := cue.Value{}
-		if val == z {
-			return fmt.Errorf("no config found, use 'hof config -h' to learn create and use configurations")
-		}
+func GetRun(args []string) (err error) {
 
-		bytes, err := format.Node(val.Syntax())
+	if flags.GetFlags.Reveal {
+		err = config.ConfirmReveal()
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(bytes))
-		return nil
+	}
+
+	if len(args) == 0 {
+		args = []string{""}
 	}
 
 	for _, a := range args {
@@ -46,11 +44,22 @@ func GetRun(args []string) (err error) {
 			return err
 		}
 
+		if !flags.GetFlags.Reveal {
+			val, err = config.MaskSecret(val)
+			if err != nil {
+				return err
+			}
+		}
+
 		bytes, err := format.Node(val.Syntax())
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%s: %s\n\n", a, string(bytes))
+		if a == "" {
+			fmt.Println(string(bytes))
+		} else {
+			fmt.Printf("%s: %s\n\n", a, string(bytes))
+		}
 	}
 
 	return nil
@@ -77,8 +86,7 @@ var GetCmd = &cobra.Command{
 
 		err = GetRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -8,10 +8,15 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/lib/config"
 )
 
-var setLong = `set secret values with an expr`
+var setLong = `set secret values with an expr
+
+Secrets are encrypted at rest, in the OS keyring when there is one,
+or else in ~/.hof/secrets.age, encrypted with a passphrase, see 'hof secret --help'.`
 
 func SetRun(expr string) (err error) {
 
@@ -58,8 +63,7 @@ var SetCmd = &cobra.Command{
 
 		err = SetRun(expr)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
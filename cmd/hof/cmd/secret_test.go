@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/hofstadter-io/hof/lib/yagu"
@@ -23,6 +24,9 @@ func TestScriptSecretCliTests(t *testing.T) {
 
 			env.Vars = append(env.Vars, "HOF_TELEMETRY_DISABLED=1")
 
+			// hof is called in this process, so the age store reads its env
+			os.Setenv("HOF_SECRET_PASSPHRASE", "correct-horse")
+
 			return nil
 		},
 		Funcs: map[string]func(ts *script.Script, args []string) error{
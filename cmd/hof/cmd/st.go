@@ -8,16 +8,17 @@ import (
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 )
 
-var stLong = `Structural diff, merge, mask, pick, and query helpers for Cue
+var stLong = `Structural diff, merge, mask, pick, query, and conv helpers for Cue
 
 Commands generally have the form: <method> <op1> <op2> [...entrypoints]
 
 Where <op> can be:
 - Cue: expr: as: string
-- @filename.cue: Cue: expr: as: string
+- @filename.cue, .json, .yaml, .toml, or .xml, or a path into it, as in @filename.cue: a.b.c
 
-If entrypoints are supplied, then an <op> without an @filename.cue will lookup from the entrypoints.
-Otherwise, the <op> is interpreted as a complete Cue value.`
+If entrypoints are supplied, then an <op> without an @filename will lookup a path, as in a.b.c, from the entrypoints.
+Otherwise, the <op> is interpreted as a complete Cue value.
+For merge and pick, the first <op> may be -, as may the files for validate, to process a stream of documents from stdin.`
 
 var StCmd = &cobra.Command{
 
@@ -27,7 +28,7 @@ var StCmd = &cobra.Command{
 		"structural",
 	},
 
-	Short: "recursive diff, merge, mask, pick, and query helpers for Cue",
+	Short: "recursive diff, merge, mask, pick, query, and conv helpers for Cue",
 
 	Long: stLong,
 
@@ -59,5 +60,7 @@ func init() {
 	StCmd.AddCommand(cmdst.PickCmd)
 	StCmd.AddCommand(cmdst.MaskCmd)
 	StCmd.AddCommand(cmdst.QueryCmd)
+	StCmd.AddCommand(cmdst.ValidateCmd)
+	StCmd.AddCommand(cmdst.ConvCmd)
 
 }
@@ -8,17 +8,30 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"github.com/hofstadter-io/hof/lib/structural"
 )
 
-var diffLong = `Calculate the difference between two Cue values`
+var diffLong = `Calculate the difference between two Cue values, printing the paths
+which were added, removed, or changed. Structs are compared field by field,
+while lists and other values are compared whole.
 
-func DiffRun(orig string, next string, entrypoints []string) (err error) {
+--format is one of
+  text   lines of "+ path: value", "- path: value", and "~ path: from -> to"
+  json   a list of {op, path, from, to}
+  patch  a JSON Patch (RFC 6902) from <orig> to <next>`
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+func init() {
 
-	err = structural.RunDiffFromArgs(orig, next, entrypoints)
+	DiffCmd.Flags().StringVarP(&(flags.DiffFlags.Format), "format", "", "text", "output format, one of text, json, or patch")
+}
+
+func DiffRun(orig string, next string, entrypoints []string) (err error) {
+
+	err = structural.RunDiffFromArgs(orig, next, entrypoints, flags.DiffFlags.Format)
 
 	return err
 }
@@ -80,8 +93,7 @@ var DiffCmd = &cobra.Command{
 
 		err = DiffRun(orig, next, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -8,6 +8,8 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
 	"github.com/hofstadter-io/hof/lib/structural"
 )
 
@@ -80,8 +82,7 @@ var MaskCmd = &cobra.Command{
 
 		err = MaskRun(orig, what, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -8,17 +8,26 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
 
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
 	"github.com/hofstadter-io/hof/lib/structural"
 )
 
-var queryLong = `query for values matching an expr and/or attributes`
+var queryLong = `Query for the values of <orig> at the paths matching <expr>.
+<expr> is a dot separated path, where * matches every field or list element,
+and numbers index lists, as in services.*.ports.0
 
-func QueryRun(orig string, expr string, entrypoints []string) (err error) {
+Matches are printed as a list, or with --output-format (-O) text,
+one per line, with strings unquoted for use in shell pipelines.
+The format is one of cue, json, yaml, or text, defaulting to the format of <orig>.
 
-	// you can safely comment this print out
-	// fmt.Println("not implemented")
+  hof st query @compose.yaml 'services.*.image' -O text | sort -u`
 
-	err = structural.RunQueryFromArgs(orig, expr, entrypoints)
+func QueryRun(orig string, expr string, entrypoints []string) (err error) {
+
+	err = structural.RunQueryFromArgs(orig, expr, entrypoints, flags.RootOutputFormatPflag)
 
 	return err
 }
@@ -80,8 +89,7 @@ var QueryCmd = &cobra.Command{
 
 		err = QueryRun(orig, expr, entrypoints)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
@@ -0,0 +1,121 @@
+package cmdst
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+var validateLong = `Validate data files against <schema>, which they must unify with and then be concrete.
+Each problem is printed with its location, as file:line:col: path: message,
+or with --output-format (-O) json, as a list of {file, path, line, column, message}.
+
+<schema> is an <op>, see 'hof st --help', of one of the --schema-type
+  cue         any Cue value, usually a definition, as in @schema.cue:#Config
+  jsonschema  a JSON Schema file, as in @schema.json
+  openapi     a component of an OpenAPI file, as in @openapi.yaml:Pet
+which is guessed from the file when not set. Cue entrypoints for <schema>
+to lookup paths in are given with --entrypoints (-e).
+
+When the file is -, each document of newline delimited JSON or multi-document Yaml
+read from stdin, see --input-format (-I), is validated one at a time.
+Valid documents are written to stdout, in --output-format (-O) or as read,
+and invalid ones are reported on stderr by their number, so the command
+filters a stream while it checks it.
+
+  hof st validate @schema.cue:#Config config/*.yaml
+  cat events.json | hof st validate @openapi.yaml:Event - > valid.json`
+
+func init() {
+
+	ValidateCmd.Flags().StringVarP(&(flags.ValidateFlags.SchemaType), "schema-type", "", "", "type of the schema, one of cue, jsonschema, or openapi, guessed by default")
+	ValidateCmd.Flags().StringSliceVarP(&(flags.ValidateFlags.Entrypoints), "entrypoints", "e", nil, "Cue entrypoints to lookup the schema in")
+}
+
+func ValidateRun(schema string, files []string) (err error) {
+
+	err = structural.RunValidateFromArgs(schema, files, flags.ValidateFlags.Entrypoints, flags.ValidateFlags.SchemaType, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
+
+	return err
+}
+
+var ValidateCmd = &cobra.Command{
+
+	Use: "validate <schema> <files...>",
+
+	Short: "validate data files against a Cue, JSON Schema, or OpenAPI schema",
+
+	Long: validateLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'schema'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var schema string
+
+		if 0 < len(args) {
+
+			schema = args[0]
+
+		}
+
+		if 1 >= len(args) {
+			fmt.Println("missing required argument: 'files'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var files []string
+
+		if 1 < len(args) {
+
+			files = args[1:]
+
+		}
+
+		err = ValidateRun(schema, files)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := ValidateCmd.HelpFunc()
+	usage := ValidateCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	ValidateCmd.SetHelpFunc(thelp)
+	ValidateCmd.SetUsageFunc(tusage)
+
+}
\ No newline at end of file
@@ -0,0 +1,92 @@
+package cmdtest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/test"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var recordLong = `Record a shell session as an hls script. Commands are run in a fresh
+work directory, seeded with the files in dir when given, and replayed
+by the script with checks for their output, status, and the files
+they touched. End the session with exit or ^D.`
+
+func RecordRun(name string, dir string) (err error) {
+
+	err = test.Record(name, dir, os.Stdin, os.Stdout)
+
+	return err
+}
+
+var RecordCmd = &cobra.Command{
+
+	Use: "record <name> [dir]",
+
+	Short: "record a shell session as a script",
+
+	Long: recordLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'name'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var name string
+
+		if 0 < len(args) {
+
+			name = args[0]
+
+		}
+
+		var dir string
+
+		if 1 < len(args) {
+
+			dir = args[1]
+
+		}
+
+		err = RecordRun(name, dir)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := RecordCmd.HelpFunc()
+	usage := RecordCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	RecordCmd.SetHelpFunc(thelp)
+	RecordCmd.SetUsageFunc(tusage)
+
+}
@@ -1,20 +1,38 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+
+	"github.com/hofstadter-io/hof/lib/tui"
 )
 
-var tuiLong = `Run hof's terminal ui`
+var tuiLong = `Run hof's terminal ui
+
+A dashboard of the current directory, with
+  - the datamodels, and j/k or the arrows to select one
+  - the module of each language, and whether it is vendored
+  - the runtimes
+  - the latest runs of hof gen
+
+Keys
+  d  diff the selected datamodel
+  m  migrate the selected datamodel
+  v  vendor the modules
+  r  refresh
+  q  quit
+
+The --tui flag shows the dashboard once any command is done.`
 
 func TuiRun(args []string) (err error) {
 
 	// you can safely comment this print out
-	fmt.Println("not implemented")
+	// fmt.Println("not implemented")
+
+	err = tui.Run()
 
 	return err
 }
@@ -40,8 +58,7 @@ var TuiCmd = &cobra.Command{
 
 		err = TuiRun(args)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			errs.Exit(cmd.CommandPath(), err)
 		}
 	},
 }
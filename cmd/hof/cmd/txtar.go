@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/cmd/hof/cmd/txtar"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+)
+
+var txtarLong = `Pack a directory tree into a txtar archive, or unpack one back out.
+
+Binary files are base64 encoded, executables and symlinks are preserved,
+and files containing txtar markers are quoted with an unquote line added.
+The archive comment, the script of an .hls file, is kept in README.hls.`
+
+var TxtarCmd = &cobra.Command{
+
+	Use: "txtar",
+
+	Short: "pack and unpack txtar archives, the format of hof test scripts",
+
+	Long: txtarLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+}
+
+func init() {
+
+	help := TxtarCmd.HelpFunc()
+	usage := TxtarCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	TxtarCmd.SetHelpFunc(thelp)
+	TxtarCmd.SetUsageFunc(tusage)
+
+	TxtarCmd.AddCommand(cmdtxtar.PackCmd)
+	TxtarCmd.AddCommand(cmdtxtar.UnpackCmd)
+
+}
@@ -0,0 +1,96 @@
+package cmdtxtar
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var unpackLong = `extract a txtar archive into a directory, the current one by default`
+
+func UnpackRun(archive string, dir string) (err error) {
+
+	a, err := txtar.ParseFile(archive)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = "."
+	}
+	err = txtar.Unpack(a, dir, txtar.PackOptions{})
+
+	return err
+}
+
+var UnpackCmd = &cobra.Command{
+
+	Use: "unpack <archive> [dir]",
+
+	Short: "extract a txtar archive into a directory",
+
+	Long: unpackLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		if 0 >= len(args) {
+			fmt.Println("missing required argument: 'archive'")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var archive string
+
+		if 0 < len(args) {
+
+			archive = args[0]
+
+		}
+
+		var dir string
+
+		if 1 < len(args) {
+
+			dir = args[1]
+
+		}
+
+		err = UnpackRun(archive, dir)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := UnpackCmd.HelpFunc()
+	usage := UnpackCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	UnpackCmd.SetHelpFunc(thelp)
+	UnpackCmd.SetUsageFunc(tusage)
+
+}
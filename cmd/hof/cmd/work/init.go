@@ -0,0 +1,64 @@
+package cmdwork
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var initLong = `create a hof.work file in the current directory using the module dirs`
+
+func InitRun(args []string) (err error) {
+
+	err = mod.WorkInit(args)
+
+	return err
+}
+
+var InitCmd = &cobra.Command{
+
+	Use: "init [dirs...]",
+
+	Short: "create a hof.work file in the current directory using the module dirs",
+
+	Long: initLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = InitRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := InitCmd.HelpFunc()
+	usage := InitCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	InitCmd.SetHelpFunc(thelp)
+	InitCmd.SetUsageFunc(tusage)
+
+}
@@ -0,0 +1,64 @@
+package cmdwork
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hofstadter-io/hof/lib/mod"
+
+	"github.com/hofstadter-io/hof/cmd/hof/ga"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+var useLong = `add module dirs to the nearest hof.work file`
+
+func UseRun(args []string) (err error) {
+
+	err = mod.WorkUse(args)
+
+	return err
+}
+
+var UseCmd = &cobra.Command{
+
+	Use: "use <dirs...>",
+
+	Short: "add module dirs to the nearest hof.work file",
+
+	Long: useLong,
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		ga.SendCommandPath(cmd.CommandPath())
+
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Argument Parsing
+
+		err = UseRun(args)
+		if err != nil {
+			errs.Exit(cmd.CommandPath(), err)
+		}
+	},
+}
+
+func init() {
+
+	help := UseCmd.HelpFunc()
+	usage := UseCmd.UsageFunc()
+
+	thelp := func(cmd *cobra.Command, args []string) {
+		ga.SendCommandPath(cmd.CommandPath() + " help")
+		help(cmd, args)
+	}
+	tusage := func(cmd *cobra.Command) error {
+		ga.SendCommandPath(cmd.CommandPath() + " usage")
+		return usage(cmd)
+	}
+	UseCmd.SetHelpFunc(thelp)
+	UseCmd.SetUsageFunc(tusage)
+
+}
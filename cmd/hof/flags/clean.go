@@ -0,0 +1,10 @@
+package flags
+
+type CleanFlagpole struct {
+	Cache     bool
+	OlderThan string
+	Module    string
+	Unused    bool
+}
+
+var CleanFlags CleanFlagpole
@@ -0,0 +1,5 @@
+package flags
+
+var (
+	ConfigLayerPflag string
+)
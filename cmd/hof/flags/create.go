@@ -0,0 +1,10 @@
+package flags
+
+type CreateFlagpole struct {
+	Account     string
+	Project     string
+	Credentials string
+	Environment string
+}
+
+var CreateFlags CreateFlagpole
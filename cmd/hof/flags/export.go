@@ -0,0 +1,7 @@
+package flags
+
+type ExportFlagpole struct {
+	Module string
+}
+
+var ExportFlags ExportFlagpole
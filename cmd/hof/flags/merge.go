@@ -0,0 +1,7 @@
+package flags
+
+type MergeFlagpole struct {
+	Inplace bool
+}
+
+var MergeFlags MergeFlagpole
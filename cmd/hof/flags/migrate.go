@@ -0,0 +1,12 @@
+package flags
+
+type MigrateFlagpole struct {
+	Format string
+	From   string
+	To     string
+	Sql    string
+	Dir    string
+	Name   string
+}
+
+var MigrateFlags MigrateFlagpole
@@ -0,0 +1,5 @@
+package flags
+
+var (
+	ModOfflinePflag bool
+)
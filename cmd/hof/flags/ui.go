@@ -0,0 +1,7 @@
+package flags
+
+type UiFlagpole struct {
+	Addr string
+}
+
+var UiFlags UiFlagpole
@@ -0,0 +1,8 @@
+package flags
+
+type ValidateFlagpole struct {
+	SchemaType  string
+	Entrypoints []string
+}
+
+var ValidateFlags ValidateFlagpole
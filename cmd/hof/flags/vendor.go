@@ -0,0 +1,7 @@
+package flags
+
+type VendorFlagpole struct {
+	Check bool
+}
+
+var VendorFlags VendorFlagpole
@@ -0,0 +1,7 @@
+package flags
+
+type ViewFlagpole struct {
+	Resolved bool
+}
+
+var ViewFlags ViewFlagpole
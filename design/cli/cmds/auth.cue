@@ -18,7 +18,13 @@ import (
 		Name:  "login"
 		Usage: "login <where>"
 		Short: "login to an account, provider, system, or url"
-		Long:  Short
+		Long: """
+			login to an account, provider, system, or url
+
+			For git hosts, where is [username@]host and the token is read from stdin,
+			so it can be piped in on CI machines. Tokens are saved in the hof auth config,
+			and used after $GITHUB_TOKEN, $GITLAB_TOKEN, $GITEA_TOKEN, $BITBUCKET_APP_PASSWORD, and ~/.netrc
+			"""
 		Args: [
 			{
 				Name: "where"
@@ -44,5 +50,58 @@ import (
 		Usage: "test [name]"
 		Short: "test your auth configuration, defaults to current context"
 		Long:  Short
+	}, {
+		TBD:   "β"
+		Name:  "helper"
+		Usage: "helper"
+		Short: "speak the git credential helper protocol"
+		Long: """
+			speak the git credential helper protocol
+
+			The credential helper set in the hof auth config is asked for tokens
+			hof has no other way to find, and hof never saves what it returns.
+
+			  helper: "osxkeychain"                          // runs git-credential-osxkeychain
+			  hosts: "git.corp.example.com": helper: "!corp-sso token"
+
+			These commands let other tools use hof, and its helper, the same way,
+			reading key=value lines from stdin, as in
+
+			  printf 'protocol=https\\nhost=github.com\\n\\n' | hof auth helper get
+			  git config --global credential.helper '!hof auth helper'
+			"""
+
+		OmitRun: true
+
+		Commands: [{
+			TBD:   "β"
+			Name:  "get"
+			Usage: "get"
+			Short: "print the credential for a host"
+			Long: """
+				Read protocol and host from stdin and print the username and password
+				found for the host, as hof finds them to fetch modules, from the environment,
+				~/.netrc, tokens saved by 'hof auth login', or the configured helper.
+				No username or password is printed when there is none.
+				"""
+		}, {
+			TBD:   "β"
+			Name:  "store"
+			Usage: "store"
+			Short: "store a credential with the configured helper"
+			Long: """
+				Read a credential from stdin and pass it to the credential helper
+				configured for its host. hof does not keep it.
+				"""
+		}, {
+			TBD:   "β"
+			Name:  "erase"
+			Usage: "erase"
+			Short: "erase a credential with the configured helper"
+			Long: """
+				Read a credential from stdin and pass it to the credential helper
+				configured for its host, to erase it, as after it was rejected.
+				"""
+		}]
 	}]
 }
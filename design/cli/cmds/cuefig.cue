@@ -9,7 +9,14 @@ import (
 	Name:  "context"
 	Usage: "context"
 	Short: "get, set, and use contexts"
-	Long:  Short
+	Long: """
+		get, set, and use contexts
+
+		A context holds references to the account, project, and credentials hof works with,
+		so you can switch between setups, such as work and personal, with 'hof context use'.
+		Contexts are kept in ~/.hof/contexts.cue, or the file given with --context-file,
+		and --context picks one for a single command.
+		"""
 
 	OmitRun: true
 
@@ -18,13 +25,13 @@ import (
 		Name:  "get"
 		Usage: "get <key.path>"
 		Short: "print a context or value(s) at path(s)"
-		Long:  Short
+		Long:  "print the context in use or value(s) at path(s) in it, as in Account or Project"
 	}, {
 		TBD:   "β"
 		Name:  "set"
 		Usage: "set [expr]"
 		Short: "set context values with an expr"
-		Long:  Short
+		Long:  "set values of the context in use with an expr, as in 'Project: \"api\"'"
 		Args: [{
 			Name:     "expr"
 			Type:     "string"
@@ -32,11 +39,94 @@ import (
 			Help:     "Cue expr for value you'd like to merge into your context"
 		}]
 	}, {
-		TBD:   "Ø"
+		TBD:   "β"
 		Name:  "use"
-		Usage: "use [name]"
+		Usage: "use <name>"
 		Short: "set a context as the current default"
-		Long:  Short
+		Long: """
+			set a context as the current default
+
+			The context in use can be changed for a single command with --context.
+			"""
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the context"
+		}]
+	}, {
+		TBD:   "β"
+		Name:  "list"
+		Usage: "list"
+		Short: "list the contexts"
+		Long: """
+			list the contexts, marking the one in use with a *
+
+			Contexts are kept in ~/.hof/contexts.cue, or the file given with --context-file.
+			Use --output-format (-O) to choose table (default), json, or yaml.
+			"""
+	}, {
+		TBD:   "β"
+		Name:  "create"
+		Usage: "create <name>"
+		Short: "add a context with account, project, and credential references"
+		Long: """
+			add a context with account, project, and credential references
+
+			Credentials are a reference, such as the name of a secret, rather than the secret itself.
+			The first context created becomes the current default, as in
+
+			  hof context create work --account acme --project api --credentials acme-studios
+			  hof context create personal --account me --credentials my-studios
+			  hof context use personal
+			"""
+		Flags: [{
+			Name:    "account"
+			Type:    "string"
+			Default: ""
+			Help:    "the account of the context"
+			Long:    "account"
+			Short:   ""
+		}, {
+			Name:    "project"
+			Type:    "string"
+			Default: ""
+			Help:    "the project of the context"
+			Long:    "project"
+			Short:   ""
+		}, {
+			Name:    "credentials"
+			Type:    "string"
+			Default: ""
+			Help:    "a reference to the credentials of the context, such as a secret name"
+			Long:    "credentials"
+			Short:   ""
+		}, {
+			Name:    "environment"
+			Type:    "string"
+			Default: ""
+			Help:    "the environment of the context"
+			Long:    "environment"
+			Short:   ""
+		}]
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the context"
+		}]
+	}, {
+		TBD:   "β"
+		Name:  "delete"
+		Usage: "delete <name>"
+		Short: "remove a context"
+		Long:  "remove a context, clearing the current default when it was that one"
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the context"
+		}]
 	}, {
 		TBD:   "Ø"
 		Name:  "source"
@@ -44,11 +134,11 @@ import (
 		Short: "source a context into your environment"
 		Long:  Short
 	}, {
-		TBD:   "Ø"
+		TBD:   "β"
 		Name:  "clear"
 		Usage: "clear"
 		Short: "clear your context and environment"
-		Long:  Short
+		Long:  "clear your context and environment, leaving no context as the current default"
 	}]
 }
 
@@ -57,28 +147,106 @@ import (
 	Name:  "config"
 	Usage: "config"
 	Short: "manage local configurations"
-	Long:  Short
+	Long: """
+		manage hof's config, layered from
+
+		  global     ~/.hof/config.cue
+		  workspace  .hofcfg.cue in the workspace root, the nearest directory up with a cue.mods file
+		  local      .hofcfg.cue in the current directory
+		  custom     the file given with --config
+
+		where later layers override the values of earlier ones.
+		Structs are merged field by field, while lists and other values are replaced.
+		Use --layer, or --global and --local, to read or write a single layer.
+
+		The aliases section adds commands which run hof command lines, so teams can share shortcuts, as in
+
+		  aliases: {
+		    "gen-api": "gen ./design/api.cue -t api -o $1"
+		    "mod-up": {
+		      cmd:  "mod outdated --update $@"
+		      help: "update the direct requires"
+		    }
+		  }
+
+		where $1, $2, and so on are the args of the alias, and $@ all of them.
+		The args are appended when the command line has no placeholders.
+		Aliases come from the global, workspace, and local layers, not --config.
+		"""
 
 	OmitRun: true
 
+	Pflags: [{
+		Name:    "layer"
+		Long:    "layer"
+		Short:   ""
+		Type:    "string"
+		Default: ""
+		Help:    "config layer to read or write, one of global, workspace, local, or custom"
+	}]
+
 	Commands: [{
 		TBD:   "β"
 		Name:  "get"
 		Usage: "get <key.path>"
 		Short: "print a config or value(s) at path(s)"
-		Long:  Short
+		Long: """
+			print a config or value(s) at path(s), as in a.b.c
+
+			Values come from all of the config layers merged, or a single layer
+			chosen with --layer, --global, --local, or --config, see 'hof config --help'.
+			"""
 	}, {
 		TBD:   "β"
 		Name:  "set"
 		Usage: "set [expr]"
 		Short: "set config values with an expr"
-		Long:  Short
+		Long: """
+			set config values with a Cue expr, merged into a config layer
+
+			The layer is the .hofcfg.cue in the current directory, unless chosen
+			with --layer, --global, --local, or --config, see 'hof config --help'.
+
+			  hof config set 'mod: proxy: "https://proxy.example.com"'
+			  hof config set --layer workspace 'gen: outdir: "out"'
+			"""
 		Args: [{
 			Name:     "expr"
 			Type:     "string"
 			Required: true
 			Help:     "Cue expr for value you'd like to merge into your config"
 		}]
+	}, {
+		TBD:   "β"
+		Name:  "view"
+		Usage: "view"
+		Short: "print the config layers, or the resolved config and where its values come from"
+		Long: """
+			print the config layers, or the resolved config and where its values come from
+
+			Each layer is printed with its file, see 'hof config --help'.
+			With --resolved, every value of the merged config is printed
+			along with the layer which set it, as in
+
+			  gen.outdir: "out"  // workspace /path/to/workspace/.hofcfg.cue
+			"""
+
+		Flags: [{
+			Name:    "resolved"
+			Type:    "bool"
+			Default: "false"
+			Help:    "print the merged config and where each value comes from"
+			Long:    "resolved"
+			Short:   ""
+		}]
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/config", ...},
+		]
+
+		Body: """
+			err = config.GetRuntime().ConfigView(flags.ViewFlags.Resolved)
+			"""
 	}, {
 		TBD:   "Ø"
 		Name:  "use"
@@ -93,7 +261,25 @@ import (
 	Name:  "secret"
 	Usage: "secret"
 	Short: "manage local secrets"
-	Long:  Short
+	Long: """
+		manage local secrets, encrypted at rest
+
+		Secrets are kept in the OS keyring, the macOS Keychain, Windows Credential Manager,
+		or the Secret Service on Linux, when there is one, and in ~/.hof/secrets.age otherwise.
+		The age file is encrypted with a passphrase, from $HOF_SECRET_PASSPHRASE or the terminal.
+		Use --secret for another age file, or $HOF_SECRET_STORE=keyring|age to pick the store.
+
+		Plaintext .hofshh.cue files from earlier versions are moved in on the next 'hof secret set'.
+
+		Secrets can also come from a provider, by reference, as <scheme>://<location>[#<key.path>]
+
+		  vault://secret/data/app#token    HashiCorp Vault, at $VAULT_ADDR with $VAULT_TOKEN
+		  awssm://prod/app#password        AWS Secrets Manager, through the aws cli
+		  sops://secrets.enc.yaml#db.pass  a SOPS file, through the sops cli
+
+		Give one to --secret to read the secrets from there, or reference one in a generator,
+		as in 'token: string @secret("vault://secret/data/app#token")', to fetch it when generating.
+		"""
 
 	OmitRun: true
 
@@ -102,13 +288,30 @@ import (
 		Name:  "get"
 		Usage: "get <key.path>"
 		Short: "print a secret or value(s) at path(s)"
-		Long:  Short
+		Long: """
+			print a secret or value(s) at path(s)
+
+			Values are masked unless --reveal is given, which asks to confirm first.
+			"""
+		Flags: [{
+			Name:    "reveal"
+			Type:    "bool"
+			Default: "false"
+			Help:    "print secret values in the clear, after confirming"
+			Long:    "reveal"
+			Short:   ""
+		}]
 	}, {
 		TBD:   "β"
 		Name:  "set"
 		Usage: "set [expr]"
 		Short: "set secret values with an expr"
-		Long:  Short
+		Long: """
+			set secret values with an expr
+
+			Secrets are encrypted at rest, in the OS keyring when there is one,
+			or else in ~/.hof/secrets.age, encrypted with a passphrase, see 'hof secret --help'.
+			"""
 		Args: [{
 			Name:     "expr"
 			Type:     "string"
@@ -66,24 +66,231 @@ import (
 	}, {
 		TBD:   "α"
 		Name:  "diff"
-		Usage: "diff"
+		Usage: "diff [names...]"
 		Aliases: ["d"]
 		Short: "show the current diff for a data model"
-		Long:  Short
+		Long: """
+			Show the changes to the fields of data models, all of them or those named,
+			from their last checkpoint, or --from, to the workspace, or --to.
+			Either may be a checkpoint, see 'hof datamodel checkpoint history', or a Cue file of the data model.
+			With --checkpoint, changed data models are saved as a new checkpoint.
+
+			Changes are one of
+			  added, removed   a field is new or gone
+			  renamed          a field was removed and one with the same Cue added
+			  type             the kind of a field changed, as in string to int
+			  constraint       the Cue of a field changed otherwise, as in bounds, regexps, or defaults
+
+			With --exit-code, hof exits with 1 when there are changes, for CI.
+			"""
+
+		Flags: [{
+			Name:    "format"
+			Type:    "string"
+			Default: "\"text\""
+			Help:    "output format, one of text or json"
+			Long:    "format"
+			Short:   ""
+		}, {
+			Name:    "from"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "checkpoint or Cue file to diff from, defaults to the last checkpoint"
+			Long:    "from"
+			Short:   ""
+		}, {
+			Name:    "to"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "checkpoint or Cue file to diff to, defaults to the workspace"
+			Long:    "to"
+			Short:   ""
+		}, {
+			Name:    "exitCode"
+			Type:    "bool"
+			Default: "false"
+			Help:    "exit with 1 when there are changes"
+			Long:    "exit-code"
+			Short:   ""
+		}, {
+			Name:    "checkpoint"
+			Type:    "bool"
+			Default: "false"
+			Help:    "save changed data models as a new checkpoint"
+			Long:    "checkpoint"
+			Short:   ""
+		}]
+	}, {
+		TBD:   "α"
+		Name:  "checkpoint"
+		Usage: "checkpoint [names...]"
+		Aliases: ["cp"]
+		Short: "save data models as a checkpoint in their history"
+		Long: """
+			Save data models, all of them or those named, as a new checkpoint in their history.
+			Checkpoints are kept in .hof/datamodels/<name>, named by the sha256 of their Cue,
+			and nothing is saved when a data model is unchanged since its last checkpoint.
+
+			Checkpoints are referred to by their id, or enough of one to be unique,
+			or as ~N, N checkpoints before the last. Diff and migrate work between any two.
+
+			  hof datamodel checkpoint -m "add users"
+			  hof datamodel checkpoint history
+			  hof datamodel diff --from ~1 --to ~0
+			"""
+
+		Flags: [{
+			Name:    "message"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "a message describing the checkpoint"
+			Long:    "message"
+			Short:   "m"
+		}]
+
+		Commands: [{
+			TBD:   "α"
+			Name:  "history"
+			Usage: "history [names...]"
+			Aliases: ["log"]
+			Short: "show the checkpoints of data models"
+			Long: """
+				Show the checkpoints of data models, all of them or those named, newest first,
+				and whether the workspace has changed since the last.
+				"""
+		}, {
+			TBD:   "α"
+			Name:  "show"
+			Usage: "show <name> [checkpoint]"
+			Short: "print a data model at a checkpoint"
+			Long: """
+				Print the Cue of a data model at a checkpoint, the last one by default.
+				The checkpoint is an id, or enough of one to be unique, or ~N, N checkpoints before the last.
+				"""
+
+			Args: [{
+				Name:     "name"
+				Type:     "string"
+				Required: true
+				Help:     "the data model"
+			}, {
+				Name: "checkpoint"
+				Type: "string"
+				Help: "the checkpoint, defaults to the last"
+			}]
+		}, {
+			TBD:   "α"
+			Name:  "rollback"
+			Usage: "rollback <name> [checkpoint]"
+			Short: "restore a data model to a checkpoint"
+			Long: """
+				Rewrite a data model in the workspace as it was at a checkpoint, the last one by default,
+				discarding the changes since. The data model is checkpointed first, so a rollback can be undone.
+				The checkpoint is an id, or enough of one to be unique, or ~N, N checkpoints before the last.
+
+				The data model must be declared in a single field at the top of a file, which is rewritten
+				with the Cue of the checkpoint, as evaluated, so references within it are resolved.
+				"""
+
+			Args: [{
+				Name:     "name"
+				Type:     "string"
+				Required: true
+				Help:     "the data model"
+			}, {
+				Name: "checkpoint"
+				Type: "string"
+				Help: "the checkpoint, defaults to the last"
+			}]
+		}]
 	}, {
 		TBD:   "α"
 		Name:  "history"
-		Usage: "history"
+		Usage: "history [names...]"
 		Aliases: ["hist", "h", "log", "l"]
 		Short: "show the history for a data model"
-		Long:  Short
+		Long: """
+			Show the checkpoints of data models, all of them or those named, newest first.
+			The same as 'hof datamodel checkpoint history'.
+			"""
 	}, {
 		TBD:   "α"
 		Name:  "migrate"
-		Usage: "migrate"
+		Usage: "migrate [names...]"
 		Aliases: ["mig", "migs", "migrations"]
 		Short: "calculate a changeset for a data model"
-		Long:  Short
+		Long: """
+			Calculate the steps migrating data models, all of them or those named,
+			from their last checkpoint, or --from, to the workspace, or --to.
+			Either may be a checkpoint, see 'hof datamodel checkpoint history', or a Cue file of the data model.
+			Data models without checkpoints are migrated from nothing.
+
+			Steps are ordered as
+			  rename   a field is renamed, so later steps use the new names
+			  add      a field is new
+			  alter    the type or constraints of a field changed
+			  drop     a field is gone
+
+			With --sql postgres or mysql, up and down SQL migrations are written instead, as
+
+			  <dir>/<datamodel>/<version>_<name>.up.sql
+			  <dir>/<datamodel>/<version>_<name>.down.sql
+
+			which runners such as golang-migrate read. Each of Models is a table, and each of its
+			fields, not starting with an upper case letter, a column. Optional fields are nullable,
+			and Cue defaults are column defaults. Columns are set further with @sql(), as in
+
+			  id:     int    @sql(primary)
+			  email:  string @sql(unique)
+			  name:   string @sql(index,type="VARCHAR(64)")
+			  userId: int    @sql(references=User.id)
+
+			Required columns added without a default are filled with the zero value of their kind.
+			"""
+
+		Flags: [{
+			Name:    "format"
+			Type:    "string"
+			Default: "\"text\""
+			Help:    "output format, one of text or json"
+			Long:    "format"
+			Short:   ""
+		}, {
+			Name:    "from"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "checkpoint or Cue file to migrate from, defaults to the last checkpoint"
+			Long:    "from"
+			Short:   ""
+		}, {
+			Name:    "to"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "checkpoint or Cue file to migrate to, defaults to the workspace"
+			Long:    "to"
+			Short:   ""
+		}, {
+			Name:    "sql"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "write SQL migrations for a database, one of postgres or mysql"
+			Long:    "sql"
+			Short:   ""
+		}, {
+			Name:    "dir"
+			Type:    "string"
+			Default: "\"migrations\""
+			Help:    "directory SQL migrations are written to"
+			Long:    "dir"
+			Short:   ""
+		}, {
+			Name:    "name"
+			Type:    "string"
+			Default: "\"\""
+			Help:    "name of the SQL migrations, defaults to where they go"
+			Long:    "name"
+			Short:   ""
+		}]
 	}, {
 		TBD:   "α"
 		Name:  "apply"
@@ -16,26 +16,166 @@ import (
 #JumpCommand: schema.#Command & {
 	TBD:   "α"
 	Name:  "jump"
-	Usage: "jump"
+	Usage: "jump [name] [args...]"
 	Aliases: ["j", "leap"]
 	Short: "Jumps help you do things with fewer keystrokes."
-	Long:  Short
+	Long: """
+		Jumps help you do things with fewer keystrokes.
+
+		A jump is a named directory shortcut, a sequence of commands, or commands run in a directory,
+		which may use the fields of the context in use, as in {{ .Project }}.
+		Jumps are kept in .hof/jumps.cue, in the workspace root and in your home directory,
+		where workspace jumps hide global ones of the same name.
+
+		'hof jump <name> [args...]' is short for 'hof jump run', and 'hof jump' lists the jumps.
+		"""
+
+	Commands: [{
+		TBD:   "α"
+		Name:  "add"
+		Usage: "add <name>"
+		Short: "add or replace a jump"
+		Long: """
+			add or replace a jump, a directory shortcut, commands, or commands run in a directory
+
+			Commands run with bash, in order, stopping at the first which fails.
+			Both may use the fields of the context in use, as in
+
+			  hof jump add api --dir ~/src/api
+			  hof jump add deploy --dir ~/src/api --cmd "make image" --cmd "kubectl --context {{ .Environment }} apply -f k8s/"
+
+			Jumps are added to the workspace, or the global jumps with --global or outside of a workspace.
+			"""
+		Flags: [{
+			Name:    "dir"
+			Type:    "string"
+			Default: ""
+			Help:    "the directory of the jump, relative to the workspace root or home directory"
+			Long:    "dir"
+			Short:   ""
+		}, {
+			Name:    "cmd"
+			Type:    "[]string"
+			Default: "nil"
+			Help:    "a command of the jump, may be repeated"
+			Long:    "cmd"
+			Short:   ""
+		}]
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the jump"
+		}]
+	}, {
+		TBD:   "α"
+		Name:  "list"
+		Usage: "list"
+		Aliases: ["ls"]
+		Short: "list the jumps"
+		Long: """
+			list the workspace and global jumps
+
+			Workspace jumps hide global ones of the same name.
+			Use --output-format (-O) to choose table (default), json, or yaml.
+			"""
+	}, {
+		TBD:   "α"
+		Name:  "rm"
+		Usage: "rm <name>"
+		Aliases: ["remove", "delete"]
+		Short: "remove a jump"
+		Long:  "remove a jump from the workspace, or the global jumps with --global"
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the jump"
+		}]
+	}, {
+		TBD:   "α"
+		Name:  "run"
+		Usage: "run <name> [args...]"
+		Short: "run a jump"
+		Long: """
+			run the commands of a jump, in its directory when it has one
+
+			Args are passed to each command, as $1, $2, and so on.
+			A jump with only a directory prints it, so a shell function can cd there, as in
+
+			  j() { cd "$(hof jump run "$1")"; }
+
+			Fields of the context in use fill in the jump, as in {{ .Project }}.
+			"""
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "name of the jump"
+		}, {
+			Name: "args"
+			Type: "[]string"
+			Rest: true
+			Help: "passed to the commands of the jump"
+		}]
+	}]
 }
 
 #UiCommand: schema.#Command & {
-	TBD:   "Ø"
 	Name:  "ui"
 	Usage: "ui"
 	Short: "Run hof's local web ui"
-	Long:  Short
+	Long: """
+		Run hof's local web ui
+
+		A browser ui for the current directory, to look through
+		the datamodels, the dependency graph of each module,
+		and the latest runs and outputs of hof gen.
+
+		The ui is built on a JSON API, which other tools can use too
+
+		  GET /api/datamodels                 the names of the datamodels
+		  GET /api/modules                    the module of each language
+		  GET /api/modules/<lang>/graph       the resolved dependency graph of a module
+		  GET /api/runs                       the latest runs of hof gen
+		  GET /api/outputs                    the files last generated, by generator
+		  GET /api/outputs/<gen>/<file>       a file as last generated
+
+		The --ui flag serves the ui once any command is done.
+		"""
+
+	Flags: [{
+		Name:    "addr"
+		Type:    "string"
+		Default: "\"localhost:2323\""
+		Help:    "the address to serve the ui on"
+		Long:    "addr"
+		Short:   ""
+	}]
 }
 
 #TuiCommand: schema.#Command & {
-	TBD:   "Ø"
 	Name:  "tui"
 	Usage: "tui"
 	Short: "Run hof's terminal ui"
-	Long:  Short
+	Long: """
+		Run hof's terminal ui
+
+		A dashboard of the current directory, with
+		  - the datamodels, and j/k or the arrows to select one
+		  - the module of each language, and whether it is vendored
+		  - the runtimes
+		  - the latest runs of hof gen
+
+		Keys
+		  d  diff the selected datamodel
+		  m  migrate the selected datamodel
+		  v  vendor the modules
+		  r  refresh
+		  q  quit
+
+		The --tui flag shows the dashboard once any command is done.
+		"""
 }
 
 #ReplCommand: schema.#Command & {
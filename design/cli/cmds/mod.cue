@@ -29,15 +29,47 @@ import (
 
     replace <module path> => <local path>
     ...
+
+  Modules are fetched through the proxies set by $HTTPS_PROXY, $HTTP_PROXY, and $NO_PROXY.
+  Set $HOF_CA_FILE to PEM files of certificate authorities to trust besides the system ones,
+  as for proxies which intercept TLS.
   """
 
 	OmitRun: true
 
-	Imports: #ModCmdImports
+	Pflags: [{
+		Name:    "offline"
+		Long:    "offline"
+		Short:   ""
+		Type:    "bool"
+		Default: "false"
+		Help:    "only use the module cache and vendor dir, also set with HOF_MOD_OFFLINE=1"
+	}]
+
+	Imports: [
+		{Path: "github.com/hofstadter-io/hof/lib/logs", ...},
+		{Path: "github.com/hofstadter-io/hof/lib/mod", ...},
+		{Path: "github.com/hofstadter-io/hof/lib/mod/cache", ...},
+	]
 
 	PersistentPrerun: true
 	PersistentPrerunBody: """
+    // cobra only runs the nearest PersistentPreRun, so not the root one
+    err = logs.Configure(flags.RootVerbosePflag, flags.RootLogFormatPflag, flags.RootQuietPflag)
+    if err != nil {
+      return errs.WithCode(errs.CodeBadFlag, "", err)
+    }
+
     mod.InitLangs()
+    if flags.ModOfflinePflag {
+      cache.SetOffline(true)
+    }
+
+    if flags.RootQuietPflag {
+      cache.SetProgress(nil)
+    } else {
+      cache.SetProgress(cache.StderrProgress(logs.Scope("mod").Enabled(logs.InfoLevel)))
+    }
   """
 	Commands: [{
 		TBD:   "✓"
@@ -63,8 +95,7 @@ import (
 		Body: """
       msg, err := mod.LangInfo(lang)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       fmt.Println(msg)
       """
@@ -96,25 +127,34 @@ import (
 			Body: """
       err = mod.Convert(lang, filename)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		},
 		{
-			TBD:   "Ø"
+			TBD:   "β"
 			Name:  "graph"
-			Usage: "graph"
+			Usage: "graph [langs...]"
 			Short: "print module requirement graph"
-			Long:  Short
+			Long: """
+        print the resolved module requirement graph
 
-			Imports: #ModCmdImports
+        Each requirement is listed along with the version MVS selected
+        for every module and the requirements which selected it.
+        Use --output-format (-O) to choose text (default), dot, json, yaml, or table.
+
+          hof mod graph -O dot | dot -Tsvg > graph.svg
+        """
+
+			Imports: [
+				{Path: "github.com/hofstadter-io/hof/lib/mod", ...},
+				{Path: "github.com/hofstadter-io/hof/cmd/hof/flags", ...},
+			]
 
 			Body: """
-      err = mod.ProcessLangs("graph", args)
+      err = mod.GraphLangs(flags.RootOutputFormatPflag, args)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		},
@@ -130,8 +170,7 @@ import (
 			Body: """
       err = mod.ProcessLangs("status", args)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		},
@@ -162,8 +201,7 @@ import (
 			Body: """
       err = mod.Init(lang, module)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		}, {
@@ -178,8 +216,7 @@ import (
 			Body: """
       err = mod.ProcessLangs("tidy", args)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		}, {
@@ -187,15 +224,33 @@ import (
 			Name:  "vendor"
 			Usage: "vendor [langs...]"
 			Short: "make a vendored copy of dependencies"
-			Long:  Short
+			Long: """
+        Make a vendored copy of the selected dependencies. Modules which are
+        no longer required are removed from the vendor dir and the sumfile.
+
+        With --check, the vendor dir is compared to the sumfile without
+        fetching or writing anything, failing when they differ, for CI.
+      """
+
+			Flags: [{
+				Name:    "check"
+				Type:    "bool"
+				Default: "false"
+				Help:    "verify the vendor dir matches the sumfile without rewriting it"
+				Long:    "check"
+				Short:   ""
+			}]
 
 			Imports: #ModCmdImports
 
 			Body: """
-      err = mod.ProcessLangs("vendor", args)
+      method := "vendor"
+      if flags.VendorFlags.Check {
+        method = "vendor-check"
+      }
+      err = mod.ProcessLangs(method, args)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
       """
 		}, {
@@ -210,9 +265,279 @@ import (
 			Body: """
       err = mod.ProcessLangs("verify", args)
       if err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        return err
       }
+      """
+		}, {
+			TBD:   "β"
+			Name:  "why"
+			Usage: "why <modules...>"
+			Short: "explain why modules are in the dependency graph"
+			Long: """
+        Print the shortest chain of requirements from the root module
+        to each module, through the versions MVS selected.
+      """
+
+			Args: [{
+				Name:     "modules"
+				Type:     "[]string"
+				Required: true
+				Rest:     true
+				Help:     "module paths to explain"
+			}]
+
+			Imports: #ModCmdImports
+
+			Body: """
+      err = mod.Why(modules)
+      """
+		}, {
+			TBD:   "β"
+			Name:  "outdated"
+			Usage: "outdated [langs...]"
+			Short: "list dependencies with newer versions upstream"
+			Long: """
+        List the selected dependencies which have newer tags at their remotes.
+        With --update, the modules required directly by version
+        are bumped to their latest tag in the mod file.
+        Use --output-format (-O) to choose table (default), json, or yaml.
+      """
+
+			Flags: [{
+				Name:    "update"
+				Type:    "bool"
+				Default: "false"
+				Help:    "update direct requires to the latest tags"
+				Long:    "update"
+				Short:   "u"
+			}]
+
+			Imports: #ModCmdImports
+
+			Body: """
+      err = mod.Outdated(flags.OutdatedFlags.Update, args)
+      """
+		}, {
+			TBD:   "β"
+			Name:  "clean"
+			Usage: "clean"
+			Short: "report the module cache size and remove stale entries"
+			Long: """
+        Report the size of the module cache and remove entries from it.
+        Leftovers from interrupted fetches are always removed.
+
+          --cache               remove the whole module cache
+          --older-than 30d      remove modules not used within the age (s, m, h, d, w)
+          --module path[@ver]   remove a module, or one version of it
+          --unused              remove modules the module in the current directory does not depend on
+      """
+
+			Flags: [{
+				Name:    "cache"
+				Type:    "bool"
+				Default: "false"
+				Help:    "remove the whole module cache"
+				Long:    "cache"
+				Short:   ""
+			}, {
+				Name:    "olderThan"
+				Type:    "string"
+				Default: ""
+				Help:    "remove modules not used within the age, as in 30d"
+				Long:    "older-than"
+				Short:   ""
+			}, {
+				Name:    "module"
+				Type:    "string"
+				Default: ""
+				Help:    "remove a module path, or a single path@version"
+				Long:    "module"
+				Short:   ""
+			}, {
+				Name:    "unused"
+				Type:    "bool"
+				Default: "false"
+				Help:    "remove modules the module in the current directory does not depend on"
+				Long:    "unused"
+				Short:   ""
+			}]
+
+			Imports: #ModCmdImports
+
+			Body: """
+      err = mod.Clean(flags.CleanFlags.Cache, flags.CleanFlags.OlderThan, flags.CleanFlags.Module, flags.CleanFlags.Unused)
+      """
+		}, {
+			TBD:   "β"
+			Name:  "cache"
+			Usage: "cache"
+			Short: "work with the module cache"
+			Long:  Short
+
+			OmitRun: true
+
+			Commands: [{
+				TBD:   "β"
+				Name:  "verify"
+				Usage: "verify"
+				Short: "check cached modules have not been damaged"
+				Long: """
+          Check every module in the cache against the hash recorded when it was fetched.
+          Modules from interrupted fetches have no hash and are reported as well.
+          With --repair, damaged modules are removed and fetched again.
+        """
+
+				Flags: [{
+					Name:    "repair"
+					Type:    "bool"
+					Default: "false"
+					Help:    "remove and refetch damaged modules"
+					Long:    "repair"
+					Short:   ""
+				}]
+
+				Imports: #ModCmdImports
+
+				Body: """
+        err = mod.CacheVerify(flags.VerifyFlags.Repair)
+        """
+			}, {
+				TBD:   "β"
+				Name:  "export"
+				Usage: "export <host/repo[:tag]>"
+				Short: "push the module cache to an OCI registry"
+				Long: """
+          Push the modules in the cache to an OCI registry as a single artifact,
+          with one layer per module version. Import it with 'hof mod cache import'
+          to pre-seed the cache in CI or air-gapped environments.
+          Credentials come from the hof auth config, ~/.netrc, or docker login.
+
+            hof mod cache export registry.example.com/team/hof-mods:v1
+            hof mod cache export --module github.com/org/repo registry.example.com/team/repo-mods
+        """
+
+				Args: [{
+					Name:     "ref"
+					Type:     "string"
+					Required: true
+					Help:     "registry repository and tag to push to"
+				}]
+
+				Flags: [{
+					Name:    "module"
+					Type:    "string"
+					Default: ""
+					Help:    "only export a module path, or a single path@version"
+					Long:    "module"
+					Short:   ""
+				}]
+
+				Imports: #ModCmdImports
+
+				Body: """
+        err = mod.CacheExport(ref, flags.ExportFlags.Module)
+        """
+			}, {
+				TBD:   "β"
+				Name:  "import"
+				Usage: "import <host/repo[:tag]>"
+				Short: "fill the module cache from an OCI registry"
+				Long: """
+          Fetch the modules in an artifact made by 'hof mod cache export' into the cache.
+          Modules already in the cache are left alone, and each one is checked
+          against its digest before it is installed.
+
+            hof mod cache import registry.example.com/team/hof-mods:v1
+        """
+
+				Args: [{
+					Name:     "ref"
+					Type:     "string"
+					Required: true
+					Help:     "registry repository and tag to pull from"
+				}]
+
+				Imports: #ModCmdImports
+
+				Body: """
+        err = mod.CacheImport(ref)
+        """
+			}]
+		}, {
+			TBD:   "β"
+			Name:  "publish"
+			Usage: "publish [langs...]"
+			Short: "validate, tag, and push a module release"
+			Long: """
+        Validate the module and release the next version of it.
+        The git worktree must be clean, the module tidy and verified,
+        and the test scripts passing. The version is tagged and pushed,
+        with the subdirectory as a tag prefix for nested modules.
+
+        Without --version, the conventional commits to the module since
+        the last release choose the part to bump: breaking changes are major,
+        except before v1 where they are minor, feat is minor, and others patch.
+
+          --version v1.2.3|major|minor|patch   the version, or the part to bump
+          --release                            create a GitHub release with the module zip
+          --dry-run                            validate and print the version only
+      """
+
+			Flags: [{
+				Name:    "version"
+				Type:    "string"
+				Default: ""
+				Help:    "version to release, or major, minor, or patch to bump"
+				Long:    "version"
+				Short:   ""
+			}, {
+				Name:    "remote"
+				Type:    "string"
+				Default: "\"origin\""
+				Help:    "git remote to push the tag to"
+				Long:    "remote"
+				Short:   ""
+			}, {
+				Name:    "release"
+				Type:    "bool"
+				Default: "false"
+				Help:    "create a GitHub release with the module zip"
+				Long:    "release"
+				Short:   ""
+			}, {
+				Name:    "tests"
+				Type:    "string"
+				Default: "\"testdata/*.txt\""
+				Help:    "glob of test scripts to run"
+				Long:    "tests"
+				Short:   ""
+			}, {
+				Name:    "noTests"
+				Type:    "bool"
+				Default: "false"
+				Help:    "skip running the test scripts"
+				Long:    "no-tests"
+				Short:   ""
+			}, {
+				Name:    "dryRun"
+				Type:    "bool"
+				Default: "false"
+				Help:    "validate and print the next version without tagging"
+				Long:    "dry-run"
+				Short:   ""
+			}]
+
+			Imports: #ModCmdImports
+
+			Body: """
+      err = mod.Publish(args, mod.PublishOptions{
+      	Version: flags.PublishFlags.Version,
+      	Remote:  flags.PublishFlags.Remote,
+      	Release: flags.PublishFlags.Release,
+      	Tests:   flags.PublishFlags.Tests,
+      	NoTests: flags.PublishFlags.NoTests,
+      	DryRun:  flags.PublishFlags.DryRun,
+      })
       """
 		}]
 
@@ -43,7 +43,16 @@ import (
 	Usage: "create"
 	Aliases: ["c"]
 	Short: "create resources"
-	Long:  Short
+	Long: """
+		create resources
+
+		Resources may be given as documents, in cue, json, or yaml, with - for stdin,
+		@file, or @dir for the files of a directory, or with --input, as in
+
+		  hof create @app.cue
+		  hof create -i k8s/
+		  cue export ./k8s | hof create -
+		"""
 }
 
 #GetCommand: schema.#Command & {
@@ -61,7 +70,16 @@ import (
 	Usage: "set"
 	Aliases: ["s"]
 	Short: "find and configure resources"
-	Long:  Short
+	Long: """
+		find and configure resources
+
+		Resources may be given as documents, in cue, json, or yaml, with - for stdin,
+		@file, or @dir for the files of a directory, or with --input, as in
+
+		  hof set @app.cue
+		  hof set -i k8s/
+		  cue export ./k8s | hof set -
+		"""
 }
 
 #EditCommand: schema.#Command & {
@@ -79,5 +97,14 @@ import (
 	Usage: "delete"
 	Aliases: ["del"]
 	Short: "delete resources"
-	Long:  Short
+	Long: """
+		delete resources
+
+		Resources may be given as documents, in cue, json, or yaml, with - for stdin,
+		@file, or @dir for the files of a directory, or with --input, as in
+
+		  hof delete @app.cue
+		  hof delete -i k8s/
+		  cue export ./k8s | hof delete -
+		"""
 }
@@ -5,16 +5,17 @@ import (
 )
 
 #StLong: """
-Structural diff, merge, mask, pick, and query helpers for Cue
+Structural diff, merge, mask, pick, query, and conv helpers for Cue
 
 Commands generally have the form: <method> <op1> <op2> [...entrypoints]
 
 Where <op> can be:
 - Cue: expr: as: string
-- @filename.cue: Cue: expr: as: string
+- @filename.cue, .json, .yaml, .toml, or .xml, or a path into it, as in @filename.cue: a.b.c
 
-If entrypoints are supplied, then an <op> without an @filename.cue will lookup from the entrypoints.
+If entrypoints are supplied, then an <op> without an @filename will lookup a path, as in a.b.c, from the entrypoints.
 Otherwise, the <op> is interpreted as a complete Cue value.
+For merge and pick, the first <op> may be -, as may the files for validate, to process a stream of documents from stdin.
 """
 
 #StCommand: schema.#Command & {
@@ -22,7 +23,7 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 	Name:  "st"
 	Usage: "st"
 	Aliases: ["structural"]
-	Short: "recursive diff, merge, mask, pick, and query helpers for Cue"
+	Short: "recursive diff, merge, mask, pick, query, and conv helpers for Cue"
 	Long:  #StLong
 
 	OmitRun: true
@@ -33,7 +34,31 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 		Usage: "diff <orig> <next> [...entrypoints]"
 		Short: "calculate the difference between two Cue values"
 		Long: """
-		Calculate the difference between two Cue values
+		Calculate the difference between two Cue values, printing the paths
+		which were added, removed, or changed. Structs are compared field by field,
+		while lists and other values are compared whole.
+
+		--format is one of
+		  text   lines of "+ path: value", "- path: value", and "~ path: from -> to"
+		  json   a list of {op, path, from, to}
+		  patch  a JSON Patch (RFC 6902) from <orig> to <next>
+		"""
+
+		Flags: [{
+			Name:    "format"
+			Type:    "string"
+			Default: "\"text\""
+			Help:    "output format, one of text, json, or patch"
+			Long:    "format"
+			Short:   ""
+		}]
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+		]
+
+		Body: """
+		err = structural.RunDiffFromArgs(orig, next, entrypoints, flags.DiffFlags.Format)
 		"""
 
 		Args: [{
@@ -57,7 +82,35 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 		Name:  "merge"
 		Usage: "merge <orig> <update> [...entrypoints]"
 		Short: "merge <new> onto <orig>, replacing values and adding new ones"
-		Long:  Short
+		Long: """
+		Merge <update> onto <orig>, replacing values and adding new ones.
+		Structs are merged recursively, while lists and other values are replaced.
+
+		The result is printed in the format of <orig>, or --output-format (-O),
+		or with --inplace, written back to <orig>, which must then be a whole @file.
+
+		When <orig> is -, <update> is merged onto each document of newline delimited JSON
+		or multi-document Yaml read from stdin, see --input-format (-I), one at a time.
+
+		  tail -f events.json | hof st merge - '{env: "prod"}'
+		"""
+
+		Flags: [{
+			Name:    "inplace"
+			Type:    "bool"
+			Default: "false"
+			Help:    "write the result back to the orig file"
+			Long:    "inplace"
+			Short:   ""
+		}]
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+		]
+
+		Body: """
+		err = structural.RunMergeFromArgs(orig, update, entrypoints, flags.MergeFlags.Inplace, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
+		"""
 
 		Args: [{
 			Name:     "orig"
@@ -80,7 +133,29 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 		Name:  "pick"
 		Usage: "pick <orig> <what> [...entrypoints]"
 		Short: "pick <what> Cue value(s) from <orig>"
-		Long:  Short
+		Long: """
+		Pick the values of <orig> which unify with <what>, keeping the structure around them.
+		<what> is a Cue value shape, as in {a: _, b: c: int}. Lists in <orig> keep the elements
+		which unify with <what>, or element-wise when <what> is a list too.
+
+		Use --output-format (-O) to print cue, json, yaml, or text,
+		defaulting to the format of <orig>.
+
+		When <orig> is -, <what> is picked from each document of newline delimited JSON
+		or multi-document Yaml read from stdin, see --input-format (-I), one at a time.
+
+		  hof st pick @deploy.yaml '{metadata: name: string}' -O json
+		  cat manifests.yaml | hof st pick - '{kind: _, metadata: name: _}' -O json
+		"""
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+			{Path: "github.com/hofstadter-io/hof/cmd/hof/flags", ...},
+		]
+
+		Body: """
+		err = structural.RunPickFromArgs(orig, pick, entrypoints, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
+		"""
 
 		Args: [{
 			Name:     "orig"
@@ -126,7 +201,26 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 		Name:  "query"
 		Usage: "query <orig> <expr> [...entrypoints]"
 		Short: "query for values matching an expr and/or attributes"
-		Long:  Short
+		Long: """
+		Query for the values of <orig> at the paths matching <expr>.
+		<expr> is a dot separated path, where * matches every field or list element,
+		and numbers index lists, as in services.*.ports.0
+
+		Matches are printed as a list, or with --output-format (-O) text,
+		one per line, with strings unquoted for use in shell pipelines.
+		The format is one of cue, json, yaml, or text, defaulting to the format of <orig>.
+
+		  hof st query @compose.yaml 'services.*.image' -O text | sort -u
+		"""
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+			{Path: "github.com/hofstadter-io/hof/cmd/hof/flags", ...},
+		]
+
+		Body: """
+		err = structural.RunQueryFromArgs(orig, expr, entrypoints, flags.RootOutputFormatPflag)
+		"""
 
 		Args: [{
 			Name:     "orig"
@@ -144,5 +238,119 @@ Otherwise, the <op> is interpreted as a complete Cue value.
 			Rest: true
 			Help: "Cue entrypoints"
 		}]
+	}, {
+		TBD:   "α"
+		Name:  "validate"
+		Usage: "validate <schema> <files...>"
+		Short: "validate data files against a Cue, JSON Schema, or OpenAPI schema"
+		Long: """
+		Validate data files against <schema>, which they must unify with and then be concrete.
+		Each problem is printed with its location, as file:line:col: path: message,
+		or with --output-format (-O) json, as a list of {file, path, line, column, message}.
+
+		<schema> is an <op>, see 'hof st --help', of one of the --schema-type
+		  cue         any Cue value, usually a definition, as in @schema.cue:#Config
+		  jsonschema  a JSON Schema file, as in @schema.json
+		  openapi     a component of an OpenAPI file, as in @openapi.yaml:Pet
+		which is guessed from the file when not set. Cue entrypoints for <schema>
+		to lookup paths in are given with --entrypoints (-e).
+
+		When the file is -, each document of newline delimited JSON or multi-document Yaml
+		read from stdin, see --input-format (-I), is validated one at a time.
+		Valid documents are written to stdout, in --output-format (-O) or as read,
+		and invalid ones are reported on stderr by their number, so the command
+		filters a stream while it checks it.
+
+		  hof st validate @schema.cue:#Config config/*.yaml
+		  cat events.json | hof st validate @openapi.yaml:Event - > valid.json
+		"""
+
+		Flags: [{
+			Name:    "schemaType"
+			Type:    "string"
+			Default: ""
+			Help:    "type of the schema, one of cue, jsonschema, or openapi, guessed by default"
+			Long:    "schema-type"
+			Short:   ""
+		}, {
+			Name:    "entrypoints"
+			Type:    "[]string"
+			Default: "nil"
+			Help:    "Cue entrypoints to lookup the schema in"
+			Long:    "entrypoints"
+			Short:   "e"
+		}]
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+		]
+
+		Body: """
+		err = structural.RunValidateFromArgs(schema, files, flags.ValidateFlags.Entrypoints, flags.ValidateFlags.SchemaType, flags.RootInputFormatPflag, flags.RootOutputFormatPflag)
+		"""
+
+		Args: [{
+			Name:     "schema"
+			Type:     "string"
+			Required: true
+			Help:     "schema to validate against, see 'hof st --help' for format"
+		}, {
+			Name:     "files"
+			Type:     "[]string"
+			Required: true
+			Rest:     true
+			Help:     "data files to validate, or - for a stream on stdin"
+		}]
+	}, {
+		TBD:   "α"
+		Name:  "conv"
+		Usage: "conv <in> [...entrypoints]"
+		Short: "convert between cue, json, yaml, toml, and xml, optionally with a schema"
+		Long: """
+		Convert <in> to another encoding, one of cue, json, yaml, toml, or xml, with --to.
+		<in> is read by its extension, see 'hof st --help', and Cue keeps its comments.
+
+		With --schema, <in> is unified with the schema first, filling in defaults
+		and checking it, so that it must be concrete unless converting to Cue.
+		The schema is an <op> too, as in @schema.cue: #Config
+
+		  hof st conv @config.yaml --to toml --schema @schema.cue:#Config
+		"""
+
+		Flags: [{
+			Name:    "to"
+			Type:    "string"
+			Default: "\"cue\""
+			Help:    "encoding to convert to, one of cue, json, yaml, toml, or xml"
+			Long:    "to"
+			Short:   ""
+		}, {
+			Name:    "schema"
+			Type:    "string"
+			Default: ""
+			Help:    "schema to unify with and check against before converting, see 'hof st --help' for format"
+			Long:    "schema"
+			Short:   ""
+		}]
+
+		Imports: [
+			{Path: "github.com/hofstadter-io/hof/lib/structural", ...},
+		]
+
+		Body: """
+		err = structural.RunConvFromArgs(in, entrypoints, flags.ConvFlags.To, flags.ConvFlags.Schema)
+		"""
+
+		Args: [{
+			Name:     "in"
+			Type:     "string"
+			Required: true
+			Help:     "value to convert, see 'hof st --help' for format"
+		}, {
+			Name: "entrypoints"
+			Type: "[]string"
+			Rest: true
+			Help: "Cue entrypoints"
+		}]
 	}]
 }
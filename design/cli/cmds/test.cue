@@ -22,6 +22,15 @@ import (
 			Short:   ""
 			...
 		},
+		{
+			Name:    "lint"
+			Type:    "bool"
+			Default: "false"
+			Help:    "check hls scripts for problems without running them"
+			Long:    "lint"
+			Short:   ""
+			...
+		},
 		{
 			Name:    "keep"
 			Type:    "bool"
@@ -60,6 +69,35 @@ import (
 		},
 	]
 
+	Commands: [{
+		TBD:   "α"
+		Name:  "record"
+		Usage: "record <name> [dir]"
+		Short: "record a shell session as a script"
+		Long: """
+      Record a shell session as an hls script. Commands are run in a fresh
+      work directory, seeded with the files in dir when given, and replayed
+      by the script with checks for their output, status, and the files
+      they touched. End the session with exit or ^D.
+      """
+
+		Args: [{
+			Name:     "name"
+			Type:     "string"
+			Required: true
+			Help:     "script to write, .hls is added when there is no extension"
+		}, {
+			Name: "dir"
+			Type: "string"
+			Help: "directory to seed the work directory from"
+		}]
+
+		Imports: [{Path: "github.com/hofstadter-io/hof/lib/test"}]
+
+		Body: """
+      err = test.Record(name, dir, os.Stdin, os.Stdout)
+      """
+	}]
 }
 
 #TestCommandHelp: #"""
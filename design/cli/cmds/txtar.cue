@@ -0,0 +1,89 @@
+package cmds
+
+import (
+	"github.com/hofstadter-io/hofmod-cli/schema"
+)
+
+#TxtarCmdImports: [
+	{Path: "github.com/hofstadter-io/hof/lib/gotils/txtar", ...},
+]
+
+#TxtarCommand: schema.#Command & {
+	TBD:   "α"
+	Name:  "txtar"
+	Usage: "txtar"
+	Short: "pack and unpack txtar archives, the format of hof test scripts"
+	Long: """
+  Pack a directory tree into a txtar archive, or unpack one back out.
+
+  Binary files are base64 encoded, executables and symlinks are preserved,
+  and files containing txtar markers are quoted with an unquote line added.
+  The archive comment, the script of an .hls file, is kept in README.hls.
+  """
+
+	OmitRun: true
+
+	Commands: [{
+		TBD:   "α"
+		Name:  "pack"
+		Usage: "pack <dir> [archive]"
+		Short: "build a txtar archive from a directory tree"
+		Long:  "build a txtar archive from a directory tree, printing it when no archive file is given"
+
+		Args: [{
+			Name:     "dir"
+			Type:     "string"
+			Required: true
+			Help:     "directory to archive"
+		}, {
+			Name: "archive"
+			Type: "string"
+			Help: "file to write the archive to"
+		}]
+
+		Imports: #TxtarCmdImports + [{Path: "io/ioutil"}]
+
+		Body: """
+      a, err := txtar.Pack(dir, txtar.PackOptions{})
+      if err != nil {
+        return err
+      }
+      data := txtar.Format(a)
+      if archive == "" {
+        _, err = os.Stdout.Write(data)
+        return err
+      }
+      err = ioutil.WriteFile(archive, data, 0666)
+      """
+	}, {
+		TBD:   "α"
+		Name:  "unpack"
+		Usage: "unpack <archive> [dir]"
+		Short: "extract a txtar archive into a directory"
+		Long:  "extract a txtar archive into a directory, the current one by default"
+
+		Args: [{
+			Name:     "archive"
+			Type:     "string"
+			Required: true
+			Help:     "txtar archive to extract"
+		}, {
+			Name: "dir"
+			Type: "string"
+			Help: "directory to extract into"
+		}]
+
+		Imports: #TxtarCmdImports
+
+		Body: """
+      a, err := txtar.ParseFile(archive)
+      if err != nil {
+        return err
+      }
+      if dir == "" {
+        dir = "."
+      }
+      err = txtar.Unpack(a, dir, txtar.PackOptions{})
+      """
+	}]
+}
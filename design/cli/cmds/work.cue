@@ -0,0 +1,56 @@
+package cmds
+
+import (
+	"github.com/hofstadter-io/hofmod-cli/schema"
+)
+
+#WorkCommand: schema.#Command & {
+	TBD:   "α"
+	Name:  "work"
+	Usage: "work"
+	Short: "manage workspaces of local modules which are resolved together"
+	Long: """
+  A hof.work file lists the directories of local modules, like go.work.
+  Within it, or any directory below, 'hof mod tidy', 'vendor', and 'graph'
+  run for every module of the workspace. Requirements between the modules
+  are replaced by their directories, and all of the modules use the same
+  version of each of their other dependencies, the greatest any of them selects.
+
+  workspace file format:
+
+    use (
+      ./apis
+      ./web
+    )
+
+  Set HOF_WORK=off to work with a single module, or to the path of a workspace file.
+  """
+
+	OmitRun: true
+
+	Commands: [{
+		TBD:   "α"
+		Name:  "init"
+		Usage: "init [dirs...]"
+		Short: "create a hof.work file in the current directory using the module dirs"
+		Long:  Short
+
+		Imports: #ModCmdImports
+
+		Body: """
+      err = mod.WorkInit(args)
+      """
+	}, {
+		TBD:   "α"
+		Name:  "use"
+		Usage: "use <dirs...>"
+		Short: "add module dirs to the nearest hof.work file"
+		Long:  Short
+
+		Imports: #ModCmdImports
+
+		Body: """
+      err = mod.WorkUse(args)
+      """
+	}]
+}
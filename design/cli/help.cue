@@ -39,6 +39,7 @@ Learn more about hof and the _ you can do:
 
 Download modules, add instances or content, and manage runtimes:
   \(cmds.#ModCommand.Help)
+  \(cmds.#WorkCommand.Help)
   \(cmds.#AddCommand.Help)
   \(cmds.#RuntimesCommand.Help)
 
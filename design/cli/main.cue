@@ -54,6 +54,7 @@ import (
 
 		// hof + cue
 		cmds.#ModCommand,
+		cmds.#WorkCommand,
 		cmds.#AddCommand,
 		cmds.#CmdCommand, // Cue's cmd, but processed by hof
 
@@ -74,6 +75,7 @@ import (
 		cmds.#TrimCommand,
 		cmds.#VetCommand,
 		cmds.#StCommand,
+		cmds.#TxtarCommand,
 
 		// base
 		cmds.#AuthCommand,
@@ -30,7 +30,7 @@ import (
 		Short:   ""
 		Type:    "string"
 		Default: ""
-		Help:    "The path to a hof secret file"
+		Help:    "The path to an age encrypted hof secret file, or a secret provider reference"
 	},
 	{
 		Name:    "contextFile"
@@ -46,7 +46,7 @@ import (
 		Short:   ""
 		Type:    "string"
 		Default: ""
-		Help:    "The of an entry in the context file"
+		Help:    "The name of an entry in the context file"
 	},
 	{
 		Name:    "global"
@@ -72,7 +72,7 @@ import (
 		Short:   "i"
 		Type:    "[]string"
 		Default: "nil"
-		Help:    "input streams, depending on the command context"
+		Help:    "inputs for resource commands, - for stdin, files, or directories"
 	},
 	{
 		Name:    "inputFormat"
@@ -80,7 +80,7 @@ import (
 		Short:   "I"
 		Type:    "string"
 		Default: ""
-		Help:    "input format, defaults to infered"
+		Help:    "input format, cue, json, or yaml, defaults to infered"
 	},
 
 	{
@@ -97,7 +97,7 @@ import (
 		Short:   "O"
 		Type:    "string"
 		Default: ""
-		Help:    "output format, defaults to cue"
+		Help:    "output format, json, yaml, table, or text, defaults depend on the command"
 	},
 
 	{
@@ -106,7 +106,7 @@ import (
 		Short:   ""
 		Type:    "[]string"
 		Default: "nil"
-		Help:    "error streams, depending on the command context"
+		Help:    "error destinations, stdout, stderr, or files, defaults to stdout"
 	},
 	{
 		Name:    "errorFormat"
@@ -114,7 +114,7 @@ import (
 		Short:   ""
 		Type:    "string"
 		Default: ""
-		Help:    "error format, defaults to cue"
+		Help:    "error format, text or json, defaults to text"
 	},
 
 	// context should encapsulate the next three
@@ -230,7 +230,7 @@ import (
 		Short:   "v"
 		Type:    "string"
 		Default: ""
-		Help:    "set the verbosity of output"
+		Help:    "set the log level, as in debug or info,mod=debug"
 	},
 	{
 		Name:    "quiet"
@@ -240,6 +240,14 @@ import (
 		Default: ""
 		Help:    "turn off output and assume defaults at prompts"
 	},
+	{
+		Name:    "LogFormat"
+		Long:    "log-format"
+		Short:   ""
+		Type:    "string"
+		Default: ""
+		Help:    "log format, console or json, defaults to console"
+	},
 	{
 		Name:    "ImpersonateAccount"
 		Long:    "impersonate-account"
@@ -270,7 +278,7 @@ import (
 		Short:   ""
 		Type:    "bool"
 		Default: "false"
-		Help:    "run the command from the web ui"
+		Help:    "serve the web ui once the command is done"
 	},
 	{
 		Name:    "RunTUI"
@@ -278,6 +286,6 @@ import (
 		Short:   ""
 		Type:    "bool"
 		Default: "false"
-		Help:    "run the command from the terminal ui"
+		Help:    "show the terminal ui dashboard once the command is done"
 	},
 ]
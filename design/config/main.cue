@@ -56,13 +56,16 @@ import (
 	ConfigSchema: #SecretSchema
 }
 
+// ~/.hof/contexts.cue, or --context-file, much like a kubeconfig
 #ContextSchema: {
-	Current?: #ContextItemSchema
-	Contexts?: [ContextName=string]: #ContextItemSchema & {name: ContextName}
+	// name of the context in use
+	Current?: string
+	Contexts?: [ContextName=string]: #ContextItemSchema & {Name: ContextName}
 }
 
 #ContextItemSchema: {
-	Name:         string
+	Name: string
+	// a reference, such as a secret name, not the credentials themselves
 	Credentials?: string
 	Environment?: string
 	Account?:     string
@@ -89,9 +92,17 @@ import (
 	// ... rethinking having multiple workspaces per repo, doesn't fit with the latest UX (in particular workspace/workflow integration)
 	Workspaces?: [WorkspaceName=string]:     #WorkspaceSchema & {name:   WorkspaceName}
 
+	aliases?: #AliasesSchema
+
 	...
 }
 
+// Commands which run hof command lines, with $1, $2, ... and $@ for their args
+#AliasesSchema: [Name=string]: string | {
+	cmd:   string
+	help?: string
+}
+
 // Workspace specfic config
 #WorkspaceSchema: {
 	Name: string | *""
@@ -99,4 +110,6 @@ import (
 
 	ModelsDir: string | *"models"
 	ResourcesDir: string | *"resources"
+
+	aliases?: #AliasesSchema
 }
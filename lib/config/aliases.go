@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+)
+
+// Alias is a shortcut for a hof command line, from the aliases section of hof's config, as in
+//
+//   aliases: {
+//     "gen-api": "gen ./design/api.cue -t api -o $1"
+//     "mod-up": {
+//       cmd:  "mod outdated --update $@"
+//       help: "update the direct requires"
+//     }
+//   }
+//
+// $1, $2, and so on are the args of the alias, and $@ all of them.
+// The args are appended when the command line has no placeholders.
+type Alias struct {
+	Name string `json:"-"`
+	Cmd  string `json:"cmd"`
+	Help string `json:"help,omitempty"`
+}
+
+// LoadAliases returns the aliases of the resolved config, sorted by name, see ConfigLayers.
+// The file given with --config is not used, as aliases are loaded before flags are parsed.
+func LoadAliases() ([]Alias, error) {
+	layers, err := ConfigLayers()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, L := range layers {
+		found = found || L.Exists
+	}
+	if !found {
+		return nil, nil
+	}
+	val, err := ResolveConfig(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	val = val.Lookup("aliases")
+	if !val.Exists() {
+		return nil, nil
+	}
+	S, err := val.Struct()
+	if err != nil {
+		return nil, fmt.Errorf("While reading the aliases in hof's config\n%w\n", err)
+	}
+
+	var aliases []Alias
+	iter := S.Fields()
+	for iter.Next() {
+		A := Alias{Name: iter.Label()}
+		v := iter.Value()
+		if v.Kind() == cue.StringKind {
+			A.Cmd, err = v.String()
+		} else {
+			err = v.Decode(&A)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("While reading the alias %s, it should be a command line or {cmd, help}\n%w\n", A.Name, err)
+		}
+		if strings.TrimSpace(A.Cmd) == "" {
+			return nil, fmt.Errorf("the alias %s has no command line", A.Name)
+		}
+		aliases = append(aliases, A)
+	}
+
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases, nil
+}
+
+// Expand returns the args for hof which the alias runs with args.
+func (A Alias) Expand(args []string) ([]string, error) {
+	words, err := splitCmdLine(A.Cmd)
+	if err != nil {
+		return nil, fmt.Errorf("While reading the alias %s\n%w\n", A.Name, err)
+	}
+	if len(words) > 0 && words[0] == "hof" {
+		words = words[1:]
+	}
+
+	used := false
+	var out []string
+	for _, w := range words {
+		if w == "$@" {
+			out = append(out, args...)
+			used = true
+			continue
+		}
+
+		var b strings.Builder
+		for i := 0; i < len(w); i++ {
+			// $N, with one or more digits
+			j := i + 1
+			for j < len(w) && w[j] >= '0' && w[j] <= '9' {
+				j++
+			}
+			if w[i] != '$' || j == i+1 {
+				b.WriteByte(w[i])
+				continue
+			}
+			n, _ := strconv.Atoi(w[i+1 : j])
+			if n < 1 || n > len(args) {
+				return nil, fmt.Errorf("the alias %s uses $%d, but was given %d args", A.Name, n, len(args))
+			}
+			b.WriteString(args[n-1])
+			used = true
+			i = j - 1
+		}
+		out = append(out, b.String())
+	}
+
+	if !used {
+		out = append(out, args...)
+	}
+	return out, nil
+}
+
+// splitCmdLine splits line into words as a shell would, with single and double quotes
+// and backslash escapes outside of them, but without expanding anything.
+func splitCmdLine(line string) ([]string, error) {
+	var words []string
+	var b strings.Builder
+	inWord := false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case quote == '\\':
+			b.WriteRune(r)
+			quote = 0
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"' || r == '\\':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+		default:
+			b.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c in %q", quote, line)
+	}
+	if inWord {
+		words = append(words, b.String())
+	}
+	return words, nil
+}
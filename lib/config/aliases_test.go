@@ -0,0 +1,80 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadAliases(t *testing.T) {
+	dir := inTempDir(t)
+
+	aliases, err := LoadAliases()
+	if err != nil || aliases != nil {
+		t.Fatalf("expected no aliases without config, got %v, %v", aliases, err)
+	}
+
+	writeFile(t, filepath.Join(dir, ".hofcfg.cue"), `
+greeting: en: "hello"
+aliases: {
+	greet: "config get greeting.$1"
+	show: {
+		cmd:  "config get"
+		help: "print a config value"
+	}
+}
+`)
+	aliases, err = LoadAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Alias{
+		{Name: "greet", Cmd: "config get greeting.$1"},
+		{Name: "show", Cmd: "config get", Help: "print a config value"},
+	}
+	if !reflect.DeepEqual(aliases, expect) {
+		t.Fatalf("got %v, want %v", aliases, expect)
+	}
+
+	writeFile(t, filepath.Join(dir, ".hofcfg.cue"), `aliases: broken: cmd: "  "`)
+	_, err = LoadAliases()
+	if err == nil || !strings.Contains(err.Error(), "the alias broken has no command line") {
+		t.Fatalf("expected an alias without a command line to fail, got %v", err)
+	}
+}
+
+func TestAliasExpand(t *testing.T) {
+	tests := []struct {
+		cmd    string
+		args   []string
+		expect []string
+		err    string
+	}{
+		{cmd: "config get greeting.$1", args: []string{"en"}, expect: []string{"config", "get", "greeting.en"}},
+		{cmd: "config get", args: []string{"greeting.fr"}, expect: []string{"config", "get", "greeting.fr"}},
+		{cmd: "hof gen $1 -o $2", args: []string{"a.cue", "out"}, expect: []string{"gen", "a.cue", "-o", "out"}},
+		{cmd: "mod outdated --update $@", args: []string{"x", "y"}, expect: []string{"mod", "outdated", "--update", "x", "y"}},
+		{cmd: `st get 'a b' "c d" e\ f`, expect: []string{"st", "get", "a b", "c d", "e f"}},
+		{cmd: "gen $12", args: []string{"1"}, err: "uses $12, but was given 1 args"},
+		{cmd: "gen 'open", err: "unterminated '"},
+	}
+
+	for _, tt := range tests {
+		A := Alias{Name: "test", Cmd: tt.cmd}
+		got, err := A.Expand(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.cmd, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s: got %q, want %q", tt.cmd, got, tt.expect)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"cuelang.org/go/cue"
@@ -52,19 +51,18 @@ func NewRuntime() *Runtime {
 // We can safely ignore errors here. If the file exists, cue errors will be printed, otherwise up to the user
 func (R *Runtime) Init() (err error) {
 	// These are used to track if we found a file or not
-	contextFound, configFound, secretFound := false, false, false
+	// Secrets are encrypted, so they are only loaded when used, see SecretGet
+	contextFound, configFound := false, false
 
-	// First check config/secret flags, non-existance should err as user specified a flag
+	// First check context/config flags, non-existance should err as user specified a flag
 	//  if they exist, we load into local because we prefer that later
-	if flags.RootContextPflag != "" {
-		val, err := cuefig.LoadContextConfig("", flags.RootContextPflag)
+	if flags.RootContextPflag != "" || flags.RootContextFilePflag != "" {
+		err := R.initContext()
 		if err != nil {
-			// Return early if they specify a file and we don't find it
+			// Return early if they specify a context and we don't find it
 			return err
 		}
 		contextFound = true
-		R.ContextValue = val
-		R.ContextType = "custom-context"
 	}
 	if flags.RootConfigPflag != "" {
 		val, err := cuefig.LoadConfigConfig("", flags.RootConfigPflag)
@@ -76,27 +74,7 @@ func (R *Runtime) Init() (err error) {
 		R.ConfigValue = val
 		R.ConfigType = "custom-config"
 	}
-	if flags.RootSecretPflag != "" {
-		val, err := cuefig.LoadSecretConfig("", flags.RootSecretPflag)
-		if err != nil {
-			// Return early if they specify a file and we don't find it
-			return err
-		}
-		secretFound = true
-		R.SecretValue = val
-		R.SecretType = "custom-secret"
-	}
-
-	// Second, look for local config/secret
-	if !contextFound {
-		val, err := cuefig.LoadContextDefault()
-		// NOTE, we are doing the opposite of normal err checks here
-		if err == nil {
-			configFound = true
-			R.ContextValue = val
-			R.ContextType = "local-context"
-		}
-	}
+	// Second, look for local config
 	if !configFound {
 		val, err := cuefig.LoadConfigDefault()
 		// NOTE, we are doing the opposite of normal err checks here
@@ -106,25 +84,10 @@ func (R *Runtime) Init() (err error) {
 			R.ConfigType = "local-config"
 		}
 	}
-	if !secretFound {
-		val, err := cuefig.LoadSecretDefault()
-		// NOTE, we are doing the opposite of normal err checks here
-		if err == nil {
-			secretFound = true
-			R.SecretValue = val
-			R.SecretType = "local-secret"
-		}
-	}
-
-	// Finally, check for global config/secret
+	// Finally, check for global context/config
 	if !contextFound {
-		val, err := cuefig.LoadHofctxDefault()
-		// NOTE, we are doing the opposite of normal err checks here
-		if err == nil {
-			contextFound = true
-			R.ContextValue = val
-			R.ContextType = "global-context"
-		}
+		// NOTE, a broken context file is reported when it is used
+		R.initContext()
 	}
 	if !configFound {
 		val, err := cuefig.LoadHofcfgDefault()
@@ -135,19 +98,28 @@ func (R *Runtime) Init() (err error) {
 			R.ConfigType = "global-config"
 		}
 	}
-	if !secretFound {
-		val, err := cuefig.LoadHofshhDefault()
-		// NOTE, we are doing the opposite of normal err checks here
-		if err == nil {
-			secretFound = true
-			R.SecretValue = val
-			R.SecretType = "global-secret"
-		}
-	}
-
 	return err
 }
 
+// initContext sets the context in use, see ContextFile.Selected,
+// with ContextType being its name.
+func (R *Runtime) initContext() error {
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	name, err := F.Selected()
+	if err != nil || name == "" {
+		return err
+	}
+	val, err := contextValue(F.Contexts[name])
+	if err != nil {
+		return err
+	}
+	R.ContextValue, R.ContextType = val, name
+	return nil
+}
+
 func (R *Runtime) PrintConfig() error {
 	// Get top level struct from cuelang
 	S, err := R.ConfigValue.Struct()
@@ -204,74 +176,29 @@ func (R *Runtime) PrintSecret() error {
 	return nil
 }
 
-func (R *Runtime) ContextGet(path string) (cue.Value, error) {
-	var orig cue.Value
-	var err error
-	if flags.RootContextPflag != "" {
-		orig, err = cuefig.LoadContextConfig("", flags.RootContextPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadContextConfig("", cuefig.ContextEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofctxDefault()
-	} else {
-		orig, err = cuefig.LoadContextDefault()
-	}
-
-	// now check for error
-	if err != nil {
-		return orig, err
-	}
-
-	if path == "" {
-		return orig, nil
-	}
-	paths := strings.Split(path, ".")
-	val := orig.Lookup(paths...)
-	return val, nil
-}
-
+// ConfigGet returns the value at path, or the whole config when it is empty,
+// from the layer chosen by SelectLayer, or from all of them resolved.
 func (R *Runtime) ConfigGet(path string) (cue.Value, error) {
 	var orig cue.Value
-	var err error
-	if flags.RootConfigPflag != "" {
-		orig, err = cuefig.LoadConfigConfig("", flags.RootConfigPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadConfigConfig("", cuefig.ConfigEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofcfgDefault()
-	} else {
-		orig, err = cuefig.LoadConfigDefault()
-	}
-
-	// now check for error
+	layers, err := ConfigLayers()
 	if err != nil {
 		return orig, err
 	}
 
-	if path == "" {
-		return orig, nil
-	}
-	paths := strings.Split(path, ".")
-	val := orig.Lookup(paths...)
-	return val, nil
-}
-
-func (R *Runtime) SecretGet(path string) (cue.Value, error) {
-	var orig cue.Value
-	var err error
-	if flags.RootSecretPflag != "" {
-		orig, err = cuefig.LoadSecretConfig("", flags.RootSecretPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadSecretConfig("", cuefig.SecretEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofshhDefault()
+	if name := SelectLayer(); name != "" {
+		L, err := findLayer(layers, name)
+		if err != nil {
+			return orig, err
+		}
+		if !L.Exists {
+			return orig, fmt.Errorf("no %s config found at %s", L.Name, L.File)
+		}
+		orig = L.Value
 	} else {
-		orig, err = cuefig.LoadSecretDefault()
-	}
-
-	// now check for error
-	if err != nil {
-		return orig, err
+		orig, err = ResolveConfig(layers)
+		if err != nil {
+			return orig, err
+		}
 	}
 
 	if path == "" {
@@ -279,164 +206,42 @@ func (R *Runtime) SecretGet(path string) (cue.Value, error) {
 	}
 	paths := strings.Split(path, ".")
 	val := orig.Lookup(paths...)
+	if !val.Exists() {
+		return val, fmt.Errorf("%s not found in config", path)
+	}
 	return val, nil
 }
 
-func (R *Runtime) ContextSet(expr string) (error) {
-	var orig cue.Value
-	var val cue.Value
-	var err error
-
-	// Check which config we want to work with
-	if flags.RootContextPflag != "" {
-		orig, err = cuefig.LoadContextConfig("", flags.RootContextPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadContextConfig("", cuefig.ContextEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofctxDefault()
-	} else {
-		orig, err = cuefig.LoadContextDefault()
-	}
-
-	// now check for error from that config selection process
-	if err != nil {
-		if _, ok := err.(*os.PathError); !ok && (strings.Contains(err.Error(), "file does not exist") || strings.Contains(err.Error(), "no such file")) {
-			// error is worse than non-existant
-			return err
-		}
-		// file does not exist, so we should just set
-		var r cue.Runtime
-		inst, err := r.Compile("", expr)
-		if err != nil {
-			return err
-		}
-		val = inst.Value()
-		if val.Err() != nil {
-			return val.Err()
-		}
 
-	} else {
-		val, err = structural.Merge(orig, expr)
-		if err != nil {
-			return err
-		}
-	}
 
-	// Now save
-	if flags.RootContextPflag != "" {
-		err = cuefig.SaveContextConfig("", flags.RootContextPflag, val)
-	} else if flags.RootLocalPflag {
-		err = cuefig.SaveContextConfig("", cuefig.ContextEntrypoint, val)
-	} else if flags.RootGlobalPflag {
-		err = cuefig.SaveHofctxDefault(val)
-	} else {
-		err = cuefig.SaveContextDefault(val)
+// ConfigSet merges the Cue expr into the layer chosen by SelectLayer,
+// by default the .hofcfg.cue in the current directory.
+func (R *Runtime) ConfigSet(expr string) error {
+	layers, err := ConfigLayers()
+	if err != nil {
+		return err
 	}
-	return err
-}
 
-func (R *Runtime) ConfigSet(expr string) (error) {
-	var orig cue.Value
-	var val cue.Value
-	var err error
-
-	// Check which config we want to work with
-	if flags.RootConfigPflag != "" {
-		orig, err = cuefig.LoadConfigConfig("", flags.RootConfigPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadConfigConfig("", cuefig.ConfigEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofcfgDefault()
-	} else {
-		orig, err = cuefig.LoadConfigDefault()
+	name := SelectLayer()
+	if name == "" {
+		name = LayerLocal
 	}
-
-	// now check for error from that config selection process
+	L, err := findLayer(layers, name)
 	if err != nil {
-		if _, ok := err.(*os.PathError); !ok && (strings.Contains(err.Error(), "file does not exist") || strings.Contains(err.Error(), "no such file")) {
-			// error is worse than non-existant
-			return err
-		}
-		// file does not exist, so we should just set
-		var r cue.Runtime
-		inst, err := r.Compile("", expr)
-		if err != nil {
-			return err
-		}
-		val = inst.Value()
-		if val.Err() != nil {
-			return val.Err()
-		}
-
-	} else {
-		val, err = structural.Merge(orig, expr)
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
-	// Now save
-	if flags.RootConfigPflag != "" {
-		err = cuefig.SaveConfigConfig("", flags.RootConfigPflag, val)
-	} else if flags.RootLocalPflag {
-		err = cuefig.SaveConfigConfig("", cuefig.ConfigEntrypoint, val)
-	} else if flags.RootGlobalPflag {
-		err = cuefig.SaveHofcfgDefault(val)
-	} else {
-		err = cuefig.SaveConfigDefault(val)
-	}
-	return err
-}
-
-func (R *Runtime) SecretSet(expr string) (error) {
-	var orig cue.Value
 	var val cue.Value
-	var err error
-
-	// Check which config we want to work with
-	if flags.RootSecretPflag != "" {
-		orig, err = cuefig.LoadSecretConfig("", flags.RootSecretPflag)
-	} else if flags.RootLocalPflag {
-		orig, err = cuefig.LoadSecretConfig("", cuefig.SecretEntrypoint)
-	} else if flags.RootGlobalPflag {
-		orig, err = cuefig.LoadHofshhDefault()
+	if L.Exists {
+		val, err = structural.Merge(L.Value, expr)
 	} else {
-		orig, err = cuefig.LoadSecretDefault()
+		// file does not exist, so we should just set
+		val, err = structural.Merge("{}", expr)
 	}
-
-	// now check for error from that config selection process
 	if err != nil {
-		if _, ok := err.(*os.PathError); !ok && (strings.Contains(err.Error(), "file does not exist") || strings.Contains(err.Error(), "no such file")) {
-			// error is worse than non-existant
-			return err
-		}
-		// file does not exist, so we should just set
-		var r cue.Runtime
-		inst, err := r.Compile("", expr)
-		if err != nil {
-			return err
-		}
-		val = inst.Value()
-		if val.Err() != nil {
-			return val.Err()
-		}
-
-	} else {
-		val, err = structural.Merge(orig, expr)
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
-	// Now save
-	if flags.RootSecretPflag != "" {
-		err = cuefig.SaveSecretConfig("", flags.RootSecretPflag, val)
-	} else if flags.RootLocalPflag {
-		err = cuefig.SaveSecretConfig("", cuefig.SecretEntrypoint, val)
-	} else if flags.RootGlobalPflag {
-		err = cuefig.SaveHofshhDefault(val)
-	} else {
-		err = cuefig.SaveSecretDefault(val)
-	}
-	return err
+	return L.Save(val)
 }
+
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	cuejson "cuelang.org/go/encoding/json"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/gen/cuefig"
+	"github.com/hofstadter-io/hof/lib/printer"
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+const GlobalContextFile = ".hof/contexts.cue"
+
+// Context is a named set of references to the account, project, credentials,
+// and environment hof works with, see #ContextItemSchema.
+// Other fields are kept as they are.
+type Context map[string]interface{}
+
+// Field returns a string field of the context, or "" when it is not set.
+func (C Context) Field(name string) string {
+	s, _ := C[name].(string)
+	return s
+}
+
+// ContextFile holds the named contexts and which one is current, much like a kubeconfig.
+// It is ~/.hof/contexts.cue, or the file given with --context-file.
+type ContextFile struct {
+	File     string             `json:"-"`
+	Current  string             `json:"Current,omitempty"`
+	Contexts map[string]Context `json:"Contexts,omitempty"`
+}
+
+// ContextFilePath returns the path of the context file, whether or not it exists.
+func ContextFilePath() (string, error) {
+	if flags.RootContextFilePflag != "" {
+		return filepath.Abs(flags.RootContextFilePflag)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, GlobalContextFile), nil
+}
+
+// LoadContextFile reads the context file, which is empty when it does not exist yet.
+func LoadContextFile() (*ContextFile, error) {
+	file, err := ContextFilePath()
+	if err != nil {
+		return nil, err
+	}
+	F := &ContextFile{File: file, Contexts: map[string]Context{}}
+
+	_, err = os.Stat(file)
+	if os.IsNotExist(err) {
+		return F, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := cuefig.LoadContextConfig(filepath.Dir(file), filepath.Base(file))
+	if err != nil {
+		return nil, err
+	}
+	data, err := val.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("While reading the context file %s\n%w\n", file, err)
+	}
+	err = json.Unmarshal(data, F)
+	if err != nil {
+		return nil, fmt.Errorf("While reading the context file %s\n%w\n", file, err)
+	}
+	if F.Contexts == nil {
+		F.Contexts = map[string]Context{}
+	}
+	return F, nil
+}
+
+// Save writes the context file, creating its directory as needed.
+// Comments in the file are not kept.
+func (F *ContextFile) Save() error {
+	// indented, so the formatter keeps a field per line
+	data, err := json.MarshalIndent(F, "", "  ")
+	if err != nil {
+		return err
+	}
+	expr, err := cuejson.Extract(F.File, data)
+	if err != nil {
+		return err
+	}
+	return writeCueNode(F.File, expr)
+}
+
+// Names returns the names of the contexts, sorted.
+func (F *ContextFile) Names() []string {
+	names := make([]string, 0, len(F.Contexts))
+	for name := range F.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Selected returns the name of the context in use, the one given with --context,
+// or else the current one, which is "" when there is none.
+func (F *ContextFile) Selected() (string, error) {
+	name := F.Current
+	if flags.RootContextPflag != "" {
+		name = flags.RootContextPflag
+	}
+	if name == "" {
+		return "", nil
+	}
+	if _, ok := F.Contexts[name]; !ok {
+		return name, fmt.Errorf("context %q not found in %s", name, F.File)
+	}
+	return name, nil
+}
+
+// Use makes name the current context.
+func (F *ContextFile) Use(name string) error {
+	if _, ok := F.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found in %s, use 'hof context list' to see them", name, F.File)
+	}
+	F.Current = name
+	return nil
+}
+
+// Create adds the context name, which must not exist yet.
+// It becomes the current context when it is the first one.
+func (F *ContextFile) Create(name string, ctx Context) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("bad context name %q", name)
+	}
+	if _, ok := F.Contexts[name]; ok {
+		return fmt.Errorf("context %q already exists in %s", name, F.File)
+	}
+	ctx["Name"] = name
+	F.Contexts[name] = ctx
+	if len(F.Contexts) == 1 {
+		F.Current = name
+	}
+	return nil
+}
+
+// Delete removes the context name, and clears the current context when it was that one.
+func (F *ContextFile) Delete(name string) error {
+	if _, ok := F.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found in %s", name, F.File)
+	}
+	delete(F.Contexts, name)
+	if F.Current == name {
+		F.Current = ""
+	}
+	return nil
+}
+
+// contextValue returns a context as a Cue value.
+func contextValue(ctx Context) (cue.Value, error) {
+	data, err := json.Marshal(ctx)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	var r cue.Runtime
+	inst, err := r.Compile("", data)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	val := inst.Value()
+	return val, val.Err()
+}
+
+// selectedContext loads the context file and returns it with the name of the context in use,
+// which is an error when there is none.
+func selectedContext() (*ContextFile, string, error) {
+	F, err := LoadContextFile()
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := F.Selected()
+	if err != nil {
+		return F, name, err
+	}
+	if name == "" {
+		return F, name, fmt.Errorf("no context in use, create one with 'hof context create <name>' or pick one with 'hof context use <name>'")
+	}
+	return F, name, nil
+}
+
+// ContextList prints the contexts in format, see the printer package,
+// marking the one in use with a *
+func (R *Runtime) ContextList(format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	if len(F.Contexts) == 0 && (format == "" || format == printer.Text) {
+		fmt.Printf("no contexts in %s, use 'hof context create <name>' to add one\n", F.File)
+		return nil
+	}
+	selected, _ := F.Selected()
+
+	rows := printer.NewRows("current", "name", "account", "project", "credentials")
+	for _, name := range F.Names() {
+		C := F.Contexts[name]
+		mark := ""
+		if name == selected {
+			mark = "*"
+		}
+		rows.Add(mark, name, C.Field("Account"), C.Field("Project"), C.Field("Credentials"))
+	}
+	return printer.Print(os.Stdout, format, rows)
+}
+
+// ContextUse makes name the current context.
+func (R *Runtime) ContextUse(name string) error {
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	err = F.Use(name)
+	if err != nil {
+		return err
+	}
+	return F.Save()
+}
+
+// ContextCreate adds the context name.
+func (R *Runtime) ContextCreate(name string, ctx Context) error {
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	err = F.Create(name, ctx)
+	if err != nil {
+		return err
+	}
+	return F.Save()
+}
+
+// ContextDelete removes the context name.
+func (R *Runtime) ContextDelete(name string) error {
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	err = F.Delete(name)
+	if err != nil {
+		return err
+	}
+	return F.Save()
+}
+
+// ContextClear unsets the current context.
+func (R *Runtime) ContextClear() error {
+	F, err := LoadContextFile()
+	if err != nil {
+		return err
+	}
+	F.Current = ""
+	return F.Save()
+}
+
+// ContextGet returns the value at path, or the whole context when it is empty,
+// of the context in use.
+func (R *Runtime) ContextGet(path string) (cue.Value, error) {
+	F, name, err := selectedContext()
+	if err != nil {
+		return cue.Value{}, err
+	}
+	val, err := contextValue(F.Contexts[name])
+	if err != nil {
+		return val, err
+	}
+
+	if path == "" {
+		return val, nil
+	}
+	paths := strings.Split(path, ".")
+	return val.Lookup(paths...), nil
+}
+
+// ContextSet merges expr into the context in use.
+func (R *Runtime) ContextSet(expr string) error {
+	F, name, err := selectedContext()
+	if err != nil {
+		return err
+	}
+	orig, err := contextValue(F.Contexts[name])
+	if err != nil {
+		return err
+	}
+	val, err := structural.Merge(orig, expr)
+	if err != nil {
+		return err
+	}
+
+	data, err := val.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	ctx := Context{}
+	err = json.Unmarshal(data, &ctx)
+	if err != nil {
+		return err
+	}
+	// the name is the key
+	ctx["Name"] = name
+	F.Contexts[name] = ctx
+	return F.Save()
+}
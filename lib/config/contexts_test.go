@@ -0,0 +1,97 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+func TestContexts(t *testing.T) {
+	dir := inTempDir(t)
+	file := filepath.Join(dir, "ctx.cue")
+	flags.RootContextFilePflag = file
+	defer func() { flags.RootContextFilePflag = "" }()
+	R := NewRuntime()
+
+	err := R.ContextCreate("work", Context{"Account": "acme", "Project": "api", "Credentials": "acme-studios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = R.ContextCreate("personal", Context{"Account": "me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = R.ContextCreate("work", Context{})
+	if err == nil || !strings.Contains(err.Error(), `context "work" already exists`) {
+		t.Fatalf("expected creating work again to fail, got %v", err)
+	}
+
+	// the first context is the current one
+	val, err := R.ContextGet("Account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := val.String(); s != "acme" {
+		t.Fatalf("expected the work context in use, got Account %q", s)
+	}
+
+	err = R.ContextUse("personal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `Current: "personal"
+Contexts: {
+	personal: {
+		Account: "me"
+		Name:    "personal"
+	}
+	work: {
+		Account:     "acme"
+		Credentials: "acme-studios"
+		Name:        "work"
+		Project:     "api"
+	}
+}
+`
+	if got := readFile(t, file); got != expect {
+		t.Fatalf("after use:\ngot:\n%s\nwant:\n%s", got, expect)
+	}
+
+	err = R.ContextSet(`Project: "site"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err = R.ContextGet("Project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := val.String(); s != "site" {
+		t.Fatalf("expected set to merge into the context in use, got Project %q", s)
+	}
+
+	err = R.ContextDelete("personal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `Contexts: work: {
+	Account:     "acme"
+	Credentials: "acme-studios"
+	Name:        "work"
+	Project:     "api"
+}
+`
+	if got := readFile(t, file); got != expect {
+		t.Fatalf("after delete:\ngot:\n%s\nwant:\n%s", got, expect)
+	}
+
+	_, err = R.ContextGet("")
+	if err == nil || !strings.Contains(err.Error(), "no context in use") {
+		t.Fatalf("expected no context in use after deleting the current one, got %v", err)
+	}
+	err = R.ContextUse("personal")
+	if err == nil || !strings.Contains(err.Error(), `context "personal" not found`) {
+		t.Fatalf("expected using a deleted context to fail, got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/gen/cuefig"
+	"github.com/hofstadter-io/hof/lib/structural"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// The layers of hof's config, from the lowest to the highest precedence.
+// Later layers override the values of earlier ones, see ResolveConfig.
+const (
+	// ~/.hof/config.cue
+	LayerGlobal = "global"
+	// .hofcfg.cue in the workspace root, the nearest directory up with a cue.mods file
+	LayerWorkspace = "workspace"
+	// .hofcfg.cue in the current directory, when that is not the workspace root
+	LayerLocal = "local"
+	// the file given with --config
+	LayerCustom = "custom"
+)
+
+const GlobalConfigFile = ".hof/config.cue"
+
+// Layer is one file of hof's config. Value is only set when the file exists.
+type Layer struct {
+	Name   string
+	File   string
+	Value  cue.Value
+	Exists bool
+}
+
+// ConfigLayerFiles returns the layers of hof's config, from the lowest precedence,
+// whether or not their files exist.
+func ConfigLayerFiles() ([]Layer, error) {
+	var layers []Layer
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, Layer{Name: LayerGlobal, File: filepath.Join(home, GlobalConfigFile)})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	local := filepath.Join(cwd, cuefig.ConfigEntrypoint)
+	if root := WorkspaceRoot(cwd); root != "" {
+		ws := filepath.Join(root, cuefig.ConfigEntrypoint)
+		layers = append(layers, Layer{Name: LayerWorkspace, File: ws})
+		if ws == local {
+			local = ""
+		}
+	}
+	if local != "" {
+		layers = append(layers, Layer{Name: LayerLocal, File: local})
+	}
+
+	if flags.RootConfigPflag != "" {
+		custom, err := filepath.Abs(flags.RootConfigPflag)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Name: LayerCustom, File: custom})
+	}
+
+	return layers, nil
+}
+
+// WorkspaceRoot returns the nearest directory from dir up with a cue.mods file, or "" when there is none.
+func WorkspaceRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "cue.mods")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ConfigLayers returns the layers of hof's config, with the values of those which exist.
+func ConfigLayers() ([]Layer, error) {
+	layers, err := ConfigLayerFiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range layers {
+		err = layers[i].Load()
+		if err != nil {
+			return layers, err
+		}
+	}
+	return layers, nil
+}
+
+// Load reads the layer's file, leaving it unset when the file does not exist.
+func (L *Layer) Load() error {
+	_, err := os.Stat(L.File)
+	if os.IsNotExist(err) {
+		// only --config must exist
+		if L.Name == LayerCustom {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	val, err := cuefig.LoadConfigConfig(filepath.Dir(L.File), filepath.Base(L.File))
+	if err != nil {
+		return err
+	}
+	L.Value, L.Exists = val, true
+	return nil
+}
+
+// Save writes val to the layer's file, creating its directory as needed.
+func (L *Layer) Save(val cue.Value) error {
+	err := writeCueNode(L.File, val.Syntax(cue.Docs(true)))
+	if err != nil {
+		return err
+	}
+	L.Value, L.Exists = val, true
+	return nil
+}
+
+// writeCueNode formats node into file, creating its directory as needed.
+// Structs are written as a file, without the enclosing braces.
+func writeCueNode(file string, node ast.Node) error {
+	err := yagu.Mkdir(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+
+	if sl, ok := node.(*ast.StructLit); ok {
+		node = &ast.File{Decls: sl.Elts}
+	}
+	bytes, err := format.Node(node, format.Simplify())
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, bytes, 0644)
+}
+
+// ResolveConfig merges the layers which exist, later ones overriding the values of earlier ones.
+// Structs are merged field by field, while lists and other values are replaced.
+func ResolveConfig(layers []Layer) (val cue.Value, err error) {
+	found := false
+	for _, L := range layers {
+		if !L.Exists {
+			continue
+		}
+		if !found {
+			val, found = L.Value, true
+			continue
+		}
+		val, err = structural.MergeValues(val, L.Value)
+		if err != nil {
+			return val, fmt.Errorf("While merging the %s config %s\n%w\n", L.Name, L.File, err)
+		}
+	}
+	if !found {
+		return val, fmt.Errorf("no config found, use 'hof config -h' to learn create and use configurations")
+	}
+	return val, nil
+}
+
+// SelectLayer returns the layer to read or write by 'hof config --layer',
+// or --config, --global, or --local, or "" for all of them.
+func SelectLayer() string {
+	switch {
+	case flags.ConfigLayerPflag != "":
+		return flags.ConfigLayerPflag
+	case flags.RootConfigPflag != "":
+		return LayerCustom
+	case flags.RootGlobalPflag:
+		return LayerGlobal
+	case flags.RootLocalPflag:
+		return LayerLocal
+	}
+	return ""
+}
+
+// findLayer returns the layer named name, where local falls back to the workspace
+// when the current directory is the workspace root.
+func findLayer(layers []Layer, name string) (*Layer, error) {
+	for i := range layers {
+		if layers[i].Name == name {
+			return &layers[i], nil
+		}
+	}
+	if name == LayerLocal {
+		return findLayer(layers, LayerWorkspace)
+	}
+	switch name {
+	case LayerGlobal, LayerWorkspace, LayerCustom:
+		return nil, fmt.Errorf("no %s config layer here", name)
+	default:
+		return nil, fmt.Errorf("Unknown config layer %q, should be one of global, workspace, local, or custom", name)
+	}
+}
+
+// Origin is where a value of the resolved config came from.
+type Origin struct {
+	Path  []string
+	Value cue.Value
+	Layer *Layer
+}
+
+// Provenance returns, for each leaf of the resolved config, the layer with the highest precedence
+// which sets it. Leaves are the values which are not structs.
+func Provenance(resolved cue.Value, layers []Layer) ([]Origin, error) {
+	var origins []Origin
+	var walk func(path []string, v cue.Value) error
+	walk = func(path []string, v cue.Value) error {
+		// empty structs are leaves too, except for the whole config
+		if v.Kind() != cue.StructKind || (len(path) > 0 && !hasFields(v)) {
+			O := Origin{Path: path, Value: v}
+			for i := len(layers) - 1; i >= 0; i-- {
+				if layers[i].Exists && layers[i].Value.Lookup(path...).Exists() {
+					O.Layer = &layers[i]
+					break
+				}
+			}
+			origins = append(origins, O)
+			return nil
+		}
+
+		S, err := v.Struct()
+		if err != nil {
+			return err
+		}
+		iter := S.Fields()
+		for iter.Next() {
+			err = walk(append(path[:len(path):len(path)], iter.Label()), iter.Value())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return origins, walk(nil, resolved)
+}
+
+func hasFields(v cue.Value) bool {
+	S, err := v.Struct()
+	return err == nil && S.Len() > 0
+}
+
+// ConfigView prints each config layer which exists, or when resolved,
+// every value of the merged config with the layer it came from.
+func (R *Runtime) ConfigView(resolved bool) error {
+	layers, err := ConfigLayers()
+	if err != nil {
+		return err
+	}
+
+	if !resolved {
+		found := false
+		for _, L := range layers {
+			if !L.Exists {
+				fmt.Printf("// %s: %s (not found)\n\n", L.Name, L.File)
+				continue
+			}
+			found = true
+			bytes, err := format.Node(L.Value.Syntax(cue.Docs(true)))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("// %s: %s\n%s\n\n", L.Name, L.File, strings.TrimSpace(string(bytes)))
+		}
+		if !found {
+			return fmt.Errorf("no config found, use 'hof config -h' to learn create and use configurations")
+		}
+		return nil
+	}
+
+	val, err := ResolveConfig(layers)
+	if err != nil {
+		return err
+	}
+	origins, err := Provenance(val, layers)
+	if err != nil {
+		return err
+	}
+	for _, O := range origins {
+		bytes, err := format.Node(O.Value.Syntax())
+		if err != nil {
+			return err
+		}
+		from := "unknown"
+		if O.Layer != nil {
+			from = O.Layer.Name + " " + O.Layer.File
+		}
+		fmt.Printf("%s: %s  // %s\n", structural.DotPath(O.Path), strings.TrimSpace(string(bytes)), from)
+	}
+	return nil
+}
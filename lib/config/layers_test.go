@@ -0,0 +1,133 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+// inTempDir runs the rest of a test in a new directory, which is also the home directory,
+// as the config layers are found from both. Tests using it must not run in parallel.
+func inTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "hof-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the temp dir may be a symlink, as on macOS, while Getwd is not
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		os.Setenv("HOME", home)
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func writeFile(t *testing.T, file, content string) {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestConfigSet(t *testing.T) {
+	dir := inTempDir(t)
+	R := NewRuntime()
+
+	err := R.ConfigSet(`gen: outdir: "out"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = R.ConfigSet(`gen: verbose: true`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "gen: {\n\toutdir:  \"out\"\n\tverbose: true\n}\n"
+	if got := readFile(t, filepath.Join(dir, ".hofcfg.cue")); got != expect {
+		t.Fatalf("local layer:\ngot:\n%s\nwant:\n%s", got, expect)
+	}
+
+	// --global writes to the home directory instead
+	flags.RootGlobalPflag = true
+	defer func() { flags.RootGlobalPflag = false }()
+	err = R.ConfigSet(`gen: outdir: "global"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = "gen: outdir: \"global\"\n"
+	if got := readFile(t, filepath.Join(dir, GlobalConfigFile)); got != expect {
+		t.Fatalf("global layer:\ngot:\n%s\nwant:\n%s", got, expect)
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	dir := inTempDir(t)
+
+	writeFile(t, filepath.Join(dir, GlobalConfigFile), "gen: {\n\toutdir: \"global\"\n\tverbose: true\n}\nlist: [1, 2]\n")
+	writeFile(t, filepath.Join(dir, "cue.mods"), "module github.com/test/config\n")
+	writeFile(t, filepath.Join(dir, "sub", ".hofcfg.cue"), "gen: outdir: \"local\"\nlist: [3]\n")
+	err := os.Chdir(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := ConfigLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, L := range layers {
+		names = append(names, L.Name)
+	}
+	if len(names) != 3 || names[0] != LayerGlobal || names[1] != LayerWorkspace || names[2] != LayerLocal {
+		t.Fatalf("expected the global, workspace, and local layers, got %v", names)
+	}
+	if layers[1].Exists {
+		t.Fatalf("the workspace layer should not exist, as %s has no .hofcfg.cue", dir)
+	}
+
+	val, err := ResolveConfig(layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdir, _ := val.Lookup("gen", "outdir").String()
+	verbose, _ := val.Lookup("gen", "verbose").Bool()
+	if outdir != "local" || !verbose {
+		t.Fatalf("expected structs to merge by field, got outdir %q and verbose %v", outdir, verbose)
+	}
+	list, _ := val.Lookup("list").MarshalJSON()
+	if string(list) != "[3]" {
+		t.Fatalf("expected lists to be replaced, got %s", list)
+	}
+}
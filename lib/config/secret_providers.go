@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	cuejson "cuelang.org/go/encoding/json"
+)
+
+// SecretProvider fetches secrets kept outside of hof. Secrets are referenced as
+//
+//   <scheme>://<location>[#<key.path>]
+//
+// as in vault://secret/data/app#token, where the key path picks a value of the secret,
+// which is the whole secret when it is left out.
+type SecretProvider interface {
+	// Fetch returns the secret at location as JSON, usually an object
+	Fetch(location string) ([]byte, error)
+}
+
+var secretProviders = map[string]SecretProvider{
+	"vault": vaultSecretProvider{},
+	"awssm": awsSecretProvider{},
+	"sops":  sopsSecretProvider{},
+}
+
+// RegisterSecretProvider adds, or replaces, the provider of a scheme.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProviders[scheme] = p
+}
+
+// IsSecretRef reports whether s references a secret of a provider rather than a file.
+func IsSecretRef(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+// ParseSecretRef splits a reference into its scheme, location, and key path.
+func ParseSecretRef(ref string) (scheme, location, path string, err error) {
+	i := strings.Index(ref, "://")
+	if i <= 0 {
+		return "", "", "", fmt.Errorf("bad secret reference %q, should be <scheme>://<location>[#<key.path>]", ref)
+	}
+	scheme, location = ref[:i], ref[i+3:]
+	if j := strings.LastIndex(location, "#"); j >= 0 {
+		location, path = location[:j], location[j+1:]
+	}
+	if _, ok := secretProviders[scheme]; !ok {
+		return scheme, location, path, fmt.Errorf("unknown secret provider %q in %q, should be one of vault, awssm, or sops", scheme, ref)
+	}
+	if location == "" {
+		return scheme, location, path, fmt.Errorf("secret reference %q has no location", ref)
+	}
+	return scheme, location, path, nil
+}
+
+// FetchSecret returns the secret a reference points at.
+func FetchSecret(ref string) (cue.Value, error) {
+	scheme, location, path, err := ParseSecretRef(ref)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	data, err := secretProviders[scheme].Fetch(location)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("While fetching the secret %s://%s\n%w\n", scheme, location, err)
+	}
+
+	expr, err := cuejson.Extract(ref, data)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	var r cue.Runtime
+	inst, err := r.CompileExpr(expr)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	val := inst.Value()
+	if path != "" {
+		val = val.Lookup(strings.Split(path, ".")...)
+		if !val.Exists() {
+			return val, fmt.Errorf("%s not found in the secret %s://%s", path, scheme, location)
+		}
+	}
+	return val, val.Err()
+}
+
+// ResolveSecrets fills each field of val with a @secret(<ref>) attribute with the secret it references,
+// as in
+//
+//   token: string @secret("vault://secret/data/app#token")
+func ResolveSecrets(val cue.Value) (cue.Value, error) {
+	type fill struct {
+		path []string
+		ref  string
+	}
+	var fills []fill
+
+	var walk func(path []string, v cue.Value) error
+	walk = func(path []string, v cue.Value) error {
+		if v.Kind() != cue.StructKind {
+			return nil
+		}
+		S, err := v.Struct()
+		if err != nil {
+			return err
+		}
+		iter := S.Fields()
+		for iter.Next() {
+			fpath := append(path[:len(path):len(path)], iter.Label())
+			A := iter.Value().Attribute("secret")
+			if ref, err := A.String(0); err == nil {
+				fills = append(fills, fill{path: fpath, ref: ref})
+				continue
+			}
+			err = walk(fpath, iter.Value())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := walk(nil, val)
+	if err != nil {
+		return val, err
+	}
+
+	for _, F := range fills {
+		secret, err := FetchSecret(F.ref)
+		if err != nil {
+			return val, err
+		}
+		// through Go, as the secret was compiled by another runtime
+		var x interface{}
+		err = secret.Decode(&x)
+		if err != nil {
+			return val, err
+		}
+		val = val.Fill(x, F.path...)
+	}
+	return val, val.Err()
+}
+
+// providerSecretStore reads the secrets given with --secret as a reference, which are read-only.
+type providerSecretStore struct {
+	Ref string
+}
+
+func (S *providerSecretStore) Name() string {
+	scheme, _, _, _ := ParseSecretRef(S.Ref)
+	return scheme
+}
+
+func (S *providerSecretStore) Load() ([]byte, error) {
+	val, err := FetchSecret(S.Ref)
+	if err != nil {
+		return nil, err
+	}
+	return format.Node(val.Syntax())
+}
+
+func (S *providerSecretStore) Save(src []byte) error {
+	return fmt.Errorf("the secrets of %s are read-only, set them with the tools of the provider", S.Ref)
+}
+
+// vaultSecretProvider reads from HashiCorp Vault, at $VAULT_ADDR with $VAULT_TOKEN or ~/.vault-token.
+// Locations are API paths without the /v1, as in secret/data/app for the KV version 2 engine.
+type vaultSecretProvider struct{}
+
+func (P vaultSecretProvider) Fetch(location string) ([]byte, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("$VAULT_ADDR is not set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return nil, fmt.Errorf("no Vault token, set $VAULT_TOKEN or run 'vault login'")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(location, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	err = json.Unmarshal(body, &secret)
+	if err != nil {
+		return nil, err
+	}
+	// the KV version 2 engine nests the secret, next to its metadata
+	if data, ok := secret.Data["data"]; ok && secret.Data["metadata"] != nil {
+		return data, nil
+	}
+	return json.Marshal(secret.Data)
+}
+
+// awsSecretProvider reads from AWS Secrets Manager with the aws cli, so its profiles and regions apply.
+// Locations are secret names or ARNs.
+type awsSecretProvider struct{}
+
+func (P awsSecretProvider) Fetch(location string) ([]byte, error) {
+	out, err := runSecretCmd("aws", "secretsmanager", "get-secret-value", "--secret-id", location, "--query", "SecretString", "--output", "text")
+	if err != nil {
+		return nil, err
+	}
+	out = bytes.TrimSpace(out)
+	if json.Valid(out) {
+		return out, nil
+	}
+	// a plain string secret
+	return json.Marshal(string(out))
+}
+
+// sopsSecretProvider decrypts SOPS files with the sops cli, so its keys apply.
+// Locations are file paths, as in sops://secrets.enc.yaml#db.password
+type sopsSecretProvider struct{}
+
+func (P sopsSecretProvider) Fetch(location string) ([]byte, error) {
+	return runSecretCmd("sops", "--decrypt", "--output-type", "json", location)
+}
+
+func runSecretCmd(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return nil, fmt.Errorf("the %s cli is needed for these secrets\n%w\n", name, err)
+		}
+		return nil, fmt.Errorf("%s: %s\n%w\n", name, strings.TrimSpace(stderr.String()), err)
+	}
+	return out, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+)
+
+// fakeSecretProvider serves secrets from memory, by location
+type fakeSecretProvider map[string]string
+
+func (P fakeSecretProvider) Fetch(location string) ([]byte, error) {
+	data, ok := P[location]
+	if !ok {
+		return nil, fmt.Errorf("no secret at %s", location)
+	}
+	return []byte(data), nil
+}
+
+func init() {
+	RegisterSecretProvider("fake", fakeSecretProvider{
+		"app":   `{"db": {"user": "app", "password": "pa55"}, "token": "t0k3n"}`,
+		"plain": `"just a string"`,
+	})
+}
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		scheme   string
+		location string
+		path     string
+		err      string
+	}{
+		{ref: "fake://app#db.password", scheme: "fake", location: "app", path: "db.password"},
+		{ref: "vault://secret/data/app", scheme: "vault", location: "secret/data/app"},
+		{ref: "sops://a#b.yaml#key", scheme: "sops", location: "a#b.yaml", path: "key"},
+		{ref: "nope://app", err: `unknown secret provider "nope"`},
+		{ref: "fake://#token", err: "has no location"},
+		{ref: "://app", err: "bad secret reference"},
+	}
+
+	for _, tt := range tests {
+		scheme, location, path, err := ParseSecretRef(tt.ref)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.ref, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		if scheme != tt.scheme || location != tt.location || path != tt.path {
+			t.Errorf("%s: got %q %q %q, want %q %q %q", tt.ref, scheme, location, path, tt.scheme, tt.location, tt.path)
+		}
+	}
+}
+
+func TestFetchSecret(t *testing.T) {
+	tests := []struct {
+		ref    string
+		expect string
+		err    string
+	}{
+		{ref: "fake://app#db.password", expect: `"pa55"`},
+		{ref: "fake://app#db", expect: `{"user":"app","password":"pa55"}`},
+		// the whole secret without a key path
+		{ref: "fake://app", expect: `{"db":{"user":"app","password":"pa55"},"token":"t0k3n"}`},
+		{ref: "fake://plain", expect: `"just a string"`},
+		{ref: "fake://app#db.host", err: "db.host not found in the secret fake://app"},
+		{ref: "fake://missing", err: "no secret at missing"},
+	}
+
+	for _, tt := range tests {
+		val, err := FetchSecret(tt.ref)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.ref, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		data, err := val.MarshalJSON()
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		if string(data) != tt.expect {
+			t.Errorf("%s: got %s, want %s", tt.ref, data, tt.expect)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	var r cue.Runtime
+	inst, err := r.Compile("config.cue", `
+name: "app"
+db: {
+	user:     string @secret("fake://app#db.user")
+	password: string @secret("fake://app#db.password")
+}
+token: string @secret("fake://app#token")
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := ResolveSecrets(inst.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := val.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"name":"app","db":{"user":"app","password":"pa55"},"token":"t0k3n"}`
+	if string(data) != expect {
+		t.Fatalf("got %s, want %s", data, expect)
+	}
+
+	inst, err = r.Compile("config.cue", `token: string @secret("fake://missing")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ResolveSecrets(inst.Value())
+	if err == nil || !strings.Contains(err.Error(), "While fetching the secret fake://missing") {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+}
+
+func TestProviderSecretStore(t *testing.T) {
+	S := &providerSecretStore{Ref: "fake://app#db"}
+	if S.Name() != "fake" {
+		t.Fatalf("expected the store to be named by its scheme, got %q", S.Name())
+	}
+	src, err := S.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), `password: "pa55"`) {
+		t.Fatalf("expected the secret as Cue, got:\n%s", src)
+	}
+	err = S.Save(src)
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected provider secrets to be read-only, got %v", err)
+	}
+}
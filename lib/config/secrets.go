@@ -0,0 +1,437 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	cuejson "cuelang.org/go/encoding/json"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/gen/cuefig"
+	"github.com/hofstadter-io/hof/lib/structural"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// The stores secrets are kept in, both encrypted at rest.
+const (
+	// the OS keychain, the macOS Keychain, Windows Credential Manager, or the Secret Service on Linux
+	SecretStoreKeyring = "keyring"
+	// ~/.hof/secrets.age, or the file given with --secret, encrypted with a passphrase
+	SecretStoreAge = "age"
+)
+
+const (
+	GlobalSecretFile = ".hof/secrets.age"
+
+	// SecretStoreEnv picks the store, rather than the keyring when there is one
+	SecretStoreEnv = "HOF_SECRET_STORE"
+	// SecretPassphraseEnv is the passphrase of the age file, which is prompted for otherwise
+	SecretPassphraseEnv = "HOF_SECRET_PASSPHRASE"
+
+	secretKeyringService = "hof"
+	secretKeyringUser    = "secrets"
+)
+
+// SecretMask replaces each secret value in what 'hof secret get' prints without --reveal.
+const SecretMask = "********"
+
+// SecretStore holds hof's secrets, as Cue source, encrypted.
+type SecretStore interface {
+	Name() string
+	// Load returns the secrets, or nil when none have been saved
+	Load() ([]byte, error)
+	Save(src []byte) error
+}
+
+// OpenSecretStore returns the store chosen with --secret or $HOF_SECRET_STORE,
+// or else the age file when it exists, then the keyring when the OS has one, and the age file otherwise.
+// A --secret which references a provider, see SecretProvider, reads the secrets from there instead.
+func OpenSecretStore() (SecretStore, error) {
+	if IsSecretRef(flags.RootSecretPflag) {
+		_, _, _, err := ParseSecretRef(flags.RootSecretPflag)
+		if err != nil {
+			return nil, err
+		}
+		return &providerSecretStore{Ref: flags.RootSecretPflag}, nil
+	}
+	if flags.RootSecretPflag != "" {
+		file, err := filepath.Abs(flags.RootSecretPflag)
+		if err != nil {
+			return nil, err
+		}
+		return &ageSecretStore{File: file}, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	ageStore := &ageSecretStore{File: filepath.Join(home, GlobalSecretFile)}
+
+	switch os.Getenv(SecretStoreEnv) {
+	case SecretStoreKeyring:
+		return &keyringSecretStore{}, nil
+	case SecretStoreAge:
+		return ageStore, nil
+	case "":
+	default:
+		return nil, fmt.Errorf("Unknown secret store %q in $%s, should be keyring or age", os.Getenv(SecretStoreEnv), SecretStoreEnv)
+	}
+
+	if _, err := os.Stat(ageStore.File); err == nil {
+		return ageStore, nil
+	}
+	// a keyring which answers, even with not found, is there to use
+	_, err = keyring.Get(secretKeyringService, secretKeyringUser)
+	if err == nil || err == keyring.ErrNotFound {
+		return &keyringSecretStore{}, nil
+	}
+	return ageStore, nil
+}
+
+type keyringSecretStore struct{}
+
+func (S *keyringSecretStore) Name() string {
+	return SecretStoreKeyring
+}
+
+func (S *keyringSecretStore) Load() ([]byte, error) {
+	src, err := keyring.Get(secretKeyringService, secretKeyringUser)
+	if err == keyring.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("While reading secrets from the OS keyring\n%w\n", err)
+	}
+	return []byte(src), nil
+}
+
+func (S *keyringSecretStore) Save(src []byte) error {
+	err := keyring.Set(secretKeyringService, secretKeyringUser, string(src))
+	if err != nil {
+		return fmt.Errorf("While writing secrets to the OS keyring, set $%s=age to use a file instead\n%w\n", SecretStoreEnv, err)
+	}
+	return nil
+}
+
+type ageSecretStore struct {
+	File string
+
+	// pass is the passphrase the file was loaded with, so saving does not ask again
+	pass string
+}
+
+func (S *ageSecretStore) Name() string {
+	return SecretStoreAge
+}
+
+func (S *ageSecretStore) Load() ([]byte, error) {
+	f, err := os.Open(S.File)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pass := S.pass
+	if pass == "" {
+		pass, err = secretPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	id, err := age.NewScryptIdentity(pass)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(f, id)
+	if err != nil {
+		return nil, fmt.Errorf("While decrypting %s, is the passphrase right?\n%w\n", S.File, err)
+	}
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	S.pass = pass
+	return src, nil
+}
+
+func (S *ageSecretStore) Save(src []byte) error {
+	pass := S.pass
+	if pass == "" {
+		_, err := os.Stat(S.File)
+		pass, err = secretPassphrase(os.IsNotExist(err))
+		if err != nil {
+			return err
+		}
+	}
+	rcpt, err := age.NewScryptRecipient(pass)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, rcpt)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	err = yagu.Mkdir(filepath.Dir(S.File))
+	if err != nil {
+		return err
+	}
+	// written beside and renamed, so a failure does not lose the secrets
+	tmp := S.File + ".tmp"
+	err = ioutil.WriteFile(tmp, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, S.File)
+}
+
+// secretPassphrase returns $HOF_SECRET_PASSPHRASE, or prompts for it on the terminal,
+// twice when it is new.
+func secretPassphrase(isNew bool) (string, error) {
+	if pass := os.Getenv(SecretPassphraseEnv); pass != "" {
+		return pass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("the secrets passphrase is needed, set $%s when there is no terminal", SecretPassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "secrets passphrase: ")
+	pass, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(pass) == 0 {
+		return "", fmt.Errorf("the secrets passphrase can not be empty")
+	}
+	if isNew {
+		fmt.Fprint(os.Stderr, "confirm passphrase: ")
+		again, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(pass, again) {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return string(pass), nil
+}
+
+// ConfirmReveal asks on the terminal before secrets are printed.
+func ConfirmReveal() error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("--reveal needs a terminal to confirm")
+	}
+	fmt.Fprint(os.Stderr, "print secrets in the clear? [y/N] ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("not revealing secrets")
+}
+
+// MaskSecret returns val with each of its values replaced by SecretMask, keeping the structure.
+func MaskSecret(val cue.Value) (cue.Value, error) {
+	data, err := val.MarshalJSON()
+	if err != nil {
+		return val, err
+	}
+	var x interface{}
+	err = json.Unmarshal(data, &x)
+	if err != nil {
+		return val, err
+	}
+	data, err = json.Marshal(maskValues(x))
+	if err != nil {
+		return val, err
+	}
+	expr, err := cuejson.Extract("secret", data)
+	if err != nil {
+		return val, err
+	}
+	var r cue.Runtime
+	inst, err := r.CompileExpr(expr)
+	if err != nil {
+		return val, err
+	}
+	return inst.Value(), nil
+}
+
+func maskValues(x interface{}) interface{} {
+	switch t := x.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = maskValues(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = maskValues(e)
+		}
+		return t
+	}
+	return SecretMask
+}
+
+// loadSecrets returns the secrets of store, an empty struct when there are none.
+func loadSecrets(store SecretStore) (cue.Value, error) {
+	src, err := store.Load()
+	if err != nil {
+		return cue.Value{}, err
+	}
+	var r cue.Runtime
+	inst, err := r.Compile("secrets", src)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	val := inst.Value()
+	return val, val.Err()
+}
+
+// legacySecretFiles returns the plaintext secret files which 'hof secret set' used to write, if they exist.
+func legacySecretFiles() []string {
+	var files []string
+	if _, err := os.Stat(cuefig.SecretEntrypoint); err == nil {
+		files = append(files, cuefig.SecretEntrypoint)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		file := filepath.Join(dir, cuefig.HofshhWorkpath, cuefig.HofshhEntrypoint)
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// importLegacySecrets merges the plaintext secret files left by earlier versions
+// under the secrets, whose values win over theirs.
+func importLegacySecrets(val cue.Value, files []string) (cue.Value, error) {
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return val, err
+		}
+		val, err = structural.Merge(string(data), val)
+		if err != nil {
+			return val, fmt.Errorf("While importing the plaintext secrets in %s\n%w\n", file, err)
+		}
+	}
+	return val, nil
+}
+
+// removeLegacySecrets removes the plaintext secret files once the store
+// reads back the secrets saved with them, so they are never lost.
+func removeLegacySecrets(store SecretStore, src []byte, files []string) error {
+	saved, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(saved, src) {
+		return fmt.Errorf("The %s store did not read back the secrets saved, so the plaintext secrets in %s were kept", store.Name(), strings.Join(files, ", "))
+	}
+	for _, file := range files {
+		err = os.Remove(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "moved the plaintext secrets in %s to the %s store and removed the file\n", file, store.Name())
+	}
+	return nil
+}
+
+// SecretGet returns the value at path, or all of the secrets when it is empty.
+func (R *Runtime) SecretGet(path string) (cue.Value, error) {
+	store, err := OpenSecretStore()
+	if err != nil {
+		return cue.Value{}, err
+	}
+	val, err := loadSecrets(store)
+	if err != nil {
+		return val, err
+	}
+	R.SecretValue, R.SecretType = val, store.Name()
+
+	if path == "" {
+		return val, nil
+	}
+	paths := strings.Split(path, ".")
+	val = val.Lookup(paths...)
+	if !val.Exists() {
+		return val, fmt.Errorf("secret %s not found", path)
+	}
+	return val, nil
+}
+
+// SecretSet merges the Cue expr into the secrets and saves them encrypted.
+// The plaintext secret files of earlier versions are moved into the store,
+// see importLegacySecrets and removeLegacySecrets.
+func (R *Runtime) SecretSet(expr string) error {
+	store, err := OpenSecretStore()
+	if err != nil {
+		return err
+	}
+	val, err := loadSecrets(store)
+	if err != nil {
+		return err
+	}
+
+	legacy := legacySecretFiles()
+	val, err = importLegacySecrets(val, legacy)
+	if err != nil {
+		return err
+	}
+
+	val, err = structural.Merge(val, expr)
+	if err != nil {
+		return err
+	}
+	src, err := format.Node(val.Syntax())
+	if err != nil {
+		return err
+	}
+	err = store.Save(src)
+	if err != nil {
+		return err
+	}
+	if len(legacy) > 0 {
+		err = removeLegacySecrets(store, src, legacy)
+		if err != nil {
+			return err
+		}
+	}
+
+	R.SecretValue, R.SecretType = val, store.Name()
+	return nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+// setEnv sets an env var for the rest of a test
+func setEnv(t *testing.T, key, value string) {
+	old, had := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestAgeSecretStore(t *testing.T) {
+	dir := inTempDir(t)
+	file := filepath.Join(dir, "secrets.age")
+	setEnv(t, SecretPassphraseEnv, "correct-horse")
+
+	S := &ageSecretStore{File: file}
+	src, err := S.Load()
+	if err != nil || src != nil {
+		t.Fatalf("expected no secrets before the first save, got %q, %v", src, err)
+	}
+
+	secrets := "github: token: \"s3cr3t\"\n"
+	err = S.Save([]byte(secrets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := readFile(t, file)
+	if strings.Contains(data, "s3cr3t") || !strings.Contains(data, "age-encryption.org") {
+		t.Fatalf("expected an age file without the secrets in the clear, got:\n%s", data)
+	}
+
+	// as a later run of hof would
+	S = &ageSecretStore{File: file}
+	src, err = S.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != secrets {
+		t.Fatalf("round trip:\ngot:\n%s\nwant:\n%s", src, secrets)
+	}
+
+	// saving after loading keeps the passphrase the file was loaded with
+	os.Setenv(SecretPassphraseEnv, "another-horse")
+	err = S.Save([]byte(secrets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(SecretPassphraseEnv, "correct-horse")
+	_, err = (&ageSecretStore{File: file}).Load()
+	if err != nil {
+		t.Fatalf("expected the file to keep its passphrase, got %v", err)
+	}
+
+	os.Setenv(SecretPassphraseEnv, "wrong-horse")
+	_, err = (&ageSecretStore{File: file}).Load()
+	if err == nil || !strings.Contains(err.Error(), "is the passphrase right?") {
+		t.Fatalf("expected a wrong passphrase to fail, got %v", err)
+	}
+}
+
+func TestSecretSet(t *testing.T) {
+	dir := inTempDir(t)
+	setEnv(t, SecretPassphraseEnv, "correct-horse")
+	setEnv(t, SecretStoreEnv, SecretStoreAge)
+
+	// the plaintext file of earlier versions is moved into the store
+	legacy := filepath.Join(dir, ".hofshh.cue")
+	writeFile(t, legacy, "github: token: \"old\"\nnpm: token: \"n0de\"\n")
+	if files := legacySecretFiles(); len(files) != 1 {
+		t.Fatalf("expected .hofshh.cue to be found, got %v", files)
+	}
+
+	R := NewRuntime()
+	err := R.SecretSet(`github: token: "s3cr3t"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatalf("expected the plaintext secrets to be removed, got %v", err)
+	}
+	if data := readFile(t, filepath.Join(dir, GlobalSecretFile)); strings.Contains(data, "n0de") {
+		t.Fatalf("expected the imported secrets to be encrypted, got:\n%s", data)
+	}
+
+	// the values of the store win over those of a plaintext file
+	writeFile(t, legacy, "github: token: \"older\"\n")
+	err = R.SecretSet(`aws: key: "k3y"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatalf("expected the plaintext secrets to be removed, got %v", err)
+	}
+
+	for path, expect := range map[string]string{
+		"github.token": "s3cr3t",
+		"npm.token":    "n0de",
+		"aws.key":      "k3y",
+	} {
+		val, err := R.SecretGet(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, _ := val.String(); s != expect {
+			t.Errorf("%s: got %q, want %q", path, s, expect)
+		}
+	}
+	if R.SecretType != SecretStoreAge {
+		t.Fatalf("expected the age store, got %q", R.SecretType)
+	}
+
+	// a plaintext file which is not Cue is kept, and nothing is saved
+	writeFile(t, legacy, "github: {")
+	err = R.SecretSet(`aws: key: "n3w"`)
+	if err == nil || !strings.Contains(err.Error(), "While importing the plaintext secrets in .hofshh.cue") {
+		t.Fatalf("expected the import to fail, got %v", err)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Fatalf("expected the plaintext secrets to be kept, got %v", err)
+	}
+	val, err := R.SecretGet("aws.key")
+	if s, _ := val.String(); err != nil || s != "k3y" {
+		t.Fatalf("expected the secrets unchanged, got %q, %v", s, err)
+	}
+}
+
+func TestOpenSecretStore(t *testing.T) {
+	dir := inTempDir(t)
+	defer func() { flags.RootSecretPflag = "" }()
+
+	tests := []struct {
+		name   string
+		secret string
+		store  string
+		expect string
+		file   string
+		err    string
+	}{{
+		name:   "file flag",
+		secret: "my.age",
+		expect: SecretStoreAge,
+		file:   filepath.Join(dir, "my.age"),
+	}, {
+		name:   "provider flag",
+		secret: "vault://secret/data/app#token",
+		expect: "vault",
+	}, {
+		name:   "unknown provider",
+		secret: "nope://secret",
+		err:    `unknown secret provider "nope"`,
+	}, {
+		name:   "age env",
+		store:  SecretStoreAge,
+		expect: SecretStoreAge,
+		file:   filepath.Join(dir, GlobalSecretFile),
+	}, {
+		name:   "keyring env",
+		store:  SecretStoreKeyring,
+		expect: SecretStoreKeyring,
+	}, {
+		name:  "unknown env",
+		store: "paper",
+		err:   `Unknown secret store "paper"`,
+	}}
+
+	for _, tt := range tests {
+		flags.RootSecretPflag = tt.secret
+		setEnv(t, SecretStoreEnv, tt.store)
+		S, err := OpenSecretStore()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if S.Name() != tt.expect {
+			t.Errorf("%s: expected the %s store, got %s", tt.name, tt.expect, S.Name())
+		}
+		if A, ok := S.(*ageSecretStore); ok && A.File != tt.file {
+			t.Errorf("%s: expected the file %s, got %s", tt.name, tt.file, A.File)
+		}
+	}
+
+	// without flag or env, an existing age file comes before the keyring
+	flags.RootSecretPflag = ""
+	setEnv(t, SecretStoreEnv, "")
+	keyring.MockInit()
+	S, err := OpenSecretStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if S.Name() != SecretStoreKeyring {
+		t.Fatalf("expected the keyring store, got %s", S.Name())
+	}
+	writeFile(t, filepath.Join(dir, GlobalSecretFile), "")
+	S, err = OpenSecretStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if S.Name() != SecretStoreAge {
+		t.Fatalf("expected the age store, as its file exists, got %s", S.Name())
+	}
+}
+
+// lossyStore loses what is saved to it
+type lossyStore struct{}
+
+func (S lossyStore) Name() string          { return "lossy" }
+func (S lossyStore) Load() ([]byte, error) { return nil, nil }
+func (S lossyStore) Save(src []byte) error { return nil }
+
+func TestRemoveLegacySecrets(t *testing.T) {
+	dir := inTempDir(t)
+	legacy := filepath.Join(dir, ".hofshh.cue")
+	writeFile(t, legacy, "github: token: \"old\"\n")
+
+	err := removeLegacySecrets(lossyStore{}, []byte("github: token: \"old\"\n"), []string{legacy})
+	if err == nil || !strings.Contains(err.Error(), "did not read back the secrets saved") {
+		t.Fatalf("expected the files to be kept, got %v", err)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Fatalf("expected the plaintext secrets to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package cuetils
+
+import (
+	"sort"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/load"
+)
+
+// AttrFieldNames returns the sorted names of the top-level fields with an @<attr>(...) attribute
+// in the Cue package at dir. It is quiet, as shell completion calls it,
+// so a dir without Cue, or with errors, just has no names.
+func AttrFieldNames(dir, attr string) []string {
+	// cue takes absolute package args as relative to the module root,
+	// so the package is loaded from its own directory instead
+	var names []string
+	var r cue.Runtime
+	for _, bi := range load.Instances([]string{"."}, &load.Config{Dir: dir}) {
+		if bi.Err != nil {
+			continue
+		}
+		I, err := r.Build(bi)
+		if err != nil {
+			continue
+		}
+		S, err := I.Value().Struct()
+		if err != nil {
+			continue
+		}
+		iter := S.Fields()
+		for iter.Next() {
+			for _, A := range iter.Value().Attributes() {
+				if A.Name() == attr {
+					names = append(names, iter.Label())
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,75 @@
+package cuetils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAttrFieldNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-cuetils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"dm/dm.cue": `package dm
+
+Users: {} @datamodel()
+Posts: {} @datamodel()
+Other: {} @labelset()
+plain: 1
+`,
+		"bad/bad.cue":     "package bad\n\nUsers: {} @datamodel(\n",
+		"empty/README.md": "no cue here\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	olddir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(olddir)
+
+	tests := []struct {
+		dir    string
+		attr   string
+		expect []string
+	}{
+		{dir: "dm", attr: "datamodel", expect: []string{"Posts", "Users"}},
+		{dir: "./dm", attr: "labelset", expect: []string{"Other"}},
+		{dir: filepath.Join(dir, "dm"), attr: "datamodel", expect: []string{"Posts", "Users"}},
+		{dir: "dm", attr: "runtime"},
+		// completion is quiet about errors and missing Cue
+		{dir: "bad", attr: "datamodel"},
+		{dir: "empty", attr: "datamodel"},
+		{dir: "missing", attr: "datamodel"},
+	}
+	for _, tt := range tests {
+		got := AttrFieldNames(tt.dir, tt.attr)
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s @%s(): got %q, want %q", tt.dir, tt.attr, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package datamodel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// CHECKPOINT_DIR holds the history of each datamodel, in <name>/, as
+//
+//   history.json      the checkpoints, oldest first
+//   objects/<id>.cue  the datamodel as it was, named by the sha256 of its Cue
+//
+// Checkpoints of the same Cue share an object, so rolling back to one is cheap.
+const CHECKPOINT_DIR = ".hof/datamodels"
+
+// shortID is how much of a checkpoint id is printed
+const shortID = 12
+
+// Checkpoint is an entry in the history of a datamodel.
+type Checkpoint struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Short returns the id as printed, which refs may use as well.
+func (C Checkpoint) Short() string {
+	if len(C.ID) < shortID {
+		return C.ID
+	}
+	return C.ID[:shortID]
+}
+
+func historyFile(name string) string {
+	return filepath.Join(CHECKPOINT_DIR, name, "history.json")
+}
+
+func objectFile(name, id string) string {
+	return filepath.Join(CHECKPOINT_DIR, name, "objects", id+".cue")
+}
+
+// loadDatamodels returns the datamodels of the workspace, see Names, by name.
+func loadDatamodels() (map[string]cue.Value, error) {
+	dms := map[string]cue.Value{}
+	var r cue.Runtime
+	for _, bi := range load.Instances([]string{datamodelDir()}, nil) {
+		if bi.Err != nil {
+			return nil, fmt.Errorf("While loading datamodels in %s\n%w\n", datamodelDir(), bi.Err)
+		}
+		I, err := r.Build(bi)
+		if err != nil {
+			return nil, fmt.Errorf("While loading datamodels in %s\n%w\n", datamodelDir(), err)
+		}
+		S, err := I.Value().Struct()
+		if err != nil {
+			return nil, err
+		}
+		iter := S.Fields()
+		for iter.Next() {
+			for _, A := range iter.Value().Attributes() {
+				if A.Name() == "datamodel" {
+					dms[iter.Label()] = iter.Value()
+					break
+				}
+			}
+		}
+	}
+	return dms, nil
+}
+
+func datamodelDir() string {
+	dir := "."
+	if flags.RootDatamodelDirPflag != "" {
+		dir = flags.RootDatamodelDirPflag
+	}
+	if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, ".") {
+		dir = "./" + dir
+	}
+	return dir
+}
+
+// History returns the checkpoints of a datamodel, oldest first.
+func History(name string) ([]Checkpoint, error) {
+	data, err := ioutil.ReadFile(historyFile(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var cps []Checkpoint
+	err = json.Unmarshal(data, &cps)
+	if err != nil {
+		return nil, fmt.Errorf("While reading the history of datamodel %s\n%w\n", name, err)
+	}
+	return cps, nil
+}
+
+func writeHistory(name string, cps []Checkpoint) error {
+	data, err := json.MarshalIndent(cps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(historyFile(name), append(data, '\n'), 0644)
+}
+
+// FindCheckpoint returns the checkpoint of a datamodel at ref, which is
+//
+//   ""        the last checkpoint
+//   ~N        N checkpoints before the last
+//   <id>      a checkpoint id, or enough of one to be unique
+func FindCheckpoint(name, ref string) (Checkpoint, error) {
+	cps, err := History(name)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if len(cps) == 0 {
+		return Checkpoint{}, errs.WithCode(errs.CodeNotFound, "save one with 'hof datamodel checkpoint'",
+			fmt.Errorf("datamodel %s has no checkpoints", name))
+	}
+
+	if ref == "" {
+		ref = "~0"
+	}
+	if strings.HasPrefix(ref, "~") {
+		n, err := strconv.Atoi(ref[1:])
+		if err != nil || n < 0 {
+			return Checkpoint{}, errs.WithCode(errs.CodeBadFormat, "", fmt.Errorf("bad checkpoint ref %q, should be ~N", ref))
+		}
+		if n >= len(cps) {
+			return Checkpoint{}, errs.WithCode(errs.CodeNotFound, "", fmt.Errorf("datamodel %s has %d checkpoints, %s is before the first", name, len(cps), ref))
+		}
+		return cps[len(cps)-1-n], nil
+	}
+
+	// ids may be repeated by rollbacks, so match them once
+	var found []Checkpoint
+	seen := map[string]bool{}
+	for _, C := range cps {
+		if strings.HasPrefix(C.ID, ref) && !seen[C.ID] {
+			seen[C.ID] = true
+			found = append(found, C)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Checkpoint{}, errs.WithCode(errs.CodeNotFound, "", fmt.Errorf("datamodel %s has no checkpoint %s", name, ref))
+	case 1:
+		return found[0], nil
+	default:
+		return Checkpoint{}, fmt.Errorf("checkpoint %s of datamodel %s is ambiguous, use more of the id", ref, name)
+	}
+}
+
+// loadRef returns a datamodel as it was at ref, a Cue file holding the datamodel,
+// or a checkpoint, see FindCheckpoint. The ref is returned as it should be printed.
+func loadRef(name, ref string) (cue.Value, string, error) {
+	file := ref
+	if !strings.HasSuffix(ref, ".cue") {
+		C, err := FindCheckpoint(name, ref)
+		if err != nil {
+			return cue.Value{}, ref, err
+		}
+		file, ref = objectFile(name, C.ID), C.Short()
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cue.Value{}, ref, errs.WithCode(errs.CodeNotFound, "", fmt.Errorf("datamodel %s has no checkpoint or file %s", name, ref))
+		}
+		return cue.Value{}, ref, err
+	}
+
+	expr, err := parser.ParseExpr(file, data)
+	if err != nil {
+		return cue.Value{}, ref, err
+	}
+	var r cue.Runtime
+	I, err := r.CompileExpr(expr)
+	if err != nil {
+		return cue.Value{}, ref, err
+	}
+	v := I.Value()
+	return v, ref, v.Err()
+}
+
+// checkpointSource is the Cue of a datamodel as checkpoints hold it, and its id.
+func checkpointSource(dm cue.Value) ([]byte, string, error) {
+	src, err := format.Node(dm.Syntax(cue.Docs(true), cue.Attributes(true), cue.Optional(true)))
+	if err != nil {
+		return nil, "", err
+	}
+	src = append(src, '\n')
+	sum := sha256.Sum256(src)
+	return src, hex.EncodeToString(sum[:]), nil
+}
+
+// SaveCheckpoint records the datamodel as a new checkpoint, with message, and returns it.
+// Nothing is recorded when the datamodel is the same as at its last checkpoint,
+// which is returned instead, and saved is false.
+func SaveCheckpoint(name string, dm cue.Value, message string) (C Checkpoint, saved bool, err error) {
+	src, id, err := checkpointSource(dm)
+	if err != nil {
+		return C, false, err
+	}
+
+	cps, err := History(name)
+	if err != nil {
+		return C, false, err
+	}
+	if len(cps) > 0 && cps[len(cps)-1].ID == id {
+		return cps[len(cps)-1], false, nil
+	}
+
+	C = Checkpoint{ID: id, Time: time.Now().UTC(), Message: message}
+	err = addCheckpoint(name, cps, C, src)
+	return C, err == nil, err
+}
+
+// addCheckpoint writes the object of C, when new, and appends C to the history.
+func addCheckpoint(name string, cps []Checkpoint, C Checkpoint, src []byte) error {
+	file := objectFile(name, C.ID)
+	err := yagu.Mkdir(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		err = ioutil.WriteFile(file, src, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeHistory(name, append(cps, C))
+}
+
+// RunCheckpointFromArgs checkpoints the datamodels named in args, or all of them.
+func RunCheckpointFromArgs(args []string, cmdflags flags.CheckpointFlagpole) error {
+	dms, err := loadDatamodels()
+	if err != nil {
+		return err
+	}
+
+	names, err := namesOf(dms, args)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		C, saved, err := SaveCheckpoint(name, dms[name], cmdflags.Message)
+		if err != nil {
+			return fmt.Errorf("While saving a checkpoint of datamodel %s\n%w\n", name, err)
+		}
+		if !saved {
+			fmt.Printf("datamodel %s is unchanged since checkpoint %s\n", name, C.Short())
+			continue
+		}
+		fmt.Printf("saved checkpoint %s of datamodel %s\n", C.Short(), name)
+	}
+	return nil
+}
+
+// namesOf returns args, when they are all datamodels, or all of them, sorted.
+func namesOf(dms map[string]cue.Value, args []string) ([]string, error) {
+	for _, name := range args {
+		if _, ok := dms[name]; !ok {
+			return nil, errs.WithCode(errs.CodeNotFound, "", fmt.Errorf("datamodel %q not found", name))
+		}
+	}
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	var names []string
+	for name := range dms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
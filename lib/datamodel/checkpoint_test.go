@@ -0,0 +1,169 @@
+package datamodel
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+const firstUsersDM = `package dm
+
+Users: {
+	Models: User: {
+		fullname: string
+		email:    string
+	}
+} @datamodel()
+`
+
+const renamedUsersDM = `package dm
+
+Users: {
+	Models: User: {
+		name:  string
+		email: string
+		admin: bool
+	}
+} @datamodel()
+`
+
+func TestCheckpoints(t *testing.T) {
+	dir := inTempDir(t)
+	writeFile(t, filepath.Join(dir, "dm.cue"), firstUsersDM)
+
+	_, err := FindCheckpoint("Users", "")
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected no checkpoints, got %v", err)
+	}
+
+	err = RunCheckpointFromArgs(nil, flags.CheckpointFlagpole{Message: "first users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same Cue is not saved again
+	err = RunCheckpointFromArgs([]string{"Users"}, flags.CheckpointFlagpole{Message: "again"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cps, err := History("Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cps) != 1 || cps[0].Message != "first users" {
+		t.Fatalf("expected one checkpoint, got %+v", cps)
+	}
+	first := cps[0]
+
+	writeFile(t, filepath.Join(dir, "dm.cue"), renamedUsersDM)
+	err = RunCheckpointFromArgs(nil, flags.CheckpointFlagpole{Message: "rename fullname"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref    string
+		expect string
+		err    string
+	}{
+		{ref: "", expect: "rename fullname"},
+		{ref: "~0", expect: "rename fullname"},
+		{ref: "~1", expect: "first users"},
+		{ref: first.Short(), expect: "first users"},
+		{ref: first.ID, expect: "first users"},
+		{ref: "~2", err: "has 2 checkpoints, ~2 is before the first"},
+		{ref: "~x", err: `bad checkpoint ref "~x"`},
+		{ref: "zz", err: "has no checkpoint zz"},
+	}
+	for _, tt := range tests {
+		C, err := FindCheckpoint("Users", tt.ref)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected an error with %q, got %v", tt.ref, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.ref, err)
+			continue
+		}
+		if C.Message != tt.expect {
+			t.Errorf("%q: got checkpoint %q, want %q", tt.ref, C.Message, tt.expect)
+		}
+	}
+
+	// a checkpoint diffs and migrates as a file would
+	prev, next, from, to, err := diffEnds("Users", emptyValue(), "~1", "~0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != first.Short() || to == "workspace" {
+		t.Fatalf("expected checkpoint refs, got %s -> %s", from, to)
+	}
+	changes, err := DiffDatamodels(prev, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := MigrationSteps(changes)
+	if len(steps) != 2 || steps[0].Op != StepRename || steps[0].OldPath != "Models.User.fullname" || steps[1].Op != StepAdd {
+		t.Fatalf("expected a rename and an add, got %+v", steps)
+	}
+	_, _, err = loadRef("Users", "missing.cue")
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected a missing file to be not found, got %v", err)
+	}
+
+	err = RunRollbackFromArgs("Users", "~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "dm.cue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := string(data); !strings.Contains(src, "fullname: string") || strings.Contains(src, "admin") || !strings.Contains(src, "@datamodel()") {
+		t.Fatalf("expected dm.cue as at the first checkpoint, with its attribute, got:\n%s", src)
+	}
+
+	// the workspace was saved at the last checkpoint, so only the rollback is recorded
+	cps, err = History("Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := cps[len(cps)-1]
+	if len(cps) != 3 || last.ID != first.ID || last.Message != "rollback to "+first.Short() {
+		t.Fatalf("expected the rollback last in the history, got %+v", cps)
+	}
+	_, err = FindCheckpoint("Users", first.Short())
+	if err != nil {
+		t.Fatalf("expected an id repeated by a rollback to match once, got %v", err)
+	}
+
+	err = RunRollbackFromArgs("Posts", "")
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected an unknown datamodel to be not found, got %v", err)
+	}
+}
+
+func TestRollbackSplitDatamodel(t *testing.T) {
+	dir := inTempDir(t)
+	writeFile(t, filepath.Join(dir, "dm.cue"), firstUsersDM)
+	writeFile(t, filepath.Join(dir, "more.cue"), "package dm\n\nUsers: Models: User: nick?: string\n")
+
+	dms, err := loadDatamodels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = SaveCheckpoint("Users", dms["Users"], "split")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RunRollbackFromArgs("Users", "")
+	if err == nil || !strings.Contains(err.Error(), "is declared more than once") {
+		t.Fatalf("expected a datamodel in two files not to be rolled back, got %v", err)
+	}
+}
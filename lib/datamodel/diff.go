@@ -1,11 +1,305 @@
 package datamodel
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+// The kinds of changes to the fields of a datamodel
+const (
+	FieldAdded      = "added"
+	FieldRemoved    = "removed"
+	FieldRenamed    = "renamed"
+	FieldType       = "type"
+	FieldConstraint = "constraint"
 )
 
-func RunDiffFromArgs(args []string) error {
-	fmt.Println("lib/datamodel.Diff", args)
+// FieldChange is a change to a field of a datamodel. From and To are the Cue of the field,
+// before and after, and OldPath is where a renamed field was.
+type FieldChange struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// Diff is the changes to a datamodel from one ref to another, see loadRef.
+type Diff struct {
+	Datamodel string        `json:"datamodel"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// RunDiffFromArgs diffs the datamodels named in args, or all of them,
+// from their last checkpoint or cmdflags.From, to the workspace or cmdflags.To,
+// printing the changes as text or json. With ExitCode, changes are an error, for CI,
+// and with Checkpoint, changed datamodels are saved.
+func RunDiffFromArgs(args []string, cmdflags flags.DiffFlagpole) error {
+	if cmdflags.Format != "" && cmdflags.Format != "text" && cmdflags.Format != "json" {
+		return errs.WithCode(errs.CodeBadFormat, "", fmt.Errorf("Unknown diff format %q, should be text or json", cmdflags.Format))
+	}
+	if cmdflags.Checkpoint && cmdflags.To != "" {
+		return fmt.Errorf("--checkpoint saves the workspace, and can not be used with --to")
+	}
+
+	dms, err := loadDatamodels()
+	if err != nil {
+		return err
+	}
+	names, err := namesOf(dms, args)
+	if err != nil {
+		return err
+	}
+
+	diffs := []Diff{}
+	var changed []string
+	for _, name := range names {
+		prev, next, from, to, err := diffEnds(name, dms[name], cmdflags.From, cmdflags.To)
+		if err != nil {
+			// the first checkpoint is diffed against nothing
+			if !(cmdflags.Checkpoint && cmdflags.From == "" && errs.Code(err) == errs.CodeNotFound) {
+				return err
+			}
+			prev, next, from, to = emptyValue(), dms[name], "nothing", "workspace"
+		}
+
+		D := Diff{Datamodel: name, From: from, To: to, Changes: []FieldChange{}}
+		cs, err := DiffDatamodels(prev, next)
+		if err != nil {
+			return fmt.Errorf("While diffing datamodel %s\n%w\n", name, err)
+		}
+		D.Changes = append(D.Changes, cs...)
+
+		if len(D.Changes) > 0 {
+			changed = append(changed, name)
+			if cmdflags.Checkpoint {
+				C, _, err := SaveCheckpoint(name, next, "hof datamodel diff --checkpoint")
+				if err != nil {
+					return fmt.Errorf("While saving a checkpoint of datamodel %s\n%w\n", name, err)
+				}
+				fmt.Fprintf(os.Stderr, "saved checkpoint %s of datamodel %s\n", C.Short(), name)
+			}
+		}
+		diffs = append(diffs, D)
+	}
 
+	err = writeDiffs(os.Stdout, diffs, cmdflags.Format)
+	if err != nil {
+		return err
+	}
+
+	if cmdflags.ExitCode && len(changed) > 0 {
+		return errs.WithCode(errs.CodeChanged, "", fmt.Errorf("datamodels changed: %s", strings.Join(changed, ", ")))
+	}
 	return nil
 }
+
+// diffEnds returns a datamodel at from, see loadRef, and at to, or as it is in the workspace
+// when to is empty, along with the refs as they should be printed.
+func diffEnds(name string, dm cue.Value, from, to string) (prev, next cue.Value, fromRef, toRef string, err error) {
+	prev, fromRef, err = loadRef(name, from)
+	if err != nil {
+		return prev, next, fromRef, toRef, err
+	}
+	if to == "" {
+		return prev, dm, fromRef, "workspace", nil
+	}
+	next, toRef, err = loadRef(name, to)
+	return prev, next, fromRef, toRef, err
+}
+
+func writeDiffs(w io.Writer, diffs []Diff, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+
+	for _, D := range diffs {
+		fmt.Fprintf(w, "datamodel %s, %s -> %s\n", D.Datamodel, D.From, D.To)
+		if len(D.Changes) == 0 {
+			fmt.Fprintln(w, "  no changes")
+		}
+		for _, C := range D.Changes {
+			switch C.Kind {
+			case FieldAdded:
+				fmt.Fprintf(w, "  %-10s  %s: %s\n", C.Kind, C.Path, C.To)
+			case FieldRemoved:
+				fmt.Fprintf(w, "  %-10s  %s: %s\n", C.Kind, C.Path, C.From)
+			case FieldRenamed:
+				fmt.Fprintf(w, "  %-10s  %s -> %s\n", C.Kind, C.OldPath, C.Path)
+			default:
+				fmt.Fprintf(w, "  %-10s  %s: %s -> %s\n", C.Kind, C.Path, C.From, C.To)
+			}
+		}
+	}
+	return nil
+}
+
+// DiffDatamodels returns the changes to the fields from prev to next, in field order.
+// Fields are compared by their Cue, so a field is renamed when a field with the same Cue
+// was removed from the same struct, and the only such one. A change of kind,
+// such as string to int, is a type change, and others, such as bounds, regexps,
+// defaults, or becoming optional, are constraint changes.
+func DiffDatamodels(prev, next cue.Value) ([]FieldChange, error) {
+	return diffFields(nil, nil, prev, next)
+}
+
+// field is a field of a struct, with its Cue
+type field struct {
+	label    string
+	value    cue.Value
+	optional bool
+	src      string
+}
+
+func structFields(v cue.Value) ([]field, error) {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+	var fs []field
+	for iter.Next() {
+		src, err := fieldSource(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		fs = append(fs, field{label: iter.Label(), value: iter.Value(), optional: iter.IsOptional(), src: src})
+	}
+	return fs, nil
+}
+
+func fieldSource(v cue.Value) (string, error) {
+	data, err := format.Node(v.Syntax(cue.Optional(true)), format.Simplify())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func isStruct(v cue.Value) bool {
+	return v.IncompleteKind() == cue.StructKind
+}
+
+func diffFields(changes []FieldChange, path []string, prev, next cue.Value) ([]FieldChange, error) {
+	pfs, err := structFields(prev)
+	if err != nil {
+		return changes, err
+	}
+	nfs, err := structFields(next)
+	if err != nil {
+		return changes, err
+	}
+
+	nextByLabel := map[string]field{}
+	for _, f := range nfs {
+		nextByLabel[f.label] = f
+	}
+	prevByLabel := map[string]field{}
+	for _, f := range pfs {
+		prevByLabel[f.label] = f
+	}
+
+	var removed, added []field
+	for _, f := range nfs {
+		if _, ok := prevByLabel[f.label]; !ok {
+			added = append(added, f)
+		}
+	}
+
+	for _, pf := range pfs {
+		fpath := append(path[:len(path):len(path)], pf.label)
+		nf, ok := nextByLabel[pf.label]
+		if !ok {
+			removed = append(removed, pf)
+			continue
+		}
+
+		switch {
+		case isStruct(pf.value) && isStruct(nf.value):
+			changes, err = diffFields(changes, fpath, pf.value, nf.value)
+			if err != nil {
+				return changes, err
+			}
+			if pf.optional != nf.optional {
+				changes = append(changes, changeOf(FieldConstraint, fpath, pf, nf))
+			}
+
+		case pf.value.IncompleteKind() != nf.value.IncompleteKind():
+			changes = append(changes, changeOf(FieldType, fpath, pf, nf))
+
+		case pf.src != nf.src || pf.optional != nf.optional:
+			changes = append(changes, changeOf(FieldConstraint, fpath, pf, nf))
+		}
+	}
+
+	// pair removed and added fields with the same Cue, when there is only one of each
+	count := map[string]int{}
+	for _, f := range removed {
+		count["-"+f.src]++
+	}
+	for _, f := range added {
+		count["+"+f.src]++
+	}
+	renamedTo := map[string]string{}
+	renamedFrom := map[string]bool{}
+	for _, r := range removed {
+		for _, a := range added {
+			if r.src == a.src && count["-"+r.src] == 1 && count["+"+a.src] == 1 {
+				renamedTo[a.label] = r.label
+				renamedFrom[r.label] = true
+			}
+		}
+	}
+
+	for _, f := range removed {
+		if !renamedFrom[f.label] {
+			changes = append(changes, FieldChange{Kind: FieldRemoved, Path: structural.DotPath(append(path[:len(path):len(path)], f.label)), From: labelSource(f)})
+		}
+	}
+	for _, f := range added {
+		fpath := structural.DotPath(append(path[:len(path):len(path)], f.label))
+		if old, ok := renamedTo[f.label]; ok {
+			changes = append(changes, FieldChange{Kind: FieldRenamed, Path: fpath, OldPath: structural.DotPath(append(path[:len(path):len(path)], old))})
+			continue
+		}
+		changes = append(changes, FieldChange{Kind: FieldAdded, Path: fpath, To: labelSource(f)})
+	}
+
+	return changes, nil
+}
+
+func changeOf(kind string, path []string, prev, next field) FieldChange {
+	return FieldChange{Kind: kind, Path: structural.DotPath(path), From: labelSource(prev), To: labelSource(next)}
+}
+
+// labelSource is the Cue of a field for printing, structs are elided
+func labelSource(f field) string {
+	src := f.src
+	if isStruct(f.value) {
+		src = "{...}"
+	}
+	if f.optional {
+		src += " (optional)"
+	}
+	return src
+}
+
+func emptyValue() cue.Value {
+	var r cue.Runtime
+	I, _ := r.Compile("", "{}")
+	return I.Value()
+}
@@ -0,0 +1,206 @@
+package datamodel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+const usersDM = `package dm
+
+Users: {
+	Models: User: {
+		fullname: string
+		age:      string
+		email:    string
+		nick?:    string
+	}
+} @datamodel()
+`
+
+const nextUsersDM = `package dm
+
+Users: {
+	Models: User: {
+		name:  string
+		age:   int
+		email: =~"@"
+		nick?: string
+		admin: bool
+	}
+} @datamodel()
+`
+
+// inTempDir runs the rest of a test in a new directory, which checkpoints are relative to.
+// Tests using it can not be parallel.
+func inTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "hof-datamodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	olddir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(olddir)
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func writeFile(t *testing.T, file, content string) {
+	err := ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func compileDM(t *testing.T, src, name string) cue.Value {
+	var r cue.Runtime
+	I, err := r.Compile("dm.cue", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return I.Value().Lookup(name)
+}
+
+func TestDiffDatamodels(t *testing.T) {
+	prev := compileDM(t, usersDM, "Users")
+	next := compileDM(t, nextUsersDM, "Users")
+
+	changes, err := DiffDatamodels(prev, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []FieldChange{
+		{Kind: FieldType, Path: "Models.User.age", From: "string", To: "int"},
+		{Kind: FieldConstraint, Path: "Models.User.email", From: "string", To: `=~"@"`},
+		{Kind: FieldRenamed, Path: "Models.User.name", OldPath: "Models.User.fullname"},
+		{Kind: FieldAdded, Path: "Models.User.admin", To: "bool"},
+	}
+	// Cue v0.2 orders fields by when their labels were first seen, so changes are compared by path
+	byPath := func(cs []FieldChange) map[string]FieldChange {
+		m := map[string]FieldChange{}
+		for _, C := range cs {
+			m[C.Path] = C
+		}
+		return m
+	}
+	if len(changes) != len(expect) || !reflect.DeepEqual(byPath(changes), byPath(expect)) {
+		t.Fatalf("got %+v, want %+v", changes, expect)
+	}
+
+	changes, err = DiffDatamodels(prev, prev)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes to the same datamodel, got %+v, %v", changes, err)
+	}
+
+	// two removed fields of the same Cue are not a rename
+	prev = compileDM(t, "M: {a: string, b: string}", "M")
+	next = compileDM(t, "M: {c: string}", "M")
+	changes, err = DiffDatamodels(prev, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]string{}
+	for _, C := range changes {
+		kinds[C.Path] = C.Kind
+	}
+	if !reflect.DeepEqual(kinds, map[string]string{"a": FieldRemoved, "b": FieldRemoved, "c": FieldAdded}) {
+		t.Fatalf("expected an ambiguous rename to be removes and an add, got %+v", changes)
+	}
+}
+
+func TestWriteDiffs(t *testing.T) {
+	diffs := []Diff{{
+		Datamodel: "Users",
+		From:      "0123456789ab",
+		To:        "workspace",
+		Changes: []FieldChange{
+			{Kind: FieldRenamed, Path: "Models.User.name", OldPath: "Models.User.fullname"},
+			{Kind: FieldType, Path: "Models.User.age", From: "string", To: "int"},
+			{Kind: FieldAdded, Path: "Models.User.admin", To: "bool"},
+			{Kind: FieldRemoved, Path: "Models.User.nick", From: "string (optional)"},
+		},
+	}, {
+		Datamodel: "Blog",
+		From:      "nothing",
+		To:        "workspace",
+		Changes:   []FieldChange{},
+	}}
+
+	var buf bytes.Buffer
+	err := writeDiffs(&buf, diffs, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `datamodel Users, 0123456789ab -> workspace
+  renamed     Models.User.fullname -> Models.User.name
+  type        Models.User.age: string -> int
+  added       Models.User.admin: bool
+  removed     Models.User.nick: string (optional)
+datamodel Blog, nothing -> workspace
+  no changes
+`
+	if buf.String() != expect {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), expect)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := inTempDir(t)
+	writeFile(t, filepath.Join(dir, "dm.cue"), usersDM)
+
+	err := RunDiffFromArgs(nil, flags.DiffFlagpole{})
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected no checkpoint to diff from, got %v", err)
+	}
+
+	// the first checkpoint is diffed against nothing
+	err = RunDiffFromArgs(nil, flags.DiffFlagpole{Checkpoint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cps, err := History("Users")
+	if err != nil || len(cps) != 1 || cps[0].Message != "hof datamodel diff --checkpoint" {
+		t.Fatalf("expected a checkpoint saved by diff, got %+v, %v", cps, err)
+	}
+
+	err = RunDiffFromArgs(nil, flags.DiffFlagpole{ExitCode: true})
+	if err != nil {
+		t.Fatalf("expected no changes since the checkpoint, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "dm.cue"), nextUsersDM)
+	err = RunDiffFromArgs([]string{"Users"}, flags.DiffFlagpole{ExitCode: true})
+	if errs.Code(err) != errs.CodeChanged || !strings.Contains(err.Error(), "datamodels changed: Users") {
+		t.Fatalf("expected the changes to be an error, got %v", err)
+	}
+
+	err = RunDiffFromArgs([]string{"Posts"}, flags.DiffFlagpole{})
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected an unknown datamodel to be not found, got %v", err)
+	}
+	err = RunDiffFromArgs(nil, flags.DiffFlagpole{Checkpoint: true, To: "~0"})
+	if err == nil || !strings.Contains(err.Error(), "can not be used with --to") {
+		t.Fatalf("expected --checkpoint with --to to fail, got %v", err)
+	}
+}
@@ -2,9 +2,17 @@ package datamodel
 
 import (
 	"fmt"
+
+	"github.com/hofstadter-io/hof/lib/printer"
 )
 
-func RunGetFromArgs(args []string) error {
+// RunGetFromArgs prints in format, see the printer package
+func RunGetFromArgs(args []string, format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("lib/datamodel.Get", args)
 
 	return nil
@@ -2,10 +2,72 @@ package datamodel
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
 )
 
+// RunHistoryFromArgs prints the checkpoints of the datamodels named in args, or all of them, newest first.
 func RunHistoryFromArgs(args []string) error {
-	fmt.Println("lib/datamodel.History", args)
+	dms, err := loadDatamodels()
+	if err != nil {
+		return err
+	}
+	names, err := namesOf(dms, args)
+	if err != nil {
+		return err
+	}
 
+	for _, name := range names {
+		cps, err := History(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("datamodel %s\n", name)
+		if len(cps) == 0 {
+			fmt.Println("  no checkpoints")
+			continue
+		}
+
+		// the workspace is compared with the last checkpoint
+		_, id, err := checkpointSource(dms[name])
+		if err != nil {
+			return err
+		}
+		if id != cps[len(cps)-1].ID {
+			fmt.Println("  (workspace has changes)")
+		}
+
+		for i := len(cps) - 1; i >= 0; i-- {
+			C := cps[i]
+			fmt.Printf("  %s  %s  %s\n", C.Short(), C.Time.Local().Format("2006-01-02 15:04:05"), firstLine(C.Message))
+		}
+	}
+	return nil
+}
+
+// RunShowFromArgs prints the Cue of a datamodel at a checkpoint, see FindCheckpoint.
+func RunShowFromArgs(name, ref string) error {
+	C, err := FindCheckpoint(name, ref)
+	if err != nil {
+		return err
+	}
+
+	src, err := ioutil.ReadFile(objectFile(name, C.ID))
+	if err != nil {
+		return fmt.Errorf("While reading checkpoint %s of datamodel %s\n%w\n", C.Short(), name, err)
+	}
+
+	fmt.Printf("// datamodel %s, checkpoint %s, %s\n", name, C.Short(), C.Time.Local().Format("2006-01-02 15:04:05"))
+	if C.Message != "" {
+		for _, line := range strings.Split(strings.TrimSpace(C.Message), "\n") {
+			fmt.Printf("// %s\n", line)
+		}
+	}
+	fmt.Print(string(src))
 	return nil
 }
+
+func firstLine(s string) string {
+	return strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+}
@@ -1,11 +1,231 @@
 package datamodel
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// The ops of a migration step, applied in this order
+const (
+	StepRename = "rename"
+	StepAdd    = "add"
+	StepAlter  = "alter"
+	StepDrop   = "drop"
 )
 
-func RunMigrateFromArgs(args []string) error {
-	fmt.Println("lib/datamodel.Migrate", args)
+// migrationVersion names SQL migrations, so they sort by time
+const migrationVersion = "20060102150405"
+
+var stepOrder = []string{StepRename, StepAdd, StepAlter, StepDrop}
+
+// Step is a step of a migration, from a change to a field, see FieldChange.
+type Step struct {
+	Op      string `json:"op"`
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// Migration is the steps taking a datamodel from one ref to another, see loadRef.
+type Migration struct {
+	Datamodel string `json:"datamodel"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Steps     []Step `json:"steps"`
+}
+
+// RunMigrateFromArgs calculates the migrations of the datamodels named in args, or all of them,
+// from their last checkpoint or cmdflags.From, to the workspace or cmdflags.To,
+// printing them as text or json. Datamodels without checkpoints are migrated from nothing.
+// With cmdflags.Sql, up and down SQL migrations are written instead, see writeSQLMigration.
+func RunMigrateFromArgs(args []string, cmdflags flags.MigrateFlagpole) error {
+	if cmdflags.Format != "" && cmdflags.Format != "text" && cmdflags.Format != "json" {
+		return errs.WithCode(errs.CodeBadFormat, "", fmt.Errorf("Unknown migrate format %q, should be text or json", cmdflags.Format))
+	}
+	var dialect SQLDialect
+	if cmdflags.Sql != "" {
+		D, ok := SQLDialects[cmdflags.Sql]
+		if !ok {
+			return errs.WithCode(errs.CodeBadFormat, "", fmt.Errorf("Unknown SQL dialect %q, should be postgres or mysql", cmdflags.Sql))
+		}
+		dialect = D
+	}
+
+	dms, err := loadDatamodels()
+	if err != nil {
+		return err
+	}
+	names, err := namesOf(dms, args)
+	if err != nil {
+		return err
+	}
+
+	// all migrations written together share a version
+	version := time.Now().UTC().Format(migrationVersion)
+
+	migs := []Migration{}
+	for _, name := range names {
+		prev, next, from, to, err := diffEnds(name, dms[name], cmdflags.From, cmdflags.To)
+		if err != nil {
+			if !(cmdflags.From == "" && cmdflags.To == "" && errs.Code(err) == errs.CodeNotFound) {
+				return err
+			}
+			prev, next, from, to = emptyValue(), dms[name], "nothing", "workspace"
+		}
+		cs, err := DiffDatamodels(prev, next)
+		if err != nil {
+			return fmt.Errorf("While diffing datamodel %s\n%w\n", name, err)
+		}
+		M := Migration{Datamodel: name, From: from, To: to, Steps: MigrationSteps(cs)}
+		migs = append(migs, M)
+
+		if cmdflags.Sql != "" {
+			err = writeSQLMigration(cmdflags.Dir, version, cmdflags.Name, M, prev, next, dialect)
+			if err != nil {
+				return fmt.Errorf("While writing the SQL migration of datamodel %s\n%w\n", name, err)
+			}
+		}
+	}
+
+	if cmdflags.Sql != "" {
+		return nil
+	}
+	return writeMigrations(os.Stdout, migs, cmdflags.Format)
+}
+
+// writeSQLMigration writes the SQL migrating a datamodel from prev to next, and back, as
+//
+//   <dir>/<datamodel>/<version>_<title>.up.sql
+//   <dir>/<datamodel>/<version>_<title>.down.sql
+//
+// which runners such as golang-migrate read, versions being times so they sort.
+// The title defaults to where the migration goes. Nothing is written without changes.
+func writeSQLMigration(dir, version, title string, M Migration, prev, next cue.Value, D SQLDialect) error {
+	prevT, err := SQLTables(prev)
+	if err != nil {
+		return err
+	}
+	nextT, err := SQLTables(next)
+	if err != nil {
+		return err
+	}
+
+	up, err := SQLMigration(prevT, nextT, D)
+	if err != nil {
+		return err
+	}
+	down, err := SQLMigration(nextT, prevT, D)
+	if err != nil {
+		return err
+	}
+	if len(up) == 0 {
+		fmt.Printf("datamodel %s has no SQL changes, %s -> %s\n", M.Datamodel, M.From, M.To)
+		return nil
+	}
+
+	if dir == "" {
+		dir = "migrations"
+	}
+	if title == "" {
+		title = "to_" + M.To
+	}
+	dir = filepath.Join(dir, M.Datamodel)
+	err = yagu.Mkdir(dir)
+	if err != nil {
+		return err
+	}
+
+	header := fmt.Sprintf("-- hof datamodel migrate, %s %s -> %s, %s\n", M.Datamodel, M.From, M.To, D.Name)
+	for _, file := range []struct {
+		direction string
+		stmts     []string
+	}{{"up", up}, {"down", down}} {
+		fn := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, sqlTitle(title), file.direction))
+		content := header + "\n" + strings.Join(file.stmts, "\n\n") + "\n"
+		err = ioutil.WriteFile(fn, []byte(content), 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Println("wrote", fn)
+	}
+	return nil
+}
+
+// sqlTitle makes a title safe for file names, as runners expect
+func sqlTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, title)
+}
+
+// MigrationSteps orders the changes to a datamodel as steps,
+// renames first, so that later steps use the new names, and drops last.
+func MigrationSteps(changes []FieldChange) []Step {
+	byOp := map[string][]Step{}
+	for _, C := range changes {
+		S := Step{Path: C.Path, OldPath: C.OldPath, From: C.From, To: C.To}
+		switch C.Kind {
+		case FieldRenamed:
+			S.Op = StepRename
+		case FieldAdded:
+			S.Op = StepAdd
+		case FieldRemoved:
+			S.Op = StepDrop
+		default:
+			S.Op = StepAlter
+		}
+		byOp[S.Op] = append(byOp[S.Op], S)
+	}
+
+	steps := []Step{}
+	for _, op := range stepOrder {
+		steps = append(steps, byOp[op]...)
+	}
+	return steps
+}
+
+func writeMigrations(w io.Writer, migs []Migration, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(migs)
+	}
 
+	for _, M := range migs {
+		fmt.Fprintf(w, "migration %s, %s -> %s\n", M.Datamodel, M.From, M.To)
+		if len(M.Steps) == 0 {
+			fmt.Fprintln(w, "  no steps")
+		}
+		for i, S := range M.Steps {
+			switch S.Op {
+			case StepAdd:
+				fmt.Fprintf(w, "  %d. %-6s  %s: %s\n", i+1, S.Op, S.Path, S.To)
+			case StepDrop:
+				fmt.Fprintf(w, "  %d. %-6s  %s: %s\n", i+1, S.Op, S.Path, S.From)
+			case StepRename:
+				fmt.Fprintf(w, "  %d. %-6s  %s to %s\n", i+1, S.Op, S.OldPath, S.Path)
+			default:
+				fmt.Fprintf(w, "  %d. %-6s  %s: %s -> %s\n", i+1, S.Op, S.Path, S.From, S.To)
+			}
+		}
+	}
 	return nil
 }
@@ -0,0 +1,125 @@
+package datamodel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+const blogDM = `package dm
+
+Blog: {
+	Models: {
+		User: {
+			Name:  "User"
+			id:    int    @sql(primary)
+			email: string @sql(unique)
+			bio?:  string
+		}
+		Post: {
+			Name:   "Post"
+			id:     int @sql(primary)
+			userId: int @sql(references=User.id)
+			draft:  *true | bool
+		}
+	}
+} @datamodel()
+`
+
+func TestMigrationSteps(t *testing.T) {
+	steps := MigrationSteps([]FieldChange{
+		{Kind: FieldRemoved, Path: "M.old", From: "int"},
+		{Kind: FieldAdded, Path: "M.admin", To: "bool"},
+		{Kind: FieldType, Path: "M.age", From: "string", To: "int"},
+		{Kind: FieldRenamed, Path: "M.name", OldPath: "M.fullname"},
+	})
+
+	var buf bytes.Buffer
+	err := writeMigrations(&buf, []Migration{{Datamodel: "M", From: "nothing", To: "workspace", Steps: steps}}, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `migration M, nothing -> workspace
+  1. rename  M.fullname to M.name
+  2. add     M.admin: bool
+  3. alter   M.age: string -> int
+  4. drop    M.old: int
+`
+	if buf.String() != expect {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), expect)
+	}
+}
+
+func TestRunMigrateSQL(t *testing.T) {
+	dir := inTempDir(t)
+	writeFile(t, filepath.Join(dir, "dm.cue"), blogDM)
+
+	// without checkpoints, the migration is from nothing
+	err := RunMigrateFromArgs(nil, flags.MigrateFlagpole{Sql: "postgres", Dir: "migrations", Name: "Init Blog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "migrations", "Blog", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || !strings.HasSuffix(files[0], "_init_blog.down.sql") || !strings.HasSuffix(files[1], "_init_blog.up.sql") {
+		t.Fatalf("expected up and down migrations, got %v", files)
+	}
+	if version := strings.SplitN(filepath.Base(files[0]), "_", 2)[0]; len(version) != len(migrationVersion) {
+		t.Fatalf("expected a time as the version, got %s", version)
+	}
+
+	data, err := ioutil.ReadFile(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := string(data)
+	for _, s := range []string{
+		"-- hof datamodel migrate, Blog nothing -> workspace, postgres\n",
+		`CREATE TABLE "User" (`,
+		`CREATE TABLE "Post" (`,
+		`"draft" BOOLEAN NOT NULL DEFAULT TRUE`,
+		`CREATE UNIQUE INDEX "User_email_key" ON "User" ("email");`,
+		`FOREIGN KEY ("userId") REFERENCES "User" ("id");`,
+	} {
+		if !strings.Contains(up, s) {
+			t.Errorf("expected the up migration to have %q, got:\n%s", s, up)
+		}
+	}
+	data, err = ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if down := string(data); !strings.Contains(down, `DROP TABLE "User";`) || !strings.Contains(down, `DROP TABLE "Post";`) {
+		t.Errorf("expected the down migration to drop the tables, got:\n%s", down)
+	}
+
+	// nothing is written when the workspace is as checkpointed
+	err = RunCheckpointFromArgs(nil, flags.CheckpointFlagpole{Message: "blog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RunMigrateFromArgs(nil, flags.MigrateFlagpole{Sql: "mysql", Dir: "again"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "again", "*", "*"))
+	if len(files) != 0 {
+		t.Fatalf("expected no migrations without changes, got %v", files)
+	}
+
+	err = RunMigrateFromArgs(nil, flags.MigrateFlagpole{Sql: "oracle"})
+	if errs.Code(err) != errs.CodeBadFormat {
+		t.Fatalf("expected an unknown dialect to fail, got %v", err)
+	}
+	err = RunMigrateFromArgs(nil, flags.MigrateFlagpole{From: "~1"})
+	if errs.Code(err) != errs.CodeNotFound {
+		t.Fatalf("expected a missing checkpoint not to be migrated from nothing, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package datamodel
+
+import (
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// Names returns the data models of the workspace, the fields with a @datamodel() attribute
+// in --datamodel-dir, or the current directory.
+func Names() []string {
+	dir := "."
+	if flags.RootDatamodelDirPflag != "" {
+		dir = flags.RootDatamodelDirPflag
+	}
+	return cuetils.AttrFieldNames(dir, "datamodel")
+}
@@ -0,0 +1,44 @@
+package datamodel
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+func TestNames(t *testing.T) {
+	dir := inTempDir(t)
+	writeFile(t, filepath.Join(dir, "dm.cue"), firstUsersDM)
+
+	err := os.Mkdir(filepath.Join(dir, "models"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "models", "blog.cue"), `package models
+
+Posts: {} @datamodel()
+Blog: {} @datamodel()
+`)
+
+	old := flags.RootDatamodelDirPflag
+	defer func() { flags.RootDatamodelDirPflag = old }()
+
+	tests := []struct {
+		dir    string
+		expect []string
+	}{
+		{dir: "", expect: []string{"Users"}},
+		{dir: "models", expect: []string{"Blog", "Posts"}},
+		{dir: filepath.Join(dir, "models"), expect: []string{"Blog", "Posts"}},
+		{dir: "missing"},
+	}
+	for _, tt := range tests {
+		flags.RootDatamodelDirPflag = tt.dir
+		if got := Names(); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q: got %q, want %q", tt.dir, got, tt.expect)
+		}
+	}
+}
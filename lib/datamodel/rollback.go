@@ -0,0 +1,112 @@
+package datamodel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+)
+
+// RunRollbackFromArgs rewrites a datamodel in the workspace as it was at a checkpoint,
+// see FindCheckpoint. The datamodel is checkpointed first, so the rollback can be undone,
+// and the checkpoint rolled back to is then recorded again, as the last.
+func RunRollbackFromArgs(name, ref string) error {
+	dms, err := loadDatamodels()
+	if err != nil {
+		return err
+	}
+	if _, err := namesOf(dms, []string{name}); err != nil {
+		return err
+	}
+
+	C, err := FindCheckpoint(name, ref)
+	if err != nil {
+		return err
+	}
+	file := objectFile(name, C.ID)
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("While reading checkpoint %s of datamodel %s\n%w\n", C.Short(), name, err)
+	}
+	expr, err := parser.ParseExpr(file, src)
+	if err != nil {
+		return err
+	}
+
+	f, field, err := datamodelField(name)
+	if err != nil {
+		return err
+	}
+
+	before, saved, err := SaveCheckpoint(name, dms[name], fmt.Sprintf("before rollback to %s", C.Short()))
+	if err != nil {
+		return fmt.Errorf("While saving a checkpoint of datamodel %s\n%w\n", name, err)
+	}
+	if saved {
+		fmt.Printf("saved checkpoint %s of datamodel %s\n", before.Short(), name)
+	}
+
+	// the attributes stay with the field, only the value is replaced
+	field.Value = expr
+	out, err := format.Node(f, format.Simplify())
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(f.Filename, out, 0644)
+	if err != nil {
+		return err
+	}
+
+	cps, err := History(name)
+	if err != nil {
+		return err
+	}
+	R := C
+	R.Time, R.Message = time.Now().UTC(), fmt.Sprintf("rollback to %s", C.Short())
+	if cps[len(cps)-1].ID != C.ID {
+		err = addCheckpoint(name, cps, R, src)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("rolled back datamodel %s to checkpoint %s in %s\n", name, C.Short(), f.Filename)
+	return nil
+}
+
+// datamodelField returns the field which declares a datamodel, and its file.
+// Datamodels declared across several fields or files can not be rewritten.
+func datamodelField(name string) (*ast.File, *ast.Field, error) {
+	var file *ast.File
+	var field *ast.Field
+	for _, bi := range load.Instances([]string{datamodelDir()}, nil) {
+		if bi.Err != nil {
+			return nil, nil, bi.Err
+		}
+		for _, f := range bi.Files {
+			for _, decl := range f.Decls {
+				df, ok := decl.(*ast.Field)
+				if !ok {
+					continue
+				}
+				label, _, _ := ast.LabelName(df.Label)
+				if label != name {
+					continue
+				}
+				if field != nil {
+					return nil, nil, fmt.Errorf("datamodel %s is declared more than once, in %s and %s, and can not be rolled back", name, file.Filename, f.Filename)
+				}
+				file, field = f, df
+			}
+		}
+	}
+
+	if field == nil {
+		return nil, nil, fmt.Errorf("datamodel %s is not declared at the top of a file, and can not be rolled back", name)
+	}
+	return file, field, nil
+}
@@ -0,0 +1,544 @@
+package datamodel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Table is a model of a datamodel, as a SQL table, see SQLTables.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column is a field of a model, as a SQL column.
+type Column struct {
+	Name string
+
+	// Kind is the Cue kind, and Type the SQL type from @sql(type=...), which wins
+	Kind string
+	Type string
+
+	Nullable bool
+
+	// Default is the SQL of the default, from the Cue default or @sql(default=...)
+	Default string
+
+	Primary bool
+	Unique  bool
+	Index   bool
+
+	// References is the <table>.<column> of a foreign key
+	References string
+
+	// src is the Cue of the field, renamed columns and tables are found by it
+	src string
+}
+
+// SQLDialect is how a database spells the statements of a migration.
+type SQLDialect struct {
+	Name  string
+	quote string
+	types map[string]string
+
+	// zeros are the SQL of the Cue zero values, by kind, see addColumn
+	zeros map[string]string
+}
+
+// The SQL dialects migrations are written in, by name
+var SQLDialects = map[string]SQLDialect{
+	"postgres": {
+		Name:  "postgres",
+		quote: `"`,
+		types: map[string]string{
+			"string": "TEXT",
+			"int":    "BIGINT",
+			"float":  "DOUBLE PRECISION",
+			"number": "DOUBLE PRECISION",
+			"bool":   "BOOLEAN",
+			"bytes":  "BYTEA",
+			"struct": "JSONB",
+			"list":   "JSONB",
+		},
+		zeros: map[string]string{
+			"string": "''",
+			"int":    "0",
+			"float":  "0",
+			"number": "0",
+			"bool":   "FALSE",
+			"bytes":  "''",
+			"struct": "'{}'",
+			"list":   "'[]'",
+		},
+	},
+	"mysql": {
+		Name:  "mysql",
+		quote: "`",
+		types: map[string]string{
+			// TEXT can not be indexed without a length
+			"string": "VARCHAR(255)",
+			"int":    "BIGINT",
+			"float":  "DOUBLE",
+			"number": "DOUBLE",
+			"bool":   "BOOLEAN",
+			"bytes":  "BLOB",
+			"struct": "JSON",
+			"list":   "JSON",
+		},
+		// BLOB and JSON columns can not have literal defaults
+		zeros: map[string]string{
+			"string": "''",
+			"int":    "0",
+			"float":  "0",
+			"number": "0",
+			"bool":   "FALSE",
+		},
+	},
+}
+
+func (d SQLDialect) ident(name string) string {
+	return d.quote + strings.ReplaceAll(name, d.quote, d.quote+d.quote) + d.quote
+}
+
+func (d SQLDialect) columnType(C Column) (string, error) {
+	if C.Type != "" {
+		return C.Type, nil
+	}
+	if t, ok := d.types[C.Kind]; ok {
+		return t, nil
+	}
+	return "", fmt.Errorf("column %s has no SQL type for Cue kind %q, set one with @sql(type=...)", C.Name, C.Kind)
+}
+
+func (d SQLDialect) columnDef(C Column) (string, error) {
+	t, err := d.columnType(C)
+	if err != nil {
+		return "", err
+	}
+	def := d.ident(C.Name) + " " + t
+	if !C.Nullable {
+		def += " NOT NULL"
+	}
+	if C.Default != "" {
+		def += " DEFAULT " + C.Default
+	}
+	return def, nil
+}
+
+// sqlIndex is an index, or a foreign key when References is set
+type sqlIndex struct {
+	Name       string
+	Table      string
+	Column     string
+	Unique     bool
+	References string
+}
+
+func indexesOf(T Table) (idxs, fkeys []sqlIndex) {
+	for _, C := range T.Columns {
+		switch {
+		case C.Unique:
+			idxs = append(idxs, sqlIndex{Name: T.Name + "_" + C.Name + "_key", Table: T.Name, Column: C.Name, Unique: true})
+		case C.Index:
+			idxs = append(idxs, sqlIndex{Name: T.Name + "_" + C.Name + "_idx", Table: T.Name, Column: C.Name})
+		}
+		if C.References != "" {
+			fkeys = append(fkeys, sqlIndex{Name: T.Name + "_" + C.Name + "_fkey", Table: T.Name, Column: C.Name, References: C.References})
+		}
+	}
+	return idxs, fkeys
+}
+
+func (d SQLDialect) createIndex(I sqlIndex) string {
+	unique := ""
+	if I.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, d.ident(I.Name), d.ident(I.Table), d.ident(I.Column))
+}
+
+func (d SQLDialect) dropIndex(I sqlIndex) string {
+	if d.Name == "mysql" {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", d.ident(I.Name), d.ident(I.Table))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", d.ident(I.Name))
+}
+
+func (d SQLDialect) addForeignKey(I sqlIndex) (string, error) {
+	flds := strings.SplitN(I.References, ".", 2)
+	if len(flds) != 2 {
+		return "", fmt.Errorf("column %s.%s references %q, which should be <table>.<column>", I.Table, I.Column, I.References)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s);",
+		d.ident(I.Table), d.ident(I.Name), d.ident(I.Column), d.ident(flds[0]), d.ident(flds[1])), nil
+}
+
+func (d SQLDialect) dropForeignKey(I sqlIndex) string {
+	if d.Name == "mysql" {
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", d.ident(I.Table), d.ident(I.Name))
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", d.ident(I.Table), d.ident(I.Name))
+}
+
+func (d SQLDialect) addPrimaryKey(table string, columns []string) string {
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = d.ident(c)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", d.ident(table), strings.Join(cols, ", "))
+}
+
+func (d SQLDialect) dropPrimaryKey(table string) string {
+	if d.Name == "mysql" {
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", d.ident(table))
+	}
+	// the name Postgres gives primary keys, which is kept when tables are renamed
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", d.ident(table), d.ident(table+"_pkey"))
+}
+
+func (d SQLDialect) createTable(T Table) (string, error) {
+	var lines, primary []string
+	for _, C := range T.Columns {
+		def, err := d.columnDef(C)
+		if err != nil {
+			return "", fmt.Errorf("table %s: %w", T.Name, err)
+		}
+		lines = append(lines, "  "+def)
+		if C.Primary {
+			primary = append(primary, d.ident(C.Name))
+		}
+	}
+	if len(primary) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primary, ", ")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", d.ident(T.Name), strings.Join(lines, ",\n")), nil
+}
+
+// addColumn returns the statements adding a column. Rows already in the table need a value
+// for a column which is NOT NULL, so one without a default is added with the zero value
+// of its kind, which is then dropped as the default.
+func (d SQLDialect) addColumn(table string, C Column) ([]string, error) {
+	t := d.ident(table)
+	if !C.Nullable && C.Default == "" {
+		zero, ok := d.zeros[C.Kind]
+		if C.Type != "" || !ok {
+			// a type error is clearer, when there is one
+			if _, err := d.columnType(C); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("column %s is added NOT NULL without a default, which the rows of the table do not have a value for, make it optional, or give it a Cue default or @sql(default=...)", C.Name)
+		}
+		B := C
+		B.Default = zero
+		def, err := d.columnDef(B)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", t, def),
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", t, d.ident(C.Name)),
+		}, nil
+	}
+
+	def, err := d.columnDef(C)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", t, def)}, nil
+}
+
+// alterColumn returns the statements changing the type, nullability, or default of a column.
+func (d SQLDialect) alterColumn(table string, prev, next Column) ([]string, error) {
+	pt, err := d.columnType(prev)
+	if err != nil {
+		return nil, err
+	}
+	nt, err := d.columnType(next)
+	if err != nil {
+		return nil, err
+	}
+	if pt == nt && prev.Nullable == next.Nullable && prev.Default == next.Default {
+		return nil, nil
+	}
+
+	t, c := d.ident(table), d.ident(next.Name)
+	if d.Name == "mysql" {
+		def, err := d.columnDef(next)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", t, def)}, nil
+	}
+
+	var stmts []string
+	if pt != nt {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;", t, c, nt, c, nt))
+	}
+	if prev.Nullable != next.Nullable {
+		op := "SET NOT NULL"
+		if next.Nullable {
+			op = "DROP NOT NULL"
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", t, c, op))
+	}
+	if prev.Default != next.Default {
+		op := "DROP DEFAULT"
+		if next.Default != "" {
+			op = "SET DEFAULT " + next.Default
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", t, c, op))
+	}
+	return stmts, nil
+}
+
+// renames pairs removed and added items with the same src, when there is only one of each,
+// as DiffDatamodels does for fields, returning the new names by the old.
+func renames(removed, added map[string]string) map[string]string {
+	count := map[string]int{}
+	for _, src := range removed {
+		count["-"+src]++
+	}
+	for _, src := range added {
+		count["+"+src]++
+	}
+	found := map[string]string{}
+	for from, rsrc := range removed {
+		for to, asrc := range added {
+			if rsrc == asrc && count["-"+rsrc] == 1 && count["+"+asrc] == 1 {
+				found[from] = to
+			}
+		}
+	}
+	return found
+}
+
+func tableSource(T Table) string {
+	var b strings.Builder
+	for _, C := range T.Columns {
+		fmt.Fprintf(&b, "%s: %s\n", C.Name, C.src)
+	}
+	return b.String()
+}
+
+// SQLMigration returns the statements migrating a database from the prev tables to the next, in order:
+// foreign keys and indexes which change are dropped, tables and columns are renamed, created,
+// and altered, then columns and tables are dropped, and indexes and foreign keys are created.
+// Swap prev and next for the down migration.
+func SQLMigration(prev, next []Table, d SQLDialect) ([]string, error) {
+	prevT, nextT := map[string]Table{}, map[string]Table{}
+	for _, T := range prev {
+		prevT[T.Name] = T
+	}
+	for _, T := range next {
+		nextT[T.Name] = T
+	}
+
+	removed, added := map[string]string{}, map[string]string{}
+	for _, T := range prev {
+		if _, ok := nextT[T.Name]; !ok {
+			removed[T.Name] = tableSource(T)
+		}
+	}
+	for _, T := range next {
+		if _, ok := prevT[T.Name]; !ok {
+			added[T.Name] = tableSource(T)
+		}
+	}
+	tableRenames := renames(removed, added)
+	renamedTo := map[string]bool{}
+	for _, to := range tableRenames {
+		renamedTo[to] = true
+	}
+
+	// indexes and foreign keys are compared whole, named by their table,
+	// so any change, or renaming the table, drops and creates them
+	pIdx, pFk := map[string]sqlIndex{}, map[string]sqlIndex{}
+	nIdx, nFk := map[string]sqlIndex{}, map[string]sqlIndex{}
+	for _, T := range prev {
+		idxs, fkeys := indexesOf(T)
+		for _, I := range idxs {
+			pIdx[I.Name] = I
+		}
+		for _, I := range fkeys {
+			pFk[I.Name] = I
+		}
+	}
+	for _, T := range next {
+		idxs, fkeys := indexesOf(T)
+		for _, I := range idxs {
+			nIdx[I.Name] = I
+		}
+		for _, I := range fkeys {
+			nFk[I.Name] = I
+		}
+	}
+
+	var (
+		dropFks, dropIdxs, renameTables, createTables []string
+		renameCols, addCols, alterCols, dropCols      []string
+		dropTables, createIdxs, addFks                []string
+	)
+
+	for _, name := range sortedKeys(pFk) {
+		if I, ok := nFk[name]; !ok || I != pFk[name] {
+			dropFks = append(dropFks, d.dropForeignKey(pFk[name]))
+		}
+	}
+	for _, name := range sortedKeys(pIdx) {
+		if I, ok := nIdx[name]; !ok || I != pIdx[name] {
+			dropIdxs = append(dropIdxs, d.dropIndex(pIdx[name]))
+		}
+	}
+
+	for _, T := range prev {
+		if to, ok := tableRenames[T.Name]; ok {
+			renameTables = append(renameTables, fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", d.ident(T.Name), d.ident(to)))
+			// Postgres keeps the name of the primary key, which dropPrimaryKey expects to be by the table
+			if d.Name == "postgres" && hasPrimaryKey(T) {
+				renameTables = append(renameTables, fmt.Sprintf("ALTER TABLE %s RENAME CONSTRAINT %s TO %s;",
+					d.ident(to), d.ident(T.Name+"_pkey"), d.ident(to+"_pkey")))
+			}
+			continue
+		}
+		if _, ok := nextT[T.Name]; !ok {
+			dropTables = append(dropTables, fmt.Sprintf("DROP TABLE %s;", d.ident(T.Name)))
+		}
+	}
+
+	for _, N := range next {
+		P, ok := prevT[N.Name]
+		if !ok && !renamedTo[N.Name] {
+			stmt, err := d.createTable(N)
+			if err != nil {
+				return nil, err
+			}
+			createTables = append(createTables, stmt)
+			continue
+		}
+		if renamedTo[N.Name] {
+			// the same columns, by how renames are found
+			continue
+		}
+
+		t := d.ident(N.Name)
+		pCols, nCols := map[string]Column{}, map[string]Column{}
+		for _, C := range P.Columns {
+			pCols[C.Name] = C
+		}
+		for _, C := range N.Columns {
+			nCols[C.Name] = C
+		}
+		removed, added := map[string]string{}, map[string]string{}
+		for _, C := range P.Columns {
+			if _, ok := nCols[C.Name]; !ok {
+				removed[C.Name] = C.src
+			}
+		}
+		for _, C := range N.Columns {
+			if _, ok := pCols[C.Name]; !ok {
+				added[C.Name] = C.src
+			}
+		}
+		colRenames := renames(removed, added)
+
+		// renamed columns stay in the primary key
+		var pPrimary, nPrimary []string
+		for _, C := range P.Columns {
+			if to, ok := colRenames[C.Name]; ok {
+				C.Name = to
+			}
+			if C.Primary {
+				pPrimary = append(pPrimary, C.Name)
+			}
+		}
+		for _, C := range N.Columns {
+			if C.Primary {
+				nPrimary = append(nPrimary, C.Name)
+			}
+		}
+		if strings.Join(pPrimary, ",") != strings.Join(nPrimary, ",") {
+			if len(pPrimary) > 0 {
+				dropIdxs = append(dropIdxs, d.dropPrimaryKey(P.Name))
+			}
+			if len(nPrimary) > 0 {
+				createIdxs = append(createIdxs, d.addPrimaryKey(N.Name, nPrimary))
+			}
+		}
+
+		colRenamedTo := map[string]bool{}
+		for _, C := range P.Columns {
+			if to, ok := colRenames[C.Name]; ok {
+				colRenamedTo[to] = true
+				renameCols = append(renameCols, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", t, d.ident(C.Name), d.ident(to)))
+				continue
+			}
+			if _, ok := nCols[C.Name]; !ok {
+				dropCols = append(dropCols, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", t, d.ident(C.Name)))
+			}
+		}
+
+		for _, C := range N.Columns {
+			if colRenamedTo[C.Name] {
+				continue
+			}
+			PC, ok := pCols[C.Name]
+			if !ok {
+				stmts, err := d.addColumn(N.Name, C)
+				if err != nil {
+					return nil, fmt.Errorf("table %s: %w", N.Name, err)
+				}
+				addCols = append(addCols, stmts...)
+				continue
+			}
+			stmts, err := d.alterColumn(N.Name, PC, C)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", N.Name, err)
+			}
+			alterCols = append(alterCols, stmts...)
+		}
+	}
+
+	for _, name := range sortedKeys(nIdx) {
+		if I, ok := pIdx[name]; !ok || I != nIdx[name] {
+			createIdxs = append(createIdxs, d.createIndex(nIdx[name]))
+		}
+	}
+	for _, name := range sortedKeys(nFk) {
+		if I, ok := pFk[name]; !ok || I != nFk[name] {
+			stmt, err := d.addForeignKey(nFk[name])
+			if err != nil {
+				return nil, err
+			}
+			addFks = append(addFks, stmt)
+		}
+	}
+
+	var stmts []string
+	for _, group := range [][]string{
+		dropFks, dropIdxs, renameTables, createTables,
+		renameCols, addCols, alterCols, dropCols,
+		dropTables, createIdxs, addFks,
+	} {
+		stmts = append(stmts, group...)
+	}
+	return stmts, nil
+}
+
+func hasPrimaryKey(T Table) bool {
+	for _, C := range T.Columns {
+		if C.Primary {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]sqlIndex) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
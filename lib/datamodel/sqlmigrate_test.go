@@ -0,0 +1,150 @@
+package datamodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLMigration(t *testing.T) {
+	user := Table{Name: "User", Columns: []Column{
+		{Name: "id", Kind: "int", Primary: true, src: "int"},
+		{Name: "fullname", Kind: "string", src: "string"},
+		{Name: "email", Kind: "string", Unique: true, src: "string @sql(unique)"},
+	}}
+	post := Table{Name: "Post", Columns: []Column{
+		{Name: "id", Kind: "int", Primary: true, src: "int"},
+		{Name: "userId", Kind: "int", References: "User.id", src: "int @sql(references=User.id)"},
+	}}
+	nextUser := Table{Name: "User", Columns: []Column{
+		{Name: "id", Kind: "int", Primary: true, src: "int"},
+		{Name: "name", Kind: "string", src: "string"},
+		{Name: "email", Kind: "string", Nullable: true, Unique: true, src: "string @sql(unique)?"},
+		{Name: "admin", Kind: "bool", Default: "FALSE", src: "*false | bool"},
+	}}
+
+	tests := []struct {
+		name    string
+		dialect string
+		prev    []Table
+		next    []Table
+		expect  []string
+	}{{
+		name:    "create",
+		dialect: "postgres",
+		next:    []Table{user, post},
+		expect: []string{
+			"CREATE TABLE \"User\" (\n  \"id\" BIGINT NOT NULL,\n  \"fullname\" TEXT NOT NULL,\n  \"email\" TEXT NOT NULL,\n  PRIMARY KEY (\"id\")\n);",
+			"CREATE TABLE \"Post\" (\n  \"id\" BIGINT NOT NULL,\n  \"userId\" BIGINT NOT NULL,\n  PRIMARY KEY (\"id\")\n);",
+			`CREATE UNIQUE INDEX "User_email_key" ON "User" ("email");`,
+			`ALTER TABLE "Post" ADD CONSTRAINT "Post_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id");`,
+		},
+	}, {
+		name:    "drop",
+		dialect: "postgres",
+		prev:    []Table{user, post},
+		expect: []string{
+			`ALTER TABLE "Post" DROP CONSTRAINT "Post_userId_fkey";`,
+			`DROP INDEX "User_email_key";`,
+			`DROP TABLE "User";`,
+			`DROP TABLE "Post";`,
+		},
+	}, {
+		name:    "alter postgres",
+		dialect: "postgres",
+		prev:    []Table{user},
+		next:    []Table{nextUser},
+		expect: []string{
+			`ALTER TABLE "User" RENAME COLUMN "fullname" TO "name";`,
+			`ALTER TABLE "User" ADD COLUMN "admin" BOOLEAN NOT NULL DEFAULT FALSE;`,
+			`ALTER TABLE "User" ALTER COLUMN "email" DROP NOT NULL;`,
+		},
+	}, {
+		name:    "alter mysql",
+		dialect: "mysql",
+		prev:    []Table{user},
+		next:    []Table{nextUser},
+		expect: []string{
+			"ALTER TABLE `User` RENAME COLUMN `fullname` TO `name`;",
+			"ALTER TABLE `User` ADD COLUMN `admin` BOOLEAN NOT NULL DEFAULT FALSE;",
+			"ALTER TABLE `User` MODIFY COLUMN `email` VARCHAR(255);",
+		},
+	}, {
+		name:    "rename table",
+		dialect: "mysql",
+		prev:    []Table{user},
+		next:    []Table{{Name: "Account", Columns: user.Columns}},
+		expect: []string{
+			"DROP INDEX `User_email_key` ON `User`;",
+			"ALTER TABLE `User` RENAME TO `Account`;",
+			"CREATE UNIQUE INDEX `Account_email_key` ON `Account` (`email`);",
+		},
+	}, {
+		name:    "rename table postgres",
+		dialect: "postgres",
+		prev:    []Table{user},
+		next:    []Table{{Name: "Account", Columns: user.Columns}},
+		expect: []string{
+			`DROP INDEX "User_email_key";`,
+			`ALTER TABLE "User" RENAME TO "Account";`,
+			`ALTER TABLE "Account" RENAME CONSTRAINT "User_pkey" TO "Account_pkey";`,
+			`CREATE UNIQUE INDEX "Account_email_key" ON "Account" ("email");`,
+		},
+	}, {
+		name:    "add required column",
+		dialect: "postgres",
+		prev:    []Table{user},
+		next:    []Table{{Name: "User", Columns: append(user.Columns[:3:3], Column{Name: "age", Kind: "int", src: "int"})}},
+		expect: []string{
+			`ALTER TABLE "User" ADD COLUMN "age" BIGINT NOT NULL DEFAULT 0;`,
+			`ALTER TABLE "User" ALTER COLUMN "age" DROP DEFAULT;`,
+		},
+	}, {
+		name:    "unchanged",
+		dialect: "mysql",
+		prev:    []Table{user, post},
+		next:    []Table{user, post},
+	}}
+
+	for _, tt := range tests {
+		stmts, err := SQLMigration(tt.prev, tt.next, SQLDialects[tt.dialect])
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, want := strings.Join(stmts, "\n"), strings.Join(tt.expect, "\n")
+		if got != want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, want)
+		}
+	}
+}
+
+func TestSQLMigrationNoType(t *testing.T) {
+	next := []Table{{Name: "T", Columns: []Column{{Name: "any", src: "_"}}}}
+	_, err := SQLMigration(nil, next, SQLDialects["postgres"])
+	if err == nil || !strings.Contains(err.Error(), "@sql(type=...)") {
+		t.Fatalf("expected an error asking for @sql(type=...), got %v", err)
+	}
+}
+
+func TestSQLMigrationRequiredColumn(t *testing.T) {
+	user := Table{Name: "User", Columns: []Column{{Name: "id", Kind: "int", Primary: true, src: "int"}}}
+
+	// columns without a zero value in the dialect, or of a type set by @sql(type=...)
+	for _, C := range []Column{
+		{Name: "meta", Kind: "struct", src: "{...}"},
+		{Name: "uuid", Kind: "string", Type: "CHAR(36)", src: "string @sql(type=\"CHAR(36)\")"},
+	} {
+		next := []Table{{Name: "User", Columns: []Column{user.Columns[0], C}}}
+		_, err := SQLMigration([]Table{user}, next, SQLDialects["mysql"])
+		if err == nil || !strings.Contains(err.Error(), "column "+C.Name+" is added NOT NULL without a default") {
+			t.Errorf("%s: expected an error asking for a default, got %v", C.Name, err)
+		}
+
+		// which they do not need when optional
+		C.Nullable = true
+		next = []Table{{Name: "User", Columns: []Column{user.Columns[0], C}}}
+		_, err = SQLMigration([]Table{user}, next, SQLDialects["mysql"])
+		if err != nil {
+			t.Errorf("%s: %v", C.Name, err)
+		}
+	}
+}
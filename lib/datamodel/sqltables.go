@@ -0,0 +1,159 @@
+package datamodel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+)
+
+// kindNames are the Cue kinds columns may have, see SQLDialect
+var kindNames = map[cue.Kind]string{
+	cue.StringKind: "string",
+	cue.IntKind:    "int",
+	cue.FloatKind:  "float",
+	cue.NumberKind: "number",
+	cue.BoolKind:   "bool",
+	cue.BytesKind:  "bytes",
+	cue.StructKind: "struct",
+	cue.ListKind:   "list",
+}
+
+// SQLTables returns the tables of a datamodel, one for each of its Models, in order.
+// Fields starting with an upper case letter, as Name, are about the model, and not columns.
+// Optional fields and those which may be null are nullable, and @sql() sets the rest, as in
+//
+//   id:      int    @sql(primary)
+//   email:   string @sql(unique)
+//   name:    string @sql(index,type="VARCHAR(64)")
+//   created: string @sql(default="now()")
+//   userId:  int    @sql(references=User.id)
+func SQLTables(dm cue.Value) ([]Table, error) {
+	models := dm.Lookup("Models")
+	if !models.Exists() {
+		return nil, nil
+	}
+
+	iter, err := models.Fields()
+	if err != nil {
+		return nil, err
+	}
+	var tables []Table
+	for iter.Next() {
+		T := Table{Name: iter.Label()}
+		citer, err := iter.Value().Fields(cue.Optional(true))
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %w", T.Name, err)
+		}
+		for citer.Next() {
+			label := citer.Label()
+			if r := []rune(label); unicode.IsUpper(r[0]) {
+				continue
+			}
+			C, err := columnOf(label, citer.Value(), citer.IsOptional())
+			if err != nil {
+				return nil, fmt.Errorf("model %s: %w", T.Name, err)
+			}
+			T.Columns = append(T.Columns, C)
+		}
+		tables = append(tables, T)
+	}
+	return tables, nil
+}
+
+func columnOf(name string, v cue.Value, optional bool) (Column, error) {
+	src, err := fieldSource(v)
+	if err != nil {
+		return Column{}, err
+	}
+	if optional {
+		src += "?"
+	}
+
+	kind := v.IncompleteKind()
+	C := Column{
+		Name:     name,
+		Kind:     kindNames[kind&^cue.NullKind],
+		Nullable: optional || kind&cue.NullKind != 0,
+		src:      src,
+	}
+
+	if d, ok := v.Default(); ok && d.IsConcrete() {
+		C.Default, err = sqlLiteral(d)
+		if err != nil {
+			return C, fmt.Errorf("column %s: %w", name, err)
+		}
+	}
+
+	A := v.Attribute("sql")
+	for i := 0; ; i++ {
+		arg, err := A.String(i)
+		if err != nil {
+			break
+		}
+		key, val := strings.TrimSpace(arg), ""
+		if pos := strings.Index(key, "="); pos >= 0 {
+			key, val = strings.TrimSpace(key[:pos]), strings.TrimSpace(key[pos+1:])
+			if uq, err := strconv.Unquote(val); err == nil {
+				val = uq
+			}
+		}
+
+		switch key {
+		case "primary":
+			C.Primary = true
+		case "unique":
+			C.Unique = true
+		case "index":
+			C.Index = true
+		case "type":
+			C.Type = val
+		case "default":
+			C.Default = val
+		case "references":
+			C.References = val
+		default:
+			return C, fmt.Errorf("column %s: unknown @sql(%s), should be one of primary, unique, index, type, default, references", name, key)
+		}
+	}
+
+	return C, nil
+}
+
+// sqlLiteral returns a concrete Cue value as SQL, structs and lists as JSON strings.
+func sqlLiteral(v cue.Value) (string, error) {
+	switch v.Kind() {
+	case cue.NullKind:
+		return "NULL", nil
+	case cue.BoolKind:
+		b, err := v.Bool()
+		if err != nil {
+			return "", err
+		}
+		if b {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case cue.StringKind:
+		s, err := v.String()
+		if err != nil {
+			return "", err
+		}
+		return sqlString(s), nil
+	default:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		if k := v.Kind(); k == cue.StructKind || k == cue.ListKind {
+			return sqlString(string(data)), nil
+		}
+		return string(data), nil
+	}
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,171 @@
+// Package errs reports the errors of hof commands, as text or JSON,
+// to the destinations given with --error and --error-format.
+//
+// Errors may carry a code and a hint, for tools wrapping hof, see WithCode.
+// The codes are
+//
+//   error           any error without a code
+//   bad-flag        a flag has a value hof does not know
+//   bad-format      an output format hof does not know
+//   unknown-lang    a language hof mod has no config for
+//   mod-offline     modules are missing and hof mod cannot fetch them
+//   not-found       a named thing, such as a jump, does not exist
+//   changed         with --exit-code, what was compared has changed
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+// The codes of errors, see the package doc
+const (
+	CodeError       = "error"
+	CodeBadFlag     = "bad-flag"
+	CodeBadFormat   = "bad-format"
+	CodeUnknownLang = "unknown-lang"
+	CodeModOffline  = "mod-offline"
+	CodeNotFound    = "not-found"
+	CodeChanged     = "changed"
+)
+
+// The error formats
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+// The error destinations, anything else is a file errors are appended to
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// Error is an error with a code and a hint on how to fix it.
+type Error struct {
+	Code string
+	Hint string
+	Err  error
+}
+
+func (E *Error) Error() string { return E.Err.Error() }
+
+func (E *Error) Unwrap() error { return E.Err }
+
+// WithCode returns err with a code and hint, or nil when err is nil.
+func WithCode(code, hint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Hint: hint, Err: err}
+}
+
+// Code returns the code of err, CodeError when it has none.
+func Code(err error) string {
+	var E *Error
+	if errors.As(err, &E) && E.Code != "" {
+		return E.Code
+	}
+	return CodeError
+}
+
+// Hint returns the hint of err, if it has one.
+func Hint(err error) string {
+	var E *Error
+	if errors.As(err, &E) {
+		return E.Hint
+	}
+	return ""
+}
+
+// Report is the JSON form of an error
+type Report struct {
+	Command string `json:"command,omitempty"`
+	Code    string `json:"code"`
+	Error   string `json:"error"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Exit reports the error of command, as set with --error and --error-format, and exits with 1.
+func Exit(command string, err error) {
+	Write(flags.RootErrorPflag, flags.RootErrorFormatPflag, command, err)
+	os.Exit(1)
+}
+
+// Write reports the error of command to each destination in format,
+// which are stdout and text by default.
+func Write(dests []string, format, command string, err error) {
+	if len(dests) == 0 {
+		dests = []string{Stdout}
+	}
+
+	out, ferr := Format(format, command, err)
+	if ferr != nil {
+		// still report the error, as text on stderr
+		fmt.Fprintln(os.Stderr, ferr)
+		out, _ = Format(TextFormat, command, err)
+		dests = []string{Stderr}
+	}
+
+	for _, dest := range dests {
+		werr := writeTo(dest, out)
+		if werr != nil {
+			fmt.Fprintf(os.Stderr, "While writing the error to %s\n%v\n%s", dest, werr, out)
+		}
+	}
+}
+
+// Format returns the error of command in format, text or json, ending with a newline.
+func Format(format, command string, err error) (string, error) {
+	switch format {
+	case "", TextFormat:
+		out := err.Error() + "\n"
+		if hint := Hint(err); hint != "" {
+			out += "hint: " + hint + "\n"
+		}
+		return out, nil
+
+	case JSONFormat:
+		R := Report{
+			Command: command,
+			Code:    Code(err),
+			Error:   strings.TrimSpace(err.Error()),
+			Hint:    Hint(err),
+		}
+		data, jerr := json.Marshal(R)
+		if jerr != nil {
+			return "", jerr
+		}
+		return string(data) + "\n", nil
+
+	default:
+		return "", WithCode(CodeBadFlag, "",
+			fmt.Errorf("Unknown error format %q, should be one of %s, %s", format, TextFormat, JSONFormat))
+	}
+}
+
+func writeTo(dest, out string) error {
+	var w io.Writer
+	switch dest {
+	case Stdout:
+		w = os.Stdout
+	case Stderr:
+		w = os.Stderr
+	default:
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err := io.WriteString(w, out)
+	return err
+}
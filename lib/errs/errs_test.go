@@ -0,0 +1,67 @@
+package errs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+func TestFormat(t *testing.T) {
+	err := fmt.Errorf("While jumping\n%w\n", errs.WithCode(errs.CodeNotFound, "use 'hof jump list' to see them", fmt.Errorf("jump \"x\" not found")))
+
+	out, ferr := errs.Format("", "hof jump", err)
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	expect := "While jumping\njump \"x\" not found\n\nhint: use 'hof jump list' to see them\n"
+	if out != expect {
+		t.Errorf("expected:\n%q\ngot:\n%q", expect, out)
+	}
+
+	out, ferr = errs.Format(errs.JSONFormat, "hof jump", err)
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	var R errs.Report
+	jerr := json.Unmarshal([]byte(out), &R)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	if R.Command != "hof jump" || R.Code != errs.CodeNotFound || R.Error != "While jumping\njump \"x\" not found" || R.Hint == "" {
+		t.Errorf("unexpected report %#v", R)
+	}
+
+	_, ferr = errs.Format("xml", "hof", err)
+	if errs.Code(ferr) != errs.CodeBadFlag {
+		t.Errorf("expected a bad-flag error for an unknown format, got %v", ferr)
+	}
+
+	if errs.Code(fmt.Errorf("plain")) != errs.CodeError || errs.WithCode(errs.CodeBadFlag, "", nil) != nil {
+		t.Errorf("expected plain errors to have the error code, and no error to stay nil")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-errs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "errors.txt")
+	errs.Write([]string{fn}, "", "hof", fmt.Errorf("first"))
+	errs.Write([]string{fn}, "", "hof", fmt.Errorf("second"))
+
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("expected errors to be appended, got %q", string(data))
+	}
+}
@@ -42,6 +42,13 @@ func Gen(args []string, cmdflags flags.GenFlagpole) (error) {
 	veryend := time.Now()
 	elapsed := veryend.Sub(verystart).Round(time.Millisecond)
 
+	R.CalcStats()
+
+	// the runs are shown in hof tui, failing to record them should not fail the run
+	err := R.RecordRuns(verystart, elapsed)
+	if err != nil {
+		fmt.Println("While recording the run:", err)
+	}
 
 	if cmdflags.Stats {
 		R.PrintStats()
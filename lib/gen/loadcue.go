@@ -6,6 +6,7 @@ import (
 
 	"cuelang.org/go/cue"
 
+	"github.com/hofstadter-io/hof/lib/config"
 	"github.com/hofstadter-io/hof/lib/templates"
 )
 
@@ -15,8 +16,14 @@ func (G *Generator) LoadCue() ([]error) {
 	var gen map[string]interface{}
 	start := time.Now()
 
+	// Fetch the secrets referenced with @secret(<ref>)
+	val, err := config.ResolveSecrets(G.CueValue)
+	if err != nil {
+		return []error{err}
+	}
+
 	// Decode the value into a temporary "generator" with timing
-	err := G.CueValue.Decode(&gen)
+	err = val.Decode(&gen)
 		if err != nil {
 		return []error{err}
 	}
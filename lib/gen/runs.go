@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// RunsDir holds the latest runs of hof gen, a file per project,
+// so that nothing is written into the project for them
+var RunsDir = filepath.Join(os.TempDir(), "hof/runs")
+
+func init() {
+	d, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+
+	RunsDir = filepath.Join(d, "hof/runs")
+}
+
+// MAX_RUNS is how many runs are kept for a project
+const MAX_RUNS = 20
+
+// Run records one generator of a run of hof gen
+type Run struct {
+	Generator   string        `json:"generator"`
+	Entrypoints []string      `json:"entrypoints"`
+	Time        time.Time     `json:"time"`
+	Elapsed     time.Duration `json:"elapsed"`
+
+	Written    int `json:"written"`
+	Same       int `json:"same"`
+	Skipped    int `json:"skipped"`
+	Deleted    int `json:"deleted"`
+	Conflicted int `json:"conflicted"`
+	Errors     int `json:"errors"`
+}
+
+// NewRun records the generator G, its stats must be totaled already, see CalcTotals
+func NewRun(G *Generator, entrypoints []string, start time.Time, elapsed time.Duration) Run {
+	S := G.Stats
+	return Run{
+		Generator:   G.Name,
+		Entrypoints: entrypoints,
+		Time:        start,
+		Elapsed:     elapsed.Round(time.Millisecond),
+
+		Written:    S.NumWritten,
+		Same:       S.NumSame,
+		Skipped:    S.NumSkipped,
+		Deleted:    S.NumDeleted,
+		Conflicted: S.NumConflicted,
+		Errors:     S.NumErr,
+	}
+}
+
+// RunsFile returns the file in RunsDir for the project in the current directory
+func RunsFile() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(cwd))
+	return filepath.Join(RunsDir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// LoadRuns returns the recorded runs, newest first, none when nothing was generated yet
+func LoadRuns() ([]Run, error) {
+	fn, err := RunsFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	err = json.Unmarshal(data, &runs)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// RecordRuns adds runs to the RunsFile, dropping the oldest past MAX_RUNS
+func RecordRuns(runs []Run) error {
+	fn, err := RunsFile()
+	if err != nil {
+		return err
+	}
+	old, err := LoadRuns()
+	if err != nil {
+		return err
+	}
+	runs = append(runs, old...)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time.After(runs[j].Time) })
+	if len(runs) > MAX_RUNS {
+		runs = runs[:MAX_RUNS]
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = yagu.Mkdir(RunsDir)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fn, data, 0644)
+}
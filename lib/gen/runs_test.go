@@ -0,0 +1,82 @@
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordRuns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldRunsDir := RunsDir
+	defer func() { RunsDir = oldRunsDir }()
+	RunsDir = filepath.Join(dir, "cache")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	for _, p := range []string{"a", "b"} {
+		err = os.Mkdir(filepath.Join(dir, p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir := func(p string) {
+		err := os.Chdir(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chdir("a")
+	runs, err := LoadRuns()
+	if err != nil || runs != nil {
+		t.Fatalf("expected no runs yet, got %v, %v", runs, err)
+	}
+
+	start := time.Now()
+	for i := 0; i < MAX_RUNS+5; i++ {
+		err = RecordRuns([]Run{{Generator: "api", Time: start.Add(time.Duration(i) * time.Second), Written: i}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err = LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != MAX_RUNS || runs[0].Written != MAX_RUNS+4 || runs[MAX_RUNS-1].Written != 5 {
+		t.Fatalf("expected the newest %d runs first, got %d from %d", MAX_RUNS, len(runs), runs[0].Written)
+	}
+
+	// nothing is written into the project
+	files, err := ioutil.ReadDir(".")
+	if err != nil || len(files) != 0 {
+		t.Fatalf("expected the project left alone, got %d files, %v", len(files), err)
+	}
+
+	// each project has its own runs
+	chdir("b")
+	runs, err = LoadRuns()
+	if err != nil || runs != nil {
+		t.Fatalf("expected no runs for another project, got %v, %v", runs, err)
+	}
+	err = RecordRuns([]Run{{Generator: "web", Time: start}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err = ioutil.ReadDir(RunsDir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected a runs file per project, got %d, %v", len(files), err)
+	}
+}
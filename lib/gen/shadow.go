@@ -7,14 +7,15 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 const SHADOW_DIR = ".hof/shadow/"
 
-func LoadShadow(subdir string, verbose bool) (map[string]*File, error) {
-	if verbose {
-		fmt.Printf("Loading shadow @ %q\n", SHADOW_DIR)
-	}
+func LoadShadow(subdir string) (map[string]*File, error) {
+	log := logs.Scope("gen")
+	log.Debug("loading shadow", "dir", SHADOW_DIR)
 
 	shadowDir := filepath.Join(SHADOW_DIR, subdir)
 
@@ -27,9 +28,7 @@ func LoadShadow(subdir string, verbose bool) (map[string]*File, error) {
 			return nil, err
 		}
 		// file not found, leave politely
-		if verbose {
-			fmt.Println("  shadow not found")
-		}
+		log.Debug("shadow not found", "dir", shadowDir)
 		return shadow, nil
 	}
 
@@ -39,9 +38,7 @@ func LoadShadow(subdir string, verbose bool) (map[string]*File, error) {
 			return nil
 		}
 
-		if verbose {
-			fmt.Println("  adding:", info.Name())
-		}
+		log.Debug("adding shadow file", "file", info.Name())
 
 		fpath = strings.TrimPrefix(fpath, SHADOW_DIR)
 		shadow[fpath] = &File {
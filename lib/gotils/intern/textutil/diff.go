@@ -15,6 +15,89 @@ import (
 // (That is, lines only in text1 appear with a leading -,
 // and lines only in text2 appear with a leading +.)
 func Diff(text1, text2 string) string {
+	var buf strings.Builder
+	for _, e := range diffEdits(text1, text2) {
+		fmt.Fprintf(&buf, "%c%s\n", e.op, e.line)
+	}
+	return buf.String()
+}
+
+// UnifiedDiff returns a unified diff of the two texts, in the style of
+// diff -u, with the given number of context lines around each change.
+// It returns the empty string if the texts are equal.
+func UnifiedDiff(name1, name2, text1, text2 string, context int) string {
+	edits := diffEdits(text1, text2)
+
+	// Find the ranges of edits to print, merging changes
+	// whose context would overlap.
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+		start, end := i-context, i+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, hunk{start, end})
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", name1, name2)
+	for _, h := range hunks {
+		// Line numbers of the first line of the hunk in each text.
+		l1, l2 := 1, 1
+		for _, e := range edits[:h.start] {
+			if e.op != '+' {
+				l1++
+			}
+			if e.op != '-' {
+				l2++
+			}
+		}
+		n1, n2 := 0, 0
+		for _, e := range edits[h.start:h.end] {
+			if e.op != '+' {
+				n1++
+			}
+			if e.op != '-' {
+				n2++
+			}
+		}
+		if n1 == 0 {
+			l1--
+		}
+		if n2 == 0 {
+			l2--
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", l1, n1, l2, n2)
+		for _, e := range edits[h.start:h.end] {
+			fmt.Fprintf(&buf, "%c%s\n", e.op, e.line)
+		}
+	}
+	return buf.String()
+}
+
+// edit is a line in an edit script: ' ' for kept lines,
+// '-' for lines only in text1, and '+' for lines only in text2.
+type edit struct {
+	op   byte
+	line string
+}
+
+// diffEdits returns the minimum line-level edit script to turn text1 into text2.
+func diffEdits(text1, text2 string) []edit {
 	if text1 != "" && !strings.HasSuffix(text1, "\n") {
 		text1 += "(missing final newline)"
 	}
@@ -58,21 +141,21 @@ func Diff(text1, text2 string) string {
 		}
 	}
 
-	var buf strings.Builder
+	var edits []edit
 	i, j := len(lines1), len(lines2)
 	for i > 0 || j > 0 {
 		cost := dist[i][j]
 		if i > 0 && j > 0 && cost == dist[i-1][j-1] && lines1[len(lines1)-i] == lines2[len(lines2)-j] {
-			fmt.Fprintf(&buf, " %s\n", lines1[len(lines1)-i])
+			edits = append(edits, edit{' ', lines1[len(lines1)-i]})
 			i--
 			j--
 		} else if i > 0 && cost == dist[i-1][j]+1 {
-			fmt.Fprintf(&buf, "-%s\n", lines1[len(lines1)-i])
+			edits = append(edits, edit{'-', lines1[len(lines1)-i]})
 			i--
 		} else {
-			fmt.Fprintf(&buf, "+%s\n", lines2[len(lines2)-j])
+			edits = append(edits, edit{'+', lines2[len(lines2)-j]})
 			j--
 		}
 	}
-	return buf.String()
+	return edits
 }
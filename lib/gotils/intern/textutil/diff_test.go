@@ -43,3 +43,25 @@ func TestDiff(t *testing.T) {
 		}
 	}
 }
+
+func TestUnifiedDiff(t *testing.T) {
+	text1 := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	text2 := "a\nb\nC\nd\ne\nf\ng\nh\ni\n"
+	want := `--- old
++++ new
+@@ -2,3 +2,3 @@
+ b
+-c
++C
+ d
+@@ -8,1 +8,2 @@
+ h
++i
+`
+	if got := textutil.UnifiedDiff("old", "new", text1, text2, 1); got != want {
+		t.Errorf("UnifiedDiff = %q, want %q", got, want)
+	}
+	if got := textutil.UnifiedDiff("old", "new", text1, text1, 3); got != "" {
+		t.Errorf("UnifiedDiff of equal texts = %q, want empty", got)
+	}
+}
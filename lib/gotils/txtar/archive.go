@@ -11,8 +11,7 @@
 //	- diff nicely in git history and code reviews.
 //
 // Non-goals include being a completely general archive format,
-// storing binary data, storing file modes, storing special files like
-// symbolic links, and so on.
+// storing special files like devices, and so on.
 //
 // Txtar format
 //
@@ -28,16 +27,30 @@
 // If the txtar file is missing a trailing newline on the final line,
 // parsers should consider a final newline to be present anyway.
 //
+// The file name may be followed by a parenthesized list of attributes,
+// as in "-- logo.png (base64) --". The base64 attribute marks the file
+// content as standard base64 encoded, so that small binary files can be
+// stored; File.Contents decodes it. The mode=NNNN attribute gives the
+// octal permission bits of the file, as in "-- run.sh (mode=0755) --".
+// The symlink attribute makes the entry a symbolic link whose target
+// is the (single line) file content. Unknown attributes are left as part
+// of the file name.
+//
+// Parse holds a whole archive in memory. For large archives, a Reader
+// reads the files one at a time and Extract writes them straight to disk.
+//
 // There are no possible syntax errors in a txtar archive.
 package txtar
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
@@ -50,8 +63,122 @@ type Archive struct {
 
 // A File is a single file in an archive.
 type File struct {
-	Name string // name of file ("foo/bar.txt")
-	Data []byte // text content of file
+	Name  string   // name of file ("foo/bar.txt")
+	Data  []byte   // text content of file
+	Attrs []string // attributes from the file marker ("base64", "mode=0755")
+}
+
+// Attribute names understood in file markers.
+const (
+	AttrBase64  = "base64"
+	AttrSymlink = "symlink"
+	AttrMode    = "mode=" // followed by octal permission bits
+)
+
+// HasAttr reports whether the file marker carries the given attribute.
+func (f *File) HasAttr(attr string) bool {
+	for _, a := range f.Attrs {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// Mode returns the permission bits given by a mode attribute,
+// and whether there was one.
+func (f *File) Mode() (os.FileMode, bool) {
+	for _, a := range f.Attrs {
+		if mode, ok := parseMode(a); ok {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// SetMode sets the mode attribute, replacing any existing one.
+func (f *File) SetMode(mode os.FileMode) {
+	attrs := f.Attrs[:0:0]
+	for _, a := range f.Attrs {
+		if !strings.HasPrefix(a, AttrMode) {
+			attrs = append(attrs, a)
+		}
+	}
+	f.Attrs = append(attrs, fmt.Sprintf("%s%04o", AttrMode, mode.Perm()))
+}
+
+// IsSymlink reports whether the file is a symbolic link.
+func (f *File) IsSymlink() bool {
+	return f.HasAttr(AttrSymlink)
+}
+
+// LinkTarget returns the target of a symbolic link file.
+func (f *File) LinkTarget() string {
+	return strings.TrimSpace(string(f.Data))
+}
+
+// SymlinkFile returns a File for a symbolic link to target.
+func SymlinkFile(name, target string) File {
+	return File{Name: name, Data: []byte(target + "\n"), Attrs: []string{AttrSymlink}}
+}
+
+func parseMode(attr string) (os.FileMode, bool) {
+	if !strings.HasPrefix(attr, AttrMode) {
+		return 0, false
+	}
+	mode, err := strconv.ParseUint(attr[len(AttrMode):], 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, false
+	}
+	return os.FileMode(mode), true
+}
+
+// Contents returns the file content with any encoding named by
+// its attributes removed.
+func (f *File) Contents() ([]byte, error) {
+	if !f.HasAttr(AttrBase64) {
+		return f.Data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(f.Data), nil)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding base64: %v", f.Name, err)
+	}
+	return data, nil
+}
+
+// SetContents sets the file content, encoding it as
+// required by its attributes.
+func (f *File) SetContents(data []byte) {
+	if !f.HasAttr(AttrBase64) {
+		f.Data = data
+		return
+	}
+	f.Data = encodeBase64(data)
+}
+
+// Base64File returns a File holding data encoded in base64.
+func Base64File(name string, data []byte) File {
+	f := File{Name: name, Attrs: []string{AttrBase64}}
+	f.SetContents(data)
+	return f
+}
+
+// base64LineLen is the length of the lines base64 content is wrapped to.
+const base64LineLen = 76
+
+func encodeBase64(data []byte) []byte {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(enc) > base64LineLen {
+		buf.WriteString(enc[:base64LineLen])
+		buf.WriteByte('\n')
+		enc = enc[base64LineLen:]
+	}
+	if enc != "" {
+		buf.WriteString(enc)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
 }
 
 // Format returns the serialized form of an Archive.
@@ -62,7 +189,11 @@ func Format(a *Archive) []byte {
 	var buf bytes.Buffer
 	buf.Write(fixNL(a.Comment))
 	for _, f := range a.Files {
-		fmt.Fprintf(&buf, "-- %s --\n", f.Name)
+		if len(f.Attrs) > 0 {
+			fmt.Fprintf(&buf, "-- %s (%s) --\n", f.Name, strings.Join(f.Attrs, ", "))
+		} else {
+			fmt.Fprintf(&buf, "-- %s --\n", f.Name)
+		}
 		buf.Write(fixNL(f.Data))
 	}
 	return buf.Bytes()
@@ -84,7 +215,8 @@ func Parse(data []byte) *Archive {
 	var name string
 	a.Comment, name, data = findFileMarker(data)
 	for name != "" {
-		f := File{name, nil}
+		var f File
+		f.Name, f.Attrs = splitAttrs(name)
 		f.Data, name, data = findFileMarker(data)
 		a.Files = append(a.Files, f)
 	}
@@ -183,6 +315,36 @@ func isMarker(data []byte) (name string, after []byte) {
 	return strings.TrimSpace(string(data[len(marker) : len(data)-len(markerEnd)])), after
 }
 
+// splitAttrs splits a trailing attribute list from a file marker name.
+// The list is only split off when every attribute in it is known.
+func splitAttrs(name string) (string, []string) {
+	if !strings.HasSuffix(name, ")") {
+		return name, nil
+	}
+	i := strings.LastIndex(name, " (")
+	if i < 0 {
+		return name, nil
+	}
+	var attrs []string
+	for _, a := range strings.Split(name[i+2:len(name)-1], ",") {
+		a = strings.TrimSpace(a)
+		if !knownAttr(a) {
+			return name, nil
+		}
+		attrs = append(attrs, a)
+	}
+	return strings.TrimSpace(name[:i]), attrs
+}
+
+func knownAttr(attr string) bool {
+	switch attr {
+	case AttrBase64, AttrSymlink:
+		return true
+	}
+	_, ok := parseMode(attr)
+	return ok
+}
+
 // If data is empty or ends in \n, fixNL returns data.
 // Otherwise fixNL returns a new slice consisting of data with a final \n added.
 func fixNL(data []byte) []byte {
@@ -196,33 +358,19 @@ func fixNL(data []byte) []byte {
 }
 
 // Write writes each File in an Archive to the given directory, returning any
-// errors encountered. An error is also returned in the event a file would be
+// errors encountered. Symbolic links are created and modes applied as given
+// by the file attributes. An error is also returned in the event a file would be
 // written outside of dir.
 func Write(a *Archive, dir string) error {
-	for _, f := range a.Files {
-		fp := filepath.Clean(filepath.FromSlash(f.Name))
-		if isAbs(fp) || strings.HasPrefix(fp, ".."+string(filepath.Separator)) {
-			return fmt.Errorf("%q: outside parent directory", f.Name)
-		}
-		fp = filepath.Join(dir, fp)
-
-		if err := os.MkdirAll(filepath.Dir(fp), 0777); err != nil {
-			return err
-		}
-		// Avoid overwriting existing files by using O_EXCL.
-		out, err := os.OpenFile(fp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	for i := range a.Files {
+		f := &a.Files[i]
+		data, err := f.Contents()
 		if err != nil {
 			return err
 		}
-
-		_, err = out.Write(f.Data)
-		cerr := out.Close()
-		if err != nil {
+		if err := writeFile(dir, f, bytes.NewReader(data)); err != nil {
 			return err
 		}
-		if cerr != nil {
-			return cerr
-		}
 	}
 	return nil
 }
@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -35,10 +37,26 @@ hello world`,
 		parsed: &Archive{
 			Comment: []byte("comment1\ncomment2\n"),
 			Files: []File{
-				{"file1", []byte("File 1 text.\n-- foo ---\nMore file 1 text.\n")},
-				{"file 2", []byte("File 2 text.\n")},
-				{"empty", []byte{}},
-				{"noNL", []byte("hello world\n")},
+				{"file1", []byte("File 1 text.\n-- foo ---\nMore file 1 text.\n"), nil},
+				{"file 2", []byte("File 2 text.\n"), nil},
+				{"empty", []byte{}, nil},
+				{"noNL", []byte("hello world\n"), nil},
+			},
+		},
+	},
+	// Attributes
+	{
+		name: "attrs",
+		text: `-- img.bin (base64) --
+AAEC/w==
+-- notes (draft) --
+text
+`,
+		parsed: &Archive{
+			Comment: []byte{},
+			Files: []File{
+				{"img.bin", []byte("AAEC/w==\n"), []string{"base64"}},
+				{"notes (draft)", []byte("text\n"), nil},
 			},
 		},
 	},
@@ -49,7 +67,7 @@ hello world`,
 		parsed: &Archive{
 			Comment: []byte("blah\r\n"),
 			Files: []File{
-				{"hello", []byte("hello\r\n")},
+				{"hello", []byte("hello\r\n"), nil},
 			},
 		},
 	},
@@ -105,6 +123,69 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+func TestBase64(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	a := Parse(Format(&Archive{Files: []File{Base64File("bin", data)}}))
+	if len(a.Files) != 1 || a.Files[0].Name != "bin" {
+		t.Fatalf("unexpected archive:\n%s", shortArchive(a))
+	}
+	got, err := a.Files[0].Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Contents: got %q want %q", got, data)
+	}
+
+	bad := File{Name: "bad", Data: []byte("!!!\n"), Attrs: []string{AttrBase64}}
+	if _, err := bad.Contents(); err == nil {
+		t.Fatalf("expected error decoding invalid base64")
+	}
+}
+
+func TestWriteAttrs(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+
+	a := Parse([]byte(`-- run.sh (mode=0755) --
+#!/bin/sh
+-- link (symlink) --
+run.sh
+`))
+	if mode, ok := a.Files[0].Mode(); !ok || mode != 0755 {
+		t.Fatalf("Mode: got %v, %v", mode, ok)
+	}
+	if err := Write(a, td); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	info, err := os.Stat(filepath.Join(td, "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0755 {
+		t.Fatalf("run.sh: got mode %v want 0755", info.Mode())
+	}
+	target, err := os.Readlink(filepath.Join(td, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "run.sh" {
+		t.Fatalf("link: got target %q want %q", target, "run.sh")
+	}
+
+	f := File{Name: "x", Attrs: []string{AttrBase64, "mode=0644"}}
+	f.SetMode(0700)
+	if want := []string{AttrBase64, "mode=0700"}; !reflect.DeepEqual(f.Attrs, want) {
+		t.Fatalf("SetMode: got attrs %q want %q", f.Attrs, want)
+	}
+}
+
 var unquoteErrorTests = []struct {
 	testName    string
 	data        string
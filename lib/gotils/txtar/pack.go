@@ -0,0 +1,151 @@
+package txtar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// CommentFile is the file which holds the archive comment, that is the
+// script of a testscript, when an archive is unpacked into a directory.
+const CommentFile = "README.hls"
+
+// PackOptions control how Pack builds an archive from a directory.
+type PackOptions struct {
+	// All includes dot files and directories, which are skipped by default.
+	All bool
+
+	// CommentFile names the file, relative to the directory, which holds
+	// the archive comment. It is not added to the archive as a file.
+	// If empty, CommentFile is used.
+	CommentFile string
+}
+
+// Pack builds an archive from the files in the directory tree rooted at dir.
+//
+// Binary files are stored base64 encoded, executable files and symbolic links
+// keep their mode and target, and files which contain txtar markers are quoted,
+// with an "unquote" line added to the comment as testscripts expect.
+func Pack(dir string, opts PackOptions) (*Archive, error) {
+	commentFile := opts.CommentFile
+	if commentFile == "" {
+		commentFile = CommentFile
+	}
+
+	a := new(Archive)
+	dir = filepath.Clean(dir)
+	if data, err := ioutil.ReadFile(filepath.Join(dir, commentFile)); err == nil {
+		a.Comment = fixNL(data)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var unquotes []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && !opts.All {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+		if name == filepath.ToSlash(commentFile) {
+			return nil
+		}
+
+		switch mode := info.Mode(); {
+		case mode&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			a.Files = append(a.Files, SymlinkFile(name, filepath.ToSlash(target)))
+			return nil
+		case !mode.IsRegular():
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var f File
+		switch {
+		case !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0:
+			f = Base64File(name, data)
+		case NeedsQuote(data):
+			data, err = Quote(fixNL(data))
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			f = File{Name: name, Data: data}
+			unquotes = append(unquotes, name)
+		default:
+			f = File{Name: name, Data: fixNL(data)}
+		}
+		if info.Mode()&0111 != 0 {
+			f.SetMode(info.Mode())
+		}
+		a.Files = append(a.Files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range unquotes {
+		line := "unquote " + name + "\n"
+		if !bytes.Contains(a.Comment, []byte(line)) {
+			a.Comment = append(a.Comment, line...)
+		}
+	}
+	return a, nil
+}
+
+// Unpack writes the archive to dir, as Write does, and also writes the
+// archive comment to opts.CommentFile. Files named in "unquote" lines
+// of the comment are unquoted, so that Unpack reverses Pack.
+func Unpack(a *Archive, dir string, opts PackOptions) error {
+	commentFile := opts.CommentFile
+	if commentFile == "" {
+		commentFile = CommentFile
+	}
+
+	unquote := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(a.Comment))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && fields[0] == "unquote" {
+			for _, name := range fields[1:] {
+				unquote[name] = true
+			}
+		}
+	}
+
+	b := &Archive{Files: make([]File, len(a.Files))}
+	for i, f := range a.Files {
+		if unquote[f.Name] {
+			data, err := Unquote(f.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %v", f.Name, err)
+			}
+			f.Data = data
+		}
+		b.Files[i] = f
+	}
+	if len(a.Comment) > 0 {
+		b.Files = append(b.Files, File{Name: commentFile, Data: a.Comment})
+	}
+	return Write(b, dir)
+}
@@ -0,0 +1,103 @@
+package txtar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPackUnpack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs unix permissions and symlinks")
+	}
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+
+	src := filepath.Join(td, "src")
+	files := map[string]string{
+		"README.hls":    "exec cat hello.txt\n",
+		"hello.txt":     "hello\n",
+		"sub/noNL":      "no newline\n",
+		"marker.txt":    "-- not a file --\n",
+		"bin/run.sh":    "#!/bin/sh\n",
+		"image.bin":     "\x89PNG\x00\x01",
+		".hidden/thing": "skipped\n",
+	}
+	for name, data := range files {
+		fp := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "bin/run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("hello.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Pack(src, PackOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `exec cat hello.txt
+unquote marker.txt
+-- bin/run.sh (mode=0755) --
+#!/bin/sh
+-- hello.txt --
+hello
+-- image.bin (base64) --
+iVBORwAB
+-- link (symlink) --
+hello.txt
+-- marker.txt --
+>-- not a file --
+-- sub/noNL --
+no newline
+`
+	if got := string(Format(a)); got != want {
+		t.Fatalf("Pack: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	dst := filepath.Join(td, "dst")
+	if err := Unpack(Parse([]byte(want)), dst, PackOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		got, err := ioutil.ReadFile(filepath.Join(dst, name))
+		if name == ".hidden/thing" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: expected not to be unpacked", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "README.hls" {
+			data += "unquote marker.txt\n"
+		}
+		if !bytes.Equal(got, []byte(data)) {
+			t.Errorf("%s: got %q want %q", name, got, data)
+		}
+	}
+	info, err := os.Stat(filepath.Join(dst, "bin/run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("bin/run.sh: got mode %v want 0755", info.Mode())
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "hello.txt" {
+		t.Errorf("link: got target %q (%v) want hello.txt", target, err)
+	}
+}
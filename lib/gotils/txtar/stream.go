@@ -0,0 +1,201 @@
+package txtar
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Reader reads an archive incrementally, so that large archives can be
+// processed without holding them in memory. The comment is read first,
+// then Next advances to each file in turn, and Read reads the data of the
+// current file.
+type Reader struct {
+	br      *bufio.Reader
+	started bool
+	comment []byte
+	marker  string // name of the next file marker, once reached
+	inFile  bool   // reading the data of a file
+	line    []byte // unread data of the current line
+	err     error  // sticky read error
+}
+
+// NewReader returns a Reader reading an archive from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReader(r)}
+}
+
+// Comment returns the archive comment, reading it if necessary.
+func (r *Reader) Comment() ([]byte, error) {
+	if r.started {
+		return r.comment, r.readErr()
+	}
+	r.started = true
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			r.err = err
+			break
+		}
+		if name, _ := isMarker(line); name != "" {
+			r.marker = name
+			break
+		}
+		r.comment = append(r.comment, line...)
+	}
+	return r.comment, r.readErr()
+}
+
+// Next advances to the next file in the archive, skipping any unread data
+// of the current one. The returned File has no Data; it is read with Read.
+// At the end of the archive Next returns io.EOF.
+func (r *Reader) Next() (*File, error) {
+	if _, err := r.Comment(); err != nil {
+		return nil, err
+	}
+	if r.inFile {
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			return nil, err
+		}
+	}
+	if r.marker == "" {
+		if err := r.readErr(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	f := new(File)
+	f.Name, f.Attrs = splitAttrs(r.marker)
+	r.marker = ""
+	r.inFile = true
+	return f, nil
+}
+
+// Read reads the data of the current file, as it appears in the archive.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.line) == 0 {
+		if !r.inFile {
+			return 0, io.EOF
+		}
+		line, err := r.readLine()
+		if err != nil {
+			r.err = err
+			r.inFile = false
+			continue
+		}
+		if name, _ := isMarker(line); name != "" {
+			r.marker = name
+			r.inFile = false
+			continue
+		}
+		r.line = line
+	}
+	n := copy(p, r.line)
+	r.line = r.line[n:]
+	return n, nil
+}
+
+// Contents returns a reader for the data of the current file f,
+// decoded as required by its attributes.
+func (r *Reader) Contents(f *File) io.Reader {
+	if f.HasAttr(AttrBase64) {
+		// The decoder ignores the newlines wrapping the data.
+		return base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r
+}
+
+// readLine reads the next line, adding a final newline if it is missing.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.br.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		return append(line, '\n'), nil
+	}
+	return line, err
+}
+
+// readErr returns any read error other than reaching the end of the input.
+func (r *Reader) readErr() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// ReadComment reads only the comment of the named archive.
+func ReadComment(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReader(f).Comment()
+}
+
+// Extract writes each file in the archive read from r to dir as it is read,
+// like Write, and returns the archive with the comment and the file
+// names and attributes, but no file data.
+func Extract(r io.Reader, dir string) (*Archive, error) {
+	tr := NewReader(r)
+	comment, err := tr.Comment()
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{Comment: comment}
+	for {
+		f, err := tr.Next()
+		if err == io.EOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(dir, f, tr.Contents(f)); err != nil {
+			return nil, err
+		}
+		a.Files = append(a.Files, *f)
+	}
+}
+
+// writeFile writes f, with the data read from r, to dir.
+func writeFile(dir string, f *File, r io.Reader) error {
+	fp := filepath.Clean(filepath.FromSlash(f.Name))
+	if isAbs(fp) || strings.HasPrefix(fp, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%q: outside parent directory", f.Name)
+	}
+	fp = filepath.Join(dir, fp)
+
+	if err := os.MkdirAll(filepath.Dir(fp), 0777); err != nil {
+		return err
+	}
+	if f.IsSymlink() {
+		target, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(strings.TrimSpace(string(target)), fp)
+	}
+	// Avoid overwriting existing files by using O_EXCL.
+	out, err := os.OpenFile(fp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, r)
+	cerr := out.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", f.Name, err)
+	}
+	if cerr != nil {
+		return cerr
+	}
+	if mode, ok := f.Mode(); ok {
+		return os.Chmod(fp, mode)
+	}
+	return nil
+}
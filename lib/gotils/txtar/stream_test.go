@@ -0,0 +1,103 @@
+package txtar
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReader(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read a byte at a time to exercise partial line reads.
+			r := NewReader(iotest.OneByteReader(strings.NewReader(tt.text)))
+			a := new(Archive)
+			var err error
+			if a.Comment, err = r.Comment(); err != nil {
+				t.Fatal(err)
+			}
+			if a.Comment == nil {
+				a.Comment = []byte{}
+			}
+			for {
+				f, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f.Data, err = ioutil.ReadAll(r); err != nil {
+					t.Fatal(err)
+				}
+				a.Files = append(a.Files, *f)
+			}
+			if !reflect.DeepEqual(a, tt.parsed) {
+				t.Fatalf("Reader: wrong output:\nhave:\n%s\nwant:\n%s", shortArchive(a), shortArchive(tt.parsed))
+			}
+		})
+	}
+}
+
+func TestReaderSkip(t *testing.T) {
+	r := NewReader(strings.NewReader("-- a --\nskipped\n-- b --\nread\n"))
+	var names []string
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("got files %q want %q", names, want)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+
+	text := `comment
+-- a.txt --
+hello
+-- bin/data (base64) --
+AAEC
+/w==
+`
+	a, err := Extract(strings.NewReader(text), td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a.Comment) != "comment\n" || len(a.Files) != 2 || a.Files[1].Name != "bin/data" {
+		t.Fatalf("unexpected archive:\n%s", shortArchive(a))
+	}
+	for name, want := range map[string][]byte{
+		"a.txt":    []byte("hello\n"),
+		"bin/data": {0, 1, 2, 255},
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(td, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %q want %q", name, got, want)
+		}
+	}
+
+	if _, err := Extract(strings.NewReader("-- ../bad.txt --\n"), td); err == nil {
+		t.Fatalf("expected error extracting outside of dir")
+	}
+}
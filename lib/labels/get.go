@@ -2,6 +2,8 @@ package labels
 
 import (
 	"fmt"
+
+	"github.com/hofstadter-io/hof/lib/printer"
 )
 
 func RunGetLabelFromArgs(args []string) error {
@@ -10,7 +12,13 @@ func RunGetLabelFromArgs(args []string) error {
 	return nil
 }
 
-func RunGetLabelsetFromArgs(args []string) error {
+// RunGetLabelsetFromArgs prints in format, see the printer package
+func RunGetLabelsetFromArgs(args []string, format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("lib/labels.GetLabelset", args)
 
 	return nil
@@ -2,6 +2,8 @@ package labels
 
 import (
 	"fmt"
+
+	"github.com/hofstadter-io/hof/lib/printer"
 )
 
 func RunInfoLabelFromArgs(args []string) error {
@@ -10,7 +12,13 @@ func RunInfoLabelFromArgs(args []string) error {
 	return nil
 }
 
-func RunInfoLabelsetFromArgs(args []string) error {
+// RunInfoLabelsetFromArgs prints in format, see the printer package
+func RunInfoLabelsetFromArgs(args []string, format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("lib/labels.InfoLabelset", args)
 
 	return nil
@@ -0,0 +1,11 @@
+package labels
+
+import (
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// LabelsetNames returns the labelsets of the workspace, the fields with a @labelset() attribute
+// in the current directory.
+func LabelsetNames() []string {
+	return cuetils.AttrFieldNames(".", "labelset")
+}
@@ -0,0 +1,42 @@
+package labels
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLabelsetNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "labels.cue"), []byte(`package labels
+
+tiers: {} @labelset()
+envs: {} @labelset()
+Users: {} @datamodel()
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	olddir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(olddir)
+
+	expect := []string{"envs", "tiers"}
+	if got := LabelsetNames(); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got %q, want %q", got, expect)
+	}
+}
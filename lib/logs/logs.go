@@ -0,0 +1,256 @@
+// Package logs writes hof's log lines, to stderr, for people or as JSON.
+// Each line is from a scope, the part of hof logging, such as
+// mod, gen, git, http, script, or templates, and scopes may have their own level.
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is how important a log line is, lines below the level of their scope are dropped.
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	// OffLevel drops every line
+	OffLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "off"}
+
+func (L Level) String() string {
+	if L < DebugLevel || L > OffLevel {
+		return fmt.Sprintf("level(%d)", int(L))
+	}
+	return levelNames[L]
+}
+
+// ParseLevel reads a level by name, warning may be spelled out.
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warning" {
+		s = "warn"
+	}
+	for i, name := range levelNames {
+		if s == name {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, use one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// The formats of log lines
+const (
+	// ConsoleFormat is for people, as in
+	//
+	//   INFO  git: cloning url=https://github.com/hofstadter-io/hofmod-cli ref=v0.5.0
+	ConsoleFormat = "console"
+
+	// JSONFormat is for tools, an object per line, as in
+	//
+	//   {"time":"...","level":"info","scope":"git","msg":"cloning","ref":"v0.5.0","url":"..."}
+	JSONFormat = "json"
+)
+
+// DefaultLevel is the level of scopes which are not configured
+const DefaultLevel = WarnLevel
+
+var (
+	mu     sync.Mutex
+	out    io.Writer = os.Stderr
+	format           = ConsoleFormat
+	level            = DefaultLevel
+	scopes           = map[string]Level{}
+)
+
+// Configure sets up logging from the --verbose, --log-format, and --quiet flags.
+//
+// verbose is a comma separated list of a level for every scope and levels for scopes, as in
+//
+//   -v debug
+//   -v info,mod=debug,script=error
+//
+// quiet only keeps errors, and wins over verbose.
+func Configure(verbose, logFormat string, quiet bool) error {
+	lvl, scoped, err := parseVerbose(verbose)
+	if err != nil {
+		return err
+	}
+
+	switch logFormat {
+	case "":
+		logFormat = ConsoleFormat
+	case ConsoleFormat, JSONFormat:
+	default:
+		return fmt.Errorf("unknown log format %q, use %s or %s", logFormat, ConsoleFormat, JSONFormat)
+	}
+
+	if quiet {
+		lvl, scoped = ErrorLevel, map[string]Level{}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	level, scopes, format = lvl, scoped, logFormat
+	return nil
+}
+
+func parseVerbose(verbose string) (Level, map[string]Level, error) {
+	lvl, scoped := DefaultLevel, map[string]Level{}
+	for _, item := range strings.Split(verbose, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		scope, name := "", item
+		if i := strings.Index(item, "="); i >= 0 {
+			scope, name = item[:i], item[i+1:]
+		}
+		L, err := ParseLevel(name)
+		if err != nil {
+			return lvl, scoped, err
+		}
+		if scope == "" {
+			lvl = L
+		} else {
+			scoped[scope] = L
+		}
+	}
+	return lvl, scoped, nil
+}
+
+// SetOutput sends log lines to w, rather than stderr.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Logger writes the log lines of a scope, the part of hof they are from.
+type Logger struct {
+	scope string
+}
+
+// Scope returns the logger of scope, such as mod, gen, or script.
+func Scope(scope string) *Logger {
+	return &Logger{scope: scope}
+}
+
+// Enabled is whether lines at L are written for the scope.
+func (l *Logger) Enabled(L Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l.enabled(L)
+}
+
+func (l *Logger) enabled(L Level) bool {
+	min, ok := scopes[l.scope]
+	if !ok {
+		min = level
+	}
+	return L >= min && L < OffLevel
+}
+
+// Debug logs msg with key value pairs, as in
+//
+//   log.Debug("cloning", "url", url, "ref", ref)
+func (l *Logger) Debug(msg string, kvs ...interface{}) { l.Log(DebugLevel, msg, kvs...) }
+
+// Info logs msg with key value pairs, see Debug.
+func (l *Logger) Info(msg string, kvs ...interface{}) { l.Log(InfoLevel, msg, kvs...) }
+
+// Warn logs msg with key value pairs, see Debug.
+func (l *Logger) Warn(msg string, kvs ...interface{}) { l.Log(WarnLevel, msg, kvs...) }
+
+// Error logs msg with key value pairs, see Debug.
+func (l *Logger) Error(msg string, kvs ...interface{}) { l.Log(ErrorLevel, msg, kvs...) }
+
+// Log writes msg at L, with key value pairs. A key without a value is logged with the key "extra".
+func (l *Logger) Log(L Level, msg string, kvs ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !l.enabled(L) {
+		return
+	}
+
+	if len(kvs)%2 == 1 {
+		kvs = append(kvs[:len(kvs)-1:len(kvs)-1], "extra", kvs[len(kvs)-1])
+	}
+
+	var line string
+	if format == JSONFormat {
+		line = l.jsonLine(L, msg, kvs)
+	} else {
+		line = l.consoleLine(L, msg, kvs)
+	}
+	fmt.Fprintln(out, line)
+}
+
+func (l *Logger) consoleLine(L Level, msg string, kvs []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-5s %s: %s", strings.ToUpper(L.String()), l.scope, msg)
+	for i := 0; i < len(kvs); i += 2 {
+		v := fmt.Sprint(kvs[i+1])
+		if strings.ContainsAny(v, " \t\n\"=") || v == "" {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %v=%s", kvs[i], v)
+	}
+	return b.String()
+}
+
+func (l *Logger) jsonLine(L Level, msg string, kvs []interface{}) string {
+	fields := map[string]interface{}{}
+	for i := 0; i < len(kvs); i += 2 {
+		v := kvs[i+1]
+		// errors marshal as {}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[fmt.Sprint(kvs[i])] = v
+	}
+
+	// the fixed fields first, then the others sorted
+	var b strings.Builder
+	b.WriteString("{")
+	writeField(&b, "time", time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(",")
+	writeField(&b, "level", L.String())
+	b.WriteString(",")
+	writeField(&b, "scope", l.scope)
+	b.WriteString(",")
+	writeField(&b, "msg", msg)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(",")
+		writeField(&b, k, fields[k])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func writeField(b *strings.Builder, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(k)
+	b.WriteString(":")
+	b.Write(v)
+}
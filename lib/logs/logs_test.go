@@ -0,0 +1,80 @@
+package logs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/logs"
+)
+
+func TestLevels(t *testing.T) {
+	var b bytes.Buffer
+	logs.SetOutput(&b)
+
+	err := logs.Configure("info,mod=debug,script=off", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs.Scope("gen").Debug("dropped")
+	logs.Scope("gen").Info("rendered", "file", "out/main.go", "took", "1 ms")
+	logs.Scope("mod").Debug("cloning", "ref", "")
+	logs.Scope("script").Error("dropped")
+
+	expect := `INFO  gen: rendered file=out/main.go took="1 ms"
+DEBUG mod: cloning ref=""
+`
+	if b.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, b.String())
+	}
+
+	err = logs.Configure("debug", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs.Scope("mod").Enabled(logs.WarnLevel) || !logs.Scope("mod").Enabled(logs.ErrorLevel) {
+		t.Errorf("expected --quiet to only keep errors")
+	}
+
+	for _, bad := range []string{"loud", "mod=loud"} {
+		if logs.Configure(bad, "", false) == nil {
+			t.Errorf("expected an error for -v %s", bad)
+		}
+	}
+	if logs.Configure("", "xml", false) == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var b bytes.Buffer
+	logs.SetOutput(&b)
+
+	err := logs.Configure("debug", "json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs.Scope("mod").Warn("fetching", "module", "github.com/a/b", "attempt", 2, "odd")
+
+	var line map[string]interface{}
+	err = json.Unmarshal(b.Bytes(), &line)
+	if err != nil {
+		t.Fatalf("%v in %q", err, b.String())
+	}
+	for k, v := range map[string]interface{}{
+		"level":   "warn",
+		"scope":   "mod",
+		"msg":     "fetching",
+		"module":  "github.com/a/b",
+		"attempt": 2.0,
+		"extra":   "odd",
+	} {
+		if line[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, line[k])
+		}
+	}
+	if _, ok := line["time"]; !ok {
+		t.Errorf("expected a time in %q", b.String())
+	}
+}
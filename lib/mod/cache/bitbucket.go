@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/bitbucket"
+)
+
+func fetchBitbucket(lang, owner, repo, subdir, tag, tip string) (err error) {
+	FS := memfs.New()
+	filter := zipFilter(lang, subdir)
+
+	client, err := bitbucket.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if rev, ok := modfile.VersionCommit(tag); ok {
+		// archives can be fetched at any ref
+		err = fetchBitbucketBranch(FS, filter, client, owner, repo, rev)
+	} else if tip != "" {
+		err = fetchBitbucketBranch(FS, filter, client, owner, repo, tip)
+	} else if branch, ok := modfile.VersionBranch(tag); ok {
+		err = fetchBitbucketBranch(FS, filter, client, owner, repo, branch)
+	} else if tag == "v0.0.0" {
+		err = fetchBitbucketBranch(FS, filter, client, owner, repo, "")
+	} else {
+		err = fetchBitbucketTag(FS, filter, client, owner, repo, RepoTag(subdir, tag))
+	}
+	if err != nil {
+		return fmt.Errorf("While fetching from bitbucket\n%w\n", err)
+	}
+
+	modFS, err := subtree(FS, subdir)
+	if err != nil {
+		return fmt.Errorf("While fetching from bitbucket\n%w\n", err)
+	}
+
+	err = Write(lang, "bitbucket.org", owner, path.Join(repo, subdir), tag, modFS)
+	if err != nil {
+		return fmt.Errorf("While writing to cache\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchBitbucketBranch(FS billy.Filesystem, filter *yagu.ZipFilter, client *bitbucket.Client, owner, repo, branch string) error {
+	if branch == "" {
+		r, err := bitbucket.GetRepo(client, owner, repo)
+		if err != nil {
+			return err
+		}
+		branch = r.MainBranch.Name
+	}
+
+	data, ctype, err := bitbucket.FetchArchive(client, owner, repo, branch)
+	if err != nil {
+		return fmt.Errorf("While fetching branch archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading branch archive\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchBitbucketTag(FS billy.Filesystem, filter *yagu.ZipFilter, client *bitbucket.Client, owner, repo, tag string) error {
+	tags, err := bitbucket.GetTags(client, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	// The tag we are looking for
+	var T *bitbucket.Tag
+	for _, t := range tags {
+		if tag != "" && tag == t.Name {
+			T = t
+		}
+	}
+	if T == nil {
+		return fmt.Errorf("Did not find tag %q for 'https://bitbucket.org/%s/%s' @%s", tag, owner, repo, tag)
+	}
+
+	data, ctype, err := bitbucket.FetchArchive(client, owner, repo, T.Name)
+	if err != nil {
+		return fmt.Errorf("While fetching tag archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading tag archive\n%w\n", err)
+	}
+
+	return nil
+}
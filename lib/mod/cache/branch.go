@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/git"
+)
+
+// BranchTip returns the commit at the tip of a branch of the repository of mod,
+// listing the refs of the remote without fetching any of its content.
+func BranchTip(mod, branch string) (string, error) {
+	remote, owner, repo, _ := SplitMod(mod)
+	R, err := git.NewRemote(remote + "/" + owner + "/" + repo)
+	if err != nil {
+		return "", err
+	}
+
+	refs, err := R.RemoteRefs()
+	if err != nil {
+		return "", fmt.Errorf("While listing the refs of %s/%s/%s\n%w\n", remote, owner, repo, err)
+	}
+
+	name := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == name {
+			return ref.Hash().String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("Did not find branch %q for 'https://%s/%s/%s'", branch, remote, owner, repo)
+}
+
+// BranchCommit returns the commit a cached branch was fetched at,
+// which is recorded in a file beside the entry.
+func BranchCommit(lang, mod, ver string) (string, error) {
+	data, err := ioutil.ReadFile(ModDir(lang, mod, ver) + ".commit")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// fetchBranch fetches a branch at tip, resolving the tip first when it is empty,
+// so the commit recorded with the entry is exactly the one fetched.
+func fetchBranch(lang, mod, ver, tip string) error {
+	if tip == "" {
+		branch, _ := modfile.VersionBranch(ver)
+		var err error
+		tip, err = BranchTip(mod, branch)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := fetchDirect(lang, mod, ver, tip)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ModDir(lang, mod, ver)+".commit", []byte(tip+"\n"), 0644)
+}
+
+// refreshBranch fetches a cached branch again when its tip has moved
+// since it was fetched. When the tip cannot be checked, as when the
+// host cannot be reached, the cached copy is used.
+func refreshBranch(lang, mod, ver string) error {
+	branch, _ := modfile.VersionBranch(ver)
+	tip, err := BranchTip(mod, branch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Using cached %s@%s, could not check the branch tip: %v\n", mod, ver, err)
+		return nil
+	}
+
+	if have, _ := BranchCommit(lang, mod, ver); have == tip {
+		return nil
+	}
+
+	dir := ModDir(lang, mod, ver)
+	unlock, err := lock(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// another process may have fetched it while we waited
+	if have, _ := BranchCommit(lang, mod, ver); have == tip {
+		return nil
+	}
+
+	err = remove(Entry{Lang: lang, Module: mod, Version: ver, Dir: dir})
+	if err != nil {
+		return err
+	}
+
+	return fetchProgress(mod, ver, func() error {
+		return fetchBranch(lang, mod, ver, tip)
+	})
+}
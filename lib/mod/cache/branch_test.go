@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// gitServer serves a repository in memory with the smart HTTP protocol
+type gitServer struct {
+	sync.Mutex
+	st      *memory.Storage
+	wt      *gogit.Worktree
+	fetches int
+}
+
+func newGitServer(t *testing.T) *gitServer {
+	st := memory.NewStorage()
+	R, err := gogit.Init(st, memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := R.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &gitServer{st: st, wt: wt}
+}
+
+// commit writes files to the worktree and commits them, returning the commit
+func (g *gitServer) commit(t *testing.T, files map[string]string) string {
+	g.Lock()
+	defer g.Unlock()
+	for name, content := range files {
+		err := util.WriteFile(g.wt.Filesystem, name, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = g.wt.Add(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, err := g.wt.Commit("update", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.String()
+}
+
+func (g *gitServer) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	return g.st, nil
+}
+
+func (g *gitServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.Lock()
+	defer g.Unlock()
+
+	ep, err := transport.NewEndpoint("https://" + r.Host + r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sess, err := server.NewServer(g).NewUploadPackSession(ep, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/info/refs"):
+		ar, err := sess.AdvertisedReferences()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ar.Prefix = [][]byte{[]byte("# service=git-upload-pack"), pktline.Flush}
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		ar.Encode(w)
+
+	case strings.HasSuffix(r.URL.Path, "/git-upload-pack"):
+		req := packp.NewUploadPackRequest()
+		err := req.Decode(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// the server does not do shallow clones, so it sends no shallow commits and the full history
+		shallow := !req.Depth.IsZero()
+		req.Depth = packp.DepthCommits(0)
+		req.Capabilities.Delete(capability.Shallow)
+		resp, err := sess.UploadPack(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		g.fetches++
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		if shallow {
+			pktline.NewEncoder(w).Flush()
+		}
+		resp.Encode(w)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRefreshBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-branch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	setEnv(t, map[string]string{
+		"NETRC":           filepath.Join(dir, "netrc"),
+		"HOF_AUTH_CONFIG": filepath.Join(dir, "auth.cue"),
+		"HOF_MOD_PROXY":   "direct",
+	})
+	oldBase := LocalCacheBaseDir
+	defer SetBaseDir(oldBase)
+	SetBaseDir(filepath.Join(dir, "cache"))
+
+	g := newGitServer(t)
+	srv := httptest.NewTLSServer(g)
+	defer srv.Close()
+
+	// git over https goes to the test server, which has its own certificate
+	yagu.InstallGitTransport()
+	client.InstallProtocol("https", githttp.NewClient(srv.Client()))
+	defer client.InstallProtocol("https", githttp.NewClient(yagu.HTTPClient()))
+
+	mod := strings.TrimPrefix(srv.URL, "https://") + "/test/repo"
+	first := g.commit(t, map[string]string{"cue.mods": "module " + mod + "\n", "a.cue": "package a\n"})
+
+	tip, err := BranchTip(mod, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip != first {
+		t.Fatalf("got tip %s, want %s", tip, first)
+	}
+	_, err = BranchTip(mod, "nope")
+	if err == nil || !strings.Contains(err.Error(), `Did not find branch "nope"`) {
+		t.Fatalf("expected a missing branch to fail, got %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(ModDir("cue", mod, "@master"), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	err = Fetch("cue", mod, "@master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := BranchCommit("cue", mod, "@master"); got != first {
+		t.Fatalf("expected the fetched commit recorded, got %q", got)
+	}
+	if got := read("a.cue"); got != "package a\n" {
+		t.Fatalf("got a.cue %q", got)
+	}
+
+	// the tip has not moved, so the cached copy is used
+	fetches := g.fetches
+	err = Fetch("cue", mod, "@master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.fetches != fetches {
+		t.Fatalf("expected no fetch when the tip has not moved")
+	}
+
+	// the tip moved, so the branch is fetched again at the new tip
+	second := g.commit(t, map[string]string{"a.cue": "package a\n\nx: 1\n"})
+	err = Fetch("cue", mod, "@master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := BranchCommit("cue", mod, "@master"); got != second {
+		t.Fatalf("expected the new tip recorded, got %q, want %s", got, second)
+	}
+	if got := read("a.cue"); got != "package a\n\nx: 1\n" {
+		t.Fatalf("expected the new content, got a.cue %q", got)
+	}
+
+	// the cached copy is used when the tip cannot be checked
+	srv.Close()
+	err = Fetch("cue", mod, "@master")
+	if err != nil {
+		t.Fatalf("expected the cached copy to be used, got %v", err)
+	}
+	if got := read("a.cue"); got != "package a\n\nx: 1\n" {
+		t.Fatalf("expected the cached content kept, got a.cue %q", got)
+	}
+
+	// removing the entry removes the recorded commit
+	err = Remove(Entry{Lang: "cue", Module: mod, Version: "@master", Dir: ModDir("cue", mod, "@master")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BranchCommit("cue", mod, "@master"); !os.IsNotExist(err) {
+		t.Fatalf("expected the commit removed with the entry, got %v", err)
+	}
+}
@@ -2,20 +2,16 @@ package cache
 
 import (
 	"os"
-	"strings"
 
-	"golang.org/x/mod/sumdb/dirhash"
+	"github.com/go-git/go-billy/v5/osfs"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
+// Checksum returns the dirhash of the cached copy of mod at ver,
+// calculated as for the sumfile entries written when vendoring.
 func Checksum(lang, mod, ver string) (string, error) {
-
-	flds := strings.Split(mod, "/")
-	remote := flds[0]
-	owner := flds[1]
-	repo := flds[2]
-	tag := ver
-
-	dir := Outdir(lang, remote, owner, repo, tag)
+	dir := ModDir(lang, mod, ver)
 	// fmt.Println("Cache Checksum:", dir)
 
 	_, err := os.Lstat(dir)
@@ -23,8 +19,5 @@ func Checksum(lang, mod, ver string) (string, error) {
 		return "", err
 	}
 
-	h, err := dirhash.HashDir(dir, mod, dirhash.Hash1)
-
-	return h, err
+	return yagu.BillyCalcHash(osfs.New(dir))
 }
-
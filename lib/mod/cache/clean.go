@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a module version in the cache.
+type Entry struct {
+	Lang    string
+	Module  string
+	Version string
+	Dir     string
+	Size    int64
+
+	// LastUsed is the mtime of Dir, which Load refreshes
+	LastUsed time.Time
+}
+
+// Entries walks the cache and returns every module version it holds,
+// along with any leftovers from interrupted fetches, which have an empty Module.
+func Entries() ([]Entry, error) {
+	base := filepath.Join(LocalCacheBaseDir, "mod")
+
+	langs, err := readDirNames(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, lang := range langs {
+		root := filepath.Join(base, lang)
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			name := info.Name()
+
+			// leftovers from interrupted fetches
+			if strings.HasPrefix(name, ".download-") || strings.HasPrefix(name, ".extract-") {
+				entries = append(entries, Entry{Lang: lang, Dir: path, LastUsed: info.ModTime()})
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !info.IsDir() || !strings.Contains(name, "@") {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			i := strings.Index(rel, "@")
+
+			entries = append(entries, Entry{
+				Lang:     lang,
+				Module:   rel[:i],
+				Version:  rel[i+1:],
+				Dir:      path,
+				LastUsed: info.ModTime(),
+			})
+			return filepath.SkipDir
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range entries {
+		entries[i].Size, err = dirSize(entries[i].Dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Dir < entries[j].Dir
+	})
+
+	return entries, nil
+}
+
+// Remove deletes a cache entry, making read-only directories writable first.
+// It refuses to touch anything outside of the cache. The lock for a module
+// is held while it is removed, so a fetch of it is never removed part way.
+func Remove(E Entry) error {
+	err := checkInCache(E.Dir)
+	if err != nil {
+		return err
+	}
+
+	// leftovers from interrupted fetches have no lock
+	if E.Module == "" {
+		return remove(E)
+	}
+
+	unlock, err := lock(E.Dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return remove(E)
+}
+
+// remove deletes a cache entry, the caller holds its lock.
+func remove(E Entry) error {
+	err := checkInCache(E.Dir)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.Walk(E.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// files in a directory can be removed once the directory is writable
+		if info.IsDir() && info.Mode().Perm()&0200 == 0 {
+			return os.Chmod(path, info.Mode().Perm()|0200)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(E.Dir)
+	if err != nil {
+		return err
+	}
+
+	// the hash recorded by install, and the commit of a branch
+	for _, ext := range []string{".sum", ".commit"} {
+		err = os.Remove(E.Dir + ext)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkInCache(dir string) error {
+	base := filepath.Join(LocalCacheBaseDir, "mod") + string(os.PathSeparator)
+	if !strings.HasPrefix(filepath.Clean(dir), base) {
+		return fmt.Errorf("Refusing to remove %q, which is not in the module cache %q", dir, base)
+	}
+	return nil
+}
+
+// ParseAge parses a duration, which in addition to time.ParseDuration
+// may be a whole number of days or weeks, as in 30d or 2w.
+func ParseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("Invalid age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// FormatSize formats a byte count for people.
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// touch marks a cache entry as used.
+func touch(dir string) {
+	now := time.Now()
+	os.Chtimes(dir, now, now)
+}
+
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-remove")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := LocalCacheBaseDir
+	SetBaseDir(dir)
+	defer SetBaseDir(oldBase)
+
+	E := Entry{Lang: "cue", Module: "github.com/test/a", Version: "v0.1.0", Dir: ModDir("cue", "github.com/test/a", "v0.1.0")}
+	for _, fn := range []string{filepath.Join(E.Dir, "ro", "a.cue"), E.Dir + ".sum"} {
+		err = os.MkdirAll(filepath.Dir(fn), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(fn, []byte("a"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// read-only directories are made writable to be removed
+	err = os.Chmod(filepath.Join(E.Dir, "ro"), 0555)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fetch holds the lock, so the entry is removed once it is done
+	unlock, err := lock(E.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- Remove(E) }()
+
+	select {
+	case err = <-done:
+		t.Fatalf("expected Remove to wait for the lock, got %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if _, err := os.Stat(E.Dir); err != nil {
+		t.Fatalf("expected the entry while locked, got %v", err)
+	}
+
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []string{E.Dir, E.Dir + ".sum", E.Dir + ".lock"} {
+		if _, err := os.Stat(fn); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", fn, err)
+		}
+	}
+
+	// leftovers of interrupted fetches have no lock to wait for
+	left := Entry{Lang: "cue", Dir: filepath.Join(filepath.Dir(E.Dir), ".extract-123")}
+	err = os.MkdirAll(left.Dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Remove(left)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(left.Dir + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected no lock for a leftover, got %v", err)
+	}
+
+	// nothing outside of the cache is removed
+	err = Remove(Entry{Module: "github.com/test/a", Dir: os.TempDir()})
+	if err == nil {
+		t.Fatal("expected a dir outside the cache to be refused")
+	}
+}
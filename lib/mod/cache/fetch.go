@@ -2,130 +2,223 @@ package cache
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"strings"
 
 	googithub "github.com/google/go-github/v30/github"
-	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/memfs"
 
-	"github.com/hofstadter-io/hof/lib/yagu"
+	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/mod/proxy"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/gitea"
 	"github.com/hofstadter-io/hof/lib/yagu/repos/github"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/gitlab"
 )
 
+// Fetch makes sure mod at ver is in the cache.
+// Fetches hold a lock on the entry, so parallel runs do not download it twice.
+// Cached branches are fetched again only when the tip of the branch has moved.
 func Fetch(lang, mod, ver string) (err error) {
+	dir := ModDir(lang, mod, ver)
+
+	if exists(dir) {
+		if _, ok := modfile.VersionBranch(ver); ok && !offline {
+			return refreshBranch(lang, mod, ver)
+		}
+		return nil
+	}
+
+	if offline {
+		return &MissingError{Module: mod, Version: ver}
+	}
+
+	unlock, err := lock(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// another process may have fetched it while we waited
+	if exists(dir) {
+		return nil
+	}
+
+	return fetchProgress(mod, ver, func() error {
+		return fetch(lang, mod, ver)
+	})
+}
+
+func exists(dir string) bool {
+	_, err := os.Lstat(dir)
+	return err == nil
+}
+
+// SplitMod splits a module path into its remote, owner, repo,
+// and the subdirectory of a module nested in the repository.
+// On GitHub, Bitbucket, and Gitea repositories are always owner/repo, so anything
+// deeper is a subdirectory, as in github.com/org/repo/sub/dir,
+// whose versions are tagged with the subdirectory, as in sub/dir/v1.2.3.
+// Elsewhere the owner may have several parts, as in gitlab.com/group/subgroup/repo
+// or dev.azure.com/org/project/_git/repo, so modules are at the repository root.
+func SplitMod(mod string) (remote, owner, repo, subdir string) {
 	flds := strings.Split(mod, "/")
-	remote := flds[0]
-	owner := flds[1]
-	repo := flds[2]
-	tag := ver
+	if len(flds) > 3 && flds[0] != "bitbucket.org" && !github.IsGitHub(flds[0]) && !gitea.IsGitea(flds[0]) {
+		last := len(flds) - 1
+		return flds[0], strings.Join(flds[1:last], "/"), flds[last], ""
+	}
+	return flds[0], flds[1], flds[2], strings.Join(flds[3:], "/")
+}
 
-	dir := Outdir(lang, remote, owner, repo, tag)
+// ModDir returns the cache directory for mod at ver.
+func ModDir(lang, mod, ver string) string {
+	remote, owner, repo, subdir := SplitMod(mod)
+	return Outdir(lang, remote, owner, path.Join(repo, subdir), ver)
+}
 
-	_, err = os.Lstat(dir)
+// RepoTag returns the repository tag for a version of a module,
+// which is prefixed by the subdirectory for nested modules.
+// Branches, commits, and v0.0.0 are not tags and are returned as is.
+func RepoTag(subdir, ver string) string {
+	if subdir == "" || ver == "v0.0.0" || !semver.IsValid(ver) {
+		return ver
+	}
+	return subdir + "/" + ver
+}
+
+// fetch tries each of the proxies in $HOF_MOD_PROXY in order,
+// moving on when a proxy does not have the module,
+// or after any error when the entry is followed by a pipe.
+func fetch(lang, mod, ver string) error {
+	// proxies only serve versions, branches are always fetched directly
+	if _, ok := modfile.VersionBranch(ver); ok {
+		return fetchBranch(lang, mod, ver, "")
+	}
+
+	proxies, err := proxy.Proxies()
 	if err != nil {
-		if _, ok := err.(*os.PathError); !ok && err.Error() != "file does not exist" && err.Error() != "no such file or directory" {
+		return err
+	}
+
+	for _, p := range proxies {
+		switch p.URL {
+		case proxy.Direct:
+			err = fetchDirect(lang, mod, ver, "")
+		case proxy.Off:
+			err = fmt.Errorf("Module fetching disabled by HOF_MOD_PROXY=off for %s@%s", mod, ver)
+		default:
+			err = fetchProxy(lang, mod, ver, p.URL)
+		}
+		if err == nil {
+			return nil
+		}
+		if !p.FallbackOnError && !proxy.IsNotFound(err) {
 			return err
 		}
-		// not found
-		fetch(lang, mod, ver)
 	}
 
-	// else we have it already
-	// fmt.Println("Found in cache")
-
-	return nil
+	return err
 }
 
-func fetch(lang, mod, ver string) error {
-	flds := strings.Split(mod, "/")
-	remote := flds[0]
-	owner := flds[1]
-	repo := flds[2]
+// fetchDirect fetches from the version control host of mod.
+// For branches, tip is the commit the branch was resolved to,
+// which is fetched instead of the branch when set.
+func fetchDirect(lang, mod, ver, tip string) error {
+	remote, owner, repo, subdir := SplitMod(mod)
 	tag := ver
 
+	// private repositories are cloned over ssh
+	if auth.UseSSH(remote) {
+		return fetchGit(lang, remote, owner, repo, subdir, tag, tip)
+	}
+
 	switch remote {
-	case "github.com":
-		return fetchGitHub(lang, owner, repo, tag)
+	case "bitbucket.org":
+		return fetchBitbucket(lang, owner, repo, subdir, tag, tip)
 
 	default:
-		return fmt.Errorf("Unknown remote: %q in %s", remote, mod)
+		if github.IsGitHub(remote) {
+			return fetchGitHub(lang, remote, owner, repo, subdir, tag, tip)
+		}
+		if gitlab.IsGitLab(remote) {
+			return fetchGitLab(lang, remote, owner, repo, tag, tip)
+		}
+		if gitea.IsGitea(remote) {
+			return fetchGitea(lang, remote, owner, repo, subdir, tag, tip)
+		}
+		return fetchGit(lang, remote, owner, repo, "", tag, tip)
 	}
 }
 
-func fetchGitHub(lang, owner, repo, tag string) (err error) {
-	FS := memfs.New()
-
-	if tag == "v0.0.0" {
-		err = fetchGitHubBranch(FS, lang, owner, repo, "")
+func fetchGitHub(lang, host, owner, repo, subdir, tag, tip string) (err error) {
+	var url string
+
+	if rev, ok := modfile.VersionCommit(tag); ok {
+		url, err = gitHubCommitURL(host, owner, repo, rev)
+	} else if tip != "" {
+		url = github.ArchiveURL(host, owner, repo, tip)
+	} else if branch, ok := modfile.VersionBranch(tag); ok {
+		url, err = gitHubBranchURL(host, owner, repo, branch)
+	} else if tag == "v0.0.0" {
+		url, err = gitHubBranchURL(host, owner, repo, "")
 	} else {
-		err = fetchGitHubTag(FS, lang, owner, repo, tag)
+		url, err = gitHubTagURL(host, owner, repo, RepoTag(subdir, tag))
 	}
 	if err != nil {
 		return fmt.Errorf("While fetching from github\n%w\n", err)
 	}
 
-	/*
-	fmt.Println("filelist:")
-	files, err := yagu.BillyGetFilelist(FS)
-	if err != nil {
-		return fmt.Errorf("While getting filelist\n%w\n", err)
-	}
-
-	for _, f := range files {
-		fmt.Println(" -", f.Name())
-	}
-
-	fmt.Println("Writing...", )
-	*/
-	err = Write(lang, "github.com", owner, repo, tag, FS)
+	err = WriteZip(lang, host, owner, repo, subdir, tag, func(w io.Writer) error {
+		return github.DownloadZip(host, url, w)
+	})
 	if err != nil {
 		return fmt.Errorf("While writing to cache\n%w\n", err)
 	}
 
 	return nil
 }
-func fetchGitHubBranch(FS billy.Filesystem, lang, owner, repo, branch string) error {
-	client, err := github.NewClient()
-	if err != nil {
-		return err
-	}
-
-	// TODO find and set default branch
+func gitHubBranchURL(host, owner, repo, branch string) (string, error) {
 	if branch == "" {
-		branch = "master"
-		r, err := github.GetRepo(client, owner, repo)
+		client, err := github.NewClient(host)
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		fmt.Printf("%#+v\n", *r)
+		r, err := github.GetRepo(client, owner, repo)
+		if err != nil {
+			return "", err
+		}
+		branch = r.GetDefaultBranch()
 	}
 
-	// fmt.Println("Fetch github BRANCH", lang, owner, repo, branch)
-
-	zReader, err := github.FetchBranchZip(client, branch)
+	return github.ArchiveURL(host, owner, repo, branch), nil
+}
+func gitHubCommitURL(host, owner, repo, rev string) (string, error) {
+	client, err := github.NewClient(host)
 	if err != nil {
-		return fmt.Errorf("While fetching branch zipfile\n%w\n", err)
+		return "", err
 	}
 
-	err = yagu.BillyLoadFromZip(zReader, FS, true)
+	// resolve abbreviated hashes
+	c, err := github.GetCommit(client, owner, repo, rev)
 	if err != nil {
-		return fmt.Errorf("While reading branch zipfile\n%w\n", err)
+		return "", fmt.Errorf("Did not find commit %q for 'https://%s/%s/%s'\n%w\n", rev, host, owner, repo, err)
 	}
 
-	return nil
+	return github.ArchiveURL(host, owner, repo, c.GetSHA()), nil
 }
-func fetchGitHubTag(FS billy.Filesystem, lang, owner, repo, tag string) error {
-	// fmt.Println("Fetch github TAG", lang, owner, repo, tag)
-	client, err := github.NewClient()
+func gitHubTagURL(host, owner, repo, tag string) (string, error) {
+	client, err := github.NewClient(host)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	tags, err := github.GetTags(client, owner, repo)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// The tag we are looking for
@@ -133,22 +226,11 @@ func fetchGitHubTag(FS billy.Filesystem, lang, owner, repo, tag string) error {
 	for _, t := range tags {
 		if tag != "" && tag == *t.Name {
 			T = t
-			// fmt.Printf("FOUND  %v\n", *t.Name)
 		}
 	}
 	if T == nil {
-		return fmt.Errorf("Did not find tag %q for 'https://github.com/%s/%s' @%s", tag, owner, repo, tag)
-	}
-
-	zReader, err := github.FetchTagZip(client, T)
-	if err != nil {
-		return fmt.Errorf("While fetching tag zipfile\n%w\n", err)
+		return "", fmt.Errorf("Did not find tag %q for 'https://%s/%s/%s' @%s", tag, host, owner, repo, tag)
 	}
 
-	err = yagu.BillyLoadFromZip(zReader, FS, true)
-	if err != nil {
-		return fmt.Errorf("While reading tag zipfile\n%w\n", err)
-	}
-
-	return nil
+	return T.GetZipballURL(), nil
 }
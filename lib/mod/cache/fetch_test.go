@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestSplitMod(t *testing.T) {
+	setEnv(t, map[string]string{
+		"GITHUB_BASE_URL": "",
+		"GITHUB_HOSTS":    "ghe.corp.lan",
+		"GITEA_HOSTS":     "git.corp.lan",
+	})
+
+	tests := []struct {
+		mod                         string
+		remote, owner, repo, subdir string
+	}{
+		{"github.com/org/repo", "github.com", "org", "repo", ""},
+		{"github.com/org/repo/sub", "github.com", "org", "repo", "sub"},
+		{"github.com/org/repo/sub/dir", "github.com", "org", "repo", "sub/dir"},
+		{"ghe.corp.lan/org/repo/sub", "ghe.corp.lan", "org", "repo", "sub"},
+		{"bitbucket.org/team/repo/sub", "bitbucket.org", "team", "repo", "sub"},
+		{"codeberg.org/org/repo/sub", "codeberg.org", "org", "repo", "sub"},
+		{"git.corp.lan/org/repo/sub", "git.corp.lan", "org", "repo", "sub"},
+		// elsewhere the owner may have several parts
+		{"gitlab.com/group/repo", "gitlab.com", "group", "repo", ""},
+		{"gitlab.com/group/subgroup/repo", "gitlab.com", "group/subgroup", "repo", ""},
+		{"dev.azure.com/org/project/_git/repo", "dev.azure.com", "org/project/_git", "repo", ""},
+	}
+	for _, tt := range tests {
+		remote, owner, repo, subdir := SplitMod(tt.mod)
+		if remote != tt.remote || owner != tt.owner || repo != tt.repo || subdir != tt.subdir {
+			t.Errorf("%s: got %q %q %q %q, want %q %q %q %q", tt.mod,
+				remote, owner, repo, subdir, tt.remote, tt.owner, tt.repo, tt.subdir)
+		}
+	}
+}
+
+func TestRepoTag(t *testing.T) {
+	tests := []struct {
+		subdir, ver, expect string
+	}{
+		{"", "v1.2.3", "v1.2.3"},
+		{"sub", "v1.2.3", "sub/v1.2.3"},
+		{"sub/dir", "v0.1.0-beta.1", "sub/dir/v0.1.0-beta.1"},
+		// not tags
+		{"sub", "v0.0.0", "v0.0.0"},
+		{"sub", "main", "main"},
+		{"sub", "abc123", "abc123"},
+	}
+	for _, tt := range tests {
+		if got := RepoTag(tt.subdir, tt.ver); got != tt.expect {
+			t.Errorf("%q %q: got %q, want %q", tt.subdir, tt.ver, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"path"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// filters select the files kept in the cache entries of each language, see SetFilter.
+var filters = map[string]*yagu.ZipFilter{}
+
+// SetFilter sets the files kept when fetching modules of lang, nil keeps all of them.
+// Names are relative to the module. Cache entries are shared by every project,
+// so the filter should only skip files which no project needs,
+// such as test fixtures, docs, and binaries.
+func SetFilter(lang string, filter *yagu.ZipFilter) {
+	if filter == nil {
+		delete(filters, lang)
+		return
+	}
+	filters[lang] = filter
+}
+
+// zipFilter returns the filter of lang for loading a repository archive,
+// with its globs made relative to the repository for a module in subdir,
+// so that only the files of the module are loaded.
+func zipFilter(lang, subdir string) *yagu.ZipFilter {
+	filter := filters[lang]
+	if subdir == "" {
+		return filter
+	}
+
+	out := &yagu.ZipFilter{
+		Includes: []string{path.Join(subdir, "**")},
+	}
+	if filter == nil {
+		return out
+	}
+	if len(filter.Includes) > 0 {
+		out.Includes = nil
+		for _, pattern := range filter.Includes {
+			out.Includes = append(out.Includes, path.Join(subdir, pattern))
+		}
+	}
+	for _, pattern := range filter.Excludes {
+		out.Excludes = append(out.Excludes, path.Join(subdir, pattern))
+	}
+	out.MaxSize = filter.MaxSize
+	return out
+}
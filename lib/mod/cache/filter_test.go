@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+func TestZipFilter(t *testing.T) {
+	defer SetFilter("cue", nil)
+
+	if zipFilter("cue", "") != nil {
+		t.Fatalf("expected no filter by default")
+	}
+	expect := &yagu.ZipFilter{Includes: []string{"nested/**"}}
+	if got := zipFilter("cue", "nested"); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expected only the files of a nested module, got %+v", got)
+	}
+
+	filter := &yagu.ZipFilter{Includes: []string{"**/*.cue"}, Excludes: []string{"testdata"}, MaxSize: 1024}
+	SetFilter("cue", filter)
+	if got := zipFilter("cue", ""); got != filter {
+		t.Fatalf("expected the filter as set, got %+v", got)
+	}
+	expect = &yagu.ZipFilter{Includes: []string{"nested/**/*.cue"}, Excludes: []string{"nested/testdata"}, MaxSize: 1024}
+	if got := zipFilter("cue", "nested"); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expected the globs relative to the repository, got %+v", got)
+	}
+	if ok, _ := zipFilter("cue", "nested").Match("other/app.cue", 1); ok {
+		t.Fatalf("expected the files of other modules to be skipped")
+	}
+
+	SetFilter("cue", nil)
+	if zipFilter("cue", "") != nil {
+		t.Fatalf("expected the filter to be removed")
+	}
+}
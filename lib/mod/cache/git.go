@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/git"
+)
+
+// fetchGit clones any git remote at a tag or commit, for hosts
+// without a dedicated fetcher, such as cgit or Azure DevOps,
+// and for hosts configured to use ssh in the hof auth config.
+// Branches are cloned shallowly, and must still be at tip when it is set.
+func fetchGit(lang, remote, owner, repo, subdir, tag, tip string) error {
+	ref := RepoTag(subdir, tag)
+	if rev, ok := modfile.VersionCommit(tag); ok {
+		ref = rev
+	} else if branch, ok := modfile.VersionBranch(tag); ok {
+		ref = branch
+	} else if tag == "v0.0.0" {
+		ref = ""
+	}
+
+	R, err := git.CloneRef(git.RemoteURL(remote+"/"+owner+"/"+repo), ref)
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+	}
+
+	if tip != "" {
+		head, err := R.Repo.Head()
+		if err != nil {
+			return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+		}
+		if head.Hash().String() != tip {
+			return fmt.Errorf("Branch %s of %s/%s/%s moved while fetching, try again", ref, remote, owner, repo)
+		}
+	}
+
+	FS, err := subtree(R.FS, subdir)
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+	}
+
+	err = Write(lang, remote, owner, path.Join(repo, subdir), tag, FS)
+	if err != nil {
+		return fmt.Errorf("While writing to cache\n%w\n", err)
+	}
+
+	return nil
+}
+
+// subtree returns the subdirectory of a repository which holds a nested module.
+func subtree(FS billy.Filesystem, subdir string) (billy.Filesystem, error) {
+	if subdir == "" {
+		return FS, nil
+	}
+
+	info, err := FS.Stat(subdir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("Did not find directory %q in the repository", subdir)
+	}
+
+	return FS.Chroot(subdir)
+}
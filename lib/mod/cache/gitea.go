@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/gitea"
+)
+
+func fetchGitea(lang, remote, owner, repo, subdir, tag, tip string) (err error) {
+	FS := memfs.New()
+	filter := zipFilter(lang, subdir)
+
+	client, err := gitea.NewClient(remote)
+	if err != nil {
+		return err
+	}
+
+	if rev, ok := modfile.VersionCommit(tag); ok {
+		// archives can be fetched at any ref
+		err = fetchGiteaBranch(FS, filter, client, owner, repo, rev)
+	} else if tip != "" {
+		err = fetchGiteaBranch(FS, filter, client, owner, repo, tip)
+	} else if branch, ok := modfile.VersionBranch(tag); ok {
+		err = fetchGiteaBranch(FS, filter, client, owner, repo, branch)
+	} else if tag == "v0.0.0" {
+		err = fetchGiteaBranch(FS, filter, client, owner, repo, "")
+	} else {
+		err = fetchGiteaTag(FS, filter, client, owner, repo, RepoTag(subdir, tag))
+	}
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+	}
+
+	modFS, err := subtree(FS, subdir)
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+	}
+
+	err = Write(lang, remote, owner, path.Join(repo, subdir), tag, modFS)
+	if err != nil {
+		return fmt.Errorf("While writing to cache\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchGiteaBranch(FS billy.Filesystem, filter *yagu.ZipFilter, client *gitea.Client, owner, repo, branch string) error {
+	if branch == "" {
+		r, err := gitea.GetRepo(client, owner, repo)
+		if err != nil {
+			return err
+		}
+		branch = r.DefaultBranch
+	}
+
+	data, ctype, err := gitea.FetchArchive(client, owner, repo, branch)
+	if err != nil {
+		return fmt.Errorf("While fetching branch archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading branch archive\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchGiteaTag(FS billy.Filesystem, filter *yagu.ZipFilter, client *gitea.Client, owner, repo, tag string) error {
+	tags, err := gitea.GetTags(client, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	// The tag we are looking for
+	var T *gitea.Tag
+	for _, t := range tags {
+		if tag != "" && tag == t.Name {
+			T = t
+		}
+	}
+	if T == nil {
+		return fmt.Errorf("Did not find tag %q for 'https://%s/%s/%s' @%s", tag, client.Host, owner, repo, tag)
+	}
+
+	data, ctype, err := gitea.FetchArchive(client, owner, repo, T.Name)
+	if err != nil {
+		return fmt.Errorf("While fetching tag archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading tag archive\n%w\n", err)
+	}
+
+	return nil
+}
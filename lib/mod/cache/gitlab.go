@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/gitlab"
+)
+
+func fetchGitLab(lang, remote, owner, repo, tag, tip string) (err error) {
+	FS := memfs.New()
+	filter := zipFilter(lang, "")
+
+	client, err := gitlab.NewClient(remote)
+	if err != nil {
+		return err
+	}
+
+	if rev, ok := modfile.VersionCommit(tag); ok {
+		// archives can be fetched at any ref
+		err = fetchGitLabBranch(FS, filter, client, owner, repo, rev)
+	} else if tip != "" {
+		err = fetchGitLabBranch(FS, filter, client, owner, repo, tip)
+	} else if branch, ok := modfile.VersionBranch(tag); ok {
+		err = fetchGitLabBranch(FS, filter, client, owner, repo, branch)
+	} else if tag == "v0.0.0" {
+		err = fetchGitLabBranch(FS, filter, client, owner, repo, "")
+	} else {
+		err = fetchGitLabTag(FS, filter, client, owner, repo, tag)
+	}
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", remote, err)
+	}
+
+	err = Write(lang, remote, owner, repo, tag, FS)
+	if err != nil {
+		return fmt.Errorf("While writing to cache\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchGitLabBranch(FS billy.Filesystem, filter *yagu.ZipFilter, client *gitlab.Client, owner, repo, branch string) error {
+	if branch == "" {
+		p, err := gitlab.GetProject(client, owner, repo)
+		if err != nil {
+			return err
+		}
+		branch = p.DefaultBranch
+	}
+
+	data, ctype, err := gitlab.FetchArchive(client, owner, repo, branch)
+	if err != nil {
+		return fmt.Errorf("While fetching branch archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading branch archive\n%w\n", err)
+	}
+
+	return nil
+}
+
+func fetchGitLabTag(FS billy.Filesystem, filter *yagu.ZipFilter, client *gitlab.Client, owner, repo, tag string) error {
+	tags, err := gitlab.GetTags(client, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	// The tag we are looking for
+	var T *gitlab.Tag
+	for _, t := range tags {
+		if tag != "" && tag == t.Name {
+			T = t
+		}
+	}
+	if T == nil {
+		return fmt.Errorf("Did not find tag %q for 'https://%s/%s/%s' @%s", tag, client.Host, owner, repo, tag)
+	}
+
+	data, ctype, err := gitlab.FetchArchive(client, owner, repo, T.Name)
+	if err != nil {
+		return fmt.Errorf("While fetching tag archive\n%w\n", err)
+	}
+
+	err = yagu.BillyLoadFromArchive(data, ctype, FS, true, filter)
+	if err != nil {
+		return fmt.Errorf("While reading tag archive\n%w\n", err)
+	}
+
+	return nil
+}
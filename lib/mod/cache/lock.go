@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// staleLock is how old a lock can get before it is considered
+// left behind by a process that died while holding it.
+const staleLock = 10 * time.Minute
+
+// lock takes the lock for a cache entry, waiting while another process holds it.
+// The lock is a file beside the entry, created exclusively so it works across processes.
+func lock(dir string) (unlock func(), err error) {
+	err = yagu.Mkdir(filepath.Dir(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile := dir + ".lock"
+	waiting := false
+	for {
+		f, err := os.OpenFile(lockfile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockfile) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		info, err := os.Stat(lockfile)
+		if err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lockfile)
+			continue
+		}
+
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "Waiting for lock on %s\n", dir)
+			waiting = true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
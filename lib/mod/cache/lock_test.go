@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entry := filepath.Join(dir, "github.com", "test", "a@v0.1.0")
+	unlock, err := lock(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a second fetch of the entry waits for the first
+	locked := make(chan func())
+	go func() {
+		u, err := lock(entry)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- u
+	}()
+	select {
+	case <-locked:
+		t.Fatal("expected the lock to be held")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case u := <-locked:
+		u()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock once released")
+	}
+	if _, err := os.Stat(entry + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock file removed, got %v", err)
+	}
+
+	// a lock left by a process which died is taken over
+	err = ioutil.WriteFile(entry+".lock", []byte("1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	err = os.Chtimes(entry+".lock", old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = lock(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
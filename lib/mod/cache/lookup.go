@@ -2,20 +2,13 @@ package cache
 
 import (
 	"os"
-	"strings"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/osfs"
 )
 
 func Load(lang, mod, ver string) (FS billy.Filesystem, err error) {
-	flds := strings.Split(mod, "/")
-	remote := flds[0]
-	owner := flds[1]
-	repo := flds[2]
-	tag := ver
-
-	dir := Outdir(lang, remote, owner, repo, tag)
+	dir := ModDir(lang, mod, ver)
 
 	// fmt.Println("Cache Load:", dir)
 
@@ -24,6 +17,8 @@ func Load(lang, mod, ver string) (FS billy.Filesystem, err error) {
 		if _, ok := err.(*os.PathError); !ok && err.Error() != "file does not exist" && err.Error() != "no such file or directory" {
 			return nil, err
 		}
+	} else {
+		touch(dir)
 	}
 
 	FS = osfs.New(dir)
@@ -0,0 +1,353 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/oci"
+)
+
+const (
+	ociArtifactType   = "application/vnd.hofstadter.mod.cache.v1"
+	ociConfigType     = "application/vnd.hofstadter.mod.cache.config.v1+json"
+	ociEntryLayerType = "application/vnd.hofstadter.mod.cache.entry.v1.tar+gzip"
+
+	ociAnnotationLang    = "dev.hofstadter.mod.lang"
+	ociAnnotationModule  = "dev.hofstadter.mod.module"
+	ociAnnotationVersion = "dev.hofstadter.mod.version"
+)
+
+// Export pushes cache entries to a registry as a single OCI artifact,
+// with one layer per module version, annotated with its language, module, and version.
+// Layers are built reproducibly, so unchanged modules are not uploaded again.
+func Export(ref string, entries []Entry) error {
+	R, err := oci.ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	client := oci.NewClient(R.Host)
+	err = client.Authorize(R.Repo, "pull,push")
+	if err != nil {
+		return err
+	}
+
+	tmpdir, err := ioutil.TempDir("", "hof-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	config := []byte("{}")
+	M := &oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeManifest,
+		ArtifactType:  ociArtifactType,
+		Config: oci.Descriptor{
+			MediaType: ociConfigType,
+			Digest:    digestOf(config),
+			Size:      int64(len(config)),
+		},
+	}
+	err = pushBlob(client, R.Repo, M.Config.Digest, M.Config.Size, func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(config)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, E := range entries {
+		if E.Module == "" {
+			continue
+		}
+
+		layer := filepath.Join(tmpdir, "layer.tar.gz")
+		digest, size, err := writeLayer(E.Dir, layer)
+		if err != nil {
+			return fmt.Errorf("While packing %s@%s\n%w\n", E.Module, E.Version, err)
+		}
+
+		err = pushBlob(client, R.Repo, digest, size, func() (io.ReadCloser, error) {
+			return os.Open(layer)
+		})
+		if err != nil {
+			return fmt.Errorf("While pushing %s@%s\n%w\n", E.Module, E.Version, err)
+		}
+
+		M.Layers = append(M.Layers, oci.Descriptor{
+			MediaType: ociEntryLayerType,
+			Digest:    digest,
+			Size:      size,
+			Annotations: map[string]string{
+				ociAnnotationLang:    E.Lang,
+				ociAnnotationModule:  E.Module,
+				ociAnnotationVersion: E.Version,
+			},
+		})
+		fmt.Printf("exported %s %s@%s (%s)\n", E.Lang, E.Module, E.Version, FormatSize(size))
+	}
+
+	err = client.PushManifest(R.Repo, R.Tag, M)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pushed %d modules to %s\n", len(M.Layers), R)
+	return nil
+}
+
+// Import fetches the modules in an artifact made by Export into the cache.
+// Modules already in the cache are left alone. Each layer is checked
+// against its digest and installed the same way as a fetch from a repository.
+func Import(ref string) error {
+	R, err := oci.ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	client := oci.NewClient(R.Host)
+	err = client.Authorize(R.Repo, "pull")
+	if err != nil {
+		return err
+	}
+
+	M, err := client.FetchManifest(R.Repo, R.Tag)
+	if err != nil {
+		return err
+	}
+	if M.ArtifactType != ociArtifactType && M.Config.MediaType != ociConfigType {
+		return fmt.Errorf("%s is not a module cache artifact made by 'hof mod cache export'", R)
+	}
+
+	count := 0
+	for _, L := range M.Layers {
+		if L.MediaType != ociEntryLayerType {
+			continue
+		}
+		lang := L.Annotations[ociAnnotationLang]
+		mod := L.Annotations[ociAnnotationModule]
+		ver := L.Annotations[ociAnnotationVersion]
+		if lang == "" || mod == "" || ver == "" {
+			return fmt.Errorf("Layer %s is missing its module annotations", L.Digest)
+		}
+
+		err = importLayer(client, R.Repo, L, lang, mod, ver)
+		if err != nil {
+			return fmt.Errorf("While importing %s@%s\n%w\n", mod, ver, err)
+		}
+		count++
+	}
+
+	fmt.Printf("All %d modules from %s are in the cache\n", count, R)
+	return nil
+}
+
+func importLayer(client *oci.Client, repo string, L oci.Descriptor, lang, mod, ver string) error {
+	dir := ModDir(lang, mod, ver)
+	if !strings.HasPrefix(dir, filepath.Join(LocalCacheBaseDir, "mod", lang)+string(os.PathSeparator)) {
+		return fmt.Errorf("Illegal module path %q", mod)
+	}
+	if exists(dir) {
+		return nil
+	}
+
+	unlock, err := lock(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if exists(dir) {
+		return nil
+	}
+
+	parent := filepath.Dir(dir)
+	tmpdir, err := ioutil.TempDir(parent, ".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	r, err := client.FetchBlob(repo, L.Digest)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// the digest is checked before the module is installed
+	h := sha256.New()
+	err = extractTarGz(io.TeeReader(r, h), tmpdir)
+	if err != nil {
+		return err
+	}
+	// drain the padding which tar does not read
+	io.Copy(h, r)
+	if d := "sha256:" + hex.EncodeToString(h.Sum(nil)); d != L.Digest {
+		return fmt.Errorf("digest mismatch, have %s, want %s", d, L.Digest)
+	}
+
+	fmt.Printf("imported %s %s@%s\n", lang, mod, ver)
+	return install(tmpdir, dir)
+}
+
+// pushBlob uploads a blob unless the registry already has it.
+func pushBlob(client *oci.Client, repo, digest string, size int64, open func() (io.ReadCloser, error)) error {
+	ok, err := client.BlobExists(repo, digest)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return client.PushBlob(repo, digest, size, r)
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeLayer packs the files under dir into a tar.gz at filename,
+// returning its digest and size. Times and owners are left out,
+// so the same files always make the same layer.
+func writeLayer(dir, filename string) (digest string, size int64, err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, h))
+	tw := tar.NewWriter(gz)
+
+	// Walk visits files in lexical order
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Name:   filepath.ToSlash(rel),
+			Mode:   int64(info.Mode().Perm()),
+			Format: tar.FormatPAX,
+		}
+		if info.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			return tw.WriteHeader(hdr)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = info.Size()
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		r, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(tw, r)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return "", 0, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return "", 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), info.Size(), nil
+}
+
+// extractTarGz writes the files of a layer under dir,
+// refusing any which would land outside of it.
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("Illegal file path in layer: %q", hdr.Name)
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = yagu.Mkdir(target)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if mode == 0 {
+				mode = 0644
+			}
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err != nil {
+				return err
+			}
+			w, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, tr)
+			if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("Unsupported file type in layer: %q", hdr.Name)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/oci"
+)
+
+// registry is an in memory OCI registry, without authentication
+type registry struct {
+	sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newRegistry() *registry {
+	return &registry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+}
+
+func (reg *registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reg.Lock()
+	defer reg.Unlock()
+
+	p := r.URL.Path
+	switch {
+	case p == "/v2/":
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == "POST" && strings.HasSuffix(p, "/blobs/uploads/"):
+		w.Header().Set("Location", p+"upload-1")
+		w.WriteHeader(http.StatusAccepted)
+
+	case r.Method == "PUT" && strings.Contains(p, "/blobs/uploads/"):
+		data, _ := ioutil.ReadAll(r.Body)
+		digest := r.URL.Query().Get("digest")
+		if sum := sha256.Sum256(data); "sha256:"+hex.EncodeToString(sum[:]) != digest {
+			http.Error(w, "digest does not match", http.StatusBadRequest)
+			return
+		}
+		reg.blobs[digest] = data
+		reg.uploads++
+		w.WriteHeader(http.StatusCreated)
+
+	case strings.Contains(p, "/blobs/"):
+		data, ok := reg.blobs[p[strings.LastIndex(p, "/")+1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+
+	case r.Method == "PUT" && strings.Contains(p, "/manifests/"):
+		data, _ := ioutil.ReadAll(r.Body)
+		reg.manifests[p] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case strings.Contains(p, "/manifests/"):
+		data, ok := reg.manifests[p]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (reg *registry) manifest(t *testing.T, p string) *oci.Manifest {
+	reg.Lock()
+	defer reg.Unlock()
+	var M oci.Manifest
+	err := json.Unmarshal(reg.manifests[p], &M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &M
+}
+
+func (reg *registry) setManifest(t *testing.T, p string, M *oci.Manifest) {
+	reg.Lock()
+	defer reg.Unlock()
+	data, err := json.Marshal(M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.manifests[p] = data
+}
+
+// setEnv sets the variables for the rest of a test.
+func setEnv(t *testing.T, vars map[string]string) {
+	for key, value := range vars {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		key := key
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func writeModule(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(fn), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(fn, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// no credentials are looked up for the test registry
+	setEnv(t, map[string]string{
+		"NETRC":           filepath.Join(dir, "netrc"),
+		"HOF_AUTH_CONFIG": filepath.Join(dir, "auth.cue"),
+		"DOCKER_CONFIG":   dir,
+	})
+
+	oldBase := LocalCacheBaseDir
+	defer SetBaseDir(oldBase)
+	SetBaseDir(filepath.Join(dir, "export"))
+
+	modules := map[string]map[string]string{
+		"github.com/test/a": {"cue.mods": "module github.com/test/a\n", "schema/a.cue": "package schema\n"},
+		"github.com/test/b": {"cue.mods": "module github.com/test/b\n", "b.cue": "package b\n"},
+	}
+	var entries []Entry
+	for _, mod := range []string{"github.com/test/a", "github.com/test/b"} {
+		E := Entry{Lang: "cue", Module: mod, Version: "v1.0.0", Dir: ModDir("cue", mod, "v1.0.0")}
+		writeModule(t, E.Dir, modules[mod])
+		entries = append(entries, E)
+	}
+
+	reg := newRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/team/mods:v1"
+
+	err = Export(ref, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := reg.manifest(t, "/v2/team/mods/manifests/v1")
+	if M.ArtifactType != ociArtifactType || len(M.Layers) != 2 {
+		t.Fatalf("got manifest %+v", M)
+	}
+	for i, L := range M.Layers {
+		if L.Annotations[ociAnnotationModule] != entries[i].Module || L.Annotations[ociAnnotationVersion] != "v1.0.0" || L.Annotations[ociAnnotationLang] != "cue" {
+			t.Errorf("layer %d: got annotations %v", i, L.Annotations)
+		}
+	}
+	if reg.uploads != 3 {
+		t.Fatalf("expected the config and 2 layers uploaded, got %d", reg.uploads)
+	}
+
+	// the layers are reproducible, so nothing is uploaded again
+	err = Export(ref, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.uploads != 3 {
+		t.Fatalf("expected no new uploads, got %d", reg.uploads-3)
+	}
+
+	SetBaseDir(filepath.Join(dir, "import"))
+	err = Import(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for mod, files := range modules {
+		for name, content := range files {
+			data, err := ioutil.ReadFile(filepath.Join(ModDir("cue", mod, "v1.0.0"), name))
+			if err != nil || string(data) != content {
+				t.Errorf("%s: got %s %q, %v", mod, name, data, err)
+			}
+		}
+	}
+
+	// a layer whose content does not match its digest is not installed
+	SetBaseDir(filepath.Join(dir, "tampered"))
+	reg.Lock()
+	reg.blobs[M.Layers[0].Digest] = reg.blobs[M.Layers[1].Digest]
+	reg.Unlock()
+	err = Import(ref)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	if exists(ModDir("cue", "github.com/test/a", "v1.0.0")) {
+		t.Fatalf("expected the tampered module not to be installed")
+	}
+
+	// modules may not be written outside of the cache
+	M.Layers = M.Layers[1:]
+	M.Layers[0].Annotations[ociAnnotationModule] = "github.com/../../../evil"
+	reg.setManifest(t, "/v2/team/mods/manifests/v1", M)
+	err = Import(ref)
+	if err == nil || !strings.Contains(err.Error(), "Illegal module path") {
+		t.Fatalf("expected an illegal module path, got %v", err)
+	}
+
+	M.Layers[0].Annotations = nil
+	reg.setManifest(t, "/v2/team/mods/manifests/v1", M)
+	err = Import(ref)
+	if err == nil || !strings.Contains(err.Error(), "is missing its module annotations") {
+		t.Fatalf("expected missing annotations, got %v", err)
+	}
+
+	reg.setManifest(t, "/v2/team/mods/manifests/v1", &oci.Manifest{SchemaVersion: 2, MediaType: oci.MediaTypeManifest, Config: oci.Descriptor{MediaType: "application/vnd.oci.image.config.v1+json"}})
+	err = Import(ref)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("%s is not a module cache artifact", ref)) {
+		t.Fatalf("expected an image to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// offline forbids network access, so modules only come from the cache.
+// It is set from HOF_MOD_OFFLINE or with SetOffline.
+var offline bool
+
+func init() {
+	offline, _ = strconv.ParseBool(os.Getenv("HOF_MOD_OFFLINE"))
+}
+
+func SetOffline(on bool) {
+	offline = on
+}
+
+func Offline() bool {
+	return offline
+}
+
+// MissingError is returned by Fetch when offline and a module is not in the cache.
+type MissingError struct {
+	Module  string
+	Version string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("%s@%s is not in the module cache, and fetching is disabled while offline", e.Module, e.Version)
+}
+
+// IsMissing reports whether err is, or wraps, a MissingError.
+func IsMissing(err error) bool {
+	var merr *MissingError
+	return errors.As(err, &merr)
+}
+
+// Versions lists the versions of mod in the cache.
+func Versions(lang, mod string) ([]string, error) {
+	prefix := ModDir(lang, mod, "")
+
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var vers []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		vers = append(vers, strings.TrimPrefix(match, prefix))
+	}
+	return vers, nil
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFetchOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := LocalCacheBaseDir
+	SetBaseDir(dir)
+	defer SetBaseDir(oldBase)
+	SetOffline(true)
+	defer SetOffline(false)
+
+	for _, ver := range []string{"v0.1.0", "v0.2.0"} {
+		err = os.MkdirAll(ModDir("cue", "github.com/test/a", ver), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a cached module is used, a missing one is not fetched
+	err = Fetch("cue", "github.com/test/a", "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Fetch("cue", "github.com/test/b", "v0.1.0")
+	if !IsMissing(fmt.Errorf("wrapped\n%w", err)) {
+		t.Fatalf("expected a missing error, got %v", err)
+	}
+	if err.Error() != "github.com/test/b@v0.1.0 is not in the module cache, and fetching is disabled while offline" {
+		t.Fatalf("got %q", err)
+	}
+	if IsMissing(fmt.Errorf("other")) {
+		t.Fatal("expected other errors not to be missing")
+	}
+
+	vers, err := Versions("cue", "github.com/test/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(vers)
+	if !reflect.DeepEqual(vers, []string{"v0.1.0", "v0.2.0"}) {
+		t.Fatalf("got versions %q", vers)
+	}
+	if vers, _ := Versions("cue", "github.com/test/b"); len(vers) != 0 {
+		t.Fatalf("expected no versions, got %q", vers)
+	}
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress is told about the modules being fetched into the cache.
+// Download and Extract may be called many times between Start and Done,
+// total is -1 when the size of a download is not known.
+type Progress interface {
+	Start(mod, ver string)
+	Download(mod, ver string, n, total int64)
+	Extract(mod, ver, file string)
+	Done(mod, ver string, err error)
+}
+
+// progress reports fetches, by default a line per module on stderr.
+var progress Progress = NewLogProgress(os.Stderr, false)
+
+// SetProgress sets where fetches are reported, nil turns reporting off.
+func SetProgress(p Progress) {
+	if p == nil {
+		p = NoProgress{}
+	}
+	progress = p
+}
+
+// StderrProgress reports fetches on stderr, keeping a status line up to date
+// when it is a terminal, and otherwise or when verbose, with log lines.
+func StderrProgress(verbose bool) Progress {
+	if !verbose {
+		if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return NewBarProgress(os.Stderr)
+		}
+	}
+	return NewLogProgress(os.Stderr, verbose)
+}
+
+// fetchProgress reports a fetch of mod at ver done by f.
+func fetchProgress(mod, ver string, f func() error) error {
+	progress.Start(mod, ver)
+	err := f()
+	progress.Done(mod, ver, err)
+	return err
+}
+
+// modProgress is the module and version reported for a cache entry being written.
+func modProgress(remote, owner, repo, tag string) (string, string) {
+	return remote + "/" + owner + "/" + repo, tag
+}
+
+// NoProgress reports nothing.
+type NoProgress struct{}
+
+func (NoProgress) Start(mod, ver string)                    {}
+func (NoProgress) Download(mod, ver string, n, total int64) {}
+func (NoProgress) Extract(mod, ver, file string)            {}
+func (NoProgress) Done(mod, ver string, err error)          {}
+
+// progressWriter counts the bytes written through it as downloaded.
+type progressWriter struct {
+	w        io.Writer
+	mod, ver string
+	n        int64
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.n += int64(n)
+	progress.Download(pw.mod, pw.ver, pw.n, -1)
+	return n, err
+}
+
+// LogProgress writes a line when a fetch starts and ends,
+// and when Verbose, for every megabyte downloaded and file extracted.
+type LogProgress struct {
+	W       io.Writer
+	Verbose bool
+
+	mu   sync.Mutex
+	last map[string]int64
+}
+
+func NewLogProgress(w io.Writer, verbose bool) *LogProgress {
+	return &LogProgress{W: w, Verbose: verbose, last: map[string]int64{}}
+}
+
+func (lp *LogProgress) Start(mod, ver string) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	fmt.Fprintf(lp.W, "fetching %s@%s\n", mod, ver)
+}
+
+// Download logs every megabyte, rather than every write.
+func (lp *LogProgress) Download(mod, ver string, n, total int64) {
+	if !lp.Verbose {
+		return
+	}
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	key := mod + "@" + ver
+	if n-lp.last[key] < 1<<20 {
+		return
+	}
+	lp.last[key] = n
+	fmt.Fprintf(lp.W, "downloaded %s of %s@%s\n", formatBytes(n, total), mod, ver)
+}
+
+func (lp *LogProgress) Extract(mod, ver, file string) {
+	if !lp.Verbose {
+		return
+	}
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	fmt.Fprintf(lp.W, "extracted %s@%s %s\n", mod, ver, file)
+}
+
+func (lp *LogProgress) Done(mod, ver string, err error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	delete(lp.last, mod+"@"+ver)
+	if err != nil {
+		fmt.Fprintf(lp.W, "failed %s@%s: %v\n", mod, ver, err)
+		return
+	}
+	fmt.Fprintf(lp.W, "fetched %s@%s\n", mod, ver)
+}
+
+// BarProgress keeps a single status line per fetch up to date on a terminal,
+// with the bytes downloaded and files extracted so far.
+type BarProgress struct {
+	W io.Writer
+
+	mu    sync.Mutex
+	mod   string
+	bytes int64
+	total int64
+	files int
+	drawn time.Time
+}
+
+func NewBarProgress(w io.Writer) *BarProgress {
+	return &BarProgress{W: w}
+}
+
+func (bp *BarProgress) Start(mod, ver string) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.mod = mod + "@" + ver
+	bp.bytes, bp.total, bp.files = 0, -1, 0
+	bp.draw(true)
+}
+
+func (bp *BarProgress) Download(mod, ver string, n, total int64) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.bytes, bp.total = n, total
+	bp.draw(false)
+}
+
+func (bp *BarProgress) Extract(mod, ver, file string) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.files++
+	bp.draw(false)
+}
+
+func (bp *BarProgress) Done(mod, ver string, err error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.draw(true)
+	if err != nil {
+		fmt.Fprintf(bp.W, " failed\n")
+		return
+	}
+	fmt.Fprintf(bp.W, " done\n")
+}
+
+// draw rewrites the status line, at most ten times a second unless forced.
+func (bp *BarProgress) draw(force bool) {
+	now := time.Now()
+	if !force && now.Sub(bp.drawn) < 100*time.Millisecond {
+		return
+	}
+	bp.drawn = now
+
+	line := "fetching " + bp.mod
+	if bp.bytes > 0 {
+		line += "  " + formatBytes(bp.bytes, bp.total)
+	}
+	if bp.files > 0 {
+		line += fmt.Sprintf("  %d files", bp.files)
+	}
+	// clear the rest of the line, in case it was longer before
+	fmt.Fprintf(bp.W, "\r%s\x1b[K", line)
+}
+
+// formatBytes formats n, out of total when known, in the largest fitting unit.
+func formatBytes(n, total int64) string {
+	s := byteSize(n)
+	if total > 0 {
+		s += fmt.Sprintf(" / %s (%d%%)", byteSize(total), n*100/total)
+	}
+	return s
+}
+
+func byteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordProgress records the calls made to it
+type recordProgress struct {
+	calls []string
+}
+
+func (rp *recordProgress) Start(mod, ver string) {
+	rp.calls = append(rp.calls, "start "+mod+"@"+ver)
+}
+func (rp *recordProgress) Download(mod, ver string, n, total int64) {
+	rp.calls = append(rp.calls, fmt.Sprintf("download %s@%s %d %d", mod, ver, n, total))
+}
+func (rp *recordProgress) Extract(mod, ver, file string) {
+	rp.calls = append(rp.calls, "extract "+mod+"@"+ver+" "+file)
+}
+func (rp *recordProgress) Done(mod, ver string, err error) {
+	rp.calls = append(rp.calls, fmt.Sprintf("done %s@%s %v", mod, ver, err))
+}
+
+func TestFetchProgress(t *testing.T) {
+	defer SetProgress(progress)
+
+	rp := &recordProgress{}
+	SetProgress(rp)
+	err := fetchProgress("github.com/test/a", "v1.0.0", func() error {
+		pw := &progressWriter{w: ioutil.Discard, mod: "github.com/test/a", ver: "v1.0.0"}
+		pw.Write(make([]byte, 10))
+		pw.Write(make([]byte, 5))
+		return errors.New("no network")
+	})
+	if err == nil || err.Error() != "no network" {
+		t.Fatalf("expected the error of the fetch, got %v", err)
+	}
+	expect := []string{
+		"start github.com/test/a@v1.0.0",
+		"download github.com/test/a@v1.0.0 10 -1",
+		"download github.com/test/a@v1.0.0 15 -1",
+		"done github.com/test/a@v1.0.0 no network",
+	}
+	if !reflect.DeepEqual(rp.calls, expect) {
+		t.Fatalf("got calls:\n%s", strings.Join(rp.calls, "\n"))
+	}
+
+	SetProgress(nil)
+	if _, ok := progress.(NoProgress); !ok {
+		t.Fatalf("expected nil to turn reporting off, got %T", progress)
+	}
+}
+
+func TestLogProgress(t *testing.T) {
+	var buf bytes.Buffer
+	lp := NewLogProgress(&buf, false)
+	lp.Start("github.com/test/a", "v1.0.0")
+	lp.Download("github.com/test/a", "v1.0.0", 2<<20, -1)
+	lp.Extract("github.com/test/a", "v1.0.0", "cue.mods")
+	lp.Done("github.com/test/a", "v1.0.0", nil)
+	lp.Start("github.com/test/b", "v1.0.0")
+	lp.Done("github.com/test/b", "v1.0.0", errors.New("not found"))
+
+	expect := "fetching github.com/test/a@v1.0.0\nfetched github.com/test/a@v1.0.0\n" +
+		"fetching github.com/test/b@v1.0.0\nfailed github.com/test/b@v1.0.0: not found\n"
+	if buf.String() != expect {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+
+	// verbose logs every megabyte and file
+	buf.Reset()
+	lp = NewLogProgress(&buf, true)
+	lp.Start("github.com/test/a", "v1.0.0")
+	for _, n := range []int64{1 << 19, 1 << 20, 3 << 19, 2 << 20} {
+		lp.Download("github.com/test/a", "v1.0.0", n, 4<<20)
+	}
+	lp.Extract("github.com/test/a", "v1.0.0", "cue.mods")
+	lp.Done("github.com/test/a", "v1.0.0", nil)
+
+	expect = "fetching github.com/test/a@v1.0.0\n" +
+		"downloaded 1.0 MiB / 4.0 MiB (25%) of github.com/test/a@v1.0.0\n" +
+		"downloaded 2.0 MiB / 4.0 MiB (50%) of github.com/test/a@v1.0.0\n" +
+		"extracted github.com/test/a@v1.0.0 cue.mods\n" +
+		"fetched github.com/test/a@v1.0.0\n"
+	if buf.String() != expect {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+	if len(lp.last) != 0 {
+		t.Fatalf("expected the counts forgotten when done, got %v", lp.last)
+	}
+}
+
+func TestBarProgress(t *testing.T) {
+	var buf bytes.Buffer
+	bp := NewBarProgress(&buf)
+	bp.Start("github.com/test/a", "v1.0.0")
+	// redrawn at most ten times a second, so these are not drawn
+	bp.Download("github.com/test/a", "v1.0.0", 1024, 2048)
+	bp.Extract("github.com/test/a", "v1.0.0", "cue.mods")
+	bp.Extract("github.com/test/a", "v1.0.0", "a.cue")
+	bp.Done("github.com/test/a", "v1.0.0", nil)
+
+	expect := "\rfetching github.com/test/a@v1.0.0\x1b[K" +
+		"\rfetching github.com/test/a@v1.0.0  1.0 KiB / 2.0 KiB (50%)  2 files\x1b[K done\n"
+	if buf.String() != expect {
+		t.Fatalf("got %q", buf.String())
+	}
+
+	// a new fetch starts from nothing
+	buf.Reset()
+	bp.Start("github.com/test/b", "v1.0.0")
+	bp.Done("github.com/test/b", "v1.0.0", errors.New("not found"))
+	expect = "\rfetching github.com/test/b@v1.0.0\x1b[K\rfetching github.com/test/b@v1.0.0\x1b[K failed\n"
+	if buf.String() != expect {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n, total int64
+		expect   string
+	}{
+		{n: 0, total: -1, expect: "0 B"},
+		{n: 1023, total: -1, expect: "1023 B"},
+		{n: 1536, total: -1, expect: "1.5 KiB"},
+		{n: 5 << 20, total: 10 << 20, expect: "5.0 MiB / 10.0 MiB (50%)"},
+		{n: 3 << 30, total: 0, expect: "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n, tt.total); got != tt.expect {
+			t.Errorf("%d of %d: got %q, want %q", tt.n, tt.total, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-git/go-billy/v5/memfs"
+
+	"github.com/hofstadter-io/hof/lib/mod/proxy"
+)
+
+// fetchProxy downloads the module zip from a module proxy,
+// using the latest version for v0.0.0
+func fetchProxy(lang, mod, ver, proxyURL string) error {
+	remote, owner, repo, subdir := SplitMod(mod)
+
+	client, err := proxy.NewClient(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	pver := ver
+	if ver == "v0.0.0" {
+		info, err := client.Latest(mod)
+		if err != nil {
+			return fmt.Errorf("While fetching latest from %s\n%w\n", proxyURL, err)
+		}
+		pver = info.Version
+	}
+
+	zReader, err := client.Zip(mod, pver)
+	if err != nil {
+		return fmt.Errorf("While fetching from %s\n%w\n", proxyURL, err)
+	}
+
+	FS := memfs.New()
+	err = proxy.LoadZip(zReader, FS, mod, pver)
+	if err != nil {
+		return fmt.Errorf("While reading module zipfile\n%w\n", err)
+	}
+
+	// the proxy zip holds only the module, not the whole repository
+	err = Write(lang, remote, owner, path.Join(repo, subdir), ver, FS)
+	if err != nil {
+		return fmt.Errorf("While writing to cache\n%w\n", err)
+	}
+
+	return nil
+}
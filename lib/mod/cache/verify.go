@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// Verify checks a cache entry against the hash recorded when it was written.
+func Verify(E Entry) error {
+	data, err := ioutil.ReadFile(E.Dir + ".sum")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no checksum recorded, the entry may be left from an interrupted fetch")
+		}
+		return err
+	}
+
+	hash, err := yagu.BillyCalcHash(osfs.New(E.Dir))
+	if err != nil {
+		return err
+	}
+
+	if want := strings.TrimSpace(string(data)); hash != want {
+		return fmt.Errorf("checksum mismatch, have %s, want %s", hash, want)
+	}
+
+	return nil
+}
+
+// Repair removes a damaged cache entry and fetches it again.
+func Repair(E Entry) error {
+	if offline {
+		return fmt.Errorf("Cannot refetch %s@%s while offline", E.Module, E.Version)
+	}
+
+	unlock, err := lock(E.Dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = remove(E)
+	if err != nil {
+		return err
+	}
+
+	return fetch(E.Lang, E.Module, E.Version)
+}
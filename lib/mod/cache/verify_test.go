@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+func TestVerifyRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	setEnv(t, map[string]string{
+		"NETRC":           filepath.Join(dir, "netrc"),
+		"HOF_AUTH_CONFIG": filepath.Join(dir, "auth.cue"),
+		"HOF_MOD_PROXY":   "direct",
+	})
+	oldBase := LocalCacheBaseDir
+	defer SetBaseDir(oldBase)
+	SetBaseDir(filepath.Join(dir, "cache"))
+
+	g := newGitServer(t)
+	srv := httptest.NewTLSServer(g)
+	defer srv.Close()
+
+	yagu.InstallGitTransport()
+	client.InstallProtocol("https", githttp.NewClient(srv.Client()))
+	defer client.InstallProtocol("https", githttp.NewClient(yagu.HTTPClient()))
+
+	mod := strings.TrimPrefix(srv.URL, "https://") + "/test/repo"
+	g.commit(t, map[string]string{"cue.mods": "module " + mod + "\n", "a.cue": "package a\n"})
+
+	err = Fetch("cue", mod, "@master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	E := Entry{Lang: "cue", Module: mod, Version: "@master", Dir: ModDir("cue", mod, "@master")}
+	if err := Verify(E); err != nil {
+		t.Fatalf("expected a fetched entry to verify, got %v", err)
+	}
+
+	// a changed file no longer matches the hash recorded on fetch
+	fn := filepath.Join(E.Dir, "a.cue")
+	err = ioutil.WriteFile(fn, []byte("package a\n\nx: 1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Verify(E)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+
+	// repair fetches the entry again, while holding its lock
+	err = Repair(E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(E); err != nil {
+		t.Fatalf("expected the repaired entry to verify, got %v", err)
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package a\n" {
+		t.Fatalf("expected the original content, got %q", data)
+	}
+	if _, err := os.Stat(E.Dir + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock released, got %v", err)
+	}
+
+	// entries without a recorded hash are reported, as are repairs while offline
+	err = os.Remove(E.Dir + ".sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Verify(E)
+	if err == nil || !strings.Contains(err.Error(), "no checksum recorded") {
+		t.Fatalf("expected a missing checksum, got %v", err)
+	}
+	SetOffline(true)
+	defer SetOffline(false)
+	err = Repair(E)
+	if err == nil || !strings.Contains(err.Error(), "while offline") {
+		t.Fatalf("expected repair to fail offline, got %v", err)
+	}
+}
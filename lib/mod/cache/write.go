@@ -1,10 +1,17 @@
 package cache
 
 import (
+	"archive/zip"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
 
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
@@ -21,12 +28,228 @@ func Outdir(lang, remote, owner, repo, tag string) string {
 	return outdir
 }
 
+// Write saves a module from FS, which is written to a temp dir
+// beside the cache entry and then moved into place.
+// For modules nested in a repository, repo includes the subdirectory,
+// and FS holds only the module.
 func Write(lang, remote, owner, repo, tag string, FS billy.Filesystem) error {
-	fmt.Printf("Saving %s mod %s/%s/%s@%s\n", lang, remote, owner, repo, tag)
+	mod, ver := modProgress(remote, owner, repo, tag)
 	outdir := Outdir(lang, remote, owner, repo, tag)
-	err := yagu.Mkdir(outdir)
+	parent := filepath.Dir(outdir)
+	err := yagu.Mkdir(parent)
 	if err != nil {
 		return err
 	}
-	return yagu.BillyWriteDirToOS(outdir, "/", FS)
+
+	tmpdir, err := ioutil.TempDir(parent, ".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	files, err := yagu.BillyFilenames("/", FS)
+	if err != nil {
+		return err
+	}
+	filter := filters[lang]
+	for _, file := range files {
+		if filter != nil {
+			info, err := FS.Stat(file)
+			if err != nil {
+				return err
+			}
+			ok, err := filter.Match(strings.TrimPrefix(file, "/"), info.Size())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		err = yagu.BillyWriteFileToOS(tmpdir, file, FS)
+		if err != nil {
+			return err
+		}
+		progress.Extract(mod, ver, strings.TrimPrefix(file, "/"))
+	}
+
+	return install(tmpdir, outdir)
+}
+
+// WriteZip saves a module from a zip archive of its repository without loading it into memory.
+// The archive is streamed by download into a temp file, extracted with its
+// top-level directory trimmed into a temp dir beside the cache entry,
+// and renamed into place, so an interrupted fetch never leaves a partial module.
+// Only the subdir of the repository is extracted for nested modules.
+func WriteZip(lang, remote, owner, repo, subdir, tag string, download func(w io.Writer) error) error {
+	repo = path.Join(repo, subdir)
+	mod, ver := modProgress(remote, owner, repo, tag)
+	outdir := Outdir(lang, remote, owner, repo, tag)
+	parent := filepath.Dir(outdir)
+	err := yagu.Mkdir(parent)
+	if err != nil {
+		return err
+	}
+
+	zf, err := ioutil.TempFile(parent, ".download-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(zf.Name())
+
+	err = download(&progressWriter{w: zf, mod: mod, ver: ver})
+	if cerr := zf.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("While downloading zipfile\n%w\n", err)
+	}
+
+	tmpdir, err := ioutil.TempDir(parent, ".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	err = extractZip(zf.Name(), tmpdir, subdir, filters[lang], func(name string) {
+		progress.Extract(mod, ver, name)
+	})
+	if err != nil {
+		return fmt.Errorf("While extracting zipfile\n%w\n", err)
+	}
+
+	return install(tmpdir, outdir)
+}
+
+// install moves a fully written module into the cache.
+// The hash of its content is recorded beside it first,
+// so that later reads can tell an intact entry from a damaged one.
+func install(tmpdir, outdir string) error {
+	// TempDir creates the directory as 0700
+	err := os.Chmod(tmpdir, 0755)
+	if err != nil {
+		return err
+	}
+
+	hash, err := yagu.BillyCalcHash(osfs.New(tmpdir))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(outdir+".sum", []byte(hash+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpdir, outdir)
+	if err != nil {
+		// another process may have finished the same fetch first
+		if _, serr := os.Stat(outdir); serr == nil {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// extractZip writes the files of an archive under dir,
+// dropping the leading directory that repository archives wrap their content in.
+// When subdir is set, only the files under it are written, relative to it.
+// Files are selected by their names relative to subdir with filter, which may be nil.
+// extracted is called with the name of each file once written.
+func extractZip(zipfile, dir, subdir string, filter *yagu.ZipFilter, extracted func(name string)) error {
+	zr, err := zip.OpenReader(zipfile)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	found := subdir == ""
+	for _, f := range zr.File {
+		i := strings.Index(f.Name, "/")
+		if i < 0 {
+			continue
+		}
+		name := f.Name[i+1:]
+		if subdir != "" {
+			if !strings.HasPrefix(name, subdir+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(name, subdir+"/")
+			found = true
+		}
+		if name == "" {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("Illegal file path in zipfile: %q", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			ok, err := filter.MatchDir(strings.TrimSuffix(name, "/"))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			err = os.MkdirAll(target, 0755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		ok, err := filter.Match(name, int64(f.UncompressedSize64))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = extractFile(f, target)
+		if err != nil {
+			return err
+		}
+		extracted(name)
+	}
+
+	if !found {
+		return fmt.Errorf("Did not find directory %q in zipfile", subdir)
+	}
+
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	// symlinks are written as plain files holding their target
+	mode := f.Mode().Perm()
+	if mode == 0 || f.Mode()&os.ModeSymlink != 0 {
+		mode = 0644
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
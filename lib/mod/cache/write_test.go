@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// makeZip returns an archive of files wrapped in a top-level directory, as repository archives are
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create("repo-abc123/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// listFiles returns the slash separated names of the files under dir
+func listFiles(t *testing.T, dir string) []string {
+	var files []string
+	err := filepath.Walk(dir, func(fn string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fn)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestWriteZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := LocalCacheBaseDir
+	SetBaseDir(dir)
+	defer SetBaseDir(oldBase)
+
+	data := makeZip(t, map[string]string{
+		"cue.mod/module.cue": `module: "github.com/test/a"`,
+		"a.cue":              "package a\n",
+		"b/b.cue":            "package b\n",
+	})
+
+	// the archive is written in small pieces, as it is downloaded
+	chunks := 0
+	err = WriteZip("cue", "github.com", "test", "a", "", "v0.1.0", func(w io.Writer) error {
+		r := bytes.NewReader(data)
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunks++
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					return werr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks < 2 {
+		t.Fatalf("expected the archive in pieces, got %d", chunks)
+	}
+
+	outdir := Outdir("cue", "github.com", "test", "a", "v0.1.0")
+	expect := "a.cue b/b.cue cue.mod/module.cue"
+	if got := strings.Join(listFiles(t, outdir), " "); got != expect {
+		t.Fatalf("got files %q, want %q", got, expect)
+	}
+
+	// the hash of the content is recorded beside it
+	sum, err := ioutil.ReadFile(outdir + ".sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := yagu.BillyCalcHash(osfs.New(outdir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sum) != hash+"\n" {
+		t.Fatalf("got sum %q, want %q", sum, hash)
+	}
+
+	// a failed download leaves neither the module nor its temp files
+	err = WriteZip("cue", "github.com", "test", "a", "", "v0.2.0", func(w io.Writer) error {
+		w.Write(data[:len(data)/2])
+		return fmt.Errorf("connection reset")
+	})
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("expected the download error, got %v", err)
+	}
+	// a truncated archive fails to extract
+	err = WriteZip("cue", "github.com", "test", "a", "", "v0.3.0", func(w io.Writer) error {
+		_, err := w.Write(data[:len(data)/2])
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "While extracting zipfile") {
+		t.Fatalf("expected the extract error, got %v", err)
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Dir(outdir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, " "); got != "a@v0.1.0 a@v0.1.0.sum" {
+		t.Fatalf("expected only the written module, got %q", got)
+	}
+}
+
+func TestExtractZipSubdir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	zipfile := filepath.Join(dir, "repo.zip")
+	err = ioutil.WriteFile(zipfile, makeZip(t, map[string]string{
+		"root.cue":               "package root\n",
+		"sub/a.cue":              "package a\n",
+		"sub/cue.mod/module.cue": `module: "github.com/test/repo/sub"`,
+		"sub/dir/b.cue":          "package b\n",
+		"subway/c.cue":           "package c\n",
+	}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdir string
+		expect string
+		err    string
+	}{
+		{subdir: "", expect: "root.cue sub/a.cue sub/cue.mod/module.cue sub/dir/b.cue subway/c.cue"},
+		// a sibling sharing the prefix is not part of the module
+		{subdir: "sub", expect: "a.cue cue.mod/module.cue dir/b.cue"},
+		{subdir: "sub/dir", expect: "b.cue"},
+		{subdir: "missing", err: `Did not find directory "missing" in zipfile`},
+	}
+	for i, tt := range tests {
+		out := filepath.Join(dir, fmt.Sprint(i))
+		var names []string
+		err := extractZip(zipfile, out, tt.subdir, nil, func(name string) {
+			names = append(names, name)
+		})
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%q: expected %q, got %v", tt.subdir, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.subdir, err)
+			continue
+		}
+		if got := strings.Join(listFiles(t, out), " "); got != tt.expect {
+			t.Errorf("%q: got files %q, want %q", tt.subdir, got, tt.expect)
+		}
+		// progress is reported relative to the module
+		sort.Strings(names)
+		if got := strings.Join(names, " "); got != tt.expect {
+			t.Errorf("%q: got extracted %q, want %q", tt.subdir, got, tt.expect)
+		}
+	}
+}
+
+func TestWriteZipSubdir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := LocalCacheBaseDir
+	SetBaseDir(dir)
+	defer SetBaseDir(oldBase)
+
+	data := makeZip(t, map[string]string{
+		"root.cue":      "package root\n",
+		"sub/a.cue":     "package a\n",
+		"sub/dir/b.cue": "package b\n",
+	})
+	download := func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+
+	// nested modules are cached under their full path
+	err = WriteZip("cue", "github.com", "test", "repo", "sub", "v0.1.0", download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdir := ModDir("cue", "github.com/test/repo/sub", "v0.1.0")
+	if outdir != Outdir("cue", "github.com", "test", "repo/sub", "v0.1.0") {
+		t.Fatalf("unexpected module dir %q", outdir)
+	}
+	if got := strings.Join(listFiles(t, outdir), " "); got != "a.cue dir/b.cue" {
+		t.Fatalf("got files %q", got)
+	}
+	if _, err := os.Stat(outdir + ".sum"); err != nil {
+		t.Fatalf("expected the sum beside the module, got %v", err)
+	}
+
+	// a missing subdir writes nothing
+	err = WriteZip("cue", "github.com", "test", "repo", "nope", "v0.1.0", download)
+	if err == nil || !strings.Contains(err.Error(), `Did not find directory "nope"`) {
+		t.Fatalf("expected a missing subdir to fail, got %v", err)
+	}
+	if _, err := os.Stat(Outdir("cue", "github.com", "test", "repo/nope", "v0.1.0")); !os.IsNotExist(err) {
+		t.Fatalf("expected no module for a missing subdir, got %v", err)
+	}
+}
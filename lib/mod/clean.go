@@ -0,0 +1,256 @@
+package mod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+)
+
+// Clean reports the size of the module cache and removes entries from it.
+// Leftovers from fetches interrupted over an hour ago are always removed.
+// With all, the whole cache is removed. Otherwise entries are removed when
+// they have not been used for olderThan, when they match module,
+// which is a module path with an optional @version, or with unused,
+// when the module in the current directory does not depend on them.
+func Clean(all bool, olderThan, module string, unused bool) error {
+	var age time.Duration
+	if olderThan != "" {
+		var err error
+		age, err = cache.ParseAge(olderThan)
+		if err != nil {
+			return err
+		}
+	}
+
+	var used map[string]map[sumfile.Version]bool
+	if unused {
+		var err error
+		used, err = usedModules()
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return fmt.Errorf("While reading the module cache\n%w\n", err)
+	}
+
+	var total, freed int64
+	var count, removed int
+	for _, E := range entries {
+		total += E.Size
+		if E.Module != "" {
+			count++
+		}
+
+		if !all && !cleanable(E, age, module, used) {
+			continue
+		}
+
+		err = cache.Remove(E)
+		if err != nil {
+			return fmt.Errorf("While removing %s\n%w\n", E.Dir, err)
+		}
+		if E.Module != "" {
+			fmt.Printf("removed %s %s@%s (%s)\n", E.Lang, E.Module, E.Version, cache.FormatSize(E.Size))
+			removed++
+		}
+		freed += E.Size
+	}
+
+	fmt.Printf("Module cache %s\n", cache.LocalCacheBaseDir)
+	fmt.Printf("  %d modules, %s\n", count, cache.FormatSize(total))
+	fmt.Printf("  removed %d modules, freed %s\n", removed, cache.FormatSize(freed))
+
+	return nil
+}
+
+// cleanable reports whether a cache entry should be removed.
+// used holds the module versions depended on by language,
+// the entries of languages missing from it are kept.
+func cleanable(E cache.Entry, age time.Duration, module string, used map[string]map[sumfile.Version]bool) bool {
+	// an interrupted fetch, the hour leaves running fetches alone
+	if E.Module == "" {
+		return time.Since(E.LastUsed) > time.Hour
+	}
+
+	if age > 0 && time.Since(E.LastUsed) > age {
+		return true
+	}
+
+	if vers, ok := used[E.Lang]; ok && !vers[sumfile.Version{Path: E.Module, Version: E.Version}] {
+		return true
+	}
+
+	return module != "" && matchModule(E, module)
+}
+
+// usedModules returns the module versions recorded in the sum files
+// of the module in the current directory, by language.
+func usedModules() (map[string]map[sumfile.Version]bool, error) {
+	langs := DiscoverLangs()
+	if len(langs) == 0 {
+		return nil, fmt.Errorf("No module in the current directory to find the used modules of")
+	}
+
+	used := map[string]map[sumfile.Version]bool{}
+	for _, lang := range langs {
+		mdr := LangModderMap[lang]
+		used[lang] = map[sumfile.Version]bool{}
+
+		// remote replacements are fetched in place of the modules they replace
+		data, err := ioutil.ReadFile(mdr.ModFile)
+		if err != nil {
+			return nil, err
+		}
+		f, err := modfile.Parse(mdr.ModFile, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, rep := range f.Replace {
+			if rep.New.Version != "" {
+				used[lang][sumfile.Version{Path: rep.New.Path, Version: rep.New.Version}] = true
+			}
+		}
+
+		// a module without a sum file depends on nothing in the cache
+		data, err = ioutil.ReadFile(mdr.SumFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		sum, err := sumfile.ParseSum(data, mdr.SumFile)
+		if err != nil {
+			return nil, err
+		}
+		for ver := range sum.Mods {
+			used[lang][ver] = true
+		}
+	}
+	return used, nil
+}
+
+// matchModule reports whether a cache entry is the module path, or path@version.
+func matchModule(E cache.Entry, module string) bool {
+	if strings.Contains(module, "@") {
+		return module == E.Module+"@"+E.Version
+	}
+	return module == E.Module
+}
+
+// CachedModules returns the sorted module paths in the cache, and each path@version with versions,
+// for shell completion. A cache which can not be read has none.
+func CachedModules(versions bool) []string {
+	entries, _ := cache.Entries()
+	seen := map[string]bool{}
+	var mods []string
+	for _, E := range entries {
+		if E.Module == "" {
+			continue
+		}
+		keys := []string{E.Module}
+		if versions {
+			keys = append(keys, E.Module+"@"+E.Version)
+		}
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				mods = append(mods, k)
+			}
+		}
+	}
+	sort.Strings(mods)
+	return mods
+}
+
+// CacheVerify checks every module in the cache against the hash recorded when it
+// was fetched. With repair, damaged modules are removed and fetched again.
+func CacheVerify(repair bool) error {
+	entries, err := cache.Entries()
+	if err != nil {
+		return fmt.Errorf("While reading the module cache\n%w\n", err)
+	}
+
+	bad := 0
+	for _, E := range entries {
+		if E.Module == "" {
+			continue
+		}
+
+		err = cache.Verify(E)
+		if err == nil {
+			continue
+		}
+		fmt.Printf("%s %s@%s: %v\n", E.Lang, E.Module, E.Version, err)
+
+		if !repair {
+			bad++
+			continue
+		}
+
+		err = cache.Repair(E)
+		if err != nil {
+			fmt.Printf("  repair failed: %v\n", err)
+			bad++
+			continue
+		}
+		fmt.Println("  repaired")
+	}
+
+	if bad > 0 {
+		return fmt.Errorf("%d modules in the cache failed verification", bad)
+	}
+
+	fmt.Println("all modules verified")
+	return nil
+}
+
+// CacheExport pushes the modules in the cache to an OCI registry as a single artifact,
+// ref is host/repo[:tag]. When module is set, only its versions are pushed,
+// or a single one with path@version.
+func CacheExport(ref, module string) error {
+	entries, err := cache.Entries()
+	if err != nil {
+		return fmt.Errorf("While reading the module cache\n%w\n", err)
+	}
+
+	var export []cache.Entry
+	for _, E := range entries {
+		if E.Module == "" {
+			continue
+		}
+		if module != "" && !matchModule(E, module) {
+			continue
+		}
+		export = append(export, E)
+	}
+	if len(export) == 0 {
+		return fmt.Errorf("No modules in the cache to export")
+	}
+
+	err = cache.Export(ref, export)
+	if err != nil {
+		return fmt.Errorf("While exporting the module cache to %s\n%w\n", ref, err)
+	}
+	return nil
+}
+
+// CacheImport fetches the modules in an artifact made by CacheExport into the cache,
+// so later commands find them there instead of fetching from their repositories.
+func CacheImport(ref string) error {
+	err := cache.Import(ref)
+	if err != nil {
+		return fmt.Errorf("While importing the module cache from %s\n%w\n", ref, err)
+	}
+	return nil
+}
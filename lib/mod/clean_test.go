@@ -0,0 +1,164 @@
+package mod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/langs"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+)
+
+func TestCachedModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-clean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := cache.LocalCacheBaseDir
+	defer func() { cache.LocalCacheBaseDir = old }()
+	cache.LocalCacheBaseDir = dir
+
+	// an empty cache has no modules
+	if got := CachedModules(true); got != nil {
+		t.Fatalf("expected no modules, got %q", got)
+	}
+
+	for _, d := range []string{
+		"cue/github.com/test/b@v0.1.0",
+		"cue/github.com/test/a@v1.0.0",
+		"cue/github.com/test/a@v1.1.0",
+		"go/github.com/test/a@v1.0.0",
+		"cue/github.com/test/.download-123",
+	} {
+		err = os.MkdirAll(filepath.Join(dir, "mod", filepath.FromSlash(d)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect := []string{"github.com/test/a", "github.com/test/b"}
+	if got := CachedModules(false); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got %q, want %q", got, expect)
+	}
+
+	expect = []string{
+		"github.com/test/a",
+		"github.com/test/a@v1.0.0",
+		"github.com/test/a@v1.1.0",
+		"github.com/test/b",
+		"github.com/test/b@v0.1.0",
+	}
+	if got := CachedModules(true); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got %q, want %q", got, expect)
+	}
+}
+
+func TestCleanable(t *testing.T) {
+	now := time.Now()
+	used := map[string]map[sumfile.Version]bool{
+		"cue": {{Path: "github.com/test/a", Version: "v1.0.0"}: true},
+	}
+	tests := []struct {
+		name   string
+		entry  cache.Entry
+		age    time.Duration
+		module string
+		used   map[string]map[sumfile.Version]bool
+		expect bool
+	}{
+		{name: "recent leftover", entry: cache.Entry{LastUsed: now.Add(-time.Minute)}},
+		{name: "old leftover", entry: cache.Entry{LastUsed: now.Add(-2 * time.Hour)}, expect: true},
+		{name: "kept", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now.Add(-48 * time.Hour)}},
+		{name: "unused", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now.Add(-48 * time.Hour)}, age: 24 * time.Hour, expect: true},
+		{name: "used", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now}, age: 24 * time.Hour},
+		{name: "path", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now}, module: "github.com/test/a", expect: true},
+		{name: "version", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now}, module: "github.com/test/a@v1.0.0", expect: true},
+		{name: "other version", entry: cache.Entry{Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now}, module: "github.com/test/a@v1.1.0"},
+		{name: "prefix", entry: cache.Entry{Module: "github.com/test/ab", Version: "v1.0.0", LastUsed: now}, module: "github.com/test/a"},
+		{name: "depended on", entry: cache.Entry{Lang: "cue", Module: "github.com/test/a", Version: "v1.0.0", LastUsed: now}, used: used},
+		{name: "not depended on", entry: cache.Entry{Lang: "cue", Module: "github.com/test/a", Version: "v1.1.0", LastUsed: now}, used: used, expect: true},
+		{name: "other language", entry: cache.Entry{Lang: "go", Module: "github.com/test/a", Version: "v1.1.0", LastUsed: now}, used: used},
+	}
+	for _, tt := range tests {
+		if got := cleanable(tt.entry, tt.age, tt.module, tt.used); got != tt.expect {
+			t.Errorf("%s: expected cleanable to be %v", tt.name, tt.expect)
+		}
+	}
+}
+
+func TestUsedModules(t *testing.T) {
+	err := langs.LoadBuiltins()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "hof-mod-used")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = usedModules()
+	if err == nil {
+		t.Fatal("expected an error without a module")
+	}
+
+	files := map[string]string{
+		"cue.mods": `module github.com/test/root
+
+cue v0.2.2
+
+require (
+	github.com/test/a v1.0.0
+	github.com/test/b v0.1.0
+)
+
+replace github.com/test/b => github.com/fork/b v0.1.1
+`,
+		"cue.sums": `github.com/test/a v1.0.0 h1:aaaa=
+github.com/test/a v1.0.0/cue.mods h1:bbbb=
+github.com/fork/b v0.1.1 h1:cccc=
+`,
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	used, err := usedModules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ver := range []sumfile.Version{
+		{Path: "github.com/test/a", Version: "v1.0.0"},
+		{Path: "github.com/fork/b", Version: "v0.1.1"},
+	} {
+		if !used["cue"][ver] {
+			t.Errorf("expected %v to be used, got %v", ver, used)
+		}
+	}
+	if used["cue"][sumfile.Version{Path: "github.com/test/b", Version: "v0.1.0"}] {
+		t.Errorf("expected the replaced module not to be cached")
+	}
+	if _, ok := used["go"]; ok {
+		t.Errorf("expected only the languages of the module, got %v", used)
+	}
+}
@@ -17,7 +17,7 @@ import (
 func Hack(lang string, args []string) error {
 	fmt.Println("Hack", args)
 
-	client, err := github.NewClient()
+	client, err := github.NewClient("github.com")
 	if err != nil {
 		return err
 	}
@@ -49,7 +49,7 @@ func Hack(lang string, args []string) error {
 		}
 		FS := memfs.New()
 
-		err = yagu.BillyLoadFromZip(zReader, FS, true)
+		err = yagu.BillyLoadFromZip(zReader, FS, true, nil)
 		if err != nil {
 			return fmt.Errorf("While reading zipfile\n%w\n", err)
 		}
@@ -2,27 +2,28 @@ package mod
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
-	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 
-	"cuelang.org/go/cue"
-
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/mod/cache"
 	"github.com/hofstadter-io/hof/lib/mod/langs"
-	"github.com/hofstadter-io/hof/lib/mod/modder"
 )
 
 // FROM the USER's HOME dir
 const GLOBAL_MVS_CONFIG = "hof/.mvsconfig.cue"
 const LOCAL_MVS_CONFIG = ".mvsconfig.cue"
 
+// Directories of language plugins, one or more languages per CUE file
+const GLOBAL_LANGS_DIR = "hof/langs"
+const LOCAL_LANGS_DIR = ".hof/langs"
+
 var (
-	// Default known modderr
-	LangModderMap = langs.DefaultModders
+	// Known modders, builtin and from config
+	LangModderMap = langs.LoadedModders
 )
 
 const knownLangMessage = `
@@ -56,14 +57,7 @@ func DiscoverLangs() (langs []string) {
 }
 
 func KnownLangs() string {
-	langs := []string{}
-
-	for lang, _ := range LangModderMap {
-		langs = append(langs, lang)
-	}
-
-	sort.Strings(langs)
-	langStr := strings.Join(langs, "\n  ")
+	langStr := strings.Join(langs.Names(), "\n  ")
 
 	msg := fmt.Sprintf(knownLangMessage, langStr)
 
@@ -75,22 +69,29 @@ Unknown language %q.
 
 Please check the following files for definitions
   %s  (in the current directory)
-	$HOME/%s
+  %s/*.cue
+  $HOME/%s
+  $HOME/%s/*.cue
 
 To see a list of known languages from the current directory:
 
   mvs info
 `
 
+func unknownLang(lang string) error {
+	err := fmt.Errorf(unknownLangMessage, lang, LOCAL_MVS_CONFIG, LOCAL_LANGS_DIR, GLOBAL_MVS_CONFIG, GLOBAL_LANGS_DIR)
+	return errs.WithCode(errs.CodeUnknownLang, "", err)
+}
+
 func LangInfo(lang string) (string, error) {
 
 	if lang == "" {
 		return KnownLangs(), nil
 	}
 
-	modder, ok := LangModderMap[lang]
+	modder, ok := langs.Lookup(lang)
 	if !ok {
-		return "", fmt.Errorf(unknownLangMessage, lang, LOCAL_MVS_CONFIG, GLOBAL_MVS_CONFIG)
+		return "", unknownLang(lang)
 	}
 
 	// fmt.Printf("=====\n%#+v\n=====\n", modder)
@@ -104,109 +105,42 @@ func LangInfo(lang string) (string, error) {
 	return string(bytes), nil
 }
 
+// InitLangs loads the builtin languages, then the global and local
+// language config and plugin dirs, each overriding the ones before.
 func InitLangs() {
-	var err error
-
-	rt := cue.Runtime{}
-	cueSpec, err := rt.Compile("spec.cue", langs.ModderSpec)
-	if err != nil {
-		panic(err)
-	}
-	err = cueSpec.Value().Validate()
+	err := langs.LoadBuiltins()
 	if err != nil {
 		panic(err)
 	}
-	for lang, cueString := range langs.DefaultModdersCue {
-		var mdrMap map[string]*modder.Modder
 
-		cueLang, err := rt.Compile(lang, cueString)
-		if err != nil {
-			panic(err)
-		}
-		cueLangMerged := cue.Merge(cueSpec, cueLang)
-		err = cueLangMerged.Value().Validate()
-		if err != nil {
-			panic(err)
-		}
-		err = cueLang.Value().Decode(&mdrMap)
-		if err != nil {
-			panic(err)
-		}
-		_, ok := mdrMap[lang]
-		if !ok || len(mdrMap) != 1 {
-			panic(fmt.Errorf("invalid builtin language default %s", lang))
-		}
-		mdrMap[lang].CueInstance = cueLangMerged
-		langs.DefaultModders[lang] = mdrMap[lang]
-	}
-
-	homedir, err := os.UserConfigDir()
+	configdir, err := os.UserConfigDir()
 	if err != nil {
 		fmt.Println(err)
 	}
 
 	// Global Language Modder Config
-	err = initFromFile(path.Join(homedir, GLOBAL_MVS_CONFIG))
+	err = langs.LoadFile(path.Join(configdir, GLOBAL_MVS_CONFIG))
 	if err != nil {
 		fmt.Println(err)
 	}
-
-	// Local Language Modder Config
-	err = initFromFile(LOCAL_MVS_CONFIG)
+	err = langs.LoadDir(path.Join(configdir, GLOBAL_LANGS_DIR))
 	if err != nil {
 		fmt.Println(err)
 	}
-}
-
-func initFromFile(filepath string) error {
-	// Reand an MVS config file (cue format)
-	bytes, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		if _, ok := err.(*os.PathError); !ok && err.Error() != "file does not exist" && err.Error() != "no such file or directory" {
-			return err
-		}
-		// The user has not setup a global $HOME/.mvs/mvsconfig file
-		return nil
-	}
 
-	var mdrMap map[string]*modder.Modder
-
-	// Compile the config into cue
-	rt := cue.Runtime{}
-	i, err := rt.Compile(filepath, string(bytes))
-	if err != nil {
-		return err
-	}
-	err = i.Value().Decode(&mdrMap)
+	// Local Language Modder Config
+	err = langs.LoadFile(LOCAL_MVS_CONFIG)
 	if err != nil {
-		return err
-	}
-
-	iMerged := i
-	// For each language in the local config file
-	for lang, _ := range mdrMap {
-		// TODO, do we want to merge every language in the config with the spec?
-
-		// If we find this is a language override,
-		// merge with the spec and builtin defaults (which were previously merged)
-		// TODO, maybe check for some value in the config which controls merging with defaults?
-		_, ok := langs.DefaultModders[lang]
-		if ok {
-			langSpec := langs.DefaultModders[lang].CueInstance
-			iMerged = cue.Merge(i, langSpec)
-		} else {
-			fmt.Printf("trying to customize unknown language %s\n", lang)
-		}
+		fmt.Println(err)
 	}
-
-	err = iMerged.Value().Decode(&mdrMap)
+	err = langs.LoadDir(LOCAL_LANGS_DIR)
 	if err != nil {
-		return err
+		fmt.Println(err)
 	}
 
-	for lang, mdr := range mdrMap {
-		LangModderMap[lang] = mdr
+	// the files each language keeps in the module cache
+	for _, lang := range langs.Names() {
+		mdr, _ := langs.Lookup(lang)
+		cache.SetFilter(lang, mdr.FetchFilter())
 	}
-
-	return nil
 }
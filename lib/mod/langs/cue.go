@@ -20,5 +20,15 @@ cue: {
 		"/.git/**",
 		"**/cue.mod/pkg/**",
 	]
+	IntrospectIncludeGlobs: [...string] | *[
+		"**/*.cue",
+	]
+	IntrospectExcludeGlobs: [...string] | *[
+		"cue.mod/**",
+	]
+	IntrospectExtractRegex: [...string] | *[
+		// single imports, and the lines of import blocks
+		#"(?m)^\s*(?:import\s+)?(?:[\w#]+\s+)?"([\w.~/-]+)"\s*$"#,
+	]
 }
 `
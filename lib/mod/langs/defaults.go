@@ -4,7 +4,10 @@ import (
 	"github.com/hofstadter-io/hof/lib/mod/modder"
 )
 
+// DefaultModders are the builtin languages, with only their defaults
 var DefaultModders = make(map[string]*modder.Modder)
+
+// LoadedModders are all registered languages, including those from CUE config
 var LoadedModders = make(map[string]*modder.Modder)
 
 var DefaultModdersCue = map[string]string{
@@ -0,0 +1,170 @@
+package langs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/mod/modder"
+)
+
+// The registry of languages hof mod can manage.
+//
+// Each language is a plugin, a CUE value under the language name which
+// satisfies ModderSpec. It declares the module and sum files, the layout of
+// the vendor directory, and the globs and regexps used to find imports.
+// The builtins are defined in this package, any others come from CUE config,
+// so supporting a new language needs no changes to lib/mod.
+
+// The spec and every language are compiled in the same runtime,
+// as instances from different runtimes can not be merged.
+var (
+	rt   cue.Runtime
+	spec *cue.Instance
+)
+
+// LoadBuiltins resets the registry to the builtin languages.
+func LoadBuiltins() error {
+	var err error
+
+	spec, err = rt.Compile("spec.cue", ModderSpec)
+	if err != nil {
+		return err
+	}
+	err = spec.Value().Validate()
+	if err != nil {
+		return err
+	}
+
+	for lang := range DefaultModders {
+		delete(DefaultModders, lang)
+	}
+	for lang := range LoadedModders {
+		delete(LoadedModders, lang)
+	}
+
+	for lang, src := range DefaultModdersCue {
+		names, err := Register(lang, src)
+		if err != nil {
+			return fmt.Errorf("While loading builtin language %s\n%w\n", lang, err)
+		}
+		if len(names) != 1 || names[0] != lang {
+			return fmt.Errorf("invalid builtin language default %s", lang)
+		}
+		DefaultModders[lang] = LoadedModders[lang]
+	}
+
+	return nil
+}
+
+// Register adds the languages defined in the CUE src to the registry,
+// returning their names. Each top-level field is a language.
+// Builtin languages are unified with their defaults, so config only needs the
+// fields it changes, and a language registered earlier by config is replaced.
+func Register(filename, src string) ([]string, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("language spec not loaded, call LoadBuiltins first")
+	}
+
+	i, err := rt.Compile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := i.Value().Fields()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for iter.Next() {
+		names = append(names, iter.Label())
+	}
+
+	insts := []*cue.Instance{spec}
+	for _, lang := range names {
+		if mdr, ok := DefaultModders[lang]; ok {
+			insts = append(insts, mdr.CueInstance)
+		}
+	}
+	merged := cue.Merge(append(insts, i)...)
+
+	err = merged.Value().Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var mdrMap map[string]*modder.Modder
+	err = merged.Value().Decode(&mdrMap)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lang := range names {
+		mdr := mdrMap[lang]
+		// the spec's label alias is not filled in by Decode
+		if mdr.Name == "" {
+			mdr.Name = lang
+		}
+		mdr.CueInstance = merged
+		LoadedModders[lang] = mdr
+	}
+
+	return names, nil
+}
+
+// LoadFile registers the languages in a CUE file, if it exists.
+func LoadFile(filename string) error {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	_, err = Register(filename, string(bytes))
+	if err != nil {
+		return fmt.Errorf("While loading languages from %s\n%w\n", filename, err)
+	}
+
+	return nil
+}
+
+// LoadDir registers the languages in each CUE file in dir, in name order.
+// Plugins are usually one language per file, as in langs/rust.cue.
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.cue"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, fn := range files {
+		err = LoadFile(fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the modder for a registered language.
+func Lookup(lang string) (*modder.Modder, bool) {
+	mdr, ok := LoadedModders[lang]
+	return mdr, ok
+}
+
+// Names returns the registered languages, sorted.
+func Names() []string {
+	names := make([]string, 0, len(LoadedModders))
+	for lang := range LoadedModders {
+		names = append(names, lang)
+	}
+	sort.Strings(names)
+	return names
+}
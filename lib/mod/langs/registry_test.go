@@ -0,0 +1,65 @@
+package langs
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	err := LoadBuiltins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Names(); !reflect.DeepEqual(got, []string{"cue", "go", "python"}) {
+		t.Fatalf("expected the builtin languages, got %v", got)
+	}
+
+	// a new language, and an override of a builtin which keeps its defaults
+	names, err := Register("plugin.cue", `
+proto: {
+	Version: "3"
+	ModFile: "proto.mods"
+	SumFile: "proto.sums"
+	ModsDir: "vendor/proto"
+	MappingFile: "vendor/proto/modules.txt"
+}
+go: Version: "1.16"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"go", "proto"}) {
+		t.Fatalf("got names %v", names)
+	}
+
+	proto, ok := Lookup("proto")
+	if !ok || proto.Name != "proto" || proto.ModFile != "proto.mods" {
+		t.Fatalf("expected the proto language, got %+v", proto)
+	}
+	golang, _ := Lookup("go")
+	if golang.Version != "1.16" || golang.ModFile != "go.mod" {
+		t.Fatalf("expected go with the new version and default mod file, got %+v", golang)
+	}
+
+	// languages must satisfy the spec
+	_, err = Register("bad.cue", `rust: Version: 1`)
+	if err == nil {
+		t.Fatal("expected an incomplete language to fail")
+	}
+	_, err = Register("bad.cue", `go: Name: "golang"`)
+	if err == nil || !strings.Contains(err.Error(), "conflicting values") {
+		t.Fatalf("expected the name to be the label, got %v", err)
+	}
+
+	// the builtins are restored on reload
+	err = LoadBuiltins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Lookup("proto"); ok {
+		t.Fatal("expected proto to be removed")
+	}
+}
@@ -1,5 +1,18 @@
 package langs
 
+// ModderSpec is the schema for language plugins, which are loaded from
+// .mvsconfig.cue or a langs dir, globally or locally, as in .hof/langs/proto.cue:
+//
+//   proto: {
+//     Version: "3"
+//     ModFile: "proto.mods"
+//     SumFile: "proto.sums"
+//     ModsDir: "vendor/proto"
+//     MappingFile: "vendor/proto/modules.txt"
+//     IntrospectIncludeGlobs: ["**/*.proto"]
+//     IntrospectExcludeGlobs: ["vendor/**"]
+//     IntrospectExtractRegex: [#"(?m)^\s*import\s+(?:public\s+)?"([\w.~/-]+)/[\w.-]+\.proto"\s*;"#]
+//   }
 var ModderSpec = `
 {
 	[N=string]: {
@@ -33,6 +46,10 @@ var ModderSpec = `
 		VendorPreCommands?: [...[...string]],
 		VendorPostCommands?: [...[...string]],
 
+		FetchIncludeGlobs?: [...string],
+		FetchExcludeGlobs?: [...string],
+		FetchMaxFileSize?: int,
+
 		ManageFileOnly?: bool,
 		SymlinkLocalReplaces?: bool,
 
@@ -1,25 +1,78 @@
 package mod
 
 import (
-	"fmt"
+	"sort"
 
+	"github.com/hofstadter-io/hof/lib/mod/langs"
 	"github.com/hofstadter-io/hof/lib/mod/modder"
+	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
 func getModder(lang string) (*modder.Modder, error) {
 	// TODO try to detect language by looking for
 	// a [lang].mod file
-	mod, ok := LangModderMap[lang]
+	mod, ok := langs.Lookup(lang)
 	if !ok {
-		return nil, fmt.Errorf("Unknown language %q. Add configuration at https://github.com/hofstadter-io/mvs/blob/master/lib/modder/langs.go", lang)
+		return nil, unknownLang(lang)
 	}
 
 	return mod, nil
 }
 
+// ModStatus summarizes the module of a language
+type ModStatus struct {
+	Lang     string
+	Module   string
+	Requires int
+	Vendored bool
+	// why the module could not be read
+	Err error
+}
+
+// Statuses returns the status of the module of each discovered language, sorted by language
+func Statuses() []ModStatus {
+	langs := DiscoverLangs()
+	sort.Strings(langs)
+
+	var stats []ModStatus
+	for _, lang := range langs {
+		stats = append(stats, langStatus(lang))
+	}
+	return stats
+}
+
+func langStatus(lang string) (S ModStatus) {
+	S.Lang = lang
+	mdr, err := getModder(lang)
+	if err != nil {
+		S.Err = err
+		return S
+	}
+	S.Vendored, _ = yagu.CheckPathExists(mdr.ModsDir)
+
+	M, err := mdr.RootModule()
+	if err != nil {
+		S.Err = err
+		return S
+	}
+	S.Module, S.Requires = M.Module, len(M.Require)
+	return S
+}
+
 // This is a convienence function for calling the other mod functions with a list of languages
 func ProcessLangs(method string, langs []string) error {
 
+	// tidy and vendor every module of a workspace together
+	if method == "tidy" || method == "vendor" || method == "vendor-check" {
+		fn, err := FindWorkFile()
+		if err != nil {
+			return err
+		}
+		if fn != "" {
+			return ProcessWork(fn, method, "", langs)
+		}
+	}
+
 	// discover and update slice
 	if len(langs) == 0 {
 		langs = DiscoverLangs()
@@ -29,14 +82,14 @@ func ProcessLangs(method string, langs []string) error {
 
 	for _, lang := range langs {
 		switch method {
-		case "graph":
-			err = Graph(lang)
 		case "status":
 			err = Status(lang)
 		case "tidy":
 			err = Tidy(lang)
 		case "vendor":
 			err = Vendor(lang)
+		case "vendor-check":
+			err = VendorCheck(lang)
 		case "verify":
 			err = Verify(lang)
 		default:
@@ -59,12 +112,45 @@ func Init(lang, module string) error {
 	return mdr.Init(module)
 }
 
-func Graph(lang string) error {
+// GraphLangs prints the dependency graph of each language as text, dot, or json
+func GraphLangs(format string, langs []string) error {
+	fn, err := FindWorkFile()
+	if err != nil {
+		return err
+	}
+	if fn != "" {
+		return ProcessWork(fn, "graph", format, langs)
+	}
+
+	if len(langs) == 0 {
+		langs = DiscoverLangs()
+	}
+
+	for _, lang := range langs {
+		err := Graph(lang, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Graph(lang, format string) error {
 	mdr, err := getModder(lang)
 	if err != nil {
 		return err
 	}
-	return mdr.Graph()
+	return mdr.Graph(format)
+}
+
+// GraphOf returns the resolved dependency graph of the module of lang
+func GraphOf(lang string) (*modder.ModGraph, error) {
+	mdr, err := getModder(lang)
+	if err != nil {
+		return nil, err
+	}
+	return mdr.ResolvedGraph()
 }
 
 func Status(lang string) error {
@@ -91,6 +177,14 @@ func Vendor(lang string) error {
 	return mdr.Vendor()
 }
 
+func VendorCheck(lang string) error {
+	mdr, err := getModder(lang)
+	if err != nil {
+		return err
+	}
+	return mdr.VendorCheck()
+}
+
 func Verify(lang string) error {
 	mdr, err := getModder(lang)
 	if err != nil {
@@ -98,3 +192,38 @@ func Verify(lang string) error {
 	}
 	return mdr.Verify()
 }
+
+// Why prints the require chains which bring each module into the dependency graph
+func Why(modules []string) error {
+	for _, lang := range DiscoverLangs() {
+		mdr, err := getModder(lang)
+		if err != nil {
+			return err
+		}
+		err = mdr.Why(modules)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Outdated lists the dependencies with newer tags upstream, in format, updating direct requires with update
+func Outdated(update bool, format string, langs []string) error {
+	if len(langs) == 0 {
+		langs = DiscoverLangs()
+	}
+
+	for _, lang := range langs {
+		mdr, err := getModder(lang)
+		if err != nil {
+			return err
+		}
+		err = mdr.Outdated(update, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -50,6 +50,15 @@ type Modder struct {
 	VendorPreCommands  [][]string        `yaml:"VendorPreCommands",omitempty`
 	VendorPostCommands [][]string        `yaml:"VendorPostCommands",omitempty`
 
+	// Fetch related fields
+	// filesystem globs and a size limit, in bytes, for the files kept in the module cache,
+	// so that large modules can be fetched without their test fixtures, docs, and binaries.
+	// Skipped files are missing from the dirhash of a module too, so a module sum
+	// only matches those fetched with the same filter.
+	FetchIncludeGlobs []string `yaml:"FetchIncludeGlobs,omitempty"`
+	FetchExcludeGlobs []string `yaml:"FetchExcludeGlobs,omitempty"`
+	FetchMaxFileSize  int64    `yaml:"FetchMaxFileSize,omitempty"`
+
 	// Some more vendor controls
 	ManageFileOnly       bool `yaml:"ManageFileOnly",omitempty`
 	SymlinkLocalReplaces bool `yaml:"SymlinkLocalReplaces",omitempty`
@@ -75,6 +84,16 @@ type Modder struct {
 	// module writers can then have local control over how their module is handeled during vendoring
 	depsMap map[string]*Module `yaml:"-"`
 
+	// semver constraints on dependencies, and the tags of their modules
+	constraints map[string][]constraintReq `yaml:"-"`
+	versions    map[string][]string        `yaml:"-"`
+
+	// requirements seen while resolving, for graphing
+	edges []Edge `yaml:"-"`
+
+	// the workspace this module is resolved in, if any
+	workspace *Workspace `yaml:"-"`
+
 	// compiled cue, used for merging
 	CueInstance *cue.Instance `yaml:"-"`
 }
@@ -109,3 +128,20 @@ func NewFromFile(lang, filepath string, FS billy.Filesystem) (*Modder, error) {
 
 	return mdr, nil
 }
+
+// FetchFilter returns the files kept when fetching modules into the cache,
+// or nil to keep all of them. The module and sum files are always included.
+func (mdr *Modder) FetchFilter() *yagu.ZipFilter {
+	if len(mdr.FetchIncludeGlobs) == 0 && len(mdr.FetchExcludeGlobs) == 0 && mdr.FetchMaxFileSize <= 0 {
+		return nil
+	}
+
+	filter := &yagu.ZipFilter{
+		Excludes: mdr.FetchExcludeGlobs,
+		MaxSize:  mdr.FetchMaxFileSize,
+	}
+	if len(mdr.FetchIncludeGlobs) > 0 {
+		filter.Includes = append([]string{mdr.ModFile, mdr.SumFile}, mdr.FetchIncludeGlobs...)
+	}
+	return filter
+}
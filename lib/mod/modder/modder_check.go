@@ -8,6 +8,8 @@ import (
 	"github.com/go-git/go-billy/v5/osfs"
 	"golang.org/x/mod/semver"
 
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
 	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
 	"github.com/hofstadter-io/hof/lib/yagu"
 	"github.com/hofstadter-io/hof/lib/yagu/repos/git"
@@ -273,6 +275,14 @@ func (mdr *Modder) PartitionSumEntries() ([]string, []string, []string, error) {
 			continue
 		}
 
+		// constraints are checked against the version selected when vendoring
+		if modfile.IsConstraint(R.NewVersion) {
+			if RR, ok := resolveFromSum(sf, R); ok {
+				R = RR
+				mod.SelfDeps[path] = R
+			}
+		}
+
 		ver := sumfile.Version{
 			Path:    path,
 			Version: R.NewVersion,
@@ -360,7 +370,16 @@ func (mdr *Modder) CompareSumEntryToVendor(R Replace) error {
 	}
 
 	if mismatch {
-		return fmt.Errorf("Errors with vendor integrity")
+		msg := fmt.Sprintf("Errors with vendor integrity for %s@%s in %s\n  sumfile: %s\n  vendor:  %s\n", R.NewPath, R.NewVersion, vpath, sumDirhash, vdrDirhash)
+
+		// show what changed, when the cached copy still matches the sumfile
+		if ch, err := cache.Checksum(mdr.Name, R.NewPath, R.NewVersion); err == nil && ch == sumDirhash {
+			CFS, _ := cache.Load(mdr.Name, R.NewPath, R.NewVersion)
+			if diff, err := diffFS(CFS, FS); err == nil {
+				msg += "vendor changes from cache:\n" + formatDiff(diff)
+			}
+		}
+		return fmt.Errorf("%s", msg)
 	}
 
 	return nil
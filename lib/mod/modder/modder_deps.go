@@ -87,7 +87,14 @@ func (mdr *Modder) MvsMergeDependency(m *Module) error {
 
 	// NOTE This is what basically makes us BFS
 	for _, R := range m.SelfDeps {
-		err := mdr.VendorDep(R)
+		req := R
+		R, err := mdr.resolveDep(m.Module, R)
+		if err != nil {
+			mdr.errors = append(mdr.errors, err)
+			continue
+		}
+		mdr.recordEdge(m, req, R)
+		err = mdr.VendorDep(R)
 		if err != nil {
 			mdr.errors = append(mdr.errors, err)
 		}
@@ -0,0 +1,81 @@
+package modder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// diffFS compares the files in two filesystems by hash,
+// returning a line for each file which was added, removed, or changed in b.
+func diffFS(a, b billy.Filesystem) ([]string, error) {
+	ah, err := fileHashes(a)
+	if err != nil {
+		return nil, err
+	}
+	bh, err := fileHashes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff []string
+	for fn, h := range ah {
+		bhash, ok := bh[fn]
+		switch {
+		case !ok:
+			diff = append(diff, "- "+fn)
+		case bhash != h:
+			diff = append(diff, "~ "+fn)
+		}
+	}
+	for fn := range bh {
+		if _, ok := ah[fn]; !ok {
+			diff = append(diff, "+ "+fn)
+		}
+	}
+
+	// by filename, then by kind of change
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i][2:] != diff[j][2:] {
+			return diff[i][2:] < diff[j][2:]
+		}
+		return diff[i] < diff[j]
+	})
+	return diff, nil
+}
+
+func fileHashes(FS billy.Filesystem) (map[string]string, error) {
+	files, err := yagu.BillyFilenames("/", FS)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string, len(files))
+	for _, fn := range files {
+		if strings.HasPrefix(fn, "/.git/") {
+			continue
+		}
+		h, err := yagu.BillyCalcFileHash(fn, FS)
+		if err != nil {
+			return nil, err
+		}
+		hashes[fn] = h
+	}
+	return hashes, nil
+}
+
+// formatDiff indents the lines of a diffFS for an error message.
+func formatDiff(diff []string) string {
+	if len(diff) == 0 {
+		return "  (no file differences)\n"
+	}
+	var b strings.Builder
+	for _, line := range diff {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	return b.String()
+}
@@ -2,11 +2,15 @@ package modder
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/printer"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
-func (mdr *Modder) Graph() error {
+func (mdr *Modder) Graph(format string) error {
 
 	// Graph Command Override
 	if len(mdr.CommandGraph) > 0 {
@@ -19,7 +23,7 @@ func (mdr *Modder) Graph() error {
 		}
 	} else {
 		// Otherwise, MVS venodiring
-		err := mdr.GraphMVS()
+		err := mdr.GraphMVS(format)
 		if err != nil {
 			mdr.PrintErrors()
 			return err
@@ -29,14 +33,227 @@ func (mdr *Modder) Graph() error {
 	return nil
 }
 
-// The entrypoint to the MVS internal verify process
-func (mdr *Modder) GraphMVS() error {
+// The entrypoint to the MVS internal graph process
+func (mdr *Modder) GraphMVS(format string) error {
+	err := mdr.ResolveMVS()
+	if err != nil {
+		return err
+	}
 
-	// Load minimal root module
-	err := mdr.LoadMetaFromFS(".")
+	out, err := mdr.BuildGraph().Format(format)
 	if err != nil {
 		return err
 	}
 
+	fmt.Print(out)
 	return nil
 }
+
+// ResolvedGraph resolves the root module with MVS and returns its graph.
+func (mdr *Modder) ResolvedGraph() (*ModGraph, error) {
+	if len(mdr.CommandGraph) > 0 || mdr.NoLoad {
+		return nil, fmt.Errorf("%s modules are not resolved by hof mod", mdr.Name)
+	}
+	err := mdr.ResolveMVS()
+	if err != nil {
+		return nil, err
+	}
+	return mdr.BuildGraph(), nil
+}
+
+// Edge is a requirement of one module version on another.
+// Constraints are resolved to the minimum version satisfying them.
+type Edge struct {
+	From        string `json:"from"`
+	FromVersion string `json:"fromVersion,omitempty"`
+	To          string `json:"to"`
+	Version     string `json:"version"`
+	Constraint  string `json:"constraint,omitempty"`
+}
+
+// GraphNode is a dependency and the version MVS selected for it.
+type GraphNode struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	Replace string `json:"replace,omitempty"`
+
+	// the requirers whose requirement is the selected version
+	SelectedBy []string `json:"selectedBy"`
+}
+
+// ModGraph is the resolved dependency graph of the root module.
+type ModGraph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []Edge      `json:"edges"`
+}
+
+// recordEdge notes that from required R, as written in req.
+func (mdr *Modder) recordEdge(from *Module, req, R Replace) {
+	e := Edge{
+		From:        from.Module,
+		FromVersion: from.Version,
+		To:          R.NewPath,
+		Version:     R.NewVersion,
+	}
+	if R.OldPath != "" {
+		e.To, e.Version = R.OldPath, R.OldVersion
+	}
+	if modfile.IsConstraint(req.NewVersion) {
+		e.Constraint = req.NewVersion
+	}
+	mdr.edges = append(mdr.edges, e)
+}
+
+// BuildGraph returns the graph of the versions selected by ResolveMVS,
+// keeping only the requirements of the selected versions.
+func (mdr *Modder) BuildGraph() *ModGraph {
+	root := mdr.module.Module
+	G := &ModGraph{Root: root}
+
+	seen := map[Edge]bool{}
+	for _, e := range mdr.edges {
+		if seen[e] {
+			continue
+		}
+		if e.From != root {
+			m, ok := mdr.depsMap[e.From]
+			if !ok || m.Version != e.FromVersion {
+				continue
+			}
+		}
+		if _, ok := mdr.depsMap[e.To]; !ok {
+			continue
+		}
+		seen[e] = true
+		G.Edges = append(G.Edges, e)
+	}
+	sort.Slice(G.Edges, func(i, j int) bool {
+		a, b := G.Edges[i], G.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	for path, m := range mdr.depsMap {
+		n := GraphNode{
+			Module:     path,
+			Version:    m.Version,
+			SelectedBy: []string{},
+		}
+		if m.ReplaceModule != "" && (m.ReplaceModule != path || m.ReplaceVersion != m.Version) {
+			n.Replace = nodeName(m.ReplaceModule, m.ReplaceVersion)
+		}
+		for _, e := range G.Edges {
+			if e.To == path && e.Version == m.Version {
+				n.SelectedBy = append(n.SelectedBy, nodeName(e.From, e.FromVersion))
+			}
+		}
+		G.Nodes = append(G.Nodes, n)
+	}
+	sort.Slice(G.Nodes, func(i, j int) bool {
+		return G.Nodes[i].Module < G.Nodes[j].Module
+	})
+
+	return G
+}
+
+// Format renders the graph as dot, or as one of the formats of the printer,
+// where a table lists the requirements.
+func (G *ModGraph) Format(format string) (string, error) {
+	if format == "dot" {
+		return G.Dot(), nil
+	}
+	err := printer.CheckFormat(format, "dot")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = printer.Print(&b, format, G)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Table lists the requirements, with the version each selected.
+func (G *ModGraph) Table() *printer.Rows {
+	R := printer.NewRows("from", "to", "version", "constraint")
+	for _, e := range G.Edges {
+		R.Add(nodeName(e.From, e.FromVersion), e.To, e.Version, e.Constraint)
+	}
+	return R
+}
+
+// Text lists the requirements, one per line as 'requirer required',
+// and then each selected version with the requirers that selected it.
+func (G *ModGraph) Text() string {
+	var b strings.Builder
+
+	for _, e := range G.Edges {
+		fmt.Fprintf(&b, "%s %s", nodeName(e.From, e.FromVersion), nodeName(e.To, e.Version))
+		if e.Constraint != "" {
+			fmt.Fprintf(&b, " (%s)", e.Constraint)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nselected:\n")
+	for _, n := range G.Nodes {
+		b.WriteString(nodeName(n.Module, n.Version))
+		if n.Replace != "" {
+			b.WriteString(" => " + n.Replace)
+		}
+		fmt.Fprintf(&b, "  by %s\n", strings.Join(n.SelectedBy, ", "))
+	}
+
+	return b.String()
+}
+
+// Dot renders the graph for Graphviz. Edges point at the selected version
+// and are labeled with the required one, bold where they selected it.
+func (G *ModGraph) Dot() string {
+	var b strings.Builder
+
+	selected := map[string]string{}
+	fmt.Fprintf(&b, "digraph %q {\n", G.Root)
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	fmt.Fprintf(&b, "\t%q [style=bold];\n", G.Root)
+	for _, n := range G.Nodes {
+		selected[n.Module] = n.Version
+		label := n.Module + "\n" + n.Version
+		if n.Replace != "" {
+			label += "\n=> " + n.Replace
+		}
+		fmt.Fprintf(&b, "\t%q [label=%q];\n", nodeName(n.Module, n.Version), label)
+	}
+
+	for _, e := range G.Edges {
+		from := G.Root
+		if e.From != G.Root {
+			from = nodeName(e.From, e.FromVersion)
+		}
+		label := e.Version
+		if e.Constraint != "" {
+			label = e.Constraint + " " + label
+		}
+		style := "dashed"
+		if e.Version == selected[e.To] {
+			style = "bold"
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [label=%q, style=%s];\n", from, nodeName(e.To, selected[e.To]), label, style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func nodeName(mod, ver string) string {
+	if ver == "" {
+		return mod
+	}
+	return mod + "@" + ver
+}
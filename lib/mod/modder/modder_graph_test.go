@@ -0,0 +1,90 @@
+package modder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGraph is a root requiring a and b, where a requires a newer b, which is selected.
+// The older version of a, which MVS did not select, requires c.
+func testGraph() *ModGraph {
+	root := &Module{Module: "github.com/test/root"}
+	a := &Module{Module: "github.com/test/a", Version: "v1.0.0"}
+	oldA := &Module{Module: "github.com/test/a", Version: "v0.9.0"}
+	mdr := &Modder{
+		module: root,
+		depsMap: map[string]*Module{
+			"github.com/test/a": a,
+			"github.com/test/b": {Module: "github.com/test/b", Version: "v1.1.0", ReplaceModule: "github.com/fork/b", ReplaceVersion: "v1.1.1"},
+		},
+	}
+	mdr.recordEdge(root, Replace{NewVersion: "v1.0.0"}, Replace{NewPath: "github.com/test/a", NewVersion: "v1.0.0"})
+	mdr.recordEdge(root, Replace{NewVersion: "^1.0.0"}, Replace{NewPath: "github.com/test/b", NewVersion: "v1.0.0"})
+	mdr.recordEdge(a, Replace{NewVersion: "v1.1.0"}, Replace{NewPath: "github.com/test/b", NewVersion: "v1.1.0"})
+	mdr.recordEdge(oldA, Replace{NewVersion: "v0.1.0"}, Replace{NewPath: "github.com/test/c", NewVersion: "v0.1.0"})
+	// a duplicate, as when a module is loaded twice
+	mdr.recordEdge(a, Replace{NewVersion: "v1.1.0"}, Replace{NewPath: "github.com/test/b", NewVersion: "v1.1.0"})
+	return mdr.BuildGraph()
+}
+
+func TestGraphJSON(t *testing.T) {
+	out, err := testGraph().Format("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got ModGraph
+	err = json.Unmarshal([]byte(out), &got)
+	if err != nil {
+		t.Fatalf("expected json, got %v:\n%s", err, out)
+	}
+	expect := ModGraph{
+		Root: "github.com/test/root",
+		Nodes: []GraphNode{
+			{Module: "github.com/test/a", Version: "v1.0.0", SelectedBy: []string{"github.com/test/root"}},
+			{Module: "github.com/test/b", Version: "v1.1.0", Replace: "github.com/fork/b@v1.1.1", SelectedBy: []string{"github.com/test/a@v1.0.0"}},
+		},
+		Edges: []Edge{
+			{From: "github.com/test/a", FromVersion: "v1.0.0", To: "github.com/test/b", Version: "v1.1.0"},
+			{From: "github.com/test/root", To: "github.com/test/a", Version: "v1.0.0"},
+			{From: "github.com/test/root", To: "github.com/test/b", Version: "v1.0.0", Constraint: "^1.0.0"},
+		},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got %+v, want %+v", got, expect)
+	}
+
+	// fields which are empty are left out
+	if strings.Contains(out, `"fromVersion": ""`) || strings.Contains(out, `"constraint": ""`) {
+		t.Fatalf("expected empty fields omitted:\n%s", out)
+	}
+}
+
+func TestGraphDot(t *testing.T) {
+	out, err := testGraph().Format("dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `digraph "github.com/test/root" {
+	rankdir=LR;
+	node [shape=box];
+	"github.com/test/root" [style=bold];
+	"github.com/test/a@v1.0.0" [label="github.com/test/a\nv1.0.0"];
+	"github.com/test/b@v1.1.0" [label="github.com/test/b\nv1.1.0\n=> github.com/fork/b@v1.1.1"];
+	"github.com/test/a@v1.0.0" -> "github.com/test/b@v1.1.0" [label="v1.1.0", style=bold];
+	"github.com/test/root" -> "github.com/test/a@v1.0.0" [label="v1.0.0", style=bold];
+	"github.com/test/root" -> "github.com/test/b@v1.1.0" [label="^1.0.0 v1.0.0", style=dashed];
+}
+`
+	if out != expect {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, expect)
+	}
+
+	_, err = testGraph().Format("svg")
+	if err == nil {
+		t.Fatal("expected an unknown format to fail")
+	}
+}
@@ -153,3 +153,24 @@ func (mdr *Modder) LoadMappingsFile() error {
 
 	return nil
 }
+
+// ModulePath returns the module path declared in the root mod file.
+func (mdr *Modder) ModulePath() (string, error) {
+	err := mdr.LoadMetaFromFS(".")
+	if err != nil {
+		return "", err
+	}
+	if mdr.module == nil {
+		return "", fmt.Errorf("%s modules are not loaded by hof mod", mdr.Name)
+	}
+	return mdr.module.Module, nil
+}
+
+// RootModule returns the root module, as read from its mod and sum files.
+func (mdr *Modder) RootModule() (*Module, error) {
+	_, err := mdr.ModulePath()
+	if err != nil {
+		return nil, err
+	}
+	return mdr.module, nil
+}
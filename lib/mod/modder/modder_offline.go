@@ -0,0 +1,105 @@
+package modder
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// LoadVendored reads the vendored copy of a module into memory,
+// since vendoring removes each vendored copy before writing it again.
+func (mdr *Modder) LoadVendored(R Replace) (billy.Filesystem, error) {
+	rpath := R.OldPath
+	if R.OldPath == "" {
+		rpath = R.NewPath
+	}
+	src := osfs.New(path.Join(mdr.ModsDir, rpath))
+
+	files, err := yagu.BillyFilenames("/", src)
+	if err != nil {
+		return nil, err
+	}
+
+	FS := memfs.New()
+	for _, fn := range files {
+		data, err := yagu.BillyReadAll(fn, src)
+		if err != nil {
+			return nil, err
+		}
+		f, err := FS.Create(fn)
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(data)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return FS, nil
+}
+
+// offlineVersions lists the versions of a module available without the network,
+// those in the module cache and those recorded in the sumfile.
+func (mdr *Modder) offlineVersions(mod string) ([]string, error) {
+	cached, err := cache.Versions(mdr.Name, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, v := range cached {
+		found[v] = true
+	}
+	if sf := mdr.module.SumFile; sf != nil {
+		for ver := range sf.Mods {
+			// skip the mod file entries, path/version/lang.mod
+			if ver.Path == mod && !strings.Contains(ver.Version, "/") {
+				found[ver.Version] = true
+			}
+		}
+	}
+
+	var vers []string
+	for v := range found {
+		if semver.IsValid(v) {
+			vers = append(vers, v)
+		}
+	}
+	sort.Strings(vers)
+	return vers, nil
+}
+
+// takeMissing removes the errors for modules which could not be fetched
+// while offline, returning the modules sorted and without duplicates.
+func (mdr *Modder) takeMissing() []string {
+	found := map[string]bool{}
+	var errs []error
+	for _, err := range mdr.errors {
+		var merr *cache.MissingError
+		if errors.As(err, &merr) {
+			found[merr.Module+"@"+merr.Version] = true
+			continue
+		}
+		errs = append(errs, err)
+	}
+	mdr.errors = errs
+
+	missing := make([]string, 0, len(found))
+	for m := range found {
+		missing = append(missing, m)
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,101 @@
+package modder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+)
+
+func TestResolveOffline(t *testing.T) {
+	// a is cached and requires b, which is not, and neither is c
+	offlineModule(t, `module github.com/test/root
+
+cue v0.2.2
+
+require (
+	github.com/test/a v1.0.0
+	github.com/test/c v0.2.0
+)
+`, map[string]map[string]string{
+		"github.com/test/a@v1.0.0": {
+			"cue.mods": "module github.com/test/a\n\ncue v0.2.2\n\nrequire github.com/test/b v0.1.0\n",
+		},
+	})
+
+	mdr := testModder()
+	err := mdr.ResolveMVS()
+	if err == nil {
+		t.Fatal("expected the missing modules to fail")
+	}
+
+	expect := `Offline, and these modules are in neither the module cache nor cue.mod/pkg:
+  github.com/test/b@v0.1.0
+  github.com/test/c@v0.2.0
+Run with network access to fetch them.`
+	if err.Error() != expect {
+		t.Fatalf("got:\n%s\nwant:\n%s", err, expect)
+	}
+	if code := errs.Code(err); code != errs.CodeModOffline {
+		t.Fatalf("expected the offline code, got %q", code)
+	}
+	if _, ok := mdr.depsMap["github.com/test/a"]; !ok {
+		t.Fatalf("expected the cached module loaded, got %v", mdr.depsMap)
+	}
+}
+
+func TestOfflineVersions(t *testing.T) {
+	offlineModule(t, "module github.com/test/root\n\ncue v0.2.2\n", map[string]map[string]string{
+		"github.com/test/a@v1.0.0":  {"cue.mods": "module github.com/test/a\n"},
+		"github.com/test/a@v1.1.0":  {"cue.mods": "module github.com/test/a\n"},
+		"github.com/test/a@@main":   {"cue.mods": "module github.com/test/a\n"},
+		"github.com/test/ab@v2.0.0": {"cue.mods": "module github.com/test/ab\n"},
+	})
+
+	mdr := testModder()
+	mdr.module = &Module{SumFile: &sumfile.Sum{Mods: map[sumfile.Version][]string{
+		{Path: "github.com/test/a", Version: "v0.9.0"}:          {"h1:a="},
+		{Path: "github.com/test/a", Version: "v0.9.0/cue.mods"}: {"h1:b="},
+		{Path: "github.com/test/a", Version: "v1.0.0"}:          {"h1:c="},
+		{Path: "github.com/test/b", Version: "v3.0.0"}:          {"h1:d="},
+	}}}
+
+	// the cached and the recorded tags, but not branches, or other modules
+	vers, err := mdr.offlineVersions("github.com/test/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"v0.9.0", "v1.0.0", "v1.1.0"}
+	if !reflect.DeepEqual(vers, expect) {
+		t.Fatalf("got %q, want %q", vers, expect)
+	}
+
+	// while offline they are the versions constraints resolve against
+	vers, err = mdr.remoteVersions("github.com/test/a")
+	if err != nil || !reflect.DeepEqual(vers, expect) {
+		t.Fatalf("got %q, %v, want %q", vers, err, expect)
+	}
+}
+
+func TestTakeMissing(t *testing.T) {
+	other := fmt.Errorf("bad mod file")
+	mdr := &Modder{errors: []error{
+		&cache.MissingError{Module: "github.com/test/b", Version: "v0.1.0"},
+		other,
+		fmt.Errorf("While fetching\n%w\n", &cache.MissingError{Module: "github.com/test/a", Version: "v1.0.0"}),
+		&cache.MissingError{Module: "github.com/test/b", Version: "v0.1.0"},
+	}}
+
+	missing := mdr.takeMissing()
+	expect := []string{"github.com/test/a@v1.0.0", "github.com/test/b@v0.1.0"}
+	if !reflect.DeepEqual(missing, expect) {
+		t.Fatalf("got %q, want %q", missing, expect)
+	}
+	if len(mdr.errors) != 1 || !errors.Is(mdr.errors[0], other) {
+		t.Fatalf("expected the other errors kept, got %v", mdr.errors)
+	}
+}
@@ -0,0 +1,105 @@
+package modder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/printer"
+)
+
+// Outdated lists the selected dependencies which have newer tags upstream.
+// With update, the modules the root requires directly by version are
+// bumped to their latest tag in the mod file. Indirect dependencies and
+// requirements by constraint, commit, or branch are left alone.
+// The list is printed in format, see the printer package.
+func (mdr *Modder) Outdated(update bool, format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+
+	err = mdr.ResolveMVS()
+	if err != nil {
+		mdr.PrintErrors()
+		return err
+	}
+
+	direct := map[string]string{}
+	for _, req := range mdr.module.Require {
+		direct[req.Path] = req.Version
+	}
+
+	paths := make([]string, 0, len(mdr.depsMap))
+	for path := range mdr.depsMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	R := printer.NewRows("module", "current", "latest", "note")
+
+	f := mdr.module.ModFile
+	changed := false
+	for _, path := range paths {
+		m := mdr.depsMap[path]
+		// replaced modules follow their replacement, not upstream
+		if m.ReplaceModule != "" && m.ReplaceModule != path {
+			continue
+		}
+		if !semver.IsValid(m.Version) {
+			continue
+		}
+
+		latest, err := mdr.latestVersion(path)
+		if err != nil {
+			mdr.errors = append(mdr.errors, err)
+			continue
+		}
+		if semver.Compare(latest, m.Version) <= 0 {
+			continue
+		}
+
+		note := ""
+		ver, isDirect := direct[path]
+		if !isDirect {
+			note = "(indirect)"
+		} else if update && semver.IsValid(ver) {
+			err = f.AddRequire(path, latest)
+			if err != nil {
+				return err
+			}
+			note = "(updated)"
+			changed = true
+		}
+		R.Add(path, m.Version, latest, note)
+	}
+	err = printer.Print(os.Stdout, format, R)
+	if err != nil {
+		return err
+	}
+
+	if err := mdr.CheckForErrors(); err != nil {
+		mdr.PrintErrors()
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	f.Cleanup()
+	bytes, err := f.Format()
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(mdr.ModFile, bytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	// kept off stdout, which may be read by a script
+	fmt.Fprintf(os.Stderr, "updated %s, run 'hof mod vendor' to fetch the new versions\n", mdr.ModFile)
+	return nil
+}
@@ -0,0 +1,146 @@
+package modder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+)
+
+// offlineModule makes a root module in a temp dir, which it changes to,
+// with the modules in cached in an offline module cache, by path@version.
+func offlineModule(t *testing.T, root string, cached map[string]map[string]string) {
+	dir, err := ioutil.TempDir("", "hof-mod-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	oldBase := cache.LocalCacheBaseDir
+	cache.SetBaseDir(filepath.Join(dir, "cache"))
+	t.Cleanup(func() { cache.SetBaseDir(oldBase) })
+	oldOffline := cache.Offline()
+	cache.SetOffline(true)
+	t.Cleanup(func() { cache.SetOffline(oldOffline) })
+
+	for modver, files := range cached {
+		i := strings.Index(modver, "@")
+		writeFiles(t, cache.ModDir("cue", modver[:i], modver[i+1:]), files)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"cue.mods": root})
+}
+
+func testModder() *Modder {
+	return &Modder{
+		Name:        "cue",
+		ModFile:     "cue.mods",
+		SumFile:     "cue.sums",
+		ModsDir:     "cue.mod/pkg",
+		MappingFile: "cue.mod/modules.txt",
+	}
+}
+
+// captureStdout returns what f prints
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = old }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestOutdated(t *testing.T) {
+	offlineModule(t, `module github.com/test/root
+
+cue v0.2.2
+
+require (
+	github.com/test/a v1.0.0
+	github.com/test/c ^0.1.0
+)
+`, map[string]map[string]string{
+		"github.com/test/a@v1.0.0": {
+			"cue.mods": "module github.com/test/a\n\ncue v0.2.2\n\nrequire github.com/test/b v0.1.0\n",
+		},
+		"github.com/test/b@v0.1.0": {
+			"cue.mods": "module github.com/test/b\n\ncue v0.2.2\n",
+		},
+		"github.com/test/c@v0.1.0": {
+			"cue.mods": "module github.com/test/c\n\ncue v0.2.2\n",
+		},
+	})
+
+	// the upstream tags, prereleases are not updates
+	mdr := testModder()
+	mdr.versions = map[string][]string{
+		"github.com/test/a": {"v1.0.0", "v1.2.0", "v2.0.0-rc.1"},
+		"github.com/test/b": {"v0.1.0", "v0.2.0"},
+		"github.com/test/c": {"v0.1.0", "v0.1.1"},
+	}
+
+	var err error
+	out := captureStdout(t, func() {
+		err = mdr.Outdated(true, "json")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []map[string]string
+	err = json.Unmarshal([]byte(out), &rows)
+	if err != nil {
+		t.Fatalf("expected json, got %v:\n%s", err, out)
+	}
+	expect := []map[string]string{
+		{"module": "github.com/test/a", "current": "v1.0.0", "latest": "v1.2.0", "note": "(updated)"},
+		{"module": "github.com/test/b", "current": "v0.1.0", "latest": "v0.2.0", "note": "(indirect)"},
+		{"module": "github.com/test/c", "current": "v0.1.0", "latest": "v0.1.1", "note": ""},
+	}
+	if len(rows) != len(expect) {
+		t.Fatalf("got %v, want %v", rows, expect)
+	}
+	for i := range expect {
+		for k, v := range expect[i] {
+			if rows[i][k] != v {
+				t.Errorf("row %d: got %s %q, want %q", i, k, rows[i][k], v)
+			}
+		}
+	}
+
+	// only the direct requirement by version is bumped
+	data, err := ioutil.ReadFile("cue.mods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "github.com/test/a v1.2.0") || !strings.Contains(string(data), "github.com/test/c ^0.1.0") {
+		t.Fatalf("expected a updated and c left alone, got:\n%s", data)
+	}
+	if strings.Contains(string(data), "github.com/test/b") {
+		t.Fatalf("expected no requirement on the indirect b, got:\n%s", data)
+	}
+}
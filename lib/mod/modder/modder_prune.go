@@ -0,0 +1,94 @@
+package modder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pruneVendor removes everything in the vendor dir outside of the modules to keep.
+func (mdr *Modder) pruneVendor(keep map[string]bool) error {
+	stray, err := mdr.strayVendored(keep)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range stray {
+		fmt.Println("removing", filepath.Join(mdr.ModsDir, rel))
+		err := os.RemoveAll(filepath.Join(mdr.ModsDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// strayVendored returns the paths in the vendor dir which are outside of the modules to keep,
+// relative to it and sorted. The contents of stray dirs are not listed separately.
+func (mdr *Modder) strayVendored(keep map[string]bool) ([]string, error) {
+	var stray []string
+
+	mapping := filepath.Clean(mdr.MappingFile)
+	err := filepath.Walk(mdr.ModsDir, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			if fn == mdr.ModsDir && os.IsNotExist(err) {
+				// nothing vendored yet
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(mdr.ModsDir, fn)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || filepath.Clean(fn) == mapping {
+			return nil
+		}
+
+		if keep[rel] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// the dirs which modules are nested in
+		if info.IsDir() {
+			for path := range keep {
+				if strings.HasPrefix(path, rel+"/") {
+					return nil
+				}
+			}
+		}
+
+		stray = append(stray, rel)
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(stray)
+	return stray, nil
+}
+
+// pruneSum drops the sumfile entries of the modules and versions which are no longer selected,
+// keeping the mod file hashes and branch commits along with the selected versions.
+func (mdr *Modder) pruneSum() {
+	sf := mdr.module.SumFile
+	for ver := range sf.Mods {
+		m, ok := mdr.depsMap[ver.Path]
+		if ok && (ver.Version == m.Version || strings.HasPrefix(ver.Version, m.Version+"/")) {
+			continue
+		}
+		delete(sf.Mods, ver)
+	}
+}
@@ -1 +1,190 @@
 package modder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/git"
+)
+
+// constraintReq records a semver constraint on a module and who required it.
+type constraintReq struct {
+	By         string
+	Constraint *modfile.Constraint
+}
+
+// ResolveConstraint resolves a dependency whose version is a semver constraint,
+// such as ^1.2 or ~0.3, to the lowest tagged version satisfying it.
+// Like the exact versions, these minimums are then merged by MVS,
+// which selects the greatest of them for each module.
+func (mdr *Modder) ResolveConstraint(by string, R Replace) (Replace, error) {
+	if !modfile.IsConstraint(R.NewVersion) {
+		return R, nil
+	}
+
+	c, err := modfile.ParseConstraint(R.NewVersion)
+	if err != nil {
+		return R, fmt.Errorf("In %s, requiring %s\n%w\n", by, R.NewPath, err)
+	}
+	if mdr.constraints == nil {
+		mdr.constraints = map[string][]constraintReq{}
+	}
+	mdr.constraints[R.NewPath] = append(mdr.constraints[R.NewPath], constraintReq{By: by, Constraint: c})
+
+	vers, err := mdr.remoteVersions(R.NewPath)
+	if err != nil {
+		return R, fmt.Errorf("While listing versions of %s\n%w\n", R.NewPath, err)
+	}
+
+	min := ""
+	for _, v := range vers {
+		if c.Check(v) && (min == "" || semver.Compare(v, min) < 0) {
+			min = v
+		}
+	}
+	if min == "" {
+		return R, fmt.Errorf("No version of %s satisfies %s, required by %s", R.NewPath, c, by)
+	}
+
+	if R.OldVersion == R.NewVersion {
+		R.OldVersion = min
+	}
+	R.NewVersion = min
+	return R, nil
+}
+
+// remoteVersions lists the semver tags of a module, once per module.
+func (mdr *Modder) remoteVersions(path string) ([]string, error) {
+	if vers, ok := mdr.versions[path]; ok {
+		return vers, nil
+	}
+
+	if cache.Offline() {
+		return mdr.offlineVersions(path)
+	}
+
+	// nested modules are versioned by tags prefixed with their subdirectory
+	remote, owner, rname, subdir := cache.SplitMod(path)
+	prefix := "refs/tags/"
+	if subdir != "" {
+		prefix += subdir + "/"
+	}
+
+	repo, err := git.NewRemote(remote + "/" + owner + "/" + rname)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.RemoteRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var vers []string
+	for _, ref := range refs {
+		name := ref.Name().String()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// tags are fetched by name, so they must already be valid semver
+		ver := strings.TrimPrefix(name, prefix)
+		if semver.IsValid(ver) {
+			vers = append(vers, ver)
+		}
+	}
+
+	if mdr.versions == nil {
+		mdr.versions = map[string][]string{}
+	}
+	mdr.versions[path] = vers
+	return vers, nil
+}
+
+// CheckConstraints reports the selected versions which no longer satisfy a
+// constraint because another module required a greater version.
+func (mdr *Modder) CheckConstraints() error {
+	paths := make([]string, 0, len(mdr.constraints))
+	for path := range mdr.constraints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var err error
+	for _, path := range paths {
+		m, ok := mdr.depsMap[path]
+		if !ok {
+			continue
+		}
+		ver := m.Version
+		if m.ReplaceModule == path && m.ReplaceVersion != "" {
+			ver = m.ReplaceVersion
+		}
+		for _, cr := range mdr.constraints[path] {
+			if !cr.Constraint.Check(ver) {
+				err = fmt.Errorf("Selected %s@%s does not satisfy %s, required by %s", path, ver, cr.Constraint, cr.By)
+				mdr.errors = append(mdr.errors, err)
+			}
+		}
+	}
+
+	return err
+}
+
+// PruneUnreachable removes the dependencies which are only required by
+// versions that MVS did not select, walking the graph from the root module.
+func (mdr *Modder) PruneUnreachable() {
+	reached := map[string]bool{}
+	var walk func(deps map[string]Replace)
+	walk = func(deps map[string]Replace) {
+		for path := range deps {
+			if reached[path] {
+				continue
+			}
+			reached[path] = true
+			if m, ok := mdr.depsMap[path]; ok {
+				walk(m.SelfDeps)
+			}
+		}
+	}
+	walk(mdr.module.SelfDeps)
+
+	for path := range mdr.depsMap {
+		if !reached[path] {
+			delete(mdr.depsMap, path)
+		}
+	}
+}
+
+// resolveFromSum finds the version in the sumfile which satisfies a constraint
+// in the module file, for checking without going to the network.
+func resolveFromSum(sf *sumfile.Sum, R Replace) (Replace, bool) {
+	c, err := modfile.ParseConstraint(R.NewVersion)
+	if err != nil {
+		return R, false
+	}
+
+	found := ""
+	for ver := range sf.Mods {
+		// skip the mod file entries, path/version/lang.mod
+		if ver.Path != R.NewPath || strings.Contains(ver.Version, "/") {
+			continue
+		}
+		if c.Check(ver.Version) && (found == "" || semver.Compare(ver.Version, found) > 0) {
+			found = ver.Version
+		}
+	}
+	if found == "" {
+		return R, false
+	}
+
+	if R.OldVersion == R.NewVersion {
+		R.OldVersion = found
+	}
+	R.NewVersion = found
+	return R, true
+}
@@ -0,0 +1,165 @@
+package modder
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+)
+
+func TestResolveConstraint(t *testing.T) {
+	mdr := &Modder{
+		Name: "cue",
+		versions: map[string][]string{
+			"github.com/test/a": {"v0.9.0", "v1.1.0", "v1.2.0", "v1.3.0-rc.1", "v1.4.1", "v2.0.0"},
+		},
+	}
+
+	tests := []struct {
+		version string
+		expect  string
+		err     string
+	}{
+		{version: "v1.0.0", expect: "v1.0.0"},
+		{version: "^1.2", expect: "v1.2.0"},
+		{version: "^1", expect: "v1.1.0"},
+		{version: "~1.3", err: "No version of github.com/test/a satisfies ~1.3"},
+		{version: "~1.4", expect: "v1.4.1"},
+		{version: "^0.9", expect: "v0.9.0"},
+		{version: "^3", err: "No version of github.com/test/a satisfies ^3, required by root"},
+		{version: "^1.x", err: "In root, requiring github.com/test/a"},
+	}
+
+	for _, tt := range tests {
+		R, err := mdr.ResolveConstraint("root", Replace{NewPath: "github.com/test/a", NewVersion: tt.version})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.version, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.version, err)
+			continue
+		}
+		if R.NewVersion != tt.expect {
+			t.Errorf("%s: got %s, want %s", tt.version, R.NewVersion, tt.expect)
+		}
+	}
+
+	// a replace of the same version resolves both sides
+	R, err := mdr.ResolveConstraint("root", Replace{OldPath: "github.com/test/a", OldVersion: "^1.2", NewPath: "github.com/test/a", NewVersion: "^1.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if R.OldVersion != "v1.2.0" || R.NewVersion != "v1.2.0" {
+		t.Fatalf("expected both versions resolved, got %+v", R)
+	}
+}
+
+func TestCheckConstraints(t *testing.T) {
+	mdr := &Modder{
+		Name: "cue",
+		versions: map[string][]string{
+			"github.com/test/a": {"v1.2.0", "v1.5.0", "v2.0.0"},
+		},
+	}
+	_, err := mdr.ResolveConstraint("github.com/test/b", Replace{NewPath: "github.com/test/a", NewVersion: "^1.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mdr.ResolveConstraint("github.com/test/c", Replace{NewPath: "github.com/test/a", NewVersion: "~1.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// MVS selected a version within both constraints
+	mdr.depsMap = map[string]*Module{"github.com/test/a": {Module: "github.com/test/a", Version: "v1.2.0"}}
+	err = mdr.CheckConstraints()
+	if err != nil || len(mdr.errors) != 0 {
+		t.Fatalf("expected v1.2.0 to satisfy both, got %v", err)
+	}
+
+	// another module required a greater version, which is outside ~1.2
+	mdr.depsMap["github.com/test/a"].Version = "v1.5.0"
+	err = mdr.CheckConstraints()
+	if err == nil || err.Error() != "Selected github.com/test/a@v1.5.0 does not satisfy ~1.2, required by github.com/test/c" {
+		t.Fatalf("expected the ~1.2 constraint to conflict, got %v", err)
+	}
+	if len(mdr.errors) != 1 {
+		t.Fatalf("expected one conflict recorded, got %v", mdr.errors)
+	}
+
+	// a replace is checked at its version
+	mdr.errors = nil
+	mdr.depsMap["github.com/test/a"].ReplaceModule = "github.com/test/a"
+	mdr.depsMap["github.com/test/a"].ReplaceVersion = "v2.0.0"
+	err = mdr.CheckConstraints()
+	if err == nil || len(mdr.errors) != 2 {
+		t.Fatalf("expected v2.0.0 to conflict with both, got %v", mdr.errors)
+	}
+}
+
+func TestPruneUnreachable(t *testing.T) {
+	dep := func(paths ...string) map[string]Replace {
+		deps := map[string]Replace{}
+		for _, p := range paths {
+			deps[p] = Replace{NewPath: p}
+		}
+		return deps
+	}
+
+	// b@v2 was selected over b@v1, which alone required d
+	mdr := &Modder{
+		module: &Module{SelfDeps: dep("a", "b")},
+		depsMap: map[string]*Module{
+			"a": {SelfDeps: dep("c")},
+			"b": {SelfDeps: dep("a")},
+			"c": {SelfDeps: dep("a")},
+			"d": {SelfDeps: dep("e")},
+			"e": {},
+		},
+	}
+	mdr.PruneUnreachable()
+
+	var kept []string
+	for path := range mdr.depsMap {
+		kept = append(kept, path)
+	}
+	sort.Strings(kept)
+	if strings.Join(kept, " ") != "a b c" {
+		t.Fatalf("expected d and e pruned, kept %v", kept)
+	}
+}
+
+func TestResolveFromSum(t *testing.T) {
+	sf := &sumfile.Sum{Mods: map[sumfile.Version][]string{
+		{Path: "github.com/test/a", Version: "v1.2.0"}:          {"h1:a"},
+		{Path: "github.com/test/a", Version: "v1.2.0/cue.mods"}: {"h1:b"},
+		{Path: "github.com/test/a", Version: "v1.3.0"}:          {"h1:c"},
+		{Path: "github.com/test/a", Version: "v2.1.0"}:          {"h1:d"},
+		{Path: "github.com/test/other", Version: "v1.9.0"}:      {"h1:e"},
+		{Path: "github.com/test/a", Version: "v1.4.0-rc.1"}:     {"h1:f"},
+		{Path: "github.com/test/a", Version: "v1.9.0/cue.mods"}: {"h1:g"},
+	}}
+
+	tests := []struct {
+		version string
+		expect  string
+		ok      bool
+	}{
+		{version: "^1.2", expect: "v1.3.0", ok: true},
+		{version: "~1.2", expect: "v1.2.0", ok: true},
+		{version: "^2", expect: "v2.1.0", ok: true},
+		{version: "^1.5", expect: "^1.5"},
+		{version: "v1.2.0", expect: "v1.2.0"},
+	}
+
+	for _, tt := range tests {
+		R, ok := resolveFromSum(sf, Replace{NewPath: "github.com/test/a", NewVersion: tt.version})
+		if ok != tt.ok || R.NewVersion != tt.expect {
+			t.Errorf("%s: got %s, %v, want %s, %v", tt.version, R.NewVersion, ok, tt.expect, tt.ok)
+		}
+	}
+}
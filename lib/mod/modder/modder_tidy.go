@@ -2,6 +2,14 @@ package modder
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
 
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
@@ -29,7 +37,9 @@ func (mdr *Modder) Tidy() error {
 	return nil
 }
 
-// The entrypoint to the MVS internal verify process
+// The entrypoint to the MVS internal tidy process
+// Scans the sources for imports, adds requires for imported modules
+// which are missing, and drops the requires which are not imported.
 func (mdr *Modder) TidyMVS() error {
 
 	// Load minimal root module
@@ -38,5 +48,201 @@ func (mdr *Modder) TidyMVS() error {
 		return err
 	}
 
-	return nil
+	if len(mdr.IntrospectExtractRegex) == 0 {
+		return fmt.Errorf("Tidy is not configured for %s, set IntrospectExtractRegex or CommandTidy", mdr.Name)
+	}
+
+	imports, err := mdr.ScanImports(".")
+	if err != nil {
+		return err
+	}
+
+	f := mdr.module.ModFile
+	changed := false
+
+	// drop unused requires
+	var required []string
+	for _, req := range mdr.module.Require {
+		if !importsModule(imports, req.Path) {
+			fmt.Println("removing", req.Path, req.Version)
+			f.DropRequire(req.Path)
+			changed = true
+			continue
+		}
+		required = append(required, req.Path)
+	}
+
+	// add missing requires
+	added := map[string]bool{}
+	for _, imp := range imports {
+		if !isRemoteImport(imp) || importsModule([]string{imp}, mdr.module.Module) {
+			continue
+		}
+		if modulePath(imp, required) != "" {
+			continue
+		}
+		path := mdr.workspaceModule(imp)
+		if path == "" {
+			path = guessModulePath(imp)
+		}
+		if added[path] {
+			continue
+		}
+		added[path] = true
+
+		ver, err := mdr.tidyVersion(path)
+		if err != nil {
+			mdr.errors = append(mdr.errors, err)
+			continue
+		}
+		fmt.Println("adding", path, ver)
+		err = f.AddRequire(path, ver)
+		if err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if err := mdr.CheckForErrors(); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	f.Cleanup()
+	f.SortBlocks()
+	bytes, err := f.Format()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(mdr.ModFile, bytes, 0644)
+}
+
+// ScanImports returns the sorted import paths found in the files under dir,
+// matched by the introspection globs, with the first group of each
+// introspection regex.
+func (mdr *Modder) ScanImports(dir string) ([]string, error) {
+	var res []*regexp.Regexp
+	for _, expr := range mdr.IntrospectExtractRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Bad IntrospectExtractRegex for %s\n%w\n", mdr.Name, err)
+		}
+		res = append(res, re)
+	}
+
+	found := map[string]bool{}
+	err := filepath.Walk(dir, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, fn)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		include, err := yagu.CheckShouldInclude(rel, mdr.IntrospectIncludeGlobs, mdr.IntrospectExcludeGlobs)
+		if err != nil || !include {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+		for _, re := range res {
+			for _, m := range re.FindAllSubmatch(data, -1) {
+				if len(m) > 1 && len(m[1]) > 0 {
+					found[string(m[1])] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make([]string, 0, len(found))
+	for imp := range found {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports, nil
+}
+
+// latestVersion returns the greatest released tag of a module,
+// or v0.0.0 for the default branch when there are none.
+func (mdr *Modder) latestVersion(path string) (string, error) {
+	vers, err := mdr.remoteVersions(path)
+	if err != nil {
+		return "", fmt.Errorf("While listing versions of %s\n%w\n", path, err)
+	}
+	latest := "v0.0.0"
+	for _, v := range vers {
+		if semver.Prerelease(v) == "" && semver.Compare(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+// tidyVersion returns the version to require a module at, preferring the one
+// selected for the workspace, or v0.0.0 for its modules, which are replaced.
+func (mdr *Modder) tidyVersion(path string) (string, error) {
+	if ws := mdr.workspace; ws != nil {
+		if _, ok := ws.Modules[path]; ok {
+			return "v0.0.0", nil
+		}
+		if v, ok := ws.Versions[path]; ok {
+			return v, nil
+		}
+	}
+	return mdr.latestVersion(path)
+}
+
+// importsModule reports whether any of the imports is in the module path.
+func importsModule(imports []string, path string) bool {
+	for _, imp := range imports {
+		if imp == path || strings.HasPrefix(imp, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// modulePath returns the longest of the modules which contains imp.
+func modulePath(imp string, modules []string) string {
+	found := ""
+	for _, m := range modules {
+		if importsModule([]string{imp}, m) && len(m) > len(found) {
+			found = m
+		}
+	}
+	return found
+}
+
+// isRemoteImport reports whether imp starts with a domain,
+// rather than being from the standard library.
+func isRemoteImport(imp string) bool {
+	first := strings.SplitN(imp, "/", 2)[0]
+	return strings.Contains(first, ".")
+}
+
+// guessModulePath returns domain/owner/repo for an import
+// which is not in any required module.
+func guessModulePath(imp string) string {
+	flds := strings.Split(imp, "/")
+	if len(flds) > 3 {
+		flds = flds[:3]
+	}
+	return strings.Join(flds, "/")
 }
@@ -0,0 +1,86 @@
+package modder
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestScanImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-tidy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"a.cue": `package a
+
+import "github.com/test/a/b"
+`,
+		"sub/b.cue": `package b
+
+import (
+	"strings"
+	x "github.com/test/c/d/e"
+	"github.com/test/a/b"
+)
+`,
+		// not matched by the globs
+		"cue.mod/pkg/github.com/test/z/z.cue": `import "github.com/test/z"`,
+		"notes.txt":                           `import "github.com/test/y"`,
+		".git/x.cue":                          `import "github.com/test/x"`,
+	})
+
+	// the cue language defaults
+	mdr := &Modder{
+		Name:                   "cue",
+		IntrospectIncludeGlobs: []string{"**/*.cue"},
+		IntrospectExcludeGlobs: []string{"cue.mod/**"},
+		IntrospectExtractRegex: []string{`(?m)^\s*(?:import\s+)?(?:[\w#]+\s+)?"([\w.~/-]+)"\s*$`},
+	}
+	imports, err := mdr.ScanImports(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"github.com/test/a/b", "github.com/test/c/d/e", "strings"}
+	if !reflect.DeepEqual(imports, expect) {
+		t.Fatalf("got %v, want %v", imports, expect)
+	}
+
+	mdr.IntrospectExtractRegex = []string{`(`}
+	_, err = mdr.ScanImports(dir)
+	if err == nil {
+		t.Fatal("expected a bad regex to fail")
+	}
+}
+
+func TestTidyImports(t *testing.T) {
+	required := []string{"github.com/test/a", "github.com/test/a/b", "github.com/test/c"}
+
+	tests := []struct {
+		imp    string
+		remote bool
+		module string
+		guess  string
+	}{
+		{imp: "strings", guess: "strings"},
+		{imp: "encoding/json", guess: "encoding/json"},
+		{imp: "github.com/test/a", remote: true, module: "github.com/test/a", guess: "github.com/test/a"},
+		{imp: "github.com/test/a/b/c", remote: true, module: "github.com/test/a/b", guess: "github.com/test/a"},
+		{imp: "github.com/test/ab", remote: true, guess: "github.com/test/ab"},
+		{imp: "github.com/test/d/e/f", remote: true, guess: "github.com/test/d"},
+	}
+	for _, tt := range tests {
+		if got := isRemoteImport(tt.imp); got != tt.remote {
+			t.Errorf("%s: isRemoteImport got %v", tt.imp, got)
+		}
+		if got := modulePath(tt.imp, required); got != tt.module {
+			t.Errorf("%s: modulePath got %q, want %q", tt.imp, got, tt.module)
+		}
+		if got := guessModulePath(tt.imp); got != tt.guess {
+			t.Errorf("%s: guessModulePath got %q, want %q", tt.imp, got, tt.guess)
+		}
+	}
+}
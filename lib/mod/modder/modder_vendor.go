@@ -6,7 +6,9 @@ import (
 
 	"github.com/go-git/go-billy/v5/osfs"
 
+	"github.com/hofstadter-io/hof/lib/errs"
 	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/sumdb"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
@@ -67,6 +69,21 @@ func (mdr *Modder) Vendor() error {
 
 // The entrypoint to the MVS internal vendoring process
 func (mdr *Modder) VendorMVS() error {
+	err := mdr.ResolveMVS()
+	if err != nil {
+		return err
+	}
+
+	// Finally, write out anything that needs to be
+	err = mdr.WriteVendor()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResolveMVS loads the root module and selects the versions of its dependencies
+func (mdr *Modder) ResolveMVS() error {
 	var err error
 
 	// Load minimal root module
@@ -76,22 +93,30 @@ func (mdr *Modder) VendorMVS() error {
 		return err
 	}
 	for _, R := range mdr.module.SelfDeps {
-		err := mdr.VendorDep(R)
+		req := R
+		R, err := mdr.resolveDep(mdr.module.Module, R)
+		if err != nil {
+			mdr.errors = append(mdr.errors, err)
+			continue
+		}
+		mdr.recordEdge(mdr.module, req, R)
+		err = mdr.VendorDep(R)
 		if err != nil {
 			mdr.errors = append(mdr.errors, err)
 		}
 	}
 
-	if err := mdr.CheckForErrors(); err != nil {
-		return err
+	if missing := mdr.takeMissing(); len(missing) > 0 {
+		err := fmt.Errorf("Offline, and these modules are in neither the module cache nor %s:\n  %s\nRun with network access to fetch them.", mdr.ModsDir, strings.Join(missing, "\n  "))
+		return errs.WithCode(errs.CodeModOffline, "", err)
 	}
 
-	// Finally, write out anything that needs to be
-	err = mdr.WriteVendor()
-	if err != nil {
-		return err
-	}
-	return nil
+	// Drop what only unselected versions required,
+	// then check the selected versions against any constraints
+	mdr.PruneUnreachable()
+	mdr.CheckConstraints()
+
+	return mdr.CheckForErrors()
 }
 
 func (mdr *Modder) VendorDep(R Replace) error {
@@ -147,11 +172,19 @@ func (mdr *Modder) LoadRemoteModule(R Replace) error {
 	}
 
 	err := cache.Fetch(mdr.Name, R.NewPath, R.NewVersion)
+	if err == nil {
+		m.FS, err = cache.Load(mdr.Name, R.NewPath, R.NewVersion)
+	} else if cache.IsMissing(err) {
+		// offline, fall back to an intact vendored copy
+		if mdr.module.SumFile != nil && mdr.CompareSumEntryToVendor(R) == nil {
+			m.FS, err = mdr.LoadVendored(R)
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	m.FS, err = cache.Load(mdr.Name, R.NewPath, R.NewVersion)
+	err = verifySumDB(R, m)
 	if err != nil {
 		return err
 	}
@@ -173,6 +206,22 @@ func (mdr *Modder) LoadRemoteModule(R Replace) error {
 	return nil
 }
 
+// verifySumDB checks a fetched module against the checksum database
+// configured for its path in $HOF_MOD_SUMDB, if any.
+func verifySumDB(R Replace, m *Module) error {
+	P, err := sumdb.For(R.NewPath)
+	if err != nil || P == nil {
+		return err
+	}
+
+	dirhash, err := yagu.BillyCalcHash(m.FS)
+	if err != nil {
+		return fmt.Errorf("While calculating the dirhash of %s@%s\n%w\n", R.NewPath, R.NewVersion, err)
+	}
+
+	return P.Verify(R.NewPath, R.NewVersion, dirhash)
+}
+
 func (mdr *Modder) LoadLocalReplace(R Replace) error {
 	// fmt.Printf("LoadLocalReplace %#+v\n", R)
 	var err error
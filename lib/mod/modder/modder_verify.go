@@ -2,7 +2,15 @@ package modder
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
 
+	"github.com/go-git/go-billy/v5/osfs"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
@@ -62,6 +70,13 @@ func (mdr *Modder) VerifyMVS() error {
 			valid = false
 			mdr.errors = append(mdr.errors, err)
 		}
+
+		err = mdr.CompareSumEntryToCache(R)
+		// The content changed under the version
+		if err != nil {
+			valid = false
+			mdr.errors = append(mdr.errors, err)
+		}
 	}
 
 	for _, p := range local {
@@ -81,3 +96,137 @@ func (mdr *Modder) VerifyMVS() error {
 	// We are OK!
 	return nil
 }
+
+// CompareSumEntryToCache checks the cached copy of a module, when there is one,
+// against its sumfile entry. A mismatch means the content changed under the tag,
+// and the files which differ from the vendored copy are listed.
+func (mdr *Modder) CompareSumEntryToCache(R Replace) error {
+	sf := mdr.module.SumFile
+
+	ver := sumfile.Version{
+		Path:    R.NewPath,
+		Version: R.NewVersion,
+	}
+	d, ok := sf.Mods[ver]
+	if !ok {
+		// reported as missing from the sumfile
+		return nil
+	}
+	sumDirhash := d[0]
+
+	cacheDirhash, err := cache.Checksum(mdr.Name, R.NewPath, R.NewVersion)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// not cached, nothing to compare
+			return nil
+		}
+		return fmt.Errorf("While calculating cache dirhash for '%v'\n%w\n", ver, err)
+	}
+
+	if cacheDirhash == sumDirhash {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Cached %s@%s does not match the sumfile, its content changed under the version\n  sumfile: %s\n  cache:   %s\n", R.NewPath, R.NewVersion, sumDirhash, cacheDirhash)
+
+	rpath := R.OldPath
+	if R.OldPath == "" {
+		rpath = R.NewPath
+	}
+	VFS := osfs.New(path.Join(mdr.ModsDir, rpath))
+	CFS, err := cache.Load(mdr.Name, R.NewPath, R.NewVersion)
+	if err == nil {
+		if diff, err := diffFS(VFS, CFS); err == nil {
+			msg += "cache changes from vendor:\n" + formatDiff(diff)
+		}
+	}
+
+	return fmt.Errorf("%s", msg)
+}
+
+// VendorCheck verifies that the vendor dir matches the sumfile without changing either,
+// for gating CI. Every module in the sumfile must be vendored with its recorded hashes,
+// at a single version, along with the local replaces, every require in the mod file must
+// be in the sumfile, and nothing else may be vendored. Nothing is fetched.
+func (mdr *Modder) VendorCheck() error {
+	if len(mdr.CommandVendor) > 0 {
+		return fmt.Errorf("%s is vendored with CommandVendor, which cannot be checked", mdr.Name)
+	}
+	if mdr.NoLoad {
+		return fmt.Errorf("%s modules are not loaded by hof mod", mdr.Name)
+	}
+
+	err := mdr.VendorCheckMVS()
+	if err != nil {
+		mdr.PrintErrors()
+		return err
+	}
+
+	return nil
+}
+
+// The entrypoint to the MVS internal vendor check process
+func (mdr *Modder) VendorCheckMVS() error {
+	err := mdr.LoadMetaFromFS(".")
+	if err != nil {
+		return err
+	}
+
+	_, missing, local, err := mdr.PartitionSumEntries()
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(missing)
+	for _, m := range missing {
+		R := mdr.module.SelfDeps[m]
+		mdr.errors = append(mdr.errors, fmt.Errorf("Sumfile missing: %s@%s", R.NewPath, R.NewVersion))
+	}
+
+	// appends its own errors
+	sort.Strings(local)
+	for _, p := range local {
+		mdr.CompareLocalReplaceToVendor(mdr.module.SelfDeps[p])
+	}
+
+	// the module versions, without the mod file hashes and branch commits
+	var vers []sumfile.Version
+	for ver := range mdr.module.SumFile.Mods {
+		if !strings.Contains(ver.Version, "/") {
+			vers = append(vers, ver)
+		}
+	}
+	sort.Slice(vers, func(i, j int) bool {
+		if vers[i].Path == vers[j].Path {
+			return vers[i].Version < vers[j].Version
+		}
+		return vers[i].Path < vers[j].Path
+	})
+
+	keep := map[string]bool{}
+	for _, ver := range vers {
+		if keep[ver.Path] {
+			mdr.errors = append(mdr.errors, fmt.Errorf("Sumfile has more than one version of %s", ver.Path))
+			continue
+		}
+		keep[ver.Path] = true
+
+		err := mdr.CompareSumEntryToVendor(Replace{NewPath: ver.Path, NewVersion: ver.Version})
+		if err != nil {
+			mdr.errors = append(mdr.errors, err)
+		}
+	}
+
+	stray, err := mdr.strayVendored(keep)
+	if err != nil {
+		return err
+	}
+	for _, rel := range stray {
+		mdr.errors = append(mdr.errors, fmt.Errorf("Vendored but not in the sumfile: %s", path.Join(mdr.ModsDir, rel)))
+	}
+
+	if len(mdr.errors) > 0 {
+		return fmt.Errorf("The vendor dir %s does not match %s, run 'hof mod vendor %s'", mdr.ModsDir, mdr.SumFile, mdr.Name)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package modder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(fn), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(fn, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiffFS(t *testing.T) {
+	a, b := memfs.New(), memfs.New()
+	for fn, content := range map[string]string{"same.cue": "x", "changed.cue": "1", "removed.cue": "r", ".git/HEAD": "a"} {
+		util.WriteFile(a, fn, []byte(content), 0644)
+	}
+	for fn, content := range map[string]string{"same.cue": "x", "changed.cue": "2", "added/new.cue": "n", ".git/HEAD": "b"} {
+		util.WriteFile(b, fn, []byte(content), 0644)
+	}
+
+	diff, err := diffFS(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"+ /added/new.cue", "~ /changed.cue", "- /removed.cue"}
+	if !reflect.DeepEqual(diff, expect) {
+		t.Fatalf("got %q, want %q", diff, expect)
+	}
+	if got := formatDiff(nil); got != "  (no file differences)\n" {
+		t.Fatalf("got %q for no differences", got)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := cache.LocalCacheBaseDir
+	cache.SetBaseDir(filepath.Join(dir, "cache"))
+	defer cache.SetBaseDir(oldBase)
+
+	mod, ver := "github.com/test/a", "v1.0.0"
+	files := map[string]string{
+		"cue.mods":         "module github.com/test/a\n\ncue v0.2.2\n",
+		"schema/user.cue":  "package schema\n\nUser: name: string\n",
+		"schema/other.cue": "package schema\n",
+	}
+	cached := cache.ModDir("cue", mod, ver)
+	vendored := filepath.Join(dir, "cue.mod", "pkg", mod)
+	writeFiles(t, cached, files)
+	writeFiles(t, vendored, files)
+
+	dirhash, err := yagu.BillyCalcHash(osfs.New(cached))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modhash, err := yagu.BillyCalcFileHash("cue.mods", osfs.New(cached))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mdr := &Modder{
+		Name:    "cue",
+		ModFile: "cue.mods",
+		ModsDir: filepath.Join(dir, "cue.mod", "pkg"),
+		module: &Module{SumFile: &sumfile.Sum{Mods: map[sumfile.Version][]string{
+			{Path: mod, Version: ver}:               {dirhash},
+			{Path: mod, Version: ver + "/cue.mods"}: {modhash},
+		}}},
+	}
+	R := Replace{NewPath: mod, NewVersion: ver}
+
+	err = mdr.CompareSumEntryToVendor(R)
+	if err != nil {
+		t.Fatalf("expected the vendored copy to match, got %v", err)
+	}
+	err = mdr.CompareSumEntryToCache(R)
+	if err != nil {
+		t.Fatalf("expected the cached copy to match, got %v", err)
+	}
+
+	// the vendored copy was edited, the cache shows what changed
+	writeFiles(t, vendored, map[string]string{"schema/user.cue": "package schema\n\nUser: name: int\n", "extra.cue": "package a\n"})
+	err = mdr.CompareSumEntryToVendor(R)
+	if err == nil {
+		t.Fatal("expected the tampered vendored copy to fail")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "Errors with vendor integrity for github.com/test/a@v1.0.0") ||
+		!strings.Contains(msg, "vendor changes from cache:\n  + /extra.cue\n  ~ /schema/user.cue\n") {
+		t.Fatalf("expected the vendor changes, got:\n%s", msg)
+	}
+
+	// the content changed under the version in the cache, as when a tag is moved
+	writeFiles(t, cached, map[string]string{"schema/user.cue": "package schema\n\nUser: name: int\n"})
+	os.Remove(filepath.Join(cached, "schema", "other.cue"))
+	err = mdr.CompareSumEntryToCache(R)
+	if err == nil {
+		t.Fatal("expected the changed cache to fail")
+	}
+	msg = err.Error()
+	if !strings.Contains(msg, "Cached github.com/test/a@v1.0.0 does not match the sumfile") ||
+		!strings.Contains(msg, "cache changes from vendor:\n  - /extra.cue\n  - /schema/other.cue\n") {
+		t.Fatalf("expected the cache changes, got:\n%s", msg)
+	}
+
+	// without a cached copy, there is nothing to compare
+	os.RemoveAll(cached)
+	err = mdr.CompareSumEntryToCache(R)
+	if err != nil {
+		t.Fatalf("expected no error without a cached copy, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package modder
+
+import (
+	"fmt"
+)
+
+// Why prints the shortest chain of requirements
+// from the root module to each of the modules.
+func (mdr *Modder) Why(modules []string) error {
+	err := mdr.ResolveMVS()
+	if err != nil {
+		mdr.PrintErrors()
+		return err
+	}
+
+	G := mdr.BuildGraph()
+	for i, mod := range modules {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println("#", mod)
+
+		chain := G.Why(mod)
+		if chain == nil {
+			fmt.Printf("(%s does not need module %s)\n", G.Root, mod)
+			continue
+		}
+		for _, link := range chain {
+			fmt.Println(link)
+		}
+	}
+
+	return nil
+}
+
+// Why returns the shortest chain of requirements from the root to mod,
+// as the selected module versions along the way, or nil when mod is not in the graph.
+func (G *ModGraph) Why(mod string) []string {
+	selected := map[string]string{}
+	for _, n := range G.Nodes {
+		selected[n.Module] = n.Version
+	}
+	if _, ok := selected[mod]; !ok {
+		return nil
+	}
+
+	// breadth first from the root, so the first chain found is the shortest
+	prev := map[string]string{G.Root: ""}
+	queue := []string{G.Root}
+	for len(queue) > 0 && prev[mod] == "" {
+		from := queue[0]
+		queue = queue[1:]
+		for _, e := range G.Edges {
+			if e.From != from {
+				continue
+			}
+			if _, ok := prev[e.To]; ok {
+				continue
+			}
+			prev[e.To] = from
+			queue = append(queue, e.To)
+		}
+	}
+	if _, ok := prev[mod]; !ok {
+		return nil
+	}
+
+	var chain []string
+	for m := mod; m != G.Root; m = prev[m] {
+		chain = append([]string{nodeName(m, selected[m])}, chain...)
+	}
+	return append([]string{G.Root}, chain...)
+}
@@ -0,0 +1,42 @@
+package modder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhy(t *testing.T) {
+	// root requires a and c, a requires b, both b and c require d
+	G := &ModGraph{
+		Root: "root",
+		Nodes: []GraphNode{
+			{Module: "a", Version: "v1.0.0"},
+			{Module: "b", Version: "v1.0.0"},
+			{Module: "c", Version: "v0.2.0"},
+			{Module: "d", Version: "v0.3.0"},
+			{Module: "e", Version: "v0.1.0"},
+		},
+		Edges: []Edge{
+			{From: "a", FromVersion: "v1.0.0", To: "b", Version: "v1.0.0"},
+			{From: "b", FromVersion: "v1.0.0", To: "d", Version: "v0.3.0"},
+			{From: "c", FromVersion: "v0.2.0", To: "d", Version: "v0.1.0"},
+			{From: "root", To: "a", Version: "v1.0.0"},
+			{From: "root", To: "c", Version: "v0.2.0"},
+		},
+	}
+
+	tests := map[string][]string{
+		"a": {"root", "a@v1.0.0"},
+		"b": {"root", "a@v1.0.0", "b@v1.0.0"},
+		// the shortest chain, with the selected versions along it
+		"d": {"root", "c@v0.2.0", "d@v0.3.0"},
+		// selected, but nothing requires it
+		"e": nil,
+		"f": nil,
+	}
+	for mod, expect := range tests {
+		if got := G.Why(mod); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: got %q, want %q", mod, got, expect)
+		}
+	}
+}
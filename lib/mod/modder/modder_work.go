@@ -0,0 +1,154 @@
+package modder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Workspace is a set of local modules resolved together, as listed in a hof.work file.
+// Requirements on the modules of the workspace are replaced by their directories,
+// and every module uses the greatest version of a dependency selected by any of them.
+type Workspace struct {
+	// Modules maps the module paths of the workspace to their absolute directories
+	Modules map[string]string
+
+	// Versions are the versions selected across the workspace
+	Versions map[string]string
+}
+
+func NewWorkspace() *Workspace {
+	return &Workspace{
+		Modules:  map[string]string{},
+		Versions: map[string]string{},
+	}
+}
+
+// Add loads the module in the working directory into the workspace,
+// taking the versions it requires as the starting selection.
+func (ws *Workspace) Add(mdr *Modder) (string, error) {
+	path, err := mdr.ModulePath()
+	if err != nil {
+		return "", err
+	}
+	if dir, ok := ws.Modules[path]; ok {
+		return "", fmt.Errorf("Module %s is in the workspace twice, at %s and the working directory", path, dir)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	ws.Modules[path] = dir
+	delete(ws.Versions, path)
+
+	for _, req := range mdr.module.Require {
+		if _, ok := ws.Modules[req.Path]; ok || !semver.IsValid(req.Version) {
+			continue
+		}
+		if semver.Compare(req.Version, ws.Versions[req.Path]) > 0 {
+			ws.Versions[req.Path] = req.Version
+		}
+	}
+	return path, nil
+}
+
+// ForWorkspace returns a copy of the modder for resolving a module of ws,
+// without the state of any earlier resolution.
+func (mdr *Modder) ForWorkspace(ws *Workspace) *Modder {
+	m := *mdr
+	m.module = nil
+	m.errors = nil
+	m.depsMap = nil
+	m.constraints = nil
+	m.edges = nil
+	m.workspace = ws
+	return &m
+}
+
+// Select raises the workspace versions to those selected by mdr,
+// reporting whether any changed, in which case the modules
+// resolved earlier need to be resolved again.
+func (ws *Workspace) Select(mdr *Modder) bool {
+	changed := false
+	for path, m := range mdr.depsMap {
+		// only plain requirements, replaces are local to a module
+		if m.ReplaceModule != m.Module || !semver.IsValid(m.Version) {
+			continue
+		}
+		if _, ok := ws.Modules[path]; ok {
+			continue
+		}
+		if semver.Compare(m.Version, ws.Versions[path]) > 0 {
+			ws.Versions[path] = m.Version
+			changed = true
+		}
+	}
+	return changed
+}
+
+// resolveDep resolves a requirement of by, within the workspace if any.
+func (mdr *Modder) resolveDep(by string, R Replace) (Replace, error) {
+	R = mdr.applyWorkspace(R)
+	R, err := mdr.ResolveConstraint(by, R)
+	if err != nil {
+		return R, err
+	}
+	return mdr.applyWorkspace(R), nil
+}
+
+// applyWorkspace replaces a requirement on a module of the workspace with its directory,
+// and raises the version of any other requirement to the one selected for the workspace.
+// Replace directives of the module are kept as they are.
+func (mdr *Modder) applyWorkspace(R Replace) Replace {
+	ws := mdr.workspace
+	if ws == nil || R.OldPath != "" {
+		return R
+	}
+
+	if dir, ok := ws.Modules[R.NewPath]; ok {
+		return Replace{
+			OldPath:    R.NewPath,
+			OldVersion: R.NewVersion,
+			NewPath:    relDir(dir),
+		}
+	}
+
+	if v, ok := ws.Versions[R.NewPath]; ok && semver.IsValid(R.NewVersion) && semver.Compare(v, R.NewVersion) > 0 {
+		R.NewVersion = v
+	}
+	return R
+}
+
+// relDir returns dir relative to the working directory,
+// starting with ./ or ../ as local replaces do.
+func relDir(dir string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil {
+		return dir
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// workspaceModule returns the module of the workspace which contains imp, if any.
+func (mdr *Modder) workspaceModule(imp string) string {
+	if mdr.workspace == nil {
+		return ""
+	}
+	var paths []string
+	for path := range mdr.workspace.Modules {
+		paths = append(paths, path)
+	}
+	return modulePath(imp, paths)
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
 	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
@@ -29,17 +32,31 @@ var (
 )
 
 func (mdr *Modder) WriteVendor() error {
-	// fmt.Println("Writing Vendor from scratch")
-	os.RemoveAll(mdr.ModsDir)
+	// remove what is no longer required, the selected modules are written again
+	keep := map[string]bool{}
+	for path := range mdr.depsMap {
+		keep[path] = true
+	}
+	err := mdr.pruneVendor(keep)
+	if err != nil {
+		return err
+	}
 
 	// make vendor dir if not present
-	err := yagu.Mkdir(mdr.ModsDir)
+	err = yagu.Mkdir(mdr.ModsDir)
 	if err != nil {
 		return err
 	}
 
-	// write out each dep
-	for _, m := range mdr.depsMap {
+	// write out each dep, enclosing modules before those nested within them
+	paths := make([]string, 0, len(mdr.depsMap))
+	for path := range mdr.depsMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		m := mdr.depsMap[path]
 		// fmt.Printf("  writing: %#+v\n", m)
 		// XXX, this only (?) happens with local replaces with no matching require entry
 		if m.Version == "" {
@@ -74,7 +91,15 @@ func (mdr *Modder) WriteVendor() error {
 		}
 		mdr.module.SumFile.Add(mver, modhash)
 
-		baseDir := path.Join(mdr.ModsDir, m.Module)
+		// branches move, so their entries are replaced, along with the commit they were fetched at
+		if _, ok := modfile.VersionBranch(m.Version); ok {
+			mdr.module.SumFile.Set(dver, dirhash)
+			mdr.module.SumFile.Set(mver, modhash)
+			mdr.recordBranchCommit(m)
+		}
+
+		baseDir := filepath.Join(mdr.ModsDir, m.Module)
+		os.RemoveAll(baseDir)
 
 		// fmt.Printf("Writing %-48s => %s\n", m.ReplaceModule + "@" + m.ReplaceVersion, baseDir)
 
@@ -121,6 +146,7 @@ func (mdr *Modder) WriteVendor() error {
 	if mdr.module.SumFile == nil {
 		mdr.module.SumFile = &sumfile.Sum{}
 	}
+	mdr.pruneSum()
 
 	// Write sumfile
 	out, err := mdr.module.SumFile.Write()
@@ -132,3 +158,27 @@ func (mdr *Modder) WriteVendor() error {
 
 	return nil
 }
+
+// recordBranchCommit records the commit a branch was fetched at in the sum file,
+// as path @branch/commit <sha>, so the exact content vendored can be found again.
+func (mdr *Modder) recordBranchCommit(m *Module) {
+	mod, ver := m.Module, m.Version
+	if m.ReplaceModule != "" {
+		mod, ver = m.ReplaceModule, m.ReplaceVersion
+	}
+
+	// vendored copies loaded while offline keep the commit already recorded
+	commit, err := cache.BranchCommit(mdr.Name, mod, ver)
+	if err != nil {
+		return
+	}
+
+	cver := sumfile.Version{
+		Path:    m.Module,
+		Version: m.Version + "/commit",
+	}
+	if old := mdr.module.SumFile.Mods[cver]; len(old) > 0 && old[0] != commit {
+		fmt.Printf("%s %s moved %.12s => %.12s\n", m.Module, m.Version, old[0], commit)
+	}
+	mdr.module.SumFile.Set(cver, commit)
+}
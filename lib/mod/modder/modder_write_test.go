@@ -0,0 +1,62 @@
+package modder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/mod/parse/sumfile"
+)
+
+func TestRecordBranchCommit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-mod-branch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldBase := cache.LocalCacheBaseDir
+	cache.SetBaseDir(filepath.Join(dir, "cache"))
+	defer cache.SetBaseDir(oldBase)
+
+	mod, ver := "github.com/test/a", "@main"
+	cver := sumfile.Version{Path: mod, Version: ver + "/commit"}
+	commit := func(c string) {
+		writeFiles(t, filepath.Dir(cache.ModDir("cue", mod, ver)), map[string]string{
+			filepath.Base(cache.ModDir("cue", mod, ver)) + ".commit": c + "\n",
+		})
+	}
+
+	mdr := &Modder{Name: "cue", module: &Module{SumFile: &sumfile.Sum{}}}
+	M := &Module{Module: mod, Version: ver}
+
+	// without a fetched commit, as when vendored offline, nothing is recorded
+	mdr.recordBranchCommit(M)
+	if len(mdr.module.SumFile.Mods) != 0 {
+		t.Fatalf("expected nothing recorded, got %v", mdr.module.SumFile.Mods)
+	}
+
+	commit("1111111111111111111111111111111111111111")
+	mdr.recordBranchCommit(M)
+	if got := mdr.module.SumFile.Mods[cver]; len(got) != 1 || got[0] != "1111111111111111111111111111111111111111" {
+		t.Fatalf("expected the commit recorded, got %v", got)
+	}
+
+	// a moved branch replaces the commit, rather than adding to it
+	commit("2222222222222222222222222222222222222222")
+	mdr.recordBranchCommit(M)
+	if got := mdr.module.SumFile.Mods[cver]; len(got) != 1 || got[0] != "2222222222222222222222222222222222222222" {
+		t.Fatalf("expected the new commit only, got %v", got)
+	}
+
+	out, err := mdr.module.SumFile.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "github.com/test/a @main/commit 2222222222222222222222222222222222222222\n") {
+		t.Fatalf("expected the commit in the sum file, got:\n%s", out)
+	}
+}
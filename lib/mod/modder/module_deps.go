@@ -3,6 +3,8 @@ package modder
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 func (mod *Module) PrintSelfDeps() error {
@@ -15,7 +17,7 @@ func (mod *Module) PrintSelfDeps() error {
 
 func (mod *Module) LoadSelfDeps() error {
 	for path, R := range mod.SelfDeps {
-		fmt.Println("   ", path, "~", R.OldPath, R.OldVersion, "=>", R.NewPath, R.NewVersion)
+		logs.Scope("mod").Debug("loading self dep", "path", path, "old", R.OldPath+"@"+R.OldVersion, "new", R.NewPath+"@"+R.NewVersion)
 
 		// create a module first
 
@@ -44,7 +46,7 @@ func (mod *Module) LoadSelfDeps() error {
 			}
 		*/
 		if strings.HasPrefix(R.NewPath, "./") || strings.HasPrefix(R.NewPath, "../") {
-			fmt.Println("Local Replace:", R.OldPath, R.OldVersion, "=>", R.NewPath, R.NewVersion)
+			logs.Scope("mod").Debug("local replace", "old", R.OldPath+"@"+R.OldVersion, "new", R.NewPath+"@"+R.NewVersion)
 			// is it git or not?
 
 			return nil
@@ -0,0 +1,91 @@
+package modfile
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// A Constraint is a semver range in a require statement,
+// ^1.2 allows v1.2.0 up to but not including v2.0.0,
+// and ~0.3 allows v0.3.0 up to but not including v0.4.0
+type Constraint struct {
+	// Text is the constraint as written, such as ^1.2
+	Text string
+
+	// Min is the lowest allowed version, Max the first version not allowed
+	Min string
+	Max string
+}
+
+// IsConstraint reports whether s is written as a constraint rather than a version.
+func IsConstraint(s string) bool {
+	return strings.HasPrefix(s, "^") || strings.HasPrefix(s, "~")
+}
+
+// ParseConstraint parses a ^ or ~ constraint on a (possibly partial) version.
+func ParseConstraint(s string) (*Constraint, error) {
+	if !IsConstraint(s) {
+		return nil, fmt.Errorf("invalid constraint %q, must start with ^ or ~", s)
+	}
+	op, ver := s[0], s[1:]
+	if !strings.HasPrefix(ver, "v") {
+		ver = "v" + ver
+	}
+	if !semver.IsValid(ver) || semver.Prerelease(ver) != "" || semver.Build(ver) != "" {
+		return nil, fmt.Errorf("invalid constraint %q, must be of the form ^1.2 or ~1.2.3", s)
+	}
+
+	// the parts which were given, missing parts are zero
+	parts := strings.Split(ver[1:], ".")
+	nums := make([]int, 3)
+	for i, p := range parts {
+		fmt.Sscanf(p, "%d", &nums[i])
+	}
+	min := fmt.Sprintf("v%d.%d.%d", nums[0], nums[1], nums[2])
+
+	// the part which is bumped for the upper bound
+	bump := 0
+	switch op {
+	case '^':
+		// the left most non-zero part, or the last part given
+		bump = len(parts) - 1
+		for i := 0; i < len(parts); i++ {
+			if nums[i] != 0 {
+				bump = i
+				break
+			}
+		}
+	case '~':
+		// the minor version, or the major when only it is given
+		if len(parts) > 1 {
+			bump = 1
+		}
+	}
+	max := make([]int, 3)
+	copy(max, nums[:bump])
+	max[bump] = nums[bump] + 1
+
+	return &Constraint{
+		Text: s,
+		Min:  min,
+		Max:  fmt.Sprintf("v%d.%d.%d", max[0], max[1], max[2]),
+	}, nil
+}
+
+// Check reports whether ver satisfies the constraint.
+// Prereleases never do, they must be required exactly.
+func (c *Constraint) Check(ver string) bool {
+	if !semver.IsValid(ver) {
+		return false
+	}
+	if semver.Prerelease(ver) != "" {
+		return false
+	}
+	return semver.Compare(ver, c.Min) >= 0 && semver.Compare(ver, c.Max) < 0
+}
+
+func (c *Constraint) String() string {
+	return c.Text
+}
@@ -0,0 +1,84 @@
+package modfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		text string
+		min  string
+		max  string
+		err  string
+	}{
+		{text: "^1.2.3", min: "v1.2.3", max: "v2.0.0"},
+		{text: "^1.2", min: "v1.2.0", max: "v2.0.0"},
+		{text: "^v1", min: "v1.0.0", max: "v2.0.0"},
+		{text: "^0.3.1", min: "v0.3.1", max: "v0.4.0"},
+		{text: "^0.0.4", min: "v0.0.4", max: "v0.0.5"},
+		{text: "^0.0", min: "v0.0.0", max: "v0.1.0"},
+		{text: "^0", min: "v0.0.0", max: "v1.0.0"},
+		{text: "~1.2.3", min: "v1.2.3", max: "v1.3.0"},
+		{text: "~1.2", min: "v1.2.0", max: "v1.3.0"},
+		{text: "~1", min: "v1.0.0", max: "v2.0.0"},
+		{text: "~0.3", min: "v0.3.0", max: "v0.4.0"},
+		{text: "1.2.3", err: "must start with ^ or ~"},
+		{text: ">=1.2", err: "must start with ^ or ~"},
+		{text: "^", err: "must be of the form"},
+		{text: "^1.x", err: "must be of the form"},
+		{text: "^1.2.3.4", err: "must be of the form"},
+		{text: "~1.2.3-beta", err: "must be of the form"},
+		{text: "^1.2.3+build", err: "must be of the form"},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.text)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.text, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if c.Min != tt.min || c.Max != tt.max || c.String() != tt.text {
+			t.Errorf("%s: got [%s, %s), want [%s, %s)", tt.text, c.Min, c.Max, tt.min, tt.max)
+		}
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		text string
+		ver  string
+		ok   bool
+	}{
+		{"^1.2", "v1.2.0", true},
+		{"^1.2", "v1.9.9", true},
+		{"^1.2", "v1.1.9", false},
+		{"^1.2", "v2.0.0", false},
+		{"^1.2", "v1.3.0-rc.1", false},
+		{"^0.3.1", "v0.3.5", true},
+		{"^0.3.1", "v0.4.0", false},
+		{"~1.2.3", "v1.2.9", true},
+		{"~1.2.3", "v1.3.0", false},
+		{"~1.2.3", "v1.2.2", false},
+		{"~1", "v1.8.0", true},
+		{"^1", "1.2.0", false},
+		{"^1", "main", false},
+		{"^0", "v0.0.0-20200101120000-abcdef123456", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.text)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		if ok := c.Check(tt.ver); ok != tt.ok {
+			t.Errorf("%s: check %s got %v, want %v", tt.text, tt.ver, ok, tt.ok)
+		}
+	}
+}
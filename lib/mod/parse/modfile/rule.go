@@ -225,6 +225,24 @@ func (f *File) add(errs *bytes.Buffer, line *Line, verb string, args []string, f
 			fmt.Fprintf(errs, "%s:%d: invalid quoted string: %v\n", f.Syntax.Name, line.Start.Line, err)
 			return
 		}
+		// requirements may be semver constraints, resolved to a version when vendoring,
+		// commit hashes, written bare or as @<sha>, or branches, written as @<branch>
+		if verb == "require" && strings.HasPrefix(args[1], "@") && IsCommit(args[1][1:]) {
+			args[1] = args[1][1:]
+		}
+		_, isBranch := VersionBranch(args[1])
+		if verb == "require" && (IsConstraint(args[1]) || IsCommit(args[1]) || isBranch) {
+			if _, err := ParseConstraint(args[1]); err != nil && !IsCommit(args[1]) && !isBranch {
+				fmt.Fprintf(errs, "%s:%d: %v\n", f.Syntax.Name, line.Start.Line, &Error{Verb: verb, ModPath: s, Err: err})
+				return
+			}
+			f.Require = append(f.Require, &Require{
+				Mod:      module.Version{Path: s, Version: args[1]},
+				Syntax:   line,
+				Indirect: isIndirect(line),
+			})
+			return
+		}
 		v, err := parseVersion(verb, s, &args[1], fix)
 		if err != nil {
 			fmt.Fprintf(errs, "%s:%d: %v\n", f.Syntax.Name, line.Start.Line, err)
@@ -0,0 +1,47 @@
+package modfile
+
+import (
+	"regexp"
+
+	"golang.org/x/mod/semver"
+)
+
+var (
+	commitRE = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+	// the timestamp and commit at the end of a pseudo-version,
+	// as in v0.0.0-20200101120000-abcdef123456 or v1.2.4-0.20200101-abcdef1
+	pseudoRE = regexp.MustCompile(`-(?:[0-9A-Za-z.-]*\.)?[0-9]{8,14}-([0-9a-f]{6,40})$`)
+)
+
+// IsCommit reports whether s is a commit hash, full or abbreviated.
+func IsCommit(s string) bool {
+	return commitRE.MatchString(s)
+}
+
+// IsPseudoVersion reports whether v is a pseudo-version for a commit.
+func IsPseudoVersion(v string) bool {
+	return semver.IsValid(v) && pseudoRE.MatchString(v)
+}
+
+// VersionBranch returns the branch of a version written as @<branch>, such as @main.
+// The branch is kept in the version, so that it is recorded in the sum file.
+func VersionBranch(v string) (string, bool) {
+	if len(v) < 2 || v[0] != '@' || IsCommit(v[1:]) {
+		return "", false
+	}
+	return v[1:], true
+}
+
+// VersionCommit returns the commit of a version which is a commit hash
+// or a pseudo-version, and false for tagged versions.
+func VersionCommit(v string) (string, bool) {
+	if IsCommit(v) {
+		return v, true
+	}
+	if !IsPseudoVersion(v) {
+		return "", false
+	}
+	m := pseudoRE.FindStringSubmatch(v)
+	return m[1], true
+}
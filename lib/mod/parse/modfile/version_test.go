@@ -0,0 +1,62 @@
+package modfile
+
+import (
+	"testing"
+)
+
+func TestVersionCommit(t *testing.T) {
+	tests := []struct {
+		ver    string
+		commit bool
+		pseudo bool
+		at     string
+	}{
+		{ver: "abcdef1", commit: true, at: "abcdef1"},
+		{ver: "0123456789abcdef0123456789abcdef01234567", commit: true, at: "0123456789abcdef0123456789abcdef01234567"},
+		{ver: "abcdef", at: ""},
+		{ver: "ABCDEF1", at: ""},
+		{ver: "0123456789abcdef0123456789abcdef012345678", at: ""},
+		{ver: "v0.0.0-20200101120000-abcdef123456", pseudo: true, at: "abcdef123456"},
+		{ver: "v1.2.4-0.20200101120000-abcdef123456", pseudo: true, at: "abcdef123456"},
+		{ver: "v1.2.4-pre.0.20200101120000-abcdef123456", pseudo: true, at: "abcdef123456"},
+		{ver: "v1.2.3", at: ""},
+		{ver: "v1.2.3-rc.1", at: ""},
+		{ver: "0.0.0-20200101120000-abcdef123456", at: ""},
+		{ver: "v0.0.0-2020-abcdef123456", at: ""},
+		{ver: "main", at: ""},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommit(tt.ver); got != tt.commit {
+			t.Errorf("%s: IsCommit got %v, want %v", tt.ver, got, tt.commit)
+		}
+		if got := IsPseudoVersion(tt.ver); got != tt.pseudo {
+			t.Errorf("%s: IsPseudoVersion got %v, want %v", tt.ver, got, tt.pseudo)
+		}
+		commit, ok := VersionCommit(tt.ver)
+		if commit != tt.at || ok != (tt.at != "") {
+			t.Errorf("%s: VersionCommit got %q, %v, want %q", tt.ver, commit, ok, tt.at)
+		}
+	}
+}
+
+func TestVersionBranch(t *testing.T) {
+	tests := []struct {
+		ver    string
+		branch string
+	}{
+		{ver: "@main", branch: "main"},
+		{ver: "@release/v1", branch: "release/v1"},
+		{ver: "@abcdef1"},
+		{ver: "@"},
+		{ver: "main"},
+		{ver: "v1.2.3"},
+	}
+
+	for _, tt := range tests {
+		branch, ok := VersionBranch(tt.ver)
+		if branch != tt.branch || ok != (tt.branch != "") {
+			t.Errorf("%s: VersionBranch got %q, %v, want %q", tt.ver, branch, ok, tt.branch)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package modfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A WorkFile is the parsed, interpreted form of a hof.work file,
+// which lists the local modules of a workspace.
+//
+//	use (
+//		./apis
+//		./web
+//	)
+type WorkFile struct {
+	Use []*Use
+
+	Syntax *FileSyntax
+}
+
+// A Use is a single directory statement.
+type Use struct {
+	Path   string
+	Syntax *Line
+}
+
+// ParseWork parses the data of a workspace file named file.
+func ParseWork(file string, data []byte) (*WorkFile, error) {
+	fs, err := parse(file, data)
+	if err != nil {
+		return nil, err
+	}
+	f := &WorkFile{
+		Syntax: fs,
+	}
+
+	var errs bytes.Buffer
+	for _, x := range fs.Stmt {
+		switch x := x.(type) {
+		case *Line:
+			f.add(&errs, x, x.Token[0], x.Token[1:])
+
+		case *LineBlock:
+			if len(x.Token) > 1 || x.Token[0] != "use" {
+				fmt.Fprintf(&errs, "%s:%d: unknown block type: %s\n", file, x.Start.Line, strings.Join(x.Token, " "))
+				continue
+			}
+			for _, l := range x.Line {
+				f.add(&errs, l, x.Token[0], l.Token)
+			}
+		}
+	}
+
+	if errs.Len() > 0 {
+		return nil, errors.New(strings.TrimRight(errs.String(), "\n"))
+	}
+	return f, nil
+}
+
+func (f *WorkFile) add(errs *bytes.Buffer, line *Line, verb string, args []string) {
+	if verb != "use" {
+		fmt.Fprintf(errs, "%s:%d: unknown directive: %s\n", f.Syntax.Name, line.Start.Line, verb)
+		return
+	}
+	if len(args) != 1 {
+		fmt.Fprintf(errs, "%s:%d: usage: use ./dir\n", f.Syntax.Name, line.Start.Line)
+		return
+	}
+	s, err := parseString(&args[0])
+	if err != nil {
+		fmt.Fprintf(errs, "%s:%d: invalid quoted string: %v\n", f.Syntax.Name, line.Start.Line, err)
+		return
+	}
+	if !IsDirectoryPath(s) {
+		fmt.Fprintf(errs, "%s:%d: use must be a directory path (rooted or starting with ./ or ../)\n", f.Syntax.Name, line.Start.Line)
+		return
+	}
+	for _, u := range f.Use {
+		if u.Path == s {
+			fmt.Fprintf(errs, "%s:%d: %s is used twice\n", f.Syntax.Name, line.Start.Line, s)
+			return
+		}
+	}
+	f.Use = append(f.Use, &Use{Path: s, Syntax: line})
+}
+
+// AddUse adds a directory to the workspace, if not already present.
+func (f *WorkFile) AddUse(path string) error {
+	if !IsDirectoryPath(path) {
+		return fmt.Errorf("%s must be a directory path (rooted or starting with ./ or ../)", path)
+	}
+	for _, u := range f.Use {
+		if u.Path == path {
+			return nil
+		}
+	}
+	if f.Syntax == nil {
+		f.Syntax = new(FileSyntax)
+	}
+	line := f.Syntax.addLine(nil, "use", AutoQuote(path))
+	f.Use = append(f.Use, &Use{Path: path, Syntax: line})
+	return nil
+}
+
+func (f *WorkFile) Format() ([]byte, error) {
+	return Format(f.Syntax), nil
+}
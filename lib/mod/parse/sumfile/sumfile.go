@@ -70,6 +70,15 @@ func (sum *Sum) Add(ver Version, hash string) {
 	sum.Mods[ver] = val
 }
 
+// Set replaces the hashes of ver, for entries which are expected to change,
+// such as those of branches.
+func (sum *Sum) Set(ver Version, hash string) {
+	if sum.Mods == nil {
+		sum.Mods = make(map[Version][]string)
+	}
+	sum.Mods[ver] = []string{hash}
+}
+
 func (sum *Sum) Write() (string, error) {
 	var w strings.Builder
 	// build up slice
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// Client talks to a module proxy, which serves the same endpoints as a GOPROXY
+//
+//	$base/$module/@v/list
+//	$base/$module/@v/$version.info
+//	$base/$module/@v/$version.zip
+//	$base/$module/@latest
+type Client struct {
+	// URL is the base url of the proxy
+	URL string
+
+	// Username and Token authenticate requests, when the token is set
+	Username string
+	Token    string
+}
+
+// Info is the metadata for a module version.
+type Info struct {
+	Version string
+	Time    time.Time
+}
+
+// NotFoundError is returned when the proxy does not have a module or version,
+// after which the next proxy is tried.
+type NotFoundError struct {
+	URL    string
+	Status string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Not Found: %s %s", e.Status, e.URL)
+}
+
+// NewClient uses the credential found for the host of baseURL, if any.
+func NewClient(baseURL string) (client *Client, err error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	client = &Client{
+		URL: strings.TrimSuffix(baseURL, "/"),
+	}
+	if c := auth.Lookup(u.Host); c != nil {
+		client.Username, client.Token = c.Username, c.Token
+	}
+	return client, nil
+}
+
+// List returns the versions of mod known to the proxy.
+func (client *Client) List(mod string) ([]string, error) {
+	data, err := client.get(mod, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+	var vers []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) > 0 {
+			vers = append(vers, f[0])
+		}
+	}
+	return vers, nil
+}
+
+// Latest returns the info for the latest version of mod.
+func (client *Client) Latest(mod string) (*Info, error) {
+	var info Info
+	err := client.getJSON(mod, "@latest", &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Info returns the info for mod at ver.
+func (client *Client) Info(mod, ver string) (*Info, error) {
+	ev, err := module.EscapeVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+	var info Info
+	err = client.getJSON(mod, "@v/"+ev+".info", &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Zip downloads the zip of mod at ver, whose files are under mod@ver/
+func (client *Client) Zip(mod, ver string) (*zip.Reader, error) {
+	ev, err := module.EscapeVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+	data, err := client.get(mod, "@v/"+ev+".zip")
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(data)
+
+	zfile, err := zip.NewReader(r, int64(len(data)))
+
+	return zfile, err
+}
+
+func (client *Client) getJSON(mod, endpoint string, out interface{}) error {
+	data, err := client.get(mod, endpoint)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (client *Client) get(mod, endpoint string) ([]byte, error) {
+	em, err := module.EscapePath(mod)
+	if err != nil {
+		return nil, err
+	}
+	u := client.URL + "/" + em + "/" + endpoint
+
+	req := yagu.NewRequest().Get(u)
+	if client.Token != "" {
+		req = req.SetBasicAuth(client.Username, client.Token)
+	}
+	resp, data, errs := req.EndBytes()
+	if len(errs) != 0 {
+		return nil, yagu.CertHint(errs[0])
+	}
+
+	if resp.StatusCode == 404 || resp.StatusCode == 410 {
+		return nil, &NotFoundError{URL: u, Status: resp.Status}
+	}
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("Internal Error: %s %s", resp.Status, u)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Bad Request: %s %s", resp.Status, u)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+const (
+	// Direct fetches modules from their version control hosts
+	Direct = "direct"
+
+	// Off disallows fetching modules
+	Off = "off"
+)
+
+// Proxy is an entry in $HOF_MOD_PROXY.
+type Proxy struct {
+	// URL is a proxy base url, Direct, or Off
+	URL string
+
+	// FallbackOnError is set when the entry is followed by a pipe,
+	// and the next entry is tried after any error,
+	// rather than only when the module or version is not found.
+	FallbackOnError bool
+}
+
+// Proxies parses $HOF_MOD_PROXY, a list of proxy urls, direct, and off,
+// tried in order and separated by commas or pipes as for GOPROXY.
+// It defaults to direct.
+func Proxies() ([]Proxy, error) {
+	env := strings.TrimSpace(os.Getenv("HOF_MOD_PROXY"))
+	if env == "" {
+		env = Direct
+	}
+
+	var proxies []Proxy
+	for env != "" {
+		var p Proxy
+		if i := strings.IndexAny(env, ",|"); i >= 0 {
+			p.URL, p.FallbackOnError, env = env[:i], env[i] == '|', env[i+1:]
+		} else {
+			p.URL, env = env, ""
+		}
+		p.URL = strings.TrimSpace(p.URL)
+		switch {
+		case p.URL == "":
+			continue
+		case p.URL == Direct || p.URL == Off:
+		case strings.HasPrefix(p.URL, "https://") || strings.HasPrefix(p.URL, "http://"):
+		default:
+			return nil, fmt.Errorf("Invalid HOF_MOD_PROXY entry %q", p.URL)
+		}
+		proxies = append(proxies, p)
+	}
+
+	return proxies, nil
+}
+
+// IsNotFound reports whether err is, or wraps, a NotFoundError.
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
+
+// LoadZip loads the files of mod at ver from a module zip into FS,
+// trimming their mod@ver/ prefix.
+func LoadZip(zReader *zip.Reader, FS billy.Filesystem, mod, ver string) error {
+	prefix := mod + "@" + ver + "/"
+	for _, f := range zReader.File {
+		if !strings.HasPrefix(f.Name, prefix) {
+			return fmt.Errorf("Unexpected file %q in zip for %s@%s", f.Name, mod, ver)
+		}
+		fn := strings.TrimPrefix(f.Name, prefix)
+		if fn == "" {
+			continue
+		}
+
+		if strings.HasSuffix(fn, "/") {
+			err := FS.MkdirAll(fn, 0755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := loadZipFile(f, FS, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadZipFile(f *zip.File, FS billy.Filesystem, fn string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := FS.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+func TestProxies(t *testing.T) {
+	tests := []struct {
+		env    string
+		expect []Proxy
+		err    string
+	}{
+		{env: "", expect: []Proxy{{URL: Direct}}},
+		{env: "https://proxy.example.com", expect: []Proxy{{URL: "https://proxy.example.com"}}},
+		{env: "https://a.example.com, http://b.example.com|direct", expect: []Proxy{
+			{URL: "https://a.example.com"},
+			{URL: "http://b.example.com", FallbackOnError: true},
+			{URL: Direct},
+		}},
+		{env: "off", expect: []Proxy{{URL: Off}}},
+		{env: ",,direct,", expect: []Proxy{{URL: Direct}}},
+		{env: "proxy.example.com", err: `Invalid HOF_MOD_PROXY entry "proxy.example.com"`},
+	}
+
+	defer os.Unsetenv("HOF_MOD_PROXY")
+	for _, tt := range tests {
+		os.Setenv("HOF_MOD_PROXY", tt.env)
+		proxies, err := Proxies()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected an error with %q, got %v", tt.env, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.env, err)
+			continue
+		}
+		if !reflect.DeepEqual(proxies, tt.expect) {
+			t.Errorf("%q: got %+v, want %+v", tt.env, proxies, tt.expect)
+		}
+	}
+}
+
+func moduleZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestClient(t *testing.T) {
+	// modules are escaped in urls, so upper case letters are !lower
+	zipData := moduleZip(t, map[string]string{
+		"github.com/Org/mod@v1.1.0/cue.mods":       "module github.com/Org/mod\n",
+		"github.com/Org/mod@v1.1.0/schema/app.cue": "package schema\n",
+	})
+	routes := map[string]string{
+		"/github.com/!org/mod/@v/list":        "v1.0.0\nv1.1.0 2020-01-01T00:00:00Z\n\n",
+		"/github.com/!org/mod/@latest":        `{"Version": "v1.1.0", "Time": "2020-01-01T00:00:00Z"}`,
+		"/github.com/!org/mod/@v/v1.1.0.info": `{"Version": "v1.1.0", "Time": "2020-01-01T00:00:00Z"}`,
+		"/github.com/!org/mod/@v/v1.1.0.zip":  string(zipData),
+	}
+
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/github.com/gone/mod/@v/list":
+			w.WriteHeader(http.StatusGone)
+			return
+		case "/github.com/broken/mod/@v/list":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	client := &Client{URL: srv.URL, Username: "bot", Token: "t0ken"}
+
+	vers, err := client.List("github.com/Org/mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vers, []string{"v1.0.0", "v1.1.0"}) {
+		t.Fatalf("got versions %q", vers)
+	}
+	if !strings.HasPrefix(authHeader, "Basic ") {
+		t.Fatalf("expected basic auth with a token, got %q", authHeader)
+	}
+
+	info, err := client.Latest("github.com/Org/mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.1.0" || info.Time.Year() != 2020 {
+		t.Fatalf("got latest %+v", info)
+	}
+	info, err = client.Info("github.com/Org/mod", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.1.0" {
+		t.Fatalf("got info %+v", info)
+	}
+
+	zr, err := client.Zip("github.com/Org/mod", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	FS := memfs.New()
+	err = LoadZip(zr, FS, "github.com/Org/mod", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yagu.BillyReadAll("schema/app.cue", FS)
+	if err != nil || string(data) != "package schema\n" {
+		t.Fatalf("expected the zip files without their prefix, got %q, %v", data, err)
+	}
+
+	// the next proxy is only tried when a module is not found
+	for _, mod := range []string{"github.com/Org/missing", "github.com/gone/mod"} {
+		_, err = client.List(mod)
+		if !IsNotFound(err) {
+			t.Errorf("%s: expected not found, got %v", mod, err)
+		}
+	}
+	_, err = client.List("github.com/broken/mod")
+	if err == nil || IsNotFound(err) || !strings.Contains(err.Error(), "Internal Error: 500") {
+		t.Errorf("expected a server error, got %v", err)
+	}
+
+	// without a token, requests are anonymous
+	client.Token = ""
+	_, err = client.List("github.com/Org/mod")
+	if err != nil || authHeader != "" {
+		t.Fatalf("expected an anonymous request, got %q, %v", authHeader, err)
+	}
+}
+
+func TestLoadZip(t *testing.T) {
+	data := moduleZip(t, map[string]string{
+		"github.com/org/mod@v1.0.0/cue.mods": "module github.com/org/mod\n",
+		"github.com/org/other@v1.0.0/x.cue":  "package x\n",
+	})
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = LoadZip(zr, memfs.New(), "github.com/org/mod", "v1.0.0")
+	if err == nil || !strings.Contains(err.Error(), `Unexpected file "github.com/org/other@v1.0.0/x.cue"`) {
+		t.Fatalf("expected files of another module to fail, got %v", err)
+	}
+}
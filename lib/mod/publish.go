@@ -0,0 +1,426 @@
+package mod
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/github"
+	"github.com/hofstadter-io/hof/script"
+)
+
+// PublishOptions configure Publish
+type PublishOptions struct {
+	// Version to release, as in v1.2.3, or the part to bump: major, minor, or patch.
+	// When empty, it is chosen from the conventional commits since the last release.
+	Version string
+
+	// Remote is the git remote the tag is pushed to
+	Remote string
+
+	// Release creates a GitHub release for the tag, with the module zip attached
+	Release bool
+
+	// Tests is a glob of test scripts, run with the script package
+	Tests   string
+	NoTests bool
+
+	// DryRun validates the module and reports the version without tagging
+	DryRun bool
+}
+
+// Publish validates the module in the current directory and releases it.
+// The git worktree must be clean, the module tidy and verified for each
+// language, and the test scripts passing. The next version is then tagged,
+// prefixed by the subdirectory for nested modules, and pushed.
+func Publish(langs []string, opts PublishOptions) error {
+	if len(langs) == 0 {
+		langs = DiscoverLangs()
+	}
+	if len(langs) == 0 {
+		return fmt.Errorf("No module files found, nothing to publish")
+	}
+
+	err := gitClean()
+	if err != nil {
+		return err
+	}
+
+	mdr, err := getModder(langs[0])
+	if err != nil {
+		return err
+	}
+	module, err := mdr.ModulePath()
+	if err != nil {
+		return err
+	}
+	_, _, _, subdir := cache.SplitMod(module)
+
+	for _, lang := range langs {
+		fmt.Printf("Checking %s module %s\n", lang, module)
+		err = Tidy(lang)
+		if err != nil {
+			return err
+		}
+		err = Verify(lang)
+		if err != nil {
+			return err
+		}
+	}
+	if err := gitClean(); err != nil {
+		return fmt.Errorf("The module is not tidy, review and commit the changes made by 'hof mod tidy'\n%w\n", err)
+	}
+
+	if !opts.NoTests {
+		err = runTestScripts(opts.Tests)
+		if err != nil {
+			return err
+		}
+	}
+
+	latest, err := latestRelease(subdir)
+	if err != nil {
+		return err
+	}
+	ver, err := nextVersion(subdir, latest, opts.Version)
+	if err != nil {
+		return err
+	}
+	tag := cache.RepoTag(subdir, ver)
+
+	if opts.DryRun {
+		fmt.Printf("Would publish %s@%s as tag %s\n", module, ver, tag)
+		return nil
+	}
+
+	_, err = git("tag", "-a", tag, "-m", fmt.Sprintf("Release %s %s", module, ver))
+	if err != nil {
+		return err
+	}
+	_, err = git("push", opts.Remote, "refs/tags/"+tag)
+	if err != nil {
+		return fmt.Errorf("While pushing tag %s, remove it with 'git tag -d %s' to try again\n%w\n", tag, tag, err)
+	}
+	fmt.Printf("Published %s@%s as tag %s\n", module, ver, tag)
+
+	if opts.Release {
+		return releaseGitHub(module, ver, tag)
+	}
+
+	return nil
+}
+
+// git runs a git command, returning its output, or an error with the output.
+func git(args ...string) (string, error) {
+	out, err := yagu.Exec(append([]string{"git"}, args...))
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out, nil
+}
+
+// gitClean checks that tracked files have no uncommitted changes.
+func gitClean() error {
+	out, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) != "" {
+		return fmt.Errorf("The git worktree has uncommitted changes:\n%s", out)
+	}
+	return nil
+}
+
+// latestRelease returns the greatest release tag of the module, without the
+// subdirectory prefix, or the empty string when it has not been released.
+func latestRelease(subdir string) (string, error) {
+	prefix := ""
+	if subdir != "" {
+		prefix = subdir + "/"
+	}
+
+	out, err := git("tag", "--list", prefix+"v*")
+	if err != nil {
+		return "", err
+	}
+
+	latest := ""
+	for _, tag := range strings.Fields(out) {
+		ver := strings.TrimPrefix(tag, prefix)
+		if semver.IsValid(ver) && semver.Prerelease(ver) == "" && semver.Compare(ver, latest) > 0 {
+			latest = ver
+		}
+	}
+	return latest, nil
+}
+
+// nextVersion returns the version to release after latest.
+// want is a version, or the part to bump, or empty to decide by the
+// conventional commits to the module since latest.
+// Breaking changes to v0 modules bump the minor version, as v1 is a promise
+// of stability which should be made explicitly.
+func nextVersion(subdir, latest, want string) (string, error) {
+	switch want {
+	case "major", "minor", "patch":
+
+	case "":
+		msgs, err := commitsSince(subdir, latest)
+		if err != nil {
+			return "", err
+		}
+		if len(msgs) == 0 {
+			return "", fmt.Errorf("No commits to the module since %s, nothing to publish", latest)
+		}
+		want = conventionalBump(msgs)
+		if want == "major" && (latest == "" || semver.Major(latest) == "v0") {
+			want = "minor"
+		}
+
+	default:
+		if !semver.IsValid(want) {
+			return "", fmt.Errorf("Invalid version %q, use vX.Y.Z, major, minor, or patch", want)
+		}
+		if latest != "" && semver.Compare(want, latest) <= 0 {
+			return "", fmt.Errorf("Version %s is not greater than the latest release %s", want, latest)
+		}
+		return want, nil
+	}
+
+	base := latest
+	if base == "" {
+		base = "v0.0.0"
+	}
+	parts := strings.SplitN(strings.TrimPrefix(semver.Canonical(base), "v"), ".", 3)
+	var n [3]int
+	for i, p := range parts {
+		n[i], _ = strconv.Atoi(strings.SplitN(p, "-", 2)[0])
+	}
+
+	switch want {
+	case "major":
+		n = [3]int{n[0] + 1, 0, 0}
+	case "minor":
+		n = [3]int{n[0], n[1] + 1, 0}
+	case "patch":
+		n = [3]int{n[0], n[1], n[2] + 1}
+	}
+	return fmt.Sprintf("v%d.%d.%d", n[0], n[1], n[2]), nil
+}
+
+// commitsSince returns the messages of the commits touching the current
+// directory since the tag of latest, or all of them when it is empty.
+func commitsSince(subdir, latest string) ([]string, error) {
+	args := []string{"log", "--format=%B%x00"}
+	if latest != "" {
+		args = append(args, cache.RepoTag(subdir, latest)+"..HEAD")
+	}
+	out, err := git(append(args, "--", ".")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []string
+	for _, msg := range strings.Split(out, "\x00") {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+var conventionalRE = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?:`)
+
+// conventionalBump picks the part of the version to bump from commit messages
+// following conventionalcommits.org: a breaking change is major, a feat is
+// minor, and anything else is a patch.
+func conventionalBump(msgs []string) string {
+	bump := "patch"
+	for _, msg := range msgs {
+		m := conventionalRE.FindStringSubmatch(msg)
+		if (m != nil && m[3] == "!") || strings.Contains(msg, "BREAKING CHANGE:") || strings.Contains(msg, "BREAKING-CHANGE:") {
+			return "major"
+		}
+		if m != nil && m[1] == "feat" {
+			bump = "minor"
+		}
+	}
+	return bump
+}
+
+// gitPrefix returns the current directory relative to the top of the repository.
+func gitPrefix() (string, error) {
+	out, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSpace(out), "/"), nil
+}
+
+// releaseGitHub creates a release for the tag with the module zip attached,
+// laid out like GitHub archives, with the files under a single directory.
+func releaseGitHub(module, ver, tag string) error {
+	remote, owner, repo, _ := cache.SplitMod(module)
+	if !github.IsGitHub(remote) {
+		return fmt.Errorf("Releases are only created on GitHub, and %s is not a GitHub host", remote)
+	}
+
+	zf, err := packageModule(module, ver, tag)
+	if err != nil {
+		return fmt.Errorf("While packaging the module\n%w\n", err)
+	}
+	defer os.RemoveAll(filepath.Dir(zf))
+
+	client, err := github.NewClient(remote)
+	if err != nil {
+		return err
+	}
+	rel, err := github.CreateRelease(client, owner, repo, tag, module+" "+ver, "")
+	if err != nil {
+		return fmt.Errorf("While creating the release for %s\n%w\n", tag, err)
+	}
+
+	f, err := os.Open(zf)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = github.UploadReleaseAsset(client, owner, repo, rel.GetID(), f)
+	if err != nil {
+		return fmt.Errorf("While uploading %s to the release for %s\n%w\n", filepath.Base(zf), tag, err)
+	}
+
+	fmt.Printf("Released %s\n", rel.GetHTMLURL())
+	return nil
+}
+
+// packageModule writes the module files at tag to a zip in a temp dir.
+func packageModule(module, ver, tag string) (string, error) {
+	dir, err := ioutil.TempDir("", "hof-publish-")
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(module) + "-" + strings.TrimPrefix(ver, "v")
+	zf := filepath.Join(dir, name+".zip")
+
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	prefix, err := gitPrefix()
+	if err != nil {
+		return "", err
+	}
+
+	// archive from the top, as the tree of a nested module is outside of the worktree
+	_, err = git("-C", strings.TrimSpace(top), "archive", "--format=zip", "--prefix="+name+"/", "-o", zf, tag+":"+prefix)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return zf, nil
+}
+
+var (
+	errScriptFailed  = errors.New("script failed")
+	errScriptSkipped = errors.New("script skipped")
+)
+
+// scriptT runs test scripts outside of go test,
+// printing the log of those which fail.
+type scriptT struct {
+	log    *bytes.Buffer
+	failed *[]string
+}
+
+func (t scriptT) Skip(args ...interface{}) {
+	t.Log(args...)
+	panic(errScriptSkipped)
+}
+
+func (t scriptT) Fatal(args ...interface{}) {
+	t.Log(args...)
+	t.FailNow()
+}
+
+func (t scriptT) Parallel() {}
+
+func (t scriptT) Log(args ...interface{}) {
+	fmt.Fprintln(t.log, args...)
+}
+
+func (t scriptT) FailNow() {
+	panic(errScriptFailed)
+}
+
+func (t scriptT) Verbose() bool {
+	return false
+}
+
+func (t scriptT) Run(name string, f func(script.T)) {
+	sub := scriptT{log: new(bytes.Buffer), failed: t.failed}
+	defer func() {
+		switch r := recover(); r {
+		case nil:
+			fmt.Printf("  ok    %s\n", name)
+		case errScriptSkipped:
+			fmt.Printf("  skip  %s\n", name)
+		case errScriptFailed:
+			fmt.Printf("  FAIL  %s\n%s", name, sub.log.String())
+			*t.failed = append(*t.failed, name)
+		default:
+			panic(r)
+		}
+	}()
+	f(sub)
+}
+
+// runTestScripts runs the scripts matching glob, one at a time.
+func runTestScripts(glob string) (err error) {
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Printf("No test scripts match %s, skipping tests\n", glob)
+		return nil
+	}
+
+	fmt.Printf("Running %d test scripts\n", len(files))
+	var failed []string
+	t := scriptT{log: new(bytes.Buffer), failed: &failed}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errScriptFailed {
+				panic(r)
+			}
+			err = fmt.Errorf("While running test scripts\n%s", t.log.String())
+		}
+	}()
+
+	script.RunT(t, script.Params{
+		Dir:      filepath.Dir(glob),
+		Glob:     filepath.Base(glob),
+		Sequence: true,
+		Setup: func(env *script.Env) error {
+			env.Vars = append(env.Vars, "HOF_TELEMETRY_DISABLED=1")
+			return nil
+		},
+	})
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Test scripts failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package sumdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/mod/sumdb"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[string]*sumdb.Client{}
+)
+
+// Verify checks the dirhash of mod at ver against the checksum database
+// of the policy. Only release versions are recorded in databases,
+// so branches and commits of modules under a policy are refused.
+func (P *Policy) Verify(mod, ver, dirhash string) error {
+	if !semver.IsValid(ver) {
+		return fmt.Errorf("%s@%s is not a release version and cannot be verified with checksum database %s", mod, ver, P.Name)
+	}
+
+	lines, err := client(P).Lookup(mod, ver)
+	if err != nil {
+		return fmt.Errorf("While looking up %s@%s in checksum database %s\n%w\n", mod, ver, P.Name, err)
+	}
+
+	prefix := mod + " " + ver + " "
+	for _, line := range lines {
+		if want := strings.TrimPrefix(line, prefix); want != line {
+			if want != dirhash {
+				return fmt.Errorf("SECURITY ERROR: %s@%s does not match checksum database %s\n  fetched:   %s\n  sumdb:     %s\nThe module may have been tampered with, or its tag moved since it was published.", mod, ver, P.Name, dirhash, want)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s@%s is not recorded in checksum database %s", mod, ver, P.Name)
+}
+
+func client(P *Policy) *sumdb.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	c, ok := clients[P.Key+" "+P.URL]
+	if !ok {
+		c = sumdb.NewClient(&clientOps{
+			key: P.Key,
+			url: P.URL,
+			dir: filepath.Join(cache.LocalCacheBaseDir, "sumdb"),
+		})
+		clients[P.Key+" "+P.URL] = c
+	}
+	return c
+}
+
+// clientOps stores the latest signed tree heads and the tiles and lookups
+// fetched from databases in the module cache, under sumdb/.
+type clientOps struct {
+	key string
+	url string
+	dir string
+
+	mu sync.Mutex
+}
+
+func (ops *clientOps) ReadRemote(path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", ops.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(ops.url); err == nil {
+		if c := auth.Lookup(u.Host); c != nil {
+			req.SetBasicAuth(c.Username, c.Token)
+		}
+	}
+
+	resp, err := yagu.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", ops.url+path, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (ops *clientOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(ops.key), nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(ops.dir, filepath.FromSlash(file)))
+	if os.IsNotExist(err) {
+		// start from an empty tree
+		return []byte{}, nil
+	}
+	return data, err
+}
+
+func (ops *clientOps) WriteConfig(file string, old, new []byte) error {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+
+	fn := filepath.Join(ops.dir, filepath.FromSlash(file))
+	cur, err := ioutil.ReadFile(fn)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if !bytes.Equal(cur, old) {
+		return sumdb.ErrWriteConflict
+	}
+
+	return writeFile(fn, new)
+}
+
+func (ops *clientOps) ReadCache(file string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(ops.dir, "cache", filepath.FromSlash(file)))
+}
+
+func (ops *clientOps) WriteCache(file string, data []byte) {
+	// the cache only saves requests, failing to write it is not an error
+	writeFile(filepath.Join(ops.dir, "cache", filepath.FromSlash(file)), data)
+}
+
+func (ops *clientOps) Log(msg string) {}
+
+func (ops *clientOps) SecurityError(msg string) {
+	// the client returns sumdb.ErrSecurity from the operation
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+// writeFile writes to a temp file and renames it into place,
+// so readers in other processes never see a partial file.
+func writeFile(fn string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(fn), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fn), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fn)
+}
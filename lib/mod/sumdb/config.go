@@ -0,0 +1,109 @@
+// Package sumdb verifies fetched modules against checksum databases,
+// transparency logs served like sum.golang.org, which record the dirhash
+// hof writes to sum files for every module version, as in
+//
+//	github.com/org/repo v1.2.3 h1:...
+//
+// Databases are chosen per module prefix by $HOF_MOD_SUMDB, a comma
+// separated list of prefix=key entries, where key is the verifier key
+// of the database, optionally followed by its url, or off.
+//
+//	HOF_MOD_SUMDB="github.com/org=sum.org.dev+9f2c1b3a+AX... https://sum.org.dev/hof,github.com/org/sandbox=off"
+//
+// The longest matching prefix applies, and modules with no match are not checked.
+// The url defaults to https:// followed by the name in the key.
+package sumdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Off disables verification for a prefix, as an exception to a shorter one.
+const Off = "off"
+
+// Policy is an entry in $HOF_MOD_SUMDB.
+type Policy struct {
+	// Prefix is a module path prefix, matched at path element boundaries
+	Prefix string
+
+	// Key is the verifier key of the database, empty when verification is off
+	Key string
+
+	// Name is the name of the database, from its key
+	Name string
+
+	// URL is where the database is served
+	URL string
+}
+
+// Policies parses $HOF_MOD_SUMDB.
+func Policies() ([]Policy, error) {
+	var policies []Policy
+	for _, entry := range strings.Split(os.Getenv("HOF_MOD_SUMDB"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.Index(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("Invalid HOF_MOD_SUMDB entry %q, should be prefix=key [url] or prefix=off", entry)
+		}
+		P := Policy{Prefix: strings.TrimSuffix(entry[:i], "/")}
+
+		flds := strings.Fields(entry[i+1:])
+		if len(flds) == 1 && flds[0] == Off {
+			policies = append(policies, P)
+			continue
+		}
+		if len(flds) == 0 || len(flds) > 2 {
+			return nil, fmt.Errorf("Invalid HOF_MOD_SUMDB entry %q, should be prefix=key [url] or prefix=off", entry)
+		}
+
+		verifier, err := note.NewVerifier(flds[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid HOF_MOD_SUMDB key for %s\n%w\n", P.Prefix, err)
+		}
+		P.Key, P.Name = flds[0], verifier.Name()
+		P.URL = "https://" + P.Name
+		if len(flds) == 2 {
+			P.URL = strings.TrimSuffix(flds[1], "/")
+		}
+
+		policies = append(policies, P)
+	}
+
+	return policies, nil
+}
+
+// Match returns the policy with the longest prefix of mod,
+// or nil when no policy applies or verification is off.
+func Match(policies []Policy, mod string) *Policy {
+	var best *Policy
+	for i, P := range policies {
+		if mod != P.Prefix && !strings.HasPrefix(mod, P.Prefix+"/") {
+			continue
+		}
+		if best == nil || len(P.Prefix) > len(best.Prefix) {
+			best = &policies[i]
+		}
+	}
+	if best == nil || best.Key == "" {
+		return nil
+	}
+	return best
+}
+
+// For returns the policy for mod from $HOF_MOD_SUMDB,
+// or nil when it is not verified.
+func For(mod string) (*Policy, error) {
+	policies, err := Policies()
+	if err != nil {
+		return nil, err
+	}
+	return Match(policies, mod), nil
+}
@@ -0,0 +1,104 @@
+package sumdb
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/hofstadter-io/hof/lib/mod/cache"
+)
+
+func TestMatch(t *testing.T) {
+	_, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("HOF_MOD_SUMDB", "example.com/org="+vkey+", example.com/org/sandbox=off")
+	defer os.Unsetenv("HOF_MOD_SUMDB")
+
+	policies, err := Policies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"example.com/org/repo":         "sum.example.com",
+		"example.com/org":              "sum.example.com",
+		"example.com/org/sandbox/repo": "",
+		"example.com/organization":     "",
+		"github.com/other/repo":        "",
+	}
+	for mod, want := range tests {
+		have := ""
+		if P := Match(policies, mod); P != nil {
+			have = P.Name
+			if P.URL != "https://sum.example.com" {
+				t.Errorf("%s: url %q", mod, P.URL)
+			}
+		}
+		if have != want {
+			t.Errorf("%s: have %q, want %q", mod, have, want)
+		}
+	}
+
+	os.Setenv("HOF_MOD_SUMDB", "example.com/org=notakey")
+	if _, err := Policies(); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := map[string]string{
+		"example.com/org/repo v1.0.0": "h1:good",
+	}
+	ts := sumdb.NewTestServer(skey, func(path, vers string) ([]byte, error) {
+		h, ok := records[path+" "+vers]
+		if !ok {
+			return nil, fmt.Errorf("not found")
+		}
+		return []byte(fmt.Sprintf("%s %s %s\n", path, vers, h)), nil
+	})
+	srv := httptest.NewServer(sumdb.NewServer(ts))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "hof-sumdb-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache.LocalCacheBaseDir = dir
+
+	os.Setenv("HOF_MOD_SUMDB", "example.com/org="+vkey+" "+srv.URL)
+	defer os.Unsetenv("HOF_MOD_SUMDB")
+
+	P, err := For("example.com/org/repo")
+	if err != nil || P == nil {
+		t.Fatal("no policy", err)
+	}
+
+	if err := P.Verify("example.com/org/repo", "v1.0.0", "h1:good"); err != nil {
+		t.Error(err)
+	}
+	if err := P.Verify("example.com/org/repo", "v1.0.0", "h1:bad"); err == nil || !strings.Contains(err.Error(), "SECURITY ERROR") {
+		t.Errorf("expected a mismatch, got %v", err)
+	}
+	if err := P.Verify("example.com/org/repo", "v1.1.0", "h1:good"); err == nil {
+		t.Error("expected an error for an unrecorded version")
+	}
+	if err := P.Verify("example.com/org/repo", "main", "h1:good"); err == nil {
+		t.Error("expected an error for a branch")
+	}
+}
@@ -0,0 +1,273 @@
+package mod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hofstadter-io/hof/lib/mod/modder"
+	"github.com/hofstadter-io/hof/lib/mod/parse/modfile"
+)
+
+// The workspace file, listing the local modules which are resolved together
+const WORK_FILE = "hof.work"
+
+// FindWorkFile returns the workspace file in the working directory or the
+// nearest parent with one, or the empty string when there is none.
+// $HOF_WORK may name a workspace file to use instead, or be off to disable workspaces.
+func FindWorkFile() (string, error) {
+	switch env := os.Getenv("HOF_WORK"); env {
+	case "":
+	case "off":
+		return "", nil
+	default:
+		return filepath.Abs(env)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		fn := filepath.Join(dir, WORK_FILE)
+		if _, err := os.Stat(fn); err == nil {
+			return fn, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ReadWorkFile reads and parses a workspace file.
+func ReadWorkFile(fn string) (*modfile.WorkFile, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseWork(fn, data)
+}
+
+// workDirs returns the absolute directories of the modules used by a workspace file.
+func workDirs(fn string) ([]string, error) {
+	wf, err := ReadWorkFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, u := range wf.Use {
+		dir := filepath.FromSlash(u.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(fn), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// inDir runs f with dir as the working directory.
+func inDir(dir string, f func() error) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	return f()
+}
+
+// ProcessWork runs method, one of tidy, vendor, vendor-check, or graph, for every module of the workspace.
+// Requirements between the modules are replaced by their directories, and the modules are
+// resolved again until they agree on the version of each of their other dependencies.
+func ProcessWork(fn, method, format string, langs []string) error {
+	dirs, err := workDirs(fn)
+	if err != nil {
+		return err
+	}
+
+	if len(langs) == 0 {
+		found := map[string]bool{}
+		for _, dir := range dirs {
+			err := inDir(dir, func() error {
+				for _, lang := range DiscoverLangs() {
+					if !found[lang] {
+						found[lang] = true
+						langs = append(langs, lang)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, lang := range langs {
+		mdr, err := getModder(lang)
+		if err != nil {
+			return err
+		}
+		err = processWorkLang(mdr, dirs, method, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func processWorkLang(mdr *modder.Modder, dirs []string, method, format string) error {
+	ws := modder.NewWorkspace()
+
+	// the modules of the workspace with a module file for the language
+	var used []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, mdr.ModFile)); err != nil {
+			continue
+		}
+		used = append(used, dir)
+		if mdr.NoLoad {
+			continue
+		}
+		err := inDir(dir, func() error {
+			_, err := ws.Add(mdr.ForWorkspace(ws))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("While loading workspace module %s\n%w\n", dir, err)
+		}
+	}
+
+	// tidy only needs the versions the modules require, and checks resolve nothing
+	if (method == "vendor" || method == "graph") && !mdr.NoLoad {
+		for changed := true; changed; {
+			changed = false
+			for _, dir := range used {
+				err := inDir(dir, func() error {
+					m := mdr.ForWorkspace(ws)
+					err := m.ResolveMVS()
+					if err != nil {
+						m.PrintErrors()
+						return err
+					}
+					if ws.Select(m) {
+						changed = true
+					}
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("While resolving workspace module %s\n%w\n", dir, err)
+				}
+			}
+		}
+	}
+
+	for _, dir := range used {
+		// keep dot and json output parsable, each graph names its root module
+		if method != "graph" {
+			fmt.Printf("== %s (%s)\n", dir, mdr.Name)
+		}
+		err := inDir(dir, func() error {
+			m := mdr.ForWorkspace(ws)
+			switch method {
+			case "tidy":
+				return m.Tidy()
+			case "vendor":
+				return m.Vendor()
+			case "vendor-check":
+				return m.VendorCheck()
+			case "graph":
+				return m.Graph(format)
+			default:
+				panic("unimplemented method in ProcessWork " + method)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WorkInit creates a workspace file in the working directory using dirs.
+func WorkInit(dirs []string) error {
+	if _, err := os.Stat(WORK_FILE); err == nil {
+		return fmt.Errorf("%s already exists", WORK_FILE)
+	}
+	fn, err := filepath.Abs(WORK_FILE)
+	if err != nil {
+		return err
+	}
+	return writeWork(fn, &modfile.WorkFile{}, dirs)
+}
+
+// WorkUse adds dirs to the nearest workspace file.
+func WorkUse(dirs []string) error {
+	if len(dirs) == 0 {
+		return fmt.Errorf("missing module dirs, usage: hof work use <dirs...>")
+	}
+
+	fn, err := FindWorkFile()
+	if err != nil {
+		return err
+	}
+	if fn == "" {
+		return fmt.Errorf("No %s found, create one with 'hof work init'", WORK_FILE)
+	}
+
+	wf, err := ReadWorkFile(fn)
+	if err != nil {
+		return err
+	}
+	return writeWork(fn, wf, dirs)
+}
+
+// writeWork adds dirs, relative to the working directory,
+// to a workspace file, and writes it out.
+func writeWork(fn string, wf *modfile.WorkFile, dirs []string) error {
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+
+		// paths are relative to the workspace file
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(fn), abs)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = "./"
+		} else if !modfile.IsDirectoryPath(rel) {
+			rel = "./" + rel
+		}
+		err = wf.AddUse(rel)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := wf.Format()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fn, data, 0644)
+}
@@ -1,135 +1,339 @@
 package ops
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/format"
-	"cuelang.org/go/cue/load"
+	cuejson "cuelang.org/go/encoding/json"
 
 	"github.com/hofstadter-io/hof/cmd/hof/flags"
-	"github.com/hofstadter-io/hof/lib/cuetils"
+	"github.com/hofstadter-io/hof/lib/config"
+	"github.com/hofstadter-io/hof/lib/errs"
+	"github.com/hofstadter-io/hof/lib/printer"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
-const JUMP_FILE_NAME = "jumps.cue"
+// JUMP_FILE_NAME is where jumps are kept, under your home directory
+// and under the workspace root, the nearest directory up with a cue.mods file.
+const JUMP_FILE_NAME = ".hof/jumps.cue"
 
-func RunJumpFromArgs(args []string) error {
-	// fmt.Println("lib/ops.Jump", args)
+// The scopes of jumps. Workspace jumps hide global ones of the same name.
+const (
+	JumpScopeGlobal    = "global"
+	JumpScopeWorkspace = "workspace"
+)
+
+// Jump is a directory shortcut, a sequence of commands, or commands run in a directory.
+// Both may use the fields of the context in use, as in {{ .Project }}, see #Jump.
+type Jump struct {
+	Name  string `json:"-"`
+	Scope string `json:"-"`
+	// Base is the directory a relative Dir is from, the home directory or the workspace root
+	Base string `json:"-"`
+
+	Dir  string   `json:"Dir,omitempty"`
+	Cmds []string `json:"Cmds,omitempty"`
+}
+
+// JumpFile holds the jumps of a scope.
+type JumpFile struct {
+	File  string           `json:"-"`
+	Scope string           `json:"-"`
+	Jumps map[string]*Jump `json:"Jumps,omitempty"`
+}
+
+// JumpFiles returns the jump files, global first, whether or not they exist.
+// There is no workspace file outside of a workspace.
+func JumpFiles() ([]*JumpFile, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	files := []*JumpFile{{File: filepath.Join(home, JUMP_FILE_NAME), Scope: JumpScopeGlobal}}
 
-	jfn := JUMP_FILE_NAME
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if root := config.WorkspaceRoot(cwd); root != "" {
+		files = append(files, &JumpFile{File: filepath.Join(root, JUMP_FILE_NAME), Scope: JumpScopeWorkspace})
+	}
 
-	// Check our flags
-	if flags.RootResourcesDirPflag != "" {
-		rDir := flags.RootResourcesDirPflag
-		jfn = filepath.Join(rDir, JUMP_FILE_NAME)
-	} else if flags.RootLocalPflag {
-		rDir := "resources"
-		jfn = filepath.Join(rDir, JUMP_FILE_NAME)
-	} else {
-		bDir, err := os.UserConfigDir()
+	for _, F := range files {
+		err = F.Load()
 		if err != nil {
-			return err
+			return files, err
 		}
-		rDir := "resources"
-		jfn = filepath.Join(bDir, "hof", rDir, JUMP_FILE_NAME)
 	}
-	exists, _ := yagu.CheckPathExists(jfn)
-	if !exists {
-		content := `
-		package resources
+	return files, nil
+}
 
-		jumps: {}
-		`
-		yagu.Mkdir(filepath.Dir(jfn))
-		ioutil.WriteFile(jfn, []byte(content), 0644)
+// Load reads the jump file, which is empty when it does not exist yet.
+func (F *JumpFile) Load() error {
+	F.Jumps = map[string]*Jump{}
+	src, err := ioutil.ReadFile(F.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	var err error
+	var r cue.Runtime
+	inst, err := r.Compile(F.File, src)
+	if err != nil {
+		return fmt.Errorf("While reading the jumps in %s\n%w\n", F.File, err)
+	}
+	data, err := inst.Value().MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("While reading the jumps in %s\n%w\n", F.File, err)
+	}
+	err = json.Unmarshal(data, F)
+	if err != nil {
+		return fmt.Errorf("While reading the jumps in %s\n%w\n", F.File, err)
+	}
 
-	entrypoints := []string {jfn}
+	for name, J := range F.Jumps {
+		J.Name, J.Scope, J.Base = name, F.Scope, filepath.Dir(filepath.Dir(F.File))
+	}
+	return nil
+}
 
-	rCRT := &cuetils.CueRuntime{
-		Entrypoints: entrypoints,
-		CueConfig: &load.Config{
-			ModuleRoot: "",
-			Module: "",
-			Package: "",
-			Dir: "",
-		},
+// Save writes the jump file, creating its directory as needed.
+// Comments in the file are not kept.
+func (F *JumpFile) Save() error {
+	// indented, so the formatter keeps a field per line, and without escaping commands such as a > b
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(F)
+	if err != nil {
+		return err
+	}
+	expr, err := cuejson.Extract(F.File, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	var node ast.Node = expr
+	if sl, ok := expr.(*ast.StructLit); ok {
+		node = &ast.File{Decls: sl.Elts}
+	}
+	src, err := format.Node(node, format.Simplify())
+	if err != nil {
+		return err
 	}
 
-	err = rCRT.Load()
+	err = yagu.Mkdir(filepath.Dir(F.File))
 	if err != nil {
 		return err
 	}
+	return ioutil.WriteFile(F.File, src, 0644)
+}
 
-	if len(args) == 0 {
-		bytes, err := format.Node(rCRT.CueValue.Syntax())
-		if err != nil {
-			return err
-		}
-		fmt.Printf("%s\n\n", string(bytes))
-		return nil
+// jumpScope returns the jump file add and rm work on, the global one with --global,
+// and otherwise the workspace one when there is a workspace.
+func jumpScope(files []*JumpFile) *JumpFile {
+	if flags.RootGlobalPflag {
+		return files[0]
 	}
+	return files[len(files)-1]
+}
 
-	rest := []string{}
-	for i, arg := range args {
-		if arg == "__" {
-			rest = args[i+1:]
-			args = args[:i]
+// findJump returns the jump name, from the workspace before the global scope.
+func findJump(files []*JumpFile, name string) (*Jump, error) {
+	for i := len(files) - 1; i >= 0; i-- {
+		if J, ok := files[i].Jumps[name]; ok {
+			return J, nil
 		}
 	}
+	return nil, errs.WithCode(errs.CodeNotFound, "use 'hof jump list' to see them", fmt.Errorf("jump %q not found", name))
+}
 
-	// fmt.Println("jump", args, rest)
+// JumpNames returns the names of the workspace and global jumps, for shell completion.
+func JumpNames() []string {
+	files, _ := JumpFiles()
+	seen := map[string]bool{}
+	var names []string
+	for _, F := range files {
+		for name := range F.Jumps {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
 
-	args = append([]string{"jumps"}, args...)
+// JumpAdd adds, or replaces, the jump name, with a directory, commands, or both.
+func JumpAdd(name, dir string, cmds []string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("bad jump name %q", name)
+	}
+	if dir == "" && len(cmds) == 0 {
+		return fmt.Errorf("a jump needs a --dir, a --cmd, or both")
+	}
+	files, err := JumpFiles()
+	if err != nil {
+		return err
+	}
+	F := jumpScope(files)
+	F.Jumps[name] = &Jump{Dir: dir, Cmds: cmds}
+	return F.Save()
+}
 
-	V := rCRT.CueValue.Lookup(args...)
+// JumpRemove removes the jump name.
+func JumpRemove(name string) error {
+	files, err := JumpFiles()
+	if err != nil {
+		return err
+	}
+	F := jumpScope(files)
+	if _, ok := F.Jumps[name]; !ok {
+		return fmt.Errorf("jump %q not found in %s", name, F.File)
+	}
+	delete(F.Jumps, name)
+	return F.Save()
+}
 
-	switch K := V.Kind(); K {
+// JumpList prints the jumps in format, see the printer package.
+func JumpList(format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+	files, err := JumpFiles()
+	if err != nil {
+		return err
+	}
 
-	// we found a jump, now leap!
-	case cue.StringKind:
-		str, err := V.String()
-		if err != nil {
-			return err
+	rows := printer.NewRows("name", "scope", "dir", "cmds")
+	seen := map[string]bool{}
+	for i := len(files) - 1; i >= 0; i-- {
+		F := files[i]
+		names := make([]string, 0, len(F.Jumps))
+		for name := range F.Jumps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// hidden by the workspace
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			J := F.Jumps[name]
+			rows.Add(name, J.Scope, J.Dir, strings.Join(J.Cmds, " && "))
 		}
-		return runJumpCommand(str, rest)
+	}
+	if len(rows.Rows) == 0 && (format == "" || format == printer.Text) {
+		fmt.Println("no jumps, use 'hof jump add <name>' to add one")
+		return nil
+	}
+	return printer.Print(os.Stdout, format, rows)
+}
+
+// JumpRun runs the commands of the jump name, in its directory when it has one, passing args to each.
+// A jump with only a directory prints it, for the shell to cd into, as in
+//
+//   cd $(hof jump proj)
+func JumpRun(name string, args []string) error {
+	files, err := JumpFiles()
+	if err != nil {
+		return err
+	}
+	J, err := findJump(files, name)
+	if err != nil {
+		return err
+	}
+	vars, err := jumpVars()
+	if err != nil {
+		return err
+	}
 
-	// there's more so print it
-	case cue.StructKind:
-		bytes, err := format.Node(V.Syntax())
+	dir := ""
+	if J.Dir != "" {
+		dir, err = expandJump(J, J.Dir, vars)
 		if err != nil {
 			return err
 		}
+		dir = jumpDir(J, dir)
+	}
 
-		fmt.Printf("%s\n\n", string(bytes))
+	if len(J.Cmds) == 0 {
+		fmt.Println(dir)
 		return nil
-
-	// TODO, case for list of strings for multiple commands to run?
-
-	// some sort of error or non-existant path
-	case cue.BottomKind:
-		return fmt.Errorf("Path not found: %q    (or it has errors)", args)
-
-	// unhandled kind
-	default:
-		return fmt.Errorf("Unsupported kind '%q' at requested path %q", K, args)
 	}
 
+	for _, C := range J.Cmds {
+		script, err := expandJump(J, C, vars)
+		if err != nil {
+			return err
+		}
+		// the jump name is $0, and args are $1...
+		cmd := exec.Command("bash", append([]string{"-c", script, name}, args...)...)
+		cmd.Dir = dir
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err = cmd.Run()
+		if err != nil {
+			return fmt.Errorf("jump %s: %q failed\n%w\n", name, script, err)
+		}
+	}
 	return nil
 }
 
-func runJumpCommand(cmd string, args []string) error {
-	// TODO, handle or pass in flags
-	// TODO, how to parse? is that why runtime is a thing? ('bash -c'), except we are assuming these are all bash
-	// fmt.Printf("running: %q\n", cmd)
+// jumpVars returns the fields of the context in use, or none without a context.
+func jumpVars() (config.Context, error) {
+	F, err := config.LoadContextFile()
+	if err != nil {
+		return nil, err
+	}
+	name, err := F.Selected()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return config.Context{}, nil
+	}
+	return F.Contexts[name], nil
+}
+
+// expandJump fills the context fields in s, which must all be set.
+func expandJump(J *Jump, s string, vars config.Context) (string, error) {
+	t, err := template.New(J.Name).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("While reading the jump %s\n%w\n", J.Name, err)
+	}
+	var b bytes.Buffer
+	err = t.Execute(&b, map[string]interface{}(vars))
+	if err != nil {
+		return "", fmt.Errorf("jump %s needs a field the context in use does not set, see 'hof context get'\n%w\n", J.Name, err)
+	}
+	return b.String(), nil
+}
 
-	out, err := yagu.BashTmpScriptWithArgs(cmd, args)
-	fmt.Print(out)
-	return err
+// jumpDir returns dir with ~ as the home directory, and relative to the base of the jump.
+func jumpDir(J *Jump, dir string) string {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(J.Base, dir)
+	}
+	return dir
 }
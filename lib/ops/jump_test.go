@@ -0,0 +1,193 @@
+package ops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/config"
+)
+
+// inWorkspace runs the rest of a test in a new workspace, with a home directory of its own.
+// Tests using it can not be parallel.
+func inWorkspace(t *testing.T) (dir, home string) {
+	dir, err := ioutil.TempDir("", "hof-jump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home = filepath.Join(dir, "home")
+	dir = filepath.Join(dir, "work")
+	for _, d := range []string{home, filepath.Join(dir, "sub")} {
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "cue.mods"), "module github.com/test/jumps\n\ncue v0.2.2\n")
+
+	olddir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldhome := os.Getenv("HOME")
+	err = os.Chdir(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", home)
+	t.Cleanup(func() {
+		os.Chdir(olddir)
+		os.Setenv("HOME", oldhome)
+		os.RemoveAll(filepath.Dir(dir))
+	})
+	return dir, home
+}
+
+func writeFile(t *testing.T, file, content string) {
+	err := ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestJumps(t *testing.T) {
+	dir, home := inWorkspace(t)
+	file := filepath.Join(dir, JUMP_FILE_NAME)
+
+	for _, J := range []Jump{
+		{Name: "build", Dir: ".", Cmds: []string{"echo built $1 > out.txt"}},
+		{Name: "docs", Dir: "sub", Cmds: []string{"ls"}},
+		{Name: "tmp", Dir: "tmp", Cmds: []string{"true"}},
+	} {
+		err := JumpAdd(J.Name, J.Dir, J.Cmds)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := `Jumps: {
+	build: {
+		Dir: "."
+		Cmds: [
+			"echo built $1 > out.txt",
+		]
+	}
+	docs: {
+		Dir: "sub"
+		Cmds: [
+			"ls",
+		]
+	}
+	tmp: {
+		Dir: "tmp"
+		Cmds: [
+			"true",
+		]
+	}
+}
+`
+	if got := readFile(t, file); got != expect {
+		t.Fatalf("after add:\ngot:\n%s\nwant:\n%s", got, expect)
+	}
+
+	// commands run in the jump dir, from the workspace root, with the args
+	err := JumpRun("build", []string{"fast"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(dir, "out.txt")); got != "built fast\n" {
+		t.Fatalf("expected the build jump to run in the workspace root, got %q", got)
+	}
+
+	err = JumpRemove("tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, file); strings.Contains(got, "tmp") || !strings.Contains(got, "docs") {
+		t.Fatalf("expected only tmp removed, got:\n%s", got)
+	}
+	err = JumpRemove("tmp")
+	if err == nil || !strings.Contains(err.Error(), `jump "tmp" not found`) {
+		t.Fatalf("expected removing tmp again to fail, got %v", err)
+	}
+
+	// workspace jumps hide global ones
+	flags.RootGlobalPflag = true
+	err = JumpAdd("docs", "~/docs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = JumpAdd("home", "~", nil)
+	flags.RootGlobalPflag = false
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, JUMP_FILE_NAME)); err != nil {
+		t.Fatalf("expected a global jump file, got %v", err)
+	}
+	if names := JumpNames(); !reflect.DeepEqual(names, []string{"build", "docs", "home"}) {
+		t.Fatalf("got names %q", names)
+	}
+	files, err := JumpFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	J, err := findJump(files, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if J.Scope != JumpScopeWorkspace || jumpDir(J, J.Dir) != filepath.Join(dir, "sub") {
+		t.Fatalf("expected the workspace docs jump, got %s %s", J.Scope, jumpDir(J, J.Dir))
+	}
+	J, err = findJump(files, "home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if J.Scope != JumpScopeGlobal || jumpDir(J, J.Dir) != home {
+		t.Fatalf("expected the global home jump, got %s %s", J.Scope, jumpDir(J, J.Dir))
+	}
+	_, err = findJump(files, "nope")
+	if err == nil || !strings.Contains(err.Error(), `jump "nope" not found`) {
+		t.Fatalf("expected an unknown jump to fail, got %v", err)
+	}
+
+	for _, bad := range [][]string{{"", "."}, {"a b", "."}, {"empty", ""}} {
+		err = JumpAdd(bad[0], bad[1], nil)
+		if err == nil {
+			t.Errorf("expected adding %q with dir %q to fail", bad[0], bad[1])
+		}
+	}
+}
+
+func TestExpandJump(t *testing.T) {
+	J := &Jump{Name: "proj"}
+	vars := config.Context{"Project": "api", "Account": "acme"}
+
+	got, err := expandJump(J, "~/src/{{ .Account }}/{{ .Project }}", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "~/src/acme/api" {
+		t.Fatalf("got %q", got)
+	}
+
+	_, err = expandJump(J, "{{ .Region }}", vars)
+	if err == nil || !strings.Contains(err.Error(), "jump proj needs a field the context in use does not set") {
+		t.Fatalf("expected a missing field to fail, got %v", err)
+	}
+}
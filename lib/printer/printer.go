@@ -0,0 +1,157 @@
+// Package printer writes the results of list, get, and info commands
+// in the format chosen with -O, --output-format, so that scripts can read them.
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/hofstadter-io/hof/lib/errs"
+)
+
+// The formats Print understands. Commands may add their own, such as dot for 'hof mod graph'.
+const (
+	Text  = "text"
+	JSON  = "json"
+	YAML  = "yaml"
+	Table = "table"
+)
+
+// Tabler is a result which can be printed as a table.
+type Tabler interface {
+	Table() *Rows
+}
+
+// Texter is a result with a text format of its own.
+type Texter interface {
+	Text() string
+}
+
+// Rows is a table of named columns. It is encoded as a list of objects,
+// keyed by the column names in order, for json and yaml.
+type Rows struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// NewRows returns an empty table with columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{Columns: columns, Rows: [][]string{}}
+}
+
+// Add appends a row, with a cell for each column.
+func (R *Rows) Add(cells ...string) {
+	R.Rows = append(R.Rows, cells)
+}
+
+func (R *Rows) Table() *Rows {
+	return R
+}
+
+func (R *Rows) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i, row := range R.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("{")
+		for j, col := range R.Columns {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			k, _ := json.Marshal(col)
+			v, _ := json.Marshal(cell)
+			b.Write(k)
+			b.WriteString(":")
+			b.Write(v)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]")
+	return b.Bytes(), nil
+}
+
+// CheckFormat returns an error when format is not one Print understands, or one of extra.
+func CheckFormat(format string, extra ...string) error {
+	known := append([]string{Text, JSON, YAML, Table}, extra...)
+	if format == "" {
+		return nil
+	}
+	for _, k := range known {
+		if format == k {
+			return nil
+		}
+	}
+	err := fmt.Errorf("Unknown output format %q, should be one of %s", format, strings.Join(known, ", "))
+	return errs.WithCode(errs.CodeBadFormat, "", err)
+}
+
+// Print writes v to w in format. Json and yaml encode v, table needs a Tabler,
+// and text, the default, uses the Texter or else Tabler of v, and json otherwise.
+func Print(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "", Text:
+		if t, ok := v.(Texter); ok {
+			_, err := io.WriteString(w, t.Text())
+			return err
+		}
+		if t, ok := v.(Tabler); ok {
+			return writeTable(w, t.Table())
+		}
+		return Print(w, JSON, v)
+
+	case Table:
+		t, ok := v.(Tabler)
+		if !ok {
+			return fmt.Errorf("this result can not be printed as a table, use json or yaml")
+		}
+		return writeTable(w, t.Table())
+
+	case JSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+
+	case YAML:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+
+	default:
+		return CheckFormat(format)
+	}
+}
+
+func writeTable(w io.Writer, R *Rows) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	header := make([]string, len(R.Columns))
+	for i, col := range R.Columns {
+		header[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range R.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,63 @@
+package printer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/printer"
+)
+
+func TestPrintRows(t *testing.T) {
+	R := printer.NewRows("module", "version")
+	R.Add("github.com/a/b", "v1.2.0")
+	R.Add("github.com/c/d", "v0.1.0")
+
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{"", "MODULE          VERSION\ngithub.com/a/b  v1.2.0\ngithub.com/c/d  v0.1.0\n"},
+		{"table", "MODULE          VERSION\ngithub.com/a/b  v1.2.0\ngithub.com/c/d  v0.1.0\n"},
+		{"json", `[
+  {
+    "module": "github.com/a/b",
+    "version": "v1.2.0"
+  },
+  {
+    "module": "github.com/c/d",
+    "version": "v0.1.0"
+  }
+]
+`},
+		{"yaml", "- module: github.com/a/b\n  version: v1.2.0\n- module: github.com/c/d\n  version: v0.1.0\n"},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		err := printer.Print(&b, tt.format, R)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.format, err)
+		}
+		if b.String() != tt.expect {
+			t.Errorf("%q:\nexpected:\n%s\ngot:\n%s", tt.format, tt.expect, b.String())
+		}
+	}
+}
+
+func TestPrintFormats(t *testing.T) {
+	var b bytes.Buffer
+	err := printer.Print(&b, "table", map[string]int{"a": 1})
+	if err == nil {
+		t.Errorf("expected an error printing a map as a table")
+	}
+
+	err = printer.Print(&b, "xml", map[string]int{"a": 1})
+	if err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+
+	err = printer.CheckFormat("dot", "dot")
+	if err != nil {
+		t.Errorf("expected dot to be known: %v", err)
+	}
+}
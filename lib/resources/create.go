@@ -2,10 +2,17 @@ package resources
 
 import (
 	"fmt"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
 )
 
 func RunCreateFromArgs(args []string) error {
+	inputs, args, err := ReadInputs(args)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("lib/resources.Create", args)
 
-	return nil
+	return printInputs(inputs, flags.RootLabelsPflag)
 }
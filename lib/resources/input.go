@@ -0,0 +1,225 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+// StdinInput is the input which reads documents from stdin, as in
+//
+//   cue export ./k8s | hof create -
+const StdinInput = "-"
+
+// inputExts are the files read from directories, and their formats
+var inputExts = map[string]string{
+	".cue":  "cue",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+// Input is a document given to a resource command, and where it was read from.
+type Input struct {
+	Source string
+	Value  cue.Value
+}
+
+// ReadInputs reads the documents given with --input and in args, which are
+//
+//   -         documents on stdin
+//   @file     a cue, json, or yaml file
+//   @dir      every cue, json, and yaml file in a directory
+//
+// --input takes the same, without the @. The format of each is --input-format,
+// or found by extension, or guessed, see readInput. The other args are returned,
+// as the resources named.
+func ReadInputs(args []string) (inputs []Input, names []string, err error) {
+	srcs := append([]string{}, flags.RootInputPflag...)
+	for _, arg := range args {
+		switch {
+		case arg == StdinInput:
+			srcs = append(srcs, arg)
+		case strings.HasPrefix(arg, "@"):
+			srcs = append(srcs, arg[1:])
+		default:
+			names = append(names, arg)
+		}
+	}
+
+	stdin := false
+	for _, src := range srcs {
+		var ins []Input
+		if src == StdinInput {
+			if stdin {
+				return nil, nil, fmt.Errorf("stdin can only be an input once")
+			}
+			stdin = true
+			ins, err = readInput(os.Stdin, src, flags.RootInputFormatPflag)
+		} else {
+			ins, err = readPath(src, flags.RootInputFormatPflag)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("While reading input %s\n%w\n", src, err)
+		}
+		inputs = append(inputs, ins...)
+	}
+
+	return inputs, names, nil
+}
+
+// readPath reads a file, or the files of a directory with the extensions in inputExts.
+func readPath(path, format string) ([]Input, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return readFile(path, format)
+	}
+
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+
+	var inputs []Input
+	for _, fi := range fis {
+		if fi.IsDir() || inputExts[filepath.Ext(fi.Name())] == "" {
+			continue
+		}
+		ins, err := readFile(filepath.Join(path, fi.Name()), format)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, ins...)
+	}
+	return inputs, nil
+}
+
+func readFile(file, format string) ([]Input, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = inputExts[filepath.Ext(file)]
+	}
+	return readInput(f, file, format)
+}
+
+// readInput reads the documents of in, which are cue, json, or yaml.
+// Json and Yaml may hold several documents, newline delimited or separated with ---
+// When format is empty, json is guessed from a leading { or [,
+// then cue when it compiles, and yaml otherwise.
+func readInput(in io.Reader, source, format string) ([]Input, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = guessFormat(source, data)
+	}
+
+	var vals []cue.Value
+	switch format {
+	case "cue":
+		var r cue.Runtime
+		i, err := r.Compile(source, data)
+		if err != nil {
+			return nil, err
+		}
+		v := i.Value()
+		if v.Err() != nil {
+			return nil, v.Err()
+		}
+		vals = []cue.Value{v}
+
+	case "json", "yaml", "yml":
+		vals, err = structural.ReadDocs(bytes.NewReader(data), format)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("Unknown input format %q, should be one of cue, json, yaml", format)
+	}
+
+	inputs := make([]Input, 0, len(vals))
+	for _, v := range vals {
+		inputs = append(inputs, Input{Source: source, Value: v})
+	}
+	return inputs, nil
+}
+
+func guessFormat(source string, data []byte) string {
+	trimmed := bytes.TrimLeftFunc(data, unicode.IsSpace)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return "json"
+	}
+	var r cue.Runtime
+	if _, err := r.Compile(source, data); err == nil {
+		return "cue"
+	}
+	return "yaml"
+}
+
+// Resources returns the resources of the input, as <resource>/<name>.
+// A document with kind and metadata.name, as kubectl takes, is a single resource,
+// and others hold resources by type and then name, as the workspace resources do.
+func (I Input) Resources() ([]string, error) {
+	kind, kerr := I.Value.Lookup("kind").String()
+	name, nerr := I.Value.Lookup("metadata", "name").String()
+	if kerr == nil && nerr == nil {
+		return []string{kind + "/" + name}, nil
+	}
+
+	S, err := I.Value.Struct()
+	if err != nil {
+		return nil, fmt.Errorf("%s: a resource should have kind and metadata.name, or be <resource>: <name>: {...}", I.Source)
+	}
+
+	var rs []string
+	iter := S.Fields()
+	for iter.Next() {
+		rType := iter.Label()
+		R, err := iter.Value().Struct()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s should be <name>: {...}", I.Source, rType)
+		}
+		rIter := R.Fields()
+		for rIter.Next() {
+			rs = append(rs, rType+"/"+rIter.Label())
+		}
+	}
+	return rs, nil
+}
+
+// printInputs lists the resources of each input, as the resource commands do for args.
+func printInputs(inputs []Input, labels []string) error {
+	for _, I := range inputs {
+		rs, err := I.Resources()
+		if err != nil {
+			return err
+		}
+		for _, R := range rs {
+			flds := strings.SplitN(R, "/", 2)
+			fmt.Println(" -", flds[0], flds[1], labels, "from", I.Source)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+func TestReadInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"app.cue":       "kind: \"Deployment\"\nmetadata: name: \"api\"\n",
+		"k8s/api.yaml":  "kind: Service\nmetadata:\n  name: api\n---\nkind: ConfigMap\nmetadata:\n  name: env\n",
+		"k8s/dm.json":   `{ "datamodel": { "users": {} } }`,
+		"k8s/notes.txt": "notes are skipped\n",
+		"k8s/sub/x.cue": "kind: \"Skipped\"\nmetadata: name: \"sub\"\n",
+		"stream.ndjson": "{\"kind\": \"Job\", \"metadata\": {\"name\": \"a\"}}\n{\"kind\": \"Job\", \"metadata\": {\"name\": \"b\"}}\n",
+		"resources":     "# not cue\nsecret:\n  db: {}\n  api: {}\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name   string
+		args   []string
+		input  []string
+		format string
+		expect []string
+		names  []string
+		err    string
+	}{{
+		name:   "file",
+		args:   []string{"@" + path("app.cue"), "web"},
+		expect: []string{"Deployment/api from app.cue"},
+		names:  []string{"web"},
+	}, {
+		name: "directory",
+		args: []string{"@" + path("k8s")},
+		expect: []string{
+			"Service/api from k8s/api.yaml",
+			"ConfigMap/env from k8s/api.yaml",
+			"datamodel/users from k8s/dm.json",
+		},
+	}, {
+		name:   "json stream",
+		input:  []string{path("stream.ndjson")},
+		format: "json",
+		expect: []string{"Job/a from stream.ndjson", "Job/b from stream.ndjson"},
+	}, {
+		name:   "guessed yaml",
+		args:   []string{"@" + path("resources")},
+		expect: []string{"secret/db from resources", "secret/api from resources"},
+	}, {
+		name:   "bad format",
+		args:   []string{"@" + path("app.cue")},
+		format: "toml",
+		err:    `Unknown input format "toml"`,
+	}, {
+		name: "missing",
+		args: []string{"@" + path("missing.cue")},
+		err:  "While reading input",
+	}}
+
+	defer func() { flags.RootInputPflag, flags.RootInputFormatPflag = nil, "" }()
+	for _, tt := range tests {
+		flags.RootInputPflag, flags.RootInputFormatPflag = tt.input, tt.format
+		inputs, names, err := ReadInputs(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		var got []string
+		for _, I := range inputs {
+			rs, err := I.Resources()
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			src, _ := filepath.Rel(dir, I.Source)
+			for _, R := range rs {
+				got = append(got, R+" from "+filepath.ToSlash(src))
+			}
+		}
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.expect)
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%s: got names %q, want %q", tt.name, names, tt.names)
+		}
+	}
+}
+
+func TestInputResources(t *testing.T) {
+	ins, err := readInput(strings.NewReader("[1, 2]"), "list.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ins[0].Resources()
+	if err == nil || !strings.Contains(err.Error(), "list.json: a resource should have kind and metadata.name") {
+		t.Fatalf("expected a list not to be a resource, got %v", err)
+	}
+
+	ins, err = readInput(strings.NewReader("secret: 1"), "bad.cue", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ins[0].Resources()
+	if err == nil || !strings.Contains(err.Error(), "bad.cue: secret should be <name>: {...}") {
+		t.Fatalf("expected resources to be structs, got %v", err)
+	}
+}
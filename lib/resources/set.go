@@ -8,6 +8,11 @@ import (
 )
 
 func RunSetFromArgs(args []string) error {
+	inputs, args, err := ReadInputs(args)
+	if err != nil {
+		return err
+	}
+
 	labels := flags.RootLabelsPflag
 	fmt.Println("lib/resources.Set")
 
@@ -41,5 +46,5 @@ func RunSetFromArgs(args []string) error {
 		// check resource type, mayeb do different things
 	}
 
-	return nil
+	return printInputs(inputs, labels)
 }
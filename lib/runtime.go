@@ -16,6 +16,7 @@ import (
 
 	"github.com/hofstadter-io/hof/cmd/hof/flags"
 	"github.com/hofstadter-io/hof/lib/gen"
+	"github.com/hofstadter-io/hof/lib/logs"
 	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
@@ -26,7 +27,6 @@ type Runtime struct {
 
 	// TODO configuration
 	mode string
-	verbose bool
 
 	// Cue ralated
 	CueRT           *cue.Runtime
@@ -61,7 +61,7 @@ func (R *Runtime) LoadCue() []error {
 
 	for _, bi := range BIS {
 		if bi.Err != nil {
-			fmt.Println("LoadCue:", bi.Err, bi.Incomplete, bi.DepsErrors)
+			logs.Scope("gen").Debug("loading cue", "err", bi.Err, "incomplete", bi.Incomplete, "deps", bi.DepsErrors)
 			es := errors.Errors(bi.Err)
 			for _, e := range es {
 				errs = append(errs, e.(error))
@@ -191,7 +191,7 @@ func (R *Runtime) RunGenerators() []error {
 	// var err error
 
 	/*
-	R.Shadow, err = gen.LoadShadow("", R.verbose)
+	R.Shadow, err = gen.LoadShadow("")
 	if err != nil {
 		errs = append(errs, err)
 		return errs
@@ -205,7 +205,7 @@ func (R *Runtime) RunGenerators() []error {
 			continue
 		}
 
-		shadow, err := gen.LoadShadow(G.Name, R.verbose)
+		shadow, err := gen.LoadShadow(G.Name)
 		if err != nil {
 			errs = append(errs, err)
 			return errs
@@ -392,18 +392,44 @@ func (R *Runtime) WriteOutput() []error {
 	return errs
 }
 
-func (R *Runtime) PrintStats() {
+// CalcStats totals the stats of each generator, once per run
+func (R *Runtime) CalcStats() {
 	for _, G := range R.Generators {
 		if G.Disabled {
 			continue
 		}
 
 		G.Stats.CalcTotals(G)
+	}
+}
+
+func (R *Runtime) PrintStats() {
+	for _, G := range R.Generators {
+		if G.Disabled {
+			continue
+		}
+
 		fmt.Printf("\n%s\n==========================\n", G.Name)
 		fmt.Println(G.Stats)
 	}
 }
 
+// RecordRuns adds the generators of this run to the runs file, see gen.RecordRuns
+func (R *Runtime) RecordRuns(start time.Time, elapsed time.Duration) error {
+	var runs []gen.Run
+	for _, G := range R.Generators {
+		if G.Disabled {
+			continue
+		}
+
+		runs = append(runs, gen.NewRun(G, R.Entrypoints, start, elapsed))
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	return gen.RecordRuns(runs)
+}
+
 func (R *Runtime) PrintMergeConflicts() {
 	for _, G := range R.Generators {
 		if G.Disabled {
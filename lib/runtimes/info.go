@@ -2,9 +2,17 @@ package runtimes
 
 import (
 	"fmt"
+
+	"github.com/hofstadter-io/hof/lib/printer"
 )
 
-func RunInfoFromArgs(args []string) error {
+// RunInfoFromArgs prints in format, see the printer package
+func RunInfoFromArgs(args []string, format string) error {
+	err := printer.CheckFormat(format)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("lib/runtimes.Info", args)
 
 	return nil
@@ -0,0 +1,24 @@
+package runtimes
+
+import (
+	"sort"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// Builtin are the runtimes hof has a schema for, see schema/runtimes
+var Builtin = []string{"bash", "go", "js", "py"}
+
+// Names returns the builtin runtimes and those of the workspace, the fields with a @runtime() attribute
+// in --runtimes-dir, or the current directory.
+func Names() []string {
+	dir := "."
+	if flags.RootRuntimesDirPflag != "" {
+		dir = flags.RootRuntimesDirPflag
+	}
+	names := append([]string{}, Builtin...)
+	names = append(names, cuetils.AttrFieldNames(dir, "runtime")...)
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,44 @@
+package runtimes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+)
+
+func TestNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-runtimes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "rt.cue"), []byte(`package rt
+
+deno: {} @runtime()
+ruby: {} @runtime()
+notes: {}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := flags.RootRuntimesDirPflag
+	defer func() { flags.RootRuntimesDirPflag = old }()
+
+	// the workspace runtimes are sorted in with the builtin ones
+	flags.RootRuntimesDirPflag = dir
+	expect := []string{"bash", "deno", "go", "js", "py", "ruby"}
+	if got := Names(); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got %q, want %q", got, expect)
+	}
+
+	flags.RootRuntimesDirPflag = filepath.Join(dir, "missing")
+	if got := Names(); !reflect.DeepEqual(got, Builtin) {
+		t.Fatalf("expected only the builtin runtimes, got %q", got)
+	}
+}
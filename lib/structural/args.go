@@ -0,0 +1,316 @@
+package structural
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	cuejson "cuelang.org/go/encoding/json"
+	cueyaml "cuelang.org/go/encoding/yaml"
+
+	"github.com/clbanning/mxj"
+	"github.com/naoina/toml"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// opValue is the value of an <op> argument, see 'hof st --help',
+// along with the file it was read from, if any.
+type opValue struct {
+	Value cue.Value
+	File  string
+	Path  string
+}
+
+// loadOp returns the value of an <op>, which is one of
+//
+//   @file.{cue,json,yaml,yml,toml,xml}[:path]  a file, or a path into it
+//   path                                        a path into the entrypoints, when there are some
+//   expr                                        a complete Cue value otherwise
+//
+// where paths are dot separated labels, as in a.b.c or #Schema.a
+func loadOp(op string, crt *cuetils.CueRuntime) (opValue, error) {
+	if strings.HasPrefix(op, "@") {
+		file, path := op[1:], ""
+		if i := strings.Index(file, ":"); i >= 0 {
+			file, path = file[:i], strings.TrimSpace(file[i+1:])
+		}
+		v, err := loadFile(file)
+		if err != nil {
+			return opValue{}, err
+		}
+		if path != "" {
+			v, err = lookupPath(v, path)
+			if err != nil {
+				return opValue{}, fmt.Errorf("While looking up %q in %s\n%w\n", path, file, err)
+			}
+		}
+		return opValue{Value: v, File: file, Path: path}, nil
+	}
+
+	if crt != nil {
+		v, err := lookupPath(crt.CueValue, op)
+		if err != nil {
+			return opValue{}, fmt.Errorf("While looking up %q in the entrypoints\n%w\n", op, err)
+		}
+		return opValue{Value: v}, nil
+	}
+
+	i, err := r.Compile("", op)
+	if err != nil {
+		return opValue{}, err
+	}
+	v := i.Value()
+	return opValue{Value: v}, v.Err()
+}
+
+// loadFile reads a Cue, JSON, Yaml, TOML, or XML file, by its extension.
+func loadFile(file string) (cue.Value, error) {
+	i, err := loadInstance(file)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := i.Value()
+	return v, v.Err()
+}
+
+// loadInstance is loadFile returning the instance, for the encoders which need one.
+func loadInstance(file string) (*cue.Instance, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var i *cue.Instance
+	switch filepath.Ext(file) {
+	case ".json":
+		expr, err := cuejson.Extract(file, data)
+		if err != nil {
+			return nil, err
+		}
+		i, err = r.CompileExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+
+	case ".yaml", ".yml":
+		f, err := cueyaml.Extract(file, data)
+		if err != nil {
+			return nil, err
+		}
+		i, err = r.CompileFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+	case ".toml", ".xml":
+		// decoded to Go values first, so the order of fields is not kept
+		var x interface{}
+		if filepath.Ext(file) == ".toml" {
+			err = toml.Unmarshal(data, &x)
+		} else {
+			x, err = mxj.NewMapXml(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := cuejson.Extract(file, data)
+		if err != nil {
+			return nil, err
+		}
+		i, err = r.CompileExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		i, err = r.Compile(file, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return i, i.Err
+}
+
+func lookupPath(v cue.Value, path string) (cue.Value, error) {
+	labels, err := splitPath(path)
+	if err != nil {
+		return v, err
+	}
+	for _, label := range labels {
+		if strings.HasPrefix(label, "#") {
+			v = v.LookupDef(label)
+		} else {
+			v = v.Lookup(label)
+		}
+	}
+	if !v.Exists() {
+		return v, fmt.Errorf("%s not found", path)
+	}
+	return v, v.Err()
+}
+
+// splitPath splits a path into its labels, which are separated by dots
+// and may be quoted when they are not identifiers, as in a."b.c".d
+func splitPath(path string) ([]string, error) {
+	var labels []string
+	rest := strings.TrimSpace(path)
+	for rest != "" {
+		var label string
+		if rest[0] == '"' {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil, fmt.Errorf("Unterminated quote in path %s", path)
+			}
+			var err error
+			label, err = strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("Bad quoted label in path %s: %w", path, err)
+			}
+			rest = rest[end+1:]
+			if rest != "" && rest[0] != '.' {
+				return nil, fmt.Errorf("Expected a dot after %s in path %s", strconv.Quote(label), path)
+			}
+		} else {
+			i := strings.Index(rest, ".")
+			if i < 0 {
+				i = len(rest)
+			}
+			label, rest = rest[:i], rest[i:]
+		}
+		if label == "" {
+			return nil, fmt.Errorf("Empty label in path %s", path)
+		}
+		labels = append(labels, label)
+		rest = strings.TrimPrefix(rest, ".")
+	}
+	return labels, nil
+}
+
+// encodeValue formats v as cue, json, yaml, toml, xml, or text, which is a string as is
+// and other values as JSON on one line. The format defaults to that of file.
+func encodeValue(v cue.Value, format, file string) ([]byte, error) {
+	switch format {
+	case "":
+		return formatValue(v, file)
+	case "cue":
+		return formatValue(v, "")
+	case "json", "yaml", "toml", "xml":
+		return formatValue(v, "."+format)
+	case "text":
+		if v.Kind() == cue.StringKind {
+			s, err := v.String()
+			return []byte(s + "\n"), err
+		}
+		return []byte(compactJSON(v) + "\n"), nil
+	default:
+		return nil, fmt.Errorf("Unknown output format %q, should be one of cue, json, yaml, toml, xml, or text", format)
+	}
+}
+
+// formatValue formats v as Cue, JSON, Yaml, TOML, or XML, by the extension of file,
+// Cue, with its comments, when it is empty.
+func formatValue(v cue.Value, file string) ([]byte, error) {
+	switch filepath.Ext(file) {
+	case ".json":
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		err = json.Indent(&buf, data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+
+	case ".yaml", ".yml":
+		return cueyaml.Encode(v)
+
+	case ".toml":
+		x, err := plainValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return toml.Marshal(x)
+
+	case ".xml":
+		x, err := plainValue(v)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("XML needs a struct, not a %s", v.Kind())
+		}
+		data, err := mxj.Map(m).XmlIndent("", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+
+	default:
+		// print structs as a file, without the enclosing braces
+		node := v.Syntax(cue.Docs(true))
+		if sl, ok := node.(*ast.StructLit); ok {
+			node = &ast.File{Decls: sl.Elts}
+		}
+		return format.Node(node)
+	}
+}
+
+// plainValue decodes v into Go maps, slices, and scalars for the TOML and XML encoders,
+// keeping integers as int64 rather than float64.
+func plainValue(v cue.Value) (interface{}, error) {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	err = dec.Decode(&x)
+	if err != nil {
+		return nil, err
+	}
+	return plainNumbers(x), nil
+}
+
+func plainNumbers(x interface{}) interface{} {
+	switch t := x.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = plainNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = plainNumbers(e)
+		}
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return x
+}
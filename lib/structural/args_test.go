@@ -0,0 +1,290 @@
+package structural
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints, for the Run*FromArgs functions.
+// Tests using it can not be parallel.
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	rd, wr, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = wr
+	done := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(rd)
+		done <- data
+	}()
+
+	err = f()
+	os.Stdout = old
+	wr.Close()
+	return string(<-done), err
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		expect []string
+		err    string
+	}{
+		{path: "a.b.c", expect: []string{"a", "b", "c"}},
+		{path: "#Schema.a", expect: []string{"#Schema", "a"}},
+		{path: `a."b.c".d`, expect: []string{"a", "b.c", "d"}},
+		{path: `"say \"hi\""`, expect: []string{`say "hi"`}},
+		{path: " a ", expect: []string{"a"}},
+		{path: "", expect: nil},
+		{path: "a..b", err: "Empty label"},
+		{path: `a."b`, err: "Unterminated quote"},
+		{path: `"a"b`, err: "Expected a dot"},
+	}
+
+	for _, tt := range tests {
+		labels, err := splitPath(tt.path)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected an error with %q, got %v", tt.path, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(labels, tt.expect) {
+			t.Errorf("%q: got %q, want %q", tt.path, labels, tt.expect)
+		}
+	}
+}
+
+func TestMergeInplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig := filepath.Join(dir, "orig.json")
+	update := filepath.Join(dir, "update.yaml")
+	err = ioutil.WriteFile(orig, []byte(`{"name": "app", "replicas": 1, "labels": {"tier": "web"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(update, []byte("replicas: 3\nlabels:\n  team: core\nports: [80]\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RunMergeFromArgs("@"+orig, "@"+update, nil, true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cue v0.2 orders fields by when their labels were first seen, so only the values are compared
+	var got, expect interface{}
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("expected orig.json to stay json, got:\n%s", data)
+	}
+	json.Unmarshal([]byte(`{"name": "app", "replicas": 3, "labels": {"tier": "web", "team": "core"}, "ports": [80]}`), &expect)
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got:\n%s\nwant:\n%v", data, expect)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"") {
+		t.Fatalf("expected orig.json to be indented, got:\n%s", data)
+	}
+
+	// only a whole file can be written back
+	for _, o := range []string{"@" + orig + ":labels", `{a: 1}`, StreamOp} {
+		err = RunMergeFromArgs(o, "@"+update, nil, true, "", "")
+		if err == nil || !strings.Contains(err.Error(), "Merging in place needs a whole file") {
+			t.Errorf("%s: expected merging in place to fail, got %v", o, err)
+		}
+	}
+
+	err = RunMergeFromArgs("@"+orig, `{labels: "none"}`, nil, true, "", "")
+	if err == nil || !strings.Contains(err.Error(), "labels has different type") {
+		t.Fatalf("expected a merge of another type to fail, got %v", err)
+	}
+}
+
+func TestLoadOp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-op")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.yaml")
+	err = ioutil.WriteFile(file, []byte("spec:\n  \"app.kubernetes.io/name\": web\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	O, err := loadOp("@"+file+`:spec."app.kubernetes.io/name"`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := O.Value.String(); s != "web" || O.File != file || O.Path != `spec."app.kubernetes.io/name"` {
+		t.Fatalf("got %q from %s at %s", s, O.File, O.Path)
+	}
+
+	_, err = loadOp("@"+file+":spec.missing", nil)
+	if err == nil || !strings.Contains(err.Error(), "spec.missing not found") {
+		t.Fatalf("expected a missing path to fail, got %v", err)
+	}
+
+	O, err = loadOp(`{a: 1 + 1}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := O.Value.Lookup("a").Int64(); n != 2 || O.File != "" {
+		t.Fatalf("expected an expression, got a: %d from %q", n, O.File)
+	}
+}
+
+const queryData = `{
+  "services": {
+    "web": {"image": "nginx", "ports": [80, 443]},
+    "db": {"image": "postgres", "ports": [5432]}
+  },
+  "jobs": [{"name": "backup", "every": "1h"}, {"name": "report", "every": "1d"}]
+}`
+
+func TestQueryPath(t *testing.T) {
+	i, err := r.Compile("data.cue", queryData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := i.Value()
+
+	tests := []struct {
+		path   string
+		expect []string
+		err    string
+	}{
+		{path: "services.web.image", expect: []string{`"nginx"`}},
+		{path: "services.*.image", expect: []string{`"nginx"`, `"postgres"`}},
+		{path: "services.*.ports.0", expect: []string{"5432", "80"}},
+		{path: "services.web.ports.*", expect: []string{"443", "80"}},
+		{path: "jobs.*.name", expect: []string{`"backup"`, `"report"`}},
+		{path: "jobs.1", expect: []string{`{"name":"report","every":"1d"}`}},
+		{path: "", expect: []string{compactJSON(v)}},
+		// paths which match nothing are not errors
+		{path: "services.cache.image"},
+		{path: "jobs.name"},
+		{path: "jobs.2"},
+		{path: "jobs.-1"},
+		{path: "services.web.image.more"},
+		{path: "services..image", err: "Empty label"},
+	}
+
+	for _, tt := range tests {
+		matches, err := queryPath(v, tt.path)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected an error with %q, got %v", tt.path, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.path, err)
+			continue
+		}
+		// Cue v0.2 may not keep the order of fields, so matches are compared sorted
+		var got []string
+		for _, m := range matches {
+			got = append(got, compactJSON(m))
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q: got %q, want %q", tt.path, got, tt.expect)
+		}
+	}
+}
+
+func TestRunQueryAndPick(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "data.json")
+	err = ioutil.WriteFile(file, []byte(queryData), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// text prints strings as is, one match per line
+	out, err := captureStdout(t, func() error {
+		return RunQueryFromArgs("@"+file, "jobs.*.name", nil, "text")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "backup\nreport\n" {
+		t.Fatalf("got text %q", out)
+	}
+
+	// otherwise the matches are a list, in the format of the file by default
+	out, err = captureStdout(t, func() error {
+		return RunQueryFromArgs("@"+file, "services.web.ports.*", nil, "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "[\n  80,\n  443\n]\n" {
+		t.Fatalf("got json %q", out)
+	}
+	out, err = captureStdout(t, func() error {
+		return RunQueryFromArgs("@"+file, "jobs.0.every", nil, "yaml")
+	})
+	if err != nil || out != "- 1h\n" {
+		t.Fatalf("got yaml %q, %v", out, err)
+	}
+
+	_, err = captureStdout(t, func() error {
+		return RunQueryFromArgs("@"+file, "jobs", nil, "csv")
+	})
+	if err == nil || !strings.Contains(err.Error(), `Unknown output format "csv"`) {
+		t.Fatalf("expected an unknown format to fail, got %v", err)
+	}
+
+	// pick keeps the structure around the values which unify
+	out, err = captureStdout(t, func() error {
+		return RunPickFromArgs("@"+file, `{services: web: image: string, jobs: {every: "1d"}}`, nil, "", "json")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, expect interface{}
+	err = json.Unmarshal([]byte(out), &got)
+	if err != nil {
+		t.Fatalf("expected json, got:\n%s", out)
+	}
+	json.Unmarshal([]byte(`{"services": {"web": {"image": "nginx"}}, "jobs": [{"name": "report", "every": "1d"}]}`), &expect)
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got picked:\n%s", out)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return RunPickFromArgs("@"+file+":services.db", `{image: string}`, nil, "", "text")
+	})
+	if err != nil || out != `{"image":"postgres"}`+"\n" {
+		t.Fatalf("got text %q, %v", out, err)
+	}
+}
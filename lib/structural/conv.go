@@ -0,0 +1,53 @@
+package structural
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// RunConvFromArgs prints in, see loadOp for its format, encoded as to, see encodeValue.
+// When schema is set, in is unified with it first, filling defaults and checking it,
+// and must then be concrete unless converting to Cue.
+func RunConvFromArgs(in string, entrypoints []string, to, schema string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
+
+	I, err := loadOp(in, crt)
+	if err != nil {
+		return fmt.Errorf("While loading in\n%w\n", err)
+	}
+	v := I.Value
+
+	if schema != "" {
+		S, err := loadOp(schema, crt)
+		if err != nil {
+			return fmt.Errorf("While loading schema\n%w\n", err)
+		}
+		v = S.Value.Unify(v)
+		opts := []cue.Option{}
+		if to != "cue" {
+			opts = append(opts, cue.Concrete(true))
+		}
+		err = v.Validate(opts...)
+		if err != nil {
+			return fmt.Errorf("While checking against schema\n%w\n", err)
+		}
+	}
+
+	data, err := encodeValue(v, to, I.File)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,153 @@
+package structural
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-conv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i, err := r.Compile("app.cue", `
+// the app
+app: {
+	name:     "app"
+	replicas: 3
+	ratio:    0.5
+	enabled:  true
+	tags: ["a", "b"]
+	labels: tier: "web"
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := i.Value()
+
+	expect := `{"app": {"name": "app", "replicas": 3, "ratio": 0.5, "enabled": true, "tags": ["a", "b"], "labels": {"tier": "web"}}}`
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{format: "cue", expect: expect},
+		{format: "json", expect: expect},
+		{format: "yaml", expect: expect},
+		{format: "toml", expect: expect},
+		// XML has no types, so scalars come back as strings
+		{format: "xml", expect: `{"app": {"name": "app", "replicas": "3", "ratio": "0.5", "enabled": "true", "tags": ["a", "b"], "labels": {"tier": "web"}}}`},
+	}
+
+	for _, tt := range tests {
+		data, err := encodeValue(v, tt.format, "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		file := filepath.Join(dir, "app."+tt.format)
+		err = ioutil.WriteFile(file, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		back, err := loadFile(file)
+		if err != nil {
+			t.Errorf("%s: %v\n%s", tt.format, err, data)
+			continue
+		}
+		x, err := plainValue(back)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		got, _ := json.Marshal(x)
+		var gotX, expectX interface{}
+		json.Unmarshal(got, &gotX)
+		json.Unmarshal([]byte(tt.expect), &expectX)
+		if !reflect.DeepEqual(gotX, expectX) {
+			t.Errorf("%s: got %s from:\n%s", tt.format, got, data)
+		}
+
+		// the default format is that of the file
+		byFile, err := encodeValue(back, "", file)
+		if err != nil || (tt.format != "xml" && string(byFile) != string(data)) {
+			t.Errorf("%s: expected the same encoding by the file extension, got %v:\n%s", tt.format, err, byFile)
+		}
+	}
+
+	// Cue keeps the comments
+	data, err := encodeValue(v, "cue", "")
+	if err != nil || !strings.HasPrefix(string(data), "// the app\napp: {") {
+		t.Fatalf("expected cue with the comment, got %v:\n%s", err, data)
+	}
+
+	list, err := r.Compile("list.cue", `[1, 2]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = encodeValue(list.Value(), "xml", "")
+	if err == nil || !strings.Contains(err.Error(), "XML needs a struct") {
+		t.Fatalf("expected a list to fail as XML, got %v", err)
+	}
+}
+
+func TestRunConvFromArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-conv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.json")
+	err = ioutil.WriteFile(file, []byte(`{"name": "app"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		to     string
+		schema string
+		expect string
+		err    string
+	}{
+		{to: "yaml", expect: "name: app\n"},
+		{to: "", expect: "{\n  \"name\": \"app\"\n}\n"},
+		{to: "text", expect: `{"name":"app"}` + "\n"},
+		// the schema fills defaults
+		{to: "yaml", schema: `{name: string, replicas: *1 | int}`, expect: "name: app\nreplicas: 1\n"},
+		{to: "toml", schema: `{name: string, replicas: *1 | int}`, expect: "name = \"app\"\nreplicas = 1\n"},
+		// only cue may be incomplete
+		{to: "cue", schema: `{name: string, port: int}`, expect: "name: \"app\"\nport: int\n"},
+		{to: "json", schema: `{name: string, port: int}`, err: "While checking against schema"},
+		{to: "json", schema: `{name: int}`, err: "While checking against schema"},
+		{to: "csv", err: `Unknown output format "csv"`},
+	}
+
+	for _, tt := range tests {
+		out, err := captureStdout(t, func() error {
+			return RunConvFromArgs("@"+file, nil, tt.to, tt.schema)
+		})
+		name := tt.to + " " + tt.schema
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if out != tt.expect {
+			t.Errorf("%s: got %q, want %q", name, out, tt.expect)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package structural
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 
 	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
 )
 
 func DiffValues(orig, next cue.Value) (cue.Value, error) {
@@ -17,10 +20,34 @@ func DiffValues(orig, next cue.Value) (cue.Value, error) {
 	return *c, err
 }
 
-func RunDiffFromArgs(orig, next string, entrypoints []string) error {
-	fmt.Println("lib/st.Diff", orig, next, entrypoints)
+// RunDiffFromArgs prints the paths which differ between orig and next, see loadOp for their format,
+// as text, json, or patch, see WriteChanges.
+func RunDiffFromArgs(orig, next string, entrypoints []string, format string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
 
-	return nil
+	O, err := loadOp(orig, crt)
+	if err != nil {
+		return fmt.Errorf("While loading orig\n%w\n", err)
+	}
+	N, err := loadOp(next, crt)
+	if err != nil {
+		return fmt.Errorf("While loading next\n%w\n", err)
+	}
+
+	changes, err := DiffPaths(O.Value, N.Value)
+	if err != nil {
+		return err
+	}
+
+	return WriteChanges(os.Stdout, changes, format)
 }
 
 func reportInplace(out *pvStruct, key string, val *pvStruct) {
@@ -0,0 +1,188 @@
+package structural
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+)
+
+// Change is a difference at a path between two values,
+// From is unset for additions and To for removals.
+type Change struct {
+	Op   string
+	Path []string
+	From cue.Value
+	To   cue.Value
+}
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DiffPaths compares orig and next structurally, returning the changed paths in field order.
+// Structs are compared field by field, while lists and other values are compared whole.
+func DiffPaths(orig, next cue.Value) ([]Change, error) {
+	return diffPaths(nil, nil, orig, next)
+}
+
+func diffPaths(changes []Change, path []string, orig, next cue.Value) ([]Change, error) {
+	if !isStruct(orig) || !isStruct(next) {
+		if !orig.Equals(next) {
+			changes = append(changes, Change{Op: ChangeChanged, Path: path, From: orig, To: next})
+		}
+		return changes, nil
+	}
+
+	origStruct, err := orig.Struct()
+	if err != nil {
+		return changes, err
+	}
+	iter := origStruct.Fields()
+	for iter.Next() {
+		label := iter.Label()
+		fpath := append(path[:len(path):len(path)], label)
+		nextVal, err := next.LookupField(label)
+		if err != nil {
+			changes = append(changes, Change{Op: ChangeRemoved, Path: fpath, From: iter.Value()})
+			continue
+		}
+		changes, err = diffPaths(changes, fpath, iter.Value(), nextVal.Value)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	nextStruct, err := next.Struct()
+	if err != nil {
+		return changes, err
+	}
+	iter = nextStruct.Fields()
+	for iter.Next() {
+		label := iter.Label()
+		if _, err := orig.LookupField(label); err != nil {
+			fpath := append(path[:len(path):len(path)], label)
+			changes = append(changes, Change{Op: ChangeAdded, Path: fpath, To: iter.Value()})
+		}
+	}
+
+	return changes, nil
+}
+
+var identRE = regexp.MustCompile(`^[A-Za-z_$#][A-Za-z0-9_$#]*$`)
+
+// DotPath joins the labels of a path with dots, quoting those which are not identifiers.
+// The empty path, for the whole value, is a lone dot.
+func DotPath(path []string) string {
+	if len(path) == 0 {
+		return "."
+	}
+	labels := make([]string, len(path))
+	for i, label := range path {
+		if identRE.MatchString(label) {
+			labels[i] = label
+		} else {
+			labels[i] = strconv.Quote(label)
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// PointerPath returns a path as a JSON pointer, see RFC 6901.
+func PointerPath(path []string) string {
+	var b strings.Builder
+	for _, label := range path {
+		label = strings.ReplaceAll(label, "~", "~0")
+		label = strings.ReplaceAll(label, "/", "~1")
+		b.WriteString("/" + label)
+	}
+	return b.String()
+}
+
+// WriteChanges writes changes to w as text, json, or patch, a JSON Patch (RFC 6902).
+func WriteChanges(w io.Writer, changes []Change, format string) error {
+	switch format {
+	case "", "text":
+		for _, c := range changes {
+			var err error
+			switch c.Op {
+			case ChangeAdded:
+				_, err = fmt.Fprintf(w, "+ %s: %s\n", DotPath(c.Path), compactJSON(c.To))
+			case ChangeRemoved:
+				_, err = fmt.Fprintf(w, "- %s: %s\n", DotPath(c.Path), compactJSON(c.From))
+			default:
+				_, err = fmt.Fprintf(w, "~ %s: %s -> %s\n", DotPath(c.Path), compactJSON(c.From), compactJSON(c.To))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "json":
+		type jsonChange struct {
+			Op   string     `json:"op"`
+			Path string     `json:"path"`
+			From *cue.Value `json:"from,omitempty"`
+			To   *cue.Value `json:"to,omitempty"`
+		}
+		out := []jsonChange{}
+		for i := range changes {
+			c := &changes[i]
+			jc := jsonChange{Op: c.Op, Path: DotPath(c.Path)}
+			if c.Op != ChangeAdded {
+				jc.From = &c.From
+			}
+			if c.Op != ChangeRemoved {
+				jc.To = &c.To
+			}
+			out = append(out, jc)
+		}
+		return writeJSON(w, out)
+
+	case "patch":
+		type patchOp struct {
+			Op    string     `json:"op"`
+			Path  string     `json:"path"`
+			Value *cue.Value `json:"value,omitempty"`
+		}
+		out := []patchOp{}
+		for i := range changes {
+			c := &changes[i]
+			switch c.Op {
+			case ChangeAdded:
+				out = append(out, patchOp{Op: "add", Path: PointerPath(c.Path), Value: &c.To})
+			case ChangeRemoved:
+				out = append(out, patchOp{Op: "remove", Path: PointerPath(c.Path)})
+			default:
+				out = append(out, patchOp{Op: "replace", Path: PointerPath(c.Path), Value: &c.To})
+			}
+		}
+		return writeJSON(w, out)
+
+	default:
+		return fmt.Errorf("Unknown diff format %q, should be one of text, json, or patch", format)
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// compactJSON is a value on one line for text output, or its Cue syntax when not concrete.
+func compactJSON(v cue.Value) string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		data, _ = format.Node(v.Syntax(), format.Simplify())
+	}
+	return string(data)
+}
@@ -0,0 +1,94 @@
+package structural_test
+
+import (
+	"bytes"
+	"testing"
+
+	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+func TestDiffPaths(t *testing.T) {
+	orig := `
+name: "web"
+replicas: 1
+labels: tier: "frontend"
+ports: [80]
+debug: true
+`
+	next := `
+name: "web"
+replicas: 3
+labels: {
+	tier: "frontend"
+	"team/owner": "infra"
+}
+ports: [80, 443]
+`
+
+	tests := []struct {
+		format string
+		expect string
+	}{{
+		format: "text",
+		expect: `~ replicas: 1 -> 3
++ labels."team/owner": "infra"
+~ ports: [80] -> [80,443]
+- debug: true
+`,
+	}, {
+		format: "patch",
+		expect: `[
+  {
+    "op": "replace",
+    "path": "/replicas",
+    "value": 3
+  },
+  {
+    "op": "add",
+    "path": "/labels/team~1owner",
+    "value": "infra"
+  },
+  {
+    "op": "replace",
+    "path": "/ports",
+    "value": [
+      80,
+      443
+    ]
+  },
+  {
+    "op": "remove",
+    "path": "/debug"
+  }
+]
+`,
+	}}
+
+	var r cue.Runtime
+	oi, err := r.Compile("orig", orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ni, err := r.Compile("next", next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := structural.DiffPaths(oi.Value(), ni.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := structural.WriteChanges(&buf, changes, tt.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if buf.String() != tt.expect {
+			t.Errorf("%s: got\n%s\nexpected\n%s", tt.format, buf.String(), tt.expect)
+		}
+	}
+}
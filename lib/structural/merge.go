@@ -2,8 +2,12 @@ package structural
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 
 	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
 )
 
 func MergeValues(orig, update cue.Value) (cue.Value, error) {
@@ -16,9 +20,62 @@ func MergeValues(orig, update cue.Value) (cue.Value, error) {
 	return *c, err
 }
 
-func RunMergeFromArgs(orig, update string, entrypoints []string) error {
-	fmt.Println("lib/st.Merge", orig, update, entrypoints)
+// RunMergeFromArgs merges update onto orig, see loadOp for their format,
+// printing the result in format, see encodeValue, or writing it back to orig when inplace.
+// When orig is StreamOp, update is merged onto each document read from stdin, see runStream.
+func RunMergeFromArgs(orig, update string, entrypoints []string, inplace bool, inFormat, outFormat string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
+
+	U, err := loadOp(update, crt)
+	if err != nil {
+		return fmt.Errorf("While loading update\n%w\n", err)
+	}
+
+	if orig == StreamOp {
+		if inplace {
+			return fmt.Errorf("Merging in place needs a whole file for orig, as in @file.cue")
+		}
+		return runStream(os.Stdin, os.Stdout, inFormat, outFormat, []cue.Value{U.Value}, func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+			merged, err := MergeValues(doc, ops[0])
+			return merged, true, err
+		})
+	}
+
+	O, err := loadOp(orig, crt)
+	if err != nil {
+		return fmt.Errorf("While loading orig\n%w\n", err)
+	}
+
+	if inplace && (O.File == "" || O.Path != "") {
+		return fmt.Errorf("Merging in place needs a whole file for orig, as in @file.cue")
+	}
+
+	merged, err := MergeValues(O.Value, U.Value)
+	if err != nil {
+		return err
+	}
+
+	if inplace {
+		out, err := formatValue(merged, O.File)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(O.File, out, 0644)
+	}
 
+	out, err := encodeValue(merged, outFormat, O.File)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(out))
 	return nil
 }
 
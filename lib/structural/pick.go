@@ -2,7 +2,7 @@ package structural
 
 import (
 	"fmt"
-	"strings"
+	"os"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
@@ -11,64 +11,61 @@ import (
 	"github.com/hofstadter-io/hof/lib/cuetils"
 )
 
-func RunPickFromArgs(orig, pick string, entrypoints []string) error {
-	fmt.Println("lib/st.Pick", orig, pick, entrypoints)
+// RunPickFromArgs prints the values of orig which unify with pick, see loadOp for their format,
+// keeping the structure of orig around them. See encodeValue for the output formats.
+// When orig is StreamOp, pick is applied to each document read from stdin, see runStream.
+func RunPickFromArgs(orig, pick string, entrypoints []string, inFormat, outFormat string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
 
-	crt, err := cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+	P, err := loadOp(pick, crt)
 	if err != nil {
-		return err
+		return fmt.Errorf("While loading pick\n%w\n", err)
 	}
 
-	fmt.Println("loaded")
-	fmt.Println("-------------")
-	lSyn, err := cuetils.ValueToSyntaxString(crt.CueValue)
-	if err != nil {
-		return err
+	if orig == StreamOp {
+		return runStream(os.Stdin, os.Stdout, inFormat, outFormat, []cue.Value{P.Value}, func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+			picked, err := pickValue(doc, ops[0])
+			return picked, true, err
+		})
 	}
-	fmt.Println(lSyn)
-	fmt.Println("-------------")
-
-	fmt.Println("orig")
-	fmt.Println("-------------")
-	oPath := strings.Split(orig, ".")
-	oVal := crt.CueValue.Lookup(oPath...)
-	oSyn, err := cuetils.ValueToSyntaxString(oVal)
+
+	O, err := loadOp(orig, crt)
 	if err != nil {
-		return err
+		return fmt.Errorf("While loading orig\n%w\n", err)
 	}
-	fmt.Println(oSyn)
-	fmt.Println("-------------")
-
-	fmt.Println("pick")
-	fmt.Println("-------------")
-	fmt.Println(pick)
-	/*
-	pVal, err := crt.ConvertToValue(pick)
+
+	picked, err := pickValue(O.Value, P.Value)
 	if err != nil {
 		return err
 	}
-	*/
-	fmt.Println("-------------")
 
-	fmt.Println("result")
-	fmt.Println("-------------")
-	rSyn, err := CuePick(oSyn, pick)
+	data, err := encodeValue(picked, outFormat, O.File)
 	if err != nil {
 		return err
 	}
-	/*
-	rVal, err := PickValues(oVal, pVal)
+	fmt.Print(string(data))
+	return nil
+}
+
+func pickValue(orig, pick cue.Value) (cue.Value, error) {
+	out := NewpvStruct()
+	err := cuePick(out, orig, pick)
 	if err != nil {
-		return err
+		return cue.Value{}, err
 	}
-	rSyn, err := cuetils.ValueToSyntaxString(rVal)
+	picked, err := out.ToValue()
 	if err != nil {
-		return err
+		return cue.Value{}, err
 	}
-	*/
-	fmt.Println(rSyn)
-	fmt.Println("-------------")
-	return nil
+	return *picked, nil
 }
 
 func CuePick(sorig, spick string) (string, error) {
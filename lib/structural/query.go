@@ -2,8 +2,11 @@ package structural
 
 import (
 	"fmt"
+	"strconv"
 
 	"cuelang.org/go/cue"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
 )
 
 func QueryValues(orig, query cue.Value) (cue.Value, error) {
@@ -15,12 +18,115 @@ func QueryValues(orig, query cue.Value) (cue.Value, error) {
 	c, err := out.ToValue()
 	return *c, err
 }
-func RunQueryFromArgs(orig, expr string, entrypoints []string) error {
-	fmt.Println("lib/st.Query", orig, expr, entrypoints)
 
+// RunQueryFromArgs prints the values of orig, see loadOp for its format, at the paths matching expr.
+// Expr is a path whose labels may be * to match every field or element, and numbers index lists,
+// as in services.*.ports.0, see queryPath. Matches are printed as a list,
+// or one per line with the text format, see encodeValue.
+func RunQueryFromArgs(orig, expr string, entrypoints []string, format string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
+
+	O, err := loadOp(orig, crt)
+	if err != nil {
+		return fmt.Errorf("While loading orig\n%w\n", err)
+	}
+
+	matches, err := queryPath(O.Value, expr)
+	if err != nil {
+		return err
+	}
+
+	if format == "text" {
+		for _, m := range matches {
+			data, err := encodeValue(m, format, O.File)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+		}
+		return nil
+	}
+
+	out := NewpvList()
+	for _, m := range matches {
+		out.Append(*ExprFromValue(m))
+	}
+	list, err := out.ToValue()
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeValue(*list, format, O.File)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
 	return nil
 }
 
+// queryPath returns the values of v at path, in order,
+// where a * label matches every field of a struct or element of a list,
+// and a number matches that element of a list.
+func queryPath(v cue.Value, path string) ([]cue.Value, error) {
+	labels, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []cue.Value{v}
+	for _, label := range labels {
+		next := []cue.Value{}
+		for _, val := range list {
+			switch {
+			case isStruct(val):
+				if label == "*" {
+					iter, err := val.Fields()
+					if err != nil {
+						return nil, err
+					}
+					for iter.Next() {
+						next = append(next, iter.Value())
+					}
+					continue
+				}
+				field, err := val.LookupField(label)
+				if err == nil {
+					next = append(next, field.Value)
+				}
+
+			case isList(val):
+				index := -1
+				if label != "*" {
+					index, err = strconv.Atoi(label)
+					if err != nil || index < 0 {
+						continue
+					}
+				}
+				iter, err := val.List()
+				if err != nil {
+					return nil, err
+				}
+				for i := 0; iter.Next(); i++ {
+					if index < 0 || i == index {
+						next = append(next, iter.Value())
+					}
+				}
+			}
+		}
+		list = next
+	}
+
+	return list, nil
+}
+
 func CueQuery(squery, sdata string) (string, error) {
 	out := NewpvList()
 
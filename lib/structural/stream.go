@@ -0,0 +1,227 @@
+package structural
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	cuejson "cuelang.org/go/encoding/json"
+	cueyaml "cuelang.org/go/encoding/yaml"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StreamOp is the <op> which reads a stream of documents from stdin,
+// newline delimited JSON or multi-document Yaml.
+const StreamOp = "-"
+
+// docReader reads one document at a time from a stream, returning io.EOF at the end.
+type docReader interface {
+	Next() (cue.Value, error)
+}
+
+// newDocReader returns a reader for the documents of in, which are json or yaml,
+// or guessed from the first character when format is empty.
+// The format read is returned as well.
+func newDocReader(in io.Reader, format string) (docReader, string, error) {
+	br := bufio.NewReader(in)
+	if format == "" {
+		format = "yaml"
+		for {
+			c, _, err := br.ReadRune()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			if unicode.IsSpace(c) {
+				continue
+			}
+			if c == '{' || c == '[' {
+				format = "json"
+			}
+			br.UnreadRune()
+			break
+		}
+	}
+
+	switch format {
+	case "json", "ndjson", "jsonl":
+		return &jsonDocs{dec: json.NewDecoder(br)}, "json", nil
+	case "yaml", "yml":
+		return &yamlDocs{dec: yaml.NewDecoder(br)}, "yaml", nil
+	default:
+		return nil, "", fmt.Errorf("Unknown stream format %q, should be json or yaml", format)
+	}
+}
+
+// ReadDocs reads every document of in, which are json or yaml,
+// or guessed when format is empty, see newDocReader.
+func ReadDocs(in io.Reader, format string) ([]cue.Value, error) {
+	docs, _, err := newDocReader(in, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals []cue.Value
+	for {
+		doc, err := docs.Next()
+		if err == io.EOF {
+			return vals, nil
+		}
+		if err != nil {
+			return vals, fmt.Errorf("While reading document %d\n%w\n", len(vals)+1, err)
+		}
+		vals = append(vals, doc)
+	}
+}
+
+type jsonDocs struct {
+	dec *json.Decoder
+}
+
+func (d *jsonDocs) Next() (cue.Value, error) {
+	var raw json.RawMessage
+	err := d.dec.Decode(&raw)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	expr, err := cuejson.Extract(StreamOp, raw)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	i, err := r.CompileExpr(expr)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := i.Value()
+	return v, v.Err()
+}
+
+type yamlDocs struct {
+	dec *yaml.Decoder
+}
+
+func (d *yamlDocs) Next() (cue.Value, error) {
+	var node yaml.Node
+	err := d.dec.Decode(&node)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	f, err := cueyaml.Extract(StreamOp, data)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	i, err := r.CompileFile(f)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := i.Value()
+	return v, v.Err()
+}
+
+// streamFunc is applied to each document of a stream along with the values of the other ops,
+// returning the value to write, if keep is set.
+type streamFunc func(doc cue.Value, ops []cue.Value) (out cue.Value, keep bool, err error)
+
+// runStream applies f to each document read from in, writing the results to out
+// in outFormat, which defaults to the format of the stream, see newDocReader.
+// Json is written one document per line and Yaml separated with ---
+//
+// Only one document is held at a time. The Cue runtime is replaced for each one,
+// with the ops compiled again from their syntax, so memory does not grow with the stream.
+// Documents which fail are reported on stderr by their number, counting from one,
+// and the rest of the stream is still processed.
+func runStream(in io.Reader, out io.Writer, inFormat, outFormat string, ops []cue.Value, f streamFunc) error {
+	srcs := make([][]byte, len(ops))
+	for i, op := range ops {
+		src, err := format.Node(op.Syntax())
+		if err != nil {
+			return err
+		}
+		srcs[i] = src
+	}
+
+	docs, streamFormat, err := newDocReader(in, inFormat)
+	if err != nil {
+		return err
+	}
+	if outFormat == "" {
+		outFormat = streamFormat
+	}
+
+	n, failed, written := 0, 0, 0
+	for {
+		// drop everything compiled for the previous document
+		r = cue.Runtime{}
+
+		doc, err := docs.Next()
+		if err == io.EOF {
+			break
+		}
+		n++
+		if err != nil {
+			// the decoders can not resync after a syntax error
+			return fmt.Errorf("While reading document %d\n%w\n", n, err)
+		}
+
+		vals := make([]cue.Value, len(srcs))
+		for i, src := range srcs {
+			inst, err := r.Compile("", src)
+			if err != nil {
+				return err
+			}
+			vals[i] = inst.Value()
+		}
+
+		res, keep, err := f(doc, vals)
+		if err == nil && keep {
+			// written whole and unbuffered, so each result is seen downstream right away
+			err = writeDoc(out, res, outFormat, written == 0)
+			if err == nil {
+				written++
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "document %d: %v\n", n, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d documents failed", failed, n)
+	}
+	return nil
+}
+
+func writeDoc(w io.Writer, v cue.Value, format string, first bool) error {
+	var data []byte
+	var err error
+	switch format {
+	case "json":
+		data, err = v.MarshalJSON()
+		data = append(data, '\n')
+	case "yaml":
+		data, err = cueyaml.Encode(v)
+		if !first {
+			data = append([]byte("---\n"), data...)
+		}
+	default:
+		data, err = encodeValue(v, format, "")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,167 @@
+package structural
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+)
+
+func TestReadDocs(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		format string
+		expect []string
+		err    string
+	}{
+		{name: "ndjson", in: "{\"a\": 1}\n{\"a\": 2}\n\n[3]\n", expect: []string{`{"a":1}`, `{"a":2}`, `[3]`}},
+		{name: "jsonl", in: `{"a": 1} {"a": 2}`, format: "jsonl", expect: []string{`{"a":1}`, `{"a":2}`}},
+		{name: "leading space", in: "\n  {\"a\": 1}", expect: []string{`{"a":1}`}},
+		{name: "yaml", in: "a: 1\n---\na: 2\n---\n- 3\n", expect: []string{`{"a":1}`, `{"a":2}`, `[3]`}},
+		{name: "yaml by name", in: "a: 1\n", format: "yml", expect: []string{`{"a":1}`}},
+		{name: "empty", in: "", expect: nil},
+		{name: "bad json", in: "{\"a\": 1}\n{\"a\": }\n", err: "While reading document 2"},
+		{name: "bad format", in: "a,b\n", format: "csv", err: `Unknown stream format "csv"`},
+	}
+
+	for _, tt := range tests {
+		docs, err := ReadDocs(strings.NewReader(tt.in), tt.format)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error with %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, d := range docs {
+			got = append(got, compactJSON(d))
+		}
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.expect)
+		}
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	// runStream replaces the runtime, which the order of fields elsewhere depends on
+	old := r
+	defer func() { r = old }()
+
+	i, err := r.Compile("op.cue", `{env: "prod"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []cue.Value{i.Value()}
+
+	merge := func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+		merged, err := MergeValues(doc, ops[0])
+		return merged, true, err
+	}
+
+	// json is written a document per line
+	var out bytes.Buffer
+	err = runStream(strings.NewReader("{\"n\": 1}\n{\"n\": 2}\n"), &out, "", "", ops, merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if !strings.Contains(line, `"env":"prod"`) || !strings.HasPrefix(line, `{"`) {
+			t.Fatalf("expected a merged document per line, got:\n%s", out.String())
+		}
+	}
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Fatalf("expected two lines, got:\n%s", out.String())
+	}
+
+	// yaml is separated with ---, and may be written as json
+	out.Reset()
+	err = runStream(strings.NewReader("n: 1\n---\nn: 2\n"), &out, "", "", ops, merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Split(out.String(), "---\n"); len(docs) != 2 || !strings.Contains(docs[1], "env: prod\n") || !strings.Contains(docs[1], "n: 2\n") {
+		t.Fatalf("expected two yaml documents, got:\n%s", out.String())
+	}
+	out.Reset()
+	err = runStream(strings.NewReader("n: 1\n---\nn: 2\n"), &out, "", "json", ops, merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "\n") != 2 || strings.Contains(out.String(), "---") {
+		t.Fatalf("expected json lines, got:\n%s", out.String())
+	}
+
+	// documents which fail are counted, and the rest are still written
+	i, err = r.Compile("schema.cue", `{n: int}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+		return doc, true, ValidateValue(doc, ops[0])
+	}
+	out.Reset()
+	err = runStream(strings.NewReader("{\"n\": 1}\n{\"n\": \"two\"}\n{\"n\": 3}\n"), &out, "json", "", []cue.Value{i.Value()}, validate)
+	if err == nil || err.Error() != "1 of 3 documents failed" {
+		t.Fatalf("expected one failed document, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), `"n":3`) {
+		t.Fatalf("expected the other documents written, got:\n%s", out.String())
+	}
+
+	// only the documents kept are written
+	out.Reset()
+	n := 0
+	err = runStream(strings.NewReader("{\"n\": 1}\n{\"n\": 2}\n{\"n\": 3}\n"), &out, "", "text", nil, func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+		n++
+		v, _ := doc.Lookup("n").Int64()
+		return doc.Lookup("n"), v%2 == 1, nil
+	})
+	if err != nil || n != 3 || out.String() != "1\n3\n" {
+		t.Fatalf("expected the odd documents, got %q from %d, %v", out.String(), n, err)
+	}
+
+	// syntax errors end the stream, as the decoder can not resync
+	out.Reset()
+	err = runStream(strings.NewReader("{\"n\": 1}\n{\"n\"}\n{\"n\": 3}\n"), &out, "", "", ops, merge)
+	if err == nil || !strings.Contains(err.Error(), "While reading document 2") {
+		t.Fatalf("expected the stream to end at document 2, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected only the first document written, got:\n%s", out.String())
+	}
+}
+
+func BenchmarkRunStream(b *testing.B) {
+	old := r
+	defer func() { r = old }()
+
+	var in bytes.Buffer
+	for n := 0; n < 1000; n++ {
+		fmt.Fprintf(&in, "{\"n\": %d, \"tags\": [\"a\", \"b\"]}\n", n)
+	}
+	i, err := r.Compile("op.cue", `{env: "prod"}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ops := []cue.Value{i.Value()}
+	data := in.Bytes()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		err := runStream(bytes.NewReader(data), &bytes.Buffer{}, "", "", ops, func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+			merged, err := MergeValues(doc, ops[0])
+			return merged, true, err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
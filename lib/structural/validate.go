@@ -0,0 +1,300 @@
+package structural
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	cuejson "cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/jsonschema"
+
+	"github.com/hofstadter-io/hof/lib/cuetils"
+)
+
+// The kinds of schema LoadSchema understands.
+const (
+	SchemaCue        = "cue"
+	SchemaJSONSchema = "jsonschema"
+	SchemaOpenAPI    = "openapi"
+)
+
+// ValidationError is a single problem found by ValidateFiles,
+// located in the data file, or the schema when the data has no position for it.
+type ValidationError struct {
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	loc := e.File
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", loc, e.Path, e.Message)
+}
+
+// ValidateValue checks that v unifies with schema and is concrete.
+func ValidateValue(v, schema cue.Value) error {
+	return schema.Unify(v).Validate(cue.Concrete(true))
+}
+
+// ValidateFiles checks each of the Cue, JSON, Yaml, TOML, or XML files against schema,
+// returning the problems found. The error is for files which could not be read.
+func ValidateFiles(schema cue.Value, files []string) ([]ValidationError, error) {
+	verrs := []ValidationError{}
+	for _, file := range files {
+		v, err := loadFile(file)
+		if err != nil {
+			return verrs, fmt.Errorf("While loading %s\n%w\n", file, err)
+		}
+		verrs = append(verrs, ValidationErrors(file, ValidateValue(v, schema))...)
+	}
+	return verrs, nil
+}
+
+// ValidationErrors splits a Cue error into ValidationErrors for file.
+func ValidationErrors(file string, err error) []ValidationError {
+	if err == nil {
+		return nil
+	}
+
+	var verrs []ValidationError
+	for _, e := range errors.Errors(err) {
+		format, args := e.Msg()
+		ve := ValidationError{
+			File:    file,
+			Path:    DotPath(dataPath(e.Path())),
+			Message: fmt.Sprintf(format, args...),
+		}
+
+		// prefer where the data is, the schema is where it went wrong otherwise
+		pos := e.Position()
+		for _, p := range e.InputPositions() {
+			if p.Filename() == file {
+				pos = p
+				break
+			}
+		}
+		if pos.IsValid() {
+			ve.File, ve.Line, ve.Column = pos.Filename(), pos.Line(), pos.Column()
+		}
+		verrs = append(verrs, ve)
+	}
+	return verrs
+}
+
+// dataPath drops the definitions leading a path, which are where the schema is, as in #Config.replicas,
+// so that paths are those of the data validated.
+func dataPath(path []string) []string {
+	for len(path) > 0 && strings.HasPrefix(path[0], "#") {
+		path = path[1:]
+	}
+	return path
+}
+
+// LoadSchema returns the schema of an <op>, see loadOp, which is one of
+//
+//   cue         any Cue value, usually a definition, as in @schema.cue:#Config
+//   jsonschema  a JSON Schema file, as in @schema.json, or a path into it once converted, as in @schema.json:#Item
+//   openapi     a component of an OpenAPI file, as in @openapi.yaml:Pet for #/components/schemas/Pet
+//
+// When kind is empty, it is cue for Cue files and values,
+// openapi for files with an openapi field, and jsonschema for those with a $schema field.
+func LoadSchema(op, kind string, crt *cuetils.CueRuntime) (cue.Value, error) {
+	file, path := "", ""
+	if strings.HasPrefix(op, "@") {
+		file = op[1:]
+		if i := strings.Index(file, ":"); i >= 0 {
+			file, path = file[:i], strings.TrimSpace(file[i+1:])
+		}
+	}
+	if file == "" || (kind == "" && filepath.Ext(file) == ".cue") {
+		kind = SchemaCue
+	}
+	if kind == SchemaCue {
+		S, err := loadOp(op, crt)
+		return S.Value, err
+	}
+
+	i, err := loadInstance(file)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	if kind == "" {
+		kind = SchemaCue
+		if i.Value().Lookup("openapi").Exists() {
+			kind = SchemaOpenAPI
+		} else if i.Value().Lookup("$schema").Exists() {
+			kind = SchemaJSONSchema
+		}
+	}
+
+	switch kind {
+	case SchemaCue:
+		v := i.Value()
+		if path != "" {
+			return lookupPath(v, path)
+		}
+		return v, v.Err()
+
+	case SchemaJSONSchema:
+		v, err := extractJSONSchema(i)
+		if err != nil {
+			return v, fmt.Errorf("While converting the JSON Schema %s\n%w\n", file, err)
+		}
+		if path != "" {
+			return lookupPath(v, path)
+		}
+		return v, nil
+
+	case SchemaOpenAPI:
+		if path == "" {
+			return cue.Value{}, fmt.Errorf("An OpenAPI schema needs a component, as in @%s:Name", file)
+		}
+		i, err = openAPIComponent(i.Value(), path)
+		if err != nil {
+			return cue.Value{}, fmt.Errorf("While reading the OpenAPI component %s in %s\n%w\n", path, file, err)
+		}
+		v, err := extractJSONSchema(i)
+		if err != nil {
+			return v, fmt.Errorf("While converting the OpenAPI component %s in %s\n%w\n", path, file, err)
+		}
+		return v, nil
+
+	default:
+		return cue.Value{}, fmt.Errorf("Unknown schema type %q, should be one of cue, jsonschema, or openapi", kind)
+	}
+}
+
+func extractJSONSchema(i *cue.Instance) (cue.Value, error) {
+	f, err := jsonschema.Extract(i, &jsonschema.Config{})
+	if err != nil {
+		return cue.Value{}, err
+	}
+	si, err := r.CompileFile(f)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := si.Value()
+	return v, v.Err()
+}
+
+// openAPIComponent turns the schema component name of an OpenAPI document into a JSON Schema,
+// with the other components as its definitions, so that references between them still work.
+func openAPIComponent(doc cue.Value, name string) (*cue.Instance, error) {
+	schemas := doc.Lookup("components", "schemas")
+	if !schemas.Lookup(name).Exists() {
+		return nil, fmt.Errorf("#/components/schemas/%s not found", name)
+	}
+	defs, err := plainValue(schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	js := map[string]interface{}{
+		"definitions": rewriteRefs(defs, "#/components/schemas/", "#/definitions/"),
+		"$ref":        "#/definitions/" + name,
+	}
+	data, err := json.Marshal(js)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := cuejson.Extract(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return r.CompileExpr(expr)
+}
+
+func rewriteRefs(x interface{}, from, to string) interface{} {
+	switch t := x.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if ref, ok := e.(string); ok && k == "$ref" && strings.HasPrefix(ref, from) {
+				t[k] = to + strings.TrimPrefix(ref, from)
+				continue
+			}
+			t[k] = rewriteRefs(e, from, to)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = rewriteRefs(e, from, to)
+		}
+	}
+	return x
+}
+
+// RunValidateFromArgs checks the data files against schema, see LoadSchema,
+// printing the problems found one per line, or as a JSON list when outFormat is json.
+// A file of StreamOp validates each document read from stdin instead,
+// writing those which pass to stdout, in outFormat, while failures are reported on stderr, see runStream.
+func RunValidateFromArgs(schema string, files []string, entrypoints []string, kind, inFormat, outFormat string) error {
+	var crt *cuetils.CueRuntime
+	if len(entrypoints) > 0 {
+		var err error
+		crt, err = cuetils.CueRuntimeFromEntrypointsAndFlags(entrypoints)
+		if err != nil {
+			crt.PrintCueErrors()
+			return err
+		}
+	}
+
+	S, err := LoadSchema(schema, kind, crt)
+	if err != nil {
+		return fmt.Errorf("While loading schema\n%w\n", err)
+	}
+
+	if len(files) == 1 && files[0] == StreamOp {
+		return runStream(os.Stdin, os.Stdout, inFormat, outFormat, []cue.Value{S}, func(doc cue.Value, ops []cue.Value) (cue.Value, bool, error) {
+			return doc, true, ValidateValue(doc, ops[0])
+		})
+	}
+
+	for i, file := range files {
+		if file == StreamOp {
+			return fmt.Errorf("Validating a stream from stdin, -, can not be mixed with files")
+		}
+		files[i] = strings.TrimPrefix(file, "@")
+	}
+
+	verrs, err := ValidateFiles(S, files)
+	if err != nil {
+		return err
+	}
+
+	err = writeValidationErrors(os.Stdout, verrs, outFormat)
+	if err != nil {
+		return err
+	}
+	if len(verrs) > 0 {
+		return fmt.Errorf("%d problems found", len(verrs))
+	}
+	return nil
+}
+
+func writeValidationErrors(w io.Writer, verrs []ValidationError, format string) error {
+	switch format {
+	case "", "text":
+		for _, ve := range verrs {
+			_, err := fmt.Fprintln(w, ve.String())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "json":
+		return writeJSON(w, verrs)
+
+	default:
+		return fmt.Errorf("Unknown output format %q, should be text or json", format)
+	}
+}
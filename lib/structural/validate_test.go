@@ -0,0 +1,54 @@
+package structural_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/structural"
+)
+
+func TestValidateFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-st-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	good := filepath.Join(dir, "good.json")
+	bad := filepath.Join(dir, "bad.yaml")
+	err = ioutil.WriteFile(good, []byte(`{"name": "web", "replicas": 3}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(bad, []byte("name: web\nreplicas: three\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := filepath.Join(dir, "schema.cue")
+	err = ioutil.WriteFile(schema, []byte("#Config: {\n\tname: string\n\treplicas: int\n}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	S, err := structural.LoadSchema("@"+schema+":#Config", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verrs, err := structural.ValidateFiles(S, []string{good, bad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verrs) != 1 {
+		t.Fatalf("expected 1 problem, got %d: %v", len(verrs), verrs)
+	}
+	if verrs[0].Path != "replicas" {
+		t.Errorf("expected the problem at replicas, got %q", verrs[0].Path)
+	}
+	if verrs[0].File != bad && verrs[0].File != schema {
+		t.Errorf("expected the problem in %s, got %s", bad, verrs[0].File)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"text/template"
 
 	"github.com/aymerick/raymond"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 type Template struct {
@@ -135,10 +137,10 @@ func inferTemplateSystem(content string, config *Config) string {
 
 	// pretty liberally assume golang
 	if rayLhsCnt > 0 {
-		fmt.Println("INFER RAYMOND")
+		logs.Scope("templates").Debug("inferred the template system", "system", "raymond")
 		return "raymond"
 	} else {
-		fmt.Println("INFER GOLANG")
+		logs.Scope("templates").Debug("inferred the template system", "system", "golang")
 		return "golang"
 	}
 
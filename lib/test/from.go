@@ -41,6 +41,11 @@ func RunTestFromArgsFlags(args []string, cmdflags flags.TestFlagpole) (error) {
 		return nil
 	}
 
+	// Or checking scripts without running them
+	if cmdflags.Lint {
+		return LintSuites(suites)
+	}
+
 	// Run all of our suites
 	_, err = RunSuites(suites, -1)
 
@@ -0,0 +1,329 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+)
+
+// Output with more lines than this is checked with cmp against a file
+// in the archive, rather than with a pattern per line.
+const recordMaxPatterns = 5
+
+// Record runs an interactive session, reading command lines from in and
+// running them with the system shell in a fresh work directory, seeded with
+// the files in from if it is not empty. When the session ends, with "exit"
+// or at the end of input, a script which replays the commands and checks
+// their output, status, and the files they touched is written to name.
+//
+// Lines starting with # are kept as phase headings, and "cd dir" and
+// "env KEY=VALUE" lines are applied to the session as in scripts.
+func Record(name, from string, in io.Reader, out io.Writer) error {
+	if filepath.Ext(name) == "" {
+		name += ".hls"
+	}
+
+	work, err := ioutil.TempDir("", "hof-record")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+	work, err = filepath.EvalSymlinks(work)
+	if err != nil {
+		return err
+	}
+
+	seed := new(txtar.Archive)
+	if from != "" {
+		if seed, err = txtar.Pack(from, txtar.PackOptions{}); err != nil {
+			return err
+		}
+		if err = txtar.Unpack(seed, work, txtar.PackOptions{}); err != nil {
+			return err
+		}
+	}
+	before, err := snapshot(work)
+	if err != nil {
+		return err
+	}
+
+	R := &recorder{
+		work:     work,
+		cwd:      work,
+		env:      append(os.Environ(), "WORK="+work),
+		expected: map[string][]byte{},
+	}
+	fmt.Fprintf(out, "recording %s in %s, enter exit or ^D to finish\n", name, work)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "hls> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "exit" {
+			break
+		}
+		if err := R.run(line, out); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := R.checkFiles(before); err != nil {
+		return err
+	}
+	a, err := R.archive(seed)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %s\n", name)
+	return ioutil.WriteFile(name, txtar.Format(a), 0666)
+}
+
+type recorder struct {
+	// the work directory and the current directory within it
+	work, cwd string
+
+	env []string
+
+	// the script so far
+	script strings.Builder
+
+	// files for the archive, by name, checked with cmp or golden
+	expected map[string][]byte
+	count    int
+}
+
+// run runs a line of the session and records it in the script.
+func (R *recorder) run(line string, out io.Writer) error {
+	fields := strings.Fields(line)
+	switch {
+	case line == "":
+		R.script.WriteString("\n")
+		return nil
+
+	case strings.HasPrefix(line, "#"):
+		fmt.Fprintf(&R.script, "\n%s\n", line)
+		return nil
+
+	case fields[0] == "cd" && len(fields) == 2:
+		dir := fields[1]
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(R.cwd, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("cd %s: not a directory", fields[1])
+		}
+		rel, err := filepath.Rel(R.work, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("cd %s: outside of the work directory", fields[1])
+		}
+		R.cwd = dir
+		fmt.Fprintf(&R.script, "cd %s\n", fields[1])
+		return nil
+
+	case fields[0] == "env" && len(fields) > 1:
+		for _, kv := range fields[1:] {
+			if !strings.Contains(kv, "=") {
+				return fmt.Errorf("env: expected KEY=VALUE, got %q", kv)
+			}
+		}
+		R.env = append(R.env, fields[1:]...)
+		fmt.Fprintf(&R.script, "%s\n", line)
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", line)
+	} else {
+		cmd = exec.Command("sh", "-c", line)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Dir = R.cwd
+	cmd.Env = append(R.env, "PWD="+R.cwd)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(&stdout, out)
+	cmd.Stderr = io.MultiWriter(&stderr, out)
+	err := cmd.Run()
+	status := 0
+	if err != nil {
+		ee, ok := err.(*exec.ExitError)
+		if !ok {
+			return err
+		}
+		status = ee.ExitCode()
+	}
+
+	// Simple commands replay with exec, anything else with the shell.
+	if strings.ContainsAny(line, "|&;<>()$`\\\"'*?[]#~%{}") {
+		line = "shell " + quoteArg(line)
+	} else {
+		line = "exec " + line
+	}
+	if status != 0 {
+		line = "! " + line
+	}
+	fmt.Fprintf(&R.script, "%s\n", line)
+	if status > 1 {
+		fmt.Fprintf(&R.script, "status %d\n", status)
+	}
+	R.count++
+	R.expect("stdout", stdout.String(), true)
+	R.expect("stderr", stderr.String(), false)
+	return nil
+}
+
+// expect records the checks for the output of the last command.
+// Output mentioning the work directory is checked against $WORK.
+func (R *recorder) expect(stream, output string, checkEmpty bool) {
+	if output == "" {
+		if checkEmpty {
+			fmt.Fprintf(&R.script, "! %s .\n", stream)
+		}
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) > recordMaxPatterns {
+		file := fmt.Sprintf("expect/%d.%s", R.count, stream)
+		cmp := "cmp"
+		if strings.Contains(output, R.work) {
+			cmp, output = "cmpenv", strings.Replace(output, R.work, "$WORK", -1)
+		}
+		R.expected[file] = []byte(output)
+		fmt.Fprintf(&R.script, "%s %s $WORK/%s\n", cmp, stream, file)
+		return
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// Quoted text is not expanded, so $WORK is left outside of the quotes.
+		parts := strings.Split(line, R.work)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		parts[0] = "^" + parts[0]
+		parts[len(parts)-1] += "$"
+		for i, part := range parts {
+			parts[i] = quoteArg(part)
+		}
+		fmt.Fprintf(&R.script, "%s %s\n", stream, strings.Join(parts, "${WORK@R}"))
+	}
+}
+
+// checkFiles records checks for the files created, changed, or removed by the session.
+func (R *recorder) checkFiles(before map[string][sha256.Size]byte) error {
+	after, err := snapshot(R.work)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	for _, name := range sortedKeys(after) {
+		if sum, ok := before[name]; ok && sum == after[name] {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(R.work, name))
+		if err != nil {
+			return err
+		}
+		slash := filepath.ToSlash(name)
+		R.expected["golden/"+slash] = data
+		lines = append(lines, "golden "+slash)
+	}
+	for _, name := range sortedKeys(before) {
+		if _, ok := after[name]; !ok {
+			lines = append(lines, "! exists "+filepath.ToSlash(name))
+		}
+	}
+	if len(lines) > 0 {
+		if R.cwd != R.work {
+			lines = append([]string{"cd $WORK"}, lines...)
+		}
+		fmt.Fprintf(&R.script, "\n# files touched by the session\n%s\n", strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// archive builds the script archive, with the seed files first.
+// The expected files are packed from a directory, so that binary
+// files and files which look like archives are stored correctly.
+func (R *recorder) archive(seed *txtar.Archive) (*txtar.Archive, error) {
+	stage, err := ioutil.TempDir("", "hof-record-files")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+	for name, data := range R.expected {
+		file := filepath.Join(stage, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(file, data, 0666); err != nil {
+			return nil, err
+		}
+	}
+	expected, err := txtar.Pack(stage, txtar.PackOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	a := &txtar.Archive{}
+	a.Comment = append(a.Comment, seed.Comment...)
+	a.Comment = append(a.Comment, expected.Comment...)
+	a.Comment = append(a.Comment, strings.TrimLeft(R.script.String(), "\n")...)
+	a.Files = append(append(a.Files, seed.Files...), expected.Files...)
+	return a, nil
+}
+
+// snapshot returns the checksums of the files under dir, by relative path.
+func snapshot(dir string) (map[string][sha256.Size]byte, error) {
+	sums := map[string][sha256.Size]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sha256.Sum256(data)
+		return nil
+	})
+	return sums, err
+}
+
+func sortedKeys(m map[string][sha256.Size]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteArg quotes s as a single script argument.
+func quoteArg(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+	"github.com/hofstadter-io/hof/script"
+)
+
+func TestRecord(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the session is recorded with sh")
+	}
+
+	dir, err := ioutil.TempDir("", "hof-record-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the replay below is a parallel subtest, which runs after this returns
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	from := filepath.Join(dir, "from")
+	err = os.Mkdir(from, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(from, "a.txt"), []byte("hello\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := []string{
+		"# greet",
+		"echo hi",
+		"cat a.txt",
+		"env NAME=cow",
+		"echo $NAME > b.txt",
+		"mkdir sub",
+		"cd sub",
+		"ls ..",
+		"false",
+		"sh -c 'exit 3'",
+		"rm ../a.txt",
+		"printf 'l%d\\n' 1 2 3 4 5 6",
+		"pwd",
+		"cd ../missing",
+		"exit",
+		"echo never",
+	}
+	var out strings.Builder
+	err = Record(filepath.Join(dir, "scripts", "greet"), from, strings.NewReader(strings.Join(session, "\n")), &out)
+	if err == nil || !os.IsNotExist(err) {
+		t.Fatalf("expected the missing scripts dir to fail, got %v", err)
+	}
+	err = os.Mkdir(filepath.Join(dir, "scripts"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = Record(filepath.Join(dir, "scripts", "greet"), from, strings.NewReader(strings.Join(session, "\n")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the session shows the output, and why lines were not run
+	for _, s := range []string{"hls> hi\n", "cd ../missing: not a directory\n", "wrote " + filepath.Join(dir, "scripts", "greet.hls")} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected the session to show %q, got:\n%s", s, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "never") {
+		t.Errorf("expected the session to end at exit, got:\n%s", out.String())
+	}
+
+	a, err := txtar.ParseFile(filepath.Join(dir, "scripts", "greet.hls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `# greet
+exec echo hi
+stdout '^hi$'
+exec cat a.txt
+stdout '^hello$'
+env NAME=cow
+shell 'echo $NAME > b.txt'
+! stdout .
+exec mkdir sub
+! stdout .
+cd sub
+exec ls ..
+stdout '^a\.txt$'
+stdout '^b\.txt$'
+stdout '^sub$'
+! exec false
+! stdout .
+! shell 'sh -c ''exit 3'''
+status 3
+! stdout .
+exec rm ../a.txt
+! stdout .
+shell 'printf ''l%d\n'' 1 2 3 4 5 6'
+cmp stdout $WORK/expect/9.stdout
+exec pwd
+stdout '^'${WORK@R}'/sub$'
+
+# files touched by the session
+cd $WORK
+golden b.txt
+! exists a.txt
+`
+	if string(a.Comment) != expect {
+		t.Fatalf("got script:\n%s", a.Comment)
+	}
+	files := map[string]string{}
+	for _, f := range a.Files {
+		files[f.Name] = string(f.Data)
+	}
+	expectFiles := map[string]string{
+		"a.txt":           "hello\n",
+		"expect/9.stdout": "l1\nl2\nl3\nl4\nl5\nl6\n",
+		"golden/b.txt":    "cow\n",
+	}
+	if !reflect.DeepEqual(files, expectFiles) {
+		t.Fatalf("got files %q", files)
+	}
+
+	// the recording replays
+	script.Run(t, script.Params{
+		Dir:  filepath.Join(dir, "scripts"),
+		Glob: "*.hls",
+	})
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		in, expect string
+	}{
+		{in: "", expect: "''"},
+		{in: "a b", expect: "'a b'"},
+		{in: "it's", expect: "'it''s'"},
+	}
+	for _, tt := range tests {
+		if got := quoteArg(tt.in); got != tt.expect {
+			t.Errorf("%q: got %s, want %s", tt.in, got, tt.expect)
+		}
+	}
+}
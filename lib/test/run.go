@@ -5,6 +5,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/hofstadter-io/hof/script"
 )
 
 func RunSuites(suites []Suite, verbose int) (TS Stats, err error) {
@@ -179,6 +181,48 @@ func RunExec(T *Tester, verbose int) (err error) {
 	return err
 }
 
+type ScriptTester struct {
+	BaseTester
+
+	// Glob matches the scripts in Dir, defaulting to "*.hls"
+	Glob string
+}
+
+// LintSuites checks the scripts of the hls testers without running them
+func LintSuites(suites []Suite) error {
+	count := 0
+	for _, S := range suites {
+		for _, T := range S.Tests {
+			if T.Type != "hls" && T.Type != "script" {
+				continue
+			}
+
+			var ST ScriptTester
+			err := T.Value.Decode(&ST)
+			if err != nil {
+				return err
+			}
+
+			problems, err := script.Lint(script.Params{
+				Dir:  ST.Dir,
+				Glob: ST.Glob,
+			})
+			if err != nil {
+				return err
+			}
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+			count += len(problems)
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf("\nFound %d problems in scripts", count)
+	}
+	return nil
+}
+
 func RunScript(T *Tester, verbose int) (err error) {
 	// fmt.Println("hls:", T.Name)
 
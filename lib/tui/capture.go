@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// capture runs fn, returning what it prints to stdout and stderr,
+// which would otherwise draw over the dashboard.
+func capture(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+
+	done := make(chan string)
+	go func() {
+		var b bytes.Buffer
+		io.Copy(&b, r)
+		r.Close()
+		done <- b.String()
+	}()
+
+	err = fn()
+
+	os.Stdout, os.Stderr = stdout, stderr
+	w.Close()
+	return <-done, err
+}
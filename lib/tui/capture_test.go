@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+
+	out, err := capture(func() error {
+		fmt.Println("to stdout")
+		fmt.Fprintln(os.Stderr, "to stderr")
+		return errors.New("failed")
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if out != "to stdout\nto stderr\n" {
+		t.Fatalf("got %q", out)
+	}
+	if os.Stdout != stdout || os.Stderr != stderr {
+		t.Fatal("expected stdout and stderr restored")
+	}
+}
@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/datamodel"
+	"github.com/hofstadter-io/hof/lib/gen"
+	"github.com/hofstadter-io/hof/lib/mod"
+	"github.com/hofstadter-io/hof/lib/runtimes"
+)
+
+const helpLine = "j/k select  d diff  m migrate  v vendor  r refresh  q quit"
+
+// the share of the terminal the output gets
+const outputRatio = 0.3
+
+// Dashboard shows the datamodels, modules, runtimes, and latest generator runs
+// of the current directory, and the output of the last action.
+type Dashboard struct {
+	datamodels *widgets.List
+	modules    *widgets.Table
+	runtimes   *widgets.List
+	runs       *widgets.Table
+	output     *widgets.Paragraph
+	help       *widgets.Paragraph
+
+	grid *ui.Grid
+
+	// the output of the last action, the end of it is shown
+	lines []string
+	// how many lines of output fit
+	height int
+}
+
+// Run shows the dashboard until q or Ctrl-C is pressed.
+func Run() error {
+	err := ui.Init()
+	if err != nil {
+		return fmt.Errorf("While starting the terminal ui\n%w\n", err)
+	}
+	defer ui.Close()
+
+	D := NewDashboard()
+	D.Refresh()
+	D.Resize(ui.TerminalDimensions())
+	D.Render()
+
+	for e := range ui.PollEvents() {
+		switch e.ID {
+		case "q", "<C-c>":
+			return nil
+
+		case "j", "<Down>":
+			if len(D.datamodels.Rows) > 0 {
+				D.datamodels.ScrollDown()
+			}
+		case "k", "<Up>":
+			if len(D.datamodels.Rows) > 0 {
+				D.datamodels.ScrollUp()
+			}
+
+		case "d":
+			D.onDatamodel("diff", func(args []string) error {
+				return datamodel.RunDiffFromArgs(args, flags.DiffFlagpole{})
+			})
+		case "m":
+			D.onDatamodel("migrate", func(args []string) error {
+				return datamodel.RunMigrateFromArgs(args, flags.MigrateFlagpole{})
+			})
+			D.Refresh()
+		case "v":
+			D.Do("vendor", func() error {
+				return mod.ProcessLangs("vendor", nil)
+			})
+			D.Refresh()
+
+		case "r":
+			D.Refresh()
+
+		case "<Resize>":
+			R := e.Payload.(ui.Resize)
+			D.Resize(R.Width, R.Height)
+		}
+
+		D.Render()
+	}
+	return nil
+}
+
+// NewDashboard returns an empty dashboard, see Refresh.
+func NewDashboard() *Dashboard {
+	D := &Dashboard{
+		datamodels: widgets.NewList(),
+		modules:    widgets.NewTable(),
+		runtimes:   widgets.NewList(),
+		runs:       widgets.NewTable(),
+		output:     widgets.NewParagraph(),
+		help:       widgets.NewParagraph(),
+		grid:       ui.NewGrid(),
+	}
+
+	D.datamodels.Title = "Datamodels"
+	D.datamodels.SelectedRowStyle = ui.NewStyle(ui.ColorBlack, ui.ColorCyan)
+	D.modules.Title = "Modules"
+	D.modules.RowSeparator = false
+	D.runtimes.Title = "Runtimes"
+	D.runs.Title = "Generator runs"
+	D.runs.RowSeparator = false
+	D.output.Title = "Output"
+	D.help.Border = false
+	D.help.Text = helpLine
+
+	D.grid.Set(
+		ui.NewRow(0.35,
+			ui.NewCol(0.3, D.datamodels),
+			ui.NewCol(0.7, D.modules),
+		),
+		ui.NewRow(0.3,
+			ui.NewCol(0.3, D.runtimes),
+			ui.NewCol(0.7, D.runs),
+		),
+		ui.NewRow(outputRatio, D.output),
+		ui.NewRow(0.05, D.help),
+	)
+
+	return D
+}
+
+// Refresh reads the datamodels, modules, runtimes, and runs again.
+func (D *Dashboard) Refresh() {
+	// the loaders may print, which would draw over the dashboard
+	out, _ := capture(func() error {
+		D.datamodels.Rows = datamodel.Names()
+		D.runtimes.Rows = runtimes.Names()
+		D.modules.Rows = moduleRows()
+		D.runs.Rows = runRows()
+		return nil
+	})
+	if out != "" {
+		D.setOutput(out)
+	}
+
+	if D.datamodels.SelectedRow >= len(D.datamodels.Rows) {
+		D.datamodels.SelectedRow = 0
+	}
+}
+
+// Do runs the action name, showing its output.
+func (D *Dashboard) Do(name string, action func() error) {
+	D.setOutput(name + " ...")
+	D.Render()
+
+	out, err := capture(action)
+	if err != nil {
+		out += "\n" + err.Error()
+	}
+	if strings.TrimSpace(out) == "" {
+		out = name + " done"
+	}
+	D.setOutput(out)
+}
+
+func (D *Dashboard) onDatamodel(name string, action func(args []string) error) {
+	if len(D.datamodels.Rows) == 0 {
+		D.setOutput("no datamodels to " + name)
+		return
+	}
+	dm := D.datamodels.Rows[D.datamodels.SelectedRow]
+	D.Do(name+" "+dm, func() error {
+		return action([]string{dm})
+	})
+}
+
+// Resize fits the dashboard to the terminal.
+func (D *Dashboard) Resize(width, height int) {
+	D.grid.SetRect(0, 0, width, height)
+	// less the border
+	D.height = int(float64(height)*outputRatio) - 2
+	D.showOutput()
+}
+
+// Render draws the dashboard.
+func (D *Dashboard) Render() {
+	ui.Render(D.grid)
+}
+
+func (D *Dashboard) setOutput(out string) {
+	D.lines = strings.Split(strings.TrimRight(out, "\n"), "\n")
+	D.showOutput()
+}
+
+// showOutput shows the lines of output which fit, from the end.
+func (D *Dashboard) showOutput() {
+	lines := D.lines
+	if D.height > 0 && len(lines) > D.height {
+		lines = lines[len(lines)-D.height:]
+	}
+	D.output.Text = strings.Join(lines, "\n")
+}
+
+func moduleRows() [][]string {
+	rows := [][]string{{"lang", "module", "requires", "vendored"}}
+	for _, S := range mod.Statuses() {
+		if S.Err != nil {
+			rows = append(rows, []string{S.Lang, S.Err.Error(), "", ""})
+			continue
+		}
+		rows = append(rows, []string{S.Lang, S.Module, fmt.Sprint(S.Requires), fmt.Sprint(S.Vendored)})
+	}
+	return rows
+}
+
+func runRows() [][]string {
+	rows := [][]string{{"when", "generator", "written", "conflicts", "errors"}}
+	runs, err := gen.LoadRuns()
+	if err != nil {
+		return append(rows, []string{"", err.Error(), "", "", ""})
+	}
+	for _, R := range runs {
+		rows = append(rows, []string{
+			R.Time.Format("Jan 2 15:04"),
+			R.Generator,
+			fmt.Sprint(R.Written),
+			fmt.Sprint(R.Conflicted),
+			fmt.Sprint(R.Errors),
+		})
+	}
+	return rows
+}
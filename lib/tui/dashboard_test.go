@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hofstadter-io/hof/cmd/hof/flags"
+	"github.com/hofstadter-io/hof/lib/gen"
+)
+
+// inTempDir runs the test in an empty dir, as the dashboard reads the current one,
+// with the runs kept there too, rather than in the user cache
+func inTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "hof-tui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runsDir, dmDir, rtDir := gen.RunsDir, flags.RootDatamodelDirPflag, flags.RootRuntimesDirPflag
+	gen.RunsDir = filepath.Join(dir, ".runs")
+	flags.RootDatamodelDirPflag, flags.RootRuntimesDirPflag = "", ""
+	t.Cleanup(func() {
+		gen.RunsDir = runsDir
+		flags.RootDatamodelDirPflag, flags.RootRuntimesDirPflag = dmDir, rtDir
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func TestRefresh(t *testing.T) {
+	dir := inTempDir(t)
+
+	D := NewDashboard()
+	D.Refresh()
+	if len(D.datamodels.Rows) != 0 || len(D.runs.Rows) != 1 || len(D.modules.Rows) != 1 {
+		t.Fatalf("expected an empty dashboard, got %v %v %v", D.datamodels.Rows, D.runs.Rows, D.modules.Rows)
+	}
+
+	err := ioutil.WriteFile(filepath.Join(dir, "app.cue"), []byte(`package app
+
+Users: {} @datamodel()
+Posts: {} @datamodel()
+deno: {} @runtime()
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gen.RecordRuns([]gen.Run{
+		{Generator: "api", Time: time.Date(2020, 3, 4, 15, 4, 0, 0, time.Local), Written: 3, Conflicted: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D.datamodels.SelectedRow = 5
+	D.Refresh()
+	if expect := []string{"Posts", "Users"}; !reflect.DeepEqual(D.datamodels.Rows, expect) {
+		t.Fatalf("got datamodels %q", D.datamodels.Rows)
+	}
+	if D.datamodels.SelectedRow != 0 {
+		t.Fatalf("expected the selection reset, got %d", D.datamodels.SelectedRow)
+	}
+	if expect := []string{"bash", "deno", "go", "js", "py"}; !reflect.DeepEqual(D.runtimes.Rows, expect) {
+		t.Fatalf("got runtimes %q", D.runtimes.Rows)
+	}
+	expect := [][]string{
+		{"when", "generator", "written", "conflicts", "errors"},
+		{"Mar 4 15:04", "api", "3", "1", "0"},
+	}
+	if !reflect.DeepEqual(D.runs.Rows, expect) {
+		t.Fatalf("got runs %q", D.runs.Rows)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	inTempDir(t)
+
+	D := NewDashboard()
+	D.onDatamodel("diff", func(args []string) error {
+		t.Fatal("expected no action without datamodels")
+		return nil
+	})
+	if D.output.Text != "no datamodels to diff" {
+		t.Fatalf("got %q", D.output.Text)
+	}
+
+	// only the end of the output which fits is shown
+	out := "a\nb\nc\nd\ne\nf\ng\nh"
+	D.setOutput(out + "\n")
+	if D.output.Text != out {
+		t.Fatalf("expected all of the output before a resize, got %q", D.output.Text)
+	}
+	// 30% of 20 rows, less the border
+	D.Resize(80, 20)
+	if D.output.Text != "e\nf\ng\nh" {
+		t.Fatalf("expected the last 4 lines, got %q", D.output.Text)
+	}
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hofstadter-io/hof/lib/datamodel"
+	"github.com/hofstadter-io/hof/lib/gen"
+	"github.com/hofstadter-io/hof/lib/mod"
+)
+
+// Module is the status of the module of a language, see mod.ModStatus
+type Module struct {
+	Lang     string `json:"lang"`
+	Module   string `json:"module,omitempty"`
+	Requires int    `json:"requires"`
+	Vendored bool   `json:"vendored"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Output is the files a generator last wrote, from the shadow dir
+type Output struct {
+	Generator string   `json:"generator"`
+	Files     []string `json:"files"`
+}
+
+// the lib packages keep state, as the modders do, so requests are served one at a time
+var serving sync.Mutex
+
+// get only allows GET and HEAD requests to h, the API does not change anything
+func get(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+			return
+		}
+		serving.Lock()
+		defer serving.Unlock()
+		h(w, r)
+	}
+}
+
+func handleDatamodels(w http.ResponseWriter, r *http.Request) {
+	names := datamodel.Names()
+	if names == nil {
+		names = []string{}
+	}
+	writeJSON(w, names)
+}
+
+func handleModules(w http.ResponseWriter, r *http.Request) {
+	mods := []Module{}
+	for _, S := range mod.Statuses() {
+		M := Module{Lang: S.Lang, Module: S.Module, Requires: S.Requires, Vendored: S.Vendored}
+		if S.Err != nil {
+			M.Error = S.Err.Error()
+		}
+		mods = append(mods, M)
+	}
+	writeJSON(w, mods)
+}
+
+// handleModuleGraph serves /api/modules/<lang>/graph
+func handleModuleGraph(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/modules/")
+	if !strings.HasSuffix(rest, "/graph") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+		return
+	}
+	lang := strings.TrimSuffix(rest, "/graph")
+
+	G, err := mod.GraphOf(lang)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, G)
+}
+
+func handleRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := gen.LoadRuns()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if runs == nil {
+		runs = []gen.Run{}
+	}
+	writeJSON(w, runs)
+}
+
+func handleOutputs(w http.ResponseWriter, r *http.Request) {
+	shadow, err := gen.LoadShadow("")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the shadow dir has a dir for each generator
+	byGen := map[string]*Output{}
+	for fn := range shadow {
+		parts := strings.SplitN(filepath.ToSlash(fn), "/", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		O, ok := byGen[parts[0]]
+		if !ok {
+			O = &Output{Generator: parts[0]}
+			byGen[parts[0]] = O
+		}
+		O.Files = append(O.Files, parts[1])
+	}
+
+	outs := []*Output{}
+	for _, O := range byGen {
+		sort.Strings(O.Files)
+		outs = append(outs, O)
+	}
+	sort.Slice(outs, func(i, j int) bool { return outs[i].Generator < outs[j].Generator })
+	writeJSON(w, outs)
+}
+
+// handleOutput serves /api/outputs/<gen>/<file> as text
+func handleOutput(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, "/api/outputs/")
+	fn := filepath.Join(gen.SHADOW_DIR, filepath.FromSlash(rel))
+
+	// only the shadow dir is served
+	shadowDir := filepath.Clean(gen.SHADOW_DIR)
+	if !strings.HasPrefix(fn, shadowDir+string(filepath.Separator)) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("bad output path %q", rel))
+		return
+	}
+
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("output %q not found", rel))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
@@ -0,0 +1,144 @@
+package ui
+
+// indexHTML is the web ui, a single page on the JSON API
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hof</title>
+<style>
+  body { font-family: sans-serif; margin: 2em; color: #222; }
+  h1 { font-size: 1.4em; }
+  h2 { font-size: 1.1em; margin-top: 1.5em; border-bottom: 1px solid #ccc; }
+  table { border-collapse: collapse; }
+  td, th { text-align: left; padding: 0.2em 1em 0.2em 0; }
+  a { color: #0366d6; cursor: pointer; }
+  pre { background: #f6f8fa; padding: 1em; overflow: auto; max-height: 30em; }
+  .error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>hof</h1>
+
+<h2>Datamodels</h2>
+<ul id="datamodels"></ul>
+
+<h2>Modules</h2>
+<table id="modules"></table>
+<pre id="graph" hidden></pre>
+
+<h2>Generator runs</h2>
+<table id="runs"></table>
+
+<h2>Generator outputs</h2>
+<div id="outputs"></div>
+<pre id="output" hidden></pre>
+
+<script>
+function el(tag, text) {
+  const e = document.createElement(tag);
+  if (text !== undefined) e.textContent = text;
+  return e;
+}
+
+function link(text, onclick) {
+  const a = el("a", text);
+  a.onclick = onclick;
+  return a;
+}
+
+function row(table, cells, header) {
+  const tr = el("tr");
+  for (const c of cells) {
+    const td = el(header ? "th" : "td");
+    if (c instanceof Node) td.appendChild(c); else td.textContent = c;
+    tr.appendChild(td);
+  }
+  table.appendChild(tr);
+}
+
+async function api(path) {
+  const resp = await fetch("/api/" + path);
+  const data = await resp.json();
+  if (!resp.ok) throw new Error(data.error);
+  return data;
+}
+
+function fail(id, err) {
+  const e = document.getElementById(id);
+  e.textContent = "";
+  e.appendChild(el("div", err.message)).className = "error";
+}
+
+function showText(id, text) {
+  const pre = document.getElementById(id);
+  pre.textContent = text;
+  pre.hidden = false;
+}
+
+async function datamodels() {
+  const ul = document.getElementById("datamodels");
+  const names = await api("datamodels");
+  if (names.length == 0) ul.appendChild(el("li", "none"));
+  for (const n of names) ul.appendChild(el("li", n));
+}
+
+async function graph(lang) {
+  try {
+    const G = await api("modules/" + encodeURIComponent(lang) + "/graph");
+    const lines = (G.edges || []).map(e => e.from + " -> " + e.to + "@" + e.version);
+    showText("graph", G.root + "\n\n" + (lines.join("\n") || "no dependencies"));
+  } catch (err) {
+    showText("graph", err.message);
+  }
+}
+
+async function modules() {
+  const t = document.getElementById("modules");
+  row(t, ["lang", "module", "requires", "vendored", ""], true);
+  for (const M of await api("modules")) {
+    if (M.error) {
+      row(t, [M.lang, M.error, "", "", ""]);
+      continue;
+    }
+    row(t, [M.lang, M.module, M.requires, M.vendored, link("graph", () => graph(M.lang))]);
+  }
+}
+
+async function runs() {
+  const t = document.getElementById("runs");
+  row(t, ["when", "generator", "written", "same", "conflicts", "errors"], true);
+  for (const R of await api("runs")) {
+    row(t, [new Date(R.time).toLocaleString(), R.generator, R.written, R.same, R.conflicted, R.errors]);
+  }
+}
+
+async function output(gen, file) {
+  const resp = await fetch("/api/outputs/" + encodeURIComponent(gen) + "/" + file.split("/").map(encodeURIComponent).join("/"));
+  showText("output", await resp.text());
+}
+
+async function outputs() {
+  const div = document.getElementById("outputs");
+  const outs = await api("outputs");
+  if (outs.length == 0) div.appendChild(el("p", "nothing generated yet"));
+  for (const O of outs) {
+    div.appendChild(el("h3", O.generator));
+    const ul = el("ul");
+    for (const f of O.files) {
+      const li = el("li");
+      li.appendChild(link(f, () => output(O.generator, f)));
+      ul.appendChild(li);
+    }
+    div.appendChild(ul);
+  }
+}
+
+datamodels().catch(err => fail("datamodels", err));
+modules().catch(err => fail("modules", err));
+runs().catch(err => fail("runs", err));
+outputs().catch(err => fail("outputs", err));
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DefaultAddr is where hof ui listens, only on this machine
+const DefaultAddr = "localhost:2323"
+
+// Serve runs hof's web ui, and the JSON API under /api/ it is built on, until it fails.
+//
+//   GET /api/datamodels                 the names of the datamodels
+//   GET /api/modules                    the module of each language
+//   GET /api/modules/<lang>/graph       the resolved dependency graph of a module
+//   GET /api/runs                       the latest runs of hof gen
+//   GET /api/outputs                    the files last generated, by generator
+//   GET /api/outputs/<gen>/<file>       a file as last generated
+func Serve(addr string) error {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	fmt.Printf("hof ui at http://%s\n", addr)
+	return http.ListenAndServe(addr, Handler())
+}
+
+// Handler returns the web ui and its API, for serving elsewhere or testing.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/datamodels", get(handleDatamodels))
+	mux.HandleFunc("/api/modules", get(handleModules))
+	mux.HandleFunc("/api/modules/", get(handleModuleGraph))
+	mux.HandleFunc("/api/runs", get(handleRuns))
+	mux.HandleFunc("/api/outputs", get(handleOutputs))
+	mux.HandleFunc("/api/outputs/", get(handleOutput))
+
+	mux.HandleFunc("/", get(handleIndex))
+
+	return mux
+}
+
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// writeError replies with {"error": "..."}, so clients of the API always get JSON
+func writeError(w http.ResponseWriter, code int, err error) {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
@@ -0,0 +1,110 @@
+package ui_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/gen"
+	"github.com/hofstadter-io/hof/lib/ui"
+)
+
+// inTempDir runs the test in an empty dir, as the API reads the current one,
+// with the runs kept there too, rather than in the user cache
+func inTempDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "hof-ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runsDir := gen.RunsDir
+	gen.RunsDir = filepath.Join(dir, ".runs")
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		gen.RunsDir = runsDir
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+func get(t *testing.T, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ui.Handler().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestRunsAndOutputs(t *testing.T) {
+	defer inTempDir(t)()
+
+	w := get(t, "GET", "/api/runs")
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Fatalf("expected no runs, got %d %q", w.Code, w.Body.String())
+	}
+
+	err := gen.RecordRuns([]gen.Run{{Generator: "api", Time: time.Now(), Written: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = get(t, "GET", "/api/runs")
+	var runs []gen.Run
+	err = json.Unmarshal(w.Body.Bytes(), &runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Generator != "api" || runs[0].Written != 2 {
+		t.Errorf("unexpected runs %v", runs)
+	}
+
+	fn := filepath.Join(gen.SHADOW_DIR, "api", "out", "main.go")
+	os.MkdirAll(filepath.Dir(fn), 0755)
+	ioutil.WriteFile(fn, []byte("package main\n"), 0644)
+
+	w = get(t, "GET", "/api/outputs")
+	expect := `[
+  {
+    "generator": "api",
+    "files": [
+      "out/main.go"
+    ]
+  }
+]
+`
+	if w.Body.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, w.Body.String())
+	}
+
+	w = get(t, "GET", "/api/outputs/api/out/main.go")
+	if w.Code != http.StatusOK || w.Body.String() != "package main\n" {
+		t.Errorf("unexpected output %d %q", w.Code, w.Body.String())
+	}
+
+	w = get(t, "GET", "/api/outputs/../../go.mod")
+	if w.Code == http.StatusOK {
+		t.Errorf("expected files outside of the shadow dir to not be served")
+	}
+}
+
+func TestOnlyGet(t *testing.T) {
+	defer inTempDir(t)()
+
+	w := get(t, "POST", "/api/runs")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST to not be allowed, got %d", w.Code)
+	}
+
+	w = get(t, "GET", "/")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the index, got %d", w.Code)
+	}
+}
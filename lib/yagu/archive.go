@@ -0,0 +1,149 @@
+package yagu
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+// BillyLoadFromTar loads the files of a tar archive into FS, like BillyLoadFromZip,
+// dropping the leading directory when trimFirstDir is set and overwriting existing files.
+// Only the files selected by filter are loaded, all of them when it is nil.
+// Symlinks are loaded as plain files holding their target.
+func BillyLoadFromTar(tReader *tar.Reader, FS billy.Filesystem, trimFirstDir bool, filter *ZipFilter) error {
+	for {
+		hdr, err := tReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fn := strings.TrimPrefix(hdr.Name, "./")
+		if trimFirstDir {
+			fn = fn[strings.Index(fn, "/")+1:]
+		}
+		if fn == "" {
+			continue
+		}
+		err = checkArchiveName(fn)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			ok, err := filter.MatchDir(strings.TrimSuffix(fn, "/"))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			err = FS.MkdirAll(fn, 0755)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeReg, tar.TypeRegA, tar.TypeSymlink:
+			ok, err := filter.Match(fn, hdr.Size)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			var src io.Reader = tReader
+			if hdr.Typeflag == tar.TypeSymlink {
+				src = strings.NewReader(hdr.Linkname)
+			}
+			err = billyLoadFile(src, fn, FS)
+			if err != nil {
+				return err
+			}
+
+		default:
+			// pax headers, hard links, and devices have no content of their own
+			continue
+		}
+	}
+}
+
+func billyLoadFile(src io.Reader, fn string, FS billy.Filesystem) error {
+	dst, err := FS.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// BillyLoadFromArchive loads a zip, tar, or gzipped tar archive into FS,
+// as with BillyLoadFromZip and BillyLoadFromTar.
+// The format is taken from contentType, the Content-Type of the download,
+// and from the leading bytes of data when that is empty or generic.
+func BillyLoadFromArchive(data []byte, contentType string, FS billy.Filesystem, trimFirstDir bool, filter *ZipFilter) error {
+	format, err := ArchiveFormat(data, contentType)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "zip":
+		zReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		return BillyLoadFromZip(zReader, FS, trimFirstDir, filter)
+
+	case "tar.gz":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return BillyLoadFromTar(tar.NewReader(gz), FS, trimFirstDir, filter)
+
+	default:
+		return BillyLoadFromTar(tar.NewReader(bytes.NewReader(data)), FS, trimFirstDir, filter)
+	}
+}
+
+// ArchiveFormat returns the format of an archive, one of zip, tar.gz, or tar,
+// from its Content-Type or else its leading bytes.
+func ArchiveFormat(data []byte, contentType string) (string, error) {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "application/zip", "application/x-zip-compressed":
+		return "zip", nil
+	case "application/gzip", "application/x-gzip", "application/x-gtar", "application/x-compressed-tar":
+		return "tar.gz", nil
+	case "application/x-tar":
+		return "tar", nil
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(data, []byte("\x1f\x8b")):
+		return "tar.gz", nil
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return "tar", nil
+	}
+
+	if contentType == "" {
+		contentType = "no content type"
+	}
+	return "", fmt.Errorf("Unknown archive format (%s)", contentType)
+}
@@ -0,0 +1,146 @@
+package yagu
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+func testTar(t *testing.T, hdrs []tar.Header, contents []string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i, hdr := range hdrs {
+		hdr.Size = int64(len(contents[i]))
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if hdr.Mode == 0 && hdr.Typeflag != tar.TypeXGlobalHeader {
+			hdr.Mode = 0644
+		}
+		err := tw.WriteHeader(&hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			tw.Write([]byte(contents[i]))
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(data)
+	err := gw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBillyLoadFromArchive(t *testing.T) {
+	tarData := testTar(t, []tar.Header{
+		{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": "0123abc"}},
+		{Name: "./repo-v1.0.0/", Typeflag: tar.TypeDir},
+		{Name: "./repo-v1.0.0/cue.mods", Typeflag: tar.TypeReg},
+		{Name: "./repo-v1.0.0/schema/", Typeflag: tar.TypeDir},
+		{Name: "./repo-v1.0.0/schema/app.cue", Typeflag: tar.TypeReg},
+		{Name: "./repo-v1.0.0/schema/link.cue", Typeflag: tar.TypeSymlink, Linkname: "app.cue"},
+		{Name: "./repo-v1.0.0/schema/hard.cue", Typeflag: tar.TypeLink, Linkname: "repo-v1.0.0/schema/app.cue"},
+		{Name: "./repo-v1.0.0/testdata/", Typeflag: tar.TypeDir},
+		{Name: "./repo-v1.0.0/testdata/fixture.cue", Typeflag: tar.TypeReg},
+	}, []string{"", "", "module a", "", "package schema", "", "", "", "package fixture"})
+
+	for _, tt := range []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{name: "tar", data: tarData, contentType: "application/x-tar"},
+		{name: "tar by content", data: tarData, contentType: "application/octet-stream"},
+		{name: "tar.gz", data: gzipped(t, tarData), contentType: "application/gzip"},
+		{name: "tar.gz by content", data: gzipped(t, tarData)},
+	} {
+		FS := memfs.New()
+		err := BillyLoadFromArchive(tt.data, tt.contentType, FS, true, &ZipFilter{Excludes: []string{"testdata"}})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		files, err := BillyFilenames("/", FS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(files)
+		if strings.Join(files, " ") != "/cue.mods /schema/app.cue /schema/link.cue" {
+			t.Errorf("%s: got files %q", tt.name, files)
+		}
+		if s, _ := BillyReadAllString("schema/link.cue", FS); s != "app.cue" {
+			t.Errorf("%s: expected a symlink to hold its target, got %q", tt.name, s)
+		}
+	}
+
+	// entries may not leave the filesystem, even after trimming
+	for _, name := range []string{"repo/../../etc/passwd", "./repo/../..", "repo/sub/../../../x"} {
+		data := testTar(t, []tar.Header{{Name: name, Typeflag: tar.TypeReg}}, []string{"evil"})
+		err := BillyLoadFromArchive(data, "", memfs.New(), true, nil)
+		if err == nil || !strings.Contains(err.Error(), "is outside of the archive") {
+			t.Errorf("%s: expected the entry to be rejected, got %v", name, err)
+		}
+	}
+	data := testTar(t, []tar.Header{{Name: "/etc/passwd", Typeflag: tar.TypeReg}}, []string{"evil"})
+	err := BillyLoadFromArchive(data, "", memfs.New(), false, nil)
+	if err == nil || !strings.Contains(err.Error(), "is outside of the archive") {
+		t.Errorf("expected an absolute entry to be rejected, got %v", err)
+	}
+}
+
+func TestArchiveFormat(t *testing.T) {
+	tarData := testTar(t, []tar.Header{{Name: "a.cue", Typeflag: tar.TypeReg}}, []string{"a"})
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		expect      string
+		err         string
+	}{
+		{name: "zip type", contentType: "application/zip", expect: "zip"},
+		{name: "zip type with params", contentType: "application/x-zip-compressed; charset=binary", expect: "zip"},
+		{name: "gzip type", contentType: "application/x-gzip", expect: "tar.gz"},
+		{name: "tar type", contentType: "application/x-tar", expect: "tar"},
+		{name: "zip bytes", data: []byte("PK\x03\x04rest"), contentType: "application/octet-stream", expect: "zip"},
+		{name: "empty zip bytes", data: []byte("PK\x05\x06rest"), expect: "zip"},
+		{name: "gzip bytes", data: []byte("\x1f\x8brest"), expect: "tar.gz"},
+		{name: "tar bytes", data: tarData, expect: "tar"},
+		{name: "unknown", data: []byte("<html>"), contentType: "text/html", err: "Unknown archive format (text/html)"},
+		{name: "unknown without type", data: []byte("??"), err: "Unknown archive format (no content type)"},
+	}
+
+	for _, tt := range tests {
+		format, err := ArchiveFormat(tt.data, tt.contentType)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: expected the error %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if format != tt.expect {
+			t.Errorf("%s: got %s, want %s", tt.name, format, tt.expect)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package yagu
 
 import (
 	"archive/zip"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
 
+	"github.com/bmatcuk/doublestar"
 	"github.com/go-git/go-billy/v5"
 	"golang.org/x/mod/sumdb/dirhash"
 )
@@ -129,43 +131,125 @@ func BillyGlobWriteDirToOS(baseDir string, dir string, FS billy.Filesystem, incl
 	return nil
 }
 
-func BillyLoadFromZip(zReader *zip.Reader, FS billy.Filesystem, trimFirstDir bool) error {
+// BillyLoadFromZip loads the files of an archive into FS, dropping the leading directory
+// that repository archives wrap their content in when trimFirstDir is set.
+// Only the files selected by filter are loaded, all of them when it is nil.
+func BillyLoadFromZip(zReader *zip.Reader, FS billy.Filesystem, trimFirstDir bool, filter *ZipFilter) error {
 	for _, f := range zReader.File {
+		fn := f.Name
+		if trimFirstDir {
+			fn = fn[strings.Index(fn, "/")+1:]
+		}
+		if fn == "" {
+			continue
+		}
+		err := checkArchiveName(fn)
+		if err != nil {
+			return err
+		}
 
 		// Is this a directory?
-		if strings.HasSuffix(f.Name, "/") {
-			err := FS.MkdirAll(f.Name, 0755)
+		if strings.HasSuffix(fn, "/") {
+			ok, err := filter.MatchDir(strings.TrimSuffix(fn, "/"))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			err = FS.MkdirAll(fn, 0755)
 			if err != nil {
 				return err
 			}
 			continue
 		}
 
-		fn := f.Name[strings.Index(f.Name, "/")+1:]
-
-    src, err := f.Open()
-    if err != nil {
-			return err
-    }
-    defer src.Close()
-
-		dst, err := FS.Create(fn)
+		ok, err := filter.Match(fn, int64(f.UncompressedSize64))
 		if err != nil {
 			return err
 		}
-    defer dst.Close()
+		if !ok {
+			continue
+		}
 
-		_, err = io.Copy(dst, src)
+		err = billyLoadZipFile(f, fn, FS)
 		if err != nil {
 			return err
 		}
-
-		// fmt.Println("FromZip:", fn, cnt)
 	}
 
 	return nil
 }
 
+// checkArchiveName rejects the names of archive entries which are absolute or
+// use .. to leave the directory they are loaded into.
+func checkArchiveName(name string) error {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("Archive entry %q is outside of the archive", name)
+	}
+	return nil
+}
+
+func billyLoadZipFile(f *zip.File, fn string, FS billy.Filesystem) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return billyLoadFile(src, fn, FS)
+}
+
+// ZipFilter selects the files of an archive to load, by their names
+// after any trimming and their uncompressed sizes. A nil filter selects every file.
+type ZipFilter struct {
+	// Includes are globs of the files to load, all files when empty
+	Includes []string
+	// Excludes are globs of the files to skip, even when included
+	Excludes []string
+	// MaxSize skips files larger than it, in bytes, when positive
+	MaxSize int64
+}
+
+// Match reports whether the file name of size bytes is selected.
+// Files in an excluded directory are skipped too.
+func (zf *ZipFilter) Match(name string, size int64) (bool, error) {
+	if zf == nil {
+		return true, nil
+	}
+	if zf.MaxSize > 0 && size > zf.MaxSize {
+		return false, nil
+	}
+	excluded, err := zf.excluded(name)
+	if err != nil || excluded {
+		return false, err
+	}
+	return CheckShouldInclude(name, zf.Includes, nil)
+}
+
+// MatchDir reports whether dir is kept, which it is unless it or a parent is excluded.
+func (zf *ZipFilter) MatchDir(dir string) (bool, error) {
+	if zf == nil {
+		return true, nil
+	}
+	excluded, err := zf.excluded(dir)
+	return !excluded, err
+}
+
+// excluded checks name and each of its parent directories against the excludes.
+func (zf *ZipFilter) excluded(name string) (bool, error) {
+	for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		for _, pattern := range zf.Excludes {
+			match, err := doublestar.PathMatch(pattern, p)
+			if err != nil || match {
+				return match, err
+			}
+		}
+	}
+	return false, nil
+}
+
 // Loads an initialized  zip.Reader into an initialize billy.Filesystem
 func BillyGlobLoadFromZip(zReader *zip.Reader, FS billy.Filesystem, includes, excludes []string) error {
 
@@ -0,0 +1,138 @@
+package yagu
+
+import (
+	"archive/zip"
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+type archiveFile struct {
+	name    string
+	content string
+}
+
+func testZip(t *testing.T, files []archiveFile) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestZipFilter(t *testing.T) {
+	filter := &ZipFilter{
+		Includes: []string{"**/*.cue", "cue.mods"},
+		Excludes: []string{"testdata", "**/*_gen.cue"},
+		MaxSize:  10,
+	}
+
+	tests := []struct {
+		name   string
+		size   int64
+		expect bool
+	}{
+		{name: "cue.mods", size: 5, expect: true},
+		{name: "schema/app.cue", size: 5, expect: true},
+		{name: "schema/app.cue", size: 10, expect: true},
+		{name: "schema/big.cue", size: 11, expect: false},
+		{name: "schema/app_gen.cue", size: 5, expect: false},
+		{name: "testdata/app.cue", size: 5, expect: false},
+		{name: "testdata/deep/app.cue", size: 5, expect: false},
+		{name: "docs/readme.md", size: 5, expect: false},
+	}
+	for _, tt := range tests {
+		ok, err := filter.Match(tt.name, tt.size)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if ok != tt.expect {
+			t.Errorf("%s of %d bytes: got %v, want %v", tt.name, tt.size, ok, tt.expect)
+		}
+	}
+
+	for dir, expect := range map[string]bool{"schema": true, "testdata": false, "testdata/deep": false, "docs": true} {
+		ok, err := filter.MatchDir(dir)
+		if err != nil || ok != expect {
+			t.Errorf("%s: got dir %v, %v, want %v", dir, ok, err, expect)
+		}
+	}
+
+	// a nil filter selects everything
+	var none *ZipFilter
+	if ok, _ := none.Match("anything/at/all.bin", 1<<30); !ok {
+		t.Errorf("expected a nil filter to select every file")
+	}
+	if ok, _ := none.MatchDir("testdata"); !ok {
+		t.Errorf("expected a nil filter to keep every dir")
+	}
+
+	_, err := (&ZipFilter{Excludes: []string{"[bad"}}).Match("a.cue", 1)
+	if err == nil {
+		t.Errorf("expected a bad glob to fail")
+	}
+}
+
+func TestBillyLoadFromZip(t *testing.T) {
+	zr := testZip(t, []archiveFile{
+		{"repo-v1.0.0/", ""},
+		{"repo-v1.0.0/cue.mods", "module a"},
+		{"repo-v1.0.0/schema/", ""},
+		{"repo-v1.0.0/schema/app.cue", "package schema"},
+		{"repo-v1.0.0/testdata/", ""},
+		{"repo-v1.0.0/testdata/fixture.cue", "package fixture"},
+		{"repo-v1.0.0/big.bin", strings.Repeat("x", 100)},
+	})
+
+	FS := memfs.New()
+	err := BillyLoadFromZip(zr, FS, true, &ZipFilter{Excludes: []string{"testdata"}, MaxSize: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := BillyFilenames("/", FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if strings.Join(files, " ") != "/cue.mods /schema/app.cue" {
+		t.Fatalf("got files %q", files)
+	}
+	if _, err := FS.Stat("testdata"); err == nil {
+		t.Fatalf("expected the excluded dir not to be made")
+	}
+
+	// entries may not leave the filesystem, even after trimming
+	for _, name := range []string{"repo/../../etc/passwd", "/etc/passwd", "repo/../..", `repo/..\..\x`} {
+		for _, trim := range []bool{true, false} {
+			zr = testZip(t, []archiveFile{{"repo/ok.cue", "ok"}, {name, "evil"}})
+			err = BillyLoadFromZip(zr, memfs.New(), trim, nil)
+			if trim && name == "/etc/passwd" {
+				// trimmed to etc/passwd
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), "is outside of the archive") {
+				t.Errorf("%s, trimmed %v: expected the entry to be rejected, got %v", name, trim, err)
+			}
+		}
+	}
+}
@@ -1,11 +1,12 @@
 package yagu
 
 import (
-	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 // https://blog.depado.eu/post/copy-files-and-directories-in-go [03-04-2-19]
@@ -19,7 +20,7 @@ func CopyFile(src, dst string) error {
 
 	srcfd, err = os.Open(src)
 	if err != nil {
-		fmt.Println("Error in COPY FILE", src, dst)
+		logs.Scope("yagu").Debug("copying file", "src", src, "dst", dst, "err", err)
 		return err
 	}
 	defer srcfd.Close()
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
-
-	"github.com/parnurzeal/gorequest"
 )
 
 type GaConfig struct {
@@ -77,7 +75,7 @@ func SendGaEvent(cfg GaConfig, evt GaEvent) (string, error) {
 
 	// fmt.Println("GA: ", payload)
 
-	req := gorequest.New().Post(gaURL).Send(payload)
+	req := NewRequest().Post(gaURL).Send(payload)
 
 
 	resp, body, errs := req.End()
@@ -132,7 +130,7 @@ func SendGaEvents(cfg GaConfig, evts []GaEvent) (string, error) {
 		payload += vals.Encode() + "\n"
 	}
 
-	req := gorequest.New().Post(gaURL).Send(payload)
+	req := NewRequest().Post(gaURL).Send(payload)
 
 
 	resp, body, errs := req.End()
@@ -4,12 +4,26 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+var gitTransportOnce sync.Once
+
+// InstallGitTransport makes go-git reach http and https remotes
+// through the proxies and with the certificates of Transport.
+func InstallGitTransport() {
+	gitTransportOnce.Do(func() {
+		c := http.NewClient(HTTPClient())
+		client.InstallProtocol("https", c)
+		client.InstallProtocol("http", c)
+	})
+}
+
 func CloneRepoIntoTmp(srcUrl, srcVer string) (string, error) {
 
 	co, err := SetupGitOptions(srcUrl, srcVer)
@@ -51,6 +65,8 @@ func CloneRepoIntoDir(srcUrl, srcVer, dest string) (error) {
 }
 
 func SetupGitOptions(srcUrl, srcVer string) (*git.CloneOptions, error) {
+	InstallGitTransport()
+
 	co := &git.CloneOptions{
 		URL: srcUrl,
 		// Progress: os.Stdout,
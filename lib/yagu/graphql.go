@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/hofstadter-io/dotpath"
-	"github.com/parnurzeal/gorequest"
 )
 
 func SendRequest(host, queryTemplate string, vars interface{}) (interface{}, error) {
@@ -21,7 +20,7 @@ func SendRequest(host, queryTemplate string, vars interface{}) (interface{}, err
 		"variables": nil,
 	}
 
-	req := gorequest.New().Post(host).Send(send)
+	req := NewRequest().Post(host).Send(send)
 
 	resp, body, errs := req.EndBytes()
 
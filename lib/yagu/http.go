@@ -2,15 +2,16 @@ package yagu
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/parnurzeal/gorequest"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 func BuildRequest(url string) *gorequest.SuperAgent {
 
-	req := gorequest.New().Get(url)
+	req := NewRequest().Get(url)
 
 	return req
 }
@@ -23,10 +24,8 @@ func SimpleGet(url string) (string, error) {
 	resp, body, errs := req.End()
 
 	if len(errs) != 0 && !strings.Contains(errs[0].Error(), HTTP2_GOAWAY_CHECK) {
-		fmt.Println("errs:", errs)
-		fmt.Println("resp:", resp)
-		fmt.Println("body:", body)
-		return body, errs[0]
+		logs.Scope("http").Debug("request failed", "url", url, "errs", errs, "resp", resp, "body", body)
+		return body, CertHint(errs[0])
 	}
 
 	if len(errs) != 0 || resp.StatusCode >= 500 {
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cuelang.org/go/cue"
+)
+
+// Config is the hof auth config, read from $HOF_AUTH_CONFIG
+// or auth.cue in the hof user config dir, for example
+//
+//	hosts: {
+//	  "github.com": ssh: true
+//	  "git.example.com": {
+//	    ssh: true
+//	    keyFile: "~/.ssh/example_deploy_key"
+//	  }
+//	  "github.example.com": {
+//	    kind: "github"
+//	    baseURL: "https://github.example.com/api/v3/"
+//	  }
+//	  "git.home.lan": kind: "gitea"
+//	  "git.corp.example.com": helper: "!corp-sso token"
+//	}
+//	helper: "osxkeychain"
+//
+// Tokens saved by `hof auth login` are kept here,
+// never in the module cache. Credential helpers, see RunHelper,
+// are asked for tokens which are not.
+type Config struct {
+	Hosts map[string]*HostAuth `json:"hosts"`
+
+	// Helper is the credential helper for every host without its own
+	Helper string `json:"helper,omitempty"`
+}
+
+// HostAuth is how to authenticate with a single host.
+// With ssh set and no keyFile, the ssh-agent is used.
+type HostAuth struct {
+	SSH         bool   `json:"ssh,omitempty"`
+	User        string `json:"user,omitempty"`
+	KeyFile     string `json:"keyFile,omitempty"`
+	KeyPassword string `json:"keyPassword,omitempty"`
+
+	// Username and Token are used over https
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token,omitempty"`
+
+	// Kind marks a self-hosted instance as "github", "gitlab", "gitea", or "gogs",
+	// and BaseURL is its API endpoint when not the default for the kind
+	Kind    string `json:"kind,omitempty"`
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// Helper is the credential helper for the host
+	Helper string `json:"helper,omitempty"`
+}
+
+var (
+	config     *Config
+	configErr  error
+	configOnce sync.Once
+)
+
+// ConfigPath returns the location of the hof auth config.
+func ConfigPath() (string, error) {
+	if fn := os.Getenv("HOF_AUTH_CONFIG"); fn != "" {
+		return fn, nil
+	}
+	d, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "hof", "auth.cue"), nil
+}
+
+// LoadConfig reads the hof auth config once,
+// returning an empty config if there is none.
+func LoadConfig() (*Config, error) {
+	configOnce.Do(func() {
+		config, configErr = readConfig()
+	})
+	return config, configErr
+}
+
+func readConfig() (*Config, error) {
+	cfg := &Config{Hosts: map[string]*HostAuth{}}
+
+	fn, err := ConfigPath()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	var r cue.Runtime
+	inst, err := r.Compile(fn, data)
+	if err != nil {
+		return nil, fmt.Errorf("While reading auth config %s\n%w\n", fn, err)
+	}
+	err = inst.Value().Decode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("While decoding auth config %s\n%w\n", fn, err)
+	}
+	if cfg.Hosts == nil {
+		cfg.Hosts = map[string]*HostAuth{}
+	}
+
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to the hof auth config, readable only by the user.
+// The config is written as JSON, which is also valid CUE.
+func SaveConfig(cfg *Config) error {
+	fn, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(fn), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fn, append(data, '\n'), 0600)
+}
+
+// HostConfig returns the auth config for host, or nil when there is none.
+func HostConfig(host string) *HostAuth {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil {
+		return nil
+	}
+	return cfg.Hosts[host]
+}
+
+// UseSSH reports whether host is configured to be reached over ssh.
+func UseSSH(host string) bool {
+	ha := HostConfig(host)
+	return ha != nil && ha.SSH
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/url"
+	"os"
+)
+
+// Credential is a username and token, or password, for a host.
+type Credential struct {
+	Username string
+	Token    string
+}
+
+// Lookup finds the credential for host, trying in order
+//
+//   - the environment: $GITHUB_TOKEN for github.com and the instance at $GITHUB_BASE_URL,
+//     and $BITBUCKET_USERNAME with $BITBUCKET_APP_PASSWORD for bitbucket.org
+//   - the .netrc file, $NETRC or ~/.netrc
+//   - tokens saved in the hof auth config by `hof auth login`
+//   - the credential helper configured for host, see RunHelper
+//
+// It returns nil when there is none, and anonymous access should be used.
+func Lookup(host string) *Credential {
+	if c := lookupEnv(host); c != nil {
+		return c
+	}
+
+	var def *Credential
+	for _, l := range readNetrc() {
+		if l.password == "" {
+			continue
+		}
+		if l.machine == host {
+			return &Credential{Username: l.login, Token: l.password}
+		}
+		if l.machine == "default" && def == nil {
+			def = &Credential{Username: l.login, Token: l.password}
+		}
+	}
+
+	if ha := HostConfig(host); ha != nil && ha.Token != "" {
+		return &Credential{Username: ha.Username, Token: ha.Token}
+	}
+
+	if c := lookupHelper(host); c != nil {
+		return c
+	}
+
+	return def
+}
+
+func lookupEnv(host string) *Credential {
+	switch host {
+	case "github.com", enterpriseHost():
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			return &Credential{Token: token}
+		}
+	case "bitbucket.org":
+		user, pass := os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD")
+		if user != "" && pass != "" {
+			return &Credential{Username: user, Token: pass}
+		}
+	}
+	return nil
+}
+
+// enterpriseHost is the host of $GITHUB_BASE_URL, for GitHub Enterprise.
+func enterpriseHost() string {
+	u, err := url.Parse(os.Getenv("GITHUB_BASE_URL"))
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hofstadter-io/hof/lib/logs"
+)
+
+// Credential helpers speak the git credential protocol, key=value lines
+// ending with an empty line, on stdin and stdout, as in
+//
+//	protocol=https
+//	host=github.com
+//	username=me
+//	password=...
+//
+// A helper is set in the hof auth config, for every host or a single one
+//
+//	helper: "osxkeychain"
+//	hosts: "git.corp.example.com": helper: "!corp-sso token"
+//
+// A name runs the git helper git-credential-<name>, so osxkeychain, libsecret,
+// manager, and pass based helpers work as they do with git. A leading ! runs
+// a shell command, and an absolute path runs that program. The action,
+// get, store, or erase, is appended to the arguments.
+//
+// hof never saves what a helper returns.
+
+// The helper actions
+const (
+	HelperGet   = "get"
+	HelperStore = "store"
+	HelperErase = "erase"
+)
+
+// helperEnv is set for helpers hof runs, so hof used as a helper does not call itself
+const helperEnv = "HOF_AUTH_HELPER_RUNNING"
+
+// Attrs are the key=value lines of the credential helper protocol.
+type Attrs map[string]string
+
+// attrOrder is the order attributes are written in, others follow sorted
+var attrOrder = []string{"protocol", "host", "path", "username", "password"}
+
+// ReadAttrs reads attributes until an empty line or the end of r.
+func ReadAttrs(r io.Reader) (Attrs, error) {
+	attrs := Attrs{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("bad credential line %q, should be key=value", line)
+		}
+		attrs[line[:i]] = line[i+1:]
+	}
+	return attrs, scanner.Err()
+}
+
+// WriteAttrs writes the attributes which are set, ending with an empty line.
+func WriteAttrs(w io.Writer, attrs Attrs) error {
+	var b bytes.Buffer
+	seen := map[string]bool{}
+	for _, k := range attrOrder {
+		seen[k] = true
+		if v := attrs[k]; v != "" {
+			fmt.Fprintf(&b, "%s=%s\n", k, v)
+		}
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v := attrs[k]; v != "" {
+			fmt.Fprintf(&b, "%s=%s\n", k, v)
+		}
+	}
+	b.WriteString("\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// HelperFor returns the credential helper for host, its own or the default, if any.
+func HelperFor(host string) string {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil {
+		return ""
+	}
+	if ha := cfg.Hosts[host]; ha != nil && ha.Helper != "" {
+		return ha.Helper
+	}
+	return cfg.Helper
+}
+
+// RunHelper runs helper with action, writing attrs to its stdin.
+// For get, it returns the attributes the helper printed.
+// The helper's stderr is passed through, as it may prompt.
+func RunHelper(helper, action string, attrs Attrs) (Attrs, error) {
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(helper, "!"):
+		cmd = exec.Command("sh", "-c", helper[1:]+" "+action)
+	default:
+		args := strings.Fields(helper)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("empty credential helper")
+		}
+		if !filepath.IsAbs(args[0]) {
+			args[0] = "git-credential-" + args[0]
+		}
+		cmd = exec.Command(args[0], append(args[1:], action)...)
+	}
+
+	var in, out bytes.Buffer
+	err := WriteAttrs(&in, attrs)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = &in
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("While running credential helper %q %s\n%w\n", helper, action, err)
+	}
+	if action != HelperGet {
+		return nil, nil
+	}
+	return ReadAttrs(&out)
+}
+
+var (
+	helperMu    sync.Mutex
+	helperCache = map[string]*Credential{}
+)
+
+// lookupHelper asks the credential helper for host, once per run of hof.
+func lookupHelper(host string) *Credential {
+	if os.Getenv(helperEnv) != "" {
+		return nil
+	}
+	helper := HelperFor(host)
+	if helper == "" {
+		return nil
+	}
+
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	if c, ok := helperCache[host]; ok {
+		return c
+	}
+
+	var c *Credential
+	attrs, err := RunHelper(helper, HelperGet, Attrs{"protocol": "https", "host": host})
+	if err != nil {
+		logs.Scope("auth").Warn("credential helper failed", "host", host, "err", err)
+	} else if attrs["password"] != "" {
+		c = &Credential{Username: attrs["username"], Token: attrs["password"]}
+	}
+	helperCache[host] = c
+	return c
+}
+
+// forgetHelper drops what the helper returned for host, after it was stored or erased.
+func forgetHelper(host string) {
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	delete(helperCache, host)
+}
+
+// HelperGetRun looks up the credential for the host in attrs, as Lookup does,
+// for hof to be used as a credential helper by other tools.
+// Nothing is returned when there is none, which helpers take as not found.
+func HelperGetRun(attrs Attrs) (Attrs, error) {
+	host := attrs["host"]
+	if host == "" {
+		return nil, fmt.Errorf("missing host in credential request")
+	}
+	c := Lookup(host)
+	if c == nil {
+		return Attrs{}, nil
+	}
+	user := c.Username
+	if user == "" {
+		user = attrs["username"]
+	}
+	return Attrs{
+		"protocol": attrs["protocol"],
+		"host":     host,
+		"username": user,
+		"password": c.Token,
+	}, nil
+}
+
+// HelperPassRun passes store and erase to the configured helper for the host in attrs,
+// as hof does not keep what helpers return.
+func HelperPassRun(action string, attrs Attrs) error {
+	host := attrs["host"]
+	if host == "" {
+		return fmt.Errorf("missing host in credential request")
+	}
+	helper := HelperFor(host)
+	if helper == "" {
+		return fmt.Errorf("no credential helper configured for %s, hof does not keep credentials it did not log in with, see 'hof auth login'", host)
+	}
+	if os.Getenv(helperEnv) != "" {
+		return fmt.Errorf("hof is its own credential helper for %s", host)
+	}
+	forgetHelper(host)
+	_, err := RunHelper(helper, action, attrs)
+	return err
+}
@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+func TestAttrs(t *testing.T) {
+	in := "host=github.com\r\nprotocol=https\nwwwauth[]=Basic\n\nignored=after the end\n"
+	attrs, err := auth.ReadAttrs(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attrs) != 3 || attrs["host"] != "github.com" {
+		t.Errorf("unexpected attrs %v", attrs)
+	}
+
+	var b bytes.Buffer
+	err = auth.WriteAttrs(&b, attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "protocol=https\nhost=github.com\nwwwauth[]=Basic\n\n"
+	if b.String() != expect {
+		t.Errorf("expected:\n%q\ngot:\n%q", expect, b.String())
+	}
+
+	_, err = auth.ReadAttrs(strings.NewReader("no equals\n"))
+	if err == nil {
+		t.Errorf("expected an error for a line without =")
+	}
+}
+
+func TestHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a helper which answers get, and records the other actions
+	log := filepath.Join(dir, "log")
+	script := filepath.Join(dir, "helper")
+	err = ioutil.WriteFile(script, []byte(`#!/bin/sh
+case "$1" in
+  get) cat > /dev/null; printf 'username=bot\npassword=t0ken\n' ;;
+  *) echo "$1" >> `+log+`; cat >> `+log+` ;;
+esac
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, helper := range []string{script, "!" + script} {
+		attrs, err := auth.RunHelper(helper, auth.HelperGet, auth.Attrs{"protocol": "https", "host": "git.example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attrs["username"] != "bot" || attrs["password"] != "t0ken" {
+			t.Errorf("%s: unexpected attrs %v", helper, attrs)
+		}
+	}
+
+	_, err = auth.RunHelper(script, auth.HelperErase, auth.Attrs{"host": "git.example.com", "username": "bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "erase\nhost=git.example.com\nusername=bot\n\n" {
+		t.Errorf("unexpected erase %q", string(data))
+	}
+
+	_, err = auth.RunHelper("!exit 3", auth.HelperGet, auth.Attrs{})
+	if err == nil {
+		t.Errorf("expected an error from a failing helper")
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcLine is a machine entry in a .netrc file.
+type netrcLine struct {
+	machine  string
+	login    string
+	password string
+}
+
+// netrcPath returns $NETRC, or the .netrc (_netrc on Windows) in the home dir.
+func netrcPath() (string, error) {
+	if fn := os.Getenv("NETRC"); fn != "" {
+		return fn, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	base := ".netrc"
+	if runtime.GOOS == "windows" {
+		base = "_netrc"
+	}
+	return filepath.Join(home, base), nil
+}
+
+func readNetrc() []netrcLine {
+	fn, err := netrcPath()
+	if err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil
+	}
+	return parseNetrc(string(data))
+}
+
+// parseNetrc parses the machine, default, login, and password tokens
+// of a .netrc file, skipping macros.
+func parseNetrc(data string) []netrcLine {
+	var lines []netrcLine
+	inMacro := false
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			// macros end with an empty line
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		f := strings.Fields(line)
+		for i := 0; i < len(f); i++ {
+			switch f[i] {
+			case "machine":
+				if i+1 < len(f) {
+					lines = append(lines, netrcLine{machine: f[i+1]})
+					i++
+				}
+			case "default":
+				lines = append(lines, netrcLine{machine: "default"})
+			case "login", "password":
+				if i+1 < len(f) && len(lines) > 0 {
+					l := &lines[len(lines)-1]
+					if f[i] == "login" {
+						l.login = f[i+1]
+					} else {
+						l.password = f[i+1]
+					}
+					i++
+				}
+			case "macdef":
+				inMacro = true
+				i = len(f)
+			}
+		}
+	}
+	return lines
+}
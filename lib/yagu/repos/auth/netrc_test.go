@@ -0,0 +1,89 @@
+package auth_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+func TestNetrc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-netrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	netrc := filepath.Join(dir, "netrc")
+	err = ioutil.WriteFile(netrc, []byte(`machine git.example.com login bot password t0ken
+machine gitlab.example.com
+  login deploy
+  password gl-t0ken
+
+macdef init
+machine macro.example.com login nobody password nope
+
+machine nopass.example.com login someone
+machine   spaced.example.com	login	tab   password	sp4ce
+default login anon password d3fault
+machine after.example.com login late password l4te
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the netrc file is looked at
+	for key, value := range map[string]string{
+		"NETRC":              netrc,
+		"HOF_AUTH_CONFIG":    filepath.Join(dir, "auth.cue"),
+		"GITHUB_TOKEN":       "",
+		"GITHUB_BASE_URL":    "",
+		"BITBUCKET_USERNAME": "",
+	} {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		defer func(key string) {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	tests := []struct {
+		host     string
+		username string
+		token    string
+	}{
+		{host: "git.example.com", username: "bot", token: "t0ken"},
+		{host: "gitlab.example.com", username: "deploy", token: "gl-t0ken"},
+		{host: "spaced.example.com", username: "tab", token: "sp4ce"},
+		{host: "after.example.com", username: "late", token: "l4te"},
+		// in a macro, or without a password, the default is used
+		{host: "macro.example.com", username: "anon", token: "d3fault"},
+		{host: "nopass.example.com", username: "anon", token: "d3fault"},
+		{host: "other.example.com", username: "anon", token: "d3fault"},
+	}
+
+	for _, tt := range tests {
+		c := auth.Lookup(tt.host)
+		if c == nil {
+			t.Errorf("%s: expected a credential", tt.host)
+			continue
+		}
+		if c.Username != tt.username || c.Token != tt.token {
+			t.Errorf("%s: got %s:%s, want %s:%s", tt.host, c.Username, c.Token, tt.username, tt.token)
+		}
+	}
+
+	err = ioutil.WriteFile(netrc, []byte("machine git.example.com login bot password t0ken\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := auth.Lookup("other.example.com"); c != nil {
+		t.Errorf("expected no credential without a default, got %+v", c)
+	}
+}
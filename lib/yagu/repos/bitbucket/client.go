@@ -0,0 +1,20 @@
+package bitbucket
+
+import (
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// Client talks to the Bitbucket Cloud 2.0 API.
+type Client struct {
+	// Username and AppPassword authenticate requests, when both are set
+	Username    string
+	AppPassword string
+}
+
+func NewClient() (client *Client, err error) {
+	client = &Client{}
+	if c := auth.Lookup("bitbucket.org"); c != nil {
+		client.Username, client.AppPassword = c.Username, c.Token
+	}
+	return client, err
+}
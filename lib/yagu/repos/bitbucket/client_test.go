@@ -0,0 +1,136 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The auth config is read once, so every test of the client is in this one.
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-bitbucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 150 tags, the api links to the next page until the last
+	var tags []map[string]string
+	for i := 0; i < 150; i++ {
+		tags = append(tags, map[string]string{"name": fmt.Sprintf("v0.%d.0", i)})
+	}
+
+	var srv *httptest.Server
+	var user, pass string
+	var hasAuth bool
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, hasAuth = r.BasicAuth()
+		switch r.URL.EscapedPath() {
+		case "/2.0/repositories/team/repo":
+			w.Write([]byte(`{"full_name": "team/repo", "mainbranch": {"name": "trunk"}}`))
+		case "/2.0/repositories/team/repo/refs/tags":
+			if r.URL.Query().Get("pagelen") != "100" {
+				http.Error(w, "expected a pagelen", http.StatusBadRequest)
+				return
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			batch := tags[(page-1)*100:]
+			next := ""
+			if len(batch) > 100 {
+				batch = batch[:100]
+				next = fmt.Sprintf("%s/2.0/repositories/team/repo/refs/tags?pagelen=100&page=%d", srv.URL, page+1)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"values": batch, "next": next})
+		case "/team/repo/get/feature%2Fx.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("zip data"))
+		case "/2.0/repositories/broken/repo":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	oldAPI, oldSite := apiURL, siteURL
+	apiURL, siteURL = srv.URL+"/2.0", srv.URL
+	defer func() { apiURL, siteURL = oldAPI, oldSite }()
+
+	for key, value := range map[string]string{
+		"HOF_AUTH_CONFIG":        filepath.Join(dir, "auth.cue"),
+		"NETRC":                  filepath.Join(dir, "netrc"),
+		"BITBUCKET_USERNAME":     "hof",
+		"BITBUCKET_APP_PASSWORD": "app-passw0rd",
+	} {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		defer func(key string) {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	// the app password is sent with basic auth
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	R, err := GetRepo(client, "team", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if R.MainBranch.Name != "trunk" || !hasAuth || user != "hof" || pass != "app-passw0rd" {
+		t.Fatalf("got repo %+v with basic auth %q:%q, %v", R, user, pass, hasAuth)
+	}
+
+	// pages are followed by their next link
+	got, err := GetTags(client, "team", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 150 || got[0].Name != "v0.0.0" || got[149].Name != "v0.149.0" {
+		t.Fatalf("expected every tag once, got %d", len(got))
+	}
+
+	// refs are escaped, as branches may have slashes
+	data, ctype, err := FetchArchive(client, "team", "repo", "feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "zip data" || ctype != "application/zip" || !hasAuth {
+		t.Fatalf("got archive %q of type %q, with auth %v", data, ctype, hasAuth)
+	}
+
+	_, err = GetRepo(client, "team", "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "Bad Request: 404") {
+		t.Errorf("expected a missing repo to fail, got %v", err)
+	}
+	_, err = GetRepo(client, "broken", "repo")
+	if err == nil || !strings.HasPrefix(err.Error(), "Internal Error: 500") {
+		t.Errorf("expected a server error, got %v", err)
+	}
+
+	// a username without an app password is anonymous
+	os.Setenv("BITBUCKET_APP_PASSWORD", "")
+	client, err = NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetRepo(client, "team", "repo")
+	if err != nil || hasAuth {
+		t.Fatalf("expected no basic auth, got %q:%q, %v", user, pass, err)
+	}
+}
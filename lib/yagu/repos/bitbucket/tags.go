@@ -0,0 +1,101 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// The API and the site serving archives, vars so tests can use a local server.
+var (
+	apiURL  = "https://api.bitbucket.org/2.0"
+	siteURL = "https://bitbucket.org"
+)
+
+type Repository struct {
+	FullName   string `json:"full_name"`
+	MainBranch struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+type Tag struct {
+	Name   string `json:"name"`
+	Target struct {
+		Hash string `json:"hash"`
+	} `json:"target"`
+}
+
+func repoPath(owner, repo string) string {
+	return "/repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func GetRepo(client *Client, owner, repo string) (*Repository, error) {
+	var r Repository
+	err := client.getJSON(apiURL+repoPath(owner, repo), &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func GetTags(client *Client, owner, repo string) ([]*Tag, error) {
+	var tags []*Tag
+	next := apiURL + repoPath(owner, repo) + "/refs/tags?pagelen=100"
+	for next != "" {
+		var page struct {
+			Values []*Tag `json:"values"`
+			Next   string `json:"next"`
+		}
+		err := client.getJSON(next, &page)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Values...)
+		next = page.Next
+	}
+	return tags, nil
+}
+
+// FetchArchive downloads the archive of the repository at ref, a tag, branch, or commit,
+// returning it with its content type, see yagu.BillyLoadFromArchive.
+func FetchArchive(client *Client, owner, repo, ref string) ([]byte, string, error) {
+	u := fmt.Sprintf("%s/%s/%s/get/%s.zip", siteURL, url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+	return client.getType(u)
+}
+
+func (client *Client) getJSON(u string, out interface{}) error {
+	data, err := client.get(u)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (client *Client) get(u string) ([]byte, error) {
+	data, _, err := client.getType(u)
+	return data, err
+}
+
+// getType returns the body of a response along with its content type.
+func (client *Client) getType(u string) ([]byte, string, error) {
+	req := yagu.NewRequest().Get(u)
+	if client.Username != "" && client.AppPassword != "" {
+		req = req.SetBasicAuth(client.Username, client.AppPassword)
+	}
+	resp, data, errs := req.EndBytes()
+	if len(errs) != 0 {
+		return nil, "", yagu.CertHint(errs[0])
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, "", fmt.Errorf("Internal Error: %s %s", resp.Status, u)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("Bad Request: %s %s", resp.Status, u)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
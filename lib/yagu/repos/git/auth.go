@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// RemoteURL returns the url to reach srcUrl, a module path such as
+// github.com/owner/repo, with ssh when configured for its host
+// and https otherwise.
+func RemoteURL(srcUrl string) string {
+	host := srcUrl
+	path := ""
+	if i := strings.Index(srcUrl, "/"); i >= 0 {
+		host, path = srcUrl[:i], srcUrl[i+1:]
+	}
+	if ha := auth.HostConfig(host); ha != nil && ha.SSH {
+		return fmt.Sprintf("%s@%s:%s", sshUser(ha), host, path)
+	}
+	return "https://" + srcUrl
+}
+
+// urlHost returns the host of an https, ssh, or scp style url.
+func urlHost(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 {
+		// scp style, user@host:path
+		url = url[:i]
+	}
+	if i := strings.Index(url, "@"); i >= 0 {
+		url = url[i+1:]
+	}
+	if i := strings.IndexAny(url, "/:"); i >= 0 {
+		url = url[:i]
+	}
+	return url
+}
+
+func sshUser(ha *auth.HostAuth) string {
+	if ha.User != "" {
+		return ha.User
+	}
+	return "git"
+}
+
+// isSSH reports whether url is an ssh url, or scp style as in git@host:path.
+func isSSH(url string) bool {
+	if i := strings.Index(url, "://"); i >= 0 {
+		return url[:i] == "ssh"
+	}
+	i := strings.Index(url, ":")
+	return i > 0 && !strings.Contains(url[:i], "/") && filepath.VolumeName(url) == ""
+}
+
+// remoteAuth returns the credentials for url, if any.
+// Over ssh, the configured key file or the ssh-agent is used,
+// over https, the credential found for its host, and none for local repositories.
+func remoteAuth(url string) (transport.AuthMethod, error) {
+	host := urlHost(url)
+
+	if isSSH(url) {
+		ha := auth.HostConfig(host)
+		if ha == nil {
+			ha = &auth.HostAuth{}
+		}
+		if ha.KeyFile != "" {
+			return gitssh.NewPublicKeysFromFile(sshUser(ha), expandHome(ha.KeyFile), ha.KeyPassword)
+		}
+		return gitssh.NewSSHAgentAuth(sshUser(ha))
+	}
+
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, nil
+	}
+
+	if c := auth.Lookup(host); c != nil {
+		user := c.Username
+		if user == "" {
+			user = "hof" // yes, this can be anything except an empty string
+		}
+		return &http.BasicAuth{
+			Username: user,
+			Password: c.Token,
+		}, nil
+	}
+	return nil, nil
+}
+
+func expandHome(fn string) string {
+	if strings.HasPrefix(fn, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, fn[2:])
+		}
+	}
+	return fn
+}
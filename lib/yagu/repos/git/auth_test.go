@@ -0,0 +1,112 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// The auth config is read once, so it is set up before any test runs.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "hof-git-auth")
+	if err != nil {
+		panic(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	keyFile := filepath.Join(dir, "deploy_key")
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	config := filepath.Join(dir, "auth.cue")
+	err = ioutil.WriteFile(config, []byte(`hosts: {
+	"git.corp.lan": {
+		ssh:     true
+		user:    "deploy"
+		keyFile: "`+filepath.ToSlash(keyFile)+`"
+	}
+	"agent.lan": ssh: true
+	"tok.lan": {
+		username: "me"
+		token:    "t0ken"
+	}
+}
+`), 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("HOF_AUTH_CONFIG", config)
+	os.Setenv("NETRC", filepath.Join(dir, "netrc"))
+	os.Unsetenv("SSH_AUTH_SOCK")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestRemoteURL(t *testing.T) {
+	tests := map[string]string{
+		"git.corp.lan/team/repo": "deploy@git.corp.lan:team/repo",
+		"agent.lan/team/repo":    "git@agent.lan:team/repo",
+		"tok.lan/team/repo":      "https://tok.lan/team/repo",
+		"github.com/a/b":         "https://github.com/a/b",
+	}
+	for mod, expect := range tests {
+		if got := RemoteURL(mod); got != expect {
+			t.Errorf("%s: got %q, want %q", mod, got, expect)
+		}
+	}
+}
+
+func TestRemoteAuth(t *testing.T) {
+	// the configured key file
+	A, err := remoteAuth("deploy@git.corp.lan:team/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk, ok := A.(*gitssh.PublicKeys); !ok || pk.User != "deploy" {
+		t.Fatalf("expected the key file for deploy, got %#v", A)
+	}
+	A, err = remoteAuth("ssh://deploy@git.corp.lan/team/repo")
+	if _, ok := A.(*gitssh.PublicKeys); err != nil || !ok {
+		t.Fatalf("expected the key file for an ssh url, got %#v, %v", A, err)
+	}
+
+	// without a key file, the ssh-agent, which is not running here
+	_, err = remoteAuth("git@agent.lan:team/repo")
+	if err == nil || !strings.Contains(err.Error(), "SSH_AUTH_SOCK") {
+		t.Fatalf("expected the ssh-agent to be used, got %v", err)
+	}
+
+	// the token saved for the host, over https
+	A, err = remoteAuth("https://tok.lan/team/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ba, ok := A.(*http.BasicAuth); !ok || ba.Username != "me" || ba.Password != "t0ken" {
+		t.Fatalf("expected basic auth for me, got %#v", A)
+	}
+
+	// anonymous https, and local repositories, have none
+	for _, url := range []string{"https://anon.lan/team/repo", "file:///tmp/repo", "/tmp/repo", "./repo"} {
+		A, err := remoteAuth(url)
+		if err != nil || A != nil {
+			t.Errorf("%s: expected no auth, got %#v, %v", url, A, err)
+		}
+	}
+}
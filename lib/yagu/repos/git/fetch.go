@@ -2,7 +2,6 @@ package git
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/go-git/go-billy/v5/memfs"
@@ -10,28 +9,30 @@ import (
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/hofstadter-io/hof/lib/logs"
+	"github.com/hofstadter-io/hof/lib/yagu"
 )
 
 func NewRemote(srcUrl string) (*GitRepo, error) {
+	yagu.InstallGitTransport()
 
 	rc := &config.RemoteConfig{
 		Name: "origin",
 		URLs: []string{
-			"https://" + srcUrl,
+			RemoteURL(srcUrl),
 		},
 	}
 
 	lo := &gogit.ListOptions{}
 
-	if strings.Contains(srcUrl, "github.com") && os.Getenv("GITHUB_TOKEN") != "" {
-		lo.Auth = &http.BasicAuth{
-			Username: "github-token", // yes, this can be anything except an empty string
-			Password: os.Getenv("GITHUB_TOKEN"),
-		}
-		// co.URL = "git@" + strings.Replace(srcUrl, "/", ":", 1)
+	auth, err := remoteAuth(rc.URLs[0])
+	if err != nil {
+		return nil, err
 	}
+	lo.Auth = auth
 
 	// fmt.Println("URL:", rc.URLs[0])
 
@@ -59,22 +60,122 @@ func CloneLocalRepo(location string) (*GitRepo, error) {
 func CloneRepoRef(srcUrl string, ref *plumbing.Reference) (*GitRepo, error) {
 
 	co := &gogit.CloneOptions{
-		URL:           "https://" + srcUrl,
+		URL:           RemoteURL(srcUrl),
 		SingleBranch:  true,
 		ReferenceName: ref.Name(),
 	}
 
-	fmt.Println("cloning:", co.URL, ref)
+	logs.Scope("git").Info("cloning", "url", co.URL, "ref", ref)
 
-	if strings.Contains(srcUrl, "github.com") && os.Getenv("GITHUB_TOKEN") != "" {
-		co.Auth = &http.BasicAuth{
-			Username: "github-token", // yes, this can be anything except an empty string
-			Password: os.Getenv("GITHUB_TOKEN"),
-		}
+	auth, err := remoteAuth(co.URL)
+	if err != nil {
+		return nil, err
 	}
+	co.Auth = auth
 
 	// Clones the repository into the worktree (fs) and storer all the .git
 	// content into the storer
+	return cloneInto(co)
+}
+
+// CloneRef clones the repository at url, which may be an HTTPS or SSH url,
+// and checks out ref. The ref may be a tag, a branch, or a commit hash,
+// and the default branch is used when it is empty.
+func CloneRef(url, ref string) (*GitRepo, error) {
+	logs.Scope("git").Info("cloning", "url", url, "ref", ref)
+
+	auth, err := remoteAuth(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref == "" {
+		return cloneInto(&gogit.CloneOptions{
+			URL:          url,
+			Auth:         auth,
+			SingleBranch: true,
+			Depth:        1,
+		})
+	}
+
+	// try the ref as a tag, then as a branch
+	names := []plumbing.ReferenceName{
+		plumbing.NewTagReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+	}
+	for _, name := range names {
+		R, err := cloneInto(&gogit.CloneOptions{
+			URL:           url,
+			Auth:          auth,
+			ReferenceName: name,
+			SingleBranch:  true,
+			Depth:         1,
+		})
+		if err == nil {
+			return R, nil
+		}
+		if err != plumbing.ErrReferenceNotFound && !strings.Contains(err.Error(), "couldn't find remote ref") {
+			return nil, err
+		}
+	}
+
+	// otherwise it should be a commit, which needs the full history
+	R, err := cloneInto(&gogit.CloneOptions{
+		URL:        url,
+		Auth:       auth,
+		NoCheckout: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hash, err := resolveCommit(R.Repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("Did not find tag, branch, or commit %q in %s\n%w", ref, url, err)
+	}
+	wt, err := R.Repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	err = wt.Checkout(&gogit.CheckoutOptions{Hash: *hash, Force: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return R, nil
+}
+
+// resolveCommit resolves rev, which may be an abbreviated commit hash,
+// as in requirements such as module@abcdef1, which go-git does not resolve.
+func resolveCommit(r *gogit.Repository, rev string) (*plumbing.Hash, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err == nil {
+		return hash, nil
+	}
+
+	iter, ierr := r.CommitObjects()
+	if ierr != nil {
+		return nil, err
+	}
+	var found []plumbing.Hash
+	iter.ForEach(func(c *object.Commit) error {
+		if strings.HasPrefix(c.Hash.String(), rev) {
+			found = append(found, c.Hash)
+		}
+		return nil
+	})
+	switch len(found) {
+	case 0:
+		return nil, err
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, fmt.Errorf("commit %q is ambiguous", rev)
+	}
+}
+
+func cloneInto(co *gogit.CloneOptions) (*GitRepo, error) {
+	yagu.InstallGitTransport()
+
 	st := memory.NewStorage()
 	fs := memfs.New()
 	r, err := gogit.Clone(st, fs, co)
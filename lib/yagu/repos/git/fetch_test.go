@@ -0,0 +1,104 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCloneRef(t *testing.T) {
+	if _, err := exec.LookPath("git-upload-pack"); err != nil {
+		t.Skip("cloning file urls needs git-upload-pack")
+	}
+
+	dir, err := ioutil.TempDir("", "hof-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	R, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := R.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(content string) plumbing.Hash {
+		err := ioutil.WriteFile(filepath.Join(dir, "a.cue"), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("a.cue"); err != nil {
+			t.Fatal(err)
+		}
+		h, err := wt.Commit(content, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	// a commit only reachable from history, a tag, and a branch which has moved on
+	first := commit("first")
+	tagged := commit("tagged")
+	if _, err := R.CreateTag("v1.0.0", tagged, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := R.Storer.SetReference(plumbing.NewHashReference("refs/heads/release", tagged)); err != nil {
+		t.Fatal(err)
+	}
+	commit("tip")
+
+	url := "file://" + filepath.ToSlash(dir)
+	read := func(G *GitRepo) string {
+		f, err := G.FS.Open("a.cue")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := []struct {
+		ref    string
+		expect string
+	}{
+		{ref: "", expect: "tip"},
+		{ref: "v1.0.0", expect: "tagged"},
+		{ref: "release", expect: "tagged"},
+		{ref: "master", expect: "tip"},
+		{ref: first.String(), expect: "first"},
+		{ref: first.String()[:7], expect: "first"},
+	}
+	for _, tt := range tests {
+		G, err := CloneRef(url, tt.ref)
+		if err != nil {
+			t.Errorf("%q: %v", tt.ref, err)
+			continue
+		}
+		if got := read(G); got != tt.expect {
+			t.Errorf("%q: got %q, want %q", tt.ref, got, tt.expect)
+		}
+	}
+
+	_, err = CloneRef(url, "nope")
+	if err == nil || !strings.Contains(err.Error(), `Did not find tag, branch, or commit "nope"`) {
+		t.Fatalf("expected a missing ref to fail, got %v", err)
+	}
+}
@@ -6,12 +6,14 @@ import (
 
 	"github.com/go-git/go-git/v5/plumbing"
 	"golang.org/x/mod/semver"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 // given a git url, and a req (required) version
 // returns the minReference, allRefferences, and error
 func IndexGitRemote(url, req string) (*plumbing.Reference, []*plumbing.Reference, error) {
-	fmt.Println("indexing:", url, req)
+	logs.Scope("git").Debug("indexing", "url", url, "req", req)
 
 	if !semver.IsValid(req) {
 		return nil, nil, fmt.Errorf("Invalid SemVer v2 %q", req)
@@ -0,0 +1,54 @@
+package gitea
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// Client talks to the v1 API of a Gitea instance, which Gogs and Forgejo also serve.
+type Client struct {
+	// Host is the Gitea host, such as gitea.com
+	Host string
+
+	// Token is an access token, sent as 'Authorization: token ...'
+	Token string
+}
+
+// NewClient uses $GITEA_TOKEN, or the credential found for host.
+func NewClient(host string) (client *Client, err error) {
+	client = &Client{
+		Host:  host,
+		Token: os.Getenv("GITEA_TOKEN"),
+	}
+	if client.Token == "" {
+		if c := auth.Lookup(host); c != nil {
+			client.Token = c.Token
+		}
+	}
+	return client, err
+}
+
+// IsGitea reports whether host is gitea.com or codeberg.org, or a self-hosted instance listed
+// in the comma separated $GITEA_HOSTS or with kind "gitea" or "gogs" in the hof auth config.
+func IsGitea(host string) bool {
+	switch host {
+	case "gitea.com", "codeberg.org":
+		return true
+	}
+	for _, h := range strings.Split(os.Getenv("GITEA_HOSTS"), ",") {
+		if strings.TrimSpace(h) == host {
+			return true
+		}
+	}
+	ha := auth.HostConfig(host)
+	return ha != nil && (ha.Kind == "gitea" || ha.Kind == "gogs")
+}
+
+func (client *Client) apiURL(path string) string {
+	if ha := auth.HostConfig(client.Host); ha != nil && ha.BaseURL != "" {
+		return strings.TrimSuffix(ha.BaseURL, "/") + path
+	}
+	return "https://" + client.Host + "/api/v1" + path
+}
@@ -0,0 +1,172 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The auth config is read once, so every test of the client is in this one.
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-gitea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 120 tags, gogs returns all of them on every page
+	var tags []map[string]string
+	for i := 0; i < 120; i++ {
+		tags = append(tags, map[string]string{"name": fmt.Sprintf("v0.%d.0", i)})
+	}
+
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/api/v1/repos/team/repo":
+			w.Write([]byte(`{"full_name": "team/repo", "default_branch": "trunk"}`))
+		case "/api/v1/repos/team/repo/tags", "/api/v1/repos/gogs/repo/tags":
+			if r.URL.Query().Get("limit") != "50" {
+				http.Error(w, "expected a limit", http.StatusBadRequest)
+				return
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			batch := tags
+			if strings.HasPrefix(r.URL.Path, "/api/v1/repos/team/") {
+				batch = tags[(page-1)*50:]
+				if len(batch) > 50 {
+					batch = batch[:50]
+				}
+			}
+			json.NewEncoder(w).Encode(batch)
+		case "/api/v1/repos/team/repo/archive/feature/x.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("zip data"))
+		case "/api/v1/repos/broken/repo":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := filepath.Join(dir, "auth.cue")
+	err = ioutil.WriteFile(config, []byte(`hosts: {
+	"git.home.lan": {
+		kind:    "gitea"
+		baseURL: "`+srv.URL+`/api/v1/"
+		token:   "config-t0ken"
+	}
+	"gogs.home.lan": kind: "gogs"
+	"github.home.lan": kind: "github"
+}
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{
+		"HOF_AUTH_CONFIG": config,
+		"NETRC":           filepath.Join(dir, "netrc"),
+		"GITEA_HOSTS":     "a.example.com, b.example.com",
+		"GITEA_TOKEN":     "",
+	} {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		defer func(key string) {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	for host, expect := range map[string]bool{
+		"gitea.com":       true,
+		"codeberg.org":    true,
+		"b.example.com":   true,
+		"git.home.lan":    true,
+		"gogs.home.lan":   true,
+		"github.home.lan": false,
+		"github.com":      false,
+	} {
+		if IsGitea(host) != expect {
+			t.Errorf("%s: expected IsGitea to be %v", host, expect)
+		}
+	}
+
+	client, err := NewClient("git.home.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token != "config-t0ken" {
+		t.Fatalf("expected the token from the auth config, got %q", client.Token)
+	}
+	if got := client.apiURL("/repos/a/b"); got != srv.URL+"/api/v1/repos/a/b" {
+		t.Fatalf("expected the base url from the auth config, got %q", got)
+	}
+
+	R, err := GetRepo(client, "team", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if R.DefaultBranch != "trunk" || authHeader != "token config-t0ken" {
+		t.Fatalf("got repo %+v with authorization %q", R, authHeader)
+	}
+
+	for _, owner := range []string{"team", "gogs"} {
+		got, err := GetTags(client, owner, "repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 120 || got[0].Name != "v0.0.0" || got[119].Name != "v0.119.0" {
+			t.Errorf("%s: expected every tag once, got %d", owner, len(got))
+		}
+	}
+
+	// refs are escaped, as branches may have slashes
+	data, ctype, err := FetchArchive(client, "team", "repo", "feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "zip data" || ctype != "application/zip" {
+		t.Fatalf("got archive %q of type %q", data, ctype)
+	}
+
+	_, err = GetRepo(client, "team", "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "Bad Request: 404") {
+		t.Errorf("expected a missing repo to fail, got %v", err)
+	}
+	_, err = GetRepo(client, "broken", "repo")
+	if err == nil || !strings.HasPrefix(err.Error(), "Internal Error: 500") {
+		t.Errorf("expected a server error, got %v", err)
+	}
+
+	// $GITEA_TOKEN is used before the auth config
+	os.Setenv("GITEA_TOKEN", "env-t0ken")
+	client, err = NewClient("git.home.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetRepo(client, "team", "repo")
+	if err != nil || authHeader != "token env-t0ken" {
+		t.Fatalf("expected the token from the env, got %q, %v", authHeader, err)
+	}
+
+	// without a base url, the api is at the default path over https
+	client = &Client{Host: "gitea.com"}
+	if got := client.apiURL("/repos/a/b"); got != "https://gitea.com/api/v1/repos/a/b" {
+		t.Fatalf("got %q", got)
+	}
+	if got := repoPath("a b", "c"); got != "/repos/a%20b/c" {
+		t.Fatalf("expected the path escaped, got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+type Repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type Tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func GetRepo(client *Client, owner, repo string) (*Repository, error) {
+	var r Repository
+	err := client.getJSON(repoPath(owner, repo), &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func GetTags(client *Client, owner, repo string) ([]*Tag, error) {
+	var tags []*Tag
+	for page := 1; ; page++ {
+		var batch []*Tag
+		err := client.getJSON(fmt.Sprintf("%s/tags?limit=50&page=%d", repoPath(owner, repo), page), &batch)
+		if err != nil {
+			return nil, err
+		}
+		// Gogs ignores paging, returning every tag on each page
+		if page > 1 && len(batch) > 0 && batch[0].Name == tags[0].Name {
+			return tags, nil
+		}
+		tags = append(tags, batch...)
+		if len(batch) < 50 {
+			return tags, nil
+		}
+	}
+}
+
+// FetchArchive downloads the archive of the repository at ref, a tag, branch, or commit,
+// returning it with its content type, see yagu.BillyLoadFromArchive.
+func FetchArchive(client *Client, owner, repo, ref string) ([]byte, string, error) {
+	return client.getType(repoPath(owner, repo) + "/archive/" + url.PathEscape(ref) + ".zip")
+}
+
+func (client *Client) getJSON(path string, out interface{}) error {
+	data, err := client.get(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (client *Client) get(path string) ([]byte, error) {
+	data, _, err := client.getType(path)
+	return data, err
+}
+
+// getType returns the body of a response along with its content type.
+func (client *Client) getType(path string) ([]byte, string, error) {
+	req := yagu.NewRequest().Get(client.apiURL(path))
+	if client.Token != "" {
+		req = req.Set("Authorization", "token "+client.Token)
+	}
+	resp, data, errs := req.EndBytes()
+	if len(errs) != 0 {
+		return nil, "", yagu.CertHint(errs[0])
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, "", fmt.Errorf("Internal Error: %s %s", resp.Status, client.apiURL(path))
+	}
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("Bad Request: %s %s", resp.Status, client.apiURL(path))
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
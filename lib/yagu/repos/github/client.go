@@ -1,27 +1,103 @@
 package github
 
 import (
-	"context"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 
 	"golang.org/x/oauth2"
 
 	"github.com/google/go-github/v30/github"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
 )
 
-func NewClient() (client *github.Client, err error) {
-	ctx := context.Background()
+// NewClient returns a client for github.com, or the GitHub Enterprise instance at host.
+// Responses are cached on disk and revalidated, see Transport.
+func NewClient(host string) (client *github.Client, err error) {
+	if host == "" {
+		host = "github.com"
+	}
 
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+	// the token is set inside the cache, so responses are cached per token
+	tc := &http.Client{Transport: NewTransport(yagu.RoundTripper())}
+	if c := auth.Lookup(host); c != nil {
 		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
+			&oauth2.Token{AccessToken: c.Token},
 		)
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
+		tc.Transport = &oauth2.Transport{Source: ts, Base: tc.Transport}
+	}
 
-	} else {
-		client = github.NewClient(nil)
+	if host == "github.com" {
+		return github.NewClient(tc), nil
 	}
 
-	return client, err
+	// NewEnterpriseClient would add api/v3/ to a base url configured without it
+	client = github.NewClient(tc)
+	client.BaseURL, err = url.Parse(APIURL(host))
+	if err != nil {
+		return nil, err
+	}
+	client.UploadURL, err = url.Parse("https://" + host + "/api/uploads/")
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// IsGitHub reports whether host is github.com, or a GitHub Enterprise instance
+// set by $GITHUB_BASE_URL, listed in the comma separated $GITHUB_HOSTS,
+// or with kind "github" in the hof auth config.
+func IsGitHub(host string) bool {
+	if host == "github.com" {
+		return true
+	}
+	if u := enterpriseURL(); u != nil && u.Host == host {
+		return true
+	}
+	for _, h := range strings.Split(os.Getenv("GITHUB_HOSTS"), ",") {
+		if strings.TrimSpace(h) == host {
+			return true
+		}
+	}
+	ha := auth.HostConfig(host)
+	return ha != nil && ha.Kind == "github"
+}
+
+// APIURL returns the REST API endpoint for host, with a trailing slash.
+// Enterprise instances use $GITHUB_BASE_URL when it is for host,
+// then the baseURL in the hof auth config, then https://<host>/api/v3/.
+func APIURL(host string) string {
+	if host == "" || host == "github.com" {
+		return "https://api.github.com/"
+	}
+	if u := enterpriseURL(); u != nil && u.Host == host {
+		return u.String()
+	}
+	if ha := auth.HostConfig(host); ha != nil && ha.BaseURL != "" {
+		return strings.TrimSuffix(ha.BaseURL, "/") + "/"
+	}
+	return "https://" + host + "/api/v3/"
+}
+
+// enterpriseURL parses $GITHUB_BASE_URL, which may be the web or API address
+// of the instance, returning the API address.
+func enterpriseURL() *url.URL {
+	s := os.Getenv("GITHUB_BASE_URL")
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/api/v3/"
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u
 }
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testServer *httptest.Server
+
+// The auth config is read once, so it is set up before any test runs,
+// with an Enterprise instance served by testServer.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "hof-github")
+	if err != nil {
+		panic(err)
+	}
+
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/custom/api/repos/team/repo" || r.Header.Get("Authorization") != "Bearer ghe-t0ken" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"full_name": "team/repo", "default_branch": "trunk"}`))
+	}))
+
+	config := filepath.Join(dir, "auth.cue")
+	err = ioutil.WriteFile(config, []byte(`hosts: {
+	"ghe.config.lan": {
+		kind:    "github"
+		baseURL: "`+testServer.URL+`/custom/api"
+		token:   "ghe-t0ken"
+	}
+	"gitlab.config.lan": kind: "gitlab"
+}
+`), 0600)
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOF_AUTH_CONFIG", config)
+	os.Setenv("NETRC", filepath.Join(dir, "netrc"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	os.Unsetenv("GITHUB_TOKEN")
+
+	code := m.Run()
+	testServer.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestAPIURL(t *testing.T) {
+	tests := []struct {
+		base   string
+		host   string
+		expect string
+	}{
+		{host: "", expect: "https://api.github.com/"},
+		{host: "github.com", base: "https://ghe.env.lan", expect: "https://api.github.com/"},
+		// $GITHUB_BASE_URL may be the web or the api address
+		{host: "ghe.env.lan", base: "https://ghe.env.lan", expect: "https://ghe.env.lan/api/v3/"},
+		{host: "ghe.env.lan", base: "https://ghe.env.lan/", expect: "https://ghe.env.lan/api/v3/"},
+		{host: "ghe.env.lan", base: "https://ghe.env.lan/api/v3", expect: "https://ghe.env.lan/api/v3/"},
+		{host: "ghe.env.lan", base: "http://ghe.env.lan/gh/api", expect: "http://ghe.env.lan/gh/api/"},
+		// only for its own host
+		{host: "other.lan", base: "https://ghe.env.lan", expect: "https://other.lan/api/v3/"},
+		{host: "ghe.config.lan", expect: testServer.URL + "/custom/api/"},
+		{host: "ghe.config.lan", base: "not a url", expect: testServer.URL + "/custom/api/"},
+	}
+
+	old, had := os.LookupEnv("GITHUB_BASE_URL")
+	defer func() {
+		if had {
+			os.Setenv("GITHUB_BASE_URL", old)
+		} else {
+			os.Unsetenv("GITHUB_BASE_URL")
+		}
+	}()
+
+	for _, tt := range tests {
+		os.Setenv("GITHUB_BASE_URL", tt.base)
+		if got := APIURL(tt.host); got != tt.expect {
+			t.Errorf("%q with %q: got %q, want %q", tt.host, tt.base, got, tt.expect)
+		}
+	}
+
+	os.Setenv("GITHUB_BASE_URL", "https://ghe.env.lan")
+	os.Setenv("GITHUB_HOSTS", "a.lan, b.lan")
+	defer os.Unsetenv("GITHUB_HOSTS")
+	for host, expect := range map[string]bool{
+		"github.com":        true,
+		"ghe.env.lan":       true,
+		"b.lan":             true,
+		"ghe.config.lan":    true,
+		"gitlab.config.lan": false,
+		"other.lan":         false,
+	} {
+		if IsGitHub(host) != expect {
+			t.Errorf("%s: expected IsGitHub to be %v", host, expect)
+		}
+	}
+
+	// archives of enterprise repositories come from the api
+	if got := ArchiveURL("ghe.env.lan", "team", "repo", "v1.0.0"); got != "https://ghe.env.lan/api/v3/repos/team/repo/zipball/v1.0.0" {
+		t.Errorf("got archive %q", got)
+	}
+	if got := ArchiveURL("github.com", "team", "repo", "v1.0.0"); got != "https://github.com/team/repo/archive/v1.0.0.zip" {
+		t.Errorf("got archive %q", got)
+	}
+}
+
+func TestEnterpriseClient(t *testing.T) {
+	client, err := NewClient("ghe.config.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// used as configured, rather than with api/v3/ added
+	if got := client.BaseURL.String(); got != testServer.URL+"/custom/api/" {
+		t.Fatalf("got base url %q", got)
+	}
+	if got := client.UploadURL.String(); got != "https://ghe.config.lan/api/uploads/" {
+		t.Fatalf("got upload url %q", got)
+	}
+
+	// the token from the auth config is sent to the configured api
+	R, _, err := client.Repositories.Get(context.Background(), "team", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if R.GetDefaultBranch() != "trunk" {
+		t.Fatalf("got repo %+v", R)
+	}
+}
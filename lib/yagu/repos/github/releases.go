@@ -0,0 +1,26 @@
+package github
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v30/github"
+)
+
+// CreateRelease publishes a release for a tag which has already been pushed.
+func CreateRelease(client *github.Client, owner, repo, tag, name, body string) (*github.RepositoryRelease, error) {
+	r, _, err := client.Repositories.CreateRelease(context.Background(), owner, repo, &github.RepositoryRelease{
+		TagName: github.String(tag),
+		Name:    github.String(name),
+		Body:    github.String(body),
+	})
+	return r, err
+}
+
+// UploadReleaseAsset attaches a file to a release, named after its basename.
+func UploadReleaseAsset(client *github.Client, owner, repo string, id int64, file *os.File) (*github.ReleaseAsset, error) {
+	opts := &github.UploadOptions{Name: filepath.Base(file.Name())}
+	a, _, err := client.Repositories.UploadReleaseAsset(context.Background(), owner, repo, id, opts, file)
+	return a, err
+}
@@ -5,10 +5,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
 	"github.com/google/go-github/v30/github"
-	"github.com/parnurzeal/gorequest"
+
+	"github.com/hofstadter-io/hof/lib/logs"
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
 )
 
 func GetTagsSplit(client *github.Client, module string) ([]*github.RepositoryTag, error) {
@@ -52,14 +57,12 @@ func FetchTagZip(client *github.Client, tag *github.RepositoryTag) (*zip.Reader,
 
 	url := *tag.ZipballURL
 
-	req := gorequest.New().Get(url)
+	req := yagu.NewRequest().Get(url)
 	resp, data, errs := req.EndBytes()
 
 	check := "http2: server sent GOAWAY and closed the connection"
 	if len(errs) != 0 && !strings.Contains(errs[0].Error(), check) {
-		fmt.Println("errs:", errs)
-		fmt.Println("resp:", resp)
-		fmt.Println("body:", len(data))
+		logs.Scope("http").Debug("request failed", "url", url, "errs", errs, "resp", resp, "body", len(data))
 		return nil, errs[0]
 	}
 
@@ -77,18 +80,62 @@ func FetchTagZip(client *github.Client, tag *github.RepositoryTag) (*zip.Reader,
 	return zfile, err
 }
 
-func FetchBranchZip(client *github.Client, branch string) (*zip.Reader, error) {
+// GetCommit looks up a commit by its full or abbreviated hash with the commits API.
+func GetCommit(client *github.Client, owner, repo, sha string) (*github.RepositoryCommit, error) {
+	c, _, err := client.Repositories.GetCommit(context.Background(), owner, repo, sha)
+	return c, err
+}
+
+// ArchiveURL is the zipball of the repository at ref, a tag, branch, or full commit hash.
+// Enterprise archives come from the API, which accepts tokens for private repositories.
+func ArchiveURL(host, owner, repo, ref string) string {
+	if host == "" || host == "github.com" {
+		return fmt.Sprintf("https://github.com/%s/%s/archive/%s.zip", owner, repo, ref)
+	}
+	return fmt.Sprintf("%srepos/%s/%s/zipball/%s", APIURL(host), owner, repo, ref)
+}
+
+// downloadClient retries rate limited downloads, archives are too large to cache
+var downloadClient = &http.Client{Transport: &Transport{MaxRetries: 5}}
 
-	url := fmt.Sprintf("https://github.com/hofstadter-io/hof/archive/%s.zip", branch)
+// DownloadZip streams the archive at url to w without holding it in memory,
+// authenticating with the credential for host.
+func DownloadZip(host, url string, w io.Writer) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if c := auth.Lookup(host); c != nil {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	req := gorequest.New().Get(url)
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("Internal Error: %s %s", resp.Status, url)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("Bad Request: %s %s", resp.Status, url)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func FetchBranchZip(client *github.Client, owner, repo, branch string) (*zip.Reader, error) {
+
+	url := ArchiveURL(clientHost(client), owner, repo, branch)
+
+	req := yagu.NewRequest().Get(url)
 	resp, data, errs := req.EndBytes()
 
 	check := "http2: server sent GOAWAY and closed the connection"
 	if len(errs) != 0 && !strings.Contains(errs[0].Error(), check) {
-		fmt.Println("errs:", errs)
-		fmt.Println("resp:", resp)
-		fmt.Println("body:", len(data))
+		logs.Scope("http").Debug("request failed", "url", url, "errs", errs, "resp", resp, "body", len(data))
 		return nil, errs[0]
 	}
 
@@ -105,3 +152,12 @@ func FetchBranchZip(client *github.Client, branch string) (*zip.Reader, error) {
 
 	return zfile, err
 }
+
+// clientHost is the GitHub host a client talks to.
+func clientHost(client *github.Client) string {
+	host := client.BaseURL.Host
+	if host == "api.github.com" {
+		return "github.com"
+	}
+	return host
+}
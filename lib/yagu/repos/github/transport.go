@@ -0,0 +1,246 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+// maxWait is the longest a request waits for a rate limit to reset,
+// beyond it the rate limit response is returned to the caller.
+const maxWait = time.Minute
+
+// Transport caches GitHub API responses on disk and revalidates them with
+// conditional requests, which do not count against the rate limit.
+// It also backs off and retries when rate limited or when the server errors.
+type Transport struct {
+	Base http.RoundTripper
+
+	// Dir holds the cached responses, caching is off when it is empty
+	Dir string
+
+	// MaxRetries bounds the retries of a single request
+	MaxRetries int
+}
+
+// NewTransport returns a Transport caching in the hof user cache dir.
+func NewTransport(base http.RoundTripper) *Transport {
+	t := &Transport{Base: base, MaxRetries: 5}
+	if d, err := os.UserCacheDir(); err == nil {
+		t.Dir = filepath.Join(d, "hof", "github")
+	}
+	return t
+}
+
+type cachedResponse struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var key string
+	var cached *cachedResponse
+	if t.Dir != "" && req.Method == "GET" {
+		key = cacheKey(req)
+		cached = t.load(key)
+		if cached != nil {
+			req = req.Clone(req.Context())
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	resp, err := t.roundTripRetry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cached.response(req, resp.Header), nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if key != "" && resp.StatusCode == http.StatusOK && (etag != "" || lastModified != "") {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		t.store(key, &cachedResponse{
+			ETag:         etag,
+			LastModified: lastModified,
+			Header:       resp.Header,
+			Body:         body,
+		})
+	}
+
+	return resp, nil
+}
+
+func (t *Transport) roundTripRetry(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = yagu.RoundTripper()
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, retry := retryAfter(resp, backoff)
+		if !retry || attempt >= t.MaxRetries || wait > maxWait || !replayable(req, resp) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		fmt.Fprintf(os.Stderr, "GitHub responded %s, retrying in %s\n", resp.Status, wait.Round(time.Second))
+		time.Sleep(wait)
+		backoff *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// replayable reports whether req can be sent again after resp.
+// Rate limited requests were rejected unprocessed, but a write may have
+// taken effect before a server error, so only reads are retried then.
+func replayable(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode >= 500 && req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryAfter decides whether to retry a response, and how long to wait first.
+// Rate limits say when to retry, server errors back off exponentially.
+func retryAfter(resp *http.Response, backoff time.Duration) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		// secondary, or abuse, rate limits
+		if s := resp.Header.Get("Retry-After"); s != "" {
+			if n, err := strconv.Atoi(s); err == nil {
+				return time.Duration(n) * time.Second, true
+			}
+		}
+		// the primary rate limit
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if n, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				return time.Until(time.Unix(n, 0)) + time.Second, true
+			}
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return backoff, true
+		}
+		// otherwise a 403 is about permissions
+		return 0, false
+
+	case resp.StatusCode >= 500:
+		return backoff, true
+	}
+
+	return 0, false
+}
+
+// cacheKey identifies a request by its URL and credentials,
+// so responses are never shared between tokens.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Authorization")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Accept")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *Transport) load(key string) *cachedResponse {
+	data, err := ioutil.ReadFile(filepath.Join(t.Dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var c cachedResponse
+	if json.Unmarshal(data, &c) != nil {
+		return nil
+	}
+	return &c
+}
+
+// store saves a response, readable only by the user since it may be from a
+// private repository. Failing to cache is not an error for the request.
+func (t *Transport) store(key string, c *cachedResponse) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(t.Dir, 0700) != nil {
+		return
+	}
+	f, err := ioutil.TempFile(t.Dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if os.Rename(f.Name(), filepath.Join(t.Dir, key+".json")) != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// response rebuilds a cached response, with the current rate limit
+// headers from the 304 which revalidated it.
+func (c *cachedResponse) response(req *http.Request, fresh http.Header) *http.Response {
+	header := c.Header.Clone()
+	for k, v := range fresh {
+		if strings.HasPrefix(k, "X-Ratelimit-") {
+			header[k] = v
+		}
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
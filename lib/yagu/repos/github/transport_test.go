@@ -0,0 +1,243 @@
+package github
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testAPI answers with the responses queued for a path, then with the last one
+type testAPI struct {
+	sync.Mutex
+	responses map[string][]func(w http.ResponseWriter, r *http.Request)
+	requests  []*http.Request
+	bodies    []string
+}
+
+func (a *testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.Lock()
+	body, _ := ioutil.ReadAll(r.Body)
+	a.requests = append(a.requests, r)
+	a.bodies = append(a.bodies, string(body))
+	queue := a.responses[r.URL.Path]
+	if len(queue) == 0 {
+		a.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	f := queue[0]
+	if len(queue) > 1 {
+		a.responses[r.URL.Path] = queue[1:]
+	}
+	a.Unlock()
+	f(w, r)
+}
+
+func status(code int, header ...string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func newTestTransport(t *testing.T, api *testAPI) (*Transport, string) {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	dir, err := ioutil.TempDir("", "hof-github-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return &Transport{Base: http.DefaultTransport, Dir: dir, MaxRetries: 2}, srv.URL
+}
+
+func get(t *testing.T, T *Transport, url string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := T.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestTransportETag(t *testing.T) {
+	api := &testAPI{responses: map[string][]func(http.ResponseWriter, *http.Request){
+		"/repos/team/repo": {
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("X-RateLimit-Remaining", "59")
+				w.Write([]byte(`{"name": "repo"}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") != `"v1"` {
+					http.Error(w, "expected the etag", http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("X-RateLimit-Remaining", "58")
+				w.WriteHeader(http.StatusNotModified)
+			},
+		},
+	}}
+	T, url := newTestTransport(t, api)
+
+	resp, body := get(t, T, url+"/repos/team/repo")
+	if resp.StatusCode != http.StatusOK || body != `{"name": "repo"}` {
+		t.Fatalf("got %d %q", resp.StatusCode, body)
+	}
+
+	// revalidated, the cached body is served with the current rate limit
+	resp, body = get(t, T, url+"/repos/team/repo")
+	if resp.StatusCode != http.StatusOK || body != `{"name": "repo"}` {
+		t.Fatalf("expected the cached response, got %d %q", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "58" {
+		t.Fatalf("expected the fresh rate limit, got %q", got)
+	}
+	if got := resp.Header.Get("ETag"); got != `"v1"` {
+		t.Fatalf("expected the cached headers, got etag %q", got)
+	}
+
+	// responses are cached per token
+	req, _ := http.NewRequest("GET", url+"/repos/team/repo", nil)
+	req.Header.Set("Authorization", "token other")
+	resp, err := T.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := api.requests[len(api.requests)-1].Header.Get("If-None-Match"); got != "" {
+		t.Fatalf("expected no etag for another token, got %q", got)
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	for _, code := range []int{http.StatusForbidden, http.StatusTooManyRequests} {
+		api := &testAPI{responses: map[string][]func(http.ResponseWriter, *http.Request){
+			"/limited": {status(code, "Retry-After", "0"), status(http.StatusOK)},
+			// longer than a request waits, the response is returned
+			"/long": {status(code, "Retry-After", "3600")},
+			// a 403 without rate limit headers is about permissions
+			"/denied": {status(http.StatusForbidden), status(http.StatusOK)},
+		}}
+		T, url := newTestTransport(t, api)
+
+		if resp, _ := get(t, T, url+"/limited"); resp.StatusCode != http.StatusOK {
+			t.Errorf("%d: expected a retry, got %d", code, resp.StatusCode)
+		}
+		if resp, _ := get(t, T, url+"/long"); resp.StatusCode != code {
+			t.Errorf("%d: expected no retry, got %d", code, resp.StatusCode)
+		}
+		if resp, _ := get(t, T, url+"/denied"); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%d: expected a 403 returned, got %d", code, resp.StatusCode)
+		}
+		if len(api.requests) != 4 {
+			t.Errorf("%d: expected 4 requests, got %d", code, len(api.requests))
+		}
+	}
+
+	// retries are bounded
+	api := &testAPI{responses: map[string][]func(http.ResponseWriter, *http.Request){
+		"/limited": {status(http.StatusTooManyRequests, "Retry-After", "0")},
+	}}
+	T, url := newTestTransport(t, api)
+	if resp, _ := get(t, T, url+"/limited"); resp.StatusCode != http.StatusTooManyRequests || len(api.requests) != 3 {
+		t.Errorf("expected 3 tries, got %d with %d", len(api.requests), resp.StatusCode)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10)
+	tests := []struct {
+		code   int
+		header []string
+		wait   time.Duration
+		retry  bool
+	}{
+		{code: 200},
+		{code: 404},
+		{code: 403},
+		{code: 403, header: []string{"Retry-After", "5"}, wait: 5 * time.Second, retry: true},
+		{code: 429, header: []string{"Retry-After", "7"}, wait: 7 * time.Second, retry: true},
+		{code: 429, wait: time.Second, retry: true},
+		{code: 403, header: []string{"X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset}, wait: 31 * time.Second, retry: true},
+		{code: 403, header: []string{"X-RateLimit-Remaining", "10", "X-RateLimit-Reset", reset}},
+		{code: 502, wait: time.Second, retry: true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.code, Header: http.Header{}}
+		for i := 0; i+1 < len(tt.header); i += 2 {
+			resp.Header.Set(tt.header[i], tt.header[i+1])
+		}
+		// the reset is in whole seconds
+		wait, retry := retryAfter(resp, time.Second)
+		if d := wait - tt.wait; retry != tt.retry || d < -2*time.Second || d > 2*time.Second {
+			t.Errorf("%d %v: got %s, %v, want %s, %v", tt.code, tt.header, wait, retry, tt.wait, tt.retry)
+		}
+	}
+}
+
+func TestTransportReplayable(t *testing.T) {
+	api := &testAPI{responses: map[string][]func(http.ResponseWriter, *http.Request){
+		"/once":    {status(http.StatusTooManyRequests, "Retry-After", "0"), status(http.StatusCreated)},
+		"/limited": {status(http.StatusTooManyRequests, "Retry-After", "0"), status(http.StatusCreated)},
+		"/error":   {status(http.StatusBadGateway), status(http.StatusCreated)},
+	}}
+	T, url := newTestTransport(t, api)
+
+	post := func(path string, replay bool) *http.Response {
+		var req *http.Request
+		var err error
+		if replay {
+			req, err = http.NewRequest("POST", url+path, strings.NewReader(`{"tag": "v1"}`))
+		} else {
+			// a body which can only be read once
+			req, err = http.NewRequest("POST", url+path, ioutil.NopCloser(bytes.NewBufferString(`{"tag": "v1"}`)))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := T.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// a body which cannot be sent again is not retried
+	if resp := post("/once", false); resp.StatusCode != http.StatusTooManyRequests || len(api.requests) != 1 {
+		t.Fatalf("expected no retry, got %d after %d requests", resp.StatusCode, len(api.requests))
+	}
+
+	// a rate limited write was not processed, so it is sent again with its body
+	if resp := post("/limited", true); resp.StatusCode != http.StatusCreated || len(api.requests) != 3 {
+		t.Fatalf("expected a retry, got %d after %d requests", resp.StatusCode, len(api.requests))
+	}
+	if api.bodies[2] != `{"tag": "v1"}` {
+		t.Fatalf("expected the body sent again, got %q", api.bodies[2])
+	}
+
+	// a write may have taken effect before a server error
+	if resp := post("/error", true); resp.StatusCode != http.StatusBadGateway || len(api.requests) != 4 {
+		t.Fatalf("expected no retry, got %d after %d requests", resp.StatusCode, len(api.requests))
+	}
+}
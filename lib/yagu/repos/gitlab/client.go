@@ -0,0 +1,53 @@
+package gitlab
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// Client talks to the v4 API of gitlab.com or a self-hosted GitLab instance.
+type Client struct {
+	// Host is the GitLab host, such as gitlab.com
+	Host string
+
+	// Token is a personal, project, or CI job token, sent as PRIVATE-TOKEN
+	Token string
+}
+
+// NewClient uses $GITLAB_TOKEN, or the credential found for host.
+func NewClient(host string) (client *Client, err error) {
+	client = &Client{
+		Host:  host,
+		Token: os.Getenv("GITLAB_TOKEN"),
+	}
+	if client.Token == "" {
+		if c := auth.Lookup(host); c != nil {
+			client.Token = c.Token
+		}
+	}
+	return client, err
+}
+
+// IsGitLab reports whether host is gitlab.com, or a self-hosted instance
+// listed in the comma separated $GITLAB_HOSTS or with kind "gitlab" in the hof auth config.
+func IsGitLab(host string) bool {
+	if host == "gitlab.com" {
+		return true
+	}
+	for _, h := range strings.Split(os.Getenv("GITLAB_HOSTS"), ",") {
+		if strings.TrimSpace(h) == host {
+			return true
+		}
+	}
+	ha := auth.HostConfig(host)
+	return ha != nil && ha.Kind == "gitlab"
+}
+
+func (client *Client) apiURL(path string) string {
+	if ha := auth.HostConfig(client.Host); ha != nil && ha.BaseURL != "" {
+		return strings.TrimSuffix(ha.BaseURL, "/") + path
+	}
+	return "https://" + client.Host + "/api/v4" + path
+}
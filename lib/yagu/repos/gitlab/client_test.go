@@ -0,0 +1,182 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The auth config is read once, so every test of the client is in this one.
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-gitlab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 250 tags, over three pages of 100
+	var tags []map[string]string
+	for i := 0; i < 250; i++ {
+		tags = append(tags, map[string]string{"name": fmt.Sprintf("v0.%d.0", i)})
+	}
+
+	var tokenHeader string
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenHeader = r.Header.Get("PRIVATE-TOKEN")
+		// the project path is one escaped segment, as subgroups have slashes
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Frepo":
+			w.Write([]byte(`{"id": 7, "path_with_namespace": "group/sub/repo", "default_branch": "trunk"}`))
+		case "/api/v4/projects/group%2Fsub%2Frepo/repository/tags":
+			if r.URL.Query().Get("per_page") != "100" {
+				http.Error(w, "expected per_page", http.StatusBadRequest)
+				return
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			pages = append(pages, r.URL.Query().Get("page"))
+			batch := []map[string]string{}
+			if start := (page - 1) * 100; start < len(tags) {
+				batch = tags[start:]
+				if len(batch) > 100 {
+					batch = batch[:100]
+				}
+			}
+			json.NewEncoder(w).Encode(batch)
+		case "/api/v4/projects/group%2Fsub%2Frepo/repository/archive.zip":
+			if r.URL.Query().Get("sha") != "feature/x" {
+				http.Error(w, "expected the sha", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("zip data"))
+		case "/api/v4/projects/broken%2Frepo":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := filepath.Join(dir, "auth.cue")
+	err = ioutil.WriteFile(config, []byte(`hosts: {
+	"gitlab.home.lan": {
+		kind:    "gitlab"
+		baseURL: "`+srv.URL+`/api/v4/"
+		token:   "config-t0ken"
+	}
+	"github.home.lan": kind: "github"
+}
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{
+		"HOF_AUTH_CONFIG": config,
+		"NETRC":           filepath.Join(dir, "netrc"),
+		"GITLAB_HOSTS":    "a.example.com, b.example.com",
+		"GITLAB_TOKEN":    "",
+	} {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		defer func(key string) {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	for host, expect := range map[string]bool{
+		"gitlab.com":      true,
+		"b.example.com":   true,
+		"gitlab.home.lan": true,
+		"github.home.lan": false,
+		"github.com":      false,
+	} {
+		if IsGitLab(host) != expect {
+			t.Errorf("%s: expected IsGitLab to be %v", host, expect)
+		}
+	}
+
+	client, err := NewClient("gitlab.home.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token != "config-t0ken" {
+		t.Fatalf("expected the token from the auth config, got %q", client.Token)
+	}
+
+	P, err := GetProject(client, "group/sub", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if P.ID != 7 || P.DefaultBranch != "trunk" || tokenHeader != "config-t0ken" {
+		t.Fatalf("got project %+v with PRIVATE-TOKEN %q", P, tokenHeader)
+	}
+
+	// pages are read until one is short
+	got, err := GetTags(client, "group/sub", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 250 || got[0].Name != "v0.0.0" || got[249].Name != "v0.249.0" {
+		t.Fatalf("expected every tag once, got %d", len(got))
+	}
+	if strings.Join(pages, ",") != "1,2,3" {
+		t.Fatalf("expected three pages, got %v", pages)
+	}
+
+	// refs are escaped, as branches may have slashes
+	data, ctype, err := FetchArchive(client, "group/sub", "repo", "feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "zip data" || ctype != "application/zip" {
+		t.Fatalf("got archive %q of type %q", data, ctype)
+	}
+
+	_, err = GetProject(client, "group/sub", "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "Bad Request: 404") {
+		t.Errorf("expected a missing project to fail, got %v", err)
+	}
+	_, err = GetProject(client, "broken", "repo")
+	if err == nil || !strings.HasPrefix(err.Error(), "Internal Error: 500") {
+		t.Errorf("expected a server error, got %v", err)
+	}
+
+	// $GITLAB_TOKEN is used before the auth config
+	os.Setenv("GITLAB_TOKEN", "env-t0ken")
+	client, err = NewClient("gitlab.home.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetProject(client, "group/sub", "repo")
+	if err != nil || tokenHeader != "env-t0ken" {
+		t.Fatalf("expected the token from the env, got %q, %v", tokenHeader, err)
+	}
+
+	// anonymous requests have no token
+	client = &Client{Host: "gitlab.home.lan"}
+	_, err = GetProject(client, "group/sub", "repo")
+	if err != nil || tokenHeader != "" {
+		t.Fatalf("expected no token, got %q, %v", tokenHeader, err)
+	}
+
+	// without a base url, the api is at the default path over https
+	client = &Client{Host: "gitlab.com"}
+	if got := client.apiURL("/projects/a"); got != "https://gitlab.com/api/v4/projects/a" {
+		t.Fatalf("got %q", got)
+	}
+	if got := projectPath("a/b c", "d"); got != "/projects/a%2Fb%20c%2Fd" {
+		t.Fatalf("expected the path escaped, got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+)
+
+type Project struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+type Tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+// projectPath returns the API path of the project at owner/repo,
+// where owner may include subgroups, as in group/subgroup.
+func projectPath(owner, repo string) string {
+	return "/projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+func GetProject(client *Client, owner, repo string) (*Project, error) {
+	var p Project
+	err := client.getJSON(projectPath(owner, repo), &p)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func GetTags(client *Client, owner, repo string) ([]*Tag, error) {
+	var tags []*Tag
+	for page := 1; ; page++ {
+		var batch []*Tag
+		err := client.getJSON(fmt.Sprintf("%s/repository/tags?per_page=100&page=%d", projectPath(owner, repo), page), &batch)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, batch...)
+		if len(batch) < 100 {
+			return tags, nil
+		}
+	}
+}
+
+// FetchArchive downloads the archive of the repository at ref, a tag, branch, or commit,
+// returning it with its content type, see yagu.BillyLoadFromArchive.
+func FetchArchive(client *Client, owner, repo, ref string) ([]byte, string, error) {
+	path := projectPath(owner, repo) + "/repository/archive.zip?sha=" + url.QueryEscape(ref)
+	return client.getType(path)
+}
+
+func (client *Client) getJSON(path string, out interface{}) error {
+	data, err := client.get(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (client *Client) get(path string) ([]byte, error) {
+	data, _, err := client.getType(path)
+	return data, err
+}
+
+// getType returns the body of a response along with its content type.
+func (client *Client) getType(path string) ([]byte, string, error) {
+	req := yagu.NewRequest().Get(client.apiURL(path))
+	if client.Token != "" {
+		req = req.Set("PRIVATE-TOKEN", client.Token)
+	}
+	resp, data, errs := req.EndBytes()
+	if len(errs) != 0 {
+		return nil, "", yagu.CertHint(errs[0])
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, "", fmt.Errorf("Internal Error: %s %s", resp.Status, client.apiURL(path))
+	}
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("Bad Request: %s %s", resp.Status, client.apiURL(path))
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,378 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/yagu"
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+const MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+
+// Ref is an artifact in a registry, as in registry.example.com/team/mods:v1
+type Ref struct {
+	Host string
+	Repo string
+	// Tag is a tag, or a digest as in sha256:...
+	Tag string
+}
+
+// ParseRef parses host/repo[:tag] or host/repo@digest, the tag defaults to latest.
+func ParseRef(s string) (Ref, error) {
+	var r Ref
+	i := strings.Index(s, "/")
+	if i < 0 {
+		return r, fmt.Errorf("Invalid OCI reference %q, should be host/repo[:tag]", s)
+	}
+	r.Host, r.Repo = s[:i], s[i+1:]
+
+	if j := strings.Index(r.Repo, "@"); j >= 0 {
+		r.Repo, r.Tag = r.Repo[:j], r.Repo[j+1:]
+	} else if j := strings.LastIndex(r.Repo, ":"); j >= 0 {
+		r.Repo, r.Tag = r.Repo[:j], r.Repo[j+1:]
+	}
+	if r.Tag == "" {
+		r.Tag = "latest"
+	}
+	if r.Repo == "" {
+		return r, fmt.Errorf("Invalid OCI reference %q, should be host/repo[:tag]", s)
+	}
+	return r, nil
+}
+
+func (r Ref) String() string {
+	if strings.Contains(r.Tag, ":") {
+		return r.Host + "/" + r.Repo + "@" + r.Tag
+	}
+	return r.Host + "/" + r.Repo + ":" + r.Tag
+}
+
+// Descriptor points at a blob in a repository.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest, used for artifacts.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Client talks to a registry with the OCI distribution API.
+// Registries on localhost are reached over plain http, others over https.
+type Client struct {
+	Host  string
+	base  string
+	cred  *auth.Credential
+	token string
+}
+
+// NewClient returns a client for the registry at host, authenticating with the
+// credential found by auth.Lookup, or in the docker config, when there is one.
+func NewClient(host string) *Client {
+	scheme := "https"
+	if strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+		scheme = "http"
+	}
+
+	cred := auth.Lookup(host)
+	if cred == nil {
+		cred = dockerCredential(host)
+	}
+
+	return &Client{
+		Host: host,
+		base: scheme + "://" + host,
+		cred: cred,
+	}
+}
+
+// Authorize gets a token for the actions, as in "pull" or "pull,push", on repo.
+// Registries which do not challenge need no token.
+func (c *Client) Authorize(repo, actions string) error {
+	resp, err := yagu.HTTPClient().Get(c.base + "/v2/")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+		if c.cred == nil {
+			return fmt.Errorf("%s requires credentials, add them to the hof auth config or ~/.netrc", c.Host)
+		}
+		return nil
+	}
+
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("Unsupported authentication challenge from %s: %q", c.Host, challenge)
+	}
+
+	q := url.Values{}
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	q.Set("scope", "repository:"+repo+":"+actions)
+
+	req, err := http.NewRequest("GET", realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.cred != nil {
+		req.SetBasicAuth(c.cred.Username, c.cred.Token)
+	}
+
+	resp, err = yagu.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("While authenticating with %s: %s", c.Host, resp.Status)
+	}
+
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tok)
+	if err != nil {
+		return err
+	}
+	c.token = tok.Token
+	if c.token == "" {
+		c.token = tok.AccessToken
+	}
+	return nil
+}
+
+// parseChallenge parses the parameters of a Bearer challenge,
+// as in Bearer realm="https://auth.example.com/token",service="registry"
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	if i := strings.Index(s, " "); i >= 0 {
+		s = s[i+1:]
+	}
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		params[strings.TrimSpace(kv[:i])] = strings.Trim(strings.TrimSpace(kv[i+1:]), `"`)
+	}
+	return params
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.cred != nil {
+		req.SetBasicAuth(c.cred.Username, c.cred.Token)
+	}
+	return yagu.HTTPClient().Do(req)
+}
+
+// check turns an unexpected response into an error, closing its body.
+func check(resp *http.Response, what string, want ...int) error {
+	for _, code := range want {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("While %s: %s\n%s", what, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// BlobExists reports whether the repository already has the blob.
+func (c *Client) BlobExists(repo, digest string) (bool, error) {
+	req, err := http.NewRequest("HEAD", c.base+"/v2/"+repo+"/blobs/"+digest, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("While checking for blob %s: %s", digest, resp.Status)
+}
+
+// PushBlob uploads size bytes from r as the blob with digest, in a single request.
+func (c *Client) PushBlob(repo, digest string, size int64, r io.Reader) error {
+	req, err := http.NewRequest("POST", c.base+"/v2/"+repo+"/blobs/uploads/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "starting the upload of "+digest, http.StatusAccepted); err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	base, _ := url.Parse(c.base)
+	loc = base.ResolveReference(loc)
+	q := loc.Query()
+	q.Set("digest", digest)
+	loc.RawQuery = q.Encode()
+
+	req, err = http.NewRequest("PUT", loc.String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.do(req)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "uploading "+digest, http.StatusCreated); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// FetchBlob returns the content of a blob, which the caller must close.
+func (c *Client) FetchBlob(repo, digest string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", c.base+"/v2/"+repo+"/blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(resp, "fetching blob "+digest, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// PushManifest uploads the manifest under tag.
+func (c *Client) PushManifest(repo, tag string, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", c.base+"/v2/"+repo+"/manifests/"+tag, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", m.MediaType)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "pushing manifest "+tag, http.StatusCreated, http.StatusOK); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// FetchManifest returns the manifest for a tag or digest.
+func (c *Client) FetchManifest(repo, tag string) (*Manifest, error) {
+	req, err := http.NewRequest("GET", c.base+"/v2/"+repo+"/manifests/"+tag, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaTypeManifest)
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(resp, "fetching manifest "+tag, http.StatusOK); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var m Manifest
+	err = json.NewDecoder(resp.Body).Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// dockerCredential reads the credential for host from the docker config,
+// $DOCKER_CONFIG/config.json or ~/.docker/config.json, as written by docker login.
+// Credential helpers are not supported.
+func dockerCredential(host string) *auth.Credential {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return nil
+	}
+
+	a, ok := cfg.Auths[host]
+	if !ok {
+		a, ok = cfg.Auths["https://"+host]
+	}
+	if !ok || a.Auth == "" {
+		return nil
+	}
+	dec, err := base64.StdEncoding.DecodeString(a.Auth)
+	if err != nil {
+		return nil
+	}
+	i := strings.Index(string(dec), ":")
+	if i < 0 {
+		return nil
+	}
+	return &auth.Credential{Username: string(dec[:i]), Token: string(dec[i+1:])}
+}
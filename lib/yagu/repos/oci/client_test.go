@@ -0,0 +1,173 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hofstadter-io/hof/lib/yagu/repos/auth"
+)
+
+// setEnv sets the variables for the rest of a test.
+func setEnv(t *testing.T, vars map[string]string) {
+	for key, value := range vars {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		key := key
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref    string
+		expect Ref
+		str    string
+		err    bool
+	}{
+		{ref: "registry.example.com/team/mods:v1", expect: Ref{"registry.example.com", "team/mods", "v1"}, str: "registry.example.com/team/mods:v1"},
+		{ref: "localhost:5000/mods", expect: Ref{"localhost:5000", "mods", "latest"}, str: "localhost:5000/mods:latest"},
+		{ref: "localhost:5000/mods@sha256:abc", expect: Ref{"localhost:5000", "mods", "sha256:abc"}, str: "localhost:5000/mods@sha256:abc"},
+		{ref: "registry.example.com/mods:", expect: Ref{"registry.example.com", "mods", "latest"}, str: "registry.example.com/mods:latest"},
+		{ref: "mods", err: true},
+		{ref: "registry.example.com/", err: true},
+	}
+	for _, tt := range tests {
+		R, err := ParseRef(tt.ref)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.ref, R)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		if R != tt.expect || R.String() != tt.str {
+			t.Errorf("%s: got %+v %s, want %+v %s", tt.ref, R, R, tt.expect, tt.str)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a:pull"`)
+	if params["realm"] != "https://auth.example.com/token" || params["service"] != "registry.example.com" || params["scope"] != "repository:a:pull" {
+		t.Fatalf("got %v", params)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var gotScope, gotService, gotAuth string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/token":
+			user, pass, _ := r.BasicAuth()
+			if user != "bot" || pass != "t0ken" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			gotScope, gotService = r.URL.Query().Get("scope"), r.URL.Query().Get("service")
+			w.Write([]byte(`{"access_token": "bearer-t0ken"}`))
+		case "/v2/team/mods/manifests/v1":
+			gotAuth = r.Header.Get("Authorization")
+			if gotAuth != "Bearer bearer-t0ken" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(Manifest{SchemaVersion: 2, MediaType: MediaTypeManifest, ArtifactType: "test"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	client := &Client{Host: host, base: srv.URL, cred: &auth.Credential{Username: "bot", Token: "t0ken"}}
+	err := client.Authorize("team/mods", "pull,push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotScope != "repository:team/mods:pull,push" || gotService != "test-registry" {
+		t.Fatalf("got scope %q and service %q", gotScope, gotService)
+	}
+
+	M, err := client.FetchManifest("team/mods", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if M.ArtifactType != "test" {
+		t.Fatalf("got manifest %+v", M)
+	}
+
+	_, err = client.FetchManifest("team/mods", "v2")
+	if err == nil || !strings.Contains(err.Error(), "While fetching manifest v2: 404 Not Found") {
+		t.Fatalf("expected a missing tag to fail, got %v", err)
+	}
+
+	// the token endpoint refuses other credentials
+	client = &Client{Host: host, base: srv.URL, cred: &auth.Credential{Username: "bot", Token: "wrong"}}
+	err = client.Authorize("team/mods", "pull")
+	if err == nil || !strings.Contains(err.Error(), "While authenticating with "+host+": 403 Forbidden") {
+		t.Fatalf("expected a bad credential to fail, got %v", err)
+	}
+}
+
+func TestDockerCredential(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setEnv(t, map[string]string{"DOCKER_CONFIG": dir})
+
+	enc := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	config := `{"auths": {
+		"registry.example.com": {"auth": "` + enc("bot:t0ken") + `"},
+		"https://other.example.com": {"auth": "` + enc("me:pa:ss") + `"},
+		"broken.example.com": {"auth": "` + enc("nocolon") + `"}
+	}}`
+	err = ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host     string
+		username string
+		token    string
+	}{
+		{host: "registry.example.com", username: "bot", token: "t0ken"},
+		{host: "other.example.com", username: "me", token: "pa:ss"},
+		{host: "broken.example.com"},
+		{host: "missing.example.com"},
+	}
+	for _, tt := range tests {
+		cred := dockerCredential(tt.host)
+		if tt.username == "" {
+			if cred != nil {
+				t.Errorf("%s: expected no credential, got %+v", tt.host, cred)
+			}
+			continue
+		}
+		if cred == nil || cred.Username != tt.username || cred.Token != tt.token {
+			t.Errorf("%s: got %+v, want %s:%s", tt.host, cred, tt.username, tt.token)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package yagu
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// The PEM files of certificate authorities to trust besides the system ones,
+// separated like $PATH, for proxies which intercept TLS and hosts with a private CA.
+const CA_FILE_ENV = "HOF_CA_FILE"
+
+var (
+	transportOnce sync.Once
+	transport     *http.Transport
+	transportErr  error
+)
+
+// Transport returns the transport shared by hof's HTTP clients.
+// It goes through the proxies set by $HTTPS_PROXY, $HTTP_PROXY, and $NO_PROXY,
+// and trusts the certificates in $HOF_CA_FILE along with the system ones.
+func Transport() (*http.Transport, error) {
+	transportOnce.Do(func() {
+		var conf *tls.Config
+		conf, transportErr = TLSConfig()
+		if transportErr != nil {
+			return
+		}
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyFromEnvironment
+		transport.TLSClientConfig = conf
+	})
+	return transport, transportErr
+}
+
+// RoundTripper returns Transport, with certificate errors explaining how to trust a CA.
+// A bad $HOF_CA_FILE is returned from every request.
+func RoundTripper() http.RoundTripper {
+	t, err := Transport()
+	if err != nil {
+		return errorTransport{err}
+	}
+	return hintTransport{t}
+}
+
+// HTTPClient returns a client using RoundTripper.
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: RoundTripper()}
+}
+
+// NewRequest returns a gorequest agent using the proxies and certificates of Transport.
+// gorequest owns its transport, so the settings are copied onto it.
+func NewRequest() *gorequest.SuperAgent {
+	req := gorequest.New()
+	t, err := Transport()
+	if err != nil {
+		req.Errors = append(req.Errors, err)
+		return req
+	}
+	req.Transport.Proxy = t.Proxy
+	req.Transport.TLSClientConfig = t.TLSClientConfig
+	return req
+}
+
+// TLSConfig returns the TLS settings for hof's HTTP clients,
+// trusting the system certificates and those in $HOF_CA_FILE.
+func TLSConfig() (*tls.Config, error) {
+	env := os.Getenv(CA_FILE_ENV)
+	if env == "" {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// not available on windows before go1.18
+		pool = x509.NewCertPool()
+	}
+
+	for _, fn := range filepath.SplitList(env) {
+		if fn == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("While reading $%s\n%w\n", CA_FILE_ENV, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("No PEM certificates found in %s from $%s", fn, CA_FILE_ENV)
+		}
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// CertHint adds to certificate errors how to trust the CA which signed the certificate,
+// usually a proxy intercepting TLS. Other errors are returned as is.
+func CertHint(err error) error {
+	var ua x509.UnknownAuthorityError
+	if errors.As(err, &ua) {
+		return fmt.Errorf("%w\nIf a proxy intercepts TLS, or the host uses a private CA, set $%s to a PEM file of the CA certificate.", err, CA_FILE_ENV)
+	}
+	return err
+}
+
+type hintTransport struct {
+	base http.RoundTripper
+}
+
+func (t hintTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	return resp, CertHint(err)
+}
+
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
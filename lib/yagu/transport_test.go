@@ -0,0 +1,162 @@
+package yagu
+
+import (
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Transport and the proxy settings are read once,
+// so every test of the shared transport is in this one.
+func TestTransport(t *testing.T) {
+	if transport != nil || transportErr != nil {
+		t.Skip("the shared transport was set up by an earlier run")
+	}
+
+	dir, err := ioutil.TempDir("", "hof-transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	}))
+	defer tlsSrv.Close()
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+
+	ca := filepath.Join(dir, "ca.pem")
+	err = ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsSrv.Certificate().Raw}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{
+		CA_FILE_ENV:  string(os.PathListSeparator) + ca,
+		"HTTP_PROXY": proxy.URL,
+		"http_proxy": proxy.URL,
+	} {
+		old, had := os.LookupEnv(key)
+		os.Setenv(key, value)
+		defer func(key string) {
+			if had {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	get := func(url string) (string, error) {
+		resp, err := HTTPClient().Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		return string(data), err
+	}
+
+	// the test server's certificate is trusted from $HOF_CA_FILE
+	body, err := get(tlsSrv.URL)
+	if err != nil || body != "secure" {
+		t.Fatalf("expected the CA to be trusted, got %q, %v", body, err)
+	}
+
+	// requests go through $HTTP_PROXY
+	body, err = get("http://modules.example.com/a/b")
+	if err != nil || body != "proxied" {
+		t.Fatalf("expected the request to be proxied, got %q, %v", body, err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://modules.example.com/a/b" {
+		t.Fatalf("got proxied requests %q", proxied)
+	}
+
+	// gorequest agents get the same settings
+	_, body, errs := NewRequest().Get("http://gorequest.example.com/c").End()
+	if len(errs) != 0 || body != "proxied" {
+		t.Fatalf("expected the gorequest agent to be proxied, got %q, %v", body, errs)
+	}
+	_, body, errs = NewRequest().Get(tlsSrv.URL).End()
+	if len(errs) != 0 || body != "secure" {
+		t.Fatalf("expected the gorequest agent to trust the CA, got %q, %v", body, errs)
+	}
+
+	// without the CA, the error says how to trust it
+	resp, err := (&http.Client{Transport: hintTransport{http.DefaultTransport}}).Get(tlsSrv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected an unknown authority")
+	}
+	if !strings.Contains(err.Error(), "set $HOF_CA_FILE to a PEM file of the CA certificate") {
+		t.Fatalf("expected a hint about $HOF_CA_FILE, got %v", err)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hof-transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	notPEM := filepath.Join(dir, "not.pem")
+	err = ioutil.WriteFile(notPEM, []byte("not a certificate"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, had := os.LookupEnv(CA_FILE_ENV)
+	defer func() {
+		if had {
+			os.Setenv(CA_FILE_ENV, old)
+		} else {
+			os.Unsetenv(CA_FILE_ENV)
+		}
+	}()
+
+	tests := []struct {
+		env string
+		err string
+	}{
+		{env: ""},
+		{env: filepath.Join(dir, "missing.pem"), err: "While reading $HOF_CA_FILE"},
+		{env: notPEM, err: "No PEM certificates found in " + notPEM},
+	}
+	for _, tt := range tests {
+		os.Setenv(CA_FILE_ENV, tt.env)
+		conf, err := TLSConfig()
+		if tt.err == "" {
+			if err != nil || conf != nil {
+				t.Errorf("%q: expected the default config, got %v, %v", tt.env, conf, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: expected an error with %q, got %v", tt.env, tt.err, err)
+		}
+	}
+
+	// a bad $HOF_CA_FILE is returned from every request
+	et := errorTransport{errors.New("bad CA file")}
+	_, err = (&http.Client{Transport: et}).Get("http://example.com")
+	if err == nil || !strings.Contains(err.Error(), "bad CA file") {
+		t.Fatalf("expected the transport error, got %v", err)
+	}
+
+	if err := CertHint(errors.New("other")); err.Error() != "other" {
+		t.Fatalf("expected other errors as is, got %v", err)
+	}
+}
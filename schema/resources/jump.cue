@@ -1,9 +1,15 @@
 package resources
 
-#Jumps: #Jump1 | #Jump2 | #Jump3 | #Jump4 | #Jump5
+// #Jumps is the shape of .hof/jumps.cue, see 'hof jump'
+#Jumps: {
+	Jumps: [Name=string]: #Jump
+}
 
-#Jump1: [_=string]: string
-#Jump2: [_=string]: [_=string]: string
-#Jump3: [_=string]: [_=string]: [_=string]: string
-#Jump4: [_=string]: [_=string]: [_=string]: [_=string]: string
-#Jump5: [_=string]: [_=string]: [_=string]: [_=string]: [_=string]: string
+// A directory shortcut, a sequence of commands, or commands run in a directory.
+// Both may use the fields of the context in use, as in {{ .Project }}.
+#Jump: {
+	// relative to the workspace root, or the home directory for global jumps
+	Dir?: string
+	// run with bash, in order, stopping at the first which fails
+	Cmds?: [...string]
+}
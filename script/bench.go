@@ -0,0 +1,115 @@
+package script
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+// RunBenchmark runs the scripts like Run, but as sub-benchmarks of b,
+// executing each script b.N times. Besides the usual ns/op for the whole
+// script, the average time of each phase is reported as a metric named
+// after the phase heading, for example "build-the-cli-ns/op".
+func RunBenchmark(b *testing.B, p Params) {
+	p = paramDefaults(p)
+
+	glob := filepath.Join(p.Dir, p.Glob)
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(files) == 0 {
+		b.Fatal(fmt.Sprintf("no scripts found matching glob: %v", glob))
+	}
+	testTempDir, err := ioutil.TempDir(os.Getenv("GOTMPDIR"), "go-bench-script")
+	if err != nil {
+		b.Fatal(err)
+	}
+	testTempDir, err = filepath.EvalSymlinks(testTempDir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer removeAll(testTempDir)
+	suite, err := suiteSetup(testTempDir, p)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if p.SuiteTeardown != nil {
+		defer p.SuiteTeardown()
+	}
+
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		b.Run(name, func(b *testing.B) {
+			if ok, err := selectedByTags(file, p); err != nil {
+				b.Fatal(err)
+			} else if !ok {
+				b.Skip("not selected by tags")
+			}
+
+			var phases []string
+			totals := make(map[string]time.Duration)
+			for i := 0; i < b.N; i++ {
+				ts := newScript(bshim{b}, testTempDir, name, file, p)
+				ts.suite = suite
+				ts.run()
+
+				b.StopTimer()
+				for _, pt := range ts.phaseTimes {
+					if _, ok := totals[pt.phase]; !ok {
+						phases = append(phases, pt.phase)
+					}
+					totals[pt.phase] += pt.elapsed
+				}
+				removeAll(ts.workdir)
+				b.StartTimer()
+			}
+
+			for _, phase := range phases {
+				b.ReportMetric(float64(totals[phase].Nanoseconds())/float64(b.N), metricName(phase)+"-ns/op")
+			}
+		})
+	}
+}
+
+// bshim adapts a *testing.B to the T interface.
+type bshim struct {
+	*testing.B
+}
+
+func (b bshim) Parallel() {}
+
+func (b bshim) Run(name string, f func(T)) {
+	b.B.Run(name, func(b *testing.B) {
+		f(bshim{b})
+	})
+}
+
+func (b bshim) Verbose() bool {
+	return testing.Verbose()
+}
+
+// metricName turns a phase heading into a unit for testing.B.ReportMetric,
+// which may not contain whitespace.
+func metricName(phase string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, phase)
+	name = strings.Trim(name, "-")
+	for strings.Contains(name, "--") {
+		name = strings.Replace(name, "--", "-", -1)
+	}
+	if name == "" {
+		name = "phase"
+	}
+	return name
+}
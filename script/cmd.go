@@ -5,15 +5,20 @@
 package script
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hofstadter-io/dotpath"
+
+	"github.com/hofstadter-io/hof/lib/gotils/intern/os/execpath"
 	"github.com/hofstadter-io/hof/lib/gotils/intern/textutil"
 	"github.com/hofstadter-io/hof/lib/gotils/txtar"
 )
@@ -24,28 +29,52 @@ import (
 // NOTE: If you make changes here, update doc.go.
 //
 var scriptCmds = map[string]func(*Script, int, []string){
-	"call":    (*Script).cmdCall,
-	"cd":      (*Script).cmdCd,
-	"chmod":   (*Script).cmdChmod,
-	"cmp":     (*Script).cmdCmp,
-	"cmpenv":  (*Script).cmdCmpenv,
-	"cp":      (*Script).cmdCp,
-	"env":     (*Script).cmdEnv,
-	"exec":    (*Script).cmdExec,
-	"exists":  (*Script).cmdExists,
-	"grep":    (*Script).cmdGrep,
-	"http":    (*Script).cmdHttp,
-	"mkdir":   (*Script).cmdMkdir,
-	"rm":      (*Script).cmdRm,
-	"unquote": (*Script).cmdUnquote,
-	"skip":    (*Script).cmdSkip,
-	"stdin":   (*Script).cmdStdin,
-	"stderr":  (*Script).cmdStderr,
-	"stdout":  (*Script).cmdStdout,
-	"status":  (*Script).cmdStatus,
-	"stop":    (*Script).cmdStop,
-	"symlink": (*Script).cmdSymlink,
-	"wait":    (*Script).cmdWait,
+	"call":     (*Script).cmdCall,
+	"cd":       (*Script).cmdCd,
+	"chmod":    (*Script).cmdChmod,
+	"cmp":      (*Script).cmdCmp,
+	"cmpenv":   (*Script).cmdCmpenv,
+	"cp":       (*Script).cmdCp,
+	"cue":      (*Script).cmdCue,
+	"dotenv":   (*Script).cmdDotenv,
+	"env":      (*Script).cmdEnv,
+	"exec":     (*Script).cmdExec,
+	"exists":   (*Script).cmdExists,
+	"gcs":      (*Script).cmdGcs,
+	"gql":      (*Script).cmdGql,
+	"golden":   (*Script).cmdGolden,
+	"grep":     (*Script).cmdGrep,
+	"help":     (*Script).cmdHelp,
+	"http":     (*Script).cmdHttp,
+	"httpstat": (*Script).cmdHttpstat,
+	"kafka":    (*Script).cmdKafka,
+	"mask":     (*Script).cmdMask,
+	"mkdir":    (*Script).cmdMkdir,
+	"nats":     (*Script).cmdNats,
+	"port":     (*Script).cmdPort,
+	"pty":      (*Script).cmdPty,
+	"rm":       (*Script).cmdRm,
+	"s3":       (*Script).cmdS3,
+	"schema":   (*Script).cmdSchema,
+	"shell":    (*Script).cmdShell,
+	"signal":   (*Script).cmdSignal,
+	"unquote":  (*Script).cmdUnquote,
+	"untar":    (*Script).cmdUntar,
+	"unzip":    (*Script).cmdUnzip,
+	"skip":     (*Script).cmdSkip,
+	"stdin":    (*Script).cmdStdin,
+	"stderr":   (*Script).cmdStderr,
+	"stdout":   (*Script).cmdStdout,
+	"status":   (*Script).cmdStatus,
+	"stop":     (*Script).cmdStop,
+	"symlink":  (*Script).cmdSymlink,
+	"tail":     (*Script).cmdTail,
+	"tar":      (*Script).cmdTar,
+	"tomlcmp":  (*Script).cmdTomlcmp,
+	"wait":     (*Script).cmdWait,
+	"waitfor":  (*Script).cmdWaitfor,
+	"yamlcmp":  (*Script).cmdYamlcmp,
+	"zip":      (*Script).cmdZip,
 }
 
 
@@ -56,7 +85,9 @@ func (ts *Script) cmdHttp(neg int, args []string) {
 	}
 
 	var err error
-	ts.stdout, ts.stderr, ts.status, err = ts.http(args)
+	var status int
+	ts.stdout, ts.stderr, status, err = ts.http(args)
+	ts.setStatus(status)
 	if ts.stdout != "" {
 		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
 	}
@@ -70,8 +101,8 @@ func (ts *Script) cmdHttp(neg int, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test timed out while making http request")
-		} else if neg > 0 {
+			ts.Fatalf("script stopped while making http request: %v", ts.ctxt.Err())
+		} else if neg == 0 {
 			ts.Fatalf("unexpected http failure")
 		}
 	}
@@ -98,9 +129,9 @@ func (ts *Script) cmdCall(neg int, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test timed out while running command")
-		} else if neg > 0 {
-			ts.Fatalf("unexpected command failure")
+			ts.Fatalf("script stopped while running command: %v", ts.ctxt.Err())
+		} else if neg == 0 {
+			ts.Fatalf("unexpected call command failure")
 		}
 	}
 }
@@ -160,11 +191,12 @@ func (ts *Script) cmdCmp(neg int, args []string) {
 		// It would be strange to say "this file can have any content except this precise byte sequence".
 		ts.Fatalf("unsupported: !? cmp")
 	}
+	opts, args := cmpFlags(args)
 	if len(args) != 2 {
-		ts.Fatalf("usage: cmp file1 file2")
+		ts.Fatalf("usage: cmp [-trim-space] [-ignore-blank] file1 file2")
 	}
 
-	ts.doCmdCmp(args, false)
+	ts.doCmdCmp(args, false, opts)
 }
 
 // cmpenv compares two files with environment variable substitution.
@@ -172,15 +204,60 @@ func (ts *Script) cmdCmpenv(neg int, args []string) {
 	if neg != 0 {
 		ts.Fatalf("unsupported: !? cmpenv")
 	}
+	opts, args := cmpFlags(args)
 	if len(args) != 2 {
-		ts.Fatalf("usage: cmpenv file1 file2")
+		ts.Fatalf("usage: cmpenv [-trim-space] [-ignore-blank] file1 file2")
 	}
-	ts.doCmdCmp(args, true)
+	ts.doCmdCmp(args, true, opts)
+}
+
+// cmpOptions control how cmp and cmpenv normalize text before comparing.
+type cmpOptions struct {
+	trimSpace   bool // ignore leading and trailing space on each line
+	ignoreBlank bool // ignore blank lines
 }
 
-func (ts *Script) doCmdCmp(args []string, env bool) {
+// cmpFlags parses the leading flags of cmp and cmpenv.
+func cmpFlags(args []string) (cmpOptions, []string) {
+	var opts cmpOptions
+	for len(args) > 0 {
+		switch args[0] {
+		case "-trim-space":
+			opts.trimSpace = true
+		case "-ignore-blank":
+			opts.ignoreBlank = true
+		default:
+			return opts, args
+		}
+		args = args[1:]
+	}
+	return opts, args
+}
+
+// normalize applies the options to text.
+func (opts cmpOptions) normalize(text string) string {
+	if !opts.trimSpace && !opts.ignoreBlank {
+		return text
+	}
+	var buf strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if opts.trimSpace {
+			line = strings.TrimSpace(line) + "\n"
+		}
+		if opts.ignoreBlank && strings.TrimSpace(line) == "" {
+			continue
+		}
+		buf.WriteString(line)
+	}
+	return buf.String()
+}
+
+func (ts *Script) doCmdCmp(args []string, env bool, opts cmpOptions) {
 	name1, name2 := args[0], args[1]
-	text1 := ts.ReadFile(name1)
+	actual := ts.ReadFile(name1)
 
 	absName2 := ts.MkAbs(name2)
 	data, err := ioutil.ReadFile(absName2)
@@ -189,19 +266,24 @@ func (ts *Script) doCmdCmp(args []string, env bool) {
 	if env {
 		text2 = ts.expand(text2)
 	}
+	text1, text2 := opts.normalize(actual), opts.normalize(text2)
 	if text1 == text2 {
 		return
 	}
-	if ts.params.UpdateScripts && !env && (args[0] == "stdout" || args[0] == "stderr") {
+	if ts.params.UpdateScripts {
 		if scriptFile, ok := ts.scriptFiles[absName2]; ok {
-			ts.scriptUpdates[scriptFile] = text1
+			if env {
+				// Best effort at undoing the expansion.
+				actual = strings.Replace(actual, ts.workdir, "$WORK", -1)
+			}
+			ts.scriptUpdates[scriptFile] = actual
 			return
 		}
 		// The file being compared against isn't in the txtar archive, so don't
 		// update the script.
 	}
 
-	ts.Logf("[diff -%s +%s]\n%s\n", name1, name2, textutil.Diff(text1, text2))
+	ts.Logf("[diff]\n%s", textutil.UnifiedDiff(name1, name2, text1, text2, 3))
 	ts.Fatalf("%s and %s differ", name1, name2)
 }
 
@@ -252,6 +334,19 @@ func (ts *Script) cmdCp(neg int, args []string) {
 	}
 }
 
+// dotenv loads KEY=VALUE pairs from files into the environment.
+func (ts *Script) cmdDotenv(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? dotenv")
+	}
+	if len(args) < 1 {
+		ts.Fatalf("usage: dotenv file...")
+	}
+	for _, file := range args {
+		ts.loadEnvFile(file)
+	}
+}
+
 // env displays or adds to the environment.
 func (ts *Script) cmdEnv(neg int, args []string) {
 	if neg != 0 {
@@ -268,6 +363,21 @@ func (ts *Script) cmdEnv(neg int, args []string) {
 		}
 		return
 	}
+	switch args[0] {
+	case "push":
+		ts.envStack = append(ts.envStack, ts.saveEnv())
+		args = args[1:]
+	case "pop":
+		if len(args) != 1 {
+			ts.Fatalf("usage: env pop")
+		}
+		if len(ts.envStack) == 0 {
+			ts.Fatalf("env pop: no environment pushed")
+		}
+		ts.restoreEnv(ts.envStack[len(ts.envStack)-1])
+		ts.envStack = ts.envStack[:len(ts.envStack)-1]
+		return
+	}
 	for _, env := range args {
 		i := strings.Index(env, "=")
 		if i < 0 {
@@ -282,23 +392,39 @@ func (ts *Script) cmdEnv(neg int, args []string) {
 // exec runs the given command.
 func (ts *Script) cmdExec(neg int, args []string) {
 
-	if len(args) < 1 || (len(args) == 1 && args[0] == "&") {
-		ts.Fatalf("usage: exec program [args...] [&]")
+	if len(args) < 1 || (len(args) == 1 && strings.HasPrefix(args[0], "&")) {
+		ts.Fatalf("usage: exec [&name] program [args...] [&|&name]")
+	}
+
+	// A leading or trailing &name runs the command in the background under that name.
+	name := ""
+	if last := args[len(args)-1]; len(last) > 1 && last[0] == '&' {
+		name = last[1:]
+		args = append(args[:len(args)-1], "&")
+	} else if len(args[0]) > 1 && args[0][0] == '&' {
+		name = args[0][1:]
+		args = append(args[1:], "&")
+	}
+	if name != "" && ts.findBackground(name) != nil {
+		ts.Fatalf("background command %q already exists", name)
 	}
 
 	var err error
 	if len(args) > 0 && args[len(args)-1] == "&" {
-		var cmd *exec.Cmd
-		cmd, err = ts.execBackground(args[0], args[1:len(args)-1]...)
+		var bg *backgroundCmd
+		bg, err = ts.execBackground(name, args[0], args[1:len(args)-1]...)
 		if err == nil {
 			wait := make(chan struct{})
 			go func() {
-				werr := ctxWait(ts.ctxt, cmd)
+				werr := ctxWait(ts.ctxt, bg.cmd)
+				bg.closeLogs()
 				close(wait)
-				ts.status = cmd.ProcessState.ExitCode()
+				ts.status = bg.cmd.ProcessState.ExitCode()
 				err = werr
 			}()
-			ts.background = append(ts.background, backgroundCmd{cmd, wait, neg})
+			bg.wait = wait
+			bg.neg = neg
+			ts.background = append(ts.background, *bg)
 		}
 		ts.stdout, ts.stderr = "", ""
 	} else {
@@ -317,7 +443,7 @@ func (ts *Script) cmdExec(neg int, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test timed out while running command")
+			ts.Fatalf("script stopped while running command: %v", ts.ctxt.Err())
 		} else if neg == 0 {
 			ts.Fatalf("unexpected exec command failure")
 		}
@@ -354,6 +480,19 @@ func (ts *Script) cmdExists(neg int, args []string) {
 	}
 }
 
+// mask redacts secret values from the test log.
+func (ts *Script) cmdMask(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? mask")
+	}
+	if len(args) < 1 {
+		ts.Fatalf("usage: mask value...")
+	}
+	for _, arg := range args {
+		ts.Mask(arg)
+	}
+}
+
 // mkdir creates directories.
 func (ts *Script) cmdMkdir(neg int, args []string) {
 	if neg != 0 {
@@ -367,6 +506,36 @@ func (ts *Script) cmdMkdir(neg int, args []string) {
 	}
 }
 
+// port allocates free TCP ports and stores them in the environment.
+func (ts *Script) cmdPort(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? port")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: port VAR [count]")
+	}
+
+	count := 1
+	if len(args) == 2 {
+		var err error
+		count, err = strconv.Atoi(args[1])
+		if err != nil || count < 1 {
+			ts.Fatalf("bad port count %q", args[1])
+		}
+	}
+
+	ports, err := freePorts(count)
+	ts.Check(err)
+
+	ts.Setenv(args[0], strconv.Itoa(ports[0]))
+	if count > 1 {
+		for i, p := range ports {
+			ts.Setenv(fmt.Sprintf("%s_%d", args[0], i+1), strconv.Itoa(p))
+		}
+	}
+	ts.Logf("%s=%v\n", args[0], ports)
+}
+
 // unquote unquotes files.
 func (ts *Script) cmdUnquote(neg int, args []string) {
 	if neg != 0 {
@@ -398,15 +567,78 @@ func (ts *Script) cmdRm(neg int, args []string) {
 	}
 }
 
+// shell runs a command line with the system shell, sh on Unix and cmd on Windows,
+// or with PowerShell when given -powershell.
+func (ts *Script) cmdShell(neg int, args []string) {
+	powershell := len(args) > 0 && args[0] == "-powershell"
+	if powershell {
+		args = args[1:]
+	}
+
+	// Keep the background markers for exec.
+	var name, bg string
+	if len(args) > 1 && len(args[0]) > 1 && args[0][0] == '&' {
+		name, args = args[0], args[1:]
+	}
+	if n := len(args); n > 1 && strings.HasPrefix(args[n-1], "&") {
+		bg, args = args[n-1], args[:n-1]
+	}
+	if len(args) < 1 {
+		ts.Fatalf("usage: shell [-powershell] [&name] command... [&|&name]")
+	}
+
+	line := strings.Join(args, " ")
+	var shell []string
+	switch {
+	case powershell:
+		prog := "powershell"
+		if _, err := execpath.Look("pwsh", ts.Getenv); err == nil {
+			prog = "pwsh"
+		}
+		shell = []string{prog, "-NoProfile", "-NonInteractive", "-Command", line}
+	case runtime.GOOS == "windows":
+		shell = []string{"cmd", "/c", line}
+	default:
+		shell = []string{"sh", "-c", line}
+	}
+	if name != "" {
+		shell = append([]string{name}, shell...)
+	}
+	if bg != "" {
+		shell = append(shell, bg)
+	}
+	ts.cmdExec(neg, shell)
+}
+
+// signal sends a signal to a named background command.
+func (ts *Script) cmdSignal(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? signal")
+	}
+	if len(args) != 2 {
+		ts.Fatalf("usage: signal name SIGHUP|SIGINT|SIGTERM|SIGQUIT|SIGKILL")
+	}
+
+	bg := ts.findBackground(args[0])
+	if bg == nil {
+		ts.Fatalf("unknown background command %q", args[0])
+	}
+
+	sig, ok := scriptSignals[strings.ToUpper(args[1])]
+	if !ok {
+		ts.Fatalf("unknown signal %q", args[1])
+	}
+
+	ts.Check(bg.cmd.Process.Signal(sig))
+}
+
 // skip marks the test skipped.
 func (ts *Script) cmdSkip(neg int, args []string) {
-	if neg != 0{
+	if neg != 0 {
 		ts.Fatalf("unsupported: !? skip")
 	}
 
-	if len(args) > 1 {
-		ts.Fatalf("usage: skip [msg]")
-	}
+	skip := ts.skipArgs("skip", args)
 
 	// Before we mark the test as skipped, shut down any background processes and
 	// make sure they have returned the correct status.
@@ -415,8 +647,9 @@ func (ts *Script) cmdSkip(neg int, args []string) {
 	}
 	ts.cmdWait(0, nil)
 
-	if len(args) == 1 {
-		ts.t.Skip(args[0])
+	ts.skipped = &skip
+	if len(args) > 0 {
+		ts.t.Skip(skip.String())
 	}
 	ts.t.Skip()
 }
@@ -455,20 +688,93 @@ func (ts *Script) cmdStatus(neg int, args []string) {
 	// Check arg
 	code, err := strconv.Atoi(args[0])
 	if err != nil {
-		ts.Fatalf("error: %v\nusage: stdin <int>", err)
+		ts.Fatalf("error: %v\nusage: status <int>", err)
 	}
 
-	// wanted different but got samd
+	// wanted different but got same
 	if neg > 0 && ts.status == code {
 		ts.Fatalf("unexpected status match: %d", code)
 	}
 
 	if neg == 0 && ts.status != code {
-		ts.Fatalf("unexpected status mismatch:  wated: %d  got %d", code, ts.status)
+		ts.Fatalf("unexpected status mismatch: wanted %d, got %d", code, ts.status)
 	}
 
 }
 
+// gql checks the data or errors of the GraphQL response from the last http call.
+func (ts *Script) cmdGql(neg int, args []string) {
+	if neg < 0 {
+		ts.Fatalf("unsupported: ? gql")
+	}
+	if len(args) < 1 {
+		ts.Fatalf("usage: gql data path [pattern] | gql errors [pattern]")
+	}
+
+	resp := ts.gqlResponse()
+
+	var (
+		name    = args[0]
+		val     interface{}
+		pattern string
+	)
+	switch name {
+	case "data":
+		if len(args) < 2 || len(args) > 3 {
+			ts.Fatalf("usage: gql data path [pattern]")
+		}
+		name = "data." + args[1]
+		if v, err := dotpath.Get(args[1], resp["data"], true); err == nil {
+			val = v
+		}
+		if len(args) == 3 {
+			pattern = args[2]
+		}
+
+	case "errors":
+		if len(args) > 2 {
+			ts.Fatalf("usage: gql errors [pattern]")
+		}
+		if errs, ok := resp["errors"].([]interface{}); ok && len(errs) > 0 {
+			val = errs
+		}
+		if len(args) == 2 {
+			pattern = args[1]
+		}
+
+	default:
+		ts.Fatalf("usage: gql data path [pattern] | gql errors [pattern]")
+	}
+
+	found := val != nil
+	text := ""
+	if found {
+		if s, ok := val.(string); ok {
+			text = s
+		} else {
+			b, err := json.Marshal(val)
+			ts.Check(err)
+			text = string(b)
+		}
+	}
+
+	if found && pattern != "" {
+		re, err := regexp.Compile(`(?m)` + pattern)
+		ts.Check(err)
+		found = re.MatchString(text)
+	}
+
+	if neg > 0 && found {
+		ts.Fatalf("unexpected gql %s found: %s", name, text)
+	}
+	if neg == 0 && !found {
+		if pattern != "" && val != nil {
+			ts.Fatalf("no match for %#q found in gql %s: %s", pattern, name, text)
+		}
+		ts.Fatalf("gql %s not found", name)
+	}
+}
+
 // grep checks that file content matches a regexp.
 // Like stdout/stderr and unlike Unix grep, it accepts Go regexp syntax.
 func (ts *Script) cmdGrep(neg int, args []string) {
@@ -480,11 +786,9 @@ func (ts *Script) cmdStop(neg int, args []string) {
 	if neg != 0 {
 		ts.Fatalf("unsupported: !? stop")
 	}
-	if len(args) > 1 {
-		ts.Fatalf("usage: stop [msg]")
-	}
-	if len(args) == 1 {
-		ts.Logf("stop: %s\n", args[0])
+	stop := ts.skipArgs("stop", args)
+	if len(args) > 0 {
+		ts.Logf("stop: %s\n", stop)
 	} else {
 		ts.Logf("stop\n")
 	}
@@ -504,6 +808,35 @@ func (ts *Script) cmdSymlink(neg int, args []string) {
 	ts.Check(os.Symlink(args[2], ts.MkAbs(args[0])))
 }
 
+// tail checks the live output log of a background command.
+func (ts *Script) cmdTail(neg int, args []string) {
+	var count []string
+	if len(args) > 0 && strings.HasPrefix(args[0], "-count=") {
+		count, args = args[:1], args[1:]
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: tail [-count=N] name[.out|.err] [pattern]")
+	}
+
+	name := args[0]
+	if ext := filepath.Ext(name); ext != ".out" && ext != ".err" {
+		name += ".out"
+	}
+	file := filepath.Join(ts.workdir, backgroundLogDir, name)
+
+	if len(args) == 1 {
+		if neg != 0 || len(count) > 0 {
+			ts.Fatalf("usage: tail [-count=N] name[.out|.err] [pattern]")
+		}
+		data, err := ioutil.ReadFile(file)
+		ts.Check(err)
+		ts.Logf("[%s]\n%s", name, data)
+		return
+	}
+
+	scriptMatch(ts, neg, append(count, args[1], file), "", "grep")
+}
+
 // Tait waits for background commands to exit, setting stderr and stdout to their result.
 func (ts *Script) cmdWait(neg int, args []string) {
 	if neg != 0 {
@@ -520,13 +853,13 @@ func (ts *Script) cmdWait(neg int, args []string) {
 		args := append([]string{filepath.Base(bg.cmd.Args[0])}, bg.cmd.Args[1:]...)
 		fmt.Fprintf(&ts.log, "[background] %s: %v\n", strings.Join(args, " "), bg.cmd.ProcessState)
 
-		cmdStdout := bg.cmd.Stdout.(*strings.Builder).String()
+		cmdStdout := bg.stdout.String()
 		if cmdStdout != "" {
 			fmt.Fprintf(&ts.log, "[stdout]\n%s", cmdStdout)
 			stdouts = append(stdouts, cmdStdout)
 		}
 
-		cmdStderr := bg.cmd.Stderr.(*strings.Builder).String()
+		cmdStderr := bg.stderr.String()
 		if cmdStderr != "" {
 			fmt.Fprintf(&ts.log, "[stderr]\n%s", cmdStderr)
 			stderrs = append(stderrs, cmdStderr)
@@ -538,7 +871,7 @@ func (ts *Script) cmdWait(neg int, args []string) {
 			}
 		} else {
 			if ts.ctxt.Err() != nil {
-				ts.Fatalf("test timed out while running command")
+				ts.Fatalf("script stopped while running command: %v", ts.ctxt.Err())
 			} else if bg.neg == 0 {
 				ts.Fatalf("unexpected command failure")
 			}
@@ -550,6 +883,31 @@ func (ts *Script) cmdWait(neg int, args []string) {
 	ts.background = nil
 }
 
+// waitfor blocks until a TCP port, URL, or log line is ready.
+func (ts *Script) cmdWaitfor(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? waitfor")
+	}
+	args, timeout := waitforArgs(args)
+	if len(args) < 1 {
+		ts.Fatalf("usage: waitfor [tcp:host:port | url [code] | log file pattern] [timeout]")
+	}
+
+	ready, what := ts.waitforCheck(args)
+
+	deadline := time.Now().Add(timeout)
+	for !ready() {
+		if ts.ctxt.Err() != nil {
+			ts.Fatalf("script stopped while waiting for %s: %v", what, ts.ctxt.Err())
+		}
+		if time.Now().After(deadline) {
+			ts.Fatalf("timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(waitforPollInterval)
+	}
+	ts.Logf("ready: %s\n", what)
+}
+
 // scriptMatch implements both stdout and stderr.
 func scriptMatch(ts *Script, neg int, args []string, text, name string) {
 	n := 0
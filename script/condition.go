@@ -0,0 +1,97 @@
+package script
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A ConditionFunc reports whether a condition holds. It receives the text
+// after the first colon in the condition, so [env:CI] calls the "env"
+// condition with arg "CI". Conditions without a colon receive "".
+type ConditionFunc func(arg string) (bool, error)
+
+var (
+	conditionsMu sync.RWMutex
+	conditions   = map[string]ConditionFunc{
+		"env":       envCondition,
+		"port-free": portFreeCondition,
+		"root":      rootCondition,
+	}
+)
+
+// RegisterCondition makes a condition available to all scripts as [name] or [name:arg].
+// It panics if the name is already registered or is otherwise special,
+// like the GOOS and GOARCH names or go1.N.
+func RegisterCondition(name string, f ConditionFunc) {
+	if name == "" || strings.ContainsAny(name, ":!") || goVersionCond.MatchString(name) {
+		panic(fmt.Sprintf("script: invalid condition name %q", name))
+	}
+	conditionsMu.Lock()
+	defer conditionsMu.Unlock()
+	if _, ok := conditions[name]; ok {
+		panic(fmt.Sprintf("script: condition %q already registered", name))
+	}
+	conditions[name] = f
+}
+
+// lookupCondition splits cond into name and arg and returns the registered func for name.
+func lookupCondition(cond string) (ConditionFunc, string, bool) {
+	name, arg := cond, ""
+	if i := strings.Index(cond, ":"); i >= 0 {
+		name, arg = cond[:i], cond[i+1:]
+	}
+	conditionsMu.RLock()
+	defer conditionsMu.RUnlock()
+	f, ok := conditions[name]
+	return f, arg, ok
+}
+
+var goVersionCond = regexp.MustCompile(`^go1\.(\d+)$`)
+
+// goVersionCondition reports whether the running Go version is at least go1.N.
+// Development versions are assumed to be new enough.
+func goVersionCondition(minor string) bool {
+	want, _ := strconv.Atoi(minor)
+	m := regexp.MustCompile(`^go1\.(\d+)`).FindStringSubmatch(runtime.Version())
+	if m == nil {
+		return true
+	}
+	have, _ := strconv.Atoi(m[1])
+	return have >= want
+}
+
+// envCondition reports whether the process environment variable is set and non-empty.
+// With [env:NAME=value] the variable must have exactly that value.
+func envCondition(arg string) (bool, error) {
+	if arg == "" {
+		return false, fmt.Errorf("usage: [env:NAME] or [env:NAME=value]")
+	}
+	if i := strings.Index(arg, "="); i >= 0 {
+		return os.Getenv(arg[:i]) == arg[i+1:], nil
+	}
+	return os.Getenv(arg) != "", nil
+}
+
+// portFreeCondition reports whether the TCP port can be listened on at localhost.
+func portFreeCondition(arg string) (bool, error) {
+	if _, err := strconv.Atoi(arg); err != nil {
+		return false, fmt.Errorf("usage: [port-free:PORT]")
+	}
+	ln, err := net.Listen("tcp", "localhost:"+arg)
+	if err != nil {
+		return false, nil
+	}
+	ln.Close()
+	return true, nil
+}
+
+// rootCondition reports whether the tests run as the superuser.
+func rootCondition(arg string) (bool, error) {
+	return os.Geteuid() == 0, nil
+}
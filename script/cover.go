@@ -8,7 +8,6 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"regexp"
 	"strconv"
@@ -17,6 +16,8 @@ import (
 	"testing"
 
 	"gopkg.in/errgo.v2/fmt/errors"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 // mergeCoverProfile merges the coverage information in f into
@@ -101,7 +102,7 @@ func mergeCoverProfiles() {
 	var cover testing.Cover
 	for f := range coverChan {
 		if err := mergeCoverProfile(&cover, f); err != nil {
-			log.Printf("cannot merge coverage profile from %v: %v", f.Name(), err)
+			logs.Scope("script").Warn("cannot merge coverage profile", "file", f.Name(), "err", err)
 		}
 		f.Close()
 		os.Remove(f.Name())
@@ -0,0 +1,119 @@
+package script
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
+)
+
+// cue runs cue eval, vet, or export in-process.
+func (ts *Script) cmdCue(neg int, args []string) {
+	const usage = "usage: cue eval|vet|export [-e path] [files or packages...]"
+	if len(args) < 1 {
+		ts.Fatalf(usage)
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "eval", "vet", "export":
+	default:
+		ts.Fatalf(usage)
+	}
+	path := ""
+	if len(args) > 0 && args[0] == "-e" {
+		if len(args) < 2 {
+			ts.Fatalf(usage)
+		}
+		path, args = args[1], args[2:]
+	}
+
+	out, err := runCue(ts.cd, sub, path, args)
+	ts.stdout, ts.stderr = out, ""
+	status := 0
+	if err != nil {
+		ts.stderr, status = cueerrors.Details(err, nil), 1
+	}
+	ts.setStatus(status)
+	if ts.stdout != "" {
+		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
+	}
+	if ts.stderr != "" {
+		fmt.Fprintf(&ts.log, "[stderr]\n%s", ts.stderr)
+	}
+	if err == nil && neg > 0 {
+		ts.Fatalf("unexpected command success")
+	}
+	if err != nil && neg == 0 {
+		ts.Fatalf("unexpected cue %s failure", sub)
+	}
+}
+
+// runCue loads the CUE files or packages in args, the package in dir by
+// default, and returns the output of the cue subcommand for each instance.
+// With a path, only the value at that dot separated path is used.
+func runCue(dir, sub, path string, args []string) (string, error) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var r cue.Runtime
+	var out bytes.Buffer
+	for _, bi := range load.Instances(args, &load.Config{Dir: dir}) {
+		if bi.Err != nil {
+			return out.String(), bi.Err
+		}
+		inst, err := r.Build(bi)
+		if err != nil {
+			return out.String(), err
+		}
+		v := inst.Value()
+		if path != "" {
+			v = v.Lookup(strings.Split(path, ".")...)
+			if !v.Exists() {
+				return out.String(), fmt.Errorf("%s: not found", path)
+			}
+		}
+
+		switch sub {
+		case "eval":
+			if err := v.Validate(); err != nil {
+				return out.String(), err
+			}
+			b, err := format.Node(v.Syntax(
+				cue.Attributes(true),
+				cue.Definitions(true),
+				cue.Docs(true),
+				cue.Optional(true),
+				cue.Final(),
+			), format.Simplify())
+			if err != nil {
+				return out.String(), err
+			}
+			out.Write(bytes.TrimSpace(b))
+			out.WriteByte('\n')
+
+		case "vet":
+			if err := v.Validate(cue.Concrete(true)); err != nil {
+				return out.String(), err
+			}
+
+		case "export":
+			if err := v.Validate(cue.Concrete(true)); err != nil {
+				return out.String(), err
+			}
+			b, err := v.MarshalJSON()
+			if err != nil {
+				return out.String(), err
+			}
+			if err := json.Indent(&out, b, "", "    "); err != nil {
+				return out.String(), err
+			}
+			out.WriteByte('\n')
+		}
+	}
+	return out.String(), nil
+}
@@ -34,6 +34,20 @@ free to mutate global variables without polluting the top level test binary.
 		}))
 	}
 
+To track performance with the same scripts, call testscript.RunBenchmark
+from a benchmark. Each script becomes a sub-benchmark, and the average time
+of each phase is reported as an extra metric named after its # heading.
+
+	func BenchmarkFoo(b *testing.B) {
+		testscript.RunBenchmark(b, testscript.Params{
+			Dir: "testdata",
+		})
+	}
+
+Params.SuiteSetup runs once before any script, for example to start a shared
+database or server, and the environment variables and values it sets are
+passed on to every script. Params.SuiteTeardown runs once after the last.
+
 In general script files should have short names: a few words, not whole sentences.
 The first word should be the general category of behavior being tested,
 often the name of a subcommand to be tested or a concept (vendor, pattern).
@@ -70,6 +84,12 @@ and then the script begins execution in that
 directory as well. Thus the example above runs in $WORK
 with $WORK/hello.txt containing the listed contents.
 
+Binary fixtures can be stored base64 encoded by marking the file
+as in "-- logo.png (base64) --"; they are decoded when unpacked.
+Similarly "-- run.sh (mode=0755) --" sets the file permissions, and
+"-- current (symlink) --" creates a symbolic link to the path given
+as the file content.
+
 The lines at the top of the script are a sequence of commands to be
 executed by a small script engine in the testscript package (not the system
 shell).  The script stops and the overall test fails if any particular
@@ -84,15 +104,59 @@ quote indicates a literal single quote, as in:
 
 	'Don''t communicate by sharing memory.'
 
+A line of the form "include path" is replaced by the script section of the
+txtar archive at path, relative to the including script, and that archive's
+files are extracted too. Files in the including script take precedence.
+Params.Prelude names archives which are included at the start of every script.
+Note that line numbers in failure messages count the spliced lines.
+
+Params.Tools pins external programs, which are downloaded once into a cache
+and put at the front of PATH. A comment line of the form "~ tools: cue, kubectl"
+declares the tools a script needs; it fails if any are not in Params.Tools,
+rather than an [exec:cue] condition quietly skipping the check.
+
+A comment line of the form "~ tags: slow, network" declares tags for the script.
+Params.Tags, or the -run-tags flag, selects scripts by tag: a script runs if it
+has any of the listed tags, and none of the tags listed with a leading "!".
+Scripts which are not selected are skipped.
+
+Scripts run in parallel. Params.MaxParallel limits how many run at once,
+and a script with a "~ serial" comment line runs while no other script does.
+
+Params.DryRun, or the Lint function, checks scripts without running them:
+every line is parsed and its conditions resolved, and unknown commands,
+malformed conditions, arguments rejected by registered commands, and
+unreadable includes or missing expected files (for cmp, cmpenv, and stdin)
+are reported with their line numbers, rather than when the line is reached.
+
+With Params.Sequence set, scripts instead run one at a time in file name
+order, so a long flow (init, add, gen, test) can be split into several
+readable scripts. A script with a "~ carry" comment line continues in the
+$WORK left by the script before it, with its own files written on top,
+rather than setting up a fresh one. It is skipped if that script did not
+pass, and carry is an error without Params.Sequence.
+
 A line beginning with # is a comment and conventionally explains what is
 being done or tested at the start of a new phase in the script.
 
+A phase heading ending in [retry=N], like "# wait for server [retry=3]",
+marks a phase which is run again from its heading, up to N more times,
+when one of its commands fails. Before each retry the environment and
+current directory are restored and background commands started in the
+phase are stopped; changes to files are not undone. The log notes each
+attempt, and the number taken is shown next to the phase heading.
+
 A special form of environment variable syntax can be used to quote
 regexp metacharacters inside environment variables. The "@R" suffix
 is special, and indicates that the variable should be quoted.
 
 	${VAR@R}
 
+Similarly, the "@U" suffix replaces the path separators in the value with
+forward slashes, so that paths can be compared the same way on all systems.
+
+	${WORK@U}/out.txt
+
 The command prefix ! indicates that the command on the rest of the line
 (typically go or a matching predicate) must fail, not succeed. Only certain
 commands support this prefix. They are indicated below by [!] in the synopsis.
@@ -104,15 +168,37 @@ should only run when the condition is satisfied. The predefined conditions are:
  - [net] for whether the external network can be used
  - [link] for whether the OS has hard link support
  - [symlink] for whether the OS has symbolic link support
+ - [pty] for whether the pty command is supported (currently Linux only)
  - [exec:prog] for whether prog is available for execution (found by exec.LookPath)
+ - [go1.N] for whether the Go version is at least 1.N
+ - [env:NAME] for whether the process environment has NAME set and non-empty,
+   or [env:NAME=value] for whether it has exactly that value
+ - [port-free:PORT] for whether localhost:PORT can be listened on
+ - [root] for whether the tests run as the superuser
+ - [network] for whether scripts may use the network (see Params.DenyNetwork)
 
 A condition can be negated: [!short] means to run the rest of the line
 when testing.Short() is false.
 
-Additional conditions can be added by passing a function to Params.Condition.
+Additional conditions can be added for all scripts with RegisterCondition,
+which also supports arguments in the form [name:arg], or by passing a
+function to Params.Condition.
+
+Commands beyond the predefined ones come from Params.Cmds or from packages
+which register reusable sets of Cmd implementations with RegisterCommands.
+A registered command's arguments are checked before it runs, and the
+failure message includes its usage. CmdFunc adapts a Cmd for Params.Cmds.
 
 The predefined commands are:
 
+- [!] call function [args...]
+  Run the named function from Params.Funcs in-process, so that it counts
+  towards code coverage. Like exec, it must (or must not) succeed, its
+  standard output and standard error are captured, and standard input
+  set by the stdin command is available on os.Stdin and then cleared.
+  The status is 0 on success, the ExitCode() of the returned error if it
+  has one (see StatusError), and 1 otherwise.
+
 - cd dir
   Change to the given directory for future commands.
 
@@ -120,27 +206,57 @@ The predefined commands are:
 
   Change the permissions of file or directory to the given octal mode (000 to 777).
 
-- cmp file1 file2
+- cmp [-trim-space] [-ignore-blank] file1 file2
   Check that the named files have the same content.
   By convention, file1 is the actual data and file2 the expected data.
   File1 can be "stdout" or "stderr" to use the standard output or standard error
   from the most recent exec or wait command.
-  (If the files have differing content, the failure prints a diff.)
+  With -trim-space, leading and trailing space on each line is ignored;
+  with -ignore-blank, blank lines are ignored.
+  (If the files have differing content, the failure prints a unified diff.)
 
-- cmpenv file1 file2
+- cmpenv [-trim-space] [-ignore-blank] file1 file2
   Like cmp, but environment variables in file2 are substituted before the
   comparison. For example, $GOOS is replaced by the target GOOS.
 
+- tomlcmp file1 file2
+- yamlcmp file1 file2
+  Like cmp, but both files are parsed as TOML or YAML and compared
+  structurally, ignoring formatting, comments, and key order.
+  The failure prints a diff of the normalized (JSON) forms.
+
+- [!] cue eval|vet|export [-e path] [files or packages...]
+  Run cue eval, vet, or export in-process with the CUE Go API, so scripts do
+  not need a cue binary on PATH and, like call, the work counts towards code
+  coverage. The arguments are CUE files or packages, the package in the
+  current directory by default, and -e selects the value at a dot separated
+  path. vet and export require concrete values, and export prints JSON.
+  The output is captured as standard output, errors as standard error, and
+  the status is 1 on failure.
+
 - cp src... dst
   Copy the listed files to the target file or existing directory.
   src can include "stdout" or "stderr" to use the standard output or standard error
   from the most recent exec or go command.
 
+- dotenv file...
+  Load KEY=VALUE lines from each file into the environment. Blank lines,
+  lines starting with #, and a leading "export " are ignored. Values are
+  subject to environment variable expansion unless single quoted.
+  Params.EnvFiles lists files to load this way before the script starts.
+
 - env [key=value...]
   With no arguments, print the environment (useful for debugging).
   Otherwise add the listed key=value pairs to the environment.
 
-- [!] exec program [args...] [&]
+- env push [key=value...]
+- env pop
+  Save the environment and then add the listed key=value pairs, or restore the
+  environment saved by the matching push. Environments still pushed at the end
+  of a phase are restored when the next phase starts, so a phase can override
+  variables such as HOME without leaking them into later phases.
+
+- [!] exec [&name] program [args...] [&|&name]
   Run the given executable program with the arguments.
   It must (or must not) succeed.
   Note that 'exec' does not terminate the script (unlike in Unix shells).
@@ -152,6 +268,12 @@ The predefined commands are:
   test. At the end of the test, any remaining background processes are
   terminated using os.Interrupt (if supported) or os.Kill.
 
+  A leading or final '&name' also runs the program in the background and names it,
+  so that it can be referred to by the signal and tail commands.
+  While running, the output of a background program is also written to
+  $WORK/.logs/<name>.out and $WORK/.logs/<name>.err, where unnamed programs
+  are named bg1, bg2, and so on in the order they were started.
+
   Standard input can be provided using the stdin command; this will be
   cleared after exec has been called.
 
@@ -159,27 +281,115 @@ The predefined commands are:
   Each of the listed files or directories must (or must not) exist.
   If -readonly is given, the files or directories must be unwritable.
 
+- golden actual [expected]
+  Check that the file or directory actual has the same content as expected,
+  which defaults to golden/actual. For directories, every file must match
+  and neither side may have extra files. With Params.UpdateScripts, golden
+  files in the script archive are added, updated, or removed to match.
+
 - [!] grep [-count=N] pattern file
   The file's content must (or must not) match the regular expression pattern.
   For positive matches, -count=N specifies an exact number of matches to require.
 
+- help [command...]
+  Print the usage and help text of the named commands registered with
+  RegisterCommands, or of all of them, to standard output.
+
+- [!] gql data path [pattern]
+- [!] gql errors [pattern]
+  Decode the standard output of the most recent http command as a GraphQL
+  response and check that the value at the dotted path under "data", or a
+  non-empty "errors" list, is (or is not) present. If pattern is given, the
+  value (JSON encoded unless it is a string) must also match it.
+  GraphQL requests are built with the http args GQL=@query.graphql and
+  the optional VARS=@vars.json, which POST a {"query","variables"} envelope.
+
+- [!] httpstat latency|size op value
+  Check the latency or response body size of the most recent http command,
+  where op is one of < <= > >= == !=. Latencies are durations like 500ms,
+  and sizes are byte counts with an optional B, KB, or MB suffix.
+  Both are also noted in the log after each request. For example:
+
+	http GET $URL/health
+	httpstat latency < 500ms
+	httpstat size <= 10KB
+
+- mask value...
+  Replace each value with "***" everywhere in the test log, including the
+  environment dump, command output, and comparison failures. Typically used
+  as 'mask $TOKEN'. Setup functions can call Env.Mask for the same effect.
+  With -v the log is streamed as the script runs, so output from before
+  the mask command is not redacted; mask secrets known up front in Setup.
+
 - mkdir path...
   Create the listed directories, if they do not already exists.
 
+- port VAR [count]
+  Find free TCP ports on localhost and store the first in $VAR.
+  If count is given, the ports are also stored in $VAR_1 through $VAR_count.
+  Ports are never handed out twice within one test binary, so scripts
+  running in parallel can start servers without colliding.
+
 - unquote file...
   Rewrite each file by replacing any leading ">" characters from
   each line. This enables a file to contain substrings that look like
   txtar file markers.
   See also https://godoc.org/github.com/hofstadter-io/hof/lib/gotils/txtar#Unquote
 
+- tar [-z] archive path...
+- zip archive path...
+  Create a tar or zip archive of the listed files and directories, which must
+  be within the current directory. Names in the archive are relative to it and
+  use forward slashes on every OS. tar compresses the archive with gzip when
+  -z is given or the archive name ends in .gz or .tgz.
+
+- untar archive [dir]
+- unzip archive [dir]
+  Extract a tar archive, gzipped or not, or a zip archive into dir, the current
+  directory by default. Entries which would be written outside of dir fail
+  the script. These do not rely on tar or unzip binaries on the host.
+
+- pty spawn program [args...]
+- [!] pty expect [-timeout=d] pattern
+- pty send [-n] text
+- [!] pty wait
+  Drive an interactive program attached to a pseudo-terminal.
+  'pty spawn' starts the program; only one may run at a time.
+  'pty expect' waits (10s by default) until the terminal output since the
+  last match matches the regexp pattern; with ! the pattern must not appear
+  before the timeout or the program exits.
+  'pty send' writes text, with Go escapes such as \x03 interpreted, followed
+  by a newline unless -n is given.
+  'pty wait' waits for the program to exit and sets stdout to everything it
+  wrote to the terminal.
+
 - rm file...
   Remove the listed files or directories.
 
-- skip [message]
-  Mark the test skipped, including the message if given.
+- [!] schema schema.json|schema.cue file
+  Validate the JSON document in file, which may be stdout or stderr, against
+  a JSON Schema or, for a .cue file, a CUE schema. On failure every constraint
+  which failed is reported with the path to the offending value. Draft 7
+  keywords are supported, with $ref limited to references within the schema.
+
+- [!] shell [-powershell] [&name] command... [&|&name]
+  Run the command line, made by joining the arguments with spaces, with the
+  system shell: sh -c on Unix and cmd /c on Windows. With -powershell it is
+  run by pwsh, or powershell if pwsh is not found, on any system. Otherwise
+  shell is like exec, including running in the background.
+
+- signal name signal
+  Send a signal (SIGHUP, SIGINT, SIGQUIT, SIGTERM, or SIGKILL) to the
+  background command started with 'exec &name'. Use wait afterwards to
+  check how it exited.
+
+- skip [reason [issue-url]]
+  Mark the test skipped, including the reason and the issue tracking the
+  skip if given. Skipped scripts are summarized, with their reasons and
+  issues, once all scripts have run, and skip events carry both fields.
 
 - stdin file
-  Set the standard input for the next exec command to the contents of the given file.
+  Set the standard input for the next exec or call command to the contents of the given file.
   File can be "stdout" or "stderr" to use the standard output or standard error
   from the most recent exec or wait command.
 
@@ -191,12 +401,60 @@ The predefined commands are:
   Apply the grep command (see above) to the standard output
   from the most recent exec or wait command.
 
-- stop [message]
-  Stop the test early (marking it as passing), including the message if given.
+- [!] status code
+  Check the status of the most recent exec, call, http, cue, or pty command:
+  its exit code, or the HTTP status code of the response. With ! the status
+  must differ from code. The status is also available as $STATUS, so later
+  commands can use it, as in 'grep ^$STATUS$ codes.txt'.
+
+- [!] nats [-timeout d] sub subject
+- [!] nats [-timeout d] pub subject message
+- [!] nats [-timeout d] recv subject [count]
+  Subscribe to a subject, publish a message, or wait for count messages
+  (1 by default) on a subject subscribed to earlier, putting them on standard
+  output one per line. Messages which arrive after sub are buffered until
+  recv, so subscribe before triggering the messages. The server is $NATS_URL,
+  nats://127.0.0.1:4222 by default, which may include a user and password
+  or token. The timeout defaults to 10s.
+
+- [!] kafka [-timeout d] produce topic message
+- [!] kafka [-timeout d] consume topic [count]
+  Produce a message to a topic, or wait for count messages (1 by default)
+  from the start of a topic, putting them on standard output one per line.
+  Messages which are valid JSON are produced as JSON, others as strings.
+  kafka talks to a Kafka REST Proxy, such as the Confluent REST Proxy or the
+  Redpanda HTTP Proxy, at $KAFKA_REST_URL, http://127.0.0.1:8082 by default.
+  Each consume uses a new consumer group. The timeout defaults to 10s.
+
+- [!] s3 [-endpoint url] put bucket/key file
+- [!] s3 [-endpoint url] get bucket/key [file]
+- [!] s3 [-endpoint url] ls bucket[/prefix]
+- [!] s3 [-endpoint url] rm bucket/key
+  Upload a file to an object, download an object to a file or standard output,
+  list the keys with a prefix one per line on standard output, or remove an
+  object. Requests are path style and signed with AWS Signature Version 4
+  using $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY, and $AWS_SESSION_TOKEN,
+  or are anonymous when no key is set. The endpoint defaults to $S3_ENDPOINT,
+  and then to AWS in $AWS_REGION (us-east-1 by default); set it to test
+  against MinIO or another S3 compatible store. $STATUS is set to the HTTP
+  status of the last response.
+
+- [!] gcs [-endpoint url] put|get|ls|rm ...
+  Like s3, for Google Cloud Storage through its XML API, signed with the HMAC
+  key in $GCS_HMAC_ACCESS_ID and $GCS_HMAC_SECRET. The endpoint defaults to
+  $GCS_ENDPOINT, and then to https://storage.googleapis.com.
+
+- stop [reason [issue-url]]
+  Stop the test early (marking it as passing), logging the reason and issue if given.
 
 - symlink file -> target
   Create file as a symlink to target. The -> (like in ls -l output) is required.
 
+- [!] tail [-count=N] name[.out|.err] [pattern]
+  Apply the grep command (see above) to the output a background command has
+  written so far, standard output unless .err is given. Without a pattern,
+  print that output to the log.
+
 - wait
   Wait for all 'exec' and 'go' commands started in the background (with the '&'
   token) to exit, and display success or failure status for them.
@@ -204,6 +462,14 @@ The predefined commands are:
   concatenation of the corresponding streams of the background commands,
   in the order in which those commands were started.
 
+- waitfor tcp:host:port [timeout]
+- waitfor url [code] [timeout]
+- waitfor log file pattern [timeout]
+  Block until a TCP connection to host:port succeeds, a GET of url
+  returns code (or any non-5xx status), or file's content matches pattern.
+  The timeout defaults to 10s. Use this instead of sleeping while
+  a background 'exec &' server starts.
+
 When TestScript runs a script and the script fails, by default TestScript shows
 the execution of the most recent phase of the script (since the last # comment)
 and only shows the # comments for earlier phases. For example, here is a
@@ -270,6 +536,8 @@ Note that the commands in earlier phases have been hidden, so that the relevant
 commands are more easily found, and the elapsed time for a completed phase
 is shown next to the phase heading. To see the entire execution, use "go test -v",
 which also adds an initial environment dump to the beginning of the log.
+With -v the log is also streamed as each phase and command runs,
+rather than printed when the script finishes, so hung scripts can be diagnosed.
 
 Note also that in reported output, the actual name of the per-script temporary directory
 has been consistently replaced with the literal string $WORK.
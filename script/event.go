@@ -0,0 +1,86 @@
+package script
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event describes the execution of a single script command.
+// When Params.EventWriter is set, one JSON encoded Event
+// is written per line for every command run.
+type Event struct {
+	Script   string        `json:"script"`
+	File     string        `json:"file"`
+	Line     int           `json:"line"`
+	Phase    string        `json:"phase,omitempty"`
+	Args     []string      `json:"args"`
+	Negate   int           `json:"negate,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Status   int           `json:"status"`
+	Stdout   int           `json:"stdout"`
+	Stderr   int           `json:"stderr"`
+	Result   string        `json:"result"`           // pass, fail, or skip
+	Reason   string        `json:"reason,omitempty"` // reason given to skip
+	Issue    string        `json:"issue,omitempty"`  // issue given to skip
+}
+
+// eventMu serializes writes from scripts running in parallel.
+var eventMu sync.Mutex
+
+// runCmd runs a script command, emitting an Event for it if requested.
+// The event is written even when the command fails the test.
+func (ts *Script) runCmd(cmd func(*Script, int, []string), neg int, args []string) {
+	if ts.params.EventWriter == nil {
+		cmd(ts, neg, args[1:])
+		return
+	}
+
+	start := time.Now()
+	result := "fail"
+	if args[0] == "skip" {
+		result = "skip"
+	}
+	defer func() {
+		redacted := make([]string, len(args))
+		for i, arg := range args {
+			redacted[i] = ts.redact(arg)
+		}
+		var reason, issue string
+		if ts.skipped != nil {
+			reason, issue = ts.skipped.reason, ts.skipped.issue
+		}
+		ts.emitEvent(Event{
+			Script:   ts.name,
+			File:     ts.file,
+			Line:     ts.lineno,
+			Phase:    ts.phase,
+			Args:     redacted,
+			Negate:   neg,
+			Start:    start,
+			Duration: time.Since(start),
+			Status:   ts.status,
+			Stdout:   len(ts.stdout),
+			Stderr:   len(ts.stderr),
+			Result:   result,
+			Reason:   reason,
+			Issue:    issue,
+		})
+	}()
+
+	cmd(ts, neg, args[1:])
+	result = "pass"
+}
+
+func (ts *Script) emitEvent(ev Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	eventMu.Lock()
+	defer eventMu.Unlock()
+	ts.params.EventWriter.Write(data)
+}
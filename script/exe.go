@@ -8,10 +8,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"sync/atomic"
 	"testing"
+
+	"github.com/hofstadter-io/hof/lib/logs"
 )
 
 var profileId int32 = 0
@@ -54,7 +55,7 @@ func RunMain(m TestingM, commands map[string]func() int) (exitCode int) {
 	if cmdName == "" {
 		defer func() {
 			if err := finalizeCoverProfile(); err != nil {
-				log.Printf("cannot merge cover profiles: %v", err)
+				logs.Scope("script").Warn("cannot merge cover profiles", "err", err)
 				exitCode = 2
 			}
 		}()
@@ -93,7 +94,7 @@ func RunMain(m TestingM, commands map[string]func() int) (exitCode int) {
 	}
 	mainf := commands[cmdName]
 	if mainf == nil {
-		log.Printf("unknown command name %q", cmdName)
+		logs.Scope("script").Error("unknown command name", "cmd", cmdName)
 		return 2
 	}
 	// The command being registered is being invoked, so run it, then exit.
@@ -146,7 +147,7 @@ func runCoverSubcommand(cprof string, mainf func() int) (exitCode int) {
 			exitCode = code
 		}
 		if _, err := os.Stat(cprof); err != nil {
-			log.Printf("failed to write coverage profile %q", cprof)
+			logs.Scope("script").Warn("failed to write coverage profile", "file", cprof)
 		}
 		if panicErr != nil {
 			// The error didn't originate from the flag package (we know that
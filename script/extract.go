@@ -0,0 +1,152 @@
+package script
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/hofstadter-io/hof/lib/gotils/par"
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+)
+
+const (
+	// archiveBatchSize is the most file data held in memory while
+	// archive files are written out. Larger files are streamed to disk.
+	archiveBatchSize = 32 << 20
+
+	// archiveParallelFiles is the number of files in a batch above which
+	// they are written concurrently. Small archives are written serially.
+	archiveParallelFiles = 16
+)
+
+// copyBufPool holds the buffers used to stream large archive files to disk.
+var copyBufPool = sync.Pool{
+	New: func() interface{} { return make([]byte, 256<<10) },
+}
+
+// An archiveBatch holds archive files waiting to be written to the work directory.
+type archiveBatch struct {
+	files []*archiveFile
+	index map[string]int // position in files, by name
+	size  int
+}
+
+type archiveFile struct {
+	name string // absolute name in the work directory
+	f    *txtar.File
+	data []byte
+}
+
+// add adds f, with its decoded contents, to the batch and returns the
+// absolute name it will be written to. A later file with the same name
+// replaces an earlier one, as it would when written in order.
+func (b *archiveBatch) add(ts *Script, f *txtar.File, data []byte) string {
+	name := ts.MkAbs(ts.expand(f.Name))
+	af := &archiveFile{name: name, f: f, data: data}
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+	if i, ok := b.index[name]; ok {
+		b.size -= len(b.files[i].data)
+		b.files[i] = af
+	} else {
+		b.index[name] = len(b.files)
+		b.files = append(b.files, af)
+	}
+	b.size += len(data)
+	return name
+}
+
+// extractArchiveFile adds f, with its contents read from r, to batch,
+// writing out the batch when it is full. Files too large for a batch are
+// streamed to disk once the files before them have been written.
+// It returns the absolute name f is written to.
+func (ts *Script) extractArchiveFile(batch *archiveBatch, f *txtar.File, r io.Reader) string {
+	data, err := ioutil.ReadAll(io.LimitReader(r, archiveBatchSize))
+	ts.Check(err)
+	if len(data) < archiveBatchSize {
+		name := batch.add(ts, f, data)
+		if batch.size >= archiveBatchSize {
+			ts.flushArchive(batch)
+		}
+		return name
+	}
+	ts.flushArchive(batch)
+	name := ts.MkAbs(ts.expand(f.Name))
+	ts.Check(ts.writeArchiveFile(name, f, io.MultiReader(bytes.NewReader(data), r)))
+	return name
+}
+
+// flushArchive writes the files in batch, concurrently when there are many
+// of them, and empties it. The first error, if any, fails the script.
+func (ts *Script) flushArchive(batch *archiveBatch) {
+	files := batch.files
+	*batch = archiveBatch{}
+	if len(files) <= archiveParallelFiles {
+		for _, af := range files {
+			ts.Check(ts.writeArchiveFile(af.name, af.f, bytes.NewReader(af.data)))
+		}
+		return
+	}
+
+	var (
+		work     par.Work
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, af := range files {
+		work.Add(af)
+	}
+	work.Do(runtime.GOMAXPROCS(0), func(item interface{}) {
+		af := item.(*archiveFile)
+		if err := ts.writeArchiveFile(af.name, af.f, bytes.NewReader(af.data)); err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}
+	})
+	ts.Check(firstErr)
+}
+
+// writeArchiveFile writes an archive file, with its decoded contents read
+// from r, to name, applying its mode or creating a symlink.
+// It is safe to call concurrently for different names.
+func (ts *Script) writeArchiveFile(name string, f *txtar.File, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	// Included files may be overridden by the script's own,
+	// so don't write through any existing symlink.
+	os.Remove(name)
+	if f.IsSymlink() {
+		target, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(ts.expand(strings.TrimSpace(string(target))), name)
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	buf := copyBufPool.Get().([]byte)
+	_, err = io.CopyBuffer(out, r, buf)
+	copyBufPool.Put(buf)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if mode, ok := f.Mode(); ok {
+		return os.Chmod(name, mode)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package script
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/gotils/intern/textutil"
+)
+
+// golden compares a generated file or directory against golden copies in the archive.
+func (ts *Script) cmdGolden(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? golden")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: golden actual [expected]")
+	}
+
+	actual := args[0]
+	expected := filepath.Join("golden", actual)
+	if len(args) == 2 {
+		expected = args[1]
+	}
+	absActual, absExpected := ts.MkAbs(actual), ts.MkAbs(expected)
+
+	// Relative paths of the files on each side.
+	actualFiles := ts.goldenFiles(absActual)
+	expectedFiles := ts.goldenFiles(absExpected)
+	if len(actualFiles) == 0 {
+		ts.Fatalf("golden: %s does not exist or has no files", actual)
+	}
+
+	info, err := os.Stat(absActual)
+	ts.Check(err)
+	isDir := info.IsDir()
+	join := func(dir, rel string) string {
+		if !isDir {
+			return dir
+		}
+		return filepath.Join(dir, rel)
+	}
+
+	var failed []string
+	for _, rel := range unionStrings(actualFiles, expectedFiles) {
+		aname, ename := join(absActual, rel), join(absExpected, rel)
+		adata, aerr := ioutil.ReadFile(aname)
+		edata, eerr := ioutil.ReadFile(ename)
+		if aerr == nil && eerr == nil && string(adata) == string(edata) {
+			continue
+		}
+
+		if ts.params.UpdateScripts && ts.updateGolden(ename, adata, aerr == nil) {
+			continue
+		}
+
+		switch {
+		case aerr != nil:
+			ts.Logf("[golden] %s is missing", aname)
+		case eerr != nil:
+			ts.Logf("[golden] %s is missing", ename)
+		default:
+			ts.Logf("[diff]\n%s", textutil.UnifiedDiff(aname, ename, string(adata), string(edata), 3))
+		}
+		failed = append(failed, rel)
+	}
+
+	if len(failed) > 0 {
+		ts.Fatalf("%s and %s differ", actual, expected)
+	}
+}
+
+// updateGolden records the actual content of a golden file in the script archive,
+// or its removal if there is no longer an actual file. It reports whether it could.
+func (ts *Script) updateGolden(ename string, data []byte, exists bool) bool {
+	name, ok := ts.scriptFiles[ename]
+	if !ok {
+		rel, err := filepath.Rel(ts.workdir, ename)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		name = filepath.ToSlash(rel)
+	}
+	if exists {
+		ts.scriptUpdates[name] = string(data)
+	} else {
+		ts.scriptDeletes[name] = true
+	}
+	return true
+}
+
+// goldenFiles returns the sorted paths, relative to root, of the regular files under root.
+// If root is a file, it returns a single empty path.
+func (ts *Script) goldenFiles(root string) []string {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		return []string{""}
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	ts.Check(err)
+	return files
+}
+
+// unionStrings returns the sorted, de-duplicated union of a and b.
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool)
+	var all []string
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			all = append(all, s)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
@@ -0,0 +1,99 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpStat records the latency and response body size of the last http request.
+type httpStat struct {
+	latency time.Duration
+	size    int64
+}
+
+// httpstat checks the latency or body size of the last http request.
+func (ts *Script) cmdHttpstat(neg int, args []string) {
+	if neg < 0 {
+		ts.Fatalf("unsupported: ? httpstat")
+	}
+	if len(args) != 3 {
+		ts.Fatalf("usage: httpstat latency|size op value")
+	}
+	if ts.httpStat == nil {
+		ts.Fatalf("httpstat: no http request has been made")
+	}
+
+	name, op, value := args[0], args[1], args[2]
+	var got, want int64
+	var show func(int64) string
+	switch name {
+	case "latency":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			ts.Fatalf("httpstat: bad latency %q: %v", value, err)
+		}
+		got, want = int64(ts.httpStat.latency), int64(d)
+		show = func(n int64) string { return time.Duration(n).String() }
+	case "size":
+		n, err := parseSize(value)
+		if err != nil {
+			ts.Fatalf("httpstat: bad size %q: %v", value, err)
+		}
+		got, want = ts.httpStat.size, n
+		show = func(n int64) string { return strconv.FormatInt(n, 10) + "B" }
+	default:
+		ts.Fatalf("usage: httpstat latency|size op value")
+	}
+
+	ok, err := compareStat(got, op, want)
+	if err != nil {
+		ts.Fatalf("httpstat: %v", err)
+	}
+	if neg > 0 && ok {
+		ts.Fatalf("unexpected httpstat match: %s %s %s %s", name, show(got), op, value)
+	}
+	if neg == 0 && !ok {
+		ts.Fatalf("httpstat %s %s %s failed: got %s", name, op, value, show(got))
+	}
+}
+
+// compareStat reports whether got op want holds.
+func compareStat(got int64, op string, want int64) (bool, error) {
+	switch op {
+	case "<":
+		return got < want, nil
+	case "<=":
+		return got <= want, nil
+	case ">":
+		return got > want, nil
+	case ">=":
+		return got >= want, nil
+	case "==":
+		return got == want, nil
+	case "!=":
+		return got != want, nil
+	}
+	return false, fmt.Errorf("unknown comparison %q, want one of < <= > >= == !=", op)
+}
+
+// parseSize parses a byte count with an optional B, KB, or MB suffix,
+// where a KB is 1024 bytes.
+func parseSize(s string) (int64, error) {
+	mult := int64(1)
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.HasSuffix(upper, "KB"):
+		mult, s = 1<<10, s[:len(s)-2]
+	case strings.HasSuffix(upper, "MB"):
+		mult, s = 1<<20, s[:len(s)-2]
+	case strings.HasSuffix(upper, "B"):
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
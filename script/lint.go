@@ -0,0 +1,276 @@
+package script
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+)
+
+// A LintError is a problem found in a script without running it.
+type LintError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *LintError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Lint checks the scripts matched by p without running them, reporting
+// unknown commands, malformed or unknown conditions, bad quoting,
+// arguments rejected by registered commands, and referenced files which
+// cannot be read. Scripts which are not selected by tags are not checked.
+func Lint(p Params) ([]*LintError, error) {
+	p = paramDefaults(p)
+	files, err := filepath.Glob(filepath.Join(p.Dir, p.Glob))
+	if err != nil {
+		return nil, err
+	}
+	var problems []*LintError
+	for _, file := range files {
+		if ok, err := selectedByTags(file, p); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		problems = append(problems, lintScript(file, p)...)
+	}
+	return problems, nil
+}
+
+// lintT is the T of a script being linted. It is never expected to be used,
+// since Fatalf reports a LintError instead of failing.
+type lintT struct{}
+
+func (lintT) Skip(...interface{})  {}
+func (lintT) Fatal(...interface{}) {}
+func (lintT) Parallel()            {}
+func (lintT) Log(...interface{})   {}
+func (lintT) FailNow()             {}
+func (lintT) Run(string, func(T))  {}
+func (lintT) Verbose() bool        { return false }
+
+// lintScript checks the script in file, continuing past each problem.
+func lintScript(file string, p Params) (problems []*LintError) {
+	name := strings.TrimSuffix(filepath.Base(file), ".txt")
+	ts := newScript(lintT{}, "", name, file, p)
+	ts.linting = true
+	ts.workdir = "$WORK"
+	ts.cd = ts.workdir
+	ts.lintEnv()
+
+	// check runs f, recording the problem it reports, if any.
+	check := func(f func()) {
+		defer func() {
+			if e := recover(); e != nil {
+				le, ok := e.(*LintError)
+				if !ok {
+					panic(e)
+				}
+				problems = append(problems, le)
+			}
+		}()
+		f()
+	}
+
+	// Files provided by the archive, and its includes, and created by cp.
+	provided := map[string]bool{"stdout": true, "stderr": true}
+	addFiles := func(files []txtar.File) {
+		for _, f := range files {
+			provided[ts.lintPath(f.Name)] = true
+		}
+	}
+	a, err := txtar.ParseFile(file)
+	if err != nil {
+		return []*LintError{{File: file, Msg: err.Error()}}
+	}
+	addFiles(a.Files)
+
+	// Included archives are extracted before the script runs, so collect
+	// their files first. Prelude lines are reported as line 0, and the
+	// lines of an included script as the line which includes it.
+	var prelude []string
+	seen := map[string]bool{file: true}
+	for _, name := range p.Prelude {
+		check(func() {
+			text, files := ts.include(name, seen)
+			prelude = append(prelude, strings.Split(text, "\n")...)
+			addFiles(files)
+		})
+	}
+	lines := strings.Split(string(a.Comment), "\n")
+	included := make(map[int][]string)
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "include" {
+			ts.lineno = i + 1
+			included[i] = nil
+			check(func() {
+				name := fields[1]
+				if !filepath.IsAbs(name) {
+					name = filepath.Join(filepath.Dir(file), name)
+				}
+				text, files := ts.include(name, seen)
+				included[i] = strings.Split(text, "\n")
+				addFiles(files)
+			})
+		}
+	}
+
+	lintLines := func(lines []string) {
+		for _, line := range lines {
+			if strings.HasPrefix(line, p.PhasePrefix) || strings.HasPrefix(line, p.CommentPrefix) {
+				continue
+			}
+			check(func() { ts.lintLine(line, provided) })
+		}
+	}
+	ts.lineno = 0
+	lintLines(prelude)
+	for i, line := range lines {
+		ts.lineno = i + 1
+		if inc, ok := included[i]; ok {
+			lintLines(inc)
+		} else {
+			lintLines([]string{line})
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// lintEnv sets up an environment like that of a running script,
+// so that references to the usual variables expand.
+func (ts *Script) lintEnv() {
+	ts.env = append(os.Environ(),
+		"WORK="+ts.workdir,
+		homeEnvName()+"=/no-home",
+		tempEnvName()+"="+filepath.Join(ts.workdir, "tmp"),
+		"devnull="+os.DevNull,
+		"/="+string(os.PathSeparator),
+		":="+string(os.PathListSeparator),
+	)
+	if runtime.GOOS == "windows" {
+		ts.env = append(ts.env, "exe=.exe")
+	} else {
+		ts.env = append(ts.env, "exe=")
+	}
+	ts.envMap = make(map[string]string)
+	for _, kv := range ts.env {
+		if i := strings.Index(kv, "="); i >= 0 {
+			ts.envMap[envvarname(kv[:i])] = kv[i+1:]
+		}
+	}
+}
+
+// lintLine checks a single script line, reporting the first problem with it.
+func (ts *Script) lintLine(line string, provided map[string]bool) {
+	args := ts.parse(line)
+	if len(args) == 0 {
+		return
+	}
+
+	for strings.HasPrefix(args[0], "[") && strings.HasSuffix(args[0], "]") {
+		cond := strings.TrimSpace(args[0][1 : len(args[0])-1])
+		args = args[1:]
+		if len(args) == 0 {
+			ts.Fatalf("missing command after condition")
+		}
+		cond = strings.TrimSpace(strings.TrimPrefix(cond, "!"))
+		if cond == "" {
+			ts.Fatalf("empty condition")
+		}
+		if _, err := ts.condition(cond); err != nil {
+			ts.Fatalf("bad condition %q: %v", cond, err)
+		}
+	}
+
+	neg := 0
+	switch args[0] {
+	case "!":
+		neg = 1
+	case "?":
+		neg = -1
+	}
+	if neg != 0 {
+		if len(args) == 1 {
+			ts.Fatalf("%s on line by itself", args[0])
+		}
+		args = args[1:]
+	}
+
+	if _, ok := scriptCmds[args[0]]; ok {
+		ts.lintBuiltin(args, provided)
+		return
+	}
+	if _, ok := ts.params.Cmds[args[0]]; ok {
+		return
+	}
+	if c, ok := lookupPluginCmd(args[0]); ok {
+		if err := c.Check(neg, args[1:]); err != nil {
+			ts.Fatalf("%v\nusage: %s", err, c.Usage())
+		}
+		return
+	}
+	ts.Fatalf("unknown command %q", args[0])
+}
+
+// lintPath returns the path of name, relative to the current directory
+// of the script, as it would be in $WORK.
+func (ts *Script) lintPath(name string) string {
+	if name == "stdout" || name == "stderr" || filepath.IsAbs(name) || strings.HasPrefix(name, ts.workdir) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(ts.cd, name)
+}
+
+// lintBuiltin follows the builtin commands which affect later lines,
+// and checks that the expected files they read are provided.
+// Files outside of $WORK are not checked.
+func (ts *Script) lintBuiltin(args []string, provided map[string]bool) {
+	want := func(name string) {
+		name = ts.lintPath(name)
+		if !provided[name] && strings.HasPrefix(name, ts.workdir) {
+			ts.Fatalf("%s: %s is not in the archive", args[0], strings.TrimPrefix(name, ts.workdir+string(filepath.Separator)))
+		}
+	}
+	switch args[0] {
+	case "port":
+		// Conditions like [port-free:$PORT] need a port to check.
+		if len(args) > 1 {
+			ts.Setenv(args[1], "0")
+		}
+	case "cd":
+		if len(args) == 2 {
+			ts.cd = ts.lintPath(args[1])
+		}
+	case "env":
+		for _, kv := range args[1:] {
+			if i := strings.Index(kv, "="); i > 0 {
+				ts.Setenv(kv[:i], kv[i+1:])
+			}
+		}
+	case "cp":
+		if len(args) == 3 {
+			provided[ts.lintPath(args[2])] = true
+		} else if len(args) > 3 {
+			dir := ts.lintPath(args[len(args)-1])
+			for _, src := range args[1 : len(args)-1] {
+				provided[filepath.Join(dir, filepath.Base(src))] = true
+			}
+		}
+	case "cmp", "cmpenv":
+		if len(args) == 3 {
+			want(args[2])
+		}
+	case "stdin":
+		if len(args) == 2 {
+			want(args[1])
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// messageTimeout is how long nats and kafka wait for messages by default.
+const messageTimeout = 10 * time.Second
+
+// messageFlags parses the leading -timeout flag of nats and kafka.
+func (ts *Script) messageFlags(args []string) (time.Duration, []string) {
+	timeout := messageTimeout
+	if len(args) >= 2 && args[0] == "-timeout" {
+		d, err := time.ParseDuration(args[1])
+		if err != nil {
+			ts.Fatalf("bad timeout %q: %v", args[1], err)
+		}
+		timeout, args = d, args[2:]
+	}
+	return timeout, args
+}
+
+// messageCount parses the optional message count argument.
+func (ts *Script) messageCount(args []string, i int) int {
+	if len(args) <= i {
+		return 1
+	}
+	n, err := strconv.Atoi(args[i])
+	if err != nil || n < 1 {
+		ts.Fatalf("bad message count %q", args[i])
+	}
+	return n
+}
+
+// checkMessages reports the result of a command which receives messages,
+// which are put on stdout one per line.
+func (ts *Script) checkMessages(neg int, name string, msgs []string, err error) {
+	ts.stdout, ts.stderr = "", ""
+	if len(msgs) > 0 {
+		ts.stdout = strings.Join(msgs, "\n") + "\n"
+		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
+	}
+	if err == nil && neg > 0 {
+		ts.Fatalf("unexpected %s success", name)
+	}
+	if err != nil {
+		ts.stderr = err.Error() + "\n"
+		fmt.Fprintf(&ts.log, "[%v]\n", err)
+		if ts.ctxt.Err() != nil {
+			ts.Fatalf("script stopped while running %s: %v", name, ts.ctxt.Err())
+		} else if neg == 0 {
+			ts.Fatalf("unexpected %s failure", name)
+		}
+	}
+}
+
+// nats publishes and receives messages with a NATS server.
+func (ts *Script) cmdNats(neg int, args []string) {
+	const usage = "usage: nats [-timeout d] sub subject | pub subject message | recv subject [count]"
+	timeout, args := ts.messageFlags(args)
+	if len(args) < 2 {
+		ts.Fatalf(usage)
+	}
+	sub, subject := args[0], args[1]
+
+	var msgs []string
+	var err error
+	switch {
+	case sub == "sub" && len(args) == 2:
+		err = ts.natsConn(timeout).subscribe(subject, timeout)
+	case sub == "pub" && len(args) == 3:
+		err = ts.natsConn(timeout).publish(subject, args[2], timeout)
+	case sub == "recv" && (len(args) == 2 || len(args) == 3):
+		msgs, err = ts.natsConn(timeout).receive(ts, subject, ts.messageCount(args, 2), timeout)
+	default:
+		ts.Fatalf(usage)
+	}
+	ts.checkMessages(neg, "nats "+sub, msgs, err)
+}
+
+// natsConn returns the connection of the script to the server in $NATS_URL,
+// connecting on first use.
+func (ts *Script) natsConn(timeout time.Duration) *natsClient {
+	if ts.nats != nil {
+		return ts.nats
+	}
+	addr := ts.Getenv("NATS_URL")
+	if addr == "" {
+		addr = "nats://127.0.0.1:4222"
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		ts.Fatalf("bad NATS_URL %q: %v", addr, err)
+	}
+	if ts.params.DenyNetwork && !isLoopback(u.Hostname()) {
+		ts.Fatalf("%s: %v", addr, errNetworkDenied)
+	}
+	c, err := dialNats(u, timeout)
+	if err != nil {
+		ts.Fatalf("nats: %v", err)
+	}
+	ts.nats = c
+	ts.Defer(c.close)
+	return c
+}
+
+// natsClient speaks enough of the NATS client protocol to publish
+// and subscribe. Messages are buffered by subject as they arrive.
+type natsClient struct {
+	conn net.Conn
+	w    *bufio.Writer
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	sids  map[string]string   // subscription ids, by subject
+	subs  map[string]string   // subscribed subjects, by id
+	msgs  map[string][]string // received messages, by subscribed subject
+	pongs int
+	err   error // set when reading fails
+}
+
+func dialNats(u *url.URL, timeout time.Duration) (*natsClient, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("%s: expected INFO from server, got %q (%v)", host, line, err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	opts := map[string]interface{}{"verbose": false, "pedantic": false, "name": "hof-script"}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"], opts["pass"] = u.User.Username(), pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	data, _ := json.Marshal(opts)
+
+	c := &natsClient{
+		conn: conn,
+		w:    bufio.NewWriter(conn),
+		sids: map[string]string{},
+		subs: map[string]string{},
+		msgs: map[string][]string{},
+	}
+	c.cond = sync.NewCond(&c.mu)
+	go c.read(r)
+	fmt.Fprintf(c.w, "CONNECT %s\r\n", data)
+	if err := c.flush(timeout); err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// read handles the messages from the server until the connection ends.
+func (c *natsClient) read(r *bufio.Reader) {
+	fail := func(err error) {
+		c.mu.Lock()
+		if c.err == nil {
+			c.err = err
+		}
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			fail(err)
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch op := strings.Fields(line); {
+		case len(op) == 0:
+		case op[0] == "PING":
+			c.mu.Lock()
+			c.w.WriteString("PONG\r\n")
+			c.w.Flush()
+			c.mu.Unlock()
+		case op[0] == "PONG":
+			c.mu.Lock()
+			c.pongs++
+			c.cond.Broadcast()
+			c.mu.Unlock()
+		case op[0] == "-ERR":
+			fail(fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+		case op[0] == "MSG" && len(op) >= 4:
+			// MSG subject sid [reply-to] size
+			// The subscribed subject may be a wildcard, so use the sid.
+			size, err := strconv.Atoi(op[len(op)-1])
+			if err != nil {
+				fail(fmt.Errorf("bad message from server: %q", line))
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				fail(err)
+				return
+			}
+			c.mu.Lock()
+			subject := c.subs[op[2]]
+			c.msgs[subject] = append(c.msgs[subject], string(payload[:size]))
+			c.cond.Broadcast()
+			c.mu.Unlock()
+		}
+	}
+}
+
+// flush sends a PING and waits for its PONG, so that everything sent
+// before it has been processed by the server.
+func (c *natsClient) flush(timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := c.pongs + 1
+	c.w.WriteString("PING\r\n")
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	return c.waitLocked(timeout, func() bool { return c.pongs >= want })
+}
+
+// waitLocked waits until done reports true, reading fails, or the timeout passes.
+func (c *natsClient) waitLocked(timeout time.Duration, done func() bool) error {
+	timer := time.AfterFunc(timeout, func() {
+		c.mu.Lock()
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	})
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+	for !done() {
+		if c.err != nil {
+			return c.err
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("timed out after %v", timeout)
+		}
+		c.cond.Wait()
+	}
+	return nil
+}
+
+func (c *natsClient) subscribe(subject string, timeout time.Duration) error {
+	c.mu.Lock()
+	if _, ok := c.sids[subject]; ok {
+		c.mu.Unlock()
+		return fmt.Errorf("already subscribed to %s", subject)
+	}
+	sid := strconv.Itoa(len(c.sids) + 1)
+	c.sids[subject], c.subs[sid] = sid, subject
+	fmt.Fprintf(c.w, "SUB %s %s\r\n", subject, sid)
+	c.mu.Unlock()
+	return c.flush(timeout)
+}
+
+func (c *natsClient) publish(subject, msg string, timeout time.Duration) error {
+	c.mu.Lock()
+	fmt.Fprintf(c.w, "PUB %s %d\r\n%s\r\n", subject, len(msg), msg)
+	c.mu.Unlock()
+	return c.flush(timeout)
+}
+
+// receive waits for count messages on the subscription to subject,
+// returning and removing them from the buffer.
+func (c *natsClient) receive(ts *Script, subject string, count int, timeout time.Duration) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.sids[subject]; !ok {
+		ts.Fatalf("nats recv: not subscribed to %s, use nats sub first", subject)
+	}
+	err := c.waitLocked(timeout, func() bool { return len(c.msgs[subject]) >= count })
+	msgs := c.msgs[subject]
+	if len(msgs) > count {
+		msgs = msgs[:count]
+	}
+	c.msgs[subject] = c.msgs[subject][len(msgs):]
+	if err != nil {
+		err = fmt.Errorf("received %d of %d messages on %s: %v", len(msgs), count, subject, err)
+	}
+	return msgs, err
+}
+
+func (c *natsClient) close() {
+	c.conn.Close()
+}
+
+// kafka produces and consumes messages through a Kafka REST Proxy,
+// such as the Confluent REST Proxy or the Redpanda HTTP Proxy.
+func (ts *Script) cmdKafka(neg int, args []string) {
+	const usage = "usage: kafka [-timeout d] produce topic message | consume topic [count]"
+	timeout, args := ts.messageFlags(args)
+	if len(args) < 2 {
+		ts.Fatalf(usage)
+	}
+	sub, topic := args[0], args[1]
+
+	base := ts.Getenv("KAFKA_REST_URL")
+	if base == "" {
+		base = "http://127.0.0.1:8082"
+	}
+	k := &kafkaREST{base: strings.TrimSuffix(base, "/"), ts: ts}
+	if u, err := url.Parse(base); err != nil {
+		ts.Fatalf("bad KAFKA_REST_URL %q: %v", base, err)
+	} else if ts.params.DenyNetwork && !isLoopback(u.Hostname()) {
+		ts.Fatalf("%s: %v", base, errNetworkDenied)
+	}
+
+	var msgs []string
+	var err error
+	switch {
+	case sub == "produce" && len(args) == 3:
+		err = k.produce(topic, args[2])
+	case sub == "consume" && (len(args) == 2 || len(args) == 3):
+		msgs, err = k.consume(topic, ts.messageCount(args, 2), timeout)
+	default:
+		ts.Fatalf(usage)
+	}
+	ts.checkMessages(neg, "kafka "+sub, msgs, err)
+}
+
+const kafkaJSON = "application/vnd.kafka.json.v2+json"
+
+type kafkaREST struct {
+	base string
+	ts   *Script
+}
+
+// do makes a request to the proxy, decoding the JSON response into out if not nil.
+func (k *kafkaREST) do(method, u string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		body, _ = json.Marshal(in)
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(k.ts.ctxt)
+	if in != nil {
+		req.Header.Set("Content-Type", kafkaJSON)
+	}
+	req.Header.Set("Accept", kafkaJSON)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	k.ts.setStatus(resp.StatusCode)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s\n%s", method, u, resp.Status, bytes.TrimSpace(data))
+	}
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func (k *kafkaREST) produce(topic, msg string) error {
+	// Messages which are JSON are sent as is, others as strings.
+	var value interface{} = msg
+	if json.Valid([]byte(msg)) {
+		value = json.RawMessage(msg)
+	}
+	records := map[string]interface{}{"records": []interface{}{map[string]interface{}{"value": value}}}
+	return k.do("POST", k.base+"/topics/"+url.PathEscape(topic), records, nil)
+}
+
+// consume reads count messages from the start of topic with a new consumer group,
+// so that messages produced before it are seen.
+func (k *kafkaREST) consume(topic string, count int, timeout time.Duration) ([]string, error) {
+	group := fmt.Sprintf("hof-script-%d", time.Now().UnixNano())
+	var consumer struct {
+		BaseURI string `json:"base_uri"`
+	}
+	err := k.do("POST", k.base+"/consumers/"+group, map[string]string{
+		"format":            "json",
+		"auto.offset.reset": "earliest",
+	}, &consumer)
+	if err != nil {
+		return nil, err
+	}
+	defer k.do("DELETE", consumer.BaseURI, nil, nil)
+	if err := k.do("POST", consumer.BaseURI+"/subscription", map[string][]string{"topics": {topic}}, nil); err != nil {
+		return nil, err
+	}
+
+	var msgs []string
+	deadline := time.Now().Add(timeout)
+	for len(msgs) < count {
+		var records []struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := k.do("GET", consumer.BaseURI+"/records", nil, &records); err != nil {
+			return msgs, err
+		}
+		for _, r := range records {
+			var s string
+			if json.Unmarshal(r.Value, &s) == nil {
+				msgs = append(msgs, s)
+			} else {
+				msgs = append(msgs, string(r.Value))
+			}
+		}
+		if len(msgs) >= count {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return msgs, fmt.Errorf("received %d of %d messages on %s: timed out after %v", len(msgs), count, topic, timeout)
+		}
+		select {
+		case <-k.ts.ctxt.Done():
+			return msgs, k.ts.ctxt.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return msgs[:count], nil
+}
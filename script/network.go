@@ -0,0 +1,96 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// errNetworkDenied is the error for network access blocked by Params.DenyNetwork.
+var errNetworkDenied = fmt.Errorf("network access denied by Params.DenyNetwork")
+
+// denyProxy is an HTTP proxy which refuses every request,
+// so that exec'd programs which honor the proxy variables fail fast.
+type denyProxy struct {
+	srv *http.Server
+	url string
+}
+
+func startDenyProxy() (*denyProxy, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, errNetworkDenied.Error(), http.StatusForbidden)
+		}),
+	}
+	go srv.Serve(l)
+	return &denyProxy{srv: srv, url: "http://" + l.Addr().String()}, nil
+}
+
+func (p *denyProxy) close() {
+	p.srv.Close()
+}
+
+// vars returns the environment which routes traffic through the proxy.
+// Loopback addresses are exempt, so scripts can still talk to local servers.
+func (p *denyProxy) vars() []string {
+	const local = "localhost,127.0.0.1,::1"
+	return []string{
+		"HTTP_PROXY=" + p.url,
+		"HTTPS_PROXY=" + p.url,
+		"ALL_PROXY=" + p.url,
+		"http_proxy=" + p.url,
+		"https_proxy=" + p.url,
+		"all_proxy=" + p.url,
+		"NO_PROXY=" + local,
+		"no_proxy=" + local,
+		"GOPROXY=off",
+	}
+}
+
+// isLoopback reports whether host names the local machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// denyRequest intercepts the request when the network is denied,
+// failing it if it is for a remote host and refusing remote dials,
+// which also covers redirects.
+func (ts *Script) denyRequest(req *gorequest.SuperAgent) error {
+	if !ts.params.DenyNetwork {
+		return nil
+	}
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return err
+	}
+	if !isLoopback(u.Hostname()) {
+		return fmt.Errorf("%s: %v", req.Url, errNetworkDenied)
+	}
+	if req.Transport != nil {
+		dialer := &net.Dialer{}
+		req.Transport.Proxy = nil
+		req.Transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			if !isLoopback(host) {
+				return nil, fmt.Errorf("%s: %v", addr, errNetworkDenied)
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package script
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Cmd is a script command which can be shipped in a reusable package,
+// such as a set of commands for working with a database or cloud provider.
+type Cmd interface {
+	// Usage returns the usage line, like "db-query [-json] query".
+	Usage() string
+
+	// Help returns a short description of the command.
+	Help() string
+
+	// Check validates the arguments before the command is run.
+	// The script fails with the error and the usage line if it is not nil.
+	Check(neg int, args []string) error
+
+	// Run runs the command, like the functions in Params.Cmds.
+	Run(ts *Script, neg int, args []string)
+}
+
+var (
+	pluginCmdsMu sync.RWMutex
+	pluginCmds   = map[string]Cmd{}
+)
+
+// RegisterCommands makes the commands available to all scripts.
+// Commands in Params.Cmds take precedence over registered ones.
+// It panics if a name is already registered or is a builtin command.
+func RegisterCommands(cmds map[string]Cmd) {
+	pluginCmdsMu.Lock()
+	defer pluginCmdsMu.Unlock()
+	for name, c := range cmds {
+		if name == "" || strings.ContainsAny(name, " \t!?") {
+			panic(fmt.Sprintf("script: invalid command name %q", name))
+		}
+		if _, ok := scriptCmds[name]; ok {
+			panic(fmt.Sprintf("script: command %q is builtin", name))
+		}
+		if _, ok := pluginCmds[name]; ok {
+			panic(fmt.Sprintf("script: command %q already registered", name))
+		}
+		pluginCmds[name] = c
+	}
+}
+
+// CmdFunc adapts c for use in Params.Cmds, checking its arguments before running it.
+func CmdFunc(c Cmd) func(ts *Script, neg int, args []string) {
+	return func(ts *Script, neg int, args []string) {
+		if err := c.Check(neg, args); err != nil {
+			ts.Fatalf("%v\nusage: %s", err, c.Usage())
+		}
+		c.Run(ts, neg, args)
+	}
+}
+
+// lookupPluginCmd returns the registered command with the given name.
+func lookupPluginCmd(name string) (Cmd, bool) {
+	pluginCmdsMu.RLock()
+	defer pluginCmdsMu.RUnlock()
+	c, ok := pluginCmds[name]
+	return c, ok
+}
+
+// help prints the usage and help of registered commands.
+func (ts *Script) cmdHelp(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? help")
+	}
+
+	names := args
+	if len(names) == 0 {
+		pluginCmdsMu.RLock()
+		for name := range pluginCmds {
+			names = append(names, name)
+		}
+		pluginCmdsMu.RUnlock()
+		sort.Strings(names)
+	}
+
+	var buf strings.Builder
+	for _, name := range names {
+		c, ok := lookupPluginCmd(name)
+		if !ok {
+			ts.Fatalf("no help for command %q", name)
+		}
+		fmt.Fprintf(&buf, "%s\n", c.Usage())
+		for _, line := range strings.Split(strings.TrimSpace(c.Help()), "\n") {
+			fmt.Fprintf(&buf, "\t%s\n", line)
+		}
+	}
+	ts.SetOutput(buf.String(), "")
+}
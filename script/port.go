@@ -0,0 +1,45 @@
+package script
+
+import (
+	"net"
+	"sync"
+)
+
+var (
+	portsMu sync.Mutex
+	// ports handed out to any script in this process,
+	// so that scripts running in parallel do not collide.
+	portsUsed = map[int]bool{}
+)
+
+// freePorts returns count distinct TCP ports which were free on localhost.
+// The listeners are held open until all ports have been found.
+func freePorts(count int) ([]int, error) {
+	portsMu.Lock()
+	defer portsMu.Unlock()
+
+	var ports []int
+	var lns []net.Listener
+	defer func() {
+		for _, ln := range lns {
+			ln.Close()
+		}
+	}()
+
+	for len(ports) < count {
+		ln, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		lns = append(lns, ln)
+
+		p := ln.Addr().(*net.TCPAddr).Port
+		if portsUsed[p] {
+			continue
+		}
+		portsUsed[p] = true
+		ports = append(ports, p)
+	}
+
+	return ports, nil
+}
@@ -0,0 +1,231 @@
+package script
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ptyDefaultTimeout = 10 * time.Second
+
+// ptySession is an interactive process attached to a pseudo-terminal.
+type ptySession struct {
+	cmd    *exec.Cmd
+	master *os.File
+	done   chan struct{} // closed when the reader hits EOF
+	err    error         // from cmd.Wait
+
+	mu     sync.Mutex
+	output []byte // everything read from the terminal so far
+	mark   int    // offset of the end of the last expect match
+}
+
+func (p *ptySession) read() {
+	defer close(p.done)
+	buf := make([]byte, 4096)
+	for {
+		n, err := p.master.Read(buf)
+		if n > 0 {
+			p.mu.Lock()
+			p.output = append(p.output, buf[:n]...)
+			p.mu.Unlock()
+		}
+		if err != nil {
+			// Linux returns EIO rather than EOF once the child side is closed.
+			return
+		}
+	}
+}
+
+// pty runs interactive programs in a pseudo-terminal.
+func (ts *Script) cmdPty(neg int, args []string) {
+	if len(args) < 1 {
+		ts.Fatalf("usage: pty spawn|expect|send|wait args...")
+	}
+	op, args := args[0], args[1:]
+	if op != "expect" && op != "wait" && neg != 0 {
+		ts.Fatalf("unsupported: !? pty %s", op)
+	}
+
+	if op != "spawn" && ts.pty == nil {
+		ts.Fatalf("pty %s: no pty process, use pty spawn first", op)
+	}
+
+	switch op {
+	case "spawn":
+		ts.ptySpawn(args)
+	case "expect":
+		ts.ptyExpect(neg, args)
+	case "send":
+		ts.ptySend(args)
+	case "wait":
+		if len(args) > 0 {
+			ts.Fatalf("usage: pty wait")
+		}
+		ts.ptyWait(neg)
+	default:
+		ts.Fatalf("usage: pty spawn|expect|send|wait args...")
+	}
+}
+
+func (ts *Script) ptySpawn(args []string) {
+	if len(args) < 1 {
+		ts.Fatalf("usage: pty spawn program [args...]")
+	}
+	if ts.pty != nil {
+		ts.Fatalf("pty spawn: a pty process is already running, use pty wait first")
+	}
+
+	cmd, err := ts.buildExecCmd(args[0], args[1:]...)
+	ts.Check(err)
+	cmd.Dir = ts.cd
+	cmd.Env = append(ts.env, "PWD="+ts.cd, "TERM=dumb")
+
+	master, err := ptyStart(cmd)
+	ts.Check(err)
+
+	p := &ptySession{
+		cmd:    cmd,
+		master: master,
+		done:   make(chan struct{}),
+	}
+	go p.read()
+	ts.pty = p
+
+	ts.Defer(func() {
+		if ts.pty == p {
+			p.cmd.Process.Kill()
+			p.cmd.Wait()
+			p.master.Close()
+		}
+	})
+}
+
+// ptyExpect waits for the terminal output since the last match to match a regexp.
+func (ts *Script) ptyExpect(neg int, args []string) {
+	timeout := ptyDefaultTimeout
+	if len(args) > 0 && strings.HasPrefix(args[0], "-timeout=") {
+		var err error
+		timeout, err = time.ParseDuration(strings.TrimPrefix(args[0], "-timeout="))
+		if err != nil {
+			ts.Fatalf("bad -timeout=: %v", err)
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		ts.Fatalf("usage: pty expect [-timeout=d] pattern")
+	}
+	re, err := regexp.Compile(`(?m)` + args[0])
+	ts.Check(err)
+
+	p := ts.pty
+	deadline := time.After(timeout)
+	for {
+		p.mu.Lock()
+		loc := re.FindIndex(p.output[p.mark:])
+		if loc != nil && neg == 0 {
+			p.mark += loc[1]
+		}
+		text := string(p.output[p.mark:])
+		p.mu.Unlock()
+
+		if loc != nil {
+			if neg > 0 {
+				ts.Fatalf("unexpected match for %#q found in pty output: %s", args[0], text[loc[0]:loc[1]])
+			}
+			return
+		}
+
+		select {
+		case <-p.done:
+			// Check once more for output read just before EOF.
+			p.mu.Lock()
+			loc = re.FindIndex(p.output[p.mark:])
+			p.mu.Unlock()
+			if loc == nil {
+				if neg > 0 {
+					return
+				}
+				ts.Logf("[pty]\n%s", text)
+				ts.Fatalf("no match for %#q found in pty output before process exited", args[0])
+			}
+		case <-deadline:
+			if neg > 0 {
+				return
+			}
+			ts.Logf("[pty]\n%s", text)
+			ts.Fatalf("timed out after %v waiting for %#q in pty output", timeout, args[0])
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// ptySend writes text followed by a newline to the terminal.
+// With -n the newline is omitted. Go escape sequences like \t and \x03 are interpreted.
+func (ts *Script) ptySend(args []string) {
+	newline := "\n"
+	if len(args) > 0 && args[0] == "-n" {
+		newline = ""
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		ts.Fatalf("usage: pty send [-n] text")
+	}
+	text, err := unescape(args[0])
+	if err != nil {
+		ts.Fatalf("bad pty send text %q: %v", args[0], err)
+	}
+	_, err = io.WriteString(ts.pty.master, text+newline)
+	ts.Check(err)
+}
+
+// ptyWait waits for the pty process to exit, setting stdout to the full terminal output.
+func (ts *Script) ptyWait(neg int) {
+	p := ts.pty
+	ts.pty = nil
+
+	werr := ctxWait(ts.ctxt, p.cmd)
+	<-p.done
+	p.master.Close()
+
+	ts.setStatus(p.cmd.ProcessState.ExitCode())
+	ts.stdout = string(p.output)
+	ts.stderr = ""
+	if ts.stdout != "" {
+		fmt.Fprintf(&ts.log, "[pty]\n%s", ts.stdout)
+	}
+	if werr == nil && neg > 0 {
+		ts.Fatalf("unexpected pty command success")
+	}
+	if werr != nil {
+		fmt.Fprintf(&ts.log, "[%v]\n", werr)
+		if ts.ctxt.Err() != nil {
+			ts.Fatalf("script stopped while running pty command: %v", ts.ctxt.Err())
+		} else if neg == 0 {
+			ts.Fatalf("unexpected pty command failure")
+		}
+	}
+}
+
+// unescape interprets Go escape sequences in s.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var buf strings.Builder
+	for len(s) > 0 {
+		r, _, tail, err := strconv.UnquoteChar(s, 0)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteRune(r)
+		s = tail
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package script
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+func ptyStart(cmd *exec.Cmd) (*os.File, error) {
+	return nil, fmt.Errorf("pty is not supported on %s", runtime.GOOS)
+}
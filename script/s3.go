@@ -0,0 +1,288 @@
+package script
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// objectStore describes how to reach an S3 compatible object store.
+type objectStore struct {
+	name     string // command name, for messages
+	endpoint string
+	region   string
+	keyID    string
+	secret   string
+	token    string
+}
+
+// s3 manages objects in S3, or an S3 compatible store such as MinIO.
+func (ts *Script) cmdS3(neg int, args []string) {
+	region := ts.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := ts.Getenv("S3_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	ts.objectCmd(neg, args, &objectStore{
+		name:     "s3",
+		endpoint: endpoint,
+		region:   region,
+		keyID:    ts.Getenv("AWS_ACCESS_KEY_ID"),
+		secret:   ts.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:    ts.Getenv("AWS_SESSION_TOKEN"),
+	})
+}
+
+// gcs manages objects in Google Cloud Storage, through its S3 compatible
+// XML API with HMAC keys.
+func (ts *Script) cmdGcs(neg int, args []string) {
+	endpoint := ts.Getenv("GCS_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	ts.objectCmd(neg, args, &objectStore{
+		name:     "gcs",
+		endpoint: endpoint,
+		region:   "auto",
+		keyID:    ts.Getenv("GCS_HMAC_ACCESS_ID"),
+		secret:   ts.Getenv("GCS_HMAC_SECRET"),
+	})
+}
+
+// objectCmd runs the put, get, ls, or rm subcommand of s3 or gcs.
+func (ts *Script) objectCmd(neg int, args []string, store *objectStore) {
+	usage := "usage: " + store.name + " [-endpoint url] put|get|ls|rm bucket[/key] [file]"
+	if len(args) >= 2 && args[0] == "-endpoint" {
+		store.endpoint, args = args[1], args[2:]
+	}
+	if len(args) < 2 {
+		ts.Fatalf(usage)
+	}
+	sub, bucket, key := args[0], args[1], ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, key = bucket[:i], bucket[i+1:]
+	}
+
+	var err error
+	ts.stdout, ts.stderr = "", ""
+	switch {
+	case sub == "put" && len(args) == 3 && key != "":
+		var data []byte
+		if data, err = ioutil.ReadFile(ts.MkAbs(args[2])); err == nil {
+			_, err = ts.objectRequest(store, "PUT", bucket, key, nil, data)
+		}
+	case sub == "get" && (len(args) == 2 || len(args) == 3) && key != "":
+		var data []byte
+		if data, err = ts.objectRequest(store, "GET", bucket, key, nil, nil); err == nil {
+			if len(args) == 3 {
+				err = ioutil.WriteFile(ts.MkAbs(args[2]), data, 0666)
+			} else {
+				ts.stdout = string(data)
+			}
+		}
+	case sub == "ls" && len(args) == 2:
+		var keys []string
+		if keys, err = ts.listObjects(store, bucket, key); err == nil && len(keys) > 0 {
+			ts.stdout = strings.Join(keys, "\n") + "\n"
+		}
+	case sub == "rm" && len(args) == 2 && key != "":
+		_, err = ts.objectRequest(store, "DELETE", bucket, key, nil, nil)
+	default:
+		ts.Fatalf(usage)
+	}
+
+	if ts.stdout != "" {
+		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
+	}
+	if err == nil && neg > 0 {
+		ts.Fatalf("unexpected %s %s success", store.name, sub)
+	}
+	if err != nil {
+		ts.stderr = err.Error() + "\n"
+		fmt.Fprintf(&ts.log, "[%v]\n", err)
+		if ts.ctxt.Err() != nil {
+			ts.Fatalf("script stopped while running %s: %v", store.name, ts.ctxt.Err())
+		} else if neg == 0 {
+			ts.Fatalf("unexpected %s %s failure", store.name, sub)
+		}
+	}
+}
+
+// listBucketResult is the part of a ListObjectsV2 response which is used.
+type listBucketResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// listObjects returns the keys in bucket starting with prefix, in order.
+func (ts *Script) listObjects(store *objectStore, bucket, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		data, err := ts.objectRequest(store, "GET", bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var res listBucketResult
+		if err := xml.Unmarshal(data, &res); err != nil {
+			return nil, fmt.Errorf("listing %s: %v", bucket, err)
+		}
+		for _, c := range res.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return keys, nil
+		}
+		query.Set("continuation-token", res.NextContinuationToken)
+	}
+}
+
+// objectRequest makes a signed, path style request for the object key in
+// bucket, or for the bucket itself when key is empty, and returns the body
+// of a successful response.
+func (ts *Script) objectRequest(store *objectStore, method, bucket, key string, query url.Values, body []byte) ([]byte, error) {
+	path := "/" + bucket
+	if key != "" {
+		path += "/" + uriEncode(key, false)
+	}
+	u, err := url.Parse(strings.TrimSuffix(store.endpoint, "/") + path)
+	if err != nil {
+		return nil, err
+	}
+	if ts.params.DenyNetwork && !isLoopback(u.Hostname()) {
+		return nil, fmt.Errorf("%s: %v", store.endpoint, errNetworkDenied)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ts.ctxt)
+	if store.keyID != "" {
+		signV4(req, body, store, time.Now())
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	ts.setStatus(resp.StatusCode)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s\n%s", method, path, resp.Status, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
+
+// signV4 signs req with AWS Signature Version 4, as accepted by S3,
+// MinIO, and Cloud Storage with HMAC keys.
+func signV4(req *http.Request, body []byte, store *objectStore, now time.Time) {
+	now = now.UTC()
+	date, stamp := now.Format("20060102"), now.Format("20060102T150405Z")
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if store.token != "" {
+		req.Header.Set("X-Amz-Security-Token", store.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		fmt.Fprintf(&canonHeaders, "%s:%s\n", k, headers[k])
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + store.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+store.secret), date)
+	key = hmacSHA256(key, store.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.keyID, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key, as Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	var keys []string
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes everything but unreserved characters, and slashes
+// unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,438 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	cueerrors "cuelang.org/go/cue/errors"
+)
+
+// schema validates a document against a JSON Schema or CUE schema.
+func (ts *Script) cmdSchema(neg int, args []string) {
+	if neg < 0 {
+		ts.Fatalf("unsupported: ? schema")
+	}
+	if len(args) != 2 {
+		ts.Fatalf("usage: schema schema.json|schema.cue stdout|stderr|file")
+	}
+
+	schemaFile, name := args[0], args[1]
+	schema := ts.ReadFile(schemaFile)
+	doc := ts.ReadFile(name)
+
+	var problems []string
+	var err error
+	if strings.HasSuffix(schemaFile, ".cue") {
+		problems, err = validateCUE(schemaFile, schema, doc)
+	} else {
+		problems, err = validateJSONSchema(schema, doc)
+	}
+	if err != nil {
+		ts.Fatalf("schema: %v", err)
+	}
+
+	if neg > 0 && len(problems) == 0 {
+		ts.Fatalf("unexpected schema match: %s is valid against %s", name, schemaFile)
+	}
+	if neg == 0 && len(problems) > 0 {
+		ts.Fatalf("%s does not match %s:\n\t%s", name, schemaFile, strings.Join(problems, "\n\t"))
+	}
+}
+
+// validateCUE unifies the JSON document with the CUE schema and returns
+// the constraints which failed, one per line.
+func validateCUE(schemaFile, schema, doc string) ([]string, error) {
+	var r cue.Runtime
+	si, err := r.Compile(schemaFile, schema)
+	if err != nil {
+		return nil, err
+	}
+	di, err := r.Compile("document", doc)
+	if err != nil {
+		return nil, fmt.Errorf("document: %v", err)
+	}
+
+	err = si.Value().Unify(di.Value()).Validate(cue.Concrete(true))
+	var problems []string
+	for _, e := range cueerrors.Errors(err) {
+		format, args := e.Msg()
+		problems = append(problems, fmt.Sprintf("%s: %s", cuePath(e.Path()), fmt.Sprintf(format, args...)))
+	}
+	return problems, nil
+}
+
+func cuePath(path []string) string {
+	if len(path) == 0 {
+		return "$"
+	}
+	return "$." + strings.Join(path, ".")
+}
+
+// validateJSONSchema validates the JSON document against the JSON Schema
+// and returns the constraints which failed, one per line.
+// It supports the commonly used keywords of draft 7, and $ref within the schema.
+func validateJSONSchema(schema, doc string) ([]string, error) {
+	var s, d interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return nil, fmt.Errorf("reading schema: %v", err)
+	}
+	if err := json.Unmarshal([]byte(doc), &d); err != nil {
+		return nil, fmt.Errorf("reading document: %v", err)
+	}
+	v := &jsonSchema{root: s}
+	v.validate(s, d, "$")
+	return v.problems, nil
+}
+
+// jsonSchema collects the problems found validating a document.
+type jsonSchema struct {
+	root     interface{}
+	problems []string
+	depth    int
+}
+
+func (v *jsonSchema) errorf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches reports whether doc is valid against schema, without recording problems.
+func (v *jsonSchema) matches(schema, doc interface{}) bool {
+	sub := &jsonSchema{root: v.root, depth: v.depth}
+	sub.validate(schema, doc, "")
+	return len(sub.problems) == 0
+}
+
+func (v *jsonSchema) validate(schema, doc interface{}, path string) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.errorf(path, "not allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObject(s, doc, path)
+	default:
+		v.errorf(path, "invalid schema %v", schema)
+	}
+}
+
+func (v *jsonSchema) validateObject(s map[string]interface{}, doc interface{}, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		if v.depth > 100 {
+			v.errorf(path, "$ref %s: too deeply nested", ref)
+			return
+		}
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.errorf(path, "%v", err)
+			return
+		}
+		v.depth++
+		v.validate(target, doc, path)
+		v.depth--
+		return
+	}
+
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, x := range t {
+				types = append(types, fmt.Sprint(x))
+			}
+		}
+		found := false
+		for _, typ := range types {
+			if isJSONType(doc, typ) {
+				found = true
+			}
+		}
+		if !found {
+			v.errorf(path, "expected %s, got %s", strings.Join(types, " or "), jsonType(doc))
+			return
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, doc) {
+				found = true
+			}
+		}
+		if !found {
+			v.errorf(path, "%s is not one of %s", jsonText(doc), jsonText(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, doc) {
+		v.errorf(path, "%s is not %s", jsonText(doc), jsonText(c))
+	}
+
+	switch d := doc.(type) {
+	case float64:
+		v.validateNumber(s, d, path)
+	case string:
+		v.validateString(s, d, path)
+	case []interface{}:
+		v.validateArray(s, d, path)
+	case map[string]interface{}:
+		v.validateProperties(s, d, path)
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, doc, path)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		found := false
+		for _, sub := range anyOf {
+			if v.matches(sub, doc) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.errorf(path, "does not match any schema in anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		n := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, doc) {
+				n++
+			}
+		}
+		if n != 1 {
+			v.errorf(path, "matches %d schemas in oneOf, want 1", n)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(not, doc) {
+		v.errorf(path, "matches the schema in not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.matches(cond, doc) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, doc, path)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(els, doc, path)
+		}
+	}
+}
+
+func (v *jsonSchema) validateNumber(s map[string]interface{}, d float64, path string) {
+	if n, ok := s["minimum"].(float64); ok && d < n {
+		v.errorf(path, "%v is less than minimum %v", d, n)
+	}
+	if n, ok := s["maximum"].(float64); ok && d > n {
+		v.errorf(path, "%v is greater than maximum %v", d, n)
+	}
+	if n, ok := s["exclusiveMinimum"].(float64); ok && d <= n {
+		v.errorf(path, "%v is not greater than exclusiveMinimum %v", d, n)
+	}
+	if n, ok := s["exclusiveMaximum"].(float64); ok && d >= n {
+		v.errorf(path, "%v is not less than exclusiveMaximum %v", d, n)
+	}
+	if n, ok := s["multipleOf"].(float64); ok && n > 0 {
+		if q := d / n; q != math.Trunc(q) {
+			v.errorf(path, "%v is not a multiple of %v", d, n)
+		}
+	}
+}
+
+func (v *jsonSchema) validateString(s map[string]interface{}, d string, path string) {
+	n := utf8.RuneCountInString(d)
+	if min, ok := s["minLength"].(float64); ok && n < int(min) {
+		v.errorf(path, "length %d is less than minLength %v", n, min)
+	}
+	if max, ok := s["maxLength"].(float64); ok && n > int(max) {
+		v.errorf(path, "length %d is greater than maxLength %v", n, max)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.errorf(path, "invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(d) {
+			v.errorf(path, "%q does not match pattern %q", d, pattern)
+		}
+	}
+}
+
+func (v *jsonSchema) validateArray(s map[string]interface{}, d []interface{}, path string) {
+	if min, ok := s["minItems"].(float64); ok && len(d) < int(min) {
+		v.errorf(path, "%d items is less than minItems %v", len(d), min)
+	}
+	if max, ok := s["maxItems"].(float64); ok && len(d) > int(max) {
+		v.errorf(path, "%d items is greater than maxItems %v", len(d), max)
+	}
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+		for i := range d {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(d[i], d[j]) {
+					v.errorf(path, "items %d and %d are equal", j, i)
+				}
+			}
+		}
+	}
+	switch items := s["items"].(type) {
+	case []interface{}:
+		for i, elem := range d {
+			if i < len(items) {
+				v.validate(items[i], elem, path+"["+strconv.Itoa(i)+"]")
+			} else if extra, ok := s["additionalItems"]; ok {
+				v.validate(extra, elem, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case nil:
+	default:
+		for i, elem := range d {
+			v.validate(items, elem, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+	if contains, ok := s["contains"]; ok {
+		found := false
+		for _, elem := range d {
+			if v.matches(contains, elem) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.errorf(path, "no item matches the schema in contains")
+		}
+	}
+}
+
+func (v *jsonSchema) validateProperties(s map[string]interface{}, d map[string]interface{}, path string) {
+	if min, ok := s["minProperties"].(float64); ok && len(d) < int(min) {
+		v.errorf(path, "%d properties is less than minProperties %v", len(d), min)
+	}
+	if max, ok := s["maxProperties"].(float64); ok && len(d) > int(max) {
+		v.errorf(path, "%d properties is greater than maxProperties %v", len(d), max)
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, ok := d[name]; !ok {
+					v.errorf(path, "missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	// Check properties in order so that problems are reported consistently.
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	props, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	for _, name := range names {
+		elem, elemPath := d[name], path+"."+name
+		if pn, ok := s["propertyNames"]; ok && !v.matches(pn, name) {
+			v.errorf(elemPath, "property name does not match propertyNames")
+		}
+		matched := false
+		if sub, ok := props[name]; ok {
+			matched = true
+			v.validate(sub, elem, elemPath)
+		}
+		for pattern, sub := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				v.errorf(path, "invalid pattern %q: %v", pattern, err)
+				continue
+			}
+			if re.MatchString(name) {
+				matched = true
+				v.validate(sub, elem, elemPath)
+			}
+		}
+		if additional, ok := s["additionalProperties"]; ok && !matched {
+			if b, ok := additional.(bool); ok && !b {
+				v.errorf(elemPath, "additional property not allowed")
+			} else {
+				v.validate(additional, elem, elemPath)
+			}
+		}
+	}
+}
+
+// resolve finds the schema referred to by a $ref within the root schema,
+// such as "#" or "#/definitions/item".
+func (v *jsonSchema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("$ref %s: only references within the schema are supported", ref)
+	}
+	cur := v.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return cur, nil
+	}
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			next, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("$ref %s: not found", ref)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("$ref %s: not found", ref)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("$ref %s: not found", ref)
+		}
+	}
+	return cur, nil
+}
+
+// isJSONType reports whether the decoded JSON value has the JSON Schema type.
+func isJSONType(doc interface{}, typ string) bool {
+	if typ == "integer" {
+		n, ok := doc.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return jsonType(doc) == typ
+}
+
+func jsonType(doc interface{}) string {
+	switch doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", doc)
+}
+
+func jsonText(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -10,18 +10,24 @@ package script
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
-	"sync/atomic"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -41,6 +47,10 @@ var execCache par.Cache
 // poke at the test file tree afterward.
 var testWork = flag.Bool("testwork", false, "")
 
+// If -run-tags is specified, only scripts selected by the comma separated
+// list of tags are run. It overrides Params.Tags, see there for the syntax.
+var runTags = flag.String("run-tags", "", "")
+
 // Env holds the environment to use at the start of a test script invocation.
 type Env struct {
 	// WorkDir holds the path to the root directory of the
@@ -94,6 +104,12 @@ func (e *Env) Setenv(key, value string) {
 	e.Vars = append(e.Vars, key+"="+value)
 }
 
+// Mask registers a secret value to redact from the test log,
+// like the mask command.
+func (e *Env) Mask(value string) {
+	e.ts.Mask(value)
+}
+
 // T returns the t argument passed to the current test by the T.Run method.
 // Note that if the tests were started by calling Run,
 // the returned value will implement testing.TB.
@@ -124,9 +140,18 @@ type Params struct {
 	// The Setup function may modify Vars and Cd as it wishes.
 	Setup func(*Env) error
 
+	// SuiteSetup is called, if not nil, once before any script runs.
+	// Its Env has a WorkDir shared by all scripts, and the Vars and
+	// Values it sets are added to the Env of every script before Setup.
+	// Defer, T, and Mask must not be called on it; use SuiteTeardown.
+	SuiteSetup func(*Env) error
+
+	// SuiteTeardown is called, if not nil, once after all scripts finish.
+	SuiteTeardown func()
+
 	// Condition is called, if not nil, to determine whether a particular
 	// condition is true. It's called only for conditions not in the
-	// standard set, and may be nil.
+	// standard set or registered with RegisterCondition, and may be nil.
 	Condition func(cond string) (bool, error)
 
 	// Cmds holds a map of commands available to the script.
@@ -155,16 +180,82 @@ type Params struct {
 	// error will be ignored.
 	IgnoreMissedCoverage bool
 
-	// UpdateScripts specifies that if a `cmp` command fails and
-	// its first argument is `stdout` or `stderr` and its second argument
-	// refers to a file inside the testscript file, the command will succeed
-	// and the testscript file will be updated to reflect the actual output.
+	// UpdateScripts specifies that if a `cmp` or `cmpenv` command fails and
+	// its second argument refers to a file inside the testscript file,
+	// the command will succeed and the testscript file will be updated
+	// to reflect the actual output. The `golden` command likewise adds,
+	// updates, and removes golden files in the testscript file.
+	// For `cmpenv`, occurrences of the work directory are written back as $WORK.
 	//
 	// The content will be quoted with txtar.Quote if needed;
 	// a manual change will be needed if it is not unquoted in the
 	// script.
 	UpdateScripts bool
 
+	// EnvFiles holds the names of files, relative to $WORK, which are
+	// loaded like the dotenv command after the archive is extracted
+	// and Setup has run.
+	EnvFiles []string
+
+	// Prelude holds the names of txtar archives whose script sections
+	// run before every script and whose files are extracted into $WORK.
+	// Names are interpreted relative to the current test directory.
+	// Scripts can also splice in an archive with an "include path" line.
+	Prelude []string
+
+	// Tags selects scripts by the tags they declare in a header line
+	// like "~ tags: slow, network" (using the CommentPrefix).
+	// A script runs if it has any of the plain tags (or there are none)
+	// and none of the tags prefixed with "!". For example, []string{"!slow"}
+	// skips slow scripts. The -run-tags flag overrides this list.
+	Tags []string
+
+	// MaxParallel limits how many scripts run at the same time.
+	// Zero means no limit beyond that of go test -parallel.
+	// Scripts with a "~ serial" directive line always run alone.
+	MaxParallel int
+
+	// EventWriter, if not nil, receives a JSON Event per line for
+	// every command run by any script, for use by external tooling.
+	// Writes are serialized across scripts running in parallel.
+	EventWriter io.Writer
+
+	// Tools lists pinned external programs which are downloaded once into
+	// ToolCache and put at the front of every script's PATH, so that scripts
+	// do not depend on what happens to be installed. A "~ tools: cue" line
+	// makes a script fail, rather than skip, when a tool it needs is not listed.
+	Tools []Tool
+
+	// ToolCache is the directory holding downloaded Tools.
+	// It defaults to hof/script-tools in the user cache directory.
+	ToolCache string
+
+	// DenyNetwork keeps scripts hermetic by blocking access to remote hosts.
+	// The http command fails for them, and exec'd programs get proxy
+	// variables pointing at a proxy which refuses every request (programs
+	// which ignore the proxy variables are not blocked). Loopback addresses
+	// are always allowed. The [network] condition is false when set.
+	DenyNetwork bool
+
+	// Sequence runs the scripts one at a time in file name order, rather
+	// than in parallel, so that long flows can be split into several
+	// scripts. A script with a "~ carry" directive line continues in the
+	// $WORK left by the script before it, instead of a fresh one, and is
+	// skipped if that script did not pass.
+	Sequence bool
+
+	// DryRun checks each script with Lint instead of running it,
+	// failing the script if any problems are found. Nothing is executed,
+	// including SuiteSetup, Setup, and the download of Tools.
+	DryRun bool
+
+	// Context, if not nil, stops the scripts when it is done, as does
+	// an interrupt signal (such as from ^C under go test). Running
+	// commands and background processes are interrupted, scripts which
+	// have not started are skipped, and each script's partial log is
+	// still reported, without applying any UpdateScripts changes.
+	Context context.Context
+
 	// Line prefix which indicates a new phase
 	// defaults to "#"
 	PhasePrefix string
@@ -194,6 +285,13 @@ type T interface {
 	Verbose() bool
 }
 
+// cleaner is implemented by *testing.T. Scripts may run in parallel after RunT
+// returns, so teardown is left to Cleanup when T has it. Other implementations
+// run each script before Run returns, and teardown runs when RunT does.
+type cleaner interface {
+	Cleanup(func())
+}
+
 type tshim struct {
 	*testing.T
 }
@@ -236,6 +334,25 @@ func RunT(t T, p Params) {
 	if len(files) == 0 {
 		t.Fatal(fmt.Sprintf("no scripts found matching glob: %v", glob))
 	}
+	if p.DryRun {
+		lintScripts(t, files, p)
+		return
+	}
+	// Teardown is registered as each resource is acquired,
+	// so it also runs when a later step fails the test.
+	var teardown []func()
+	defer func() {
+		for i := len(teardown) - 1; i >= 0; i-- {
+			teardown[i]()
+		}
+	}()
+	cleanup := func(f func()) {
+		if c, ok := t.(cleaner); ok {
+			c.Cleanup(f)
+		} else {
+			teardown = append(teardown, f)
+		}
+	}
 	testTempDir := p.WorkdirRoot
 	if testTempDir == "" {
 		testTempDir, err = ioutil.TempDir(os.Getenv("GOTMPDIR"), "go-test-script")
@@ -253,47 +370,301 @@ func RunT(t T, p Params) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	refCount := int32(len(files))
-	for _, file := range files {
-		file := file
+	if !p.TestWork && !*testWork {
+		// Remove the parent directory too.
+		cleanup(func() { os.Remove(testTempDir) })
+	}
+	suite, err := suiteSetup(testTempDir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suite != nil && !p.TestWork && !*testWork {
+		cleanup(func() { removeAll(suite.WorkDir) })
+	}
+	if p.SuiteTeardown != nil {
+		cleanup(p.SuiteTeardown)
+	}
+	toolDirs, err := provisionTools(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proxy *denyProxy
+	if p.DenyNetwork {
+		if proxy, err = startDenyProxy(); err != nil {
+			t.Fatal(err)
+		}
+		cleanup(proxy.close)
+	}
+	ctx, cancel := cancelOnInterrupt(p.Context)
+	cleanup(cancel)
+	// Scripts hold serialMu for reading while they run, serial scripts hold it for writing.
+	// When limited, they also hold a slot in parallelSem.
+	var serialMu sync.RWMutex
+	var parallelSem chan struct{}
+	if p.MaxParallel > 0 {
+		parallelSem = make(chan struct{}, p.MaxParallel)
+	}
+	skips := new(skipReport)
+	cleanup(func() { skips.log(t, len(files)) })
+	carried := make([]bool, len(files)+1)
+	for i, file := range files {
+		if carried[i], err = isCarried(file, p); err != nil {
+			t.Fatal(err)
+		}
+		if carried[i] && !p.Sequence {
+			t.Fatal(fmt.Sprintf("%s: carry requires Params.Sequence", file))
+		}
+	}
+	// In a Sequence, where each subtest finishes before the next starts,
+	// prevWork is the $WORK kept by the script before if it passed.
+	var prevWork string
+	for i, file := range files {
+		i, file := i, file
 		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		work := prevWork
+		prevWork = ""
 		t.Run(name, func(t T) {
-			t.Parallel()
-			ts := &Script{
-				t:             t,
-				testTempDir:   testTempDir,
-				name:          name,
-				file:          file,
-				params:        p,
-				ctxt:          context.Background(),
-				deferred:      func() {},
-				scriptFiles:   make(map[string]string),
-				scriptUpdates: make(map[string]string),
+			if !p.Sequence {
+				t.Parallel()
+			}
+			if ok, err := selectedByTags(file, p); err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				t.Skip("not selected by tags")
+			}
+			if carried[i] && work == "" {
+				t.Skip("carry: previous script did not run or pass")
+			}
+			if err := checkTools(file, p); err != nil {
+				t.Fatal(err)
+			}
+			if serial, err := isSerial(file, p); err != nil {
+				t.Fatal(err)
+			} else if serial {
+				serialMu.Lock()
+				defer serialMu.Unlock()
+			} else {
+				serialMu.RLock()
+				defer serialMu.RUnlock()
+			}
+			if parallelSem != nil {
+				parallelSem <- struct{}{}
+				defer func() { <-parallelSem }()
+			}
+			if err := ctx.Err(); err != nil {
+				t.Skip("not started: ", err)
+			}
+			ts := newScript(t, testTempDir, name, file, p)
+			ts.ctxt = ctx
+			ts.suite = suite
+			ts.toolDirs = toolDirs
+			ts.proxy = proxy
+			if carried[i] {
+				ts.workdir = work
 			}
 			defer func() {
-				if p.TestWork || *testWork {
+				if ts.skipped != nil {
+					skips.add(*ts.skipped)
+				}
+			}()
+			defer func() {
+				if p.TestWork || *testWork || prevWork != "" {
 					return
 				}
 				removeAll(ts.workdir)
-				if atomic.AddInt32(&refCount, -1) == 0 {
-					// This is the last subtest to finish. Remove the
-					// parent directory too.
-					os.Remove(testTempDir)
-				}
 			}()
 			ts.run()
+			if carried[i+1] {
+				// Keep $WORK for the next script.
+				prevWork = ts.workdir
+			}
+		})
+	}
+}
+
+// lintScripts runs a subtest for each script which fails with the problems Lint finds.
+func lintScripts(t T, files []string, p Params) {
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t T) {
+			t.Parallel()
+			if ok, err := selectedByTags(file, p); err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				t.Skip("not selected by tags")
+			}
+			if problems := lintScript(file, p); len(problems) > 0 {
+				var buf strings.Builder
+				for _, e := range problems {
+					fmt.Fprintf(&buf, "%v\n", e)
+				}
+				t.Fatal(buf.String())
+			}
 		})
 	}
 }
 
+// cancelOnInterrupt returns a context derived from parent, or the background
+// context if nil, which is also cancelled by the first interrupt signal.
+// Later interrupts are left to kill the process as usual.
+func cancelOnInterrupt(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	go func() {
+		select {
+		case <-sigc:
+		case <-ctx.Done():
+		}
+		signal.Stop(sigc)
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// suiteSetup runs Params.SuiteSetup, if set, with a WorkDir named suite in testTempDir.
+func suiteSetup(testTempDir string, p Params) (*Env, error) {
+	if p.SuiteSetup == nil {
+		return nil, nil
+	}
+	env := &Env{
+		WorkDir: filepath.Join(testTempDir, "suite"),
+		Values:  make(map[interface{}]interface{}),
+	}
+	env.Cd = env.WorkDir
+	if err := os.MkdirAll(env.WorkDir, 0777); err != nil {
+		return nil, err
+	}
+	if err := p.SuiteSetup(env); err != nil {
+		return nil, fmt.Errorf("suite setup: %v", err)
+	}
+	return env, nil
+}
+
+// newScript returns the execution state for running the script in file.
+func newScript(t T, testTempDir, name, file string, p Params) *Script {
+	return &Script{
+		t:             t,
+		testTempDir:   testTempDir,
+		name:          name,
+		file:          file,
+		params:        p,
+		ctxt:          context.Background(),
+		deferred:      func() {},
+		scriptFiles:   make(map[string]string),
+		scriptUpdates: make(map[string]string),
+		scriptDeletes: make(map[string]bool),
+	}
+}
+
+// scriptDirectives returns the text of the comment lines, like "~ tags: slow",
+// in the script section of the archive, without the comment prefix.
+func scriptDirectives(comment []byte, commentPrefix string) []string {
+	var directives []string
+	for _, line := range strings.Split(string(comment), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, commentPrefix) {
+			continue
+		}
+		directives = append(directives, strings.TrimSpace(strings.TrimPrefix(line, commentPrefix)))
+	}
+	return directives
+}
+
+// scriptTags returns the tags declared in the script section of the archive.
+func scriptTags(comment []byte, commentPrefix string) []string {
+	var tags []string
+	for _, line := range scriptDirectives(comment, commentPrefix) {
+		if !strings.HasPrefix(line, "tags:") {
+			continue
+		}
+		for _, tag := range strings.Split(strings.TrimPrefix(line, "tags:"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// isSerial reports whether the script in file has a "~ serial" directive,
+// meaning it must not run at the same time as any other script.
+func isSerial(file string, p Params) (bool, error) {
+	return hasDirective(file, p, "serial")
+}
+
+// isCarried reports whether the script in file has a "~ carry" directive,
+// meaning it continues in the $WORK of the script before it in a Sequence.
+func isCarried(file string, p Params) (bool, error) {
+	return hasDirective(file, p, "carry")
+}
+
+// hasDirective reports whether the script in file has the directive line.
+func hasDirective(file string, p Params, directive string) (bool, error) {
+	comment, err := txtar.ReadComment(file)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range scriptDirectives(comment, p.CommentPrefix) {
+		if line == directive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// selectedByTags reports whether the script in file should run given the tag filters.
+func selectedByTags(file string, p Params) (bool, error) {
+	filters := p.Tags
+	if *runTags != "" {
+		filters = strings.Split(*runTags, ",")
+	}
+	if len(filters) == 0 {
+		return true, nil
+	}
+
+	comment, err := txtar.ReadComment(file)
+	if err != nil {
+		return false, err
+	}
+	has := make(map[string]bool)
+	for _, tag := range scriptTags(comment, p.CommentPrefix) {
+		has[tag] = true
+	}
+
+	want, matched := false, false
+	for _, f := range filters {
+		f = strings.TrimSpace(f)
+		switch {
+		case f == "":
+		case strings.HasPrefix(f, "!"):
+			if has[f[1:]] {
+				return false, nil
+			}
+		default:
+			want = true
+			matched = matched || has[f]
+		}
+	}
+	return !want || matched, nil
+}
+
 // A Script holds execution state for a single test script.
 type Script struct {
 	params        Params
 	t             T
 	testTempDir   string
-	workdir       string                      // temporary work dir ($WORK)
+	workdir       string                      // temporary work dir ($WORK), set beforehand if carried
 	log           bytes.Buffer                // test execution log (printed at end of test)
 	mark          int                         // offset of next log truncation
+	streamed      int                         // offset of log already streamed in verbose mode
+	skipped       *scriptSkip                 // set by the skip command
+	toolDirs      []string                    // directories of Params.Tools, prepended to PATH
+	proxy         *denyProxy                  // set with Params.DenyNetwork
 	cd            string                      // current directory during test execution; initially $WORK/gopath/src
 	name          string                      // short name of test ("foo")
 	file          string                      // full file name ("testdata/script/foo.txt")
@@ -308,32 +679,110 @@ type Script struct {
 	status        int                         // status code from exec or http
 	stopped       bool                        // test wants to stop early
 	start         time.Time                   // time phase started
+	phase         string                      // heading of the current phase
+	phaseTimes    []phaseTime                 // elapsed time of completed phases
+	retry         *phaseRetry                 // state to re-run the current phase, if it has [retry=N]
+	envStack      []envScope                  // environments saved by 'env push'
+	linting       bool                        // checking the script with Lint rather than running it
+	calling       bool                        // a Funcs function is running on its own goroutine, see call
+	callFailed    bool                        // the function called Fatalf, raised once it returns
 	background    []backgroundCmd             // backgrounded 'exec' and 'go' commands
+	bgCount       int                         // number of background commands started, for naming logs
+	pty           *ptySession                 // interactive process started by 'pty spawn'
+	masks         []string                    // secrets to redact from the log
+	suite         *Env                        // environment from Params.SuiteSetup
 	deferred      func()                      // deferred cleanup actions.
-	archive       *txtar.Archive              // the testscript being run.
+	archive       *txtar.Archive              // the testscript being run, loaded when updating it.
 	scriptFiles   map[string]string           // files stored in the txtar archive (absolute paths -> path in script)
 	scriptUpdates map[string]string           // updates to testscript files via UpdateScripts.
+	scriptDeletes map[string]bool             // testscript files to remove via UpdateScripts.
 
 	httpClients map[string]*gorequest.SuperAgent
+	httpStat    *httpStat   // latency and size of the last http request
+	nats        *natsClient // connection used by the nats command
 
 	ctxt context.Context // per Script context
 }
 
+type phaseTime struct {
+	phase   string
+	elapsed time.Duration
+}
+
+// phaseRetryAnnotation matches the [retry=N] suffix of a phase heading.
+var phaseRetryAnnotation = regexp.MustCompile(`\s*\[retry=(\d+)\]$`)
+
+// errRetryPhase is raised by Fatalf to unwind to the start of a phase with retries left.
+var errRetryPhase = errors.New("retry phase")
+
+// phaseRetry holds what is needed to re-run a phase from its heading.
+type phaseRetry struct {
+	script   string // the script following the heading
+	lineno   int    // line number of the heading
+	attempt  int    // current attempt, from 1
+	attempts int    // total attempts allowed
+	env      envScope
+	cd       string
+	nbg      int // number of background commands before the phase
+}
+
+// envScope is a saved copy of the script environment.
+type envScope struct {
+	env    []string
+	envMap map[string]string
+}
+
 type backgroundCmd struct {
-	cmd  *exec.Cmd
-	wait <-chan struct{}
-	neg  int // if true, cmd should fail
+	name   string // set by exec &name, may be empty
+	cmd    *exec.Cmd
+	stdout *strings.Builder
+	stderr *strings.Builder
+	logs   []*os.File // $WORK/.logs/<name>.{out,err}, closed when cmd exits
+	wait   <-chan struct{}
+	neg    int // if true, cmd should fail
+}
+
+// backgroundLogDir is the directory, relative to $WORK,
+// where background command output is streamed.
+const backgroundLogDir = ".logs"
+
+func (bg *backgroundCmd) closeLogs() {
+	for _, f := range bg.logs {
+		f.Close()
+	}
+}
+
+// scriptSignals are the signals which can be sent with the signal command.
+var scriptSignals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  os.Interrupt,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": os.Kill,
+}
+
+// findBackground returns the running background command with the given name, or nil.
+func (ts *Script) findBackground(name string) *backgroundCmd {
+	for i := range ts.background {
+		if ts.background[i].name == name {
+			return &ts.background[i]
+		}
+	}
+	return nil
 }
 
 // setup sets up the test execution temporary directory and environment.
 // It returns the comment section of the txtar archive.
 func (ts *Script) setup() string {
-	ts.workdir = filepath.Join(ts.testTempDir, "script-"+ts.name)
+	if ts.workdir == "" {
+		ts.workdir = filepath.Join(ts.testTempDir, "script-"+ts.name)
+	}
 	ts.Check(os.MkdirAll(filepath.Join(ts.workdir, "tmp"), 0777))
+	path := append(append([]string(nil), ts.toolDirs...), os.Getenv("PATH"))
 	env := &Env{
 		Vars: []string{
 			"WORK=" + ts.workdir, // must be first for ts.abbrev
-			"PATH=" + os.Getenv("PATH"),
+			"PATH=" + strings.Join(path, string(os.PathListSeparator)),
 			homeEnvName() + "=/no-home",
 			tempEnvName() + "=" + filepath.Join(ts.workdir, "tmp"),
 			"devnull=" + os.DevNull,
@@ -356,17 +805,54 @@ func (ts *Script) setup() string {
 			"exe=",
 		)
 	}
+	if ts.proxy != nil {
+		env.Vars = append(env.Vars, ts.proxy.vars()...)
+	}
+	if ts.suite != nil {
+		env.Vars = append(env.Vars, ts.suite.Vars...)
+		for k, v := range ts.suite.Values {
+			env.Values[k] = v
+		}
+	}
 	ts.cd = env.Cd
-	// Unpack archive.
-	a, err := txtar.ParseFile(ts.file)
+	// Unpack archive, streaming its files to disk in bounded batches,
+	// written concurrently, so that large archives are not held in memory.
+	af, err := os.Open(ts.file)
 	ts.Check(err)
-	ts.archive = a
-	for _, f := range a.Files {
-		name := ts.MkAbs(ts.expand(f.Name))
-		ts.scriptFiles[name] = f.Name
-		ts.Check(os.MkdirAll(filepath.Dir(name), 0777))
-		ts.Check(ioutil.WriteFile(name, f.Data, 0666))
+	defer af.Close()
+	tr := txtar.NewReader(af)
+	comment, err := tr.Comment()
+	ts.Check(err)
+	// Splice in the prelude and any included archives.
+	// Their files are written first so the script's own files take precedence.
+	var script string
+	var included []txtar.File
+	seen := map[string]bool{ts.file: true}
+	for _, file := range ts.params.Prelude {
+		text, files := ts.include(file, seen)
+		script += text
+		included = append(included, files...)
+	}
+	text, files := ts.spliceIncludes(ts.file, string(comment), seen)
+	script += text
+	included = append(included, files...)
+	batch := new(archiveBatch)
+	for i := range included {
+		f := &included[i]
+		data, err := f.Contents()
+		ts.Check(err)
+		batch.add(ts, f, data)
 	}
+	ts.flushArchive(batch)
+	for {
+		f, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		ts.Check(err)
+		ts.scriptFiles[ts.extractArchiveFile(batch, f, tr.Contents(f))] = f.Name
+	}
+	ts.flushArchive(batch)
 	// Run any user-defined setup.
 	if ts.params.Setup != nil {
 		ts.Check(ts.params.Setup(env))
@@ -381,7 +867,52 @@ func (ts *Script) setup() string {
 			ts.envMap[envvarname(kv[:i])] = kv[i+1:]
 		}
 	}
-	return string(a.Comment)
+	for _, file := range ts.params.EnvFiles {
+		ts.loadEnvFile(file)
+	}
+	return script
+}
+
+// include reads the txtar archive in file and returns its script section,
+// with includes spliced in, along with all of the files it provides.
+func (ts *Script) include(file string, seen map[string]bool) (string, []txtar.File) {
+	if seen[file] {
+		ts.Fatalf("include cycle at %s", file)
+	}
+	seen[file] = true
+	defer delete(seen, file)
+
+	a, err := txtar.ParseFile(file)
+	ts.Check(err)
+	script, files := ts.spliceIncludes(file, string(a.Comment), seen)
+	return script, append(files, a.Files...)
+}
+
+// spliceIncludes replaces "include path" lines in script with the contents
+// of the named archive. Paths are relative to the directory of file.
+func (ts *Script) spliceIncludes(file, script string, seen map[string]bool) (string, []txtar.File) {
+	var (
+		buf   strings.Builder
+		files []txtar.File
+	)
+	for _, line := range strings.SplitAfter(script, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "include" {
+			buf.WriteString(line)
+			continue
+		}
+		name := fields[1]
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(file), name)
+		}
+		text, incFiles := ts.include(name, seen)
+		buf.WriteString(text)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			buf.WriteString("\n")
+		}
+		files = append(files, incFiles...)
+	}
+	return buf.String(), files
 }
 
 // run runs the test script.
@@ -397,10 +928,21 @@ func (ts *Script) run() {
 	// Insert elapsed time for phase at end of phase marker
 	markTime := func() {
 		if ts.mark > 0 && !ts.start.IsZero() {
+			elapsed := time.Since(ts.start)
+			ts.phaseTimes = append(ts.phaseTimes, phaseTime{ts.phase, elapsed})
 			afterMark := append([]byte{}, ts.log.Bytes()[ts.mark:]...)
+			before := ts.log.Len()
 			ts.log.Truncate(ts.mark - 1) // cut \n and afterMark
-			fmt.Fprintf(&ts.log, " (%.3fs)\n", time.Since(ts.start).Seconds())
+			if ts.retry != nil && ts.retry.attempt > 1 {
+				fmt.Fprintf(&ts.log, " (%.3fs, %d attempts)\n", elapsed.Seconds(), ts.retry.attempt)
+			} else {
+				fmt.Fprintf(&ts.log, " (%.3fs)\n", elapsed.Seconds())
+			}
 			ts.log.Write(afterMark)
+			// The phase heading may have been streamed already.
+			if ts.streamed >= ts.mark {
+				ts.streamed += ts.log.Len() - before
+			}
 		}
 		ts.start = time.Time{}
 	}
@@ -419,7 +961,11 @@ func (ts *Script) run() {
 
 		markTime()
 		// Flush testScript log to testing.T log.
-		ts.t.Log("\n" + ts.abbrev(ts.log.String()))
+		if ts.streamed > 0 {
+			ts.stream()
+		} else {
+			ts.t.Log("\n" + ts.abbrev(ts.redact(ts.log.String())))
+		}
 	}()
 	defer func() {
 		ts.deferred()
@@ -437,8 +983,46 @@ func (ts *Script) run() {
 
 	// Run script.
 	// See testdata/script/README for documentation of script form.
+	// A failure in a phase with retries left unwinds to here,
+	// and the phase is run again from its heading.
+	runLines := func(script string) (retry string) {
+		defer func() {
+			if e := recover(); e != nil {
+				if e != errRetryPhase {
+					panic(e)
+				}
+				retry = ts.retryPhase()
+			}
+		}()
+		ts.runLines(script, rewind, markTime)
+		return ""
+	}
+	for script != "" {
+		script = runLines(script)
+	}
+	ts.retry = nil
+
+	for _, bg := range ts.background {
+		interruptProcess(bg.cmd.Process)
+	}
+	ts.cmdWait(0, nil)
+
+	// Final phase ended.
+	rewind()
+	markTime()
+	if !ts.stopped {
+		fmt.Fprintf(&ts.log, "PASS\n")
+	}
+}
+
+// runLines runs the lines of script until it ends or the script stops early.
+func (ts *Script) runLines(script string, rewind, markTime func()) {
 Script:
 	for script != "" {
+		if err := ts.ctxt.Err(); err != nil {
+			ts.Fatalf("script stopped: %v", err)
+		}
+
 		// Extract next line.
 		ts.lineno++
 		var line string
@@ -459,10 +1043,23 @@ Script:
 				rewind()
 				markTime()
 			}
+			// Environments pushed by the previous phase end with it.
+			if len(ts.envStack) > 0 {
+				ts.restoreEnv(ts.envStack[0])
+				ts.envStack = nil
+			}
 			// Print phase heading and mark start of phase output.
 			fmt.Fprintf(&ts.log, "%s\n", line)
 			ts.mark = ts.log.Len()
 			ts.start = time.Now()
+			ts.phase = strings.TrimSpace(strings.TrimPrefix(line, ts.params.PhasePrefix))
+			ts.retry = nil
+			if m := phaseRetryAnnotation.FindStringSubmatch(ts.phase); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				ts.phase = strings.TrimSuffix(ts.phase, m[0])
+				ts.startRetry(script, n)
+			}
+			ts.stream()
 			continue
 		}
 
@@ -480,6 +1077,7 @@ Script:
 
 		// Echo command to log.
 		fmt.Fprintf(&ts.log, "> %s\n", line)
+		ts.stream()
 
 		// Command prefix [cond] means only run this command if cond is satisfied.
 		for strings.HasPrefix(args[0], "[") && strings.HasSuffix(args[0], "]") {
@@ -527,66 +1125,155 @@ Script:
 		if cmd == nil {
 			cmd = ts.params.Cmds[args[0]]
 		}
+		if cmd == nil {
+			if c, ok := lookupPluginCmd(args[0]); ok {
+				cmd = CmdFunc(c)
+			}
+		}
 		if cmd == nil {
 			ts.Fatalf("unknown command %q", args[0])
 		}
-		cmd(ts, neg, args[1:])
+		ts.runCmd(cmd, neg, args)
+		ts.stream()
 
 		// Command can ask script to stop early.
 		if ts.stopped {
-			// Break instead of returning, so that we check the status of any
+			// Return instead of failing, so that we check the status of any
 			// background processes and print PASS.
-			break
+			return
 		}
 	}
+}
 
-	for _, bg := range ts.background {
+// startRetry records the state at the start of a phase,
+// so that it can be run again up to n more times.
+func (ts *Script) startRetry(script string, n int) {
+	ts.retry = &phaseRetry{
+		script:   script,
+		lineno:   ts.lineno,
+		attempt:  1,
+		attempts: n + 1,
+		env:      ts.saveEnv(),
+		cd:       ts.cd,
+		nbg:      len(ts.background),
+	}
+}
+
+// retryPhase restores the state at the start of the failed phase,
+// stopping background commands it started, and returns the script to run again.
+// Changes to files are not undone.
+func (ts *Script) retryPhase() string {
+	r := ts.retry
+	for _, bg := range ts.background[r.nbg:] {
 		interruptProcess(bg.cmd.Process)
 	}
-	ts.cmdWait(0, nil)
+	for _, bg := range ts.background[r.nbg:] {
+		<-bg.wait
+	}
+	ts.background = ts.background[:r.nbg]
+	ts.restoreEnv(r.env)
+	ts.envStack = nil
+	ts.cd = r.cd
+	ts.stdin, ts.stdout, ts.stderr, ts.status = "", "", "", 0
+	ts.lineno = r.lineno
+	r.attempt++
+	fmt.Fprintf(&ts.log, "[retrying phase %q, attempt %d of %d]\n", ts.phase, r.attempt, r.attempts)
+	ts.stream()
+	return r.script
+}
 
-	// Final phase ended.
-	rewind()
-	markTime()
-	if !ts.stopped {
-		fmt.Fprintf(&ts.log, "PASS\n")
+// saveEnv returns a copy of the current environment.
+func (ts *Script) saveEnv() envScope {
+	envMap := make(map[string]string, len(ts.envMap))
+	for k, v := range ts.envMap {
+		envMap[k] = v
+	}
+	return envScope{env: append([]string(nil), ts.env...), envMap: envMap}
+}
+
+// restoreEnv replaces the environment with a copy of a saved one.
+func (ts *Script) restoreEnv(e envScope) {
+	ts.env = append([]string(nil), e.env...)
+	ts.envMap = make(map[string]string, len(e.envMap))
+	for k, v := range e.envMap {
+		ts.envMap[k] = v
 	}
 }
 
 func (ts *Script) applyScriptUpdates() {
-	if len(ts.scriptUpdates) == 0 {
+	if len(ts.scriptUpdates) == 0 && len(ts.scriptDeletes) == 0 {
+		return
+	}
+	if ts.ctxt.Err() != nil {
+		// The script was stopped part way, so its updates are incomplete.
+		return
+	}
+	// The archive is streamed during setup, so load it in full now.
+	a, err := txtar.ParseFile(ts.file)
+	if err != nil {
+		ts.t.Fatal("cannot update script: ", err)
 		return
 	}
-	for name, content := range ts.scriptUpdates {
+	ts.archive = a
+	names := make([]string, 0, len(ts.scriptUpdates))
+	for name := range ts.scriptUpdates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := []byte(ts.scriptUpdates[name])
+		// Base64 files can hold binary data, so need no quoting.
+		if f := ts.archiveFile(name); f != nil && f.HasAttr(txtar.AttrBase64) {
+			f.SetContents(data)
+			continue
+		}
+		if txtar.NeedsQuote(data) {
+			data1, err := txtar.Quote(data)
+			if err != nil {
+				ts.t.Fatal(fmt.Sprintf("cannot update script file %q: %v", name, err))
+				continue
+			}
+			data = data1
+		}
 		found := false
 		for i := range ts.archive.Files {
 			f := &ts.archive.Files[i]
 			if f.Name != name {
 				continue
 			}
-			data := []byte(content)
-			if txtar.NeedsQuote(data) {
-				data1, err := txtar.Quote(data)
-				if err != nil {
-					ts.t.Fatal(fmt.Sprintf("cannot update script file %q: %v", f.Name, err))
-					continue
-				}
-				data = data1
-			}
 			f.Data = data
 			found = true
 		}
-		// Sanity check.
+		// Files new to the archive, such as golden files, are added at the end.
 		if !found {
-			panic("script update file not found")
+			ts.archive.Files = append(ts.archive.Files, txtar.File{Name: name, Data: data})
 		}
 	}
+	if len(ts.scriptDeletes) > 0 {
+		files := ts.archive.Files[:0]
+		for _, f := range ts.archive.Files {
+			if !ts.scriptDeletes[f.Name] {
+				files = append(files, f)
+			}
+		}
+		ts.archive.Files = files
+	}
 	if err := ioutil.WriteFile(ts.file, txtar.Format(ts.archive), 0666); err != nil {
 		ts.t.Fatal("cannot update script: ", err)
 	}
 	ts.Logf("%s updated", ts.file)
 }
 
+// archiveFile returns the named file in the script archive, or nil.
+func (ts *Script) archiveFile(name string) *txtar.File {
+	for i := range ts.archive.Files {
+		if ts.archive.Files[i].Name == name {
+			return &ts.archive.Files[i]
+		}
+	}
+	return nil
+}
+
 // condition reports whether the given condition is satisfied.
 func (ts *Script) condition(cond string) (bool, error) {
 	switch cond {
@@ -598,6 +1285,10 @@ func (ts *Script) condition(cond string) (bool, error) {
 		return testenv.HasLink(), nil
 	case "symlink":
 		return testenv.HasSymlink(), nil
+	case "pty":
+		return runtime.GOOS == "linux", nil
+	case "network":
+		return !ts.params.DenyNetwork, nil
 	case runtime.GOOS, runtime.GOARCH:
 		return true, nil
 	default:
@@ -606,12 +1297,19 @@ func (ts *Script) condition(cond string) (bool, error) {
 		}
 		if strings.HasPrefix(cond, "exec:") {
 			prog := cond[len("exec:"):]
-			ok := execCache.Do(prog, func() interface{} {
+			// Key by PATH too, as Params.Tools may add to it.
+			ok := execCache.Do(prog+string(os.PathListSeparator)+ts.Getenv("PATH"), func() interface{} {
 				_, err := execpath.Look(prog, ts.Getenv)
 				return err == nil
 			}).(bool)
 			return ok, nil
 		}
+		if m := goVersionCond.FindStringSubmatch(cond); m != nil {
+			return goVersionCondition(m[1]), nil
+		}
+		if f, arg, ok := lookupCondition(cond); ok {
+			return f(arg)
+		}
 		if ts.params.Condition != nil {
 			return ts.params.Condition(cond)
 		}
@@ -622,6 +1320,44 @@ func (ts *Script) condition(cond string) (bool, error) {
 
 // Helpers for command implementations.
 
+// stream passes new log output to the testing.T log as it happens
+// when running verbosely, so that hung scripts can be diagnosed.
+// Otherwise the log is only flushed when the script finishes.
+func (ts *Script) stream() {
+	if !ts.t.Verbose() || ts.log.Len() <= ts.streamed {
+		return
+	}
+	chunk := ts.redact(string(ts.log.Bytes()[ts.streamed:]))
+	if ts.streamed == 0 {
+		chunk = ts.abbrev(chunk)
+	} else {
+		chunk = strings.Replace(chunk, ts.workdir, "$WORK", -1)
+	}
+	ts.t.Log("\n" + strings.TrimSuffix(chunk, "\n"))
+	ts.streamed = ts.log.Len()
+}
+
+// Mask registers a secret value which is replaced by "***" everywhere
+// in the test log, including command output and comparison failures.
+func (ts *Script) Mask(value string) {
+	if value == "" {
+		return
+	}
+	ts.masks = append(ts.masks, value)
+	// Replace longer secrets first, in case one contains another.
+	sort.Slice(ts.masks, func(i, j int) bool {
+		return len(ts.masks[i]) > len(ts.masks[j])
+	})
+}
+
+// redact replaces the masked secrets in s.
+func (ts *Script) redact(s string) string {
+	for _, m := range ts.masks {
+		s = strings.Replace(s, m, "***", -1)
+	}
+	return s
+}
+
 // abbrev abbreviates the actual work directory in the string s to the literal string "$WORK".
 func (ts *Script) abbrev(s string) string {
 	s = strings.Replace(s, ts.workdir, "$WORK", -1)
@@ -669,22 +1405,33 @@ func (ts *Script) call(function string, args ...string) (string, string, error)
 	}
 
 	// backup originals
+	oldstdin := os.Stdin
 	oldstdout := os.Stdout
 	oldstderr := os.Stderr
+	stdin, inw, _ := os.Pipe()
 	stdout, outw, _ := os.Pipe()
 	stderr, errw, _ := os.Pipe()
-	os.Stdout = stdout
-	os.Stderr = stderr
+	os.Stdin = stdin
+	os.Stdout = outw
+	os.Stderr = errw
+
+	// feed any pending stdin, closing so the function sees EOF
+	go func(in string) {
+		io.WriteString(inw, in)
+		inw.Close()
+	}(ts.stdin)
+	ts.stdin = ""
 
 	var err error
 	done := make(chan string)
 	outC := make(chan string, 1)
 	errC := make(chan string, 1)
 
-	// call the function
+	// call the function, done is sent even when it calls Fatalf
+	ts.calling = true
 	go func() {
+		defer func() { done <- "done" }()
 		err = fn(ts, args)
-		done <- "done"
 	}()
 
 	// copy the output in a separate goroutine so printing can't block indefinitely
@@ -705,6 +1452,8 @@ func (ts *Script) call(function string, args ...string) (string, string, error)
 	// restore OS stds
 	outw.Close()
 	errw.Close()
+	stdin.Close()
+	os.Stdin = oldstdin
 	os.Stdout = oldstdout
 	os.Stderr = oldstderr
 
@@ -712,9 +1461,47 @@ func (ts *Script) call(function string, args ...string) (string, string, error)
 	funcout := <-outC
 	funcerr := <-errC
 
+	ts.calling = false
+	if ts.callFailed {
+		ts.callFailed = false
+		ts.failNow()
+	}
+
+	ts.setStatus(callStatus(err))
 	return funcout, funcerr, err
 }
 
+// StatusError is an error a Funcs function can return
+// to report a specific exit status to the script.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Status)
+	}
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// ExitCode returns the status, mirroring exec.ExitError.
+func (e *StatusError) ExitCode() int { return e.Status }
+
+// callStatus maps the error returned from a Funcs function to a status code.
+// Errors with an ExitCode method report that code, other errors report 1.
+func callStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if ec, ok := err.(interface{ ExitCode() int }); ok {
+		return ec.ExitCode()
+	}
+	return 1
+}
+
 // exec runs the given command line (an actual subprocess, not simulated)
 // in ts.cd with environment ts.env and then returns collected standard output and standard error.
 func (ts *Script) exec(command string, args ...string) (stdout, stderr string, err error) {
@@ -730,27 +1517,58 @@ func (ts *Script) exec(command string, args ...string) (stdout, stderr string, e
 	cmd.Stderr = &stderrBuf
 	if err = cmd.Start(); err == nil {
 		err = ctxWait(ts.ctxt, cmd)
-		ts.status = cmd.ProcessState.ExitCode()
+		ts.setStatus(cmd.ProcessState.ExitCode())
 	}
 	ts.stdin = ""
 	return stdoutBuf.String(), stderrBuf.String(), err
 }
 
 // execBackground starts the given command line (an actual subprocess, not simulated)
-// in ts.cd with environment ts.env.
-func (ts *Script) execBackground(command string, args ...string) (*exec.Cmd, error) {
+// in ts.cd with environment ts.env. Its output is buffered for wait and also
+// streamed to $WORK/.logs/<name>.{out,err}. Unnamed commands are named bg<N>.
+func (ts *Script) execBackground(name, command string, args ...string) (*backgroundCmd, error) {
 	cmd, err := ts.buildExecCmd(command, args...)
 	if err != nil {
 		return nil, err
 	}
 	cmd.Dir = ts.cd
 	cmd.Env = append(ts.env, "PWD="+ts.cd)
-	var stdoutBuf, stderrBuf strings.Builder
+
+	ts.bgCount++
+	logName := name
+	if logName == "" {
+		logName = fmt.Sprintf("bg%d", ts.bgCount)
+	}
+	logDir := filepath.Join(ts.workdir, backgroundLogDir)
+	if err := os.MkdirAll(logDir, 0777); err != nil {
+		return nil, err
+	}
+	outLog, err := os.Create(filepath.Join(logDir, logName+".out"))
+	if err != nil {
+		return nil, err
+	}
+	errLog, err := os.Create(filepath.Join(logDir, logName+".err"))
+	if err != nil {
+		outLog.Close()
+		return nil, err
+	}
+
+	bg := &backgroundCmd{
+		name:   name,
+		cmd:    cmd,
+		stdout: new(strings.Builder),
+		stderr: new(strings.Builder),
+		logs:   []*os.File{outLog, errLog},
+	}
 	cmd.Stdin = strings.NewReader(ts.stdin)
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	cmd.Stdout = io.MultiWriter(bg.stdout, outLog)
+	cmd.Stderr = io.MultiWriter(bg.stderr, errLog)
 	ts.stdin = ""
-	return cmd, cmd.Start()
+	if err := cmd.Start(); err != nil {
+		bg.closeLogs()
+		return nil, err
+	}
+	return bg, nil
 }
 
 func (ts *Script) buildExecCmd(command string, args ...string) (*exec.Cmd, error) {
@@ -816,19 +1634,52 @@ func (ts *Script) Exec(command string, args ...string) error {
 	return err
 }
 
+// SetOutput sets the standard output and standard error inspected by
+// subsequent script commands, for commands which produce output in-process.
+func (ts *Script) SetOutput(stdout, stderr string) {
+	ts.stdout, ts.stderr = stdout, stderr
+	if ts.stdout != "" {
+		ts.Logf("[stdout]\n%s", ts.stdout)
+	}
+	if ts.stderr != "" {
+		ts.Logf("[stderr]\n%s", ts.stderr)
+	}
+}
+
 // expand applies environment variable expansion to the string s.
 func (ts *Script) expand(s string) string {
 	return os.Expand(s, func(key string) string {
 		if key1 := strings.TrimSuffix(key, "@R"); len(key1) != len(key) {
 			return regexp.QuoteMeta(ts.Getenv(key1))
 		}
+		if key1 := strings.TrimSuffix(key, "@U"); len(key1) != len(key) {
+			return filepath.ToSlash(ts.Getenv(key1))
+		}
 		return ts.Getenv(key)
 	})
 }
 
 // fatalf aborts the test with the given failure message.
 func (ts *Script) Fatalf(format string, args ...interface{}) {
+	if ts.linting {
+		panic(&LintError{File: ts.file, Line: ts.lineno, Msg: fmt.Sprintf(format, args...)})
+	}
 	fmt.Fprintf(&ts.log, "FAIL: %s:%d: %s\n", ts.file, ts.lineno, fmt.Sprintf(format, args...))
+	// off the script goroutine, a panic would crash the test binary,
+	// so the failure is recorded and raised by call once the function is done
+	if ts.calling {
+		ts.callFailed = true
+		runtime.Goexit()
+	}
+	ts.failNow()
+}
+
+// failNow unwinds to the start of a phase with retries left, or ends the test.
+// It must be called on the script goroutine.
+func (ts *Script) failNow() {
+	if ts.retry != nil && ts.retry.attempt < ts.retry.attempts && ts.ctxt.Err() == nil {
+		panic(errRetryPhase)
+	}
 	ts.t.FailNow()
 }
 
@@ -868,11 +1719,57 @@ func (ts *Script) Setenv(key, value string) {
 	ts.envMap[envvarname(key)] = value
 }
 
+// setStatus records the exit or HTTP status of the last command,
+// which scripts check with the status command or read as $STATUS.
+// It replaces the previous $STATUS, as it is set after every command.
+func (ts *Script) setStatus(status int) {
+	ts.status = status
+	value := strconv.Itoa(status)
+	for i, kv := range ts.env {
+		if eq := strings.Index(kv, "="); eq > 0 && envvarname(kv[:eq]) == envvarname("STATUS") {
+			ts.env[i] = "STATUS=" + value
+			ts.envMap[envvarname("STATUS")] = value
+			return
+		}
+	}
+	ts.Setenv("STATUS", value)
+}
+
 // Getenv gets the value of the environment variable named by the key.
 func (ts *Script) Getenv(key string) string {
 	return ts.envMap[envvarname(key)]
 }
 
+// loadEnvFile sets the KEY=VALUE pairs found in file.
+// Blank lines, # comments, and a leading "export " are ignored.
+// Values are expanded unless they are single quoted,
+// so later lines may refer to earlier ones.
+func (ts *Script) loadEnvFile(file string) {
+	for i, line := range strings.Split(ts.ReadFile(file), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			ts.Fatalf("%s:%d: expected KEY=VALUE, got %q", file, i+1, line)
+		}
+		key, val := strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+
+		switch {
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
+		case len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"':
+			val = ts.expand(val[1 : len(val)-1])
+		default:
+			val = ts.expand(val)
+		}
+		ts.Setenv(key, val)
+	}
+}
+
 // parse parses a single line as a list of space-separated arguments
 // subject to environment variable expansion (but not resplitting).
 // Single quotes around text disable splitting and expansion.
@@ -986,8 +1883,14 @@ func (ts *Script) http(args []string) (string, string, int, error) {
 
 	req, err := ts.reqFromArgs(args)
 	ts.Check(err)
+	if err := ts.denyRequest(req); err != nil {
+		return "", "", 0, err
+	}
 
+	start := time.Now()
 	resp, body, errs := req.End()
+	ts.httpStat = &httpStat{latency: time.Since(start), size: int64(len(body))}
+	fmt.Fprintf(&ts.log, "[httpstat latency=%s size=%dB]\n", ts.httpStat.latency.Round(time.Millisecond), ts.httpStat.size)
 	body += "\n"
 
 	if len(errs) != 0 && !strings.Contains(errs[0].Error(), HTTP2_GOAWAY_CHECK) {
@@ -1009,7 +1912,7 @@ func (ts *Script) http(args []string) (string, string, int, error) {
 func (ts *Script) manageHttpClient(args []string) error {
 	L := len(args)
 	if L < 1 {
-		ts.Fatalf("usage: http client [new,del] <name> http-args...")
+		ts.Fatalf("usage: http client [new,mod,del] <name> [oauth2 oauth2-args...] http-args...")
 	}
 
 	key, name := args[0], "default"
@@ -1026,7 +1929,13 @@ func (ts *Script) manageHttpClient(args []string) error {
 
 	switch key {
 	case "new":
-		req, err := ts.newReqFromArgs(args)
+		var req *gorequest.SuperAgent
+		var err error
+		if len(args) > 0 && args[0] == "oauth2" {
+			req, err = ts.newOAuth2ReqFromArgs(args[1:])
+		} else {
+			req, err = ts.newReqFromArgs(args)
+		}
 		ts.Check(err)
 		ts.httpClients[name] = req
 
@@ -1069,6 +1978,80 @@ func (ts *Script) newReqFromArgs(args []string) (*gorequest.SuperAgent, error) {
 	return ts.applyArgsToReq(req, args)
 }
 
+// newOAuth2ReqFromArgs performs an OAuth2 client-credentials exchange
+// and returns a request obj which sends the access token as a bearer token.
+// The oauth2 args are consumed and any remaining args are applied to the request.
+func (ts *Script) newOAuth2ReqFromArgs(args []string) (*gorequest.SuperAgent, error) {
+	var tokenURL, clientID, clientSecret, scope string
+	rest := []string{}
+	for _, arg := range args {
+		flds := strings.SplitN(arg, "=", 2)
+		val := ""
+		if len(flds) == 2 {
+			val = flds[1]
+		}
+		if strings.HasPrefix(val, "@") {
+			val = strings.TrimSpace(ts.ReadFile(val[1:]))
+		}
+
+		switch strings.ToUpper(flds[0]) {
+		case "TOKEN_URL":
+			tokenURL = val
+		case "CLIENT_ID":
+			clientID = val
+		case "CLIENT_SECRET":
+			clientSecret = val
+		case "SCOPE":
+			scope = val
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if tokenURL == "" || clientID == "" {
+		ts.Fatalf("usage: http client new <name> oauth2 TOKEN_URL=... CLIENT_ID=... [CLIENT_SECRET=...] [SCOPE=...] http-args...")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+
+	treq := gorequest.New().Post(tokenURL).
+		Type("form").
+		SetBasicAuth(clientID, clientSecret).
+		Send(form.Encode())
+	if err := ts.denyRequest(treq); err != nil {
+		return nil, err
+	}
+
+	resp, body, errs := treq.End()
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("oauth2 token exchange failed: %v", errs)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("oauth2 token exchange failed: %s\n%s", resp.Status, body)
+	}
+
+	tok := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	}{}
+	if err := json.Unmarshal([]byte(body), &tok); err != nil {
+		return nil, fmt.Errorf("oauth2 token exchange returned bad json: %v\n%s", err, body)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token exchange returned no access_token:\n%s", body)
+	}
+
+	req, err := ts.newReqFromArgs(rest)
+	if err != nil {
+		return nil, err
+	}
+	return req.Set("Authorization", "Bearer "+tok.AccessToken), nil
+}
+
 func (ts *Script) applyDefaultsToReq(req *gorequest.SuperAgent) *gorequest.SuperAgent {
 
 	req.Method = "GET"
@@ -1135,6 +2118,27 @@ func (ts *Script) applyArgToReq(req *gorequest.SuperAgent, arg string) (*goreque
 
 		req = req.Retry(c, t, cs...)
 
+	case "GQL", "GRAPHQL":
+		if strings.HasPrefix(val, "@") {
+			val = ts.ReadFile(val[1:])
+		}
+		req.Method = "POST"
+		req = req.Send(map[string]interface{}{
+			"query": val,
+		})
+
+	case "VARS", "VARIABLES":
+		if strings.HasPrefix(val, "@") {
+			val = ts.ReadFile(val[1:])
+		}
+		var vars interface{}
+		if err := json.Unmarshal([]byte(val), &vars); err != nil {
+			return nil, fmt.Errorf("bad graphql variables %q: %v", arg, err)
+		}
+		req = req.Send(map[string]interface{}{
+			"variables": vars,
+		})
+
 	case "D", "DATA", "S", "SEND":
 		if strings.HasPrefix(val, "@") {
 			val = ts.ReadFile(val[1:])
@@ -1155,6 +2159,12 @@ func (ts *Script) applyArgToReq(req *gorequest.SuperAgent, arg string) (*goreque
 		k, v := strings.TrimSpace(flds[0]), strings.TrimSpace(flds[1])
 		req = req.SetBasicAuth(k, v)
 
+	case "TOKEN", "BEARER":
+		if strings.HasPrefix(val, "@") {
+			val = strings.TrimSpace(ts.ReadFile(val[1:]))
+		}
+		req = req.Set("Authorization", "Bearer "+val)
+
 	case "H", "HEADER":
 		flds := strings.Split(val, ":")
 		k, v := strings.TrimSpace(flds[0]), strings.TrimSpace(flds[1])
@@ -1179,3 +2189,17 @@ func (ts *Script) applyArgToReq(req *gorequest.SuperAgent, arg string) (*goreque
 
 	return req, nil
 }
+
+// gqlResponse decodes the GraphQL envelope from the most recent http call.
+func (ts *Script) gqlResponse() map[string]interface{} {
+	body := ts.stdout
+	if strings.TrimSpace(body) == "" {
+		body = ts.stderr
+	}
+
+	resp := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		ts.Fatalf("gql: response is not a GraphQL envelope: %v", err)
+	}
+	return resp
+}
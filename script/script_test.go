@@ -5,17 +5,30 @@
 package script
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -56,6 +69,36 @@ func signalCatcher() int {
 	return 0
 }
 
+func init() {
+	RegisterCondition("has-arg", func(arg string) (bool, error) {
+		return arg == "yes", nil
+	})
+	RegisterCommands(map[string]Cmd{
+		"greet": greetCmd{},
+	})
+}
+
+// greetCmd is a registered command which greets its one argument.
+type greetCmd struct{}
+
+func (greetCmd) Usage() string { return "greet name" }
+
+func (greetCmd) Help() string { return "Print a greeting for name to stdout." }
+
+func (greetCmd) Check(neg int, args []string) error {
+	if neg != 0 {
+		return errors.New("unsupported: !? greet")
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("greet takes one argument, got %d", len(args))
+	}
+	return nil
+}
+
+func (greetCmd) Run(ts *Script, neg int, args []string) {
+	ts.SetOutput(fmt.Sprintf("hello, %s\n", args[0]), "")
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(RunMain(m, map[string]func() int{
 		"printargs":     printArgs,
@@ -128,6 +171,24 @@ func TestEnv(t *testing.T) {
 	}
 }
 
+func TestSetStatus(t *testing.T) {
+	ts := &Script{
+		env:    []string{"HOME=/no-home"},
+		envMap: map[string]string{envvarname("HOME"): "/no-home"},
+	}
+	for _, status := range []int{3, 0, 200} {
+		ts.setStatus(status)
+	}
+
+	// $STATUS is set after every command, so it is replaced rather than added again
+	if want := []string{"HOME=/no-home", "STATUS=200"}; !reflect.DeepEqual(ts.env, want) {
+		t.Errorf("ts.env == %q, want %q", ts.env, want)
+	}
+	if got, want := ts.Getenv("STATUS"), "200"; got != want {
+		t.Errorf(`ts.Getenv("STATUS") == %q, want %q`, got, want)
+	}
+}
+
 func TestHttp(t *testing.T) {
 	Run(t, Params{
 		Dir: "testhttp",
@@ -173,6 +234,203 @@ func TestScripts(t *testing.T) {
 					ts.Fatalf("test-values t does not implement testing.TB")
 				}
 			},
+			"flaky": func(ts *Script, neg int, args []string) {
+				if len(args) != 2 {
+					ts.Fatalf("flaky <filename> <n>")
+				}
+				// Count the attempts in the file and fail until there are n.
+				n, err := strconv.Atoi(args[1])
+				ts.Check(err)
+				count := ts.ReadFile(args[0]) + "x"
+				ts.Check(ioutil.WriteFile(ts.MkAbs(args[0]), []byte(count), 0666))
+				if len(count) < n {
+					ts.Fatalf("flaky: attempt %d of %d", len(count), n)
+				}
+			},
+			"fakehttp": func(ts *Script, neg int, args []string) {
+				if len(args) != 2 {
+					ts.Fatalf("fakehttp <latency> <size>")
+				}
+				// Record a request as the http command would.
+				latency, err := time.ParseDuration(args[0])
+				ts.Check(err)
+				size, err := strconv.ParseInt(args[1], 10, 64)
+				ts.Check(err)
+				ts.httpStat = &httpStat{latency: latency, size: size}
+			},
+			"fakes3": func(ts *Script, neg int, args []string) {
+				// Serve an in-memory, path style S3 and point s3 at it.
+				var mu sync.Mutex
+				objects := map[string][]byte{}
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+						http.Error(w, "AccessDenied", http.StatusForbidden)
+						return
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case r.Method == "PUT":
+						data, _ := ioutil.ReadAll(r.Body)
+						objects[r.URL.Path] = data
+					case r.Method == "DELETE":
+						delete(objects, r.URL.Path)
+					case r.URL.Query().Get("list-type") == "2":
+						var keys []string
+						prefix := r.URL.Path + "/" + r.URL.Query().Get("prefix")
+						for name := range objects {
+							if strings.HasPrefix(name, prefix) {
+								keys = append(keys, "<Contents><Key>"+strings.TrimPrefix(name, r.URL.Path+"/")+"</Key></Contents>")
+							}
+						}
+						sort.Strings(keys)
+						fmt.Fprintf(w, "<ListBucketResult>%s</ListBucketResult>", strings.Join(keys, ""))
+					default:
+						data, ok := objects[r.URL.Path]
+						if !ok {
+							http.Error(w, "NoSuchKey", http.StatusNotFound)
+							return
+						}
+						w.Write(data)
+					}
+				}))
+				ts.Defer(srv.Close)
+				ts.Setenv("S3_ENDPOINT", srv.URL)
+			},
+			"fakegql": func(ts *Script, neg int, args []string) {
+				// Serve a GraphQL endpoint which knows the sound of one animal,
+				// answering others with an error, and point $GQL_URL at it.
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var req struct {
+						Query     string
+						Variables map[string]string
+					}
+					if r.Method != "POST" || json.NewDecoder(r.Body).Decode(&req) != nil || !strings.Contains(req.Query, "animal") {
+						http.Error(w, "expected a GraphQL envelope", http.StatusBadRequest)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					if sound := req.Variables["sound"]; sound != "moo" {
+						fmt.Fprintf(w, `{"data": {"animal": null}, "errors": [{"message": "no animal says %s", "path": ["animal"]}]}`, sound)
+						return
+					}
+					fmt.Fprint(w, `{"data": {"animal": {"name": "cow", "sounds": ["moo", "low"]}}}`)
+				}))
+				ts.Defer(srv.Close)
+				ts.Setenv("GQL_URL", srv.URL)
+			},
+			"fakeoauth2": func(ts *Script, neg int, args []string) {
+				// Serve an OAuth2 client-credentials token endpoint and an API
+				// which needs its token, and point $OAUTH2_URL at them.
+				var mu sync.Mutex
+				exchanges := 0
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					switch r.URL.Path {
+					case "/token":
+						id, secret, _ := r.BasicAuth()
+						if r.Method != "POST" || id != "hof" || secret != "s3cret" || r.PostFormValue("grant_type") != "client_credentials" {
+							http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+							return
+						}
+						exchanges++
+						fmt.Fprintf(w, `{"access_token": "tok-%s", "token_type": "bearer"}`, r.PostFormValue("scope"))
+					case "/whoami":
+						auth := r.Header.Get("Authorization")
+						if !strings.HasPrefix(auth, "Bearer tok-") {
+							http.Error(w, "missing token", http.StatusUnauthorized)
+							return
+						}
+						fmt.Fprintf(w, "%s exchanges=%d\n", auth, exchanges)
+					default:
+						http.NotFound(w, r)
+					}
+				}))
+				ts.Defer(srv.Close)
+				ts.Setenv("OAUTH2_URL", srv.URL)
+			},
+			"fakenats": func(ts *Script, neg int, args []string) {
+				// Serve a NATS server which delivers every message to
+				// every subscription on the connection, and point nats at it.
+				l, err := net.Listen("tcp", "127.0.0.1:0")
+				ts.Check(err)
+				ts.Defer(func() { l.Close() })
+				go func() {
+					for {
+						conn, err := l.Accept()
+						if err != nil {
+							return
+						}
+						go func() {
+							defer conn.Close()
+							var sids []string
+							fmt.Fprintf(conn, "INFO {}\r\n")
+							r := bufio.NewReader(conn)
+							for {
+								line, err := r.ReadString('\n')
+								if err != nil {
+									return
+								}
+								f := strings.Fields(line)
+								switch {
+								case len(f) == 0:
+								case f[0] == "PING":
+									fmt.Fprintf(conn, "PONG\r\n")
+								case f[0] == "SUB":
+									sids = append(sids, f[2])
+								case f[0] == "PUB":
+									payload, _ := r.ReadString('\n')
+									for _, sid := range sids {
+										fmt.Fprintf(conn, "MSG %s %s %s\r\n%s", f[1], sid, f[2], payload)
+									}
+								}
+							}
+						}()
+					}
+				}()
+				ts.Setenv("NATS_URL", "nats://"+l.Addr().String())
+			},
+			"fakekafka": func(ts *Script, neg int, args []string) {
+				// Serve a Kafka REST Proxy with a single consumer,
+				// which reads each topic from the start.
+				var mu sync.Mutex
+				topics := map[string][]json.RawMessage{}
+				var subscribed []string
+				var srv *httptest.Server
+				srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+					switch {
+					case parts[0] == "topics" && r.Method == "POST":
+						var body struct {
+							Records []struct{ Value json.RawMessage }
+						}
+						json.NewDecoder(r.Body).Decode(&body)
+						for _, rec := range body.Records {
+							topics[parts[1]] = append(topics[parts[1]], rec.Value)
+						}
+					case len(parts) == 2 && r.Method == "POST":
+						fmt.Fprintf(w, `{"base_uri": %q}`, srv.URL+"/consumers/g/instances/c")
+					case parts[len(parts)-1] == "subscription":
+						var body struct{ Topics []string }
+						json.NewDecoder(r.Body).Decode(&body)
+						subscribed = body.Topics
+					case parts[len(parts)-1] == "records":
+						var records []map[string]json.RawMessage
+						for _, topic := range subscribed {
+							for _, v := range topics[topic] {
+								records = append(records, map[string]json.RawMessage{"value": v})
+							}
+							topics[topic] = nil
+						}
+						json.NewEncoder(w).Encode(records)
+					}
+				}))
+				ts.Defer(srv.Close)
+				ts.Setenv("KAFKA_REST_URL", srv.URL)
+			},
 			"testreadfile": func(ts *Script, neg int, args []string) {
 				if len(args) != 1 {
 					ts.Fatalf("testreadfile <filename>")
@@ -214,6 +472,33 @@ func TestScripts(t *testing.T) {
 				}
 			},
 		},
+		Funcs: map[string]func(ts *Script, args []string) error{
+			"upper": func(ts *Script, args []string) error {
+				data, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				fmt.Print(strings.ToUpper(string(data)))
+				return nil
+			},
+			"exitcode": func(ts *Script, args []string) error {
+				code, err := strconv.Atoi(args[0])
+				if err != nil || code == 0 {
+					return err
+				}
+				fmt.Fprintln(os.Stderr, "exiting with", code)
+				return &StatusError{Status: code}
+			},
+			// flaky as a function, which runs off the script goroutine
+			"flaky": func(ts *Script, args []string) error {
+				count := ts.ReadFile(args[0]) + "x"
+				ts.Check(ioutil.WriteFile(ts.MkAbs(args[0]), []byte(count), 0666))
+				if len(count) < 3 {
+					ts.Fatalf("flaky: attempt %d of 3", len(count))
+				}
+				return nil
+			},
+		},
 		Setup: func(env *Env) error {
 			infos, err := ioutil.ReadDir(env.WorkDir)
 			if err != nil {
@@ -238,6 +523,13 @@ func TestScripts(t *testing.T) {
 	// TODO check that the temp directory has been removed.
 }
 
+func BenchmarkScripts(b *testing.B) {
+	RunBenchmark(b, Params{
+		Dir:  "testdata",
+		Glob: "hello.txt",
+	})
+}
+
 // TestTestwork tests that using the flag -testwork will make sure the work dir isn't removed
 // after the test is done. It uses an empty testscript file that doesn't do anything.
 func TestTestwork(t *testing.T) {
@@ -318,6 +610,663 @@ func TestBadDir(t *testing.T) {
 	}
 }
 
+func TestSelectedByTags(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	file := filepath.Join(td, "tagged.txt")
+	contents := []byte("~ tags: slow, network\nexec true\n-- a.txt --\n~ tags: ignored\n")
+	if err := ioutil.WriteFile(file, contents, 0644); err != nil {
+		t.Fatalf("failed to write to %v: %v", file, err)
+	}
+
+	for _, tt := range []struct {
+		tags []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"slow"}, true},
+		{[]string{"postgres", "network"}, true},
+		{[]string{"postgres"}, false},
+		{[]string{"ignored"}, false},
+		{[]string{"!slow"}, false},
+		{[]string{"!postgres"}, true},
+		{[]string{"network", "!slow"}, false},
+	} {
+		got, err := selectedByTags(file, paramDefaults(Params{Tags: tt.tags}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("selectedByTags with %q = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	if err := ioutil.WriteFile(filepath.Join(td, "greet.txt"), []byte("greet a b\n"), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	rt := &recordT{}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(rt, Params{
+			Dir:  td,
+			Glob: "*.txt",
+		})
+	}()
+	log := strings.Join(rt.logs, "\n")
+	if !strings.Contains(log, "greet takes one argument, got 2\nusage: greet name") {
+		t.Fatalf("expected argument check failure with usage, got log:\n%s", log)
+	}
+}
+
+func TestMask(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	// The secret only exists once the script builds it, and is never spelled out.
+	contents := []byte("env OTHER=hunter${N}\nmask $OTHER\necho stdout token=$OTHER setup=$SECRET\nstdout 'token=hunter'\n")
+	if err := ioutil.WriteFile(filepath.Join(td, "mask.txt"), contents, 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	rt := &recordT{}
+	RunT(rt, Params{
+		Dir:  td,
+		Glob: "*.txt",
+		Setup: func(env *Env) error {
+			env.Setenv("N", "2")
+			env.Setenv("SECRET", "swordfish")
+			env.Mask("swordfish")
+			return nil
+		},
+	})
+	log := strings.Join(rt.logs, "\n")
+	for _, secret := range []string{"hunter2", "swordfish"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("secret %q found in log:\n%s", secret, log)
+		}
+	}
+	if !strings.Contains(log, "token=*** setup=***") {
+		t.Errorf("masked secrets not found in log:\n%s", log)
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir:         "testdata",
+			Glob:        "hello.txt",
+			EventWriter: &buf,
+		})
+	})
+
+	var events []Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) == 0 {
+		t.Fatal("no events written")
+	}
+	for _, ev := range events {
+		if ev.Script != "hello" || ev.Line == 0 || len(ev.Args) == 0 || ev.Result != "pass" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	}
+}
+
+func TestSkipReport(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	// recordT runs scripts in order and its Skip aborts the run,
+	// so the skipping script must come last.
+	scripts := map[string]string{
+		"a-pass.txt": "env X=1\n",
+		"b-skip.txt": "env X=1\nskip 'needs docker' https://example.com/issues/1\n",
+	}
+	for name, contents := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(td, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	rt := &recordT{}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(rt, Params{
+			Dir:         td,
+			Glob:        "*.txt",
+			EventWriter: &buf,
+		})
+	}()
+
+	log := strings.Join(rt.logs, "\n")
+	want := "skipped 1 of 2 scripts:\n\tb-skip:2: needs docker (https://example.com/issues/1)\n"
+	if !strings.Contains(log, want) {
+		t.Fatalf("expected skip summary %q, got log:\n%s", want, log)
+	}
+	if !strings.Contains(buf.String(), `"result":"skip","reason":"needs docker","issue":"https://example.com/issues/1"`) {
+		t.Fatalf("expected skip event with reason and issue, got:\n%s", buf.String())
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "items": {"$ref": "#/definitions/tag"}, "uniqueItems": true}
+		},
+		"additionalProperties": false,
+		"definitions": {"tag": {"type": "string", "pattern": "^[a-z]+$"}}
+	}`
+	doc := `{"id": 0, "role": "root", "tags": ["Go", "go", "go"], "extra": true}`
+	got, err := validateJSONSchema(schema, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`$: missing required property "name"`,
+		`$.extra: additional property not allowed`,
+		`$.id: 0 is less than minimum 1`,
+		`$.role: "root" is not one of ["admin","user"]`,
+		`$.tags: items 1 and 2 are equal`,
+		`$.tags[0]: "Go" does not match pattern "^[a-z]+$"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got, err := validateJSONSchema(schema, `{"id": 2, "name": "x", "tags": ["go"]}`); err != nil || len(got) != 0 {
+		t.Fatalf("expected valid document, got %q, %v", got, err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	script := `# lint finds problems without running anything
+exec false
+[unknowncond] exec true
+[linux]
+! 
+ecco hello
+greet
+stdin missing.txt
+cmp stdout want.txt
+cd sub
+cmp stdout want.txt
+exists 'unterminated
+include missing.txt
+-- want.txt --
+-- sub/want.txt --
+`
+	if err := ioutil.WriteFile(filepath.Join(td, "bad.txt"), []byte(script), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	problems, err := Lint(Params{Dir: td, Glob: "*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range problems {
+		got = append(got, strings.TrimPrefix(e.Error(), td+string(filepath.Separator)))
+	}
+	want := []string{
+		`bad.txt:3: unknown condition "unknowncond"`,
+		`bad.txt:4: missing command after condition`,
+		`bad.txt:5: ! on line by itself`,
+		`bad.txt:6: unknown command "ecco"`,
+		"bad.txt:7: greet takes one argument, got 0\nusage: greet name",
+		`bad.txt:8: stdin: missing.txt is not in the archive`,
+		`bad.txt:12: unterminated quoted argument`,
+	}
+	if !reflect.DeepEqual(got[:len(got)-1], want) {
+		t.Fatalf("got problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if last := got[len(got)-1]; !strings.Contains(last, "missing.txt") {
+		t.Fatalf("expected unreadable include, got %s", last)
+	}
+
+	// With DryRun, RunT fails the script with the problems instead of running it.
+	rt := &recordT{}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(rt, Params{Dir: td, Glob: "*.txt", DryRun: true})
+	}()
+	if len(rt.failMsgs) == 0 || !strings.Contains(rt.failMsgs[0], `unknown command "ecco"`) {
+		t.Fatalf("expected dry run to fail with the problems, got %q", rt.failMsgs)
+	}
+
+	// The scripts in testdata are all well formed.
+	cmds := make(map[string]func(ts *Script, neg int, args []string))
+	for _, name := range []string{"setSpecialVal", "ensureSpecialVal", "interrupt", "waitfile", "testdefer",
+		"setup-filenames", "test-values", "testreadfile", "testscript-update", "flaky", "fakehttp", "fakes3", "fakegql", "fakeoauth2", "fakenats", "fakekafka"} {
+		cmds[name] = nil
+	}
+	problems, err = Lint(Params{Dir: "testdata", Glob: "*.txt", Cmds: cmds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range problems {
+		t.Errorf("unexpected problem: %v", e)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs echo and sleep")
+	}
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	script := "exec echo changed\ncmp stdout want.txt\nexec sleep 10\n-- want.txt --\noriginal\n"
+	file := filepath.Join(td, "stopped.txt")
+	if err := ioutil.WriteFile(file, []byte(script), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	rt := &recordT{}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(rt, Params{
+			Dir:           td,
+			Glob:          "*.txt",
+			Context:       ctx,
+			UpdateScripts: true,
+		})
+	}()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("script was not stopped, took %v", elapsed)
+	}
+	if log := strings.Join(rt.logs, "\n"); !strings.Contains(log, "script stopped while running command: context deadline exceeded") {
+		t.Fatalf("expected partial log of stopped script, got:\n%s", log)
+	}
+	if data, err := ioutil.ReadFile(file); err != nil || string(data) != script {
+		t.Fatalf("stopped script was updated: %q, %v", data, err)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	scripts := map[string]string{
+		"1-init.txt":  "exists init.txt\n-- init.txt --\n",
+		"2-add.txt":   "~ carry\nexists init.txt\ncp init.txt added.txt\n",
+		"3-check.txt": "~ carry\nexists init.txt added.txt\n",
+		"4-fresh.txt": "! exists init.txt\n",
+	}
+	for name, contents := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(td, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+	}
+
+	var order []string
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir:      td,
+			Glob:     "*.txt",
+			Sequence: true,
+			Setup: func(env *Env) error {
+				order = append(order, filepath.Base(env.WorkDir))
+				return nil
+			},
+		})
+	})
+	want := []string{"script-1-init", "script-1-init", "script-1-init", "script-4-fresh"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("got work dirs %q want %q", order, want)
+	}
+
+	// Without Sequence, carry is an error.
+	rt := &recordT{}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(rt, Params{
+			Dir:  td,
+			Glob: "*.txt",
+		})
+	}()
+	if len(rt.failMsgs) == 0 || !strings.Contains(rt.failMsgs[0], "carry requires Params.Sequence") {
+		t.Fatalf("expected carry error, got %q", rt.failMsgs)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+
+	// Enough files to be written concurrently, with a later file
+	// replacing an earlier one of the same name.
+	const n = 200
+	var buf bytes.Buffer
+	buf.WriteString("cmp dup.txt want.txt\n")
+	buf.WriteString("-- dup.txt --\nfirst\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "-- d%d/f%d.txt --\n%d\n", i%7, i, i)
+	}
+	buf.WriteString("-- dup.txt --\nlast\n-- want.txt --\nlast\n")
+	if err := ioutil.WriteFile(filepath.Join(td, "many.hls"), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir: td,
+			Setup: func(env *Env) error {
+				for i := 0; i < n; i++ {
+					name := filepath.Join(env.WorkDir, fmt.Sprintf("d%d", i%7), fmt.Sprintf("f%d.txt", i))
+					data, err := ioutil.ReadFile(name)
+					if err != nil {
+						return err
+					}
+					if got, want := string(data), fmt.Sprintf("%d\n", i); got != want {
+						return fmt.Errorf("%s: got %q want %q", name, got, want)
+					}
+				}
+				return nil
+			},
+		})
+	})
+}
+
+func TestTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tool is a shell script")
+	}
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(zw)
+	greeter := []byte("#!/bin/sh\necho hello from greeter\n")
+	tw.WriteHeader(&tar.Header{Name: "greeter-1.0/bin/greeter", Mode: 0755, Size: int64(len(greeter))})
+	tw.Write(greeter)
+	tw.Close()
+	zw.Close()
+
+	var downloads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		if r.URL.Path != "/greeter-1.0-"+runtime.GOOS+".tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive.Bytes())
+	}))
+	defer srv.Close()
+
+	scripts := filepath.Join(td, "scripts")
+	os.Mkdir(scripts, 0777)
+	contents := "~ tools: greeter\nexec greeter\nstdout 'hello from greeter'\n"
+	if err := ioutil.WriteFile(filepath.Join(scripts, "greeter.txt"), []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	p := Params{
+		Dir:  scripts,
+		Glob: "*.txt",
+		Tools: []Tool{{
+			Name:    "greeter",
+			Version: "1.0",
+			URL:     srv.URL + "/greeter-$VERSION-$GOOS.tar.gz",
+			Path:    "greeter-$VERSION/bin/greeter",
+		}},
+		ToolCache: filepath.Join(td, "cache"),
+	}
+	for i := 0; i < 2; i++ {
+		t.Run("run", func(t *testing.T) {
+			Run(t, p)
+		})
+	}
+	if downloads != 1 {
+		t.Errorf("tool downloaded %d times, want once", downloads)
+	}
+
+	p.Tools = nil
+	if err := checkTools(filepath.Join(scripts, "greeter.txt"), paramDefaults(p)); err == nil {
+		t.Errorf("expected error for script requiring a tool not in Params.Tools")
+	}
+}
+
+func TestDenyNetwork(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	contents := `[network] exists network-allowed
+! http GET https://example.com/
+exec sh -c 'echo $HTTPS_PROXY'
+stdout '^http://127.0.0.1:'
+[exec:curl] ! exec curl -sf http://example.com/
+`
+	if err := ioutil.WriteFile(filepath.Join(td, "deny.txt"), []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir:         td,
+			Glob:        "*.txt",
+			DenyNetwork: true,
+		})
+	})
+}
+
+func TestMaxParallel(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	for i := 0; i < 6; i++ {
+		file := filepath.Join(td, fmt.Sprintf("script%d.txt", i))
+		if err := ioutil.WriteFile(file, []byte("env X=1\n"), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+	}
+
+	var running, max int32
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir:         td,
+			Glob:        "*.txt",
+			MaxParallel: 2,
+			Setup: func(env *Env) error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+				env.Defer(func() { atomic.AddInt32(&running, -1) })
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			},
+		})
+	})
+	if max > 2 {
+		t.Errorf("%d scripts ran at once, want at most 2", max)
+	}
+}
+
+func TestSuiteSetup(t *testing.T) {
+	td, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(td)
+	for i := 0; i < 3; i++ {
+		file := filepath.Join(td, fmt.Sprintf("script%d.txt", i))
+		if err := ioutil.WriteFile(file, []byte("suite-value\nexists $SUITE/shared.txt\n"), 0644); err != nil {
+			t.Fatalf("failed to write script: %v", err)
+		}
+	}
+
+	setups, teardowns := 0, 0
+	t.Run("run", func(t *testing.T) {
+		Run(t, Params{
+			Dir:  td,
+			Glob: "*.txt",
+			SuiteSetup: func(env *Env) error {
+				setups++
+				env.Setenv("SUITE", env.WorkDir)
+				env.Values["shared"] = "yes"
+				return ioutil.WriteFile(filepath.Join(env.WorkDir, "shared.txt"), nil, 0666)
+			},
+			SuiteTeardown: func() {
+				teardowns++
+			},
+			Cmds: map[string]func(ts *Script, neg int, args []string){
+				"suite-value": func(ts *Script, neg int, args []string) {
+					if ts.Value("shared") != "yes" {
+						ts.Fatalf("suite value not shared")
+					}
+				},
+			},
+		})
+	})
+	if setups != 1 || teardowns != 1 {
+		t.Errorf("got %d setups and %d teardowns, want 1 of each", setups, teardowns)
+	}
+
+	// a failure after the suite is set up still tears it down,
+	// with Cleanup when T has it, or else when RunT returns
+	failing := Params{
+		Dir:  td,
+		Glob: "*.txt",
+		SuiteSetup: func(env *Env) error {
+			setups++
+			return nil
+		},
+		SuiteTeardown: func() {
+			teardowns++
+		},
+		Tools:     []Tool{{Name: "missing", Version: "1.0", URL: "http://127.0.0.1:1/missing"}},
+		ToolCache: filepath.Join(td, "cache"),
+	}
+	for _, ft := range []T{&fakeT{}, &cleanupT{}} {
+		setups, teardowns = 0, 0
+		func() {
+			defer func() {
+				if err := recover(); err != errAbort {
+					t.Fatalf("expected the tools to fail, got %v", err)
+				}
+			}()
+			RunT(ft, failing)
+		}()
+		if ct, ok := ft.(*cleanupT); ok {
+			if teardowns != 0 {
+				t.Errorf("expected the teardown left to Cleanup")
+			}
+			ct.cleanup()
+		}
+		if setups != 1 || teardowns != 1 {
+			t.Errorf("%T: got %d setups and %d teardowns, want 1 of each", ft, setups, teardowns)
+		}
+	}
+}
+
+// cleanupT is a T which runs its cleanups when told to, as *testing.T does when the test ends.
+type cleanupT struct {
+	fakeT
+	cleanups []func()
+}
+
+func (t *cleanupT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *cleanupT) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+// recordT is a verbose T which records the log.
+type recordT struct {
+	fakeT
+	logs []string
+}
+
+func (t *recordT) Log(args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func (t *recordT) Run(name string, f func(T)) {
+	f(t)
+}
+
+func (t *recordT) Verbose() bool {
+	return true
+}
+
 func setSpecialVal(ts *Script, neg int, args []string) {
 	ts.Setenv("SPECIALVAL", "42")
 }
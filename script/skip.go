@@ -0,0 +1,71 @@
+package script
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scriptSkip records why a script was skipped by the skip command.
+type scriptSkip struct {
+	name   string
+	line   int
+	reason string
+	issue  string // optional issue URL or reference tracking the skip
+}
+
+func (s scriptSkip) String() string {
+	msg := s.reason
+	if msg == "" {
+		msg = "skipped"
+	}
+	if s.issue != "" {
+		msg += " (" + s.issue + ")"
+	}
+	return msg
+}
+
+// skipReport collects the skips of scripts running in parallel,
+// to be summarized once they have all finished.
+type skipReport struct {
+	mu    sync.Mutex
+	skips []scriptSkip
+}
+
+func (r *skipReport) add(s scriptSkip) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skips = append(r.skips, s)
+}
+
+// log writes a summary of the skipped scripts, if any, to t.
+func (r *skipReport) log(t T, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.skips) == 0 {
+		return
+	}
+	sort.Slice(r.skips, func(i, j int) bool { return r.skips[i].name < r.skips[j].name })
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "skipped %d of %d scripts:\n", len(r.skips), total)
+	for _, s := range r.skips {
+		fmt.Fprintf(&buf, "\t%s:%d: %s\n", s.name, s.line, s)
+	}
+	t.Log(buf.String())
+}
+
+// skipArgs parses the [reason [issue]] arguments of skip and stop.
+func (ts *Script) skipArgs(cmd string, args []string) scriptSkip {
+	if len(args) > 2 {
+		ts.Fatalf("usage: %s [reason [issue-url]]", cmd)
+	}
+	s := scriptSkip{name: ts.name, line: ts.lineno}
+	if len(args) > 0 {
+		s.reason = args[0]
+	}
+	if len(args) > 1 {
+		s.issue = args[1]
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/naoina/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/hofstadter-io/hof/lib/gotils/intern/textutil"
+)
+
+// yamlcmp compares two YAML files structurally.
+func (ts *Script) cmdYamlcmp(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? yamlcmp")
+	}
+	if len(args) != 2 {
+		ts.Fatalf("usage: yamlcmp file1 file2")
+	}
+	ts.doStructCmp(args, "yaml", func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := yaml.Unmarshal(data, &v)
+		return v, err
+	})
+}
+
+// tomlcmp compares two TOML files structurally.
+func (ts *Script) cmdTomlcmp(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? tomlcmp")
+	}
+	if len(args) != 2 {
+		ts.Fatalf("usage: tomlcmp file1 file2")
+	}
+	ts.doStructCmp(args, "toml", func(data []byte) (interface{}, error) {
+		v := map[string]interface{}{}
+		err := toml.Unmarshal(data, &v)
+		return v, err
+	})
+}
+
+// doStructCmp decodes both files and compares their canonical JSON forms,
+// so formatting and key order do not matter.
+func (ts *Script) doStructCmp(args []string, format string, decode func([]byte) (interface{}, error)) {
+	name1, name2 := args[0], args[1]
+
+	canon := func(name string) string {
+		v, err := decode([]byte(ts.ReadFile(name)))
+		if err != nil {
+			ts.Fatalf("%s: bad %s: %v", name, format, err)
+		}
+		b, err := json.MarshalIndent(normalizeStruct(v), "", "  ")
+		if err != nil {
+			ts.Fatalf("%s: %v", name, err)
+		}
+		return string(b) + "\n"
+	}
+
+	text1, text2 := canon(name1), canon(name2)
+	if text1 == text2 {
+		return
+	}
+
+	ts.Logf("[diff]\n%s", textutil.UnifiedDiff(name1, name2, text1, text2, 3))
+	ts.Fatalf("%s and %s differ", name1, name2)
+}
+
+// normalizeStruct converts maps with non-string keys, as produced by
+// some decoders, into map[string]interface{} so they can be JSON encoded.
+func normalizeStruct(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeStruct(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = normalizeStruct(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, val := range t {
+			l[i] = normalizeStruct(val)
+		}
+		return l
+	default:
+		return v
+	}
+}
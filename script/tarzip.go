@@ -0,0 +1,261 @@
+package script
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tar creates a tar archive of the named files and directories.
+func (ts *Script) cmdTar(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? tar")
+	}
+	gz := false
+	if len(args) > 0 && args[0] == "-z" {
+		gz, args = true, args[1:]
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: tar [-z] archive path...")
+	}
+	name := args[0]
+	if strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz = true
+	}
+
+	f, err := os.Create(ts.MkAbs(name))
+	ts.Check(err)
+	defer f.Close()
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if gz {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	ts.walkArchivePaths(args[0], args[1:], func(path, rel string, info os.FileInfo) error {
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	ts.Check(tw.Close())
+	if zw != nil {
+		ts.Check(zw.Close())
+	}
+	ts.Check(f.Close())
+}
+
+// untar extracts a tar archive, gzipped or not, into a directory.
+func (ts *Script) cmdUntar(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? untar")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: untar archive [dir]")
+	}
+	dir := ts.cd
+	if len(args) == 2 {
+		dir = ts.MkAbs(args[1])
+	}
+
+	f, err := os.Open(ts.MkAbs(args[0]))
+	ts.Check(err)
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		ts.Check(err)
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			ts.Fatalf("untar %s: %v", args[0], err)
+		}
+		target := ts.archiveTarget(args[0], dir, hdr.Name)
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			ts.Check(os.MkdirAll(target, 0777))
+		case tar.TypeSymlink:
+			ts.Check(os.MkdirAll(filepath.Dir(target), 0777))
+			os.Remove(target)
+			ts.Check(os.Symlink(hdr.Linkname, target))
+		case tar.TypeReg:
+			ts.Check(writeFileFrom(target, tr, mode))
+		default:
+			ts.Logf("untar: skipping %s, unsupported type %q", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+// zip creates a zip archive of the named files and directories.
+func (ts *Script) cmdZip(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? zip")
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: zip archive path...")
+	}
+
+	f, err := os.Create(ts.MkAbs(args[0]))
+	ts.Check(err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	ts.walkArchivePaths(args[0], args[1:], func(path, rel string, info os.FileInfo) error {
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s: symlinks are not supported in zip archives", rel)
+		}
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if info.IsDir() {
+			hdr.Name += "/"
+		} else {
+			hdr.Method = zip.Deflate
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return copyFileTo(w, path)
+	})
+	ts.Check(zw.Close())
+	ts.Check(f.Close())
+}
+
+// unzip extracts a zip archive into a directory.
+func (ts *Script) cmdUnzip(neg int, args []string) {
+	if neg != 0 {
+		ts.Fatalf("unsupported: !? unzip")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: unzip archive [dir]")
+	}
+	dir := ts.cd
+	if len(args) == 2 {
+		dir = ts.MkAbs(args[1])
+	}
+
+	zr, err := zip.OpenReader(ts.MkAbs(args[0]))
+	if err != nil {
+		ts.Fatalf("unzip %s: %v", args[0], err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		target := ts.archiveTarget(args[0], dir, zf.Name)
+		if zf.FileInfo().IsDir() {
+			ts.Check(os.MkdirAll(target, 0777))
+			continue
+		}
+		r, err := zf.Open()
+		ts.Check(err)
+		err = writeFileFrom(target, r, zf.Mode().Perm())
+		r.Close()
+		ts.Check(err)
+	}
+}
+
+// walkArchivePaths calls fn for each of the files and directories under
+// paths, other than the archive being written, with its name relative to the
+// current directory, using forward slashes, so that archives are the same
+// on every OS.
+func (ts *Script) walkArchivePaths(archive string, paths []string, fn func(path, rel string, info os.FileInfo) error) {
+	archive = ts.MkAbs(archive)
+	for _, p := range paths {
+		err := filepath.Walk(ts.MkAbs(p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(ts.cd, path)
+			if err != nil {
+				return err
+			}
+			if rel == "." || path == archive {
+				return nil
+			}
+			if strings.HasPrefix(rel, "..") {
+				return fmt.Errorf("%s is outside of the current directory", p)
+			}
+			return fn(path, filepath.ToSlash(rel), info)
+		})
+		ts.Check(err)
+	}
+}
+
+// archiveTarget returns where the archive entry name is extracted to in dir,
+// failing if it would be outside of dir.
+func (ts *Script) archiveTarget(archive, dir, name string) string {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if target != dir && !strings.HasPrefix(target, dir+string(filepath.Separator)) {
+		ts.Fatalf("%s: entry %s is outside of the extraction directory", archive, name)
+	}
+	return target
+}
+
+// copyFileTo copies the contents of the named file to w.
+func copyFileTo(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// writeFileFrom writes the contents of r to name with the given permissions,
+// creating its directory if needed.
+func writeFileFrom(name string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	if perm == 0 {
+		perm = 0666
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chmod(name, perm)
+}
@@ -0,0 +1,224 @@
+package script
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/hofstadter-io/hof/lib/gotils/txtar"
+)
+
+// A Tool is a pinned version of an external program which is downloaded
+// once into the tool cache and made available to scripts on their PATH.
+type Tool struct {
+	// Name is the name of the program, as run by scripts.
+	Name string
+
+	// Version is the pinned version, which is part of the cache key.
+	Version string
+
+	// URL is where the tool is downloaded from. It may refer to
+	// $VERSION, $GOOS, and $GOARCH. URLs ending in .tar.gz, .tgz,
+	// or .zip are archives, other URLs are the program itself.
+	URL string
+
+	// Path is the path of the program within an archive.
+	// It defaults to Name, and may refer to the same variables as URL.
+	Path string
+
+	// SHA256 is the expected hex checksum of the download, if set.
+	SHA256 string
+}
+
+// toolsMu serializes provisioning, so that each tool is downloaded once
+// even when several suites share the cache.
+var toolsMu sync.Mutex
+
+// provisionTools makes sure each of the tools is in the cache,
+// downloading it if necessary, and returns the directories holding them.
+func provisionTools(p Params) ([]string, error) {
+	if len(p.Tools) == 0 {
+		return nil, nil
+	}
+	cache := p.ToolCache
+	if cache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("tool cache: %v", err)
+		}
+		cache = filepath.Join(dir, "hof", "script-tools")
+	}
+
+	toolsMu.Lock()
+	defer toolsMu.Unlock()
+	var dirs []string
+	for _, tool := range p.Tools {
+		dir := filepath.Join(cache, tool.Name, tool.Version, runtime.GOOS+"_"+runtime.GOARCH)
+		if err := tool.fetch(dir); err != nil {
+			return nil, fmt.Errorf("tool %s@%s: %v", tool.Name, tool.Version, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// expand replaces the tool variables in s.
+func (tool Tool) expand(s string) string {
+	return os.Expand(s, func(key string) string {
+		switch key {
+		case "VERSION":
+			return tool.Version
+		case "GOOS":
+			return runtime.GOOS
+		case "GOARCH":
+			return runtime.GOARCH
+		}
+		return ""
+	})
+}
+
+// fetch downloads the tool into dir, unless it is already there.
+func (tool Tool) fetch(dir string) error {
+	exe := filepath.Join(dir, tool.Name)
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	if _, err := os.Stat(exe); err == nil {
+		return nil
+	}
+
+	url := tool.expand(tool.URL)
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if tool.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, tool.SHA256) {
+			return fmt.Errorf("downloading %s: checksum %s, want %s", url, got, tool.SHA256)
+		}
+	}
+
+	name := tool.Path
+	if name == "" {
+		name = tool.Name
+	}
+	name = tool.expand(name)
+	switch {
+	case strings.HasSuffix(url, ".tar.gz"), strings.HasSuffix(url, ".tgz"):
+		data, err = fromTarGz(data, name)
+	case strings.HasSuffix(url, ".zip"):
+		data, err = fromZip(data, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted
+	// download never leaves a broken tool in the cache.
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, tool.Name+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), exe)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func fromTarGz(data []byte, name string) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Clean(hdr.Name) == name {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+func fromZip(data []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if path.Clean(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// checkTools reports an error if the script in file declares, with a
+// "~ tools: cue, kubectl" directive, tools which are not in Params.Tools.
+func checkTools(file string, p Params) error {
+	comment, err := txtar.ReadComment(file)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool)
+	for _, tool := range p.Tools {
+		have[tool.Name] = true
+	}
+	for _, line := range scriptDirectives(comment, p.CommentPrefix) {
+		if !strings.HasPrefix(line, "tools:") {
+			continue
+		}
+		for _, name := range strings.Split(strings.TrimPrefix(line, "tools:"), ",") {
+			if name = strings.TrimSpace(name); name != "" && !have[name] {
+				return fmt.Errorf("script requires tool %q, which is not in Params.Tools", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package script
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	waitforDefaultTimeout = 10 * time.Second
+	waitforPollInterval   = 50 * time.Millisecond
+)
+
+// waitforArgs splits a trailing duration off of args, returning the default if not present.
+func waitforArgs(args []string) ([]string, time.Duration) {
+	if len(args) > 0 {
+		if d, err := time.ParseDuration(args[len(args)-1]); err == nil {
+			return args[:len(args)-1], d
+		}
+	}
+	return args, waitforDefaultTimeout
+}
+
+// waitforCheck builds the readiness check for a waitfor target.
+// It returns a description of what is being waited on for messages.
+func (ts *Script) waitforCheck(args []string) (func() bool, string) {
+	target := args[0]
+	switch {
+	case strings.HasPrefix(target, "tcp:"):
+		if len(args) != 1 {
+			ts.Fatalf("usage: waitfor tcp:host:port [timeout]")
+		}
+		addr := strings.TrimPrefix(target, "tcp:")
+		return func() bool {
+			conn, err := net.DialTimeout("tcp", addr, waitforPollInterval)
+			if err != nil {
+				return false
+			}
+			conn.Close()
+			return true
+		}, target
+
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		if len(args) > 2 {
+			ts.Fatalf("usage: waitfor url [code] [timeout]")
+		}
+		code := 0
+		if len(args) == 2 {
+			var err error
+			code, err = strconv.Atoi(args[1])
+			if err != nil {
+				ts.Fatalf("bad status code %q: %v", args[1], err)
+			}
+		}
+		client := &http.Client{Timeout: time.Second}
+		return func() bool {
+			resp, err := client.Get(target)
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			if code != 0 {
+				return resp.StatusCode == code
+			}
+			return resp.StatusCode < 500
+		}, target
+
+	case target == "log":
+		if len(args) != 3 {
+			ts.Fatalf("usage: waitfor log file pattern [timeout]")
+		}
+		file := ts.MkAbs(args[1])
+		re, err := regexp.Compile(`(?m)` + args[2])
+		ts.Check(err)
+		return func() bool {
+			data, err := ioutil.ReadFile(file)
+			return err == nil && re.Match(data)
+		}, "log " + args[1] + " " + args[2]
+
+	default:
+		ts.Fatalf("usage: waitfor [tcp:host:port | url [code] | log file pattern] [timeout]")
+		panic("unreachable")
+	}
+}